@@ -0,0 +1,324 @@
+package config
+
+import (
+	"Techiebulter/interview/backend/models"
+	"time"
+)
+
+// Config is the effective configuration of the service.
+// Values are layered in order of precedence: defaults, an optional YAML/TOML file,
+// environment variables and finally command line flags.
+type Config struct {
+	Server      ServerConfig      `yaml:"server" toml:"server"`
+	GRPC        GRPCConfig        `yaml:"grpc" toml:"grpc"`
+	GraphQL     GraphQLConfig     `yaml:"graphql" toml:"graphql"`
+	Events      EventsConfig      `yaml:"events" toml:"events"`
+	Webhooks    WebhooksConfig    `yaml:"webhooks" toml:"webhooks"`
+	Outbox      OutboxConfig      `yaml:"outbox" toml:"outbox"`
+	Overtime    OvertimeConfig    `yaml:"overtime" toml:"overtime"`
+	Payroll     PayrollConfig     `yaml:"payroll" toml:"payroll"`
+	Currency    CurrencyConfig    `yaml:"currency" toml:"currency"`
+	Reports     ReportsConfig     `yaml:"reports" toml:"reports"`
+	Branding    BrandingConfig    `yaml:"branding" toml:"branding"`
+	Database    DatabaseConfig    `yaml:"database" toml:"database"`
+	Log         LogConfig         `yaml:"log" toml:"log"`
+	CORS        CORSConfig        `yaml:"cors" toml:"cors"`
+	Auth        AuthConfig        `yaml:"auth" toml:"auth"`
+	Idempotency IdempotencyConfig `yaml:"idempotency" toml:"idempotency"`
+	Features    FeatureConfig     `yaml:"features" toml:"features"`
+
+	// sources records where each setting was last set from, keyed like "server.port"
+	sources map[string]string
+}
+
+// ServerConfig holds the HTTP listener settings.
+type ServerConfig struct {
+	Port            string        `yaml:"port" toml:"port"`
+	ReadTimeout     time.Duration `yaml:"readTimeout" toml:"readTimeout"`
+	WriteTimeout    time.Duration `yaml:"writeTimeout" toml:"writeTimeout"`
+	IdleTimeout     time.Duration `yaml:"idleTimeout" toml:"idleTimeout"`
+	ShutdownTimeout time.Duration `yaml:"shutdownTimeout" toml:"shutdownTimeout"`
+}
+
+// GRPCConfig holds the gRPC listener settings, it serves next to the HTTP listener.
+type GRPCConfig struct {
+	Enabled bool   `yaml:"enabled" toml:"enabled"`
+	Port    string `yaml:"port" toml:"port"`
+	// Reflection lets tools like grpcurl discover the services
+	Reflection bool `yaml:"reflection" toml:"reflection"`
+}
+
+// GraphQLConfig holds the /graphql endpoint settings. Queries nested deeper than MaxDepth or
+// estimated to resolve more than MaxComplexity fields are rejected before they run.
+type GraphQLConfig struct {
+	Enabled       bool `yaml:"enabled" toml:"enabled"`
+	MaxDepth      int  `yaml:"maxDepth" toml:"maxDepth"`
+	MaxComplexity int  `yaml:"maxComplexity" toml:"maxComplexity"`
+}
+
+// EventsConfig holds the employee change stream settings. Idle streams are sent a heartbeat every
+// Heartbeat, so proxies keep them open and disconnected clients are noticed.
+type EventsConfig struct {
+	Heartbeat time.Duration `yaml:"heartbeat" toml:"heartbeat"`
+}
+
+// WebhooksConfig controls the delivery of webhooks. Failed deliveries are retried after BackoffInitial,
+// doubling up to BackoffMax, and dead-lettered after MaxAttempts. Due deliveries are looked for every
+// PollInterval and right after a change.
+type WebhooksConfig struct {
+	MaxAttempts    int           `yaml:"maxAttempts" toml:"maxAttempts"`
+	BackoffInitial time.Duration `yaml:"backoffInitial" toml:"backoffInitial"`
+	BackoffMax     time.Duration `yaml:"backoffMax" toml:"backoffMax"`
+	Timeout        time.Duration `yaml:"timeout" toml:"timeout"`
+	PollInterval   time.Duration `yaml:"pollInterval" toml:"pollInterval"`
+}
+
+// OutboxConfig controls the relay publishing logged employee events to the Sinks, named from
+// OutboxSinks. The file sink appends to File. Up to BatchSize due messages are published every
+// PollInterval and right after a change, failed ones are retried after BackoffInitial, doubling up to
+// BackoffMax, and a sink may take PublishTimeout to accept an event.
+type OutboxConfig struct {
+	Sinks          []string      `yaml:"sinks" toml:"sinks"`
+	File           string        `yaml:"file" toml:"file"`
+	BatchSize      int           `yaml:"batchSize" toml:"batchSize"`
+	PollInterval   time.Duration `yaml:"pollInterval" toml:"pollInterval"`
+	BackoffInitial time.Duration `yaml:"backoffInitial" toml:"backoffInitial"`
+	BackoffMax     time.Duration `yaml:"backoffMax" toml:"backoffMax"`
+	PublishTimeout time.Duration `yaml:"publishTimeout" toml:"publishTimeout"`
+}
+
+// OvertimeConfig holds the rules worked hours are split into regular and overtime hours by. Hours
+// beyond DailyHours on a day are overtime, as are regular hours beyond WeeklyHours in a week, a zero
+// limit is not applied. Overtime hours are paid Multiplier times the regular rate.
+type OvertimeConfig struct {
+	DailyHours  float64 `yaml:"dailyHours" toml:"dailyHours"`
+	WeeklyHours float64 `yaml:"weeklyHours" toml:"weeklyHours"`
+	Multiplier  float64 `yaml:"multiplier" toml:"multiplier"`
+}
+
+// PayrollConfig holds the rules payroll runs compute payslips by. SalaryBasis tells whether salaries are
+// annual or monthly, contractors are paid theirs per hour. Components are the allowances and deductions
+// on every payslip.
+type PayrollConfig struct {
+	SalaryBasis models.SalaryBasis `yaml:"salaryBasis" toml:"salaryBasis"`
+	Components  []PayComponent     `yaml:"components" toml:"components"`
+}
+
+// PayComponent is an allowance or a deduction, either Percent of the pay or a fixed Amount of the base
+// currency per payslip.
+type PayComponent struct {
+	Name    string                  `yaml:"name" toml:"name"`
+	Kind    models.PayComponentKind `yaml:"kind" toml:"kind"`
+	Percent float64                 `yaml:"percent" toml:"percent"`
+	Amount  models.Money            `yaml:"amount" toml:"amount"`
+}
+
+// CurrencyConfig holds the Base currency exchange rates are quoted in, payroll runs are totalled in and
+// salary reports are normalized to unless asked otherwise. New employees are paid in it by default.
+type CurrencyConfig struct {
+	Base models.Currency `yaml:"base" toml:"base"`
+}
+
+// ReportsConfig holds the settings of the compensation analytics. Reports are computed at most once per
+// CacheTTL, 0 computes them on every request, and groups of fewer than MinGroupSize employees are left
+// out so their salaries cannot be told apart.
+type ReportsConfig struct {
+	CacheTTL     time.Duration `yaml:"cacheTtl" toml:"cacheTtl"`
+	MinGroupSize int           `yaml:"minGroupSize" toml:"minGroupSize"`
+}
+
+// BrandingConfig is the company shown on documents like payslips. Logo is a PNG or JPEG file printed in
+// the header and Color, like #1f4e79, the accent of headings and rules.
+type BrandingConfig struct {
+	CompanyName    string `yaml:"companyName" toml:"companyName"`
+	CompanyAddress string `yaml:"companyAddress" toml:"companyAddress"`
+	Logo           string `yaml:"logo" toml:"logo"`
+	Color          string `yaml:"color" toml:"color"`
+}
+
+// Outbox sinks selectable with OUTBOX_SINKS
+const (
+	SinkWebhooks = "webhooks"
+	SinkFile     = "file"
+)
+
+// OutboxSinks are the sinks OUTBOX_SINKS may name
+var OutboxSinks = []string{SinkWebhooks, SinkFile}
+
+// Database drivers selectable with DB_DRIVER
+const (
+	DriverPostgres = "postgres"
+	DriverSQLite   = "sqlite"
+)
+
+// DatabaseConfig holds the database connection, pool and reconnect settings.
+type DatabaseConfig struct {
+	// Driver picks the storage backend, SQLitePath is only used by the sqlite driver.
+	Driver     string `yaml:"driver" toml:"driver"`
+	SQLitePath string `yaml:"sqlitePath" toml:"sqlitePath"`
+
+	URL             string        `yaml:"url" toml:"url"`
+	MaxOpenConns    int           `yaml:"maxOpenConns" toml:"maxOpenConns"`
+	MaxIdleConns    int           `yaml:"maxIdleConns" toml:"maxIdleConns"`
+	ConnMaxLifetime time.Duration `yaml:"connMaxLifetime" toml:"connMaxLifetime"`
+	ConnMaxIdleTime time.Duration `yaml:"connMaxIdleTime" toml:"connMaxIdleTime"`
+
+	// ReplicaURLs are read replicas used for reads, a replica lagging more than
+	// MaxReplicaLag behind the primary is skipped until it catches up. Replicas are
+	// checked every HealthCheckInterval, or twice per MaxReplicaLag if that is more often.
+	ReplicaURLs   []string      `yaml:"replicaUrls" toml:"replicaUrls"`
+	MaxReplicaLag time.Duration `yaml:"maxReplicaLag" toml:"maxReplicaLag"`
+
+	// ConnectAttempts bounds the startup connection attempts, each limited by ConnectTimeout
+	// and separated by an exponential backoff between BackoffInitial and BackoffMax.
+	ConnectAttempts int           `yaml:"connectAttempts" toml:"connectAttempts"`
+	ConnectTimeout  time.Duration `yaml:"connectTimeout" toml:"connectTimeout"`
+	BackoffInitial  time.Duration `yaml:"backoffInitial" toml:"backoffInitial"`
+	BackoffMax      time.Duration `yaml:"backoffMax" toml:"backoffMax"`
+
+	// AllowDegradedStart lets the service start without a database, readiness reports
+	// unhealthy until the background health check manages to connect.
+	AllowDegradedStart bool `yaml:"allowDegradedStart" toml:"allowDegradedStart"`
+
+	HealthCheckInterval time.Duration `yaml:"healthCheckInterval" toml:"healthCheckInterval"`
+	// SaturationThreshold is the share of MaxOpenConns in use above which a warning is logged.
+	SaturationThreshold float64 `yaml:"saturationThreshold" toml:"saturationThreshold"`
+}
+
+// LogConfig holds the logrus level and output format.
+type LogConfig struct {
+	Level  string `yaml:"level" toml:"level"`
+	Format string `yaml:"format" toml:"format"`
+}
+
+// CORSConfig mirrors the fiber cors middleware settings, lists are comma separated.
+type CORSConfig struct {
+	Enabled          bool   `yaml:"enabled" toml:"enabled"`
+	AllowOrigins     string `yaml:"allowOrigins" toml:"allowOrigins"`
+	AllowHeaders     string `yaml:"allowHeaders" toml:"allowHeaders"`
+	AllowMethods     string `yaml:"allowMethods" toml:"allowMethods"`
+	AllowCredentials bool   `yaml:"allowCredentials" toml:"allowCredentials"`
+}
+
+// AuthConfig holds the API keys accepted by the REST API.
+type AuthConfig struct {
+	Enabled bool     `yaml:"enabled" toml:"enabled"`
+	APIKeys []APIKey `yaml:"apiKeys" toml:"apiKeys"`
+}
+
+// APIKey grants its holder a role, employee keys are additionally bound to one employee.
+type APIKey struct {
+	Key        string      `yaml:"key" toml:"key"`
+	Role       models.Role `yaml:"role" toml:"role"`
+	EmployeeID int         `yaml:"employeeId" toml:"employeeId"`
+}
+
+// IdempotencyConfig controls how long Idempotency-Key responses are kept for replays. A key is leased
+// to its request for Lease, a retry takes the key over once the lease ran out without a response.
+type IdempotencyConfig struct {
+	TTL   time.Duration `yaml:"ttl" toml:"ttl"`
+	Lease time.Duration `yaml:"lease" toml:"lease"`
+}
+
+// FeatureConfig holds the feature toggles.
+type FeatureConfig struct {
+	RequestLogging bool `yaml:"requestLogging" toml:"requestLogging"`
+
+	// ValidateRequests checks requests against the OpenAPI document and answers mismatches with 400.
+	// ValidateResponses checks responses too and replaces mismatches with a 500, meant for tests.
+	ValidateRequests  bool `yaml:"validateRequests" toml:"validateRequests"`
+	ValidateResponses bool `yaml:"validateResponses" toml:"validateResponses"`
+}
+
+// Default returns the configuration used when nothing else is set.
+func Default() *Config {
+	return &Config{
+		Server: ServerConfig{
+			Port:            "3000",
+			ReadTimeout:     30 * time.Second,
+			WriteTimeout:    30 * time.Second,
+			IdleTimeout:     60 * time.Second,
+			ShutdownTimeout: 15 * time.Second,
+		},
+		GRPC: GRPCConfig{
+			Enabled:    true,
+			Port:       "50051",
+			Reflection: true,
+		},
+		GraphQL: GraphQLConfig{
+			Enabled:       true,
+			MaxDepth:      8,
+			MaxComplexity: 1000,
+		},
+		Events: EventsConfig{
+			Heartbeat: 15 * time.Second,
+		},
+		Webhooks: WebhooksConfig{
+			MaxAttempts:    8,
+			BackoffInitial: 30 * time.Second,
+			BackoffMax:     time.Hour,
+			Timeout:        10 * time.Second,
+			PollInterval:   5 * time.Second,
+		},
+		Outbox: OutboxConfig{
+			Sinks:          []string{SinkWebhooks},
+			BatchSize:      100,
+			PollInterval:   time.Second,
+			BackoffInitial: time.Second,
+			BackoffMax:     5 * time.Minute,
+			PublishTimeout: 10 * time.Second,
+		},
+		Overtime: OvertimeConfig{
+			DailyHours:  8,
+			WeeklyHours: 40,
+			Multiplier:  1.5,
+		},
+		Payroll: PayrollConfig{
+			SalaryBasis: models.SalaryAnnual,
+		},
+		Currency: CurrencyConfig{
+			Base: models.DefaultCurrency,
+		},
+		Reports: ReportsConfig{
+			CacheTTL:     5 * time.Minute,
+			MinGroupSize: 5,
+		},
+		Branding: BrandingConfig{
+			CompanyName: "Techiebulter",
+			Color:       "#1f4e79",
+		},
+		Database: DatabaseConfig{
+			Driver:              DriverPostgres,
+			SQLitePath:          "employees.db",
+			MaxOpenConns:        25,
+			MaxIdleConns:        5,
+			ConnMaxLifetime:     30 * time.Minute,
+			ConnMaxIdleTime:     5 * time.Minute,
+			MaxReplicaLag:       5 * time.Second,
+			ConnectAttempts:     5,
+			ConnectTimeout:      5 * time.Second,
+			BackoffInitial:      500 * time.Millisecond,
+			BackoffMax:          30 * time.Second,
+			HealthCheckInterval: 15 * time.Second,
+			SaturationThreshold: 0.8,
+		},
+		Log: LogConfig{
+			Level:  "info",
+			Format: "text",
+		},
+		CORS: CORSConfig{
+			AllowOrigins: "http://localhost:3000",
+			AllowHeaders: "Origin, Content-Type, Accept, Idempotency-Key",
+			AllowMethods: "GET, POST, PUT, PATCH, DELETE",
+		},
+		Idempotency: IdempotencyConfig{
+			TTL:   24 * time.Hour,
+			Lease: time.Minute,
+		},
+		Features: FeatureConfig{
+			RequestLogging:   true,
+			ValidateRequests: true,
+		},
+		sources: map[string]string{},
+	}
+}
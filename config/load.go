@@ -0,0 +1,410 @@
+package config
+
+import (
+	"Techiebulter/interview/backend/models"
+	"errors"
+	"flag"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/BurntSushi/toml"
+	"github.com/joho/godotenv"
+	"gopkg.in/yaml.v3"
+)
+
+// Sources a setting can come from, lowest precedence first
+const (
+	SourceDefault = "default"
+	SourceFile    = "file"
+	SourceEnv     = "env"
+	SourceFlag    = "flag"
+)
+
+// binding ties one setting to its file key, environment variable and command line flag
+type binding struct {
+	key    string
+	env    string
+	flag   string
+	usage  string
+	secret bool
+	value  value
+}
+
+// value reads and writes a single config field as text
+type value interface {
+	Set(string) error
+	String() string
+}
+
+// bindings lists every setting that can be overridden from the environment or the command line
+func (c *Config) bindings() []binding {
+	return []binding{
+		{key: "server.port", env: "FIBER_PORT", flag: "port", usage: "HTTP listen port", value: (*stringValue)(&c.Server.Port)},
+		{key: "server.readTimeout", env: "SERVER_READ_TIMEOUT", flag: "read-timeout", usage: "maximum duration for reading a request", value: (*durationValue)(&c.Server.ReadTimeout)},
+		{key: "server.writeTimeout", env: "SERVER_WRITE_TIMEOUT", flag: "write-timeout", usage: "maximum duration for writing a response", value: (*durationValue)(&c.Server.WriteTimeout)},
+		{key: "server.idleTimeout", env: "SERVER_IDLE_TIMEOUT", flag: "idle-timeout", usage: "how long keep-alive connections may stay idle", value: (*durationValue)(&c.Server.IdleTimeout)},
+		{key: "server.shutdownTimeout", env: "SHUTDOWN_TIMEOUT", flag: "shutdown-timeout", usage: "how long in-flight requests may drain on shutdown", value: (*durationValue)(&c.Server.ShutdownTimeout)},
+
+		{key: "grpc.enabled", env: "GRPC_ENABLED", flag: "grpc", usage: "serve the gRPC API", value: (*boolValue)(&c.GRPC.Enabled)},
+		{key: "grpc.port", env: "GRPC_PORT", flag: "grpc-port", usage: "gRPC listen port", value: (*stringValue)(&c.GRPC.Port)},
+		{key: "grpc.reflection", env: "GRPC_REFLECTION", flag: "grpc-reflection", usage: "register the gRPC reflection service", value: (*boolValue)(&c.GRPC.Reflection)},
+
+		{key: "graphql.enabled", env: "GRAPHQL_ENABLED", flag: "graphql", usage: "serve the /graphql endpoint", value: (*boolValue)(&c.GraphQL.Enabled)},
+		{key: "graphql.maxDepth", env: "GRAPHQL_MAX_DEPTH", flag: "graphql-max-depth", usage: "deepest field nesting a GraphQL query may use", value: (*intValue)(&c.GraphQL.MaxDepth)},
+		{key: "graphql.maxComplexity", env: "GRAPHQL_MAX_COMPLEXITY", flag: "graphql-max-complexity", usage: "most fields a GraphQL query may resolve", value: (*intValue)(&c.GraphQL.MaxComplexity)},
+
+		{key: "events.heartbeat", env: "EVENTS_HEARTBEAT", flag: "events-heartbeat", usage: "interval of the heartbeat sent on idle event streams", value: (*durationValue)(&c.Events.Heartbeat)},
+
+		{key: "webhooks.maxAttempts", env: "WEBHOOK_MAX_ATTEMPTS", flag: "webhook-max-attempts", usage: "attempts before a webhook delivery is dead-lettered", value: (*intValue)(&c.Webhooks.MaxAttempts)},
+		{key: "webhooks.backoffInitial", env: "WEBHOOK_BACKOFF_INITIAL", flag: "webhook-backoff-initial", usage: "delay before the first webhook retry", value: (*durationValue)(&c.Webhooks.BackoffInitial)},
+		{key: "webhooks.backoffMax", env: "WEBHOOK_BACKOFF_MAX", flag: "webhook-backoff-max", usage: "upper bound of the webhook retry delay", value: (*durationValue)(&c.Webhooks.BackoffMax)},
+		{key: "webhooks.timeout", env: "WEBHOOK_TIMEOUT", flag: "webhook-timeout", usage: "how long a webhook receiver may take to answer", value: (*durationValue)(&c.Webhooks.Timeout)},
+		{key: "webhooks.pollInterval", env: "WEBHOOK_POLL_INTERVAL", flag: "webhook-poll-interval", usage: "interval at which due webhook deliveries are looked for", value: (*durationValue)(&c.Webhooks.PollInterval)},
+
+		{key: "outbox.sinks", env: "OUTBOX_SINKS", flag: "outbox-sinks", usage: "comma separated sinks the outbox relay publishes to: webhooks, file", value: (*listValue)(&c.Outbox.Sinks)},
+		{key: "outbox.file", env: "OUTBOX_FILE", flag: "outbox-file", usage: "file the file sink appends events to", value: (*stringValue)(&c.Outbox.File)},
+		{key: "outbox.batchSize", env: "OUTBOX_BATCH_SIZE", flag: "outbox-batch-size", usage: "outbox messages claimed at a time", value: (*intValue)(&c.Outbox.BatchSize)},
+		{key: "outbox.pollInterval", env: "OUTBOX_POLL_INTERVAL", flag: "outbox-poll-interval", usage: "interval at which due outbox messages are looked for", value: (*durationValue)(&c.Outbox.PollInterval)},
+		{key: "outbox.backoffInitial", env: "OUTBOX_BACKOFF_INITIAL", flag: "outbox-backoff-initial", usage: "delay before an unpublished outbox message is retried", value: (*durationValue)(&c.Outbox.BackoffInitial)},
+		{key: "outbox.backoffMax", env: "OUTBOX_BACKOFF_MAX", flag: "outbox-backoff-max", usage: "upper bound of the outbox retry delay", value: (*durationValue)(&c.Outbox.BackoffMax)},
+		{key: "outbox.publishTimeout", env: "OUTBOX_PUBLISH_TIMEOUT", flag: "outbox-publish-timeout", usage: "how long a sink may take to accept an event", value: (*durationValue)(&c.Outbox.PublishTimeout)},
+
+		{key: "overtime.dailyHours", env: "OVERTIME_DAILY_HOURS", flag: "overtime-daily-hours", usage: "hours worked on a day before the rest is overtime, 0 means no daily limit", value: (*floatValue)(&c.Overtime.DailyHours)},
+		{key: "overtime.weeklyHours", env: "OVERTIME_WEEKLY_HOURS", flag: "overtime-weekly-hours", usage: "regular hours in a week before the rest is overtime, 0 means no weekly limit", value: (*floatValue)(&c.Overtime.WeeklyHours)},
+		{key: "overtime.multiplier", env: "OVERTIME_MULTIPLIER", flag: "overtime-multiplier", usage: "factor overtime hours are paid at", value: (*floatValue)(&c.Overtime.Multiplier)},
+
+		{key: "payroll.salaryBasis", env: "PAYROLL_SALARY_BASIS", flag: "payroll-salary-basis", usage: "period salaries are paid for: annual or monthly", value: (*stringValue)(&c.Payroll.SalaryBasis)},
+		{key: "payroll.components", env: "PAYROLL_COMPONENTS", flag: "payroll-components", usage: "comma separated name:allowance|deduction:amount entries, amounts ending in % are a share of the pay", value: (*payComponentsValue)(&c.Payroll.Components)},
+
+		{key: "currency.base", env: "BASE_CURRENCY", flag: "base-currency", usage: "ISO 4217 code of the currency exchange rates are quoted in and reports are totalled in", value: (*stringValue)(&c.Currency.Base)},
+		{key: "reports.cacheTtl", env: "REPORTS_CACHE_TTL", flag: "reports-cache-ttl", usage: "how long compensation reports are served from the cache, 0 disables the cache", value: (*durationValue)(&c.Reports.CacheTTL)},
+		{key: "reports.minGroupSize", env: "REPORTS_MIN_GROUP_SIZE", flag: "reports-min-group-size", usage: "fewest employees a group of a compensation report must have to be shown", value: (*intValue)(&c.Reports.MinGroupSize)},
+		{key: "branding.companyName", env: "COMPANY_NAME", flag: "company-name", usage: "company name printed on payslips", value: (*stringValue)(&c.Branding.CompanyName)},
+		{key: "branding.companyAddress", env: "COMPANY_ADDRESS", flag: "company-address", usage: "company address printed on payslips", value: (*stringValue)(&c.Branding.CompanyAddress)},
+		{key: "branding.logo", env: "COMPANY_LOGO", flag: "company-logo", usage: "PNG or JPEG logo printed on payslips", value: (*stringValue)(&c.Branding.Logo)},
+		{key: "branding.color", env: "BRAND_COLOR", flag: "brand-color", usage: "hex color like #1f4e79 of payslip headings", value: (*stringValue)(&c.Branding.Color)},
+
+		{key: "database.driver", env: "DB_DRIVER", flag: "db-driver", usage: "storage backend: postgres or sqlite", value: (*stringValue)(&c.Database.Driver)},
+		{key: "database.sqlitePath", env: "SQLITE_PATH", flag: "sqlite-path", usage: "database file used by the sqlite driver", value: (*stringValue)(&c.Database.SQLitePath)},
+		{key: "database.url", env: "PGSQL_URL", flag: "database-url", usage: "PostgreSQL connection string", secret: true, value: (*stringValue)(&c.Database.URL)},
+		{key: "database.maxOpenConns", env: "DB_MAX_OPEN_CONNS", flag: "db-max-open-conns", usage: "maximum open database connections, 0 means unlimited", value: (*intValue)(&c.Database.MaxOpenConns)},
+		{key: "database.maxIdleConns", env: "DB_MAX_IDLE_CONNS", flag: "db-max-idle-conns", usage: "maximum idle database connections", value: (*intValue)(&c.Database.MaxIdleConns)},
+		{key: "database.connMaxLifetime", env: "DB_CONN_MAX_LIFETIME", flag: "db-conn-max-lifetime", usage: "maximum lifetime of a database connection, 0 means forever", value: (*durationValue)(&c.Database.ConnMaxLifetime)},
+		{key: "database.connMaxIdleTime", env: "DB_CONN_MAX_IDLE_TIME", flag: "db-conn-max-idle-time", usage: "maximum idle time of a database connection, 0 means forever", value: (*durationValue)(&c.Database.ConnMaxIdleTime)},
+		{key: "database.replicaUrls", env: "DB_REPLICA_URLS", flag: "db-replica-urls", usage: "comma separated read replica connection strings", secret: true, value: (*listValue)(&c.Database.ReplicaURLs)},
+		{key: "database.maxReplicaLag", env: "DB_MAX_REPLICA_LAG", flag: "db-max-replica-lag", usage: "staleness tolerated before reads skip a replica", value: (*durationValue)(&c.Database.MaxReplicaLag)},
+		{key: "database.connectAttempts", env: "DB_CONNECT_ATTEMPTS", flag: "db-connect-attempts", usage: "database connection attempts at startup", value: (*intValue)(&c.Database.ConnectAttempts)},
+		{key: "database.connectTimeout", env: "DB_CONNECT_TIMEOUT", flag: "db-connect-timeout", usage: "timeout of a single database connection attempt", value: (*durationValue)(&c.Database.ConnectTimeout)},
+		{key: "database.backoffInitial", env: "DB_BACKOFF_INITIAL", flag: "db-backoff-initial", usage: "delay before the first database reconnect", value: (*durationValue)(&c.Database.BackoffInitial)},
+		{key: "database.backoffMax", env: "DB_BACKOFF_MAX", flag: "db-backoff-max", usage: "upper bound of the database reconnect delay", value: (*durationValue)(&c.Database.BackoffMax)},
+		{key: "database.allowDegradedStart", env: "DB_ALLOW_DEGRADED_START", flag: "db-allow-degraded-start", usage: "start even if the database is unreachable", value: (*boolValue)(&c.Database.AllowDegradedStart)},
+		{key: "database.healthCheckInterval", env: "DB_HEALTH_CHECK_INTERVAL", flag: "db-health-check-interval", usage: "interval of the background database health check", value: (*durationValue)(&c.Database.HealthCheckInterval)},
+		{key: "database.saturationThreshold", env: "DB_SATURATION_THRESHOLD", flag: "db-saturation-threshold", usage: "pool usage ratio above which saturation is logged", value: (*floatValue)(&c.Database.SaturationThreshold)},
+
+		{key: "log.level", env: "LOG_LEVEL", flag: "log-level", usage: "log level: trace, debug, info, warn, error", value: (*stringValue)(&c.Log.Level)},
+		{key: "log.format", env: "LOG_FORMAT", flag: "log-format", usage: "log format: text or json", value: (*stringValue)(&c.Log.Format)},
+
+		{key: "cors.enabled", env: "CORS_ENABLED", flag: "cors", usage: "enable the CORS middleware", value: (*boolValue)(&c.CORS.Enabled)},
+		{key: "cors.allowOrigins", env: "CORS_ALLOW_ORIGINS", flag: "cors-allow-origins", usage: "comma separated allowed origins", value: (*stringValue)(&c.CORS.AllowOrigins)},
+		{key: "cors.allowHeaders", env: "CORS_ALLOW_HEADERS", flag: "cors-allow-headers", usage: "comma separated allowed headers", value: (*stringValue)(&c.CORS.AllowHeaders)},
+		{key: "cors.allowMethods", env: "CORS_ALLOW_METHODS", flag: "cors-allow-methods", usage: "comma separated allowed methods", value: (*stringValue)(&c.CORS.AllowMethods)},
+		{key: "cors.allowCredentials", env: "CORS_ALLOW_CREDENTIALS", flag: "cors-allow-credentials", usage: "allow credentials in CORS requests", value: (*boolValue)(&c.CORS.AllowCredentials)},
+
+		{key: "auth.enabled", env: "AUTH_ENABLED", flag: "auth", usage: "require an API key on /api routes", value: (*boolValue)(&c.Auth.Enabled)},
+		{key: "auth.apiKeys", env: "AUTH_API_KEYS", flag: "auth-api-keys", usage: "comma separated key:role[:employeeID] entries", secret: true, value: (*apiKeysValue)(&c.Auth.APIKeys)},
+
+		{key: "idempotency.ttl", env: "IDEMPOTENCY_TTL", flag: "idempotency-ttl", usage: "how long Idempotency-Key responses are replayed", value: (*durationValue)(&c.Idempotency.TTL)},
+		{key: "idempotency.lease", env: "IDEMPOTENCY_LEASE", flag: "idempotency-lease", usage: "how long a request holds its Idempotency-Key before a retry may take it over", value: (*durationValue)(&c.Idempotency.Lease)},
+
+		{key: "features.requestLogging", env: "FEATURE_REQUEST_LOGGING", flag: "feature-request-logging", usage: "log every HTTP request", value: (*boolValue)(&c.Features.RequestLogging)},
+		{key: "features.validateRequests", env: "FEATURE_VALIDATE_REQUESTS", flag: "feature-validate-requests", usage: "reject requests that do not match the OpenAPI document", value: (*boolValue)(&c.Features.ValidateRequests)},
+		{key: "features.validateResponses", env: "FEATURE_VALIDATE_RESPONSES", flag: "feature-validate-responses", usage: "fail responses that do not match the OpenAPI document, for tests", value: (*boolValue)(&c.Features.ValidateResponses)},
+	}
+}
+
+// Load builds the configuration from defaults, the config file, the environment and args.
+// A .env file in the working directory is read into the environment first if present.
+// Every problem found along the way is reported together in a single *ValidationError.
+func Load(args []string) (*Config, error) {
+	cfg := Default()
+	bindings := cfg.bindings()
+	var problems []string
+
+	// .env is optional, variables already present in the environment win over it
+	if err := godotenv.Load(".env"); err != nil && !errors.Is(err, fs.ErrNotExist) {
+		problems = append(problems, fmt.Sprintf(".env: %v", err))
+	}
+
+	// flags are parsed first to find the config file but applied last so they take precedence
+	type flagValue struct {
+		binding binding
+		raw     string
+	}
+	var flagValues []flagValue
+
+	flags := flag.NewFlagSet("employee-management", flag.ContinueOnError)
+	configFile := flags.String("config", os.Getenv("CONFIG_FILE"), "path to a YAML or TOML config file (env CONFIG_FILE)")
+	for _, b := range bindings {
+		b := b
+		flags.Func(b.flag, fmt.Sprintf("%s (env %s)", b.usage, b.env), func(raw string) error {
+			flagValues = append(flagValues, flagValue{binding: b, raw: raw})
+			return nil
+		})
+	}
+	if err := flags.Parse(args); err != nil {
+		return nil, err
+	}
+
+	if *configFile != "" {
+		keys, err := cfg.loadFile(*configFile)
+		if err != nil {
+			problems = append(problems, fmt.Sprintf("config file %s: %v", *configFile, err))
+		}
+		for _, key := range keys {
+			cfg.sources[key] = SourceFile
+		}
+	}
+
+	for _, b := range bindings {
+		raw, ok := os.LookupEnv(b.env)
+		if !ok || raw == "" {
+			continue
+		}
+		if err := b.value.Set(raw); err != nil {
+			problems = append(problems, fmt.Sprintf("env %s: %v", b.env, err))
+			continue
+		}
+		cfg.sources[b.key] = SourceEnv
+	}
+
+	for _, fv := range flagValues {
+		if err := fv.binding.value.Set(fv.raw); err != nil {
+			problems = append(problems, fmt.Sprintf("flag -%s: %v", fv.binding.flag, err))
+			continue
+		}
+		cfg.sources[fv.binding.key] = SourceFlag
+	}
+
+	problems = append(problems, cfg.problems()...)
+	if len(problems) > 0 {
+		return cfg, &ValidationError{Problems: problems}
+	}
+
+	return cfg, nil
+}
+
+// loadFile decodes a YAML or TOML file over cfg and returns the dotted keys it contained
+func (c *Config) loadFile(path string) ([]string, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	raw := map[string]interface{}{}
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".yaml", ".yml":
+		if err := yaml.Unmarshal(data, c); err != nil {
+			return nil, err
+		}
+		if err := yaml.Unmarshal(data, &raw); err != nil {
+			return nil, err
+		}
+	case ".toml":
+		if err := toml.Unmarshal(data, c); err != nil {
+			return nil, err
+		}
+		if err := toml.Unmarshal(data, &raw); err != nil {
+			return nil, err
+		}
+	default:
+		return nil, fmt.Errorf("unsupported extension %q, use .yaml, .yml or .toml", filepath.Ext(path))
+	}
+
+	return flattenKeys("", raw), nil
+}
+
+// flattenKeys turns nested maps into dotted keys such as "server.port"
+func flattenKeys(prefix string, raw map[string]interface{}) []string {
+	var keys []string
+	for k, v := range raw {
+		key := k
+		if prefix != "" {
+			key = prefix + "." + k
+		}
+		if nested, ok := v.(map[string]interface{}); ok {
+			keys = append(keys, flattenKeys(key, nested)...)
+			continue
+		}
+		keys = append(keys, key)
+	}
+	return keys
+}
+
+type stringValue string
+
+func (v *stringValue) Set(s string) error {
+	*v = stringValue(strings.TrimSpace(s))
+	return nil
+}
+
+func (v *stringValue) String() string { return string(*v) }
+
+type intValue int
+
+func (v *intValue) Set(s string) error {
+	n, err := strconv.Atoi(strings.TrimSpace(s))
+	if err != nil {
+		return fmt.Errorf("%q is not an integer", s)
+	}
+	*v = intValue(n)
+	return nil
+}
+
+func (v *intValue) String() string { return strconv.Itoa(int(*v)) }
+
+type floatValue float64
+
+func (v *floatValue) Set(s string) error {
+	f, err := strconv.ParseFloat(strings.TrimSpace(s), 64)
+	if err != nil {
+		return fmt.Errorf("%q is not a number", s)
+	}
+	*v = floatValue(f)
+	return nil
+}
+
+func (v *floatValue) String() string { return strconv.FormatFloat(float64(*v), 'g', -1, 64) }
+
+type boolValue bool
+
+func (v *boolValue) Set(s string) error {
+	b, err := strconv.ParseBool(strings.TrimSpace(s))
+	if err != nil {
+		return fmt.Errorf("%q is not a boolean", s)
+	}
+	*v = boolValue(b)
+	return nil
+}
+
+func (v *boolValue) String() string { return strconv.FormatBool(bool(*v)) }
+
+type durationValue time.Duration
+
+func (v *durationValue) Set(s string) error {
+	d, err := time.ParseDuration(strings.TrimSpace(s))
+	if err != nil {
+		return fmt.Errorf("%q is not a duration such as 30s or 2m", s)
+	}
+	*v = durationValue(d)
+	return nil
+}
+
+func (v *durationValue) String() string { return time.Duration(*v).String() }
+
+// listValue parses comma separated entries
+type listValue []string
+
+func (v *listValue) Set(s string) error {
+	var list []string
+	for _, entry := range strings.Split(s, ",") {
+		if entry = strings.TrimSpace(entry); entry != "" {
+			list = append(list, entry)
+		}
+	}
+	*v = list
+	return nil
+}
+
+func (v *listValue) String() string { return strings.Join(*v, ",") }
+
+// apiKeysValue parses "key:role[:employeeID]" entries separated by commas
+type apiKeysValue []APIKey
+
+func (v *apiKeysValue) Set(s string) error {
+	var keys []APIKey
+	for _, entry := range strings.Split(s, ",") {
+		entry = strings.TrimSpace(entry)
+		if entry == "" {
+			continue
+		}
+		parts := strings.Split(entry, ":")
+		if len(parts) < 2 || len(parts) > 3 {
+			return errors.New("API keys must look like key:role or key:employee:<employeeID>")
+		}
+		key := APIKey{Key: parts[0], Role: models.Role(parts[1])}
+		if len(parts) == 3 {
+			id, err := strconv.Atoi(parts[2])
+			if err != nil {
+				return fmt.Errorf("employee ID %q of an API key is not an integer", parts[2])
+			}
+			key.EmployeeID = id
+		}
+		keys = append(keys, key)
+	}
+	*v = keys
+	return nil
+}
+
+func (v *apiKeysValue) String() string {
+	entries := make([]string, 0, len(*v))
+	for _, key := range *v {
+		entry := key.Key + ":" + string(key.Role)
+		if key.EmployeeID != 0 {
+			entry += ":" + strconv.Itoa(key.EmployeeID)
+		}
+		entries = append(entries, entry)
+	}
+	return strings.Join(entries, ",")
+}
+
+// payComponentsValue parses "name:kind:amount" entries separated by commas, an amount like "20%" is a percentage
+type payComponentsValue []PayComponent
+
+func (v *payComponentsValue) Set(s string) error {
+	var components []PayComponent
+	for _, entry := range strings.Split(s, ",") {
+		entry = strings.TrimSpace(entry)
+		if entry == "" {
+			continue
+		}
+		parts := strings.Split(entry, ":")
+		if len(parts) != 3 {
+			return errors.New("pay components must look like name:allowance:100 or name:deduction:20%")
+		}
+		component := PayComponent{Name: strings.TrimSpace(parts[0]), Kind: models.PayComponentKind(strings.TrimSpace(parts[1]))}
+		amount := strings.TrimSpace(parts[2])
+		var err error
+		if strings.HasSuffix(amount, "%") {
+			component.Percent, err = strconv.ParseFloat(strings.TrimSuffix(amount, "%"), 64)
+		} else {
+			component.Amount, err = models.ParseMoney(amount)
+		}
+		var amountErr *models.AmountError
+		if errors.As(err, &amountErr) {
+			return fmt.Errorf("amount %q of pay component %s: %w", amount, component.Name, err)
+		}
+		if err != nil {
+			return fmt.Errorf("amount %q of pay component %s is not a number", amount, component.Name)
+		}
+		components = append(components, component)
+	}
+	*v = components
+	return nil
+}
+
+func (v *payComponentsValue) String() string {
+	entries := make([]string, 0, len(*v))
+	for _, component := range *v {
+		amount := component.Amount.String()
+		if component.Percent != 0 {
+			amount = strconv.FormatFloat(component.Percent, 'f', -1, 64) + "%"
+		}
+		entries = append(entries, component.Name+":"+string(component.Kind)+":"+amount)
+	}
+	return strings.Join(entries, ",")
+}
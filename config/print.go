@@ -0,0 +1,82 @@
+package config
+
+import (
+	"fmt"
+	"io"
+	"net/url"
+	"regexp"
+	"strings"
+	"text/tabwriter"
+)
+
+const mask = "****"
+
+// dsnPassword matches the password of a key/value connection string such as "user=x password=y"
+var dsnPassword = regexp.MustCompile(`(password\s*=\s*)('[^']*'|\S+)`)
+
+// Print writes the effective configuration with the source of every value, secrets masked.
+func (c *Config) Print(w io.Writer) error {
+	tw := tabwriter.NewWriter(w, 0, 4, 2, ' ', 0)
+	fmt.Fprintln(tw, "KEY\tVALUE\tSOURCE\tENV\tFLAG")
+	for _, b := range c.bindings() {
+		val := b.value.String()
+		if b.secret {
+			val = maskSecret(b.key, val)
+		}
+		if val == "" {
+			val = `""`
+		}
+		fmt.Fprintf(tw, "%s\t%s\t%s\t%s\t-%s\n", b.key, val, c.Source(b.key), b.env, b.flag)
+	}
+	return tw.Flush()
+}
+
+// Source reports where the setting with the given dotted key came from
+func (c *Config) Source(key string) string {
+	if source, ok := c.sources[key]; ok {
+		return source
+	}
+	return SourceDefault
+}
+
+func maskSecret(key, val string) string {
+	if val == "" {
+		return val
+	}
+	switch key {
+	case "database.url":
+		return MaskDSN(val)
+	case "database.replicaUrls":
+		// key/value connection strings contain spaces but never commas
+		var masked []string
+		for _, dsn := range strings.Split(val, ",") {
+			masked = append(masked, MaskDSN(dsn))
+		}
+		return strings.Join(masked, ",")
+	case "auth.apiKeys":
+		// keep the roles visible so operators can still tell the keys apart
+		var masked []string
+		for _, entry := range strings.Split(val, ",") {
+			if i := strings.Index(entry, ":"); i >= 0 {
+				masked = append(masked, mask+entry[i:])
+			} else {
+				masked = append(masked, mask)
+			}
+		}
+		return strings.Join(masked, ",")
+	}
+	return mask
+}
+
+// MaskDSN hides the password of a PostgreSQL URL or key/value connection string
+func MaskDSN(dsn string) string {
+	if u, err := url.Parse(dsn); err == nil && u.Scheme != "" && u.User != nil {
+		if _, ok := u.User.Password(); ok {
+			// url.UserPassword would escape the mask, the user name cannot contain the @ ending it
+			u.User = url.User(u.User.Username())
+			return strings.Replace(u.String(), "@", ":"+mask+"@", 1)
+		}
+		return u.String()
+	}
+	return dsnPassword.ReplaceAllString(dsn, "${1}"+mask)
+}
@@ -0,0 +1,285 @@
+package config
+
+import (
+	"Techiebulter/interview/backend/models"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/sirupsen/logrus"
+)
+
+// ValidationError lists every problem found while loading the configuration
+type ValidationError struct {
+	Problems []string
+}
+
+func (e *ValidationError) Error() string {
+	return "invalid configuration:\n  - " + strings.Join(e.Problems, "\n  - ")
+}
+
+// brandColor matches the hex colors of branding.color
+var brandColor = regexp.MustCompile(`^#[0-9a-fA-F]{6}$`)
+
+// Validate checks the configuration and reports all problems at once.
+func (c *Config) Validate() error {
+	if problems := c.problems(); len(problems) > 0 {
+		return &ValidationError{Problems: problems}
+	}
+	return nil
+}
+
+func (c *Config) problems() []string {
+	var problems []string
+	addf := func(format string, args ...interface{}) {
+		problems = append(problems, fmt.Sprintf(format, args...))
+	}
+
+	if port, err := strconv.Atoi(c.Server.Port); err != nil || port < 1 || port > 65535 {
+		addf("server.port: %q is not a port between 1 and 65535", c.Server.Port)
+	}
+	if c.Server.ReadTimeout < 0 {
+		addf("server.readTimeout: must not be negative")
+	}
+	if c.Server.WriteTimeout < 0 {
+		addf("server.writeTimeout: must not be negative")
+	}
+	if c.Server.IdleTimeout < 0 {
+		addf("server.idleTimeout: must not be negative")
+	}
+	if c.Server.ShutdownTimeout <= 0 {
+		addf("server.shutdownTimeout: must be positive")
+	}
+
+	if c.GRPC.Enabled {
+		if port, err := strconv.Atoi(c.GRPC.Port); err != nil || port < 1 || port > 65535 {
+			addf("grpc.port: %q is not a port between 1 and 65535", c.GRPC.Port)
+		} else if c.GRPC.Port == c.Server.Port {
+			addf("grpc.port: %s is already used by server.port", c.GRPC.Port)
+		}
+	}
+
+	if c.GraphQL.Enabled {
+		if c.GraphQL.MaxDepth < 1 {
+			addf("graphql.maxDepth: must be at least 1")
+		}
+		if c.GraphQL.MaxComplexity < 1 {
+			addf("graphql.maxComplexity: must be at least 1")
+		}
+	}
+
+	if c.Events.Heartbeat <= 0 {
+		addf("events.heartbeat: must be positive")
+	}
+
+	if c.Webhooks.MaxAttempts < 1 {
+		addf("webhooks.maxAttempts: must be at least 1")
+	}
+	if c.Webhooks.BackoffInitial <= 0 {
+		addf("webhooks.backoffInitial: must be positive")
+	}
+	if c.Webhooks.BackoffMax < c.Webhooks.BackoffInitial {
+		addf("webhooks.backoffMax: must not be below webhooks.backoffInitial")
+	}
+	if c.Webhooks.Timeout <= 0 {
+		addf("webhooks.timeout: must be positive")
+	}
+	if c.Webhooks.PollInterval <= 0 {
+		addf("webhooks.pollInterval: must be positive")
+	}
+
+	for _, sink := range c.Outbox.Sinks {
+		known := false
+		for _, name := range OutboxSinks {
+			known = known || sink == name
+		}
+		if !known {
+			addf("outbox.sinks: unknown sink %q, use %s", sink, strings.Join(OutboxSinks, " or "))
+		}
+		if sink == SinkFile && c.Outbox.File == "" {
+			addf("outbox.file: is required by the file sink (env OUTBOX_FILE)")
+		}
+	}
+	if c.Outbox.BatchSize < 1 {
+		addf("outbox.batchSize: must be at least 1")
+	}
+	if c.Outbox.PollInterval <= 0 {
+		addf("outbox.pollInterval: must be positive")
+	}
+	if c.Outbox.BackoffInitial <= 0 {
+		addf("outbox.backoffInitial: must be positive")
+	}
+	if c.Outbox.BackoffMax < c.Outbox.BackoffInitial {
+		addf("outbox.backoffMax: must not be below outbox.backoffInitial")
+	}
+	if c.Outbox.PublishTimeout <= 0 {
+		addf("outbox.publishTimeout: must be positive")
+	}
+
+	if c.Overtime.DailyHours < 0 || c.Overtime.DailyHours > 24 {
+		addf("overtime.dailyHours: must be between 0 and 24")
+	}
+	if c.Overtime.WeeklyHours < 0 || c.Overtime.WeeklyHours > 168 {
+		addf("overtime.weeklyHours: must be between 0 and 168")
+	}
+	if c.Overtime.Multiplier < 1 {
+		addf("overtime.multiplier: must be at least 1")
+	}
+
+	if !c.Payroll.SalaryBasis.IsValid() {
+		addf("payroll.salaryBasis: %q must be %s or %s", c.Payroll.SalaryBasis, models.SalaryAnnual, models.SalaryMonthly)
+	}
+	names := map[string]bool{}
+	for i, component := range c.Payroll.Components {
+		if component.Name == "" {
+			addf("payroll.components[%d]: name is required", i)
+		} else if names[component.Name] {
+			addf("payroll.components[%d]: name %q is used twice", i, component.Name)
+		}
+		names[component.Name] = true
+		if !component.Kind.IsValid() {
+			addf("payroll.components[%d]: kind %q must be %s or %s", i, component.Kind, models.Allowance, models.Deduction)
+		}
+		if (component.Percent == 0) == component.Amount.IsZero() {
+			addf("payroll.components[%d]: exactly one of percent and amount must be set", i)
+		}
+		if component.Percent < 0 || component.Percent > 100 || component.Amount.Cmp(models.Money{}) < 0 {
+			addf("payroll.components[%d]: percent must be between 0 and 100 and amount must not be negative", i)
+		}
+		if component.Amount.Cmp(models.MoneyFromCents(models.MaxPayslipAmount*100)) >= 0 {
+			addf("payroll.components[%d]: amount must be below %d", i, models.MaxPayslipAmount)
+		}
+	}
+
+	if !c.Currency.Base.IsValid() {
+		addf("currency.base: %q must be an ISO 4217 currency code like EUR", c.Currency.Base)
+	}
+
+	if c.Reports.CacheTTL < 0 {
+		addf("reports.cacheTtl: must not be negative")
+	}
+	if c.Reports.MinGroupSize < 1 {
+		addf("reports.minGroupSize: must be at least 1")
+	}
+
+	if c.Branding.CompanyName == "" {
+		addf("branding.companyName: is required (env COMPANY_NAME)")
+	}
+	if !brandColor.MatchString(c.Branding.Color) {
+		addf("branding.color: %q must be a hex color like #1f4e79", c.Branding.Color)
+	}
+	if c.Branding.Logo != "" {
+		switch strings.ToLower(filepath.Ext(c.Branding.Logo)) {
+		case ".png", ".jpg", ".jpeg":
+			if _, err := os.Stat(c.Branding.Logo); err != nil {
+				addf("branding.logo: %v", err)
+			}
+		default:
+			addf("branding.logo: %q must be a PNG or JPEG file", c.Branding.Logo)
+		}
+	}
+
+	switch c.Database.Driver {
+	case DriverPostgres:
+		if c.Database.URL == "" {
+			addf("database.url: is required (env PGSQL_URL)")
+		}
+	case DriverSQLite:
+		if c.Database.SQLitePath == "" {
+			addf("database.sqlitePath: is required by the sqlite driver (env SQLITE_PATH)")
+		}
+		if len(c.Database.ReplicaURLs) > 0 {
+			addf("database.replicaUrls: read replicas are only supported by the postgres driver")
+		}
+	default:
+		addf("database.driver: %q must be %s or %s", c.Database.Driver, DriverPostgres, DriverSQLite)
+	}
+	if c.Database.MaxOpenConns < 0 {
+		addf("database.maxOpenConns: must not be negative")
+	}
+	if c.Database.MaxIdleConns < 0 {
+		addf("database.maxIdleConns: must not be negative")
+	}
+	if c.Database.MaxOpenConns > 0 && c.Database.MaxIdleConns > c.Database.MaxOpenConns {
+		addf("database.maxIdleConns: %d exceeds database.maxOpenConns %d", c.Database.MaxIdleConns, c.Database.MaxOpenConns)
+	}
+	for i, url := range c.Database.ReplicaURLs {
+		if url == c.Database.URL {
+			addf("database.replicaUrls[%d]: is the primary database", i)
+		}
+	}
+	if len(c.Database.ReplicaURLs) > 0 && c.Database.MaxReplicaLag <= 0 {
+		addf("database.maxReplicaLag: must be positive when replicas are configured")
+	}
+	if c.Database.ConnMaxLifetime < 0 {
+		addf("database.connMaxLifetime: must not be negative")
+	}
+	if c.Database.ConnMaxIdleTime < 0 {
+		addf("database.connMaxIdleTime: must not be negative")
+	}
+	if c.Database.ConnectAttempts < 1 {
+		addf("database.connectAttempts: must be at least 1")
+	}
+	if c.Database.ConnectTimeout <= 0 {
+		addf("database.connectTimeout: must be positive")
+	}
+	if c.Database.BackoffInitial <= 0 {
+		addf("database.backoffInitial: must be positive")
+	}
+	if c.Database.BackoffMax < c.Database.BackoffInitial {
+		addf("database.backoffMax: must not be below database.backoffInitial")
+	}
+	if c.Database.HealthCheckInterval <= 0 {
+		addf("database.healthCheckInterval: must be positive")
+	}
+	if c.Database.SaturationThreshold <= 0 || c.Database.SaturationThreshold > 1 {
+		addf("database.saturationThreshold: %v must be within (0, 1]", c.Database.SaturationThreshold)
+	}
+
+	if _, err := logrus.ParseLevel(c.Log.Level); err != nil {
+		addf("log.level: %q is not a valid level", c.Log.Level)
+	}
+	if c.Log.Format != "text" && c.Log.Format != "json" {
+		addf("log.format: %q must be text or json", c.Log.Format)
+	}
+
+	if c.CORS.Enabled {
+		if strings.TrimSpace(c.CORS.AllowOrigins) == "" {
+			addf("cors.allowOrigins: is required when CORS is enabled")
+		}
+		if c.CORS.AllowCredentials && strings.Contains(c.CORS.AllowOrigins, "*") {
+			addf("cors.allowCredentials: cannot be combined with a wildcard origin")
+		}
+	}
+
+	if c.Auth.Enabled && len(c.Auth.APIKeys) == 0 {
+		addf("auth.apiKeys: at least one key is required when auth is enabled")
+	}
+	seen := map[string]bool{}
+	for i, key := range c.Auth.APIKeys {
+		if key.Key == "" {
+			addf("auth.apiKeys[%d]: key is empty", i)
+		} else if seen[key.Key] {
+			addf("auth.apiKeys[%d]: key is listed more than once", i)
+		}
+		seen[key.Key] = true
+		if !key.Role.IsValid() {
+			addf("auth.apiKeys[%d]: unknown role %q", i, key.Role)
+		}
+		if key.Role == models.RoleEmployee && key.EmployeeID < 1 {
+			addf("auth.apiKeys[%d]: employee keys need an employee ID", i)
+		}
+	}
+
+	if c.Idempotency.TTL <= 0 {
+		addf("idempotency.ttl: must be positive")
+	}
+	if c.Idempotency.Lease <= 0 || c.Idempotency.Lease > c.Idempotency.TTL {
+		addf("idempotency.lease: must be positive and at most idempotency.ttl")
+	}
+
+	return problems
+}
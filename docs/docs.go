@@ -0,0 +1,20 @@
+// Package docs embeds the API documentation served by the server.
+package docs
+
+import "embed"
+
+// OpenAPI is the OpenAPI 3.1 document describing every route of the server
+//
+//go:embed openapi.json
+var OpenAPI []byte
+
+// SwaggerUI is a page rendering OpenAPI with Swagger UI
+//
+//go:embed swagger.html
+var SwaggerUI []byte
+
+// SwaggerUIAssets holds the stylesheet and script of swagger-ui-dist 5.18.2 the page loads, so it renders
+// without internet access
+//
+//go:embed swagger-ui
+var SwaggerUIAssets embed.FS
@@ -1,7 +1,11 @@
 package main
 
 import (
+	"Techiebulter/interview/backend/config"
 	"Techiebulter/interview/backend/server"
+	"errors"
+	"flag"
+	"fmt"
 	"os"
 	"os/signal"
 	"syscall"
@@ -10,17 +14,72 @@ import (
 )
 
 func main() {
+	args := os.Args[1:]
+
+	// "config print [flags]" shows the effective configuration and exits
+	if len(args) >= 2 && args[0] == "config" && args[1] == "print" {
+		os.Exit(printConfig(args[2:]))
+	}
+
+	cfg, err := config.Load(args)
+	if errors.Is(err, flag.ErrHelp) {
+		os.Exit(0)
+	}
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(2)
+	}
+
 	Send := make(chan os.Signal, 1)
 	signal.Notify(Send, os.Interrupt, syscall.SIGINT, syscall.SIGTERM)
 	print("start")
 
 	//SrvInit() initiates the PG database and  DBHandler
-	srv := server.SrvInit()
+	srv := server.SrvInit(cfg)
+
+	// Start blocks until the listener stops, so its error is reported back over a channel
+	startErr := make(chan error, 1)
+	go func() {
+		startErr <- srv.Start()
+	}()
 
-	go srv.Start()
+	exitCode := 0
+	select {
+	case <-Send:
+		logrus.Info("Graceful shutdown")
+	case err := <-startErr:
+		logrus.Errorf("Server stopped unexpectedly: %v", err)
+		exitCode = 1
+	}
 
-	<-Send
 	//Gracefully stops all the services like Db and HTTP
-	logrus.Info("Graceful shutdown")
-	srv.Stop()
+	if err := srv.Stop(); err != nil {
+		logrus.Errorf("Shutdown: %v", err)
+		exitCode = 1
+	}
+
+	os.Exit(exitCode)
+}
+
+// printConfig prints the configuration with secrets masked, followed by any validation problems
+func printConfig(args []string) int {
+	cfg, err := config.Load(args)
+	if errors.Is(err, flag.ErrHelp) {
+		return 0
+	}
+	var invalid *config.ValidationError
+	if err != nil && !errors.As(err, &invalid) {
+		fmt.Fprintln(os.Stderr, err)
+		return 2
+	}
+
+	if err := cfg.Print(os.Stdout); err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return 1
+	}
+	if invalid != nil {
+		fmt.Fprintln(os.Stderr, invalid)
+		return 2
+	}
+	return 0
 }
@@ -0,0 +1,114 @@
+package models
+
+import (
+	"sort"
+	"time"
+)
+
+// SalaryStats describe the distribution of a set of salaries. Percentiles are interpolated between the
+// two nearest salaries and rounded to the cent.
+type SalaryStats struct {
+	Min    Money `json:"min"`
+	P10    Money `json:"p10"`
+	P25    Money `json:"p25"`
+	Median Money `json:"median"`
+	P75    Money `json:"p75"`
+	P90    Money `json:"p90"`
+	Max    Money `json:"max"`
+	Mean   Money `json:"mean"`
+}
+
+// newSalaryStats describes salaries, which must not be empty
+func newSalaryStats(salaries []Money) SalaryStats {
+	sorted := append([]Money(nil), salaries...)
+	sort.Slice(sorted, func(i, j int) bool { return sorted[i].Cmp(sorted[j]) < 0 })
+	var total Money
+	for _, salary := range sorted {
+		total = total.Add(salary)
+	}
+	return SalaryStats{
+		Min:    sorted[0],
+		P10:    percentile(sorted, 10),
+		P25:    percentile(sorted, 25),
+		Median: percentile(sorted, 50),
+		P75:    percentile(sorted, 75),
+		P90:    percentile(sorted, 90),
+		Max:    sorted[len(sorted)-1],
+		Mean:   total.MulDiv(1, int64(len(sorted))),
+	}
+}
+
+// percentile returns the pth percentile of sorted, interpolated linearly between the nearest ranks
+func percentile(sorted []Money, p int64) Money {
+	rank := p * int64(len(sorted)-1)
+	lower, fraction := rank/100, rank%100
+	if fraction == 0 {
+		return sorted[lower]
+	}
+	return sorted[lower].Add(sorted[lower+1].Sub(sorted[lower]).MulDiv(fraction, 100))
+}
+
+// CompensationGroup describes the salaries of the employees sharing a Key
+type CompensationGroup struct {
+	Key       string      `json:"key"`
+	Headcount int         `json:"headcount"`
+	Salary    SalaryStats `json:"salary"`
+}
+
+// CompensationReport describes the salaries the employees employed on AsOf are paid, normalized to
+// Currency with Rates, by group. Groups of fewer than MinGroupSize employees are left out and only
+// counted in SuppressedGroups; Headcount, Salary and Rates cover the groups shown, Salary is nil if there
+// are none.
+type CompensationReport struct {
+	AsOf             Date                `json:"asOf"`
+	GroupBy          SalaryGroupBy       `json:"groupBy"`
+	Currency         Currency            `json:"currency"`
+	MinGroupSize     int                 `json:"minGroupSize"`
+	Headcount        int                 `json:"headcount"`
+	Salary           *SalaryStats        `json:"salary"`
+	Groups           []CompensationGroup `json:"groups"`
+	SuppressedGroups int                 `json:"suppressedGroups"`
+	Rates            []ExchangeRate      `json:"rates"`
+	GeneratedAt      time.Time           `json:"generatedAt"`
+}
+
+// NewCompensationReport describes the salaries the employees employed on query.AsOf are paid on the day
+// by history, which must be ordered by employee and effective date, grouped by query.GroupBy. Salaries are
+// normalized to query.Currency by converter, it returns a *MissingRateError if a rate is missing.
+func NewCompensationReport(query SalaryReportQuery, minGroupSize int, employees []Employee, history []SalaryChange,
+	converter Converter) (CompensationReport, error) {
+	report := CompensationReport{AsOf: query.AsOf, GroupBy: query.GroupBy, Currency: query.Currency, MinGroupSize: minGroupSize,
+		Groups: []CompensationGroup{}, GeneratedAt: time.Now()}
+	paid, err := salariesOn(query.AsOf, query.Currency, employees, history, converter)
+	if err != nil {
+		return report, err
+	}
+
+	groups := map[string][]paidSalary{}
+	for _, p := range paid {
+		key := query.GroupBy.key(p.employee)
+		groups[key] = append(groups[key], p)
+	}
+	var shown []paidSalary
+	var shownSalaries []Money
+	for key, members := range groups {
+		if len(members) < minGroupSize {
+			report.SuppressedGroups++
+			continue
+		}
+		salaries := make([]Money, len(members))
+		for i, member := range members {
+			salaries[i] = member.normalized
+		}
+		report.Groups = append(report.Groups, CompensationGroup{Key: key, Headcount: len(members), Salary: newSalaryStats(salaries)})
+		shown, shownSalaries = append(shown, members...), append(shownSalaries, salaries...)
+	}
+	sort.Slice(report.Groups, func(i, j int) bool { return report.Groups[i].Key < report.Groups[j].Key })
+	if len(shown) > 0 {
+		stats := newSalaryStats(shownSalaries)
+		report.Headcount, report.Salary = len(shown), &stats
+	}
+	// the rates of suppressed groups would tell the currencies they are paid in
+	report.Rates = usedRates(converter, query.Currency, shown)
+	return report, nil
+}
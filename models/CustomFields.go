@@ -0,0 +1,188 @@
+package models
+
+import (
+	"database/sql/driver"
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+)
+
+// customFieldName keeps field names usable as JSON keys and in reports
+var customFieldName = regexp.MustCompile(`^[a-z][a-zA-Z0-9_]{0,62}$`)
+
+// CustomFields holds customer specific employee attributes, validated against the CustomFieldDefinitions
+type CustomFields map[string]interface{}
+
+// Value stores the fields as a JSON string, lib/pq would send []byte as bytea
+func (f CustomFields) Value() (driver.Value, error) {
+	if f == nil {
+		return "{}", nil
+	}
+	data, err := json.Marshal(f)
+	if err != nil {
+		return nil, err
+	}
+	return string(data), nil
+}
+
+func (f *CustomFields) Scan(src interface{}) error {
+	var data []byte
+	switch v := src.(type) {
+	case nil:
+		*f = CustomFields{}
+		return nil
+	case string:
+		data = []byte(v)
+	case []byte:
+		data = v
+	default:
+		return fmt.Errorf("cannot scan %T into custom fields", src)
+	}
+
+	fields := CustomFields{}
+	if err := json.Unmarshal(data, &fields); err != nil {
+		return err
+	}
+	*f = fields
+	return nil
+}
+
+// CustomFieldDefinition is the schema of one custom field, defined by an admin
+type CustomFieldDefinition struct {
+	Name        string          `json:"name"`
+	Type        CustomFieldType `json:"type"`
+	Required    bool            `json:"required"`
+	Options     StringList      `json:"options,omitempty"`
+	Description string          `json:"description,omitempty"`
+	CreatedAt   time.Time       `json:"createdAt"`
+}
+
+// CustomFieldDefinitionRules apply to new custom field definitions
+var CustomFieldDefinitionRules = RuleSet[CustomFieldDefinition]{
+	{Field: "name", Value: func(d *CustomFieldDefinition) interface{} { return d.Name }, Checks: []Check{Required(),
+		Pattern("must start with a lowercase letter and contain only letters, digits and underscores", customFieldName.MatchString)}},
+	{Field: "type", Value: func(d *CustomFieldDefinition) interface{} { return d.Type }, Checks: []Check{Required(),
+		OneOf(string(CustomFieldString), string(CustomFieldNumber), string(CustomFieldBoolean), string(CustomFieldDate), string(CustomFieldEnum))}},
+	{Field: "options", Value: func(d *CustomFieldDefinition) interface{} { return d }, Checks: []Check{enumOptions}},
+	{Field: "description", Value: func(d *CustomFieldDefinition) interface{} { return d.Description }, Checks: []Check{MaxLength(1000)}},
+}
+
+// enumOptions requires options on enum fields and forbids them elsewhere
+var enumOptions = Check{Code: CodeInvalidValue, Message: "are required on enum fields and not allowed on others", Valid: func(value interface{}) bool {
+	d := value.(*CustomFieldDefinition)
+	return (d.Type == CustomFieldEnum) == (len(d.Options) > 0)
+}}
+
+// Validate checks the definition against CustomFieldDefinitionRules
+func (d *CustomFieldDefinition) Validate() ValidationErrors {
+	return CustomFieldDefinitionRules.Validate(d)
+}
+
+// check validates a single value against the definition
+func (d *CustomFieldDefinition) check(value interface{}) *FieldError {
+	invalid := func(code, message string) *FieldError {
+		return &FieldError{Field: "customFields." + d.Name, Code: code, Message: "custom field " + d.Name + " " + message}
+	}
+
+	switch d.Type {
+	case CustomFieldString:
+		if _, ok := value.(string); !ok {
+			return invalid(CodeInvalidType, "must be a string")
+		}
+	case CustomFieldNumber:
+		if _, ok := value.(float64); !ok {
+			return invalid(CodeInvalidType, "must be a number")
+		}
+	case CustomFieldBoolean:
+		if _, ok := value.(bool); !ok {
+			return invalid(CodeInvalidType, "must be a boolean")
+		}
+	case CustomFieldDate:
+		s, ok := value.(string)
+		if !ok {
+			return invalid(CodeInvalidType, "must be a date like 2006-01-02")
+		}
+		if _, err := ParseDate(s); err != nil {
+			return invalid(CodeInvalidFormat, "must be a date like 2006-01-02")
+		}
+	case CustomFieldEnum:
+		s, _ := value.(string)
+		for _, option := range d.Options {
+			if s == option {
+				return nil
+			}
+		}
+		return invalid(CodeInvalidValue, "must be one of "+strings.Join(d.Options, ", "))
+	}
+	return nil
+}
+
+// ValidateCustomFields checks fields against the definitions: every field must be defined,
+// hold a value of the defined type and every required field must be present.
+func ValidateCustomFields(definitions []CustomFieldDefinition, fields CustomFields) ValidationErrors {
+	byName := make(map[string]*CustomFieldDefinition, len(definitions))
+	for i := range definitions {
+		byName[definitions[i].Name] = &definitions[i]
+	}
+
+	// sorted so the problems are reported in the same order every time
+	names := make([]string, 0, len(fields))
+	for name := range fields {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	var errs ValidationErrors
+	for _, name := range names {
+		definition, ok := byName[name]
+		if !ok {
+			errs = append(errs, FieldError{Field: "customFields." + name, Code: CodeUnknownField,
+				Message: "custom field " + name + " is not defined"})
+			continue
+		}
+		if fieldErr := definition.check(fields[name]); fieldErr != nil {
+			errs = append(errs, *fieldErr)
+		}
+	}
+
+	for _, definition := range definitions {
+		if _, ok := fields[definition.Name]; definition.Required && !ok {
+			errs = append(errs, FieldError{Field: "customFields." + definition.Name, Code: CodeRequired,
+				Message: "custom field " + definition.Name + " is required"})
+		}
+	}
+	return errs
+}
+
+// StringList is a list of strings stored as a JSON array
+type StringList []string
+
+func (l StringList) Value() (driver.Value, error) {
+	if l == nil {
+		return "[]", nil
+	}
+	data, err := json.Marshal([]string(l))
+	if err != nil {
+		return nil, err
+	}
+	return string(data), nil
+}
+
+func (l *StringList) Scan(src interface{}) error {
+	var data []byte
+	switch v := src.(type) {
+	case nil:
+		*l = nil
+		return nil
+	case string:
+		data = []byte(v)
+	case []byte:
+		data = v
+	default:
+		return fmt.Errorf("cannot scan %T into a string list", src)
+	}
+	return json.Unmarshal(data, (*[]string)(l))
+}
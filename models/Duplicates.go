@@ -0,0 +1,322 @@
+package models
+
+import (
+	"fmt"
+	"math"
+	"sort"
+	"strings"
+	"time"
+	"unicode"
+)
+
+// Weights of the duplicate score, they add up to 1
+const (
+	nameWeight  = 0.6
+	emailWeight = 0.25
+	phoneWeight = 0.15
+)
+
+// DuplicateNameThreshold is the name similarity above which two employees are compared
+// even when they share no contact details
+const DuplicateNameThreshold = 0.85
+
+// DuplicateCandidate is a pair of employees that are probably the same person
+type DuplicateCandidate struct {
+	Employee       Employee `json:"employee"`
+	Duplicate      Employee `json:"duplicate"`
+	Score          float64  `json:"score"`
+	NameSimilarity float64  `json:"nameSimilarity"`
+	EmailMatch     bool     `json:"emailMatch"`
+	PhoneMatch     bool     `json:"phoneMatch"`
+}
+
+// FindDuplicates scores every pair of employees sharing a contact detail or a name prefix and
+// returns those scoring at least minScore, best first. The score weighs the name similarity
+// (Jaro-Winkler on the normalized names) with matching email addresses and phone numbers.
+func FindDuplicates(employees []Employee, minScore float64) []DuplicateCandidate {
+	// Only employees sharing a blocking key are compared, comparing all pairs grows too fast
+	blocks := map[string][]int{}
+	names := make([]string, len(employees))
+	for i, emp := range employees {
+		names[i] = normalizeName(emp.Name)
+		for _, key := range blockingKeys(emp, names[i]) {
+			blocks[key] = append(blocks[key], i)
+		}
+	}
+
+	type pair struct{ a, b int }
+	seen := map[pair]bool{}
+	var candidates []DuplicateCandidate
+	for _, members := range blocks {
+		for x := 0; x < len(members); x++ {
+			for y := x + 1; y < len(members); y++ {
+				p := pair{members[x], members[y]}
+				if seen[p] {
+					continue
+				}
+				seen[p] = true
+
+				a, b := employees[p.a], employees[p.b]
+				candidate := DuplicateCandidate{
+					Employee:       a,
+					Duplicate:      b,
+					NameSimilarity: round(jaroWinkler(names[p.a], names[p.b])),
+					EmailMatch:     a.Email != "" && strings.EqualFold(a.Email, b.Email),
+					PhoneMatch:     phonesMatch(a.Phone, b.Phone),
+				}
+				if candidate.NameSimilarity < DuplicateNameThreshold && !candidate.EmailMatch && !candidate.PhoneMatch {
+					continue
+				}
+				candidate.Score = nameWeight * candidate.NameSimilarity
+				if candidate.EmailMatch {
+					candidate.Score += emailWeight
+				}
+				if candidate.PhoneMatch {
+					candidate.Score += phoneWeight
+				}
+				candidate.Score = round(candidate.Score)
+				if candidate.Score >= minScore {
+					candidates = append(candidates, candidate)
+				}
+			}
+		}
+	}
+
+	sort.Slice(candidates, func(i, j int) bool {
+		if candidates[i].Score != candidates[j].Score {
+			return candidates[i].Score > candidates[j].Score
+		}
+		if candidates[i].Employee.ID != candidates[j].Employee.ID {
+			return candidates[i].Employee.ID < candidates[j].Employee.ID
+		}
+		return candidates[i].Duplicate.ID < candidates[j].Duplicate.ID
+	})
+	return candidates
+}
+
+func blockingKeys(emp Employee, name string) []string {
+	var keys []string
+	if emp.Email != "" {
+		keys = append(keys, "email:"+strings.ToLower(emp.Email))
+	}
+	if phone := phoneDigits(emp.Phone); phone != "" {
+		keys = append(keys, "phone:"+phone)
+	}
+	for _, token := range strings.Fields(name) {
+		if len(token) > 3 {
+			token = token[:3]
+		}
+		keys = append(keys, "name:"+token)
+	}
+	return keys
+}
+
+// normalizeName lowercases name, drops punctuation and sorts the words, so "Doe, John" matches "John Doe"
+func normalizeName(name string) string {
+	tokens := strings.FieldsFunc(strings.ToLower(name), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	})
+	sort.Strings(tokens)
+	return strings.Join(tokens, " ")
+}
+
+// phoneDigits keeps the last 10 digits of a phone number, dropping country codes and formatting
+func phoneDigits(phone string) string {
+	digits := strings.Map(func(r rune) rune {
+		if r >= '0' && r <= '9' {
+			return r
+		}
+		return -1
+	}, phone)
+	if len(digits) > 10 {
+		digits = digits[len(digits)-10:]
+	}
+	if len(digits) < 7 {
+		return ""
+	}
+	return digits
+}
+
+func phonesMatch(a, b string) bool {
+	digits := phoneDigits(a)
+	return digits != "" && digits == phoneDigits(b)
+}
+
+func round(f float64) float64 {
+	return float64(int(f*1000+0.5)) / 1000
+}
+
+// jaroWinkler returns the Jaro-Winkler similarity of a and b between 0 and 1
+func jaroWinkler(a, b string) float64 {
+	s1, s2 := []rune(a), []rune(b)
+	if len(s1) == 0 && len(s2) == 0 {
+		return 1
+	}
+	if len(s1) == 0 || len(s2) == 0 {
+		return 0
+	}
+
+	window := maxInt(len(s1), len(s2))/2 - 1
+	if window < 0 {
+		window = 0
+	}
+	matched1 := make([]bool, len(s1))
+	matched2 := make([]bool, len(s2))
+	matches := 0
+	for i := range s1 {
+		for j := maxInt(0, i-window); j < minInt(len(s2), i+window+1); j++ {
+			if !matched2[j] && s1[i] == s2[j] {
+				matched1[i], matched2[j] = true, true
+				matches++
+				break
+			}
+		}
+	}
+	if matches == 0 {
+		return 0
+	}
+
+	transpositions, j := 0, 0
+	for i := range s1 {
+		if !matched1[i] {
+			continue
+		}
+		for !matched2[j] {
+			j++
+		}
+		if s1[i] != s2[j] {
+			transpositions++
+		}
+		j++
+	}
+
+	m := float64(matches)
+	jaro := (m/float64(len(s1)) + m/float64(len(s2)) + (m-float64(transpositions)/2)/m) / 3
+
+	prefix := 0
+	for prefix < minInt(4, minInt(len(s1), len(s2))) && s1[prefix] == s2[prefix] {
+		prefix++
+	}
+	return jaro + float64(prefix)*0.1*(1-jaro)
+}
+
+func maxInt(a, b int) int {
+	if a > b {
+		return a
+	}
+	return b
+}
+
+func minInt(a, b int) int {
+	if a < b {
+		return a
+	}
+	return b
+}
+
+// EmployeeMerge records that a duplicate employee was merged into a surviving one. The
+// snapshots keep both records as they were, the duplicate's ID redirects to the survivor.
+type EmployeeMerge struct {
+	ID                 int        `json:"id"`
+	SurvivorID         int        `json:"survivorId"`
+	MergedID           int        `json:"mergedId"`
+	SurvivorBefore     Employee   `json:"survivorBefore"`
+	MergedEmployee     Employee   `json:"mergedEmployee"`
+	TakenFromDuplicate StringList `json:"takenFromDuplicate"`
+	MergedBy           string     `json:"mergedBy"`
+	MergedAt           time.Time  `json:"mergedAt"`
+}
+
+// MergeRequest names the employees to merge and the fields the survivor takes from the duplicate
+type MergeRequest struct {
+	SurvivorID        int      `json:"survivorId"`
+	DuplicateID       int      `json:"duplicateId"`
+	TakeFromDuplicate []string `json:"takeFromDuplicate"`
+}
+
+// mergeableFields are the fields TakeFromDuplicate may name, with how to copy them
+var mergeableFields = map[string]func(survivor *Employee, duplicate Employee){
+	"Name":            func(s *Employee, d Employee) { s.Name = d.Name },
+	"position":        func(s *Employee, d Employee) { s.Position = d.Position },
+	"Salary":          func(s *Employee, d Employee) { s.Salary, s.Currency = d.Salary, d.Currency },
+	"email":           func(s *Employee, d Employee) { s.Email = d.Email },
+	"phone":           func(s *Employee, d Employee) { s.Phone = d.Phone },
+	"hireDate":        func(s *Employee, d Employee) { s.HireDate = d.HireDate },
+	"terminationDate": func(s *Employee, d Employee) { s.TerminationDate = d.TerminationDate },
+	"employmentType":  func(s *Employee, d Employee) { s.EmploymentType = d.EmploymentType },
+	"status":          func(s *Employee, d Employee) { s.Status = d.Status },
+	"location":        func(s *Employee, d Employee) { s.Location = d.Location },
+	"department":      func(s *Employee, d Employee) { s.Department = d.Department },
+	"managerId":       func(s *Employee, d Employee) { s.ManagerID = d.ManagerID },
+}
+
+// MergeRequestRules apply to merge requests
+var MergeRequestRules = RuleSet[MergeRequest]{
+	{Field: "survivorId", Value: func(r *MergeRequest) interface{} { return r.SurvivorID }, Checks: []Check{Required(), Range(1, math.MaxInt32)}},
+	{Field: "duplicateId", Value: func(r *MergeRequest) interface{} { return r.DuplicateID }, Checks: []Check{Required(), Range(1, math.MaxInt32)}},
+	{Field: "duplicateId", Value: func(r *MergeRequest) interface{} { return r }, Checks: []Check{distinctMergeIDs}},
+	{Field: "takeFromDuplicate", Value: func(r *MergeRequest) interface{} { return r.TakeFromDuplicate }, Checks: []Check{mergeableFieldNames}},
+}
+
+// distinctMergeIDs rejects merging an employee into itself
+var distinctMergeIDs = Check{Code: CodeInvalidValue, Message: "must differ from survivorId", Valid: func(value interface{}) bool {
+	r := value.(*MergeRequest)
+	return r.DuplicateID == 0 || r.DuplicateID != r.SurvivorID
+}}
+
+// mergeableFieldNames only accepts fields listed in mergeableFields
+var mergeableFieldNames = Check{Code: CodeInvalidValue, Message: "may only name Name, position, Salary, email, phone, hireDate, terminationDate, employmentType, status, location, department and managerId",
+	Valid: func(value interface{}) bool {
+		for _, field := range value.([]string) {
+			if mergeableFields[field] == nil {
+				return false
+			}
+		}
+		return true
+	}}
+
+// Merge combines survivor and duplicate: the survivor keeps its fields except those named in
+// TakeFromDuplicate, fills its empty fields from the duplicate and gains the duplicate's custom
+// fields it does not have. The earlier hire date of both is kept, a manager that is one of the two is dropped.
+func (r MergeRequest) Merge(survivor, duplicate Employee) (Employee, error) {
+	if survivor.ID != r.SurvivorID || duplicate.ID != r.DuplicateID {
+		return survivor, fmt.Errorf("merge of %d into %d applied to %d and %d", r.DuplicateID, r.SurvivorID, duplicate.ID, survivor.ID)
+	}
+
+	merged := survivor
+	merged.CustomFields = CustomFields{}
+	for name, value := range duplicate.CustomFields {
+		merged.CustomFields[name] = value
+	}
+	for name, value := range survivor.CustomFields {
+		merged.CustomFields[name] = value
+	}
+
+	if merged.Email == "" {
+		merged.Email = duplicate.Email
+	}
+	if merged.Phone == "" {
+		merged.Phone = duplicate.Phone
+	}
+	if merged.Location == "" {
+		merged.Location = duplicate.Location
+	}
+	if merged.Department == "" {
+		merged.Department = duplicate.Department
+	}
+	if merged.ManagerID == nil {
+		merged.ManagerID = duplicate.ManagerID
+	}
+	if merged.HireDate == nil || (duplicate.HireDate != nil && duplicate.HireDate.Before(merged.HireDate.Time)) {
+		merged.HireDate = duplicate.HireDate
+	}
+
+	for _, field := range r.TakeFromDuplicate {
+		mergeableFields[field](&merged, duplicate)
+	}
+	// neither of the two manages the employee they become
+	if merged.ManagerID != nil && (*merged.ManagerID == survivor.ID || *merged.ManagerID == duplicate.ID) {
+		merged.ManagerID = nil
+	}
+	return merged, nil
+}
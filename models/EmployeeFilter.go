@@ -0,0 +1,15 @@
+package models
+
+// EmployeeFilter selects employees by their profile, unset fields match every employee
+type EmployeeFilter struct {
+	// NameContains matches part of the name, ignoring case
+	NameContains   string
+	Position       string
+	Status         EmployeeStatus
+	EmploymentType EmploymentType
+	Location       string
+	Department     string
+	// HiredFrom and HiredTo bound the hire date, both inclusive
+	HiredFrom *Date
+	HiredTo   *Date
+}
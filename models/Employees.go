@@ -1,28 +1,107 @@
 package models
 
-import "errors"
+import (
+	"math"
+	"strings"
+	"time"
+)
 
 type Employee struct {
-	ID       int     `json:"ID"`
-	Name     string  `json:"Name"`
-	Position string  `json:"position"`
-	Salary   float64 `json:"Salary"`
+	ID              int            `json:"ID"`
+	Name            string         `json:"Name"`
+	Position        string         `json:"position"`
+	Salary          Money          `json:"Salary"`
+	Currency        Currency       `json:"currency"`
+	Email           string         `json:"email"`
+	Phone           string         `json:"phone"`
+	HireDate        *Date          `json:"hireDate"`
+	TerminationDate *Date          `json:"terminationDate"`
+	EmploymentType  EmploymentType `json:"employmentType"`
+	Status          EmployeeStatus `json:"status"`
+	Location        string         `json:"location"`
+	Department      string         `json:"department"`
+	ManagerID       *int           `json:"managerId"`
+	CustomFields    CustomFields   `json:"customFields"`
+	CreatedAt       time.Time      `json:"createdAt"`
+	UpdatedAt       time.Time      `json:"updatedAt"`
 }
 
-func (e *Employee) CheckFeilds() error {
-	// Check if all fields are present
-	if e.Name == "" || e.Position == "" || e.Salary < 1 {
-		return errors.New("all fields are mandatory")
-	}
+// employeeProfileRules cover the profile fields every rule set checks when they are set
+var employeeProfileRules = RuleSet[Employee]{
+	{Field: "email", Value: func(e *Employee) interface{} { return e.Email }, Checks: []Check{MaxLength(MaxTextLength), Email()}},
+	{Field: "phone", Value: func(e *Employee) interface{} { return e.Phone }, Checks: []Check{MaxLength(32)}},
+	{Field: "employmentType", Value: func(e *Employee) interface{} { return e.EmploymentType },
+		Checks: []Check{OneOf(string(EmploymentFullTime), string(EmploymentPartTime), string(EmploymentContractor))}},
+	{Field: "status", Value: func(e *Employee) interface{} { return e.Status },
+		Checks: []Check{OneOf(string(StatusActive), string(StatusOnLeave), string(StatusTerminated))}},
+	{Field: "location", Value: func(e *Employee) interface{} { return e.Location }, Checks: []Check{MaxLength(MaxTextLength)}},
+	{Field: "department", Value: func(e *Employee) interface{} { return e.Department }, Checks: []Check{MaxLength(MaxTextLength)}},
+	{Field: "currency", Value: func(e *Employee) interface{} { return e.Currency }, Checks: []Check{currencyCode}},
+	{Field: "terminationDate", Value: func(e *Employee) interface{} { return e }, Checks: []Check{terminationAfterHire}},
+	{Field: "managerId", Value: func(e *Employee) interface{} { return e }, Checks: []Check{managerIsAnotherEmployee}},
+}
+
+// terminationAfterHire compares the dates of an employee, either may be unset
+var terminationAfterHire = Check{Code: CodeOutOfRange, Message: "must not be before hireDate", Valid: func(value interface{}) bool {
+	e := value.(*Employee)
+	return e.HireDate == nil || e.TerminationDate == nil || !e.TerminationDate.Before(e.HireDate.Time)
+}}
+
+// managerIsAnotherEmployee rejects IDs that cannot be an employee and employees managing themselves, whether
+// the manager exists is checked when the employee is stored
+var managerIsAnotherEmployee = Check{Code: CodeInvalidValue, Message: "must be the ID of another employee", Valid: func(value interface{}) bool {
+	e := value.(*Employee)
+	return e.ManagerID == nil || (*e.ManagerID >= 1 && *e.ManagerID <= math.MaxInt32 && *e.ManagerID != e.ID)
+}}
+
+// EmployeeCreateRules apply to new employees, which need a name, position and salary with its currency
+var EmployeeCreateRules = append(RuleSet[Employee]{
+	{Field: "Name", Value: func(e *Employee) interface{} { return e.Name }, Checks: []Check{Required(), MaxLength(MaxTextLength)}},
+	{Field: "position", Value: func(e *Employee) interface{} { return e.Position }, Checks: []Check{Required(), MaxLength(MaxTextLength)}},
+	{Field: "Salary", Value: func(e *Employee) interface{} { return e.Salary }, Checks: []Check{Required(), Range(0.01, MaxSalary)}},
+	{Field: "currency", Value: func(e *Employee) interface{} { return e.Currency }, Checks: []Check{Required()}},
+}, employeeProfileRules...)
+
+// EmployeeUpdateRules apply to updates, which change only the fields that are set
+var EmployeeUpdateRules = append(RuleSet[Employee]{
+	{Field: "ID", Value: func(e *Employee) interface{} { return e.ID }, Checks: []Check{Required(), Range(1, math.MaxInt32)}},
+	{Field: "Name", Value: func(e *Employee) interface{} { return e.Name }, Checks: []Check{MaxLength(MaxTextLength)}},
+	{Field: "position", Value: func(e *Employee) interface{} { return e.Position }, Checks: []Check{MaxLength(MaxTextLength)}},
+	{Field: "Salary", Value: func(e *Employee) interface{} { return e.Salary }, Checks: []Check{Range(0.01, MaxSalary)}},
+}, employeeProfileRules...)
+
+// EmployeePatchRules apply to the result of patching a stored employee, which must remain a complete employee
+var EmployeePatchRules = append(RuleSet[Employee]{
+	{Field: "ID", Value: func(e *Employee) interface{} { return e.ID }, Checks: []Check{Required(), Range(1, math.MaxInt32)}},
+}, EmployeeCreateRules...)
 
-	return nil
+// Normalize cleans up fields before they are validated and stored
+func (e *Employee) Normalize() {
+	e.Name = strings.TrimSpace(e.Name)
+	e.Position = strings.TrimSpace(e.Position)
+	e.Department = strings.TrimSpace(e.Department)
+	e.Email = strings.ToLower(strings.TrimSpace(e.Email))
+	e.Currency = Currency(strings.ToUpper(strings.TrimSpace(string(e.Currency))))
 }
 
-func (e *Employee) CheckId() error {
-	// Check if all fields are present
-	if e.ID < 1 {
-		return errors.New("all fields are mandatory")
-	}
+// Validate normalizes the employee and checks it against rules
+func (e *Employee) Validate(rules RuleSet[Employee]) ValidationErrors {
+	e.Normalize()
+	return rules.Validate(e)
+}
 
-	return nil
+// ApplyDefaults fills in the profile fields a new employee starts with
+func (e *Employee) ApplyDefaults() {
+	if e.EmploymentType == "" {
+		e.EmploymentType = EmploymentFullTime
+	}
+	if e.Status == "" {
+		e.Status = StatusActive
+	}
+	if e.Currency == "" {
+		e.Currency = DefaultCurrency
+	}
+	if e.CustomFields == nil {
+		e.CustomFields = CustomFields{}
+	}
 }
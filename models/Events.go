@@ -0,0 +1,25 @@
+package models
+
+import "time"
+
+// EmployeeEventType is the kind of change an EmployeeEvent reports
+type EmployeeEventType string
+
+const (
+	EmployeeCreated EmployeeEventType = "created"
+	EmployeeUpdated EmployeeEventType = "updated"
+	EmployeeDeleted EmployeeEventType = "deleted"
+)
+
+// EmployeeEventTypes are the event types in the order they are documented
+var EmployeeEventTypes = []EmployeeEventType{EmployeeCreated, EmployeeUpdated, EmployeeDeleted}
+
+// EmployeeEvent reports a change to an employee, Employee is the stored record and nil after a delete.
+// ID orders the events of the event log, it is 0 for events that could not be logged.
+type EmployeeEvent struct {
+	ID         int64             `json:"id"`
+	Type       EmployeeEventType `json:"type"`
+	EmployeeID int               `json:"employeeId"`
+	Employee   *Employee         `json:"employee,omitempty"`
+	Time       time.Time         `json:"time"`
+}
@@ -0,0 +1,316 @@
+package models
+
+import (
+	"database/sql/driver"
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+	"math/big"
+	"strings"
+	"time"
+)
+
+// RateScale is the precision of exchange rates, the eight decimals of the NUMERIC(18, 8) column
+const RateScale = 100000000
+
+// MaxRate is the first rate a NUMERIC(18, 8) column cannot hold
+const MaxRate = 10000000000
+
+// Rate is an exact exchange rate with eight decimals, written to JSON as a number like 1.0834
+type Rate struct {
+	scaled int64
+}
+
+// ParseRate reads a decimal number like 1.0834, rounding beyond eight decimals half away from zero
+func ParseRate(s string) (Rate, error) {
+	r, ok := new(big.Rat).SetString(strings.TrimSpace(s))
+	if !ok {
+		return Rate{}, fmt.Errorf("%q is not a decimal number", s)
+	}
+	scaled, ok := roundRat(r.Mul(r, big.NewRat(RateScale, 1)))
+	if !ok {
+		return Rate{}, fmt.Errorf("%q is out of range", s)
+	}
+	return Rate{scaled: scaled}, nil
+}
+
+// MustParseRate is like ParseRate but panics if s is not a number, for constants
+func MustParseRate(s string) Rate {
+	r, err := ParseRate(s)
+	if err != nil {
+		panic(err)
+	}
+	return r
+}
+
+// rat returns r as a fraction
+func (r Rate) rat() *big.Rat {
+	return big.NewRat(r.scaled, RateScale)
+}
+
+// Float64 returns the nearest float to r, for checks and APIs that only know float numbers
+func (r Rate) Float64() float64 {
+	return float64(r.scaled) / RateScale
+}
+
+// String writes r without trailing zeros like 1.0834
+func (r Rate) String() string {
+	sign, scaled := "", r.scaled
+	if scaled < 0 {
+		sign, scaled = "-", -scaled
+	}
+	s := fmt.Sprintf("%s%d.%08d", sign, scaled/RateScale, scaled%RateScale)
+	return strings.TrimSuffix(strings.TrimRight(s, "0"), ".")
+}
+
+// MarshalJSON writes r as a JSON number
+func (r Rate) MarshalJSON() ([]byte, error) {
+	return []byte(r.String()), nil
+}
+
+// UnmarshalJSON reads a JSON number, null leaves r unchanged
+func (r *Rate) UnmarshalJSON(data []byte) error {
+	if string(data) == "null" {
+		return nil
+	}
+	if len(data) == 0 || data[0] == '"' {
+		return fmt.Errorf("cannot unmarshal %s into a rate, it must be a number", data)
+	}
+	parsed, err := ParseRate(string(data))
+	if err != nil {
+		return err
+	}
+	*r = parsed
+	return nil
+}
+
+// Value stores r as a decimal string
+func (r Rate) Value() (driver.Value, error) {
+	return r.String(), nil
+}
+
+func (r *Rate) Scan(src interface{}) error {
+	var (
+		parsed Rate
+		err    error
+	)
+	switch v := src.(type) {
+	case int64:
+		parsed = Rate{scaled: v * RateScale}
+	case float64:
+		parsed, err = ParseRate(fmt.Sprintf("%.8f", v))
+	case []byte:
+		parsed, err = ParseRate(string(v))
+	case string:
+		parsed, err = ParseRate(v)
+	default:
+		err = fmt.Errorf("cannot scan %T into a rate", src)
+	}
+	if err != nil {
+		return err
+	}
+	*r = parsed
+	return nil
+}
+
+// ExchangeRate is what one unit of Currency is worth in the base currency from EffectiveDate until the
+// next rate of the currency
+type ExchangeRate struct {
+	Currency      Currency  `json:"currency"`
+	Rate          Rate      `json:"rate"`
+	EffectiveDate Date      `json:"effectiveDate"`
+	UpdatedAt     time.Time `json:"updatedAt"`
+}
+
+// Normalize cleans up fields before they are validated and stored
+func (r *ExchangeRate) Normalize() {
+	r.Currency = Currency(strings.ToUpper(strings.TrimSpace(string(r.Currency))))
+}
+
+// ExchangeRateRules apply to exchange rates, whether posted or uploaded
+var ExchangeRateRules = RuleSet[ExchangeRate]{
+	{Field: "currency", Value: func(r *ExchangeRate) interface{} { return r.Currency }, Checks: []Check{Required(), currencyCode}},
+	{Field: "rate", Value: func(r *ExchangeRate) interface{} { return r.Rate }, Checks: []Check{Required(), positiveRate}},
+	{Field: "effectiveDate", Value: func(r *ExchangeRate) interface{} { return r.EffectiveDate }, Checks: []Check{Required()}},
+}
+
+// Validate normalizes the rate and checks it against ExchangeRateRules. The base currency has no rate,
+// a unit of it is always worth 1.
+func (r *ExchangeRate) Validate(base Currency) ValidationErrors {
+	r.Normalize()
+	errs := ExchangeRateRules.Validate(r)
+	if r.Currency != "" && r.Currency == base {
+		errs = append(errs, FieldError{Field: "currency", Code: CodeInvalidValue, Message: "currency must not be the base currency " + string(base)})
+	}
+	return errs
+}
+
+// positiveRate accepts rates a NUMERIC(18, 8) column holds, above 0
+var positiveRate = Check{Code: CodeOutOfRange, Message: fmt.Sprintf("must be above 0 and below %d", MaxRate), Valid: func(value interface{}) bool {
+	r := value.(Rate)
+	return r.scaled > 0 && r.scaled/RateScale < MaxRate
+}}
+
+// MaxExchangeRatesUpload is the number of rates a CSV upload may hold
+const MaxExchangeRatesUpload = 10000
+
+// exchangeRateColumns are the columns of an exchange rate CSV upload
+var exchangeRateColumns = []string{"currency", "rate", "effectiveDate"}
+
+// ParseExchangeRatesCSV reads exchange rates quoted in base from CSV with a header row naming the
+// currency, rate and effectiveDate columns in any order. Every rate must be valid, the problems of all
+// lines are reported as fields like lines[3].rate, counting the header as line 1.
+func ParseExchangeRatesCSV(r io.Reader, base Currency) ([]ExchangeRate, ValidationErrors) {
+	reader := csv.NewReader(r)
+	reader.TrimLeadingSpace = true
+	header, err := reader.Read()
+	if errors.Is(err, io.EOF) {
+		return nil, ValidationErrors{{Field: "csv", Code: CodeRequired, Message: "csv must have a header row and at least one rate"}}
+	}
+	if err != nil {
+		return nil, ValidationErrors{{Field: "csv", Code: CodeInvalidFormat, Message: "csv " + err.Error()}}
+	}
+	index := map[string]int{}
+	for i, name := range header {
+		index[strings.TrimSpace(name)] = i
+	}
+	var errs ValidationErrors
+	for _, name := range exchangeRateColumns {
+		if _, ok := index[name]; !ok {
+			errs = append(errs, FieldError{Field: "csv", Code: CodeRequired, Message: "csv must have a " + name + " column"})
+		}
+	}
+	if len(errs) > 0 {
+		return nil, errs
+	}
+
+	var rates []ExchangeRate
+	for line := 2; ; line++ {
+		record, err := reader.Read()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		prefix := fmt.Sprintf("lines[%d].", line)
+		if err != nil {
+			return nil, append(errs, FieldError{Field: "csv", Code: CodeInvalidFormat, Message: "csv " + err.Error()})
+		}
+		if len(rates) == MaxExchangeRatesUpload {
+			return nil, ValidationErrors{{Field: "csv", Code: CodeOutOfRange,
+				Message: fmt.Sprintf("csv must hold at most %d rates", MaxExchangeRatesUpload)}}
+		}
+
+		rate := ExchangeRate{Currency: Currency(record[index["currency"]])}
+		// fields that do not parse are reported once, not as missing as well
+		unparsed := map[string]bool{}
+		if value := strings.TrimSpace(record[index["rate"]]); value != "" {
+			if rate.Rate, err = ParseRate(value); err != nil {
+				unparsed["rate"] = true
+				errs = append(errs, FieldError{Field: prefix + "rate", Code: CodeInvalidFormat, Message: prefix + "rate must be a decimal number"})
+			}
+		}
+		if value := strings.TrimSpace(record[index["effectiveDate"]]); value != "" {
+			if rate.EffectiveDate, err = ParseDate(value); err != nil {
+				unparsed["effectiveDate"] = true
+				errs = append(errs, FieldError{Field: prefix + "effectiveDate", Code: CodeInvalidFormat,
+					Message: prefix + "effectiveDate must be a date like 2006-01-02"})
+			}
+		}
+		for _, fieldErr := range rate.Validate(base) {
+			if unparsed[fieldErr.Field] {
+				continue
+			}
+			errs = append(errs, FieldError{Field: prefix + fieldErr.Field, Code: fieldErr.Code, Message: prefix + fieldErr.Message})
+		}
+		rates = append(rates, rate)
+	}
+	if len(rates) == 0 && len(errs) == 0 {
+		errs = append(errs, FieldError{Field: "csv", Code: CodeRequired, Message: "csv must have a header row and at least one rate"})
+	}
+	return rates, errs
+}
+
+// ExchangeRateFilter selects exchange rates, zero fields match every rate
+type ExchangeRateFilter struct {
+	Currency Currency
+	// Until only matches rates effective on or before the day
+	Until *Date
+}
+
+// MissingRateError is returned when an amount cannot be exchanged for lack of a rate
+type MissingRateError struct {
+	Currency Currency
+	On       Date
+}
+
+func (e *MissingRateError) Error() string {
+	return fmt.Sprintf("no exchange rate for %s on or before %s", e.Currency, e.On)
+}
+
+// Converter exchanges amounts between currencies with the rates effective on a day
+type Converter struct {
+	Base  Currency
+	On    Date
+	rates map[Currency]ExchangeRate
+}
+
+// NewConverter picks the latest of rates, which are quoted in base, effective on or before on for every
+// currency
+func NewConverter(base Currency, on Date, rates []ExchangeRate) Converter {
+	c := Converter{Base: base, On: on, rates: map[Currency]ExchangeRate{}}
+	for _, rate := range rates {
+		if rate.EffectiveDate.After(on.Time) {
+			continue
+		}
+		if current, ok := c.rates[rate.Currency]; !ok || rate.EffectiveDate.After(current.EffectiveDate.Time) {
+			c.rates[rate.Currency] = rate
+		}
+	}
+	return c
+}
+
+// rate returns what a unit of currency is worth in the base currency
+func (c Converter) rate(currency Currency) (*big.Rat, error) {
+	if currency == c.Base {
+		return big.NewRat(1, 1), nil
+	}
+	rate, ok := c.rates[currency]
+	if !ok {
+		return nil, &MissingRateError{Currency: currency, On: c.On}
+	}
+	return rate.Rate.rat(), nil
+}
+
+// Exchange converts amount from one currency to another through the base currency, rounded to the cent
+// once. It returns a *MissingRateError if either currency has no rate, and an *OverflowError if the
+// result is too large for an amount.
+func (c Converter) Exchange(amount Money, from, to Currency) (Money, error) {
+	if from == to {
+		return amount, nil
+	}
+	fromRate, err := c.rate(from)
+	if err != nil {
+		return Money{}, err
+	}
+	toRate, err := c.rate(to)
+	if err != nil {
+		return Money{}, err
+	}
+	exchanged, ok := amount.scaleChecked(fromRate.Quo(fromRate, toRate))
+	if !ok {
+		return Money{}, &OverflowError{What: amount.String() + " " + string(from) + " in " + string(to)}
+	}
+	return exchanged, nil
+}
+
+// Used lists the rates of the given currencies in the order given, the base currency and currencies
+// without a rate are left out
+func (c Converter) Used(currencies []Currency) []ExchangeRate {
+	used := []ExchangeRate{}
+	for _, currency := range currencies {
+		if rate, ok := c.rates[currency]; ok {
+			used = append(used, rate)
+		}
+	}
+	return used
+}
@@ -0,0 +1,123 @@
+package models
+
+import "time"
+
+// HeadcountInterval is the length of the periods of a headcount trend
+type HeadcountInterval string
+
+const (
+	IntervalMonth   HeadcountInterval = "month"
+	IntervalQuarter HeadcountInterval = "quarter"
+	IntervalYear    HeadcountInterval = "year"
+)
+
+// IsValid reports whether i is a known interval
+func (i HeadcountInterval) IsValid() bool {
+	switch i {
+	case IntervalMonth, IntervalQuarter, IntervalYear:
+		return true
+	}
+	return false
+}
+
+// months is the number of months in a period of the interval
+func (i HeadcountInterval) months() int {
+	switch i {
+	case IntervalQuarter:
+		return 3
+	case IntervalYear:
+		return 12
+	}
+	return 1
+}
+
+// periodStart returns the first day of the period of the interval day falls in
+func (i HeadcountInterval) periodStart(day Date) Date {
+	month := (int(day.Month())-1)/i.months()*i.months() + 1
+	return NewDate(time.Date(day.Year(), time.Month(month), 1, 0, 0, 0, 0, time.UTC))
+}
+
+// MaxHeadcountPoints bounds the periods of a headcount trend
+const MaxHeadcountPoints = 120
+
+// HeadcountQuery selects the days and periods of a headcount trend
+type HeadcountQuery struct {
+	From     Date
+	To       Date
+	Interval HeadcountInterval
+}
+
+// HeadcountQueryRules apply to headcount trend queries
+var HeadcountQueryRules = RuleSet[HeadcountQuery]{
+	{Field: "interval", Value: func(q *HeadcountQuery) interface{} { return q.Interval },
+		Checks: []Check{OneOf(string(IntervalMonth), string(IntervalQuarter), string(IntervalYear))}},
+	{Field: "to", Value: func(q *HeadcountQuery) interface{} { return q }, Checks: []Check{toAfterFrom, fewPoints}},
+}
+
+// toAfterFrom rejects ranges that end before they start
+var toAfterFrom = Check{Code: CodeOutOfRange, Message: "must not be before from", Valid: func(value interface{}) bool {
+	q := value.(*HeadcountQuery)
+	return !q.To.Before(q.From.Time)
+}}
+
+// fewPoints keeps a trend to MaxHeadcountPoints periods
+var fewPoints = Check{Code: CodeOutOfRange, Message: "must be less than " + formatNumber(MaxHeadcountPoints) + " periods after from",
+	Valid: func(value interface{}) bool {
+		q := value.(*HeadcountQuery)
+		return !q.Interval.IsValid() ||
+			q.To.Before(q.Interval.periodStart(q.From).AddDate(0, MaxHeadcountPoints*q.Interval.months(), 0))
+	}}
+
+// HeadcountPoint counts the employees employed on the last day of a period, and the hires and
+// terminations in it. The first and last periods are cut to the days of the trend.
+type HeadcountPoint struct {
+	Start        Date `json:"start"`
+	End          Date `json:"end"`
+	Headcount    int  `json:"headcount"`
+	Hires        int  `json:"hires"`
+	Terminations int  `json:"terminations"`
+}
+
+// HeadcountTrend is the headcount of the periods From to To by hire and termination dates
+type HeadcountTrend struct {
+	From        Date              `json:"from"`
+	To          Date              `json:"to"`
+	Interval    HeadcountInterval `json:"interval"`
+	Points      []HeadcountPoint  `json:"points"`
+	GeneratedAt time.Time         `json:"generatedAt"`
+}
+
+// NewHeadcountTrend counts employees by the periods of query. Employees without a hire date are counted
+// from the start, they are not counted as hires.
+func NewHeadcountTrend(query HeadcountQuery, employees []Employee) HeadcountTrend {
+	trend := HeadcountTrend{From: query.From, To: query.To, Interval: query.Interval, Points: []HeadcountPoint{},
+		GeneratedAt: time.Now()}
+	months := query.Interval.months()
+	for start := query.Interval.periodStart(query.From); !start.After(query.To.Time); start = NewDate(start.AddDate(0, months, 0)) {
+		point := HeadcountPoint{Start: start, End: NewDate(start.AddDate(0, months, -1))}
+		if point.Start.Before(query.From.Time) {
+			point.Start = query.From
+		}
+		if point.End.After(query.To.Time) {
+			point.End = query.To
+		}
+		for _, employee := range employees {
+			if employedOn(employee, point.End) {
+				point.Headcount++
+			}
+			if within(employee.HireDate, point.Start, point.End) {
+				point.Hires++
+			}
+			if within(employee.TerminationDate, point.Start, point.End) {
+				point.Terminations++
+			}
+		}
+		trend.Points = append(trend.Points, point)
+	}
+	return trend
+}
+
+// within reports whether day is set and falls between from and to
+func within(day *Date, from, to Date) bool {
+	return day != nil && !day.Before(from.Time) && !day.After(to.Time)
+}
@@ -0,0 +1,26 @@
+package models
+
+import "time"
+
+// IdempotencyRecord remembers the response to a request sent with an Idempotency-Key,
+// so a retried request gets the same response instead of being executed again.
+type IdempotencyRecord struct {
+	// Scope separates the keys of different callers
+	Scope string
+	Key   string
+	// Fingerprint identifies the request, reusing a key for another request is an error
+	Fingerprint string
+
+	// StatusCode is 0 while the original request is still being handled
+	StatusCode  int
+	ContentType string
+	Body        []byte
+
+	CreatedAt time.Time
+	ExpiresAt time.Time
+}
+
+// Completed reports whether the response of the original request has been stored
+func (r *IdempotencyRecord) Completed() bool {
+	return r.StatusCode != 0
+}
@@ -0,0 +1,250 @@
+package models
+
+import (
+	"math"
+	"strings"
+	"time"
+)
+
+// MaxLeaveDays is the first number of days a NUMERIC(6, 2) column cannot hold
+const MaxLeaveDays = 10000
+
+// LeaveAccrual is how an employee earns days of a leave type
+type LeaveAccrual string
+
+const (
+	// AccrualMonthly credits DaysPerPeriod at the end of every full month of service
+	AccrualMonthly LeaveAccrual = "monthly"
+	// AccrualYearly credits DaysPerPeriod at the start of every year of service, the first year on the first day
+	AccrualYearly LeaveAccrual = "yearly"
+	// AccrualUnlimited has no balance, requests are never rejected for lack of days
+	AccrualUnlimited LeaveAccrual = "unlimited"
+)
+
+// IsValid reports whether a is one of the known accrual policies
+func (a LeaveAccrual) IsValid() bool {
+	switch a {
+	case AccrualMonthly, AccrualYearly, AccrualUnlimited:
+		return true
+	}
+	return false
+}
+
+// LeaveRequestStatus is where a leave request stands in the approval workflow
+type LeaveRequestStatus string
+
+const (
+	LeavePending   LeaveRequestStatus = "pending"
+	LeaveApproved  LeaveRequestStatus = "approved"
+	LeaveRejected  LeaveRequestStatus = "rejected"
+	LeaveCancelled LeaveRequestStatus = "cancelled"
+)
+
+// IsValid reports whether s is one of the known leave request statuses
+func (s LeaveRequestStatus) IsValid() bool {
+	switch s {
+	case LeavePending, LeaveApproved, LeaveRejected, LeaveCancelled:
+		return true
+	}
+	return false
+}
+
+// LeaveType is a kind of leave, like vacation or sick leave, with the policy its days accrue by
+type LeaveType struct {
+	ID            int          `json:"id"`
+	Name          string       `json:"name"`
+	Accrual       LeaveAccrual `json:"accrual"`
+	DaysPerPeriod float64      `json:"daysPerPeriod"`
+	CreatedAt     time.Time    `json:"createdAt"`
+}
+
+// LeaveTypeRules apply to new leave types
+var LeaveTypeRules = RuleSet[LeaveType]{
+	{Field: "name", Value: func(t *LeaveType) interface{} { return t.Name }, Checks: []Check{Required(), MaxLength(MaxTextLength)}},
+	{Field: "accrual", Value: func(t *LeaveType) interface{} { return t.Accrual }, Checks: []Check{Required(),
+		OneOf(string(AccrualMonthly), string(AccrualYearly), string(AccrualUnlimited))}},
+	{Field: "daysPerPeriod", Value: func(t *LeaveType) interface{} { return t.DaysPerPeriod }, Checks: []Check{Range(0.01, MaxLeaveDays)}},
+	{Field: "daysPerPeriod", Value: func(t *LeaveType) interface{} { return t }, Checks: []Check{accruedDays}},
+}
+
+// accruedDays requires days on the policies that accrue them and forbids them on unlimited leave
+var accruedDays = Check{Code: CodeInvalidValue, Message: "is required unless accrual is unlimited, where it is not allowed", Valid: func(value interface{}) bool {
+	t := value.(*LeaveType)
+	return !t.Accrual.IsValid() || (t.Accrual == AccrualUnlimited) == (t.DaysPerPeriod == 0)
+}}
+
+// Validate trims the name and checks the leave type against LeaveTypeRules
+func (t *LeaveType) Validate() ValidationErrors {
+	t.Name = strings.TrimSpace(t.Name)
+	return LeaveTypeRules.Validate(t)
+}
+
+// Accrued returns the days earned from the start of service until on, both included
+func (t LeaveType) Accrued(serviceStart, on Date) float64 {
+	if on.Before(serviceStart.Time) {
+		return 0
+	}
+	months := (on.Year()-serviceStart.Year())*12 + int(on.Month()-serviceStart.Month())
+	if on.Day() < serviceStart.Day() {
+		months--
+	}
+	switch t.Accrual {
+	case AccrualMonthly:
+		return roundDays(float64(months) * t.DaysPerPeriod)
+	case AccrualYearly:
+		return roundDays(float64(months/12+1) * t.DaysPerPeriod)
+	}
+	return 0
+}
+
+// LeaveRequest asks for the working days from StartDate to EndDate off. Days is set by the server.
+type LeaveRequest struct {
+	ID           int                `json:"id"`
+	EmployeeID   int                `json:"employeeId"`
+	LeaveTypeID  int                `json:"leaveTypeId"`
+	StartDate    Date               `json:"startDate"`
+	EndDate      Date               `json:"endDate"`
+	Days         float64            `json:"days"`
+	Reason       string             `json:"reason"`
+	Status       LeaveRequestStatus `json:"status"`
+	DecidedBy    string             `json:"decidedBy"`
+	DecisionNote string             `json:"decisionNote"`
+	DecidedAt    *time.Time         `json:"decidedAt"`
+	CreatedAt    time.Time          `json:"createdAt"`
+}
+
+// LeaveRequestRules apply to new leave requests
+var LeaveRequestRules = RuleSet[LeaveRequest]{
+	{Field: "leaveTypeId", Value: func(r *LeaveRequest) interface{} { return r.LeaveTypeID }, Checks: []Check{Required(), Range(1, math.MaxInt32)}},
+	{Field: "startDate", Value: func(r *LeaveRequest) interface{} { return r.StartDate }, Checks: []Check{Required()}},
+	{Field: "endDate", Value: func(r *LeaveRequest) interface{} { return r.EndDate }, Checks: []Check{Required()}},
+	{Field: "endDate", Value: func(r *LeaveRequest) interface{} { return r }, Checks: []Check{endAfterStart, withinAYear, workingDaysIncluded}},
+	{Field: "reason", Value: func(r *LeaveRequest) interface{} { return r.Reason }, Checks: []Check{MaxLength(1000)}},
+}
+
+// endAfterStart rejects ranges that end before they start, either date may be unset
+var endAfterStart = Check{Code: CodeOutOfRange, Message: "must not be before startDate", Valid: func(value interface{}) bool {
+	r := value.(*LeaveRequest)
+	return r.StartDate.IsZero() || r.EndDate.IsZero() || !r.EndDate.Before(r.StartDate.Time)
+}}
+
+// withinAYear keeps a request to a year, longer absences are not leave
+var withinAYear = Check{Code: CodeOutOfRange, Message: "must be less than a year after startDate", Valid: func(value interface{}) bool {
+	r := value.(*LeaveRequest)
+	return r.StartDate.IsZero() || r.EndDate.Before(r.StartDate.AddDate(1, 0, 0))
+}}
+
+// workingDaysIncluded rejects ranges that fall on a weekend only
+var workingDaysIncluded = Check{Code: CodeOutOfRange, Message: "must leave a working day between startDate and endDate", Valid: func(value interface{}) bool {
+	r := value.(*LeaveRequest)
+	return r.StartDate.IsZero() || r.EndDate.IsZero() || WorkingDays(r.StartDate, r.EndDate) > 0
+}}
+
+// Validate trims the reason, checks the request against LeaveRequestRules and counts its days
+func (r *LeaveRequest) Validate() ValidationErrors {
+	r.Reason = strings.TrimSpace(r.Reason)
+	errs := LeaveRequestRules.Validate(r)
+	if len(errs) == 0 {
+		r.Days = WorkingDays(r.StartDate, r.EndDate)
+	}
+	return errs
+}
+
+// LeaveDecision is the note left with an approval, rejection or cancellation, it may be empty
+type LeaveDecision struct {
+	Note string `json:"note"`
+}
+
+// LeaveDecisionRules apply to the note of a decision
+var LeaveDecisionRules = RuleSet[LeaveDecision]{
+	{Field: "note", Value: func(d *LeaveDecision) interface{} { return d.Note }, Checks: []Check{MaxLength(1000)}},
+}
+
+// Overlaps reports whether the two requests share a day
+func (r LeaveRequest) Overlaps(other LeaveRequest) bool {
+	return !r.EndDate.Before(other.StartDate.Time) && !other.EndDate.Before(r.StartDate.Time)
+}
+
+// Covers reports whether day falls within the request
+func (r LeaveRequest) Covers(day Date) bool {
+	return !day.Before(r.StartDate.Time) && !day.After(r.EndDate.Time)
+}
+
+// Holds reports whether the request takes days from the balance, which pending and approved requests do
+func (r LeaveRequest) Holds() bool {
+	return r.Status == LeavePending || r.Status == LeaveApproved
+}
+
+// CanBecome reports whether the workflow lets the request move to status on the day today. Pending
+// requests are approved, rejected or cancelled, approved ones can be cancelled until the leave starts.
+func (r LeaveRequest) CanBecome(status LeaveRequestStatus, today Date) bool {
+	switch r.Status {
+	case LeavePending:
+		return status == LeaveApproved || status == LeaveRejected || status == LeaveCancelled
+	case LeaveApproved:
+		return status == LeaveCancelled && today.Before(r.StartDate.Time)
+	}
+	return false
+}
+
+// WorkingDays counts the days from start to end, both included, that fall on Monday to Friday
+func WorkingDays(start, end Date) float64 {
+	days := 0
+	for day := start.Time; !day.After(end.Time); day = day.AddDate(0, 0, 1) {
+		if day.Weekday() != time.Saturday && day.Weekday() != time.Sunday {
+			days++
+		}
+	}
+	return float64(days)
+}
+
+// LeaveBalance is what an employee has of one leave type. Available is what accrued minus the
+// approved days, pending requests are listed apart and not deducted yet.
+type LeaveBalance struct {
+	LeaveTypeID int          `json:"leaveTypeId"`
+	LeaveType   string       `json:"leaveType"`
+	Accrual     LeaveAccrual `json:"accrual"`
+	Accrued     float64      `json:"accrued"`
+	Used        float64      `json:"used"`
+	Pending     float64      `json:"pending"`
+	Available   float64      `json:"available"`
+}
+
+// Limited reports whether requests are checked against the balance
+func (b LeaveBalance) Limited() bool {
+	return b.Accrual != AccrualUnlimited
+}
+
+// NewLeaveBalance computes the balance of a leave type on a day from the employee's requests, requests
+// of other types are ignored
+func NewLeaveBalance(t LeaveType, serviceStart, on Date, requests []LeaveRequest) LeaveBalance {
+	balance := LeaveBalance{LeaveTypeID: t.ID, LeaveType: t.Name, Accrual: t.Accrual, Accrued: t.Accrued(serviceStart, on)}
+	for _, request := range requests {
+		if request.LeaveTypeID != t.ID {
+			continue
+		}
+		switch request.Status {
+		case LeaveApproved:
+			balance.Used += request.Days
+		case LeavePending:
+			balance.Pending += request.Days
+		}
+	}
+	if balance.Limited() {
+		balance.Available = roundDays(balance.Accrued - balance.Used)
+	}
+	return balance
+}
+
+// ServiceStart is the day leave starts to accrue, the hire date or else the day the employee was added
+func (e Employee) ServiceStart() Date {
+	if e.HireDate != nil {
+		return *e.HireDate
+	}
+	return NewDate(e.CreatedAt)
+}
+
+// roundDays keeps days to the hundredths a NUMERIC(6, 2) column stores
+func roundDays(days float64) float64 {
+	return math.Round(days*100) / 100
+}
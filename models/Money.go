@@ -0,0 +1,267 @@
+package models
+
+import (
+	"database/sql/driver"
+	"fmt"
+	"math"
+	"math/big"
+	"strconv"
+	"strings"
+)
+
+// Money is an exact amount in hundredths of a currency unit, the precision of the NUMERIC(10, 2) salary
+// column. It is written to JSON as a number like 1234.50 and read from JSON numbers and SQL values
+// without going through float64, so amounts add up to the cent. Amounts with fractions of a cent are
+// rejected rather than rounded. The zero value is 0.00.
+type Money struct {
+	cents int64
+}
+
+// MoneyFromCents returns the amount of cents hundredths
+func MoneyFromCents(cents int64) Money {
+	return Money{cents: cents}
+}
+
+// MoneyFromFloat returns f rounded to the cent, for floats read from the database
+func MoneyFromFloat(f float64) Money {
+	return Money{cents: int64(math.Round(f * 100))}
+}
+
+// ExactMoney returns f as an amount for APIs that only know float numbers. f is taken as the shortest
+// decimal that prints it, so 0.07 is 7 cents, and must be a whole number of cents.
+func ExactMoney(f float64) (Money, error) {
+	if math.IsNaN(f) || math.IsInf(f, 0) {
+		return Money{}, &AmountError{Code: CodeInvalidType, Message: "must be a number"}
+	}
+	return ParseMoney(strconv.FormatFloat(f, 'f', -1, 64))
+}
+
+// AmountError is an amount that cannot be held exactly, Code is the validation code to report it with
+type AmountError struct {
+	Code    string
+	Message string
+}
+
+func (e *AmountError) Error() string {
+	return "amount " + e.Message
+}
+
+// FieldError reports e as a validation error of field
+func (e *AmountError) FieldError(field string) FieldError {
+	return FieldError{Field: field, Code: e.Code, Message: field + " " + e.Message}
+}
+
+// ParseMoney reads a decimal number like 1234.5 or -3. Numbers with fractions of a cent or too large
+// for an amount are rejected with an *AmountError.
+func ParseMoney(s string) (Money, error) {
+	r, ok := new(big.Rat).SetString(strings.TrimSpace(s))
+	if !ok {
+		return Money{}, fmt.Errorf("%q is not a decimal number", s)
+	}
+	r.Mul(r, big.NewRat(100, 1))
+	if !r.IsInt() {
+		return Money{}, &AmountError{Code: CodeInvalidValue, Message: "must not have fractions of a cent"}
+	}
+	if !r.Num().IsInt64() {
+		return Money{}, &AmountError{Code: CodeOutOfRange, Message: "is too large"}
+	}
+	return Money{cents: r.Num().Int64()}, nil
+}
+
+// OverflowError is a computed amount that is too large to be stored or computed, What describes it
+type OverflowError struct {
+	What   string
+	Amount Money
+	Max    Money
+}
+
+func (e *OverflowError) Error() string {
+	if e.Amount.IsZero() {
+		return e.What + " is too large to compute"
+	}
+	return e.What + " of " + e.Amount.String() + " must be below " + e.Max.String()
+}
+
+// MustParseMoney is like ParseMoney but panics if s is not an amount, for constants
+func MustParseMoney(s string) Money {
+	m, err := ParseMoney(s)
+	if err != nil {
+		panic(err)
+	}
+	return m
+}
+
+// Cents returns the amount in hundredths
+func (m Money) Cents() int64 {
+	return m.cents
+}
+
+// IsZero reports whether m is 0.00
+func (m Money) IsZero() bool {
+	return m.cents == 0
+}
+
+// Float64 returns the nearest float to m, for APIs that only know float numbers
+func (m Money) Float64() float64 {
+	return float64(m.cents) / 100
+}
+
+// String writes m with two decimals like 1234.50
+func (m Money) String() string {
+	sign, cents := "", m.cents
+	if cents < 0 {
+		sign, cents = "-", -cents
+	}
+	return fmt.Sprintf("%s%d.%02d", sign, cents/100, cents%100)
+}
+
+// Add returns m + o
+func (m Money) Add(o Money) Money {
+	return Money{cents: m.cents + o.cents}
+}
+
+// Sub returns m - o
+func (m Money) Sub(o Money) Money {
+	return Money{cents: m.cents - o.cents}
+}
+
+// Cmp returns -1, 0 or 1 as m is less than, equal to or greater than o
+func (m Money) Cmp(o Money) int {
+	switch {
+	case m.cents < o.cents:
+		return -1
+	case m.cents > o.cents:
+		return 1
+	}
+	return 0
+}
+
+// Mul returns m times factor rounded to the cent, factor is taken exactly as the float it is
+func (m Money) Mul(factor float64) Money {
+	return m.scale(new(big.Rat).SetFloat64(factor))
+}
+
+// MulDiv returns m * n / d rounded to the cent, d must not be 0
+func (m Money) MulDiv(n, d int64) Money {
+	return m.scale(big.NewRat(n, d))
+}
+
+// Percent returns percent of m rounded to the cent
+func (m Money) Percent(percent float64) Money {
+	r := new(big.Rat).SetFloat64(percent)
+	return m.scale(r.Quo(r, big.NewRat(100, 1)))
+}
+
+// scale multiplies m by r rounding half away from zero, nil r like a NaN factor gives 0. Products
+// too large for an amount give 0 as well, callers whose factors are not bounded use scaleChecked.
+func (m Money) scale(r *big.Rat) Money {
+	scaled, _ := m.scaleChecked(r)
+	return scaled
+}
+
+// scaleChecked is scale reporting whether the product fits an amount
+func (m Money) scaleChecked(r *big.Rat) (Money, bool) {
+	if r == nil {
+		return Money{}, true
+	}
+	cents, ok := roundRat(r.Mul(r, new(big.Rat).SetInt64(m.cents)))
+	if !ok {
+		return Money{}, false
+	}
+	return Money{cents: cents}, true
+}
+
+// roundRat rounds r half away from zero, ok is false if the result does not fit an int64
+func roundRat(r *big.Rat) (int64, bool) {
+	quo, rem := new(big.Int).QuoRem(r.Num(), r.Denom(), new(big.Int))
+	if rem.Sign() != 0 && new(big.Int).Mul(new(big.Int).Abs(rem), big.NewInt(2)).Cmp(r.Denom()) >= 0 {
+		quo.Add(quo, big.NewInt(int64(rem.Sign())))
+	}
+	return quo.Int64(), quo.IsInt64()
+}
+
+// MarshalJSON writes m as a JSON number with two decimals
+func (m Money) MarshalJSON() ([]byte, error) {
+	return []byte(m.String()), nil
+}
+
+// UnmarshalJSON reads a JSON number, null leaves m unchanged
+func (m *Money) UnmarshalJSON(data []byte) error {
+	if string(data) == "null" {
+		return nil
+	}
+	if len(data) == 0 || data[0] == '"' {
+		return fmt.Errorf("cannot unmarshal %s into an amount, it must be a number", data)
+	}
+	parsed, err := ParseMoney(string(data))
+	if err != nil {
+		return err
+	}
+	*m = parsed
+	return nil
+}
+
+// UnmarshalText reads amounts of YAML and TOML settings
+func (m *Money) UnmarshalText(text []byte) error {
+	parsed, err := ParseMoney(string(text))
+	if err != nil {
+		return err
+	}
+	*m = parsed
+	return nil
+}
+
+// Value stores m as a decimal string, which NUMERIC columns take without rounding
+func (m Money) Value() (driver.Value, error) {
+	return m.String(), nil
+}
+
+func (m *Money) Scan(src interface{}) error {
+	switch v := src.(type) {
+	case int64:
+		*m = Money{cents: v * 100}
+	case float64:
+		*m = MoneyFromFloat(v)
+	case []byte:
+		return m.UnmarshalText(v)
+	case string:
+		return m.UnmarshalText([]byte(v))
+	default:
+		return fmt.Errorf("cannot scan %T into an amount", src)
+	}
+	return nil
+}
+
+// Currency is an ISO 4217 currency code like EUR
+type Currency string
+
+// DefaultCurrency is the base currency unless configured otherwise, and the currency of the salaries
+// stored before salaries had one
+const DefaultCurrency Currency = "USD"
+
+// IsValid reports whether c is an active ISO 4217 currency code
+func (c Currency) IsValid() bool {
+	return currencies[c]
+}
+
+// currencyCode accepts ISO 4217 currency codes
+var currencyCode = Check{Code: CodeInvalidValue, Message: "must be an ISO 4217 currency code like EUR", Valid: func(value interface{}) bool {
+	c := value.(Currency)
+	return c == "" || c.IsValid()
+}}
+
+// currencies are the active ISO 4217 codes
+var currencies = map[Currency]bool{}
+
+func init() {
+	for _, code := range strings.Fields(`
+        AED AFN ALL AMD ANG AOA ARS AUD AWG AZN BAM BBD BDT BGN BHD BIF BMD BND BOB BRL BSD BTN BWP BYN BZD
+        CAD CDF CHF CLP CNY COP CRC CUP CVE CZK DJF DKK DOP DZD EGP ERN ETB EUR FJD FKP GBP GEL GHS GIP GMD
+        GNF GTQ GYD HKD HNL HTG HUF IDR ILS INR IQD IRR ISK JMD JOD JPY KES KGS KHR KMF KPW KRW KWD KYD KZT
+        LAK LBP LKR LRD LSL LYD MAD MDL MGA MKD MMK MNT MOP MRU MUR MVR MWK MXN MYR MZN NAD NGN NIO NOK NPR
+        NZD OMR PAB PEN PGK PHP PKR PLN PYG QAR RON RSD RUB RWF SAR SBD SCR SDG SEK SGD SHP SLE SOS SRD SSP
+        STN SVC SYP SZL THB TJS TMT TND TOP TRY TTD TWD TZS UAH UGX USD UYU UZS VES VND VUV WST XAF XCD XOF
+        XPF YER ZAR ZMW ZWL`) {
+		currencies[Currency(code)] = true
+	}
+}
@@ -0,0 +1,38 @@
+package models
+
+import "time"
+
+// OutboxMessage is a logged employee event waiting to be published to the outbox sinks. It is written
+// in the transaction of the change it reports and removed once every sink accepted it.
+type OutboxMessage struct {
+	ID            int64         `json:"id"`
+	Event         EmployeeEvent `json:"event"`
+	Attempts      int           `json:"attempts"`
+	NextAttemptAt time.Time     `json:"nextAttemptAt"`
+	LastError     string        `json:"lastError"`
+	CreatedAt     time.Time     `json:"createdAt"`
+}
+
+// OutboxBacklog describes the messages not published yet, OldestCreatedAt is nil when there are none
+type OutboxBacklog struct {
+	Pending         int        `json:"pending"`
+	Failing         int        `json:"failing"`
+	OldestCreatedAt *time.Time `json:"oldestCreatedAt"`
+}
+
+// OutboxSinkMetrics counts the publish attempts of one sink since the server started
+type OutboxSinkMetrics struct {
+	Published       int64      `json:"published"`
+	Failed          int64      `json:"failed"`
+	LastError       string     `json:"lastError"`
+	LastPublishedAt *time.Time `json:"lastPublishedAt"`
+}
+
+// OutboxMetrics describes the outbox relay of this instance and the backlog shared by all instances
+type OutboxMetrics struct {
+	Backlog OutboxBacklog `json:"backlog"`
+	// Published counts the messages every sink accepted, Retried those scheduled for another attempt
+	Published int64                        `json:"published"`
+	Retried   int64                        `json:"retried"`
+	Sinks     map[string]OutboxSinkMetrics `json:"sinks"`
+}
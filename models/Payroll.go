@@ -0,0 +1,465 @@
+package models
+
+import (
+	"database/sql/driver"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"time"
+)
+
+// PayPeriodLayout is how pay periods are written, a calendar month
+const PayPeriodLayout = "2006-01"
+
+// PayPeriod is the calendar month a payroll run pays, written like 2024-07
+type PayPeriod string
+
+// ParsePayPeriod parses a "2006-01" month
+func ParsePayPeriod(s string) (PayPeriod, error) {
+	t, err := time.Parse(PayPeriodLayout, s)
+	if err != nil {
+		return "", fmt.Errorf("%q is not a month like 2006-01", s)
+	}
+	return PayPeriod(t.Format(PayPeriodLayout)), nil
+}
+
+// IsValid reports whether p is a month like 2006-01
+func (p PayPeriod) IsValid() bool {
+	parsed, err := ParsePayPeriod(string(p))
+	return err == nil && parsed == p
+}
+
+// Start is the first day of the period, the zero date for an invalid period
+func (p PayPeriod) Start() Date {
+	t, _ := time.Parse(PayPeriodLayout, string(p))
+	return Date{t}
+}
+
+// End is the last day of the period
+func (p PayPeriod) End() Date {
+	return Date{p.Start().AddDate(0, 1, -1)}
+}
+
+// Days is the number of days in the period
+func (p PayPeriod) Days() int {
+	return p.End().Day()
+}
+
+// SalaryBasis is the time Employee.Salary pays for. Contractors are paid their Salary per hour of their
+// approved timesheets whatever the basis.
+type SalaryBasis string
+
+const (
+	SalaryAnnual  SalaryBasis = "annual"
+	SalaryMonthly SalaryBasis = "monthly"
+)
+
+// IsValid reports whether b is one of the known salary bases
+func (b SalaryBasis) IsValid() bool {
+	switch b {
+	case SalaryAnnual, SalaryMonthly:
+		return true
+	}
+	return false
+}
+
+// PayComponentKind tells whether a pay component adds to or takes from the pay
+type PayComponentKind string
+
+const (
+	Allowance PayComponentKind = "allowance"
+	Deduction PayComponentKind = "deduction"
+)
+
+// IsValid reports whether k is one of the known pay component kinds
+func (k PayComponentKind) IsValid() bool {
+	switch k {
+	case Allowance, Deduction:
+		return true
+	}
+	return false
+}
+
+// PayComponent is an allowance or deduction on every payslip. It is either Percent of the base pay for
+// allowances and of the gross pay for deductions, or a fixed Amount of the base currency.
+type PayComponent struct {
+	Name    string           `json:"name"`
+	Kind    PayComponentKind `json:"kind"`
+	Percent float64          `json:"percent,omitempty"`
+	Amount  Money            `json:"amount,omitempty"`
+}
+
+// PayrollRules are what payslips are computed by, a run stores the rules it was computed with
+type PayrollRules struct {
+	SalaryBasis SalaryBasis    `json:"salaryBasis"`
+	Components  []PayComponent `json:"components"`
+	Overtime    OvertimeRules  `json:"overtime"`
+}
+
+// Value stores the rules as a JSON string, lib/pq would send []byte as bytea
+func (r PayrollRules) Value() (driver.Value, error) {
+	return jsonValue(r)
+}
+
+func (r *PayrollRules) Scan(src interface{}) error {
+	return scanJSON(src, r, "payroll rules")
+}
+
+// SalaryChange is the Salary an employee is paid from EffectiveFrom until the next change, the salary
+// history payroll runs are computed from
+type SalaryChange struct {
+	EmployeeID    int       `json:"employeeId"`
+	Salary        Money     `json:"salary"`
+	Currency      Currency  `json:"currency"`
+	EffectiveFrom Date      `json:"effectiveFrom"`
+	RecordedAt    time.Time `json:"recordedAt"`
+}
+
+// SalaryEffectiveFrom returns the day the stored Salary of the employee takes effect when it is recorded
+// on today. A salary is effective from today, or from the hire date if that is later or the employee was
+// just created, so payroll runs for the days before a change keep the salary paid on them.
+func (e Employee) SalaryEffectiveFrom(created bool, today Date) Date {
+	if e.HireDate != nil && (created || e.HireDate.After(today.Time)) {
+		return *e.HireDate
+	}
+	return today
+}
+
+// PaySegment is a stretch of the period paid at one Salary in its Currency. Salaried employees are paid
+// Days of their monthly salary, contractors the payable Hours of an approved timesheet at their hourly
+// rate. Amount is in the currency of the payslip.
+type PaySegment struct {
+	From     Date     `json:"from"`
+	To       Date     `json:"to"`
+	Salary   Money    `json:"salary"`
+	Currency Currency `json:"currency"`
+	Days     int      `json:"days,omitempty"`
+	Hours    float64  `json:"hours,omitempty"`
+	Amount   Money    `json:"amount"`
+}
+
+// PaySegments are stored as a JSON array in a single column
+type PaySegments []PaySegment
+
+// Value stores the segments as a JSON string
+func (s PaySegments) Value() (driver.Value, error) {
+	if s == nil {
+		return "[]", nil
+	}
+	return jsonValue(s)
+}
+
+func (s *PaySegments) Scan(src interface{}) error {
+	segments := PaySegments{}
+	if err := scanJSON(src, &segments, "pay segments"); err != nil {
+		return err
+	}
+	*s = segments
+	return nil
+}
+
+// PayslipLine is an allowance or deduction applied to a payslip
+type PayslipLine struct {
+	Name   string           `json:"name"`
+	Kind   PayComponentKind `json:"kind"`
+	Amount Money            `json:"amount"`
+}
+
+// PayslipLines are stored as a JSON array in a single column
+type PayslipLines []PayslipLine
+
+// Value stores the lines as a JSON string
+func (l PayslipLines) Value() (driver.Value, error) {
+	if l == nil {
+		return "[]", nil
+	}
+	return jsonValue(l)
+}
+
+func (l *PayslipLines) Scan(src interface{}) error {
+	lines := PayslipLines{}
+	if err := scanJSON(src, &lines, "payslip lines"); err != nil {
+		return err
+	}
+	*l = lines
+	return nil
+}
+
+// Payslip is the pay of one employee in a payroll run, in the Currency of the employee. BasePay sums the
+// segments, Gross adds the allowances and Net is Gross less the deductions. The employee's name and job
+// are kept as they were.
+type Payslip struct {
+	ID             int            `json:"id"`
+	RunID          int            `json:"runId"`
+	Period         PayPeriod      `json:"period"`
+	EmployeeID     int            `json:"employeeId"`
+	Name           string         `json:"name"`
+	Position       string         `json:"position"`
+	Department     string         `json:"department"`
+	EmploymentType EmploymentType `json:"employmentType"`
+	Currency       Currency       `json:"currency"`
+	Segments       PaySegments    `json:"segments"`
+	BasePay        Money          `json:"basePay"`
+	Lines          PayslipLines   `json:"lines"`
+	Gross          Money          `json:"gross"`
+	Deductions     Money          `json:"deductions"`
+	Net            Money          `json:"net"`
+}
+
+// PayrollRunStatus is where a payroll run stands, an approved run is locked
+type PayrollRunStatus string
+
+const (
+	PayrollDraft    PayrollRunStatus = "draft"
+	PayrollApproved PayrollRunStatus = "approved"
+)
+
+// MaxPayslipAmount is the first amount the NUMERIC(12, 2) payslip columns cannot hold, and
+// MaxPayrollTotal the first total the NUMERIC(14, 2) columns of a run cannot hold
+const (
+	MaxPayslipAmount = 10000000000
+	MaxPayrollTotal  = 1000000000000
+)
+
+// PayrollRun is the payroll of a period. A draft run is replaced when the period is run again, an
+// approved run can no longer change. Payslips are only read with a single run. The totals are in the base
+// Currency, exchanged at the rates of the last day of the period.
+type PayrollRun struct {
+	ID         int              `json:"id"`
+	Period     PayPeriod        `json:"period"`
+	Status     PayrollRunStatus `json:"status"`
+	Rules      PayrollRules     `json:"rules"`
+	Currency   Currency         `json:"currency"`
+	Employees  int              `json:"employees"`
+	Gross      Money            `json:"gross"`
+	Deductions Money            `json:"deductions"`
+	Net        Money            `json:"net"`
+	Payslips   []Payslip        `json:"payslips,omitempty"`
+	CreatedBy  string           `json:"createdBy"`
+	CreatedAt  time.Time        `json:"createdAt"`
+	ApprovedBy string           `json:"approvedBy"`
+	ApprovedAt *time.Time       `json:"approvedAt"`
+}
+
+// PayrollRunRequest names the period to run or preview
+type PayrollRunRequest struct {
+	Period PayPeriod `json:"period"`
+}
+
+// PayrollRunRequestRules apply to payroll run requests
+var PayrollRunRequestRules = RuleSet[PayrollRunRequest]{
+	{Field: "period", Value: func(r *PayrollRunRequest) interface{} { return string(r.Period) }, Checks: []Check{Required(), payPeriod}},
+}
+
+// payPeriod accepts months like 2006-01
+var payPeriod = Check{Code: CodeInvalidFormat, Message: "must be a month like 2006-01", Valid: func(value interface{}) bool {
+	s := value.(string)
+	return s == "" || PayPeriod(s).IsValid()
+}}
+
+// NewPayrollRun computes the draft payroll of period. Salaried employees are paid the salary of each day
+// they are employed in the period by history, which must be ordered by employee and effective date, as a
+// share of their monthly salary. Contractors are paid the payable hours of their approved timesheets of
+// the weeks starting in the period at the salary effective on the first day of the week. Employees with
+// nothing to be paid get no payslip. Amounts in other currencies than the employee's, fixed components
+// and the run totals are exchanged by converter, it returns a *MissingRateError if a rate is missing.
+// Payslip amounts and run totals too large for their columns fail the run with an *OverflowError.
+func NewPayrollRun(period PayPeriod, rules PayrollRules, employees []Employee, history []SalaryChange, timesheets []Timesheet,
+	converter Converter) (PayrollRun, error) {
+	run := PayrollRun{Period: period, Status: PayrollDraft, Rules: rules, Currency: converter.Base, Payslips: []Payslip{}}
+	changes := map[int][]SalaryChange{}
+	for _, change := range history {
+		changes[change.EmployeeID] = append(changes[change.EmployeeID], change)
+	}
+
+	sorted := append([]Employee(nil), employees...)
+	sort.Slice(sorted, func(i, j int) bool { return sorted[i].ID < sorted[j].ID })
+	for _, employee := range sorted {
+		employeeChanges := changes[employee.ID]
+		if len(employeeChanges) == 0 {
+			// employees without a recorded salary are paid the one they have now
+			employeeChanges = []SalaryChange{{EmployeeID: employee.ID, Salary: employee.Salary, Currency: employee.Currency}}
+		}
+		var segments PaySegments
+		if employee.EmploymentType == EmploymentContractor {
+			segments = contractorSegments(period, rules.Overtime, employee.ID, employeeChanges, timesheets)
+		} else {
+			segments = salarySegments(period, rules.SalaryBasis, employee, employeeChanges)
+		}
+		if len(segments) == 0 {
+			continue
+		}
+
+		payslip := Payslip{Period: period, EmployeeID: employee.ID, Name: employee.Name, Position: employee.Position,
+			Department: employee.Department, EmploymentType: employee.EmploymentType, Currency: employee.Currency,
+			Segments: segments, Lines: PayslipLines{}}
+		for i, segment := range segments {
+			amount, err := converter.Exchange(segment.Amount, segment.Currency, payslip.Currency)
+			if err != nil {
+				return run, err
+			}
+			payslip.Segments[i].Amount = amount
+			payslip.BasePay = payslip.BasePay.Add(amount)
+		}
+		if err := payslip.applyComponents(rules.Components, converter); err != nil {
+			return run, err
+		}
+		for _, amount := range []struct {
+			name  string
+			value Money
+		}{{"base pay", payslip.BasePay}, {"gross pay", payslip.Gross}, {"deductions", payslip.Deductions}} {
+			if err := checkBelow(fmt.Sprintf("%s of employee %d", amount.name, employee.ID), amount.value, MaxPayslipAmount); err != nil {
+				return run, err
+			}
+		}
+
+		run.Payslips = append(run.Payslips, payslip)
+		run.Employees++
+		for _, total := range []struct{ run, payslip *Money }{{&run.Gross, &payslip.Gross}, {&run.Deductions, &payslip.Deductions},
+			{&run.Net, &payslip.Net}} {
+			amount, err := converter.Exchange(*total.payslip, payslip.Currency, run.Currency)
+			if err != nil {
+				return run, err
+			}
+			*total.run = total.run.Add(amount)
+		}
+	}
+	for _, total := range []struct {
+		name  string
+		value Money
+	}{{"gross pay", run.Gross}, {"deductions", run.Deductions}} {
+		if err := checkBelow("total "+total.name+" of the run", total.value, MaxPayrollTotal); err != nil {
+			return run, err
+		}
+	}
+	return run, nil
+}
+
+// checkBelow returns an *OverflowError if amount is not below max units of its currency
+func checkBelow(what string, amount Money, max int64) error {
+	if limit := MoneyFromCents(max * 100); amount.Cmp(limit) >= 0 {
+		return &OverflowError{What: what, Amount: amount, Max: limit}
+	}
+	return nil
+}
+
+// salarySegments pays the days of the period the employee is employed on, between the hire and
+// termination dates, split where the salary changes. Amounts are in the currency of the salary.
+func salarySegments(period PayPeriod, basis SalaryBasis, employee Employee, changes []SalaryChange) PaySegments {
+	from, to := period.Start(), period.End()
+	if employee.HireDate != nil && employee.HireDate.After(from.Time) {
+		from = *employee.HireDate
+	}
+	if employee.TerminationDate != nil && employee.TerminationDate.Before(to.Time) {
+		to = *employee.TerminationDate
+	}
+
+	var segments PaySegments
+	for i, change := range changes {
+		start, end := from, to
+		// the first recorded salary is also paid before it was recorded
+		if i > 0 && change.EffectiveFrom.After(start.Time) {
+			start = change.EffectiveFrom
+		}
+		if i+1 < len(changes) {
+			if next := (Date{changes[i+1].EffectiveFrom.AddDate(0, 0, -1)}); next.Before(end.Time) {
+				end = next
+			}
+		}
+		if end.Before(start.Time) {
+			continue
+		}
+		months := int64(1)
+		if basis == SalaryAnnual {
+			months = 12
+		}
+		days := int(end.Sub(start.Time).Hours()/24) + 1
+		segments = append(segments, PaySegment{From: start, To: end, Salary: change.Salary, Currency: change.Currency, Days: days,
+			Amount: change.Salary.MulDiv(int64(days), months*int64(period.Days()))})
+	}
+	return segments
+}
+
+// contractorSegments pays the approved timesheets of the weeks starting in the period, one segment a week
+func contractorSegments(period PayPeriod, overtime OvertimeRules, employeeID int, changes []SalaryChange, timesheets []Timesheet) PaySegments {
+	var segments PaySegments
+	for _, timesheet := range timesheets {
+		if timesheet.EmployeeID != employeeID || timesheet.Status != TimesheetApproved ||
+			timesheet.WeekStart.Before(period.Start().Time) || timesheet.WeekStart.After(period.End().Time) {
+			continue
+		}
+		rate := changes[0]
+		for _, change := range changes {
+			if !change.EffectiveFrom.After(timesheet.WeekStart.Time) {
+				rate = change
+			}
+		}
+		days := map[Date]float64{}
+		for _, entry := range timesheet.Entries {
+			days[entry.Date] += entry.Hours
+		}
+		hours := overtime.Split(days).Payable
+		segments = append(segments, PaySegment{From: timesheet.WeekStart, To: Date{timesheet.WeekStart.AddDate(0, 0, 6)},
+			Salary: rate.Salary, Currency: rate.Currency, Hours: hours, Amount: rate.Salary.Mul(hours)})
+	}
+	sort.Slice(segments, func(i, j int) bool { return segments[i].From.Before(segments[j].From.Time) })
+	return segments
+}
+
+// applyComponents adds the allowances to the base pay and takes the deductions from the gross pay,
+// deductions stop at the gross pay so the net pay is never negative. Fixed amounts are exchanged from the
+// base currency.
+func (p *Payslip) applyComponents(components []PayComponent, converter Converter) error {
+	p.Gross = p.BasePay
+	for _, component := range components {
+		if component.Kind != Allowance {
+			continue
+		}
+		fixed, err := converter.Exchange(component.Amount, converter.Base, p.Currency)
+		if err != nil {
+			return err
+		}
+		amount := fixed.Add(p.BasePay.Percent(component.Percent))
+		p.Lines = append(p.Lines, PayslipLine{Name: component.Name, Kind: Allowance, Amount: amount})
+		p.Gross = p.Gross.Add(amount)
+	}
+	for _, component := range components {
+		if component.Kind != Deduction {
+			continue
+		}
+		fixed, err := converter.Exchange(component.Amount, converter.Base, p.Currency)
+		if err != nil {
+			return err
+		}
+		amount := fixed.Add(p.Gross.Percent(component.Percent))
+		if left := p.Gross.Sub(p.Deductions); amount.Cmp(left) > 0 {
+			amount = left
+		}
+		p.Lines = append(p.Lines, PayslipLine{Name: component.Name, Kind: Deduction, Amount: amount})
+		p.Deductions = p.Deductions.Add(amount)
+	}
+	p.Net = p.Gross.Sub(p.Deductions)
+	return nil
+}
+
+// jsonValue stores v as a JSON string, lib/pq would send []byte as bytea
+func jsonValue(v interface{}) (driver.Value, error) {
+	data, err := json.Marshal(v)
+	if err != nil {
+		return nil, err
+	}
+	return string(data), nil
+}
+
+// scanJSON decodes a JSON column into v, what names the value in errors
+func scanJSON(src interface{}, v interface{}, what string) error {
+	switch data := src.(type) {
+	case nil:
+		return nil
+	case string:
+		return json.Unmarshal([]byte(data), v)
+	case []byte:
+		return json.Unmarshal(data, v)
+	}
+	return fmt.Errorf("cannot scan %T into %s", src, what)
+}
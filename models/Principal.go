@@ -0,0 +1,24 @@
+package models
+
+import "fmt"
+
+// Principal is the authenticated caller of a request
+type Principal struct {
+	Role       Role `json:"role"`
+	EmployeeID int  `json:"employeeId,omitempty"`
+}
+
+// HasRole reports whether the principal holds any of the given roles
+func (p Principal) HasRole(roles ...Role) bool {
+	for _, role := range roles {
+		if p.Role == role {
+			return true
+		}
+	}
+	return false
+}
+
+// String identifies the principal as role:employeeID, for scoping and audit records
+func (p Principal) String() string {
+	return fmt.Sprintf("%s:%d", p.Role, p.EmployeeID)
+}
@@ -0,0 +1,280 @@
+package models
+
+import (
+	"math"
+	"sort"
+	"strings"
+	"time"
+)
+
+// BandEnforcement is what happens when an employee is given a salary outside the band of their position
+type BandEnforcement string
+
+const (
+	// EnforceWarn stores the salary and answers with a warning
+	EnforceWarn BandEnforcement = "warn"
+	// EnforceReject rejects the salary as invalid
+	EnforceReject BandEnforcement = "reject"
+)
+
+// IsValid reports whether e is a known enforcement
+func (e BandEnforcement) IsValid() bool {
+	switch e {
+	case EnforceWarn, EnforceReject:
+		return true
+	}
+	return false
+}
+
+// SalaryBand is the salary range of a position, like "Engineer" or a level like "Engineer L3", in Currency.
+// Employees are matched to the band of their position ignoring case and repeated spaces.
+type SalaryBand struct {
+	ID          int             `json:"id"`
+	Position    string          `json:"position"`
+	Currency    Currency        `json:"currency"`
+	Min         Money           `json:"min"`
+	Mid         Money           `json:"mid"`
+	Max         Money           `json:"max"`
+	Enforcement BandEnforcement `json:"enforcement"`
+	CreatedAt   time.Time       `json:"createdAt"`
+	UpdatedAt   time.Time       `json:"updatedAt"`
+}
+
+// SalaryBandRules apply to new and changed salary bands
+var SalaryBandRules = RuleSet[SalaryBand]{
+	{Field: "position", Value: func(b *SalaryBand) interface{} { return b.Position }, Checks: []Check{Required(), MaxLength(MaxTextLength)}},
+	{Field: "currency", Value: func(b *SalaryBand) interface{} { return b.Currency }, Checks: []Check{Required(), currencyCode}},
+	{Field: "min", Value: func(b *SalaryBand) interface{} { return b.Min }, Checks: []Check{Required(), Range(0.01, MaxSalary)}},
+	{Field: "mid", Value: func(b *SalaryBand) interface{} { return b.Mid }, Checks: []Check{Required(), Range(0.01, MaxSalary)}},
+	{Field: "mid", Value: func(b *SalaryBand) interface{} { return b }, Checks: []Check{midWithinBand}},
+	{Field: "max", Value: func(b *SalaryBand) interface{} { return b.Max }, Checks: []Check{Required(), Range(0.01, MaxSalary)}},
+	{Field: "enforcement", Value: func(b *SalaryBand) interface{} { return b.Enforcement },
+		Checks: []Check{OneOf(string(EnforceWarn), string(EnforceReject))}},
+}
+
+// midWithinBand requires min <= mid <= max, unset amounts are reported as required
+var midWithinBand = Check{Code: CodeOutOfRange, Message: "must be between min and max", Valid: func(value interface{}) bool {
+	b := value.(*SalaryBand)
+	return b.Min.IsZero() || b.Mid.IsZero() || b.Max.IsZero() || (b.Min.Cmp(b.Mid) <= 0 && b.Mid.Cmp(b.Max) <= 0)
+}}
+
+// Normalize cleans up fields before they are validated and stored, bands warn unless told otherwise
+func (b *SalaryBand) Normalize() {
+	b.Position = strings.TrimSpace(b.Position)
+	b.Currency = Currency(strings.ToUpper(strings.TrimSpace(string(b.Currency))))
+	if b.Enforcement == "" {
+		b.Enforcement = EnforceWarn
+	}
+}
+
+// Validate normalizes the band and checks it against SalaryBandRules
+func (b *SalaryBand) Validate() ValidationErrors {
+	b.Normalize()
+	return SalaryBandRules.Validate(b)
+}
+
+// PositionKey is what positions are matched to bands by, the position in lower case with single spaces
+func PositionKey(position string) string {
+	return strings.ToLower(strings.Join(strings.Fields(position), " "))
+}
+
+// FindSalaryBand returns the band of position among bands
+func FindSalaryBand(bands []SalaryBand, position string) (SalaryBand, bool) {
+	key := PositionKey(position)
+	for _, band := range bands {
+		if PositionKey(band.Position) == key {
+			return band, true
+		}
+	}
+	return SalaryBand{}, false
+}
+
+// BandStanding is where a salary falls relative to its band
+type BandStanding string
+
+const (
+	BelowBand  BandStanding = "below"
+	WithinBand BandStanding = "within"
+	AboveBand  BandStanding = "above"
+)
+
+// BandCheck compares a salary, exchanged to the currency of Band, to the band
+type BandCheck struct {
+	Band SalaryBand
+	// Salary is the salary in the currency of the band
+	Salary Money
+	// CompaRatio is Salary divided by the midpoint of the band, rounded to three decimals
+	CompaRatio float64
+	Standing   BandStanding
+}
+
+// Check compares salary, paid in currency, to the band. It returns a *MissingRateError if converter has no
+// rate to exchange it to the currency of the band.
+func (b SalaryBand) Check(salary Money, currency Currency, converter Converter) (BandCheck, error) {
+	exchanged, err := converter.Exchange(salary, currency, b.Currency)
+	if err != nil {
+		return BandCheck{}, err
+	}
+	check := BandCheck{Band: b, Salary: exchanged, Standing: WithinBand,
+		CompaRatio: math.Round(float64(exchanged.Cents())/float64(b.Mid.Cents())*1000) / 1000}
+	if exchanged.Cmp(b.Min) < 0 {
+		check.Standing = BelowBand
+	} else if exchanged.Cmp(b.Max) > 0 {
+		check.Standing = AboveBand
+	}
+	return check, nil
+}
+
+// FieldError reports a salary outside its band as a validation error of the Salary field, whether it
+// rejects the salary or only warns about it
+func (c BandCheck) FieldError() FieldError {
+	message := "Salary must be between " + c.Band.Min.String() + " and " + c.Band.Max.String() + " " + string(c.Band.Currency) +
+		", the band of " + c.Band.Position
+	return FieldError{Field: "Salary", Code: CodeOutOfRange, Message: message}
+}
+
+// CheckSalaryBand compares the salary of employee to the band of their position among bands. It returns
+// nil when the position has no band or the salary is within it, and a *MissingRateError if converter has
+// no rate to exchange the salary to the currency of the band.
+func CheckSalaryBand(employee Employee, bands []SalaryBand, converter Converter) (*BandCheck, error) {
+	band, ok := FindSalaryBand(bands, employee.Position)
+	if !ok {
+		return nil, nil
+	}
+	check, err := band.Check(employee.Salary, employee.Currency, converter)
+	if err != nil || check.Standing == WithinBand {
+		return nil, err
+	}
+	return &check, nil
+}
+
+// RatioStats describe a set of compa-ratios
+type RatioStats struct {
+	Min    float64 `json:"min"`
+	Median float64 `json:"median"`
+	Mean   float64 `json:"mean"`
+	Max    float64 `json:"max"`
+}
+
+// newRatioStats describes ratios, which must not be empty, rounding the median and mean to three decimals
+func newRatioStats(ratios []float64) RatioStats {
+	sorted := append([]float64(nil), ratios...)
+	sort.Float64s(sorted)
+	var total float64
+	for _, ratio := range sorted {
+		total += ratio
+	}
+	n := len(sorted)
+	median := sorted[n/2]
+	if n%2 == 0 {
+		median = (sorted[n/2-1] + sorted[n/2]) / 2
+	}
+	return RatioStats{Min: sorted[0], Median: math.Round(median*1000) / 1000, Mean: math.Round(total/float64(n)*1000) / 1000,
+		Max: sorted[n-1]}
+}
+
+// PayEquityGroup counts the employees sharing a Key by where their salaries fall in their bands.
+// Headcount and CompaRatio only cover employees whose position has a band, CompaRatio is nil if none has.
+type PayEquityGroup struct {
+	Key        string      `json:"key"`
+	Headcount  int         `json:"headcount"`
+	Unbanded   int         `json:"unbanded"`
+	BelowBand  int         `json:"belowBand"`
+	WithinBand int         `json:"withinBand"`
+	AboveBand  int         `json:"aboveBand"`
+	CompaRatio *RatioStats `json:"compaRatio"`
+}
+
+// OutOfBandEmployee is an employee paid outside the band of their position
+type OutOfBandEmployee struct {
+	EmployeeID int      `json:"employeeId"`
+	Name       string   `json:"name"`
+	Position   string   `json:"position"`
+	Group      string   `json:"group"`
+	Salary     Money    `json:"salary"`
+	Currency   Currency `json:"currency"`
+	// BandSalary is Salary in the currency of Band
+	BandSalary Money        `json:"bandSalary"`
+	Band       SalaryBand   `json:"band"`
+	CompaRatio float64      `json:"compaRatio"`
+	Standing   BandStanding `json:"standing"`
+}
+
+// PayEquityReport compares the salaries the employees employed on AsOf are paid to the bands of their
+// positions, exchanged to the currencies of the bands with Rates
+type PayEquityReport struct {
+	AsOf        Date                `json:"asOf"`
+	GroupBy     SalaryGroupBy       `json:"groupBy"`
+	Headcount   int                 `json:"headcount"`
+	Unbanded    int                 `json:"unbanded"`
+	Groups      []PayEquityGroup    `json:"groups"`
+	OutOfBand   []OutOfBandEmployee `json:"outOfBand"`
+	Rates       []ExchangeRate      `json:"rates"`
+	GeneratedAt time.Time           `json:"generatedAt"`
+}
+
+// NewPayEquityReport compares the salaries the employees employed on query.AsOf are paid on the day by
+// history, which must be ordered by employee and effective date, to bands, grouped by query.GroupBy.
+// Out of band employees are listed by group and ID. It returns a *MissingRateError if converter has no
+// rate to exchange a salary to the currency of its band.
+func NewPayEquityReport(query SalaryReportQuery, bands []SalaryBand, employees []Employee, history []SalaryChange,
+	converter Converter) (PayEquityReport, error) {
+	report := PayEquityReport{AsOf: query.AsOf, GroupBy: query.GroupBy, Groups: []PayEquityGroup{}, OutOfBand: []OutOfBandEmployee{},
+		GeneratedAt: time.Now()}
+
+	groups := map[string]*PayEquityGroup{}
+	ratios := map[string][]float64{}
+	used := map[Currency]bool{}
+	for _, p := range salariesPaidOn(query.AsOf, employees, history) {
+		key := query.GroupBy.key(p.employee)
+		group, ok := groups[key]
+		if !ok {
+			group = &PayEquityGroup{Key: key}
+			groups[key] = group
+		}
+		band, ok := FindSalaryBand(bands, p.employee.Position)
+		if !ok {
+			group.Unbanded++
+			report.Unbanded++
+			continue
+		}
+		check, err := band.Check(p.salary.Salary, p.salary.Currency, converter)
+		if err != nil {
+			return report, err
+		}
+		if p.salary.Currency != band.Currency {
+			// exchanging goes through the rates of both currencies
+			used[p.salary.Currency], used[band.Currency] = true, true
+		}
+		group.Headcount++
+		report.Headcount++
+		ratios[key] = append(ratios[key], check.CompaRatio)
+		switch check.Standing {
+		case BelowBand:
+			group.BelowBand++
+		case AboveBand:
+			group.AboveBand++
+		default:
+			group.WithinBand++
+			continue
+		}
+		report.OutOfBand = append(report.OutOfBand, OutOfBandEmployee{EmployeeID: p.employee.ID, Name: p.employee.Name,
+			Position: p.employee.Position, Group: key, Salary: p.salary.Salary, Currency: p.salary.Currency, BandSalary: check.Salary,
+			Band: band, CompaRatio: check.CompaRatio, Standing: check.Standing})
+	}
+
+	for key, group := range groups {
+		if len(ratios[key]) > 0 {
+			stats := newRatioStats(ratios[key])
+			group.CompaRatio = &stats
+		}
+		report.Groups = append(report.Groups, *group)
+	}
+	sort.Slice(report.Groups, func(i, j int) bool { return report.Groups[i].Key < report.Groups[j].Key })
+	sort.SliceStable(report.OutOfBand, func(i, j int) bool {
+		a, b := report.OutOfBand[i], report.OutOfBand[j]
+		return a.Group < b.Group || (a.Group == b.Group && a.EmployeeID < b.EmployeeID)
+	})
+	report.Rates = ratesOf(converter, used)
+	return report, nil
+}
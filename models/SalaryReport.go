@@ -0,0 +1,196 @@
+package models
+
+import "sort"
+
+// SalaryGroupBy is the employee field a salary report groups by
+type SalaryGroupBy string
+
+const (
+	GroupByDepartment SalaryGroupBy = "department"
+	GroupByPosition   SalaryGroupBy = "position"
+	GroupByLocation   SalaryGroupBy = "location"
+)
+
+// IsValid reports whether g is a known grouping
+func (g SalaryGroupBy) IsValid() bool {
+	switch g {
+	case GroupByDepartment, GroupByPosition, GroupByLocation:
+		return true
+	}
+	return false
+}
+
+// key returns the group of employee
+func (g SalaryGroupBy) key(employee Employee) string {
+	switch g {
+	case GroupByPosition:
+		return employee.Position
+	case GroupByLocation:
+		return employee.Location
+	}
+	return employee.Department
+}
+
+// SalaryReportQuery selects the day, grouping and currency of a salary report
+type SalaryReportQuery struct {
+	AsOf     Date
+	GroupBy  SalaryGroupBy
+	Currency Currency
+}
+
+// SalaryReportQueryRules apply to salary report queries
+var SalaryReportQueryRules = RuleSet[SalaryReportQuery]{
+	{Field: "groupBy", Value: func(q *SalaryReportQuery) interface{} { return q.GroupBy },
+		Checks: []Check{OneOf(string(GroupByDepartment), string(GroupByPosition), string(GroupByLocation))}},
+	{Field: "currency", Value: func(q *SalaryReportQuery) interface{} { return q.Currency }, Checks: []Check{currencyCode}},
+}
+
+// CurrencyTotal sums the salaries paid in one currency
+type CurrencyTotal struct {
+	Currency  Currency `json:"currency"`
+	Headcount int      `json:"headcount"`
+	Amount    Money    `json:"amount"`
+}
+
+// SalaryGroup sums the salaries of the employees sharing a Key, by the currency they are paid in and in
+// Total normalized to the currency of the report
+type SalaryGroup struct {
+	Key       string          `json:"key"`
+	Headcount int             `json:"headcount"`
+	Totals    []CurrencyTotal `json:"totals"`
+	Total     Money           `json:"total"`
+}
+
+// SalaryReport sums the salaries of the employees employed on AsOf by group. Totals are normalized to
+// Currency with the Rates effective on the day.
+type SalaryReport struct {
+	AsOf      Date           `json:"asOf"`
+	GroupBy   SalaryGroupBy  `json:"groupBy"`
+	Currency  Currency       `json:"currency"`
+	Headcount int            `json:"headcount"`
+	Total     Money          `json:"total"`
+	Groups    []SalaryGroup  `json:"groups"`
+	Rates     []ExchangeRate `json:"rates"`
+}
+
+// employedOn reports whether employee is employed on day, between the hire and termination dates
+func employedOn(employee Employee, day Date) bool {
+	return (employee.HireDate == nil || !employee.HireDate.After(day.Time)) &&
+		(employee.TerminationDate == nil || !employee.TerminationDate.Before(day.Time))
+}
+
+// salaryOn returns the salary of employee effective on day by changes, which are ordered by effective
+// date. Employees without a recorded salary on the day are taken to be paid the one they have now.
+func salaryOn(employee Employee, changes []SalaryChange, day Date) SalaryChange {
+	salary := SalaryChange{EmployeeID: employee.ID, Salary: employee.Salary, Currency: employee.Currency}
+	for _, change := range changes {
+		if !change.EffectiveFrom.After(day.Time) {
+			salary = change
+		}
+	}
+	return salary
+}
+
+// paidSalary is the salary an employee is paid on a day, normalized to the currency of a report if it has one
+type paidSalary struct {
+	employee   Employee
+	salary     SalaryChange
+	normalized Money
+}
+
+// salariesPaidOn returns the salaries the employees employed on day are paid on it by history, which must
+// be ordered by employee and effective date
+func salariesPaidOn(day Date, employees []Employee, history []SalaryChange) []paidSalary {
+	changes := map[int][]SalaryChange{}
+	for _, change := range history {
+		changes[change.EmployeeID] = append(changes[change.EmployeeID], change)
+	}
+
+	var paid []paidSalary
+	for _, employee := range employees {
+		if employedOn(employee, day) {
+			paid = append(paid, paidSalary{employee: employee, salary: salaryOn(employee, changes[employee.ID], day)})
+		}
+	}
+	return paid
+}
+
+// salariesOn is salariesPaidOn with the salaries normalized to currency by converter. It returns a
+// *MissingRateError if a rate is missing.
+func salariesOn(day Date, currency Currency, employees []Employee, history []SalaryChange, converter Converter) ([]paidSalary, error) {
+	paid := salariesPaidOn(day, employees, history)
+	for i, p := range paid {
+		normalized, err := converter.Exchange(p.salary.Salary, p.salary.Currency, currency)
+		if err != nil {
+			return nil, err
+		}
+		paid[i].normalized = normalized
+	}
+	return paid, nil
+}
+
+// usedRates lists the rates of converter paid was normalized to currency with
+func usedRates(converter Converter, currency Currency, paid []paidSalary) []ExchangeRate {
+	used := map[Currency]bool{}
+	for _, p := range paid {
+		if p.salary.Currency != currency {
+			// exchanging goes through the rates of both currencies
+			used[p.salary.Currency], used[currency] = true, true
+		}
+	}
+	return ratesOf(converter, used)
+}
+
+// ratesOf lists the rates of converter for the used currencies by currency
+func ratesOf(converter Converter, used map[Currency]bool) []ExchangeRate {
+	var currencies []Currency
+	for c := range used {
+		currencies = append(currencies, c)
+	}
+	sort.Slice(currencies, func(i, j int) bool { return currencies[i] < currencies[j] })
+	return converter.Used(currencies)
+}
+
+// NewSalaryReport sums the salaries the employees employed on query.AsOf are paid on the day by history,
+// which must be ordered by employee and effective date, grouped by query.GroupBy. Totals are normalized
+// to query.Currency by converter, it returns a *MissingRateError if a rate is missing.
+func NewSalaryReport(query SalaryReportQuery, employees []Employee, history []SalaryChange, converter Converter) (SalaryReport, error) {
+	report := SalaryReport{AsOf: query.AsOf, GroupBy: query.GroupBy, Currency: query.Currency, Groups: []SalaryGroup{}}
+	paid, err := salariesOn(query.AsOf, query.Currency, employees, history, converter)
+	if err != nil {
+		return report, err
+	}
+
+	groups := map[string]*SalaryGroup{}
+	totals := map[string]map[Currency]*CurrencyTotal{}
+	for _, p := range paid {
+		key := query.GroupBy.key(p.employee)
+		group, ok := groups[key]
+		if !ok {
+			group = &SalaryGroup{Key: key}
+			groups[key], totals[key] = group, map[Currency]*CurrencyTotal{}
+		}
+		total, ok := totals[key][p.salary.Currency]
+		if !ok {
+			total = &CurrencyTotal{Currency: p.salary.Currency}
+			totals[key][p.salary.Currency] = total
+		}
+		total.Headcount++
+		total.Amount = total.Amount.Add(p.salary.Salary)
+		group.Headcount++
+		group.Total = group.Total.Add(p.normalized)
+		report.Headcount++
+		report.Total = report.Total.Add(p.normalized)
+	}
+
+	for key, group := range groups {
+		for _, total := range totals[key] {
+			group.Totals = append(group.Totals, *total)
+		}
+		sort.Slice(group.Totals, func(i, j int) bool { return group.Totals[i].Currency < group.Totals[j].Currency })
+		report.Groups = append(report.Groups, *group)
+	}
+	sort.Slice(report.Groups, func(i, j int) bool { return report.Groups[i].Key < report.Groups[j].Key })
+	report.Rates = usedRates(converter, query.Currency, paid)
+	return report, nil
+}
@@ -0,0 +1,358 @@
+package models
+
+import (
+	"database/sql/driver"
+	"encoding/json"
+	"fmt"
+	"math"
+	"sort"
+	"strings"
+	"time"
+)
+
+// MaxHoursPerDay is the most hours an employee can work on a day
+const MaxHoursPerDay = 24
+
+// MaxTimesheetEntries bounds the entries of one timesheet
+const MaxTimesheetEntries = 200
+
+// AttendanceEntry is a stretch of work an employee clocked in and out of, ClockOut is nil while the
+// employee is still clocked in. Hours is set on clocking out.
+type AttendanceEntry struct {
+	ID         int        `json:"id"`
+	EmployeeID int        `json:"employeeId"`
+	ClockIn    time.Time  `json:"clockIn"`
+	ClockOut   *time.Time `json:"clockOut"`
+	Hours      float64    `json:"hours"`
+	Note       string     `json:"note"`
+}
+
+// Day is the day the entry counts towards, the UTC day it was clocked in on
+func (a AttendanceEntry) Day() Date {
+	return NewDate(a.ClockIn.UTC())
+}
+
+// ClockRequest is the optional body of a clock in
+type ClockRequest struct {
+	Note string `json:"note"`
+}
+
+// ClockRequestRules apply to clock ins
+var ClockRequestRules = RuleSet[ClockRequest]{
+	{Field: "note", Value: func(r *ClockRequest) interface{} { return r.Note }, Checks: []Check{MaxLength(1000)}},
+}
+
+// TimesheetStatus is where a timesheet stands in the approval workflow
+type TimesheetStatus string
+
+const (
+	TimesheetSubmitted TimesheetStatus = "submitted"
+	TimesheetApproved  TimesheetStatus = "approved"
+	TimesheetRejected  TimesheetStatus = "rejected"
+)
+
+// IsValid reports whether s is one of the known timesheet statuses
+func (s TimesheetStatus) IsValid() bool {
+	switch s {
+	case TimesheetSubmitted, TimesheetApproved, TimesheetRejected:
+		return true
+	}
+	return false
+}
+
+// TimesheetEntry is time worked on a project on one day of the week
+type TimesheetEntry struct {
+	Date    Date    `json:"date"`
+	Project string  `json:"project"`
+	Hours   float64 `json:"hours"`
+	Note    string  `json:"note"`
+}
+
+// TimesheetEntryRules apply to every entry of a timesheet
+var TimesheetEntryRules = RuleSet[TimesheetEntry]{
+	{Field: "date", Value: func(e *TimesheetEntry) interface{} { return e.Date }, Checks: []Check{Required()}},
+	{Field: "project", Value: func(e *TimesheetEntry) interface{} { return e.Project }, Checks: []Check{Required(), MaxLength(MaxTextLength)}},
+	{Field: "hours", Value: func(e *TimesheetEntry) interface{} { return e.Hours }, Checks: []Check{Required(), hoursOfADay}},
+	{Field: "note", Value: func(e *TimesheetEntry) interface{} { return e.Note }, Checks: []Check{MaxLength(1000)}},
+}
+
+// hoursOfADay accepts hours that fit in a day
+var hoursOfADay = Check{Code: CodeOutOfRange, Message: fmt.Sprintf("must be above 0 and at most %d", MaxHoursPerDay), Valid: func(value interface{}) bool {
+	hours, _ := value.(float64)
+	return hours == 0 || (hours > 0 && hours <= MaxHoursPerDay)
+}}
+
+// TimesheetEntries are stored as a JSON array in a single column
+type TimesheetEntries []TimesheetEntry
+
+// Value stores the entries as a JSON string, lib/pq would send []byte as bytea
+func (e TimesheetEntries) Value() (driver.Value, error) {
+	if e == nil {
+		return "[]", nil
+	}
+	data, err := json.Marshal(e)
+	if err != nil {
+		return nil, err
+	}
+	return string(data), nil
+}
+
+func (e *TimesheetEntries) Scan(src interface{}) error {
+	var data []byte
+	switch v := src.(type) {
+	case nil:
+		*e = TimesheetEntries{}
+		return nil
+	case string:
+		data = []byte(v)
+	case []byte:
+		data = v
+	default:
+		return fmt.Errorf("cannot scan %T into timesheet entries", src)
+	}
+
+	entries := TimesheetEntries{}
+	if err := json.Unmarshal(data, &entries); err != nil {
+		return err
+	}
+	*e = entries
+	return nil
+}
+
+// Timesheet is the time an employee worked on projects in the week starting on WeekStart, a Monday.
+// It is submitted for HR to approve, a rejected timesheet may be submitted again. Hours is set by the server.
+type Timesheet struct {
+	ID           int              `json:"id"`
+	EmployeeID   int              `json:"employeeId"`
+	WeekStart    Date             `json:"weekStart"`
+	Entries      TimesheetEntries `json:"entries"`
+	Hours        float64          `json:"hours"`
+	Status       TimesheetStatus  `json:"status"`
+	DecidedBy    string           `json:"decidedBy"`
+	DecisionNote string           `json:"decisionNote"`
+	DecidedAt    *time.Time       `json:"decidedAt"`
+	CreatedAt    time.Time        `json:"createdAt"`
+}
+
+// TimesheetRules apply to submitted timesheets, their entries are checked by TimesheetEntryRules
+var TimesheetRules = RuleSet[Timesheet]{
+	{Field: "weekStart", Value: func(t *Timesheet) interface{} { return t.WeekStart }, Checks: []Check{Required(), monday}},
+	{Field: "entries", Value: func(t *Timesheet) interface{} { return len(t.Entries) }, Checks: []Check{Required(), Range(1, MaxTimesheetEntries+1)}},
+}
+
+// monday accepts the days a week starts on
+var monday = Check{Code: CodeInvalidValue, Message: "must be a Monday", Valid: func(value interface{}) bool {
+	d := value.(Date)
+	return d.IsZero() || d.Weekday() == time.Monday
+}}
+
+// Validate trims the text of the entries and checks the timesheet against TimesheetRules, every entry
+// against TimesheetEntryRules and that entries fall in the week without any day adding up to more than
+// MaxHoursPerDay. Entry errors are reported like entries[0].hours.
+func (t *Timesheet) Validate() ValidationErrors {
+	errs := TimesheetRules.Validate(t)
+	days := map[Date]float64{}
+	for i := range t.Entries {
+		entry := &t.Entries[i]
+		entry.Project = strings.TrimSpace(entry.Project)
+		entry.Note = strings.TrimSpace(entry.Note)
+		prefix := fmt.Sprintf("entries[%d].", i)
+		entryErrs := TimesheetEntryRules.Validate(entry)
+		if len(entryErrs) == 0 && !t.WeekStart.IsZero() && !t.Covers(entry.Date) {
+			entryErrs = append(entryErrs, FieldError{Field: "date", Code: CodeOutOfRange, Message: "date must fall in the week starting on weekStart"})
+		}
+		for _, fieldErr := range entryErrs {
+			errs = append(errs, FieldError{Field: prefix + fieldErr.Field, Code: fieldErr.Code, Message: prefix + fieldErr.Message})
+		}
+		days[entry.Date] += entry.Hours
+	}
+	for _, hours := range days {
+		if hours > MaxHoursPerDay {
+			errs = append(errs, FieldError{Field: "entries", Code: CodeOutOfRange,
+				Message: fmt.Sprintf("entries must not add up to more than %d hours on a day", MaxHoursPerDay)})
+			break
+		}
+	}
+	if len(errs) == 0 {
+		t.Hours = 0
+		for _, entry := range t.Entries {
+			t.Hours += entry.Hours
+		}
+		t.Hours = roundHours(t.Hours)
+	}
+	return errs
+}
+
+// Covers reports whether day falls in the week of the timesheet
+func (t Timesheet) Covers(day Date) bool {
+	return !day.Before(t.WeekStart.Time) && day.Before(t.WeekStart.AddDate(0, 0, 7))
+}
+
+// TimesheetDecision is the note left with an approval or rejection, it may be empty
+type TimesheetDecision struct {
+	Note string `json:"note"`
+}
+
+// TimesheetDecisionRules apply to the note of a decision
+var TimesheetDecisionRules = RuleSet[TimesheetDecision]{
+	{Field: "note", Value: func(d *TimesheetDecision) interface{} { return d.Note }, Checks: []Check{MaxLength(1000)}},
+}
+
+// TimesheetFilter selects timesheets, unset fields match every timesheet
+type TimesheetFilter struct {
+	EmployeeID int
+	WeekStart  Date
+	Status     TimesheetStatus
+}
+
+// WeekOf returns the Monday of the week day falls in
+func WeekOf(day Date) Date {
+	return Date{day.AddDate(0, 0, -(int(day.Weekday())+6)%7)}
+}
+
+// OvertimeRules split worked hours into regular and overtime hours. Hours beyond DailyHours on a day
+// are overtime, as are regular hours beyond WeeklyHours in the week, a zero limit is not applied.
+// Overtime hours are paid Multiplier times.
+type OvertimeRules struct {
+	DailyHours  float64 `json:"dailyHours"`
+	WeeklyHours float64 `json:"weeklyHours"`
+	Multiplier  float64 `json:"multiplier"`
+}
+
+// WorkedHours are the hours worked in a week split by the OvertimeRules. Payable counts each
+// overtime hour Multiplier times, it is what hourly pay is computed from.
+type WorkedHours struct {
+	Hours    float64 `json:"hours"`
+	Regular  float64 `json:"regular"`
+	Overtime float64 `json:"overtime"`
+	Payable  float64 `json:"payable"`
+}
+
+// Split applies the rules to the hours worked on each day of a week
+func (r OvertimeRules) Split(days map[Date]float64) WorkedHours {
+	var worked WorkedHours
+	for _, hours := range days {
+		worked.Hours += hours
+		if r.DailyHours > 0 && hours > r.DailyHours {
+			worked.Overtime += hours - r.DailyHours
+			hours = r.DailyHours
+		}
+		worked.Regular += hours
+	}
+	if r.WeeklyHours > 0 && worked.Regular > r.WeeklyHours {
+		worked.Overtime += worked.Regular - r.WeeklyHours
+		worked.Regular = r.WeeklyHours
+	}
+	return worked.rounded(r.Multiplier)
+}
+
+// add sums two splits, which are rounded again with the multiplier
+func (w WorkedHours) add(other WorkedHours, multiplier float64) WorkedHours {
+	return WorkedHours{Hours: w.Hours + other.Hours, Regular: w.Regular + other.Regular, Overtime: w.Overtime + other.Overtime}.rounded(multiplier)
+}
+
+func (w WorkedHours) rounded(multiplier float64) WorkedHours {
+	w.Hours, w.Regular, w.Overtime = roundHours(w.Hours), roundHours(w.Regular), roundHours(w.Overtime)
+	w.Payable = roundHours(w.Regular + w.Overtime*multiplier)
+	return w
+}
+
+// HoursSource tells where the hours of an employee's week come from
+type HoursSource string
+
+const (
+	// HoursFromTimesheet counts the approved timesheet of the week
+	HoursFromTimesheet HoursSource = "timesheet"
+	// HoursFromAttendance counts the attendance entries clocked out of, for weeks without an approved timesheet
+	HoursFromAttendance HoursSource = "attendance"
+)
+
+// EmployeeWeek is the week of one employee in a WeeklySummary, Projects holds the hours per project
+// of an approved timesheet
+type EmployeeWeek struct {
+	EmployeeID     int                `json:"employeeId"`
+	Name           string             `json:"name"`
+	Department     string             `json:"department"`
+	EmploymentType EmploymentType     `json:"employmentType"`
+	Source         HoursSource        `json:"source"`
+	Projects       map[string]float64 `json:"projects,omitempty"`
+	WorkedHours
+}
+
+// DepartmentWeek totals the weeks of the employees of a department, employees without one are
+// totalled under an empty department
+type DepartmentWeek struct {
+	Department string `json:"department"`
+	Employees  int    `json:"employees"`
+	WorkedHours
+}
+
+// WeeklySummary reports the hours worked in the week starting on WeekStart per employee, ordered by
+// ID, and per department, ordered by name
+type WeeklySummary struct {
+	WeekStart   Date             `json:"weekStart"`
+	Rules       OvertimeRules    `json:"rules"`
+	Employees   []EmployeeWeek   `json:"employees"`
+	Departments []DepartmentWeek `json:"departments"`
+}
+
+// NewWeeklySummary sums up the week of every employee with an approved timesheet of the week or with
+// attendance entries clocked in during the week, other timesheets and entries are ignored. Employees
+// not in employees are left out.
+func NewWeeklySummary(weekStart Date, rules OvertimeRules, employees []Employee, attendance []AttendanceEntry, timesheets []Timesheet) WeeklySummary {
+	week := Timesheet{WeekStart: weekStart}
+	summary := WeeklySummary{WeekStart: weekStart, Rules: rules, Employees: []EmployeeWeek{}, Departments: []DepartmentWeek{}}
+	departments := map[string]*DepartmentWeek{}
+	for _, employee := range employees {
+		employeeWeek := EmployeeWeek{EmployeeID: employee.ID, Name: employee.Name, Department: employee.Department,
+			EmploymentType: employee.EmploymentType, Source: HoursFromAttendance}
+		days := map[Date]float64{}
+		for _, timesheet := range timesheets {
+			if timesheet.EmployeeID != employee.ID || timesheet.Status != TimesheetApproved || !timesheet.WeekStart.Equal(weekStart.Time) {
+				continue
+			}
+			employeeWeek.Source, employeeWeek.Projects = HoursFromTimesheet, map[string]float64{}
+			for _, entry := range timesheet.Entries {
+				days[entry.Date] += entry.Hours
+				employeeWeek.Projects[entry.Project] = roundHours(employeeWeek.Projects[entry.Project] + entry.Hours)
+			}
+		}
+		if employeeWeek.Source == HoursFromAttendance {
+			for _, entry := range attendance {
+				if entry.EmployeeID == employee.ID && entry.ClockOut != nil && week.Covers(entry.Day()) {
+					days[entry.Day()] += entry.Hours
+				}
+			}
+		}
+		if len(days) == 0 {
+			continue
+		}
+		employeeWeek.WorkedHours = rules.Split(days)
+		summary.Employees = append(summary.Employees, employeeWeek)
+
+		department := departments[employee.Department]
+		if department == nil {
+			department = &DepartmentWeek{Department: employee.Department}
+			departments[employee.Department] = department
+		}
+		department.Employees++
+		department.WorkedHours = department.WorkedHours.add(employeeWeek.WorkedHours, rules.Multiplier)
+	}
+
+	sort.Slice(summary.Employees, func(i, j int) bool { return summary.Employees[i].EmployeeID < summary.Employees[j].EmployeeID })
+	for _, department := range departments {
+		summary.Departments = append(summary.Departments, *department)
+	}
+	sort.Slice(summary.Departments, func(i, j int) bool { return summary.Departments[i].Department < summary.Departments[j].Department })
+	return summary
+}
+
+// HoursBetween returns the hours from start to end, rounded like the NUMERIC(6, 2) columns storing them
+func HoursBetween(start, end time.Time) float64 {
+	return roundHours(end.Sub(start).Hours())
+}
+
+// roundHours keeps hours to the hundredths a NUMERIC(6, 2) column stores
+func roundHours(hours float64) float64 {
+	return math.Round(hours*100) / 100
+}
@@ -0,0 +1,97 @@
+package models
+
+import (
+	"encoding/json"
+	"net/url"
+	"time"
+)
+
+// MinWebhookSecretLength keeps webhook signatures from being guessed
+const MinWebhookSecretLength = 16
+
+// WebhookSubscription asks for the employee events of EventTypes, or of every type when empty,
+// to be posted to URL, signed with Secret
+type WebhookSubscription struct {
+	ID         int        `json:"id"`
+	URL        string     `json:"url"`
+	EventTypes StringList `json:"eventTypes"`
+	// Secret is write only, it is left out of responses
+	Secret    string    `json:"secret,omitempty"`
+	CreatedAt time.Time `json:"createdAt"`
+}
+
+// Wants reports whether events of eventType are posted to the subscription
+func (s WebhookSubscription) Wants(eventType EmployeeEventType) bool {
+	if len(s.EventTypes) == 0 {
+		return true
+	}
+	for _, t := range s.EventTypes {
+		if EmployeeEventType(t) == eventType {
+			return true
+		}
+	}
+	return false
+}
+
+// WebhookSubscriptionRules apply to new webhook subscriptions
+var WebhookSubscriptionRules = RuleSet[WebhookSubscription]{
+	{Field: "url", Value: func(s *WebhookSubscription) interface{} { return s.URL }, Checks: []Check{Required(), MaxLength(2048),
+		Pattern("must be an absolute http or https URL", isWebhookURL)}},
+	{Field: "eventTypes", Value: func(s *WebhookSubscription) interface{} { return s.EventTypes }, Checks: []Check{eventTypeNames}},
+	{Field: "secret", Value: func(s *WebhookSubscription) interface{} { return s.Secret }, Checks: []Check{Required(), MaxLength(MaxTextLength),
+		Pattern("must be at least 16 characters", func(secret string) bool { return len(secret) >= MinWebhookSecretLength })}},
+}
+
+func isWebhookURL(s string) bool {
+	u, err := url.Parse(s)
+	return err == nil && (u.Scheme == "http" || u.Scheme == "https") && u.Host != ""
+}
+
+// eventTypeNames only accepts the names of EmployeeEventTypes
+var eventTypeNames = Check{Code: CodeInvalidValue, Message: "may only name created, updated and deleted", Valid: func(value interface{}) bool {
+	for _, name := range value.(StringList) {
+		known := false
+		for _, t := range EmployeeEventTypes {
+			known = known || string(t) == name
+		}
+		if !known {
+			return false
+		}
+	}
+	return true
+}}
+
+// WebhookDeliveryStatus is where a delivery stands. Failed attempts keep it pending until it
+// succeeds or runs out of attempts and is dead-lettered.
+type WebhookDeliveryStatus string
+
+const (
+	WebhookPending   WebhookDeliveryStatus = "pending"
+	WebhookDelivered WebhookDeliveryStatus = "delivered"
+	WebhookDead      WebhookDeliveryStatus = "dead"
+)
+
+func (s WebhookDeliveryStatus) IsValid() bool {
+	switch s {
+	case WebhookPending, WebhookDelivered, WebhookDead:
+		return true
+	}
+	return false
+}
+
+// WebhookDelivery posts one employee event to one subscription. Payload is the EmployeeEvent as JSON,
+// the last attempt is described by LastStatusCode, 0 when no response arrived, and LastError.
+type WebhookDelivery struct {
+	ID             int64                 `json:"id"`
+	SubscriptionID int                   `json:"subscriptionId"`
+	EventID        int64                 `json:"eventId"`
+	EventType      EmployeeEventType     `json:"eventType"`
+	Payload        json.RawMessage       `json:"payload"`
+	Status         WebhookDeliveryStatus `json:"status"`
+	Attempts       int                   `json:"attempts"`
+	NextAttemptAt  *time.Time            `json:"nextAttemptAt"`
+	LastStatusCode int                   `json:"lastStatusCode"`
+	LastError      string                `json:"lastError"`
+	DeliveredAt    *time.Time            `json:"deliveredAt"`
+	CreatedAt      time.Time             `json:"createdAt"`
+}
@@ -7,3 +7,77 @@ const (
 	PGSQL_URL  DatabaseURL = "PGSQL_URL"
 	FIBER_PORT PORT        = "FIBER_PORT"
 )
+
+// Role is the access level granted to an API key
+type Role string
+
+const (
+	RoleAdmin    Role = "admin"
+	RoleHR       Role = "hr"
+	RoleEmployee Role = "employee"
+)
+
+// IsValid reports whether r is one of the known roles
+func (r Role) IsValid() bool {
+	switch r {
+	case RoleAdmin, RoleHR, RoleEmployee:
+		return true
+	}
+	return false
+}
+
+// EmploymentType is the contract an employee works under
+type EmploymentType string
+
+const (
+	EmploymentFullTime   EmploymentType = "full-time"
+	EmploymentPartTime   EmploymentType = "part-time"
+	EmploymentContractor EmploymentType = "contractor"
+)
+
+// IsValid reports whether t is one of the known employment types
+func (t EmploymentType) IsValid() bool {
+	switch t {
+	case EmploymentFullTime, EmploymentPartTime, EmploymentContractor:
+		return true
+	}
+	return false
+}
+
+// EmployeeStatus is where an employee stands in the employment lifecycle
+type EmployeeStatus string
+
+const (
+	StatusActive     EmployeeStatus = "active"
+	StatusOnLeave    EmployeeStatus = "on-leave"
+	StatusTerminated EmployeeStatus = "terminated"
+)
+
+// IsValid reports whether s is one of the known statuses
+func (s EmployeeStatus) IsValid() bool {
+	switch s {
+	case StatusActive, StatusOnLeave, StatusTerminated:
+		return true
+	}
+	return false
+}
+
+// CustomFieldType is the type of value a custom field holds
+type CustomFieldType string
+
+const (
+	CustomFieldString  CustomFieldType = "string"
+	CustomFieldNumber  CustomFieldType = "number"
+	CustomFieldBoolean CustomFieldType = "boolean"
+	CustomFieldDate    CustomFieldType = "date"
+	CustomFieldEnum    CustomFieldType = "enum"
+)
+
+// IsValid reports whether t is one of the known custom field types
+func (t CustomFieldType) IsValid() bool {
+	switch t {
+	case CustomFieldString, CustomFieldNumber, CustomFieldBoolean, CustomFieldDate, CustomFieldEnum:
+		return true
+	}
+	return false
+}
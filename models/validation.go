@@ -0,0 +1,153 @@
+package models
+
+import (
+	"fmt"
+	"math"
+	"net/mail"
+	"reflect"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+)
+
+// Codes reported in FieldError.Code
+const (
+	CodeRequired      = "required"
+	CodeTooLong       = "too_long"
+	CodeOutOfRange    = "out_of_range"
+	CodeInvalidFormat = "invalid_format"
+	CodeInvalidValue  = "invalid_value"
+	CodeInvalidType   = "invalid_type"
+	CodeUnknownField  = "unknown_field"
+)
+
+// MaxTextLength matches the VARCHAR(255) columns
+const MaxTextLength = 255
+
+// MaxSalary is the first value a NUMERIC(10, 2) column cannot hold
+const MaxSalary = 100000000
+
+// FieldError describes why the value of one field was rejected
+type FieldError struct {
+	Field   string `json:"field"`
+	Code    string `json:"code"`
+	Message string `json:"message"`
+}
+
+// ValidationErrors lists every rejected field of a request, in rule order
+type ValidationErrors []FieldError
+
+func (v ValidationErrors) Error() string {
+	messages := make([]string, len(v))
+	for i, fieldErr := range v {
+		messages[i] = fieldErr.Message
+	}
+	return strings.Join(messages, "; ")
+}
+
+// Check tests a single value. Apart from Required, checks accept zero values
+// so optional fields are only checked when they are set.
+type Check struct {
+	Code    string
+	Message string
+	Valid   func(value interface{}) bool
+}
+
+// Rule applies its checks to one field of T, stopping at the first failing check
+type Rule[T any] struct {
+	// Field is the JSON name reported in FieldError.Field
+	Field  string
+	Value  func(t *T) interface{}
+	Checks []Check
+}
+
+// RuleSet is the list of rules a request must pass
+type RuleSet[T any] []Rule[T]
+
+// Validate runs every rule against t and collects the failures
+func (rs RuleSet[T]) Validate(t *T) ValidationErrors {
+	var errs ValidationErrors
+	for _, rule := range rs {
+		value := rule.Value(t)
+		for _, check := range rule.Checks {
+			if !check.Valid(value) {
+				errs = append(errs, FieldError{Field: rule.Field, Code: check.Code, Message: rule.Field + " " + check.Message})
+				break
+			}
+		}
+	}
+	return errs
+}
+
+func isZero(value interface{}) bool {
+	if value == nil {
+		return true
+	}
+	v := reflect.ValueOf(value)
+	return v.IsZero() || (v.Kind() == reflect.Map && v.Len() == 0)
+}
+
+// Required rejects zero values
+func Required() Check {
+	return Check{Code: CodeRequired, Message: "is required", Valid: func(value interface{}) bool {
+		return !isZero(value)
+	}}
+}
+
+// MaxLength limits the number of characters of a string
+func MaxLength(max int) Check {
+	return Check{Code: CodeTooLong, Message: fmt.Sprintf("must be at most %d characters", max), Valid: func(value interface{}) bool {
+		s, _ := value.(string)
+		return utf8.RuneCountInString(s) <= max
+	}}
+}
+
+// Email accepts a plain address such as jane@example.com
+func Email() Check {
+	return Check{Code: CodeInvalidFormat, Message: "must be a valid email address", Valid: func(value interface{}) bool {
+		s, _ := value.(string)
+		if s == "" {
+			return true
+		}
+		addr, err := mail.ParseAddress(s)
+		return err == nil && addr.Address == s
+	}}
+}
+
+// Range accepts numbers with min <= value < max
+func Range(min, max float64) Check {
+	return Check{Code: CodeOutOfRange, Message: "must be at least " + formatNumber(min) + " and below " + formatNumber(max), Valid: func(value interface{}) bool {
+		var n float64
+		switch v := value.(type) {
+		case float64:
+			n = v
+		case int:
+			n = float64(v)
+		case Money:
+			n = v.Float64()
+		default:
+			return false
+		}
+		return n == 0 || (n >= min && n < max && !math.IsNaN(n))
+	}}
+}
+
+func formatNumber(n float64) string {
+	return strconv.FormatFloat(n, 'f', -1, 64)
+}
+
+// OneOf accepts the values of an enum type, which reports them through IsValid
+func OneOf(options ...string) Check {
+	return Check{Code: CodeInvalidValue, Message: "must be one of " + strings.Join(options, ", "), Valid: func(value interface{}) bool {
+		enum, ok := value.(interface{ IsValid() bool })
+		return ok && (isZero(value) || enum.IsValid())
+	}}
+}
+
+// Pattern accepts strings that match a description checked by valid
+func Pattern(message string, valid func(s string) bool) Check {
+	return Check{Code: CodeInvalidFormat, Message: message, Valid: func(value interface{}) bool {
+		s, _ := value.(string)
+		return s == "" || valid(s)
+	}}
+}
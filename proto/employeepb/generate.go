@@ -0,0 +1,4 @@
+// Package employeepb holds the gRPC API generated from employee.proto.
+package employeepb
+
+//go:generate protoc -I .. --go_out=.. --go_opt=paths=source_relative --go-grpc_out=.. --go-grpc_opt=paths=source_relative employeepb/employee.proto
@@ -1,11 +1,157 @@
 package providers
 
-import "Techiebulter/interview/backend/models"
+import (
+	"Techiebulter/interview/backend/models"
+	"time"
+)
 
 type DbHelperProvider interface {
 	CreateEmployee(employee models.Employee) error
+	// InsertEmployee is CreateEmployee returning the stored employee with its ID
+	InsertEmployee(employee models.Employee) (models.Employee, error)
 	GetEmployeeById(id int) (models.Employee, error)
 	UpdateEmployee(empolyee models.Employee) (models.Employee, error)
 	DeleteEmployeeById(id int) error
 	GetAllEmployees(page string, limit string) ([]models.Employee, error)
+	// GetEmployeesByIds reads many employees in one query, IDs without an employee are left out
+	GetEmployeesByIds(ids []int) ([]models.Employee, error)
+	// SearchEmployees returns up to limit employees matching filter with an ID above afterID, by ID
+	SearchEmployees(filter models.EmployeeFilter, afterID int, limit int) ([]models.Employee, error)
+
+	// PatchEmployee locks the employee, passes it to patch and stores every field of the result in one
+	// transaction. patch must not use the database, an error from it is returned unchanged.
+	PatchEmployee(id int, patch func(current models.Employee) (models.Employee, error)) (models.Employee, error)
+
+	// MergeEmployees merges the duplicate into the survivor as request describes in one transaction: the merged
+	// survivor is validated and stored, the duplicate is deleted and its ID redirects to the survivor. Reading a
+	// merged ID from GetEmployeeById returns an *EmployeeMergedError.
+	MergeEmployees(request models.MergeRequest, mergedBy string) (models.Employee, error)
+	// GetEmployeeMerges lists the merges into the survivor, oldest first
+	GetEmployeeMerges(survivorID int) ([]models.EmployeeMerge, error)
+
+	// custom field schema defined by admins, employee custom fields are validated against it
+	CreateCustomFieldDefinition(definition models.CustomFieldDefinition) error
+	GetCustomFieldDefinitions() ([]models.CustomFieldDefinition, error)
+	DeleteCustomFieldDefinition(name string) error
+
+	// ClaimIdempotencyKey stores record unless its scope and key are taken by a record that has not expired,
+	// in which case that record is returned with claimed set to false
+	ClaimIdempotencyKey(record models.IdempotencyRecord) (stored models.IdempotencyRecord, claimed bool, err error)
+	// CompleteIdempotencyKey stores the response of a claimed key and keeps it until record.ExpiresAt
+	CompleteIdempotencyKey(record models.IdempotencyRecord) error
+	// ReleaseIdempotencyKey forgets a claimed key, so the request can be retried
+	ReleaseIdempotencyKey(scope string, key string) error
+
+	// AppendEmployeeEvent stores event in the event log and returns it with its ID. The employee writes
+	// above log their changes themselves, in their own transaction. Every logged event is queued in the
+	// outbox in the transaction that logs it.
+	AppendEmployeeEvent(event models.EmployeeEvent) (models.EmployeeEvent, error)
+	// GetLatestEmployeeEventID returns the ID of the newest logged event, 0 when none was logged
+	GetLatestEmployeeEventID() (int64, error)
+	// GetEmployeeEvents returns up to limit logged events with an ID above afterID, oldest first.
+	// Only events of the given types are returned, or of every type when types is empty.
+	GetEmployeeEvents(afterID int64, types []models.EmployeeEventType, limit int) ([]models.EmployeeEvent, error)
+
+	// webhook subscriptions managed by admins, their secrets are returned as well
+	CreateWebhookSubscription(subscription models.WebhookSubscription) (models.WebhookSubscription, error)
+	GetWebhookSubscriptions() ([]models.WebhookSubscription, error)
+	// DeleteWebhookSubscription removes the subscription with its deliveries, ErrWebhookNotFound if there is none
+	DeleteWebhookSubscription(id int) error
+
+	// QueueWebhookDeliveries queues a delivery of a logged event for every subscription to its type and
+	// returns how many it queued. Deliveries queued for the event before are kept as they are.
+	QueueWebhookDeliveries(event models.EmployeeEvent) (int, error)
+	// ClaimWebhookDeliveries returns up to limit pending deliveries due at now, oldest first, and postpones
+	// them to leaseUntil, so no other instance attempts them while they are being posted
+	ClaimWebhookDeliveries(now time.Time, leaseUntil time.Time, limit int) ([]models.WebhookDelivery, error)
+	// CompleteWebhookAttempt stores the Status, Attempts, NextAttemptAt, LastStatusCode, LastError and
+	// DeliveredAt of a claimed delivery after an attempt
+	CompleteWebhookAttempt(delivery models.WebhookDelivery) error
+	// GetWebhookDeliveries lists up to limit deliveries of a subscription newest first, only those with
+	// status unless it is empty
+	GetWebhookDeliveries(subscriptionID int, status models.WebhookDeliveryStatus, limit int) ([]models.WebhookDelivery, error)
+	// RedeliverWebhook makes a delivery of the subscription pending and due again with no attempts made,
+	// ErrWebhookDeliveryNotFound if there is none
+	RedeliverWebhook(subscriptionID int, deliveryID int64) (models.WebhookDelivery, error)
+
+	// ClaimOutboxMessages returns up to limit outbox messages due at now, oldest first, and postpones them
+	// to leaseUntil, so no other instance publishes them meanwhile. A message is only returned once the
+	// older messages of its employee are published, so every employee's events are published in order.
+	ClaimOutboxMessages(now time.Time, leaseUntil time.Time, limit int) ([]models.OutboxMessage, error)
+	// CompleteOutboxAttempt removes a claimed message once published, and otherwise stores its Attempts,
+	// NextAttemptAt and LastError
+	CompleteOutboxAttempt(message models.OutboxMessage, published bool) error
+	// GetOutboxBacklog counts the outbox messages not published yet
+	GetOutboxBacklog() (models.OutboxBacklog, error)
+
+	// leave types defined by HR, ErrLeaveTypeExists if the name is taken
+	CreateLeaveType(leaveType models.LeaveType) (models.LeaveType, error)
+	GetLeaveTypes() ([]models.LeaveType, error)
+	// CreateLeaveRequest stores a validated request as pending. It returns ErrEmployeeNotFound,
+	// ErrLeaveTypeNotFound, ErrLeaveOverlap when the employee has pending or approved leave on one of the
+	// days, and ErrInsufficientLeave when the balance on the first day, less the pending days, is too small.
+	CreateLeaveRequest(request models.LeaveRequest) (models.LeaveRequest, error)
+	// GetLeaveRequest reads one request, ErrLeaveRequestNotFound if there is none
+	GetLeaveRequest(id int) (models.LeaveRequest, error)
+	// GetLeaveRequests lists the requests of an employee by start date, only those with status unless it is empty
+	GetLeaveRequests(employeeID int, status models.LeaveRequestStatus) ([]models.LeaveRequest, error)
+	// GetEmployeesOnLeave lists the IDs of the employees an approved request covers on day
+	GetEmployeesOnLeave(day models.Date) ([]int, error)
+	// DecideLeaveRequest moves a request to status if LeaveRequest.CanBecome allows it today, and returns
+	// ErrLeaveRequestDecided otherwise. Approvals return ErrInsufficientLeave when the balance on the first
+	// day no longer covers the request.
+	DecideLeaveRequest(id int, status models.LeaveRequestStatus, decidedBy string, note string) (models.LeaveRequest, error)
+
+	// ClockIn opens an attendance entry for the employee now. It returns ErrEmployeeNotFound, and
+	// ErrAlreadyClockedIn when the employee has an open entry.
+	ClockIn(employeeID int, note string) (models.AttendanceEntry, error)
+	// ClockOut closes the open attendance entry of the employee now, ErrNotClockedIn if there is none
+	ClockOut(employeeID int) (models.AttendanceEntry, error)
+	// GetAttendance lists the entries clocked in from the first to the last day, both included, by clock
+	// in time. Entries of every employee are listed when employeeID is 0.
+	GetAttendance(employeeID int, from models.Date, to models.Date) ([]models.AttendanceEntry, error)
+	// CreateTimesheet stores a validated timesheet as submitted. It returns ErrEmployeeNotFound, and
+	// ErrTimesheetExists when the week has a submitted or approved timesheet of the employee.
+	CreateTimesheet(timesheet models.Timesheet) (models.Timesheet, error)
+	// GetTimesheet reads one timesheet, ErrTimesheetNotFound if there is none
+	GetTimesheet(id int) (models.Timesheet, error)
+	// GetTimesheets lists the timesheets matching filter by week and ID
+	GetTimesheets(filter models.TimesheetFilter) ([]models.Timesheet, error)
+	// DecideTimesheet approves or rejects a submitted timesheet, ErrTimesheetDecided if it is not submitted
+	DecideTimesheet(id int, status models.TimesheetStatus, decidedBy string, note string) (models.Timesheet, error)
+
+	// GetSalaryHistory lists the salary changes effective on or before until by employee and effective date,
+	// those of every employee when employeeID is 0. The employee writes above record a change whenever the
+	// stored salary differs from the last recorded one.
+	GetSalaryHistory(employeeID int, until models.Date) ([]models.SalaryChange, error)
+	// SavePayrollRun stores a computed run with its payslips as a draft, replacing the draft of its period if
+	// there is one. It returns ErrPayrollRunApproved when the run of the period is approved.
+	SavePayrollRun(run models.PayrollRun) (models.PayrollRun, error)
+	// GetPayrollRuns lists the runs by period, without their payslips
+	GetPayrollRuns() ([]models.PayrollRun, error)
+	// GetPayrollRun reads a run with its payslips ordered by employee ID, ErrPayrollRunNotFound if there is none
+	GetPayrollRun(id int) (models.PayrollRun, error)
+	// ApprovePayrollRun locks a draft run, ErrPayrollRunApproved if it is approved already
+	ApprovePayrollRun(id int, approvedBy string) (models.PayrollRun, error)
+	// GetPayslips lists the payslips of an employee in approved runs by period
+	GetPayslips(employeeID int) ([]models.Payslip, error)
+	// GetPayslip reads the payslip of an employee for a period, ErrPayslipNotFound unless its run is approved
+	GetPayslip(employeeID int, period models.PayPeriod) (models.Payslip, error)
+
+	// SaveExchangeRates stores rates in one transaction, replacing the rate of a currency on the same
+	// effective date, and returns them as stored
+	SaveExchangeRates(rates []models.ExchangeRate) ([]models.ExchangeRate, error)
+	// GetExchangeRates lists the rates matching filter by currency and effective date
+	GetExchangeRates(filter models.ExchangeRateFilter) ([]models.ExchangeRate, error)
+
+	// salary bands defined by HR, ErrSalaryBandExists if another band has a position with the same
+	// PositionKey and ErrSalaryBandNotFound if no band has the ID
+	CreateSalaryBand(band models.SalaryBand) (models.SalaryBand, error)
+	// GetSalaryBands lists the bands by position
+	GetSalaryBands() ([]models.SalaryBand, error)
+	UpdateSalaryBand(band models.SalaryBand) (models.SalaryBand, error)
+	DeleteSalaryBand(id int) error
+
+	// ReadFromPrimary returns a helper whose reads skip the read replicas
+	ReadFromPrimary() DbHelperProvider
 }
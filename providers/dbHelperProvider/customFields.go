@@ -0,0 +1,96 @@
+package dbHelperProvider
+
+import (
+	"Techiebulter/interview/backend/models"
+	"Techiebulter/interview/backend/providers"
+	"context"
+	"errors"
+	"log"
+	"time"
+
+	"github.com/lib/pq"
+)
+
+// CreateCustomFieldDefinition adds a custom field to the employee schema.
+func (dh *DBHelper) CreateCustomFieldDefinition(definition models.CustomFieldDefinition) error {
+	if err := dh.ensureMigrated(); err != nil {
+		return err
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	query := `
+        INSERT INTO custom_field_definitions (name, type, required, options, description)
+        VALUES ($1, $2, $3, $4, $5)
+    `
+	_, err := dh.pgClient.ExecContext(ctx, query, definition.Name, definition.Type, definition.Required,
+		definition.Options, definition.Description)
+	if err != nil {
+		var pqErr *pq.Error
+		if errors.As(err, &pqErr) && pqErr.Code == "23505" {
+			return providers.ErrCustomFieldExists
+		}
+		log.Println("CreateCustomFieldDefinition: unable to insert definition into database:", err)
+		return err
+	}
+
+	return nil
+}
+
+// GetCustomFieldDefinitions lists the custom field schema ordered by name.
+func (dh *DBHelper) GetCustomFieldDefinitions() ([]models.CustomFieldDefinition, error) {
+	if err := dh.ensureMigrated(); err != nil {
+		return nil, err
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	query := `
+        SELECT name, type, required, options, description, created_at
+        FROM custom_field_definitions
+        ORDER BY name
+    `
+	rows, err := dh.reader().QueryContext(ctx, query)
+	if err != nil {
+		log.Println("GetCustomFieldDefinitions: error getting results from database:", err)
+		return nil, err
+	}
+	defer rows.Close()
+
+	definitions := []models.CustomFieldDefinition{}
+	for rows.Next() {
+		var definition models.CustomFieldDefinition
+		err := rows.Scan(&definition.Name, &definition.Type, &definition.Required, &definition.Options,
+			&definition.Description, &definition.CreatedAt)
+		if err != nil {
+			log.Println("GetCustomFieldDefinitions: error scanning row:", err)
+			return nil, err
+		}
+		definitions = append(definitions, definition)
+	}
+
+	return definitions, rows.Err()
+}
+
+// DeleteCustomFieldDefinition removes a custom field from the schema, values already stored on employees are kept.
+func (dh *DBHelper) DeleteCustomFieldDefinition(name string) error {
+	if err := dh.ensureMigrated(); err != nil {
+		return err
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	result, err := dh.pgClient.ExecContext(ctx, `DELETE FROM custom_field_definitions WHERE name = $1`, name)
+	if err != nil {
+		log.Println("DeleteCustomFieldDefinition: error deleting definition from database:", err)
+		return err
+	}
+	if deleted, _ := result.RowsAffected(); deleted == 0 {
+		return providers.ErrCustomFieldNotFound
+	}
+
+	return nil
+}
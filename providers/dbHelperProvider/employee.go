@@ -2,59 +2,140 @@ package dbHelperProvider
 
 import (
 	"Techiebulter/interview/backend/models"
+	"Techiebulter/interview/backend/providers"
 	"context"
 	"database/sql"
+	"errors"
 	"fmt"
 	"log"
 	"strconv"
 	"strings"
 	"time"
+
+	"github.com/lib/pq"
 )
 
+// employeeColumns is selected by every query returning employees, in the order scanEmployee expects
+const employeeColumns = `id, name, position, salary, COALESCE(email, ''), phone, hire_date, termination_date,
+        employment_type, status, location, department, manager_id, custom_fields, currency, created_at, updated_at`
+
+// scanEmployee scans a row selected with employeeColumns
+func scanEmployee(row interface {
+	Scan(dest ...interface{}) error
+}, emp *models.Employee) error {
+	return row.Scan(&emp.ID, &emp.Name, &emp.Position, &emp.Salary, &emp.Email, &emp.Phone, &emp.HireDate, &emp.TerminationDate,
+		&emp.EmploymentType, &emp.Status, &emp.Location, &emp.Department, &emp.ManagerID, &emp.CustomFields, &emp.Currency,
+		&emp.CreatedAt, &emp.UpdatedAt)
+}
+
+// translateError maps missing rows and constraint violations to the errors of the providers package
+func translateError(err error) error {
+	if errors.Is(err, sql.ErrNoRows) {
+		return providers.ErrEmployeeNotFound
+	}
+	var pqErr *pq.Error
+	if errors.As(err, &pqErr) && pqErr.Code == "23505" && pqErr.Constraint == "employees_email_key" {
+		return providers.ErrEmailTaken
+	}
+	if errors.As(err, &pqErr) && pqErr.Code == "23514" && pqErr.Constraint == "employees_termination_after_hire" {
+		return providers.ErrTerminationBeforeHire
+	}
+	if errors.As(err, &pqErr) && pqErr.Code == "23503" && pqErr.Constraint == "employees_manager_id_fkey" {
+		return providers.ErrManagerNotFound
+	}
+	return err
+}
+
+// replaceEmployeeQuery writes every column of an employee
+const replaceEmployeeQuery = `
+        UPDATE employees
+        SET name = $1, position = $2, salary = $3, email = NULLIF($4, ''), phone = $5, hire_date = $6,
+            termination_date = $7, employment_type = $8, status = $9, location = $10, department = $11,
+            manager_id = $12, custom_fields = $13, currency = COALESCE(NULLIF($14, ''), currency), updated_at = now()
+        WHERE id = $15
+        RETURNING ` + employeeColumns
+
+// replaceEmployee stores every field of employee in the row with the given ID and returns the stored row
+func replaceEmployee(ctx context.Context, tx *sql.Tx, id int, employee models.Employee) (models.Employee, error) {
+	var stored models.Employee
+	err := scanEmployee(tx.QueryRowContext(ctx, replaceEmployeeQuery, employee.Name, employee.Position, employee.Salary,
+		employee.Email, employee.Phone, employee.HireDate, employee.TerminationDate, employee.EmploymentType,
+		employee.Status, employee.Location, employee.Department, employee.ManagerID, employee.CustomFields, employee.Currency, id), &stored)
+	return stored, translateError(err)
+}
+
+// recordChange records that employee was stored as it is now, in the transaction that stored it, and
+// adds its salary to the salary history when it changed
+func recordChange(ctx context.Context, tx *sql.Tx, eventType models.EmployeeEventType, employee models.Employee) error {
+	if err := recordSalary(ctx, tx, employee, eventType == models.EmployeeCreated); err != nil {
+		return err
+	}
+	_, err := recordEmployeeEvent(ctx, tx, models.EmployeeEvent{Type: eventType, EmployeeID: employee.ID, Employee: &employee,
+		Time: time.Now().UTC()})
+	return err
+}
+
+// recordDeletion records that the employee with the given ID was deleted, in the transaction that deleted it
+func recordDeletion(ctx context.Context, tx *sql.Tx, id int) error {
+	_, err := recordEmployeeEvent(ctx, tx, models.EmployeeEvent{Type: models.EmployeeDeleted, EmployeeID: id, Time: time.Now().UTC()})
+	return err
+}
+
 // CreateEmployee creates a new employee record in the database.
 func (dh *DBHelper) CreateEmployee(employee models.Employee) error {
+	_, err := dh.InsertEmployee(employee)
+	return err
+}
+
+// InsertEmployee creates a new employee record and returns it as stored, with its ID.
+func (dh *DBHelper) InsertEmployee(employee models.Employee) (models.Employee, error) {
+	var created models.Employee
+
+	if err := dh.ensureMigrated(); err != nil {
+		return created, err
+	}
+
 	// Set a timeout for the database operation
 	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
 	defer cancel()
 
-	// Define the SQL query for creating the employees table if it doesn't exist
-	createTableQuery := `
-        DO $$ BEGIN
-            CREATE TABLE IF NOT EXISTS employees (
-                id SERIAL PRIMARY KEY,
-                name VARCHAR(255) NOT NULL,
-                position VARCHAR(255) NOT NULL,
-                salary NUMERIC(10, 2) NOT NULL
-            );
-        EXCEPTION
-            WHEN duplicate_table THEN
-                -- Table already exists, do nothing
-                NULL;
-        END $$;
-    `
-
 	// Define the SQL query for inserting values into the employees table
 	insertQuery := `
-        INSERT INTO employees (name, position, salary)
-        VALUES ($1, $2, $3)
-    `
+        INSERT INTO employees (name, position, salary, email, phone, hire_date, termination_date,
+            employment_type, status, location, department, manager_id, custom_fields, currency)
+        VALUES ($1, $2, $3, NULLIF($4, ''), $5, $6, $7, $8, $9, $10, $11, $12, $13, $14)
+        RETURNING ` + employeeColumns
 
-	// Execute the create table query to ensure the table exists
-	_, err := dh.pgClient.ExecContext(ctx, createTableQuery)
+	tx, err := dh.pgClient.BeginTx(ctx, nil)
 	if err != nil {
-		log.Print("CreateEmployee: unable to create employees table:", err)
-		return err
+		log.Println("InsertEmployee: unable to begin transaction:", err)
+		return created, err
 	}
+	defer func() { _ = tx.Rollback() }()
 
 	// Execute the insert query to add the new employee
-	_, err = dh.pgClient.ExecContext(ctx, insertQuery, employee.Name, employee.Position, employee.Salary)
+	employee.ApplyDefaults()
+	err = scanEmployee(tx.QueryRowContext(ctx, insertQuery, employee.Name, employee.Position, employee.Salary,
+		employee.Email, employee.Phone, employee.HireDate, employee.TerminationDate,
+		employee.EmploymentType, employee.Status, employee.Location, employee.Department, employee.ManagerID,
+		employee.CustomFields, employee.Currency), &created)
 	if err != nil {
-		log.Print("CreateEmployee: unable to insert employee into database:", err)
-		return err
+		log.Print("InsertEmployee: unable to insert employee into database:", err)
+		return created, translateError(err)
+	}
+
+	if err := recordChange(ctx, tx, models.EmployeeCreated, created); err != nil {
+		log.Println("InsertEmployee:", err)
+		return created, err
+	}
+
+	if err := tx.Commit(); err != nil {
+		log.Println("InsertEmployee: unable to commit transaction:", err)
+		return created, err
 	}
 
 	// Employee successfully created
-	return nil
+	return created, nil
 }
 
 // GetEmployeeById retrieves an employee from the database by their ID.
@@ -62,23 +143,31 @@ func (dh *DBHelper) GetEmployeeById(id int) (models.Employee, error) {
 	// Initialize an empty Employee struct to store the result
 	var emp models.Employee
 
+	if err := dh.ensureMigrated(); err != nil {
+		return emp, err
+	}
+
 	// Set a timeout for the database operation
 	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
 	defer cancel()
 
 	// Define the SQL query to select an employee by ID
 	query := `
-        SELECT id, name, position, salary
+        SELECT ` + employeeColumns + `
         FROM employees
         WHERE id = $1
     `
 
 	// Execute the SQL query to retrieve the employee by ID
-	err := dh.pgClient.QueryRowContext(ctx, query, id).Scan(&emp.ID, &emp.Name, &emp.Position, &emp.Salary)
+	err := scanEmployee(dh.reader().QueryRowContext(ctx, query, id), &emp)
 	if err != nil {
 		if err == sql.ErrNoRows {
+			// The ID may belong to a duplicate that was merged into another employee
+			if target, redirectErr := redirectOf(ctx, dh.reader(), id); redirectErr == nil {
+				return emp, &providers.EmployeeMergedError{ID: id, MergedInto: target}
+			}
 			// If no employee with the given ID is found, return a specific error
-			return emp, fmt.Errorf("employee with ID %d not found", id)
+			return emp, &providers.EmployeeNotFoundError{ID: id}
 		}
 		// If there's an error other than "no rows", return it
 		log.Println("GetEmployeeById: error retrieving employee from database:", err)
@@ -94,6 +183,10 @@ func (dh *DBHelper) UpdateEmployee(employee models.Employee) (models.Employee, e
 	// Initialize an empty Employee struct to store the updated details
 	var updatedEmployee models.Employee
 
+	if err := dh.ensureMigrated(); err != nil {
+		return updatedEmployee, err
+	}
+
 	// Set a timeout for the database operation
 	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
 	defer cancel()
@@ -102,32 +195,84 @@ func (dh *DBHelper) UpdateEmployee(employee models.Employee) (models.Employee, e
 	query := "UPDATE employees SET"
 	var args []interface{}
 
+	// set adds a column assignment, numbering the placeholders by the arguments collected so far
+	set := func(column string, value interface{}) {
+		args = append(args, value)
+		query += fmt.Sprintf(" %s = $%d,", column, len(args))
+	}
+
 	// Check each field of the employee struct and add non-zero and non-empty fields to the query
 	if employee.Name != "" {
-		query += " name = $1,"
-		args = append(args, employee.Name)
+		set("name", employee.Name)
 	}
 	if employee.Position != "" {
-		query += " position = $2,"
-		args = append(args, employee.Position)
+		set("position", employee.Position)
 	}
-	if employee.Salary != 0 {
-		query += " salary = $3,"
-		args = append(args, employee.Salary)
+	if !employee.Salary.IsZero() {
+		set("salary", employee.Salary)
+	}
+	if employee.Currency != "" {
+		set("currency", employee.Currency)
+	}
+	if employee.Email != "" {
+		set("email", employee.Email)
+	}
+	if employee.Phone != "" {
+		set("phone", employee.Phone)
+	}
+	if employee.HireDate != nil {
+		set("hire_date", employee.HireDate)
+	}
+	if employee.TerminationDate != nil {
+		set("termination_date", employee.TerminationDate)
+	}
+	if employee.EmploymentType != "" {
+		set("employment_type", employee.EmploymentType)
+	}
+	if employee.Status != "" {
+		set("status", employee.Status)
+	}
+	if employee.Location != "" {
+		set("location", employee.Location)
+	}
+	if employee.Department != "" {
+		set("department", employee.Department)
+	}
+	if employee.ManagerID != nil {
+		set("manager_id", employee.ManagerID)
+	}
+	if employee.CustomFields != nil {
+		set("custom_fields", employee.CustomFields)
+	}
+	if len(args) == 0 {
+		return updatedEmployee, errors.New("no fields to update")
 	}
-
-	// Remove the trailing comma from the query
-	query = strings.TrimSuffix(query, ",")
 
 	// Add the WHERE clause to update the employee with the given ID
-	query += " WHERE id = $4 RETURNING id, name, position, salary"
 	args = append(args, employee.ID)
+	query += fmt.Sprintf(" updated_at = now() WHERE id = $%d RETURNING %s", len(args), employeeColumns)
+
+	tx, err := dh.pgClient.BeginTx(ctx, nil)
+	if err != nil {
+		log.Println("UpdateEmployee: unable to begin transaction:", err)
+		return updatedEmployee, err
+	}
+	defer func() { _ = tx.Rollback() }()
 
 	// Execute the SQL query to update the employee's details and retrieve the updated record
-	err := dh.pgClient.QueryRowContext(ctx, query, args...).
-		Scan(&updatedEmployee.ID, &updatedEmployee.Name, &updatedEmployee.Position, &updatedEmployee.Salary)
+	err = scanEmployee(tx.QueryRowContext(ctx, query, args...), &updatedEmployee)
 	if err != nil {
 		log.Println("UpdateEmployee: error updating employee details in database:", err)
+		return updatedEmployee, translateError(err)
+	}
+
+	if err := recordChange(ctx, tx, models.EmployeeUpdated, updatedEmployee); err != nil {
+		log.Println("UpdateEmployee:", err)
+		return updatedEmployee, err
+	}
+
+	if err := tx.Commit(); err != nil {
+		log.Println("UpdateEmployee: unable to commit transaction:", err)
 		return updatedEmployee, err
 	}
 
@@ -135,8 +280,68 @@ func (dh *DBHelper) UpdateEmployee(employee models.Employee) (models.Employee, e
 	return updatedEmployee, nil
 }
 
-// DeleteEmployeeById deletes an employee from the database by their ID.
+// PatchEmployee replaces every field of an employee with the result of patch, holding a row lock so
+// concurrent patches apply one after the other.
+func (dh *DBHelper) PatchEmployee(id int, patch func(current models.Employee) (models.Employee, error)) (models.Employee, error) {
+	var patched models.Employee
+
+	if err := dh.ensureMigrated(); err != nil {
+		return patched, err
+	}
+
+	// Set a timeout for the database operation
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	tx, err := dh.pgClient.BeginTx(ctx, nil)
+	if err != nil {
+		log.Println("PatchEmployee: unable to begin transaction:", err)
+		return patched, err
+	}
+	defer func() { _ = tx.Rollback() }()
+
+	// Lock the employee so nobody changes it between reading and writing
+	var current models.Employee
+	query := `SELECT ` + employeeColumns + ` FROM employees WHERE id = $1 FOR UPDATE`
+	if err := scanEmployee(tx.QueryRowContext(ctx, query, id), &current); err != nil {
+		if err == sql.ErrNoRows {
+			return patched, providers.ErrEmployeeNotFound
+		}
+		log.Println("PatchEmployee: error retrieving employee from database:", err)
+		return patched, err
+	}
+
+	employee, err := patch(current)
+	if err != nil {
+		return patched, err
+	}
+
+	// Write every column, so fields the patch cleared are cleared in the database too
+	patched, err = replaceEmployee(ctx, tx, id, employee)
+	if err != nil {
+		log.Println("PatchEmployee: error updating employee in database:", err)
+		return patched, err
+	}
+
+	if err := recordChange(ctx, tx, models.EmployeeUpdated, patched); err != nil {
+		log.Println("PatchEmployee:", err)
+		return patched, err
+	}
+
+	if err := tx.Commit(); err != nil {
+		log.Println("PatchEmployee: unable to commit transaction:", err)
+		return patched, err
+	}
+
+	return patched, nil
+}
+
+// DeleteEmployeeById deletes an employee from the database by their ID, it returns ErrEmployeeNotFound if none has it.
 func (dh *DBHelper) DeleteEmployeeById(id int) error {
+	if err := dh.ensureMigrated(); err != nil {
+		return err
+	}
+
 	// Set a timeout for the database operation
 	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
 	defer cancel()
@@ -147,13 +352,34 @@ func (dh *DBHelper) DeleteEmployeeById(id int) error {
         WHERE id = $1
     `
 
+	tx, err := dh.pgClient.BeginTx(ctx, nil)
+	if err != nil {
+		log.Println("DeleteEmployeeById: unable to begin transaction:", err)
+		return err
+	}
+	defer func() { _ = tx.Rollback() }()
+
 	// Execute the SQL query to delete the employee by ID
-	_, err := dh.pgClient.ExecContext(ctx, query, id)
+	result, err := tx.ExecContext(ctx, query, id)
 	if err != nil {
 		log.Println("DeleteEmployeeById: error deleting employee from database:", err)
 		return err
 	}
 
+	// Deleting an employee that does not exist changes nothing
+	if deleted, _ := result.RowsAffected(); deleted == 0 {
+		return &providers.EmployeeNotFoundError{ID: id}
+	}
+	if err := recordDeletion(ctx, tx, id); err != nil {
+		log.Println("DeleteEmployeeById:", err)
+		return err
+	}
+
+	if err := tx.Commit(); err != nil {
+		log.Println("DeleteEmployeeById: unable to commit transaction:", err)
+		return err
+	}
+
 	// Employee successfully deleted
 	return nil
 }
@@ -163,6 +389,10 @@ func (dh *DBHelper) GetAllEmployees(page string, limit string) ([]models.Employe
 	// Initialize a slice of Employee structs to store the results
 	var employees []models.Employee
 
+	if err := dh.ensureMigrated(); err != nil {
+		return nil, err
+	}
+
 	// Set a timeout for the database operation
 	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
 	defer cancel()
@@ -182,14 +412,14 @@ func (dh *DBHelper) GetAllEmployees(page string, limit string) ([]models.Employe
 
 	// Define the SQL query to select employees with pagination
 	query := `
-        SELECT id, name, position, salary
+        SELECT ` + employeeColumns + `
         FROM employees
         ORDER BY id
         LIMIT $1 OFFSET $2
     `
 
 	// Execute the SQL query to retrieve employees with pagination
-	rows, err := dh.pgClient.QueryContext(ctx, query, limitNumber, offset)
+	rows, err := dh.reader().QueryContext(ctx, query, limitNumber, offset)
 	if err != nil {
 		log.Println("GetAllEmployees: error getting results from database:", err)
 		return nil, err
@@ -199,7 +429,7 @@ func (dh *DBHelper) GetAllEmployees(page string, limit string) ([]models.Employe
 	// Iterate through the result rows and scan each employee into the slice
 	for rows.Next() {
 		var emp models.Employee
-		err := rows.Scan(&emp.ID, &emp.Name, &emp.Position, &emp.Salary)
+		err := scanEmployee(rows, &emp)
 		if err != nil {
 			log.Println("GetAllEmployees: error scanning row:", err)
 			return nil, err
@@ -216,3 +446,119 @@ func (dh *DBHelper) GetAllEmployees(page string, limit string) ([]models.Employe
 	// Return the slice of employees and nil error
 	return employees, nil
 }
+
+// GetEmployeesByIds retrieves the employees with the given IDs in one query, IDs without an employee are skipped.
+func (dh *DBHelper) GetEmployeesByIds(ids []int) ([]models.Employee, error) {
+	var employees []models.Employee
+
+	if err := dh.ensureMigrated(); err != nil {
+		return nil, err
+	}
+
+	// Set a timeout for the database operation
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	query := `
+        SELECT ` + employeeColumns + `
+        FROM employees
+        WHERE id = ANY($1)
+        ORDER BY id
+    `
+
+	rows, err := dh.reader().QueryContext(ctx, query, pq.Array(ids))
+	if err != nil {
+		log.Println("GetEmployeesByIds: error getting results from database:", err)
+		return nil, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var emp models.Employee
+		if err := scanEmployee(rows, &emp); err != nil {
+			log.Println("GetEmployeesByIds: error scanning row:", err)
+			return nil, err
+		}
+		employees = append(employees, emp)
+	}
+	if err := rows.Err(); err != nil {
+		log.Println("GetEmployeesByIds: error iterating over rows:", err)
+		return nil, err
+	}
+
+	return employees, nil
+}
+
+// SearchEmployees retrieves up to limit employees matching filter with an ID above afterID, ordered by ID.
+func (dh *DBHelper) SearchEmployees(filter models.EmployeeFilter, afterID int, limit int) ([]models.Employee, error) {
+	var employees []models.Employee
+
+	if err := dh.ensureMigrated(); err != nil {
+		return nil, err
+	}
+
+	// Set a timeout for the database operation
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	// Build the conditions of the filter, each set field adds one
+	args := []interface{}{afterID}
+	query := `SELECT ` + employeeColumns + ` FROM employees WHERE id > $1`
+	where := func(condition string, value interface{}) {
+		args = append(args, value)
+		query += fmt.Sprintf(" AND "+condition, len(args))
+	}
+	if filter.NameContains != "" {
+		where("name ILIKE $%d", "%"+escapeLike(filter.NameContains)+"%")
+	}
+	if filter.Position != "" {
+		where("position = $%d", filter.Position)
+	}
+	if filter.Status != "" {
+		where("status = $%d", filter.Status)
+	}
+	if filter.EmploymentType != "" {
+		where("employment_type = $%d", filter.EmploymentType)
+	}
+	if filter.Location != "" {
+		where("location = $%d", filter.Location)
+	}
+	if filter.Department != "" {
+		where("department = $%d", filter.Department)
+	}
+	if filter.HiredFrom != nil {
+		where("hire_date >= $%d", *filter.HiredFrom)
+	}
+	if filter.HiredTo != nil {
+		where("hire_date <= $%d", *filter.HiredTo)
+	}
+	args = append(args, limit)
+	query += fmt.Sprintf(" ORDER BY id LIMIT $%d", len(args))
+
+	rows, err := dh.reader().QueryContext(ctx, query, args...)
+	if err != nil {
+		log.Println("SearchEmployees: error getting results from database:", err)
+		return nil, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var emp models.Employee
+		if err := scanEmployee(rows, &emp); err != nil {
+			log.Println("SearchEmployees: error scanning row:", err)
+			return nil, err
+		}
+		employees = append(employees, emp)
+	}
+	if err := rows.Err(); err != nil {
+		log.Println("SearchEmployees: error iterating over rows:", err)
+		return nil, err
+	}
+
+	return employees, nil
+}
+
+// escapeLike escapes the wildcards of a LIKE pattern, backslash is the escape character
+func escapeLike(s string) string {
+	return strings.NewReplacer(`\`, `\\`, `%`, `\%`, `_`, `\_`).Replace(s)
+}
@@ -0,0 +1,143 @@
+package dbHelperProvider
+
+import (
+	"Techiebulter/interview/backend/models"
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"log"
+	"time"
+
+	"github.com/lib/pq"
+)
+
+// recordEmployeeEvent logs event and queues it in the outbox within tx, so the event is published
+// exactly when the change it reports is committed
+func recordEmployeeEvent(ctx context.Context, tx *sql.Tx, event models.EmployeeEvent) (models.EmployeeEvent, error) {
+	// deletes carry no employee, they are stored as NULL
+	var employee sql.NullString
+	if event.Employee != nil {
+		data, err := json.Marshal(event.Employee)
+		if err != nil {
+			return event, err
+		}
+		employee = sql.NullString{String: string(data), Valid: true}
+	}
+
+	insertQuery := `
+        INSERT INTO employee_events (type, employee_id, employee, occurred_at)
+        VALUES ($1, $2, $3, $4)
+        RETURNING id
+    `
+	err := tx.QueryRowContext(ctx, insertQuery, event.Type, event.EmployeeID, employee, event.Time).Scan(&event.ID)
+	if err != nil {
+		return event, fmt.Errorf("log employee event: %w", err)
+	}
+
+	outboxQuery := `INSERT INTO outbox (event_id, employee_id) VALUES ($1, $2)`
+	if _, err := tx.ExecContext(ctx, outboxQuery, event.ID, event.EmployeeID); err != nil {
+		return event, fmt.Errorf("queue employee event: %w", err)
+	}
+
+	return event, nil
+}
+
+// AppendEmployeeEvent stores an event in the employee event log and the outbox.
+func (dh *DBHelper) AppendEmployeeEvent(event models.EmployeeEvent) (models.EmployeeEvent, error) {
+	if err := dh.ensureMigrated(); err != nil {
+		return event, err
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	tx, err := dh.pgClient.BeginTx(ctx, nil)
+	if err != nil {
+		log.Println("AppendEmployeeEvent: unable to begin transaction:", err)
+		return event, err
+	}
+	defer func() { _ = tx.Rollback() }()
+
+	event, err = recordEmployeeEvent(ctx, tx, event)
+	if err != nil {
+		log.Println("AppendEmployeeEvent: unable to insert event into database:", err)
+		return event, err
+	}
+
+	if err := tx.Commit(); err != nil {
+		log.Println("AppendEmployeeEvent: unable to commit transaction:", err)
+		return event, err
+	}
+
+	return event, nil
+}
+
+// GetLatestEmployeeEventID returns the ID of the newest logged event, 0 when the log is empty.
+func (dh *DBHelper) GetLatestEmployeeEventID() (int64, error) {
+	if err := dh.ensureMigrated(); err != nil {
+		return 0, err
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	var id int64
+	err := dh.reader().QueryRowContext(ctx, `SELECT COALESCE(MAX(id), 0) FROM employee_events`).Scan(&id)
+	if err != nil {
+		log.Println("GetLatestEmployeeEventID: error getting results from database:", err)
+		return 0, err
+	}
+
+	return id, nil
+}
+
+// GetEmployeeEvents reads the employee event log after an event ID.
+func (dh *DBHelper) GetEmployeeEvents(afterID int64, types []models.EmployeeEventType, limit int) ([]models.EmployeeEvent, error) {
+	if err := dh.ensureMigrated(); err != nil {
+		return nil, err
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	typeNames := make([]string, len(types))
+	for i, eventType := range types {
+		typeNames[i] = string(eventType)
+	}
+
+	query := `
+        SELECT id, type, employee_id, employee, occurred_at
+        FROM employee_events
+        WHERE id > $1 AND (cardinality($2::text[]) = 0 OR type = ANY($2))
+        ORDER BY id
+        LIMIT $3
+    `
+	rows, err := dh.reader().QueryContext(ctx, query, afterID, pq.Array(typeNames), limit)
+	if err != nil {
+		log.Println("GetEmployeeEvents: error getting results from database:", err)
+		return nil, err
+	}
+	defer rows.Close()
+
+	events := []models.EmployeeEvent{}
+	for rows.Next() {
+		var (
+			event    models.EmployeeEvent
+			employee []byte
+		)
+		if err := rows.Scan(&event.ID, &event.Type, &event.EmployeeID, &employee, &event.Time); err != nil {
+			log.Println("GetEmployeeEvents: error scanning row:", err)
+			return nil, err
+		}
+		if employee != nil {
+			event.Employee = &models.Employee{}
+			if err := json.Unmarshal(employee, event.Employee); err != nil {
+				return nil, err
+			}
+		}
+		events = append(events, event)
+	}
+
+	return events, rows.Err()
+}
@@ -0,0 +1,88 @@
+package dbHelperProvider
+
+import (
+	"Techiebulter/interview/backend/models"
+	"context"
+	"log"
+	"time"
+)
+
+// exchangeRateColumns is selected by every query returning exchange rates, in the order scanExchangeRate expects
+const exchangeRateColumns = `currency, rate, effective_date, updated_at`
+
+func scanExchangeRate(row interface {
+	Scan(dest ...interface{}) error
+}, rate *models.ExchangeRate) error {
+	return row.Scan(&rate.Currency, &rate.Rate, &rate.EffectiveDate, &rate.UpdatedAt)
+}
+
+// SaveExchangeRates upserts exchange rates keyed by currency and effective date.
+func (dh *DBHelper) SaveExchangeRates(rates []models.ExchangeRate) ([]models.ExchangeRate, error) {
+	if err := dh.ensureMigrated(); err != nil {
+		return nil, err
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	tx, err := dh.pgClient.BeginTx(ctx, nil)
+	if err != nil {
+		log.Println("SaveExchangeRates: unable to begin transaction:", err)
+		return nil, err
+	}
+	defer func() { _ = tx.Rollback() }()
+
+	query := `
+        INSERT INTO exchange_rates (currency, rate, effective_date)
+        VALUES ($1, $2, $3)
+        ON CONFLICT (currency, effective_date) DO UPDATE SET rate = excluded.rate, updated_at = now()
+        RETURNING ` + exchangeRateColumns
+	saved := make([]models.ExchangeRate, len(rates))
+	for i, rate := range rates {
+		if err := scanExchangeRate(tx.QueryRowContext(ctx, query, rate.Currency, rate.Rate, rate.EffectiveDate), &saved[i]); err != nil {
+			log.Println("SaveExchangeRates: unable to upsert exchange rate into database:", err)
+			return nil, err
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		log.Println("SaveExchangeRates: unable to commit transaction:", err)
+		return nil, err
+	}
+	return saved, nil
+}
+
+// GetExchangeRates lists exchange rates, optionally of one currency and up to a day.
+func (dh *DBHelper) GetExchangeRates(filter models.ExchangeRateFilter) ([]models.ExchangeRate, error) {
+	if err := dh.ensureMigrated(); err != nil {
+		return nil, err
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	query := `
+        SELECT ` + exchangeRateColumns + `
+        FROM exchange_rates
+        WHERE ($1 = '' OR currency = $1) AND ($2::date IS NULL OR effective_date <= $2)
+        ORDER BY currency, effective_date
+    `
+	rows, err := dh.reader().QueryContext(ctx, query, filter.Currency, filter.Until)
+	if err != nil {
+		log.Println("GetExchangeRates: error getting results from database:", err)
+		return nil, err
+	}
+	defer rows.Close()
+
+	rates := []models.ExchangeRate{}
+	for rows.Next() {
+		var rate models.ExchangeRate
+		if err := scanExchangeRate(rows, &rate); err != nil {
+			log.Println("GetExchangeRates: error scanning row:", err)
+			return nil, err
+		}
+		rates = append(rates, rate)
+	}
+
+	return rates, rows.Err()
+}
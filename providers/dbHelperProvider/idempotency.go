@@ -0,0 +1,101 @@
+package dbHelperProvider
+
+import (
+	"Techiebulter/interview/backend/models"
+	"context"
+	"database/sql"
+	"errors"
+	"log"
+	"time"
+)
+
+// idempotencyColumns is selected by every query returning idempotency records, in the order scanIdempotencyRecord expects
+const idempotencyColumns = `scope, key, fingerprint, status_code, content_type, body, created_at, expires_at`
+
+func scanIdempotencyRecord(row *sql.Row, record *models.IdempotencyRecord) error {
+	return row.Scan(&record.Scope, &record.Key, &record.Fingerprint, &record.StatusCode, &record.ContentType, &record.Body,
+		&record.CreatedAt, &record.ExpiresAt)
+}
+
+// ClaimIdempotencyKey stores record unless an unexpired record holds the same scope and key.
+func (dh *DBHelper) ClaimIdempotencyKey(record models.IdempotencyRecord) (models.IdempotencyRecord, bool, error) {
+	var stored models.IdempotencyRecord
+
+	if err := dh.ensureMigrated(); err != nil {
+		return stored, false, err
+	}
+
+	// Set a timeout for the database operation
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	// Expired keys are removed on the way, which keeps the table small
+	if _, err := dh.pgClient.ExecContext(ctx, `DELETE FROM idempotency_keys WHERE expires_at <= now()`); err != nil {
+		log.Println("ClaimIdempotencyKey: error deleting expired keys from database:", err)
+		return stored, false, err
+	}
+
+	insertQuery := `
+        INSERT INTO idempotency_keys (scope, key, fingerprint, expires_at)
+        VALUES ($1, $2, $3, $4)
+        ON CONFLICT (scope, key) DO NOTHING
+    `
+	selectQuery := `SELECT ` + idempotencyColumns + ` FROM idempotency_keys WHERE scope = $1 AND key = $2`
+
+	// The stored record can be released between the insert and the select, the insert is retried then
+	for attempt := 0; attempt < 3; attempt++ {
+		result, err := dh.pgClient.ExecContext(ctx, insertQuery, record.Scope, record.Key, record.Fingerprint, record.ExpiresAt)
+		if err != nil {
+			log.Println("ClaimIdempotencyKey: unable to insert key into database:", err)
+			return stored, false, err
+		}
+		if inserted, _ := result.RowsAffected(); inserted == 1 {
+			return record, true, nil
+		}
+
+		err = scanIdempotencyRecord(dh.pgClient.QueryRowContext(ctx, selectQuery, record.Scope, record.Key), &stored)
+		if errors.Is(err, sql.ErrNoRows) {
+			continue
+		}
+		if err != nil {
+			log.Println("ClaimIdempotencyKey: error retrieving key from database:", err)
+		}
+		return stored, false, err
+	}
+
+	return stored, false, errors.New("idempotency key is claimed and released concurrently")
+}
+
+// CompleteIdempotencyKey stores the response of a claimed key, kept until record.ExpiresAt.
+func (dh *DBHelper) CompleteIdempotencyKey(record models.IdempotencyRecord) error {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	query := `
+        UPDATE idempotency_keys
+        SET status_code = $3, content_type = $4, body = $5, expires_at = $6
+        WHERE scope = $1 AND key = $2
+    `
+	_, err := dh.pgClient.ExecContext(ctx, query, record.Scope, record.Key, record.StatusCode, record.ContentType, record.Body,
+		record.ExpiresAt)
+	if err != nil {
+		log.Println("CompleteIdempotencyKey: error updating key in database:", err)
+		return err
+	}
+
+	return nil
+}
+
+// ReleaseIdempotencyKey forgets a claimed key.
+func (dh *DBHelper) ReleaseIdempotencyKey(scope string, key string) error {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	_, err := dh.pgClient.ExecContext(ctx, `DELETE FROM idempotency_keys WHERE scope = $1 AND key = $2`, scope, key)
+	if err != nil {
+		log.Println("ReleaseIdempotencyKey: error deleting key from database:", err)
+		return err
+	}
+
+	return nil
+}
@@ -0,0 +1,338 @@
+package dbHelperProvider
+
+import (
+	"Techiebulter/interview/backend/models"
+	"Techiebulter/interview/backend/providers"
+	"context"
+	"database/sql"
+	"errors"
+	"log"
+	"time"
+
+	"github.com/lib/pq"
+)
+
+// leaveRequestColumns is selected by every query returning leave requests, in the order scanLeaveRequest expects
+const leaveRequestColumns = `id, employee_id, leave_type_id, start_date, end_date, days, reason, status, decided_by,
+    decision_note, decided_at, created_at`
+
+func scanLeaveRequest(row interface {
+	Scan(dest ...interface{}) error
+}, request *models.LeaveRequest) error {
+	return row.Scan(&request.ID, &request.EmployeeID, &request.LeaveTypeID, &request.StartDate, &request.EndDate, &request.Days,
+		&request.Reason, &request.Status, &request.DecidedBy, &request.DecisionNote, &request.DecidedAt, &request.CreatedAt)
+}
+
+// CreateLeaveType stores a leave type.
+func (dh *DBHelper) CreateLeaveType(leaveType models.LeaveType) (models.LeaveType, error) {
+	if err := dh.ensureMigrated(); err != nil {
+		return leaveType, err
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	query := `
+        INSERT INTO leave_types (name, accrual, days_per_period)
+        VALUES ($1, $2, $3)
+        RETURNING id, created_at
+    `
+	err := dh.pgClient.QueryRowContext(ctx, query, leaveType.Name, leaveType.Accrual, leaveType.DaysPerPeriod).
+		Scan(&leaveType.ID, &leaveType.CreatedAt)
+	if err != nil {
+		var pqErr *pq.Error
+		if errors.As(err, &pqErr) && pqErr.Code == "23505" {
+			return leaveType, providers.ErrLeaveTypeExists
+		}
+		log.Println("CreateLeaveType: unable to insert leave type into database:", err)
+		return leaveType, err
+	}
+
+	return leaveType, nil
+}
+
+// GetLeaveTypes lists the leave types ordered by ID.
+func (dh *DBHelper) GetLeaveTypes() ([]models.LeaveType, error) {
+	if err := dh.ensureMigrated(); err != nil {
+		return nil, err
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	rows, err := dh.reader().QueryContext(ctx, `SELECT id, name, accrual, days_per_period, created_at FROM leave_types ORDER BY id`)
+	if err != nil {
+		log.Println("GetLeaveTypes: error getting results from database:", err)
+		return nil, err
+	}
+	defer rows.Close()
+
+	leaveTypes := []models.LeaveType{}
+	for rows.Next() {
+		var leaveType models.LeaveType
+		if err := rows.Scan(&leaveType.ID, &leaveType.Name, &leaveType.Accrual, &leaveType.DaysPerPeriod, &leaveType.CreatedAt); err != nil {
+			log.Println("GetLeaveTypes: error scanning row:", err)
+			return nil, err
+		}
+		leaveTypes = append(leaveTypes, leaveType)
+	}
+
+	return leaveTypes, rows.Err()
+}
+
+// lockLeave locks the employee, so its leave requests are checked and changed one at a time, and
+// returns its service start with its leave requests
+func lockLeave(ctx context.Context, tx *sql.Tx, employeeID int) (models.Date, []models.LeaveRequest, error) {
+	var employee models.Employee
+	err := tx.QueryRowContext(ctx, `SELECT hire_date, created_at FROM employees WHERE id = $1 FOR UPDATE`, employeeID).
+		Scan(&employee.HireDate, &employee.CreatedAt)
+	if errors.Is(err, sql.ErrNoRows) {
+		return models.Date{}, nil, providers.ErrEmployeeNotFound
+	}
+	if err != nil {
+		return models.Date{}, nil, err
+	}
+
+	rows, err := tx.QueryContext(ctx, `SELECT `+leaveRequestColumns+` FROM leave_requests WHERE employee_id = $1`, employeeID)
+	if err != nil {
+		return models.Date{}, nil, err
+	}
+	defer rows.Close()
+	var requests []models.LeaveRequest
+	for rows.Next() {
+		var request models.LeaveRequest
+		if err := scanLeaveRequest(rows, &request); err != nil {
+			return models.Date{}, nil, err
+		}
+		requests = append(requests, request)
+	}
+	return employee.ServiceStart(), requests, rows.Err()
+}
+
+// getLeaveType reads one leave type, ErrLeaveTypeNotFound if there is none
+func getLeaveType(ctx context.Context, tx *sql.Tx, id int) (models.LeaveType, error) {
+	var leaveType models.LeaveType
+	err := tx.QueryRowContext(ctx, `SELECT id, name, accrual, days_per_period, created_at FROM leave_types WHERE id = $1`, id).
+		Scan(&leaveType.ID, &leaveType.Name, &leaveType.Accrual, &leaveType.DaysPerPeriod, &leaveType.CreatedAt)
+	if errors.Is(err, sql.ErrNoRows) {
+		return leaveType, providers.ErrLeaveTypeNotFound
+	}
+	return leaveType, err
+}
+
+// CreateLeaveRequest stores a pending leave request after checking it against the employee's other
+// requests and balance, with the employee locked.
+func (dh *DBHelper) CreateLeaveRequest(request models.LeaveRequest) (models.LeaveRequest, error) {
+	if err := dh.ensureMigrated(); err != nil {
+		return request, err
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	tx, err := dh.pgClient.BeginTx(ctx, nil)
+	if err != nil {
+		log.Println("CreateLeaveRequest: unable to begin transaction:", err)
+		return request, err
+	}
+	defer func() { _ = tx.Rollback() }()
+
+	serviceStart, requests, err := lockLeave(ctx, tx, request.EmployeeID)
+	if err != nil {
+		return request, err
+	}
+	leaveType, err := getLeaveType(ctx, tx, request.LeaveTypeID)
+	if err != nil {
+		return request, err
+	}
+	for _, other := range requests {
+		if other.Holds() && other.Overlaps(request) {
+			return request, providers.ErrLeaveOverlap
+		}
+	}
+	// pending requests are promised already, approving them all must stay possible
+	balance := models.NewLeaveBalance(leaveType, serviceStart, request.StartDate, requests)
+	if balance.Limited() && request.Days > balance.Available-balance.Pending {
+		return request, providers.ErrInsufficientLeave
+	}
+
+	query := `
+        INSERT INTO leave_requests (employee_id, leave_type_id, start_date, end_date, days, reason, status)
+        VALUES ($1, $2, $3, $4, $5, $6, $7)
+        RETURNING ` + leaveRequestColumns
+	err = scanLeaveRequest(tx.QueryRowContext(ctx, query, request.EmployeeID, request.LeaveTypeID, request.StartDate,
+		request.EndDate, request.Days, request.Reason, models.LeavePending), &request)
+	if err != nil {
+		log.Println("CreateLeaveRequest: unable to insert leave request into database:", err)
+		return request, err
+	}
+
+	if err := tx.Commit(); err != nil {
+		log.Println("CreateLeaveRequest: unable to commit transaction:", err)
+		return request, err
+	}
+
+	return request, nil
+}
+
+// GetLeaveRequest reads one leave request.
+func (dh *DBHelper) GetLeaveRequest(id int) (models.LeaveRequest, error) {
+	var request models.LeaveRequest
+
+	if err := dh.ensureMigrated(); err != nil {
+		return request, err
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	err := scanLeaveRequest(dh.reader().QueryRowContext(ctx, `SELECT `+leaveRequestColumns+` FROM leave_requests WHERE id = $1`, id), &request)
+	if errors.Is(err, sql.ErrNoRows) {
+		return request, providers.ErrLeaveRequestNotFound
+	}
+	if err != nil {
+		log.Println("GetLeaveRequest: error getting result from database:", err)
+		return request, err
+	}
+
+	return request, nil
+}
+
+// GetLeaveRequests lists the leave requests of an employee by start date, only those with the given status unless it is empty.
+func (dh *DBHelper) GetLeaveRequests(employeeID int, status models.LeaveRequestStatus) ([]models.LeaveRequest, error) {
+	if err := dh.ensureMigrated(); err != nil {
+		return nil, err
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	query := `
+        SELECT ` + leaveRequestColumns + `
+        FROM leave_requests
+        WHERE employee_id = $1 AND ($2 = '' OR status = $2)
+        ORDER BY start_date, id
+    `
+	rows, err := dh.reader().QueryContext(ctx, query, employeeID, status)
+	if err != nil {
+		log.Println("GetLeaveRequests: error getting results from database:", err)
+		return nil, err
+	}
+	defer rows.Close()
+
+	requests := []models.LeaveRequest{}
+	for rows.Next() {
+		var request models.LeaveRequest
+		if err := scanLeaveRequest(rows, &request); err != nil {
+			log.Println("GetLeaveRequests: error scanning row:", err)
+			return nil, err
+		}
+		requests = append(requests, request)
+	}
+
+	return requests, rows.Err()
+}
+
+// GetEmployeesOnLeave lists the IDs of the employees an approved leave request covers on day.
+func (dh *DBHelper) GetEmployeesOnLeave(day models.Date) ([]int, error) {
+	if err := dh.ensureMigrated(); err != nil {
+		return nil, err
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	query := `
+        SELECT DISTINCT employee_id
+        FROM leave_requests
+        WHERE status = $1 AND start_date <= $2 AND end_date >= $2
+        ORDER BY employee_id
+    `
+	rows, err := dh.reader().QueryContext(ctx, query, models.LeaveApproved, day)
+	if err != nil {
+		log.Println("GetEmployeesOnLeave: error getting results from database:", err)
+		return nil, err
+	}
+	defer rows.Close()
+
+	ids := []int{}
+	for rows.Next() {
+		var id int
+		if err := rows.Scan(&id); err != nil {
+			log.Println("GetEmployeesOnLeave: error scanning row:", err)
+			return nil, err
+		}
+		ids = append(ids, id)
+	}
+
+	return ids, rows.Err()
+}
+
+// DecideLeaveRequest moves a leave request to status with the employee locked. Approvals are checked
+// against the balance once more, it may have been used up since the request was made.
+func (dh *DBHelper) DecideLeaveRequest(id int, status models.LeaveRequestStatus, decidedBy string, note string) (models.LeaveRequest, error) {
+	var request models.LeaveRequest
+
+	if err := dh.ensureMigrated(); err != nil {
+		return request, err
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	tx, err := dh.pgClient.BeginTx(ctx, nil)
+	if err != nil {
+		log.Println("DecideLeaveRequest: unable to begin transaction:", err)
+		return request, err
+	}
+	defer func() { _ = tx.Rollback() }()
+
+	var employeeID int
+	err = tx.QueryRowContext(ctx, `SELECT employee_id FROM leave_requests WHERE id = $1`, id).Scan(&employeeID)
+	if errors.Is(err, sql.ErrNoRows) {
+		return request, providers.ErrLeaveRequestNotFound
+	}
+	if err != nil {
+		log.Println("DecideLeaveRequest: error getting result from database:", err)
+		return request, err
+	}
+	serviceStart, requests, err := lockLeave(ctx, tx, employeeID)
+	if err != nil {
+		return request, err
+	}
+	for _, other := range requests {
+		if other.ID == id {
+			request = other
+		}
+	}
+	if !request.CanBecome(status, models.NewDate(time.Now())) {
+		return request, providers.ErrLeaveRequestDecided
+	}
+	if status == models.LeaveApproved {
+		leaveType, err := getLeaveType(ctx, tx, request.LeaveTypeID)
+		if err != nil {
+			return request, err
+		}
+		balance := models.NewLeaveBalance(leaveType, serviceStart, request.StartDate, requests)
+		if balance.Limited() && request.Days > balance.Available {
+			return request, providers.ErrInsufficientLeave
+		}
+	}
+
+	query := `
+        UPDATE leave_requests
+        SET status = $1, decided_by = $2, decision_note = $3, decided_at = now()
+        WHERE id = $4
+        RETURNING ` + leaveRequestColumns
+	if err := scanLeaveRequest(tx.QueryRowContext(ctx, query, status, decidedBy, note, id), &request); err != nil {
+		log.Println("DecideLeaveRequest: unable to update leave request in database:", err)
+		return request, err
+	}
+
+	if err := tx.Commit(); err != nil {
+		log.Println("DecideLeaveRequest: unable to commit transaction:", err)
+		return request, err
+	}
+
+	return request, nil
+}
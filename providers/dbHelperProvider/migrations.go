@@ -0,0 +1,498 @@
+package dbHelperProvider
+
+import (
+	"Techiebulter/interview/backend/models"
+	"context"
+	"database/sql"
+	"fmt"
+	"log"
+	"time"
+)
+
+// migrationLockID serialises migrations between instances starting at the same time
+const migrationLockID = 7410221
+
+type migration struct {
+	version int
+	name    string
+	query   string
+}
+
+// migrations are applied in order and never edited once released, add a new one instead.
+var migrations = []migration{
+	{
+		// databases created before migrations existed already have this table
+		version: 1,
+		name:    "create employees",
+		query: `
+            CREATE TABLE IF NOT EXISTS employees (
+                id SERIAL PRIMARY KEY,
+                name VARCHAR(255) NOT NULL,
+                position VARCHAR(255) NOT NULL,
+                salary NUMERIC(10, 2) NOT NULL
+            );
+        `,
+	},
+	{
+		version: 2,
+		name:    "extend employee profile",
+		query: `
+            ALTER TABLE employees
+                ADD COLUMN email VARCHAR(255) UNIQUE,
+                ADD COLUMN phone VARCHAR(32) NOT NULL DEFAULT '',
+                ADD COLUMN hire_date DATE,
+                ADD COLUMN termination_date DATE,
+                ADD COLUMN employment_type VARCHAR(16) NOT NULL DEFAULT 'full-time'
+                    CHECK (employment_type IN ('full-time', 'part-time', 'contractor')),
+                ADD COLUMN status VARCHAR(16) NOT NULL DEFAULT 'active'
+                    CHECK (status IN ('active', 'on-leave', 'terminated')),
+                ADD COLUMN location VARCHAR(255) NOT NULL DEFAULT '',
+                ADD COLUMN custom_fields JSONB NOT NULL DEFAULT '{}',
+                ADD COLUMN created_at TIMESTAMPTZ NOT NULL DEFAULT now(),
+                ADD COLUMN updated_at TIMESTAMPTZ NOT NULL DEFAULT now(),
+                ADD CONSTRAINT employees_termination_after_hire CHECK (termination_date >= hire_date);
+
+            CREATE TABLE custom_field_definitions (
+                name VARCHAR(63) PRIMARY KEY,
+                type VARCHAR(16) NOT NULL,
+                required BOOLEAN NOT NULL DEFAULT false,
+                options JSONB NOT NULL DEFAULT '[]',
+                description TEXT NOT NULL DEFAULT '',
+                created_at TIMESTAMPTZ NOT NULL DEFAULT now()
+            );
+        `,
+	},
+	{
+		version: 3,
+		name:    "create idempotency keys",
+		query: `
+            CREATE TABLE idempotency_keys (
+                scope VARCHAR(255) NOT NULL,
+                key VARCHAR(255) NOT NULL,
+                fingerprint CHAR(64) NOT NULL,
+                status_code INTEGER NOT NULL DEFAULT 0,
+                content_type VARCHAR(255) NOT NULL DEFAULT '',
+                body BYTEA,
+                created_at TIMESTAMPTZ NOT NULL DEFAULT now(),
+                expires_at TIMESTAMPTZ NOT NULL,
+                PRIMARY KEY (scope, key)
+            );
+
+            CREATE INDEX idempotency_keys_expires_at ON idempotency_keys (expires_at);
+        `,
+	},
+	{
+		version: 4,
+		name:    "create employee merges",
+		query: `
+            CREATE TABLE employee_merges (
+                id SERIAL PRIMARY KEY,
+                survivor_id INTEGER NOT NULL,
+                merged_id INTEGER NOT NULL UNIQUE,
+                survivor_before JSONB NOT NULL,
+                merged_employee JSONB NOT NULL,
+                taken_fields JSONB NOT NULL DEFAULT '[]',
+                merged_by VARCHAR(255) NOT NULL DEFAULT '',
+                merged_at TIMESTAMPTZ NOT NULL DEFAULT now()
+            );
+
+            CREATE INDEX employee_merges_survivor_id ON employee_merges (survivor_id);
+
+            CREATE TABLE employee_redirects (
+                id INTEGER PRIMARY KEY,
+                target_id INTEGER NOT NULL REFERENCES employees (id) ON DELETE CASCADE
+            );
+
+            CREATE INDEX employee_redirects_target_id ON employee_redirects (target_id);
+        `,
+	},
+	{
+		version: 5,
+		name:    "create employee events",
+		query: `
+            CREATE TABLE employee_events (
+                id BIGSERIAL PRIMARY KEY,
+                type VARCHAR(16) NOT NULL,
+                employee_id INTEGER NOT NULL,
+                employee JSONB,
+                occurred_at TIMESTAMPTZ NOT NULL
+            );
+        `,
+	},
+	{
+		version: 6,
+		name:    "create webhooks",
+		query: `
+            CREATE TABLE webhook_subscriptions (
+                id SERIAL PRIMARY KEY,
+                url VARCHAR(2048) NOT NULL,
+                event_types JSONB NOT NULL DEFAULT '[]',
+                secret VARCHAR(255) NOT NULL,
+                created_at TIMESTAMPTZ NOT NULL DEFAULT now()
+            );
+
+            CREATE TABLE webhook_deliveries (
+                id BIGSERIAL PRIMARY KEY,
+                subscription_id INTEGER NOT NULL REFERENCES webhook_subscriptions (id) ON DELETE CASCADE,
+                event_id BIGINT NOT NULL,
+                event_type VARCHAR(16) NOT NULL,
+                payload TEXT NOT NULL,
+                status VARCHAR(16) NOT NULL DEFAULT 'pending',
+                attempts INTEGER NOT NULL DEFAULT 0,
+                next_attempt_at TIMESTAMPTZ,
+                last_status_code INTEGER NOT NULL DEFAULT 0,
+                last_error TEXT NOT NULL DEFAULT '',
+                delivered_at TIMESTAMPTZ,
+                created_at TIMESTAMPTZ NOT NULL DEFAULT now()
+            );
+
+            CREATE INDEX webhook_deliveries_due ON webhook_deliveries (next_attempt_at) WHERE status = 'pending';
+            CREATE INDEX webhook_deliveries_subscription_id ON webhook_deliveries (subscription_id, id);
+        `,
+	},
+	{
+		version: 7,
+		name:    "create outbox",
+		query: `
+            CREATE TABLE outbox (
+                id BIGSERIAL PRIMARY KEY,
+                event_id BIGINT NOT NULL REFERENCES employee_events (id) ON DELETE CASCADE,
+                employee_id INTEGER NOT NULL,
+                attempts INTEGER NOT NULL DEFAULT 0,
+                next_attempt_at TIMESTAMPTZ NOT NULL DEFAULT now(),
+                last_error TEXT NOT NULL DEFAULT '',
+                created_at TIMESTAMPTZ NOT NULL DEFAULT now()
+            );
+
+            CREATE INDEX outbox_due ON outbox (next_attempt_at, id);
+            CREATE INDEX outbox_employee_id ON outbox (employee_id, id);
+
+            -- the relay publishes at least once, a repeated event must not be queued twice
+            CREATE UNIQUE INDEX webhook_deliveries_event ON webhook_deliveries (subscription_id, event_id);
+        `,
+	},
+	{
+		version: 8,
+		name:    "create leave",
+		query: `
+            CREATE TABLE leave_types (
+                id SERIAL PRIMARY KEY,
+                name VARCHAR(255) NOT NULL UNIQUE,
+                accrual TEXT NOT NULL,
+                days_per_period NUMERIC(6, 2) NOT NULL DEFAULT 0,
+                created_at TIMESTAMPTZ NOT NULL DEFAULT now()
+            );
+
+            CREATE TABLE leave_requests (
+                id SERIAL PRIMARY KEY,
+                employee_id INTEGER NOT NULL REFERENCES employees (id) ON DELETE CASCADE,
+                leave_type_id INTEGER NOT NULL REFERENCES leave_types (id),
+                start_date DATE NOT NULL,
+                end_date DATE NOT NULL CHECK (end_date >= start_date),
+                days NUMERIC(6, 2) NOT NULL,
+                reason TEXT NOT NULL DEFAULT '',
+                status TEXT NOT NULL DEFAULT 'pending',
+                decided_by TEXT NOT NULL DEFAULT '',
+                decision_note TEXT NOT NULL DEFAULT '',
+                decided_at TIMESTAMPTZ,
+                created_at TIMESTAMPTZ NOT NULL DEFAULT now()
+            );
+
+            CREATE INDEX leave_requests_employee_id ON leave_requests (employee_id, start_date);
+        `,
+	},
+	{
+		version: 9,
+		name:    "create attendance and timesheets",
+		query: `
+            ALTER TABLE employees ADD COLUMN department VARCHAR(255) NOT NULL DEFAULT '';
+
+            CREATE TABLE attendance (
+                id SERIAL PRIMARY KEY,
+                employee_id INTEGER NOT NULL REFERENCES employees (id) ON DELETE CASCADE,
+                clock_in TIMESTAMPTZ NOT NULL,
+                clock_out TIMESTAMPTZ CHECK (clock_out >= clock_in),
+                hours NUMERIC(6, 2) NOT NULL DEFAULT 0,
+                note TEXT NOT NULL DEFAULT ''
+            );
+
+            CREATE INDEX attendance_clock_in ON attendance (clock_in);
+            CREATE INDEX attendance_employee_id ON attendance (employee_id, clock_in);
+            -- an employee is clocked in once at a time
+            CREATE UNIQUE INDEX attendance_open ON attendance (employee_id) WHERE clock_out IS NULL;
+
+            CREATE TABLE timesheets (
+                id SERIAL PRIMARY KEY,
+                employee_id INTEGER NOT NULL REFERENCES employees (id) ON DELETE CASCADE,
+                week_start DATE NOT NULL,
+                entries JSONB NOT NULL DEFAULT '[]',
+                hours NUMERIC(6, 2) NOT NULL,
+                status TEXT NOT NULL DEFAULT 'submitted',
+                decided_by TEXT NOT NULL DEFAULT '',
+                decision_note TEXT NOT NULL DEFAULT '',
+                decided_at TIMESTAMPTZ,
+                created_at TIMESTAMPTZ NOT NULL DEFAULT now()
+            );
+
+            CREATE INDEX timesheets_week_start ON timesheets (week_start);
+            -- a rejected timesheet may be submitted again, any other one holds its week
+            CREATE UNIQUE INDEX timesheets_week ON timesheets (employee_id, week_start) WHERE status <> 'rejected';
+        `,
+	},
+	{
+		version: 10,
+		name:    "create salary history and payroll",
+		query: `
+            CREATE TABLE salary_history (
+                id SERIAL PRIMARY KEY,
+                employee_id INTEGER NOT NULL REFERENCES employees (id) ON DELETE CASCADE,
+                salary NUMERIC(10, 2) NOT NULL,
+                effective_from DATE NOT NULL,
+                recorded_at TIMESTAMPTZ NOT NULL DEFAULT now(),
+                UNIQUE (employee_id, effective_from)
+            );
+
+            -- the salaries stored so far are taken to apply since the hire date
+            INSERT INTO salary_history (employee_id, salary, effective_from, recorded_at)
+            SELECT id, salary, COALESCE(hire_date, created_at::date), now() FROM employees;
+
+            CREATE TABLE payroll_runs (
+                id SERIAL PRIMARY KEY,
+                period VARCHAR(7) NOT NULL UNIQUE,
+                status TEXT NOT NULL DEFAULT 'draft',
+                rules JSONB NOT NULL,
+                employees INTEGER NOT NULL DEFAULT 0,
+                gross NUMERIC(14, 2) NOT NULL DEFAULT 0,
+                deductions NUMERIC(14, 2) NOT NULL DEFAULT 0,
+                net NUMERIC(14, 2) NOT NULL DEFAULT 0,
+                created_by TEXT NOT NULL DEFAULT '',
+                created_at TIMESTAMPTZ NOT NULL DEFAULT now(),
+                approved_by TEXT NOT NULL DEFAULT '',
+                approved_at TIMESTAMPTZ
+            );
+
+            -- payslips outlive the employees they pay, so they keep the employee ID without a reference
+            CREATE TABLE payslips (
+                id SERIAL PRIMARY KEY,
+                run_id INTEGER NOT NULL REFERENCES payroll_runs (id) ON DELETE CASCADE,
+                employee_id INTEGER NOT NULL,
+                name VARCHAR(255) NOT NULL,
+                position VARCHAR(255) NOT NULL,
+                department VARCHAR(255) NOT NULL DEFAULT '',
+                employment_type TEXT NOT NULL,
+                segments JSONB NOT NULL DEFAULT '[]',
+                base_pay NUMERIC(12, 2) NOT NULL,
+                lines JSONB NOT NULL DEFAULT '[]',
+                gross NUMERIC(12, 2) NOT NULL,
+                deductions NUMERIC(12, 2) NOT NULL,
+                net NUMERIC(12, 2) NOT NULL,
+                UNIQUE (run_id, employee_id)
+            );
+
+            CREATE INDEX payslips_employee_id ON payslips (employee_id);
+        `,
+	},
+	{
+		version: 11,
+		name:    "add currencies and exchange rates",
+		query: `
+            -- the amounts stored so far are in the base currency, which is only known to the helper:
+            -- currency_backfill records the rows it tags with the base currency after migrating
+            ALTER TABLE employees ADD COLUMN currency VARCHAR(3) NOT NULL DEFAULT 'USD';
+            ALTER TABLE salary_history ADD COLUMN currency VARCHAR(3) NOT NULL DEFAULT 'USD';
+            ALTER TABLE payroll_runs ADD COLUMN currency VARCHAR(3) NOT NULL DEFAULT 'USD';
+            ALTER TABLE payslips ADD COLUMN currency VARCHAR(3) NOT NULL DEFAULT 'USD';
+
+            CREATE TABLE currency_backfill (
+                table_name VARCHAR(64) PRIMARY KEY,
+                last_id INTEGER NOT NULL
+            );
+            INSERT INTO currency_backfill (table_name, last_id) SELECT 'employees', id FROM employees ORDER BY id DESC LIMIT 1;
+            INSERT INTO currency_backfill (table_name, last_id) SELECT 'salary_history', id FROM salary_history ORDER BY id DESC LIMIT 1;
+            INSERT INTO currency_backfill (table_name, last_id) SELECT 'payroll_runs', id FROM payroll_runs ORDER BY id DESC LIMIT 1;
+            INSERT INTO currency_backfill (table_name, last_id) SELECT 'payslips', id FROM payslips ORDER BY id DESC LIMIT 1;
+
+            -- a rate is what one unit of the currency is worth in the base currency from its effective date
+            CREATE TABLE exchange_rates (
+                currency VARCHAR(3) NOT NULL,
+                effective_date DATE NOT NULL,
+                rate NUMERIC(18, 8) NOT NULL CHECK (rate > 0),
+                updated_at TIMESTAMPTZ NOT NULL DEFAULT now(),
+                PRIMARY KEY (currency, effective_date)
+            );
+        `,
+	},
+	{
+		version: 12,
+		name:    "add salary bands",
+		query: `
+            -- positions are matched to bands by position_key, the position in lower case with single spaces
+            CREATE TABLE salary_bands (
+                id SERIAL PRIMARY KEY,
+                position VARCHAR(255) NOT NULL,
+                position_key VARCHAR(255) NOT NULL UNIQUE,
+                currency VARCHAR(3) NOT NULL,
+                min NUMERIC(10, 2) NOT NULL,
+                mid NUMERIC(10, 2) NOT NULL,
+                max NUMERIC(10, 2) NOT NULL,
+                enforcement VARCHAR(10) NOT NULL CHECK (enforcement IN ('warn', 'reject')),
+                created_at TIMESTAMPTZ NOT NULL DEFAULT now(),
+                updated_at TIMESTAMPTZ NOT NULL DEFAULT now(),
+                CHECK (min > 0 AND min <= mid AND mid <= max)
+            );
+        `,
+	},
+	{
+		version: 13,
+		name:    "add employee managers",
+		query: `
+            -- reports of a deleted employee are left without a manager
+            ALTER TABLE employees ADD COLUMN manager_id INTEGER REFERENCES employees (id) ON DELETE SET NULL;
+        `,
+	},
+}
+
+// ensureMigrated migrates the schema on first use. It is retried on every call until it succeeds,
+// so a service that started without its database migrates once the database becomes reachable.
+func (dh *DBHelper) ensureMigrated() error {
+	if dh.schema.migrated.Load() {
+		return nil
+	}
+
+	dh.schema.mu.Lock()
+	defer dh.schema.mu.Unlock()
+	if dh.schema.migrated.Load() {
+		return nil
+	}
+
+	if err := dh.migrate(); err != nil {
+		log.Println("ensureMigrated: unable to migrate the database:", err)
+		return err
+	}
+	dh.schema.migrated.Store(true)
+	return nil
+}
+
+// migrate applies every migration newer than the recorded schema version, each in its own transaction
+func (dh *DBHelper) migrate() error {
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+
+	createTableQuery := `
+        CREATE TABLE IF NOT EXISTS schema_migrations (
+            version INTEGER PRIMARY KEY,
+            name TEXT NOT NULL,
+            applied_at TIMESTAMPTZ NOT NULL DEFAULT now()
+        )
+    `
+	if _, err := dh.pgClient.ExecContext(ctx, createTableQuery); err != nil {
+		log.Println("migrate: unable to create schema_migrations table:", err)
+		return err
+	}
+
+	for _, m := range migrations {
+		if err := dh.applyMigration(ctx, m); err != nil {
+			return fmt.Errorf("migration %d (%s): %w", m.version, m.name, err)
+		}
+	}
+
+	return dh.backfillBaseCurrency(ctx)
+}
+
+// backfillBaseCurrency tags the amounts stored before currencies existed, as recorded in
+// currency_backfill, with the base currency and forgets them. It holds the migration lock,
+// so instances starting at the same time backfill once.
+func (dh *DBHelper) backfillBaseCurrency(ctx context.Context) error {
+	tx, err := dh.pgClient.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer func() { _ = tx.Rollback() }()
+
+	if _, err := tx.ExecContext(ctx, `SELECT pg_advisory_xact_lock($1)`, migrationLockID); err != nil {
+		return fmt.Errorf("backfill base currency: %w", err)
+	}
+	backfilled, err := backfillCurrency(ctx, tx, dh.baseCurrency, `UPDATE %s SET currency = $1 WHERE id <= $2`)
+	if err != nil {
+		return fmt.Errorf("backfill base currency: %w", err)
+	}
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("backfill base currency: %w", err)
+	}
+	if backfilled {
+		log.Printf("migrate: tagged the amounts stored before currencies with %s", dh.baseCurrency)
+	}
+	return nil
+}
+
+// applyMigration runs m unless another instance already did, holding the migration lock while checking
+func (dh *DBHelper) applyMigration(ctx context.Context, m migration) error {
+	tx, err := dh.pgClient.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer func() { _ = tx.Rollback() }()
+
+	if _, err := tx.ExecContext(ctx, `SELECT pg_advisory_xact_lock($1)`, migrationLockID); err != nil {
+		return err
+	}
+
+	var applied bool
+	err = tx.QueryRowContext(ctx, `SELECT EXISTS (SELECT 1 FROM schema_migrations WHERE version = $1)`, m.version).Scan(&applied)
+	if err != nil || applied {
+		return err
+	}
+
+	if _, err := tx.ExecContext(ctx, m.query); err != nil {
+		return err
+	}
+	if _, err := tx.ExecContext(ctx, `INSERT INTO schema_migrations (version, name) VALUES ($1, $2)`, m.version, m.name); err != nil {
+		return err
+	}
+	if err := tx.Commit(); err != nil {
+		return err
+	}
+
+	log.Printf("migrate: applied migration %d (%s)", m.version, m.name)
+	return nil
+}
+
+// backfillCurrency sets the currency of the rows recorded in currency_backfill with update, a statement
+// taking the table name for %s and the currency and the last ID as parameters. It reports whether there were any.
+func backfillCurrency(ctx context.Context, tx *sql.Tx, currency models.Currency, update string) (bool, error) {
+	rows, err := tx.QueryContext(ctx, `SELECT table_name, last_id FROM currency_backfill`)
+	if err != nil {
+		return false, err
+	}
+	type pending struct {
+		table  string
+		lastID int
+	}
+	var backfill []pending
+	for rows.Next() {
+		var p pending
+		if err := rows.Scan(&p.table, &p.lastID); err != nil {
+			_ = rows.Close()
+			return false, err
+		}
+		backfill = append(backfill, p)
+	}
+	if err := rows.Err(); err != nil {
+		_ = rows.Close()
+		return false, err
+	}
+	if err := rows.Close(); err != nil {
+		return false, err
+	}
+
+	// the table names were written by the migration, not taken from a request
+	for _, p := range backfill {
+		if _, err := tx.ExecContext(ctx, fmt.Sprintf(update, p.table), currency, p.lastID); err != nil {
+			return false, err
+		}
+	}
+	if _, err := tx.ExecContext(ctx, `DELETE FROM currency_backfill`); err != nil {
+		return false, err
+	}
+	return len(backfill) > 0, nil
+}
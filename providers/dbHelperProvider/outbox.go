@@ -0,0 +1,116 @@
+package dbHelperProvider
+
+import (
+	"Techiebulter/interview/backend/models"
+	"context"
+	"encoding/json"
+	"log"
+	"time"
+)
+
+// ClaimOutboxMessages leases the due outbox messages that are the oldest of their employee. Rows
+// another instance is claiming are skipped instead of waited for.
+func (dh *DBHelper) ClaimOutboxMessages(now time.Time, leaseUntil time.Time, limit int) ([]models.OutboxMessage, error) {
+	if err := dh.ensureMigrated(); err != nil {
+		return nil, err
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	// a message waits while an older one of its employee is unpublished, even one leased elsewhere
+	query := `
+        WITH claimed AS (
+            UPDATE outbox
+            SET next_attempt_at = $2
+            WHERE id IN (
+                SELECT o.id FROM outbox o
+                WHERE o.next_attempt_at <= $1
+                    AND NOT EXISTS (SELECT 1 FROM outbox older WHERE older.employee_id = o.employee_id AND older.id < o.id)
+                ORDER BY o.id
+                LIMIT $3
+                FOR UPDATE SKIP LOCKED
+            )
+            RETURNING id, event_id, attempts, next_attempt_at, last_error, created_at
+        )
+        SELECT c.id, c.attempts, c.next_attempt_at, c.last_error, c.created_at,
+            e.id, e.type, e.employee_id, e.employee, e.occurred_at
+        FROM claimed c
+        JOIN employee_events e ON e.id = c.event_id
+        ORDER BY c.id
+    `
+	rows, err := dh.pgClient.QueryContext(ctx, query, now, leaseUntil, limit)
+	if err != nil {
+		log.Println("ClaimOutboxMessages: error claiming messages in database:", err)
+		return nil, err
+	}
+	defer rows.Close()
+
+	messages := []models.OutboxMessage{}
+	for rows.Next() {
+		var (
+			message  models.OutboxMessage
+			employee []byte
+		)
+		err := rows.Scan(&message.ID, &message.Attempts, &message.NextAttemptAt, &message.LastError, &message.CreatedAt,
+			&message.Event.ID, &message.Event.Type, &message.Event.EmployeeID, &employee, &message.Event.Time)
+		if err != nil {
+			log.Println("ClaimOutboxMessages: error scanning row:", err)
+			return nil, err
+		}
+		if employee != nil {
+			message.Event.Employee = &models.Employee{}
+			if err := json.Unmarshal(employee, message.Event.Employee); err != nil {
+				return nil, err
+			}
+		}
+		messages = append(messages, message)
+	}
+
+	return messages, rows.Err()
+}
+
+// CompleteOutboxAttempt removes a published message or stores when it is attempted again.
+func (dh *DBHelper) CompleteOutboxAttempt(message models.OutboxMessage, published bool) error {
+	if err := dh.ensureMigrated(); err != nil {
+		return err
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	var err error
+	if published {
+		_, err = dh.pgClient.ExecContext(ctx, `DELETE FROM outbox WHERE id = $1`, message.ID)
+	} else {
+		query := `UPDATE outbox SET attempts = $2, next_attempt_at = $3, last_error = $4 WHERE id = $1`
+		_, err = dh.pgClient.ExecContext(ctx, query, message.ID, message.Attempts, message.NextAttemptAt, message.LastError)
+	}
+	if err != nil {
+		log.Println("CompleteOutboxAttempt: error updating message in database:", err)
+		return err
+	}
+
+	return nil
+}
+
+// GetOutboxBacklog counts the unpublished outbox messages.
+func (dh *DBHelper) GetOutboxBacklog() (models.OutboxBacklog, error) {
+	var backlog models.OutboxBacklog
+
+	if err := dh.ensureMigrated(); err != nil {
+		return backlog, err
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	query := `SELECT COUNT(*), COUNT(*) FILTER (WHERE attempts > 0), MIN(created_at) FROM outbox`
+	err := dh.pgClient.QueryRowContext(ctx, query).Scan(&backlog.Pending, &backlog.Failing, &backlog.OldestCreatedAt)
+	if err != nil {
+		log.Println("GetOutboxBacklog: error getting results from database:", err)
+		return backlog, err
+	}
+
+	return backlog, nil
+}
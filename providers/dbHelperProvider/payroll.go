@@ -0,0 +1,329 @@
+package dbHelperProvider
+
+import (
+	"Techiebulter/interview/backend/models"
+	"Techiebulter/interview/backend/providers"
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"log"
+	"time"
+)
+
+// payrollPeriodLockID is the class of the advisory locks that serialise saving the runs of a period
+const payrollPeriodLockID = 7410222
+
+// payrollRunColumns is selected by every query returning payroll runs, in the order scanPayrollRun expects
+const payrollRunColumns = `id, period, status, rules, currency, employees, gross, deductions, net, created_by, created_at,
+    approved_by, approved_at`
+
+func scanPayrollRun(row interface {
+	Scan(dest ...interface{}) error
+}, run *models.PayrollRun) error {
+	return row.Scan(&run.ID, &run.Period, &run.Status, &run.Rules, &run.Currency, &run.Employees, &run.Gross, &run.Deductions, &run.Net,
+		&run.CreatedBy, &run.CreatedAt, &run.ApprovedBy, &run.ApprovedAt)
+}
+
+// payslipColumns is selected from payslips p joined with their run r, in the order scanPayslip expects
+const payslipColumns = `p.id, p.run_id, r.period, p.employee_id, p.name, p.position, p.department, p.employment_type,
+    p.currency, p.segments, p.base_pay, p.lines, p.gross, p.deductions, p.net`
+
+func scanPayslip(row interface {
+	Scan(dest ...interface{}) error
+}, payslip *models.Payslip) error {
+	return row.Scan(&payslip.ID, &payslip.RunID, &payslip.Period, &payslip.EmployeeID, &payslip.Name, &payslip.Position,
+		&payslip.Department, &payslip.EmploymentType, &payslip.Currency, &payslip.Segments, &payslip.BasePay, &payslip.Lines, &payslip.Gross,
+		&payslip.Deductions, &payslip.Net)
+}
+
+// recordSalary adds the salary of employee to its salary history within tx, unless it is the salary recorded last
+func recordSalary(ctx context.Context, tx *sql.Tx, employee models.Employee, created bool) error {
+	var last models.SalaryChange
+	err := tx.QueryRowContext(ctx, `SELECT salary, currency FROM salary_history WHERE employee_id = $1 ORDER BY effective_from DESC LIMIT 1`,
+		employee.ID).Scan(&last.Salary, &last.Currency)
+	if err == nil && last.Salary == employee.Salary && last.Currency == employee.Currency {
+		return nil
+	}
+	if err != nil && !errors.Is(err, sql.ErrNoRows) {
+		return fmt.Errorf("read salary history: %w", err)
+	}
+
+	query := `
+        INSERT INTO salary_history (employee_id, salary, currency, effective_from)
+        VALUES ($1, $2, $3, $4)
+        ON CONFLICT (employee_id, effective_from) DO UPDATE
+        SET salary = excluded.salary, currency = excluded.currency, recorded_at = now()
+    `
+	effectiveFrom := employee.SalaryEffectiveFrom(created, models.NewDate(time.Now().UTC()))
+	if _, err := tx.ExecContext(ctx, query, employee.ID, employee.Salary, employee.Currency, effectiveFrom); err != nil {
+		return fmt.Errorf("record salary: %w", err)
+	}
+	return nil
+}
+
+// GetSalaryHistory lists the salary changes effective up to a day.
+func (dh *DBHelper) GetSalaryHistory(employeeID int, until models.Date) ([]models.SalaryChange, error) {
+	if err := dh.ensureMigrated(); err != nil {
+		return nil, err
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	query := `
+        SELECT employee_id, salary, currency, effective_from, recorded_at
+        FROM salary_history
+        WHERE ($1 = 0 OR employee_id = $1) AND effective_from <= $2
+        ORDER BY employee_id, effective_from
+    `
+	rows, err := dh.reader().QueryContext(ctx, query, employeeID, until)
+	if err != nil {
+		log.Println("GetSalaryHistory: error getting results from database:", err)
+		return nil, err
+	}
+	defer rows.Close()
+
+	history := []models.SalaryChange{}
+	for rows.Next() {
+		var change models.SalaryChange
+		if err := rows.Scan(&change.EmployeeID, &change.Salary, &change.Currency, &change.EffectiveFrom, &change.RecordedAt); err != nil {
+			log.Println("GetSalaryHistory: error scanning row:", err)
+			return nil, err
+		}
+		history = append(history, change)
+	}
+
+	return history, rows.Err()
+}
+
+// SavePayrollRun stores a draft payroll run with its payslips.
+func (dh *DBHelper) SavePayrollRun(run models.PayrollRun) (models.PayrollRun, error) {
+	if err := dh.ensureMigrated(); err != nil {
+		return run, err
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	tx, err := dh.pgClient.BeginTx(ctx, nil)
+	if err != nil {
+		log.Println("SavePayrollRun: unable to begin transaction:", err)
+		return run, err
+	}
+	defer func() { _ = tx.Rollback() }()
+
+	// the period is locked besides its run, there is no run to lock before the first one is saved
+	if _, err := tx.ExecContext(ctx, `SELECT pg_advisory_xact_lock($1, hashtext($2))`, payrollPeriodLockID, run.Period); err != nil {
+		log.Println("SavePayrollRun: unable to lock the period:", err)
+		return run, err
+	}
+
+	// a draft of the period is replaced with its payslips, an approved run is kept
+	var status models.PayrollRunStatus
+	err = tx.QueryRowContext(ctx, `SELECT status FROM payroll_runs WHERE period = $1 FOR UPDATE`, run.Period).Scan(&status)
+	if err != nil && !errors.Is(err, sql.ErrNoRows) {
+		log.Println("SavePayrollRun: error getting result from database:", err)
+		return run, err
+	}
+	if status == models.PayrollApproved {
+		return run, providers.ErrPayrollRunApproved
+	}
+	if _, err := tx.ExecContext(ctx, `DELETE FROM payroll_runs WHERE period = $1`, run.Period); err != nil {
+		log.Println("SavePayrollRun: unable to delete draft from database:", err)
+		return run, err
+	}
+
+	payslips := run.Payslips
+	query := `
+        INSERT INTO payroll_runs (period, status, rules, currency, employees, gross, deductions, net, created_by)
+        VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9)
+        RETURNING ` + payrollRunColumns
+	err = scanPayrollRun(tx.QueryRowContext(ctx, query, run.Period, models.PayrollDraft, run.Rules, run.Currency, run.Employees, run.Gross,
+		run.Deductions, run.Net, run.CreatedBy), &run)
+	if err != nil {
+		log.Println("SavePayrollRun: unable to insert payroll run into database:", err)
+		return run, err
+	}
+
+	payslipQuery := `
+        INSERT INTO payslips (run_id, employee_id, name, position, department, employment_type, currency, segments, base_pay,
+            lines, gross, deductions, net)
+        VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13)
+        RETURNING id
+    `
+	run.Payslips = make([]models.Payslip, len(payslips))
+	for i, payslip := range payslips {
+		payslip.RunID, payslip.Period = run.ID, run.Period
+		err := tx.QueryRowContext(ctx, payslipQuery, run.ID, payslip.EmployeeID, payslip.Name, payslip.Position, payslip.Department,
+			payslip.EmploymentType, payslip.Currency, payslip.Segments, payslip.BasePay, payslip.Lines, payslip.Gross, payslip.Deductions,
+			payslip.Net).Scan(&payslip.ID)
+		if err != nil {
+			log.Println("SavePayrollRun: unable to insert payslip into database:", err)
+			return run, err
+		}
+		run.Payslips[i] = payslip
+	}
+
+	if err := tx.Commit(); err != nil {
+		log.Println("SavePayrollRun: unable to commit transaction:", err)
+		return run, err
+	}
+
+	return run, nil
+}
+
+// GetPayrollRuns lists the payroll runs by period.
+func (dh *DBHelper) GetPayrollRuns() ([]models.PayrollRun, error) {
+	if err := dh.ensureMigrated(); err != nil {
+		return nil, err
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	rows, err := dh.reader().QueryContext(ctx, `SELECT `+payrollRunColumns+` FROM payroll_runs ORDER BY period`)
+	if err != nil {
+		log.Println("GetPayrollRuns: error getting results from database:", err)
+		return nil, err
+	}
+	defer rows.Close()
+
+	runs := []models.PayrollRun{}
+	for rows.Next() {
+		var run models.PayrollRun
+		if err := scanPayrollRun(rows, &run); err != nil {
+			log.Println("GetPayrollRuns: error scanning row:", err)
+			return nil, err
+		}
+		runs = append(runs, run)
+	}
+
+	return runs, rows.Err()
+}
+
+// GetPayrollRun reads one payroll run with its payslips.
+func (dh *DBHelper) GetPayrollRun(id int) (models.PayrollRun, error) {
+	var run models.PayrollRun
+
+	if err := dh.ensureMigrated(); err != nil {
+		return run, err
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	err := scanPayrollRun(dh.reader().QueryRowContext(ctx, `SELECT `+payrollRunColumns+` FROM payroll_runs WHERE id = $1`, id), &run)
+	if errors.Is(err, sql.ErrNoRows) {
+		return run, providers.ErrPayrollRunNotFound
+	}
+	if err != nil {
+		log.Println("GetPayrollRun: error getting result from database:", err)
+		return run, err
+	}
+
+	if run.Payslips, err = queryPayslips(ctx, dh.reader(), `p.run_id = $1 ORDER BY p.employee_id`, id); err != nil {
+		log.Println("GetPayrollRun: error getting payslips from database:", err)
+		return run, err
+	}
+
+	return run, nil
+}
+
+// ApprovePayrollRun locks a draft payroll run.
+func (dh *DBHelper) ApprovePayrollRun(id int, approvedBy string) (models.PayrollRun, error) {
+	var run models.PayrollRun
+
+	if err := dh.ensureMigrated(); err != nil {
+		return run, err
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	query := `
+        UPDATE payroll_runs
+        SET status = $1, approved_by = $2, approved_at = now()
+        WHERE id = $3 AND status = $4
+        RETURNING ` + payrollRunColumns
+	err := scanPayrollRun(dh.pgClient.QueryRowContext(ctx, query, models.PayrollApproved, approvedBy, id, models.PayrollDraft), &run)
+	if errors.Is(err, sql.ErrNoRows) {
+		// either there is no such run or it was approved already
+		if _, err := dh.ReadFromPrimary().GetPayrollRun(id); err != nil {
+			return run, err
+		}
+		return run, providers.ErrPayrollRunApproved
+	}
+	if err != nil {
+		log.Println("ApprovePayrollRun: unable to update payroll run in database:", err)
+		return run, err
+	}
+
+	if run.Payslips, err = queryPayslips(ctx, dh.pgClient, `p.run_id = $1 ORDER BY p.employee_id`, id); err != nil {
+		log.Println("ApprovePayrollRun: error getting payslips from database:", err)
+		return run, err
+	}
+
+	return run, nil
+}
+
+// GetPayslips lists the approved payslips of an employee by period.
+func (dh *DBHelper) GetPayslips(employeeID int) ([]models.Payslip, error) {
+	if err := dh.ensureMigrated(); err != nil {
+		return nil, err
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	payslips, err := queryPayslips(ctx, dh.reader(), `p.employee_id = $1 AND r.status = $2 ORDER BY r.period`, employeeID,
+		models.PayrollApproved)
+	if err != nil {
+		log.Println("GetPayslips: error getting results from database:", err)
+		return nil, err
+	}
+
+	return payslips, nil
+}
+
+// GetPayslip reads the approved payslip of an employee for a period.
+func (dh *DBHelper) GetPayslip(employeeID int, period models.PayPeriod) (models.Payslip, error) {
+	if err := dh.ensureMigrated(); err != nil {
+		return models.Payslip{}, err
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	payslips, err := queryPayslips(ctx, dh.reader(), `p.employee_id = $1 AND r.period = $2 AND r.status = $3`, employeeID, period,
+		models.PayrollApproved)
+	if err != nil {
+		log.Println("GetPayslip: error getting result from database:", err)
+		return models.Payslip{}, err
+	}
+	if len(payslips) == 0 {
+		return models.Payslip{}, providers.ErrPayslipNotFound
+	}
+
+	return payslips[0], nil
+}
+
+// queryPayslips reads the payslips matching where, which may refer to the payslip as p and its run as r
+func queryPayslips(ctx context.Context, db *sql.DB, where string, args ...interface{}) ([]models.Payslip, error) {
+	rows, err := db.QueryContext(ctx, `SELECT `+payslipColumns+` FROM payslips p JOIN payroll_runs r ON r.id = p.run_id WHERE `+where, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	payslips := []models.Payslip{}
+	for rows.Next() {
+		var payslip models.Payslip
+		if err := scanPayslip(rows, &payslip); err != nil {
+			return nil, err
+		}
+		payslips = append(payslips, payslip)
+	}
+
+	return payslips, rows.Err()
+}
@@ -1,18 +1,56 @@
 package dbHelperProvider
 
 import (
+	"Techiebulter/interview/backend/models"
 	"Techiebulter/interview/backend/providers"
 	"database/sql"
+	"sync"
+	"sync/atomic"
 
 	_ "github.com/lib/pq"
 )
 
 type DBHelper struct {
+	// pgClient is the primary, every write goes there
 	pgClient *sql.DB
+	client   providers.PgClientProvider
+
+	// readPrimary routes reads to the primary as well, for read-your-writes
+	readPrimary bool
+
+	// baseCurrency tags the amounts stored before currencies existed
+	baseCurrency models.Currency
+
+	// schema is shared with the copies made by ReadFromPrimary
+	schema *schemaState
+}
+
+// schemaState remembers whether the migrations have run
+type schemaState struct {
+	mu       sync.Mutex
+	migrated atomic.Bool
 }
 
-func NewDBHelper(pgClient *sql.DB) providers.DbHelperProvider {
+func NewDBHelper(pgClient providers.PgClientProvider, baseCurrency models.Currency) providers.DbHelperProvider {
 	return &DBHelper{
-		pgClient: pgClient,
+		pgClient:     pgClient.Client(),
+		client:       pgClient,
+		baseCurrency: baseCurrency,
+		schema:       &schemaState{},
+	}
+}
+
+// ReadFromPrimary returns a copy of the helper that reads from the primary instead of a replica
+func (dh *DBHelper) ReadFromPrimary() providers.DbHelperProvider {
+	primary := *dh
+	primary.readPrimary = true
+	return &primary
+}
+
+// reader returns the database reads go to, a replica unless the primary was asked for
+func (dh *DBHelper) reader() *sql.DB {
+	if dh.readPrimary {
+		return dh.pgClient
 	}
+	return dh.client.Reader()
 }
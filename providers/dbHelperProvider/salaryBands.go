@@ -0,0 +1,131 @@
+package dbHelperProvider
+
+import (
+	"Techiebulter/interview/backend/models"
+	"Techiebulter/interview/backend/providers"
+	"context"
+	"database/sql"
+	"errors"
+	"log"
+	"time"
+
+	"github.com/lib/pq"
+)
+
+// salaryBandColumns is selected by every query returning salary bands, in the order scanSalaryBand expects
+const salaryBandColumns = `id, position, currency, min, mid, max, enforcement, created_at, updated_at`
+
+func scanSalaryBand(row interface {
+	Scan(dest ...interface{}) error
+}, band *models.SalaryBand) error {
+	return row.Scan(&band.ID, &band.Position, &band.Currency, &band.Min, &band.Mid, &band.Max, &band.Enforcement,
+		&band.CreatedAt, &band.UpdatedAt)
+}
+
+// CreateSalaryBand stores a salary band.
+func (dh *DBHelper) CreateSalaryBand(band models.SalaryBand) (models.SalaryBand, error) {
+	if err := dh.ensureMigrated(); err != nil {
+		return band, err
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	query := `
+        INSERT INTO salary_bands (position, position_key, currency, min, mid, max, enforcement, created_at, updated_at)
+        VALUES ($1, $2, $3, $4, $5, $6, $7, now(), now())
+        RETURNING ` + salaryBandColumns
+	err := scanSalaryBand(dh.pgClient.QueryRowContext(ctx, query, band.Position, models.PositionKey(band.Position), band.Currency,
+		band.Min, band.Mid, band.Max, band.Enforcement), &band)
+	if err != nil {
+		var pqErr *pq.Error
+		if errors.As(err, &pqErr) && pqErr.Code == "23505" {
+			return band, providers.ErrSalaryBandExists
+		}
+		log.Println("CreateSalaryBand: unable to insert salary band into database:", err)
+		return band, err
+	}
+
+	return band, nil
+}
+
+// GetSalaryBands lists the salary bands ordered by position.
+func (dh *DBHelper) GetSalaryBands() ([]models.SalaryBand, error) {
+	if err := dh.ensureMigrated(); err != nil {
+		return nil, err
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	rows, err := dh.reader().QueryContext(ctx, `SELECT `+salaryBandColumns+` FROM salary_bands ORDER BY position_key`)
+	if err != nil {
+		log.Println("GetSalaryBands: error getting results from database:", err)
+		return nil, err
+	}
+	defer rows.Close()
+
+	bands := []models.SalaryBand{}
+	for rows.Next() {
+		var band models.SalaryBand
+		if err := scanSalaryBand(rows, &band); err != nil {
+			log.Println("GetSalaryBands: error scanning row:", err)
+			return nil, err
+		}
+		bands = append(bands, band)
+	}
+
+	return bands, rows.Err()
+}
+
+// UpdateSalaryBand replaces every field of a salary band.
+func (dh *DBHelper) UpdateSalaryBand(band models.SalaryBand) (models.SalaryBand, error) {
+	if err := dh.ensureMigrated(); err != nil {
+		return band, err
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	query := `
+        UPDATE salary_bands
+        SET position = $1, position_key = $2, currency = $3, min = $4, mid = $5, max = $6, enforcement = $7, updated_at = now()
+        WHERE id = $8
+        RETURNING ` + salaryBandColumns
+	err := scanSalaryBand(dh.pgClient.QueryRowContext(ctx, query, band.Position, models.PositionKey(band.Position), band.Currency,
+		band.Min, band.Mid, band.Max, band.Enforcement, band.ID), &band)
+	if errors.Is(err, sql.ErrNoRows) {
+		return band, providers.ErrSalaryBandNotFound
+	}
+	if err != nil {
+		var pqErr *pq.Error
+		if errors.As(err, &pqErr) && pqErr.Code == "23505" {
+			return band, providers.ErrSalaryBandExists
+		}
+		log.Println("UpdateSalaryBand: unable to update salary band in database:", err)
+		return band, err
+	}
+
+	return band, nil
+}
+
+// DeleteSalaryBand removes a salary band.
+func (dh *DBHelper) DeleteSalaryBand(id int) error {
+	if err := dh.ensureMigrated(); err != nil {
+		return err
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	result, err := dh.pgClient.ExecContext(ctx, `DELETE FROM salary_bands WHERE id = $1`, id)
+	if err != nil {
+		log.Println("DeleteSalaryBand: error deleting salary band from database:", err)
+		return err
+	}
+	if deleted, _ := result.RowsAffected(); deleted == 0 {
+		return providers.ErrSalaryBandNotFound
+	}
+
+	return nil
+}
@@ -0,0 +1,259 @@
+package dbHelperProvider
+
+import (
+	"Techiebulter/interview/backend/models"
+	"Techiebulter/interview/backend/providers"
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"log"
+	"time"
+
+	"github.com/lib/pq"
+)
+
+// attendanceColumns is selected by every query returning attendance entries, in the order scanAttendanceEntry expects
+const attendanceColumns = `id, employee_id, clock_in, clock_out, hours, note`
+
+func scanAttendanceEntry(row interface {
+	Scan(dest ...interface{}) error
+}, entry *models.AttendanceEntry) error {
+	return row.Scan(&entry.ID, &entry.EmployeeID, &entry.ClockIn, &entry.ClockOut, &entry.Hours, &entry.Note)
+}
+
+// timesheetColumns is selected by every query returning timesheets, in the order scanTimesheet expects
+const timesheetColumns = `id, employee_id, week_start, entries, hours, status, decided_by, decision_note, decided_at, created_at`
+
+func scanTimesheet(row interface {
+	Scan(dest ...interface{}) error
+}, timesheet *models.Timesheet) error {
+	return row.Scan(&timesheet.ID, &timesheet.EmployeeID, &timesheet.WeekStart, &timesheet.Entries, &timesheet.Hours,
+		&timesheet.Status, &timesheet.DecidedBy, &timesheet.DecisionNote, &timesheet.DecidedAt, &timesheet.CreatedAt)
+}
+
+// ClockIn opens an attendance entry for the employee now.
+func (dh *DBHelper) ClockIn(employeeID int, note string) (models.AttendanceEntry, error) {
+	var entry models.AttendanceEntry
+
+	if err := dh.ensureMigrated(); err != nil {
+		return entry, err
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	query := `
+        INSERT INTO attendance (employee_id, clock_in, note)
+        VALUES ($1, now(), $2)
+        RETURNING ` + attendanceColumns
+	err := scanAttendanceEntry(dh.pgClient.QueryRowContext(ctx, query, employeeID, note), &entry)
+	if err != nil {
+		var pqErr *pq.Error
+		if errors.As(err, &pqErr) && pqErr.Code == "23505" {
+			return entry, providers.ErrAlreadyClockedIn
+		}
+		if errors.As(err, &pqErr) && pqErr.Code == "23503" {
+			return entry, providers.ErrEmployeeNotFound
+		}
+		log.Println("ClockIn: unable to insert attendance entry into database:", err)
+		return entry, err
+	}
+
+	return entry, nil
+}
+
+// ClockOut closes the open attendance entry of the employee now.
+func (dh *DBHelper) ClockOut(employeeID int) (models.AttendanceEntry, error) {
+	var entry models.AttendanceEntry
+
+	if err := dh.ensureMigrated(); err != nil {
+		return entry, err
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	query := `
+        UPDATE attendance
+        SET clock_out = now(), hours = ROUND((EXTRACT(EPOCH FROM now() - clock_in) / 3600)::numeric, 2)
+        WHERE employee_id = $1 AND clock_out IS NULL
+        RETURNING ` + attendanceColumns
+	err := scanAttendanceEntry(dh.pgClient.QueryRowContext(ctx, query, employeeID), &entry)
+	if errors.Is(err, sql.ErrNoRows) {
+		return entry, providers.ErrNotClockedIn
+	}
+	if err != nil {
+		log.Println("ClockOut: unable to update attendance entry in database:", err)
+		return entry, err
+	}
+
+	return entry, nil
+}
+
+// GetAttendance lists the entries clocked in from the first to the last day by clock in time, of every employee when employeeID is 0.
+func (dh *DBHelper) GetAttendance(employeeID int, from models.Date, to models.Date) ([]models.AttendanceEntry, error) {
+	if err := dh.ensureMigrated(); err != nil {
+		return nil, err
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	query := `
+        SELECT ` + attendanceColumns + `
+        FROM attendance
+        WHERE ($1 = 0 OR employee_id = $1) AND clock_in >= $2 AND clock_in < $3
+        ORDER BY clock_in, id
+    `
+	rows, err := dh.reader().QueryContext(ctx, query, employeeID, from.Time, to.AddDate(0, 0, 1))
+	if err != nil {
+		log.Println("GetAttendance: error getting results from database:", err)
+		return nil, err
+	}
+	defer rows.Close()
+
+	entries := []models.AttendanceEntry{}
+	for rows.Next() {
+		var entry models.AttendanceEntry
+		if err := scanAttendanceEntry(rows, &entry); err != nil {
+			log.Println("GetAttendance: error scanning row:", err)
+			return nil, err
+		}
+		entries = append(entries, entry)
+	}
+
+	return entries, rows.Err()
+}
+
+// CreateTimesheet stores a timesheet as submitted.
+func (dh *DBHelper) CreateTimesheet(timesheet models.Timesheet) (models.Timesheet, error) {
+	if err := dh.ensureMigrated(); err != nil {
+		return timesheet, err
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	query := `
+        INSERT INTO timesheets (employee_id, week_start, entries, hours, status)
+        VALUES ($1, $2, $3, $4, $5)
+        RETURNING ` + timesheetColumns
+	err := scanTimesheet(dh.pgClient.QueryRowContext(ctx, query, timesheet.EmployeeID, timesheet.WeekStart, timesheet.Entries,
+		timesheet.Hours, models.TimesheetSubmitted), &timesheet)
+	if err != nil {
+		var pqErr *pq.Error
+		if errors.As(err, &pqErr) && pqErr.Code == "23505" {
+			return timesheet, providers.ErrTimesheetExists
+		}
+		if errors.As(err, &pqErr) && pqErr.Code == "23503" {
+			return timesheet, providers.ErrEmployeeNotFound
+		}
+		log.Println("CreateTimesheet: unable to insert timesheet into database:", err)
+		return timesheet, err
+	}
+
+	return timesheet, nil
+}
+
+// GetTimesheet reads one timesheet.
+func (dh *DBHelper) GetTimesheet(id int) (models.Timesheet, error) {
+	var timesheet models.Timesheet
+
+	if err := dh.ensureMigrated(); err != nil {
+		return timesheet, err
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	err := scanTimesheet(dh.reader().QueryRowContext(ctx, `SELECT `+timesheetColumns+` FROM timesheets WHERE id = $1`, id), &timesheet)
+	if errors.Is(err, sql.ErrNoRows) {
+		return timesheet, providers.ErrTimesheetNotFound
+	}
+	if err != nil {
+		log.Println("GetTimesheet: error getting result from database:", err)
+		return timesheet, err
+	}
+
+	return timesheet, nil
+}
+
+// GetTimesheets lists the timesheets matching filter by week and ID.
+func (dh *DBHelper) GetTimesheets(filter models.TimesheetFilter) ([]models.Timesheet, error) {
+	if err := dh.ensureMigrated(); err != nil {
+		return nil, err
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	// Build the conditions of the filter, each set field adds one
+	var args []interface{}
+	query := `SELECT ` + timesheetColumns + ` FROM timesheets WHERE true`
+	where := func(condition string, value interface{}) {
+		args = append(args, value)
+		query += fmt.Sprintf(" AND "+condition, len(args))
+	}
+	if filter.EmployeeID != 0 {
+		where("employee_id = $%d", filter.EmployeeID)
+	}
+	if !filter.WeekStart.IsZero() {
+		where("week_start = $%d", filter.WeekStart)
+	}
+	if filter.Status != "" {
+		where("status = $%d", filter.Status)
+	}
+	query += " ORDER BY week_start, id"
+
+	rows, err := dh.reader().QueryContext(ctx, query, args...)
+	if err != nil {
+		log.Println("GetTimesheets: error getting results from database:", err)
+		return nil, err
+	}
+	defer rows.Close()
+
+	timesheets := []models.Timesheet{}
+	for rows.Next() {
+		var timesheet models.Timesheet
+		if err := scanTimesheet(rows, &timesheet); err != nil {
+			log.Println("GetTimesheets: error scanning row:", err)
+			return nil, err
+		}
+		timesheets = append(timesheets, timesheet)
+	}
+
+	return timesheets, rows.Err()
+}
+
+// DecideTimesheet approves or rejects a submitted timesheet.
+func (dh *DBHelper) DecideTimesheet(id int, status models.TimesheetStatus, decidedBy string, note string) (models.Timesheet, error) {
+	var timesheet models.Timesheet
+
+	if err := dh.ensureMigrated(); err != nil {
+		return timesheet, err
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	query := `
+        UPDATE timesheets
+        SET status = $1, decided_by = $2, decision_note = $3, decided_at = now()
+        WHERE id = $4 AND status = $5
+        RETURNING ` + timesheetColumns
+	err := scanTimesheet(dh.pgClient.QueryRowContext(ctx, query, status, decidedBy, note, id, models.TimesheetSubmitted), &timesheet)
+	if errors.Is(err, sql.ErrNoRows) {
+		// either there is no such timesheet or it was decided already
+		if _, err := dh.ReadFromPrimary().GetTimesheet(id); err != nil {
+			return timesheet, err
+		}
+		return timesheet, providers.ErrTimesheetDecided
+	}
+	if err != nil {
+		log.Println("DecideTimesheet: unable to update timesheet in database:", err)
+		return timesheet, err
+	}
+
+	return timesheet, nil
+}
@@ -0,0 +1,264 @@
+package dbHelperProvider
+
+import (
+	"Techiebulter/interview/backend/models"
+	"Techiebulter/interview/backend/providers"
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"log"
+	"time"
+)
+
+// webhookDeliveryColumns is selected by every query returning deliveries, in the order scanWebhookDelivery expects
+const webhookDeliveryColumns = `id, subscription_id, event_id, event_type, payload, status, attempts, next_attempt_at,
+    last_status_code, last_error, delivered_at, created_at`
+
+func scanWebhookDelivery(row interface {
+	Scan(dest ...interface{}) error
+}, delivery *models.WebhookDelivery) error {
+	var payload string
+	err := row.Scan(&delivery.ID, &delivery.SubscriptionID, &delivery.EventID, &delivery.EventType, &payload, &delivery.Status,
+		&delivery.Attempts, &delivery.NextAttemptAt, &delivery.LastStatusCode, &delivery.LastError, &delivery.DeliveredAt,
+		&delivery.CreatedAt)
+	delivery.Payload = []byte(payload)
+	return err
+}
+
+// CreateWebhookSubscription stores a webhook subscription.
+func (dh *DBHelper) CreateWebhookSubscription(subscription models.WebhookSubscription) (models.WebhookSubscription, error) {
+	if err := dh.ensureMigrated(); err != nil {
+		return subscription, err
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	query := `
+        INSERT INTO webhook_subscriptions (url, event_types, secret)
+        VALUES ($1, $2, $3)
+        RETURNING id, created_at
+    `
+	err := dh.pgClient.QueryRowContext(ctx, query, subscription.URL, subscription.EventTypes, subscription.Secret).
+		Scan(&subscription.ID, &subscription.CreatedAt)
+	if err != nil {
+		log.Println("CreateWebhookSubscription: unable to insert subscription into database:", err)
+		return subscription, err
+	}
+
+	return subscription, nil
+}
+
+// GetWebhookSubscriptions lists the webhook subscriptions ordered by ID.
+func (dh *DBHelper) GetWebhookSubscriptions() ([]models.WebhookSubscription, error) {
+	if err := dh.ensureMigrated(); err != nil {
+		return nil, err
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	query := `SELECT id, url, event_types, secret, created_at FROM webhook_subscriptions ORDER BY id`
+	rows, err := dh.reader().QueryContext(ctx, query)
+	if err != nil {
+		log.Println("GetWebhookSubscriptions: error getting results from database:", err)
+		return nil, err
+	}
+	defer rows.Close()
+
+	subscriptions := []models.WebhookSubscription{}
+	for rows.Next() {
+		var subscription models.WebhookSubscription
+		err := rows.Scan(&subscription.ID, &subscription.URL, &subscription.EventTypes, &subscription.Secret, &subscription.CreatedAt)
+		if err != nil {
+			log.Println("GetWebhookSubscriptions: error scanning row:", err)
+			return nil, err
+		}
+		subscriptions = append(subscriptions, subscription)
+	}
+
+	return subscriptions, rows.Err()
+}
+
+// DeleteWebhookSubscription removes a webhook subscription, its deliveries are deleted with it.
+func (dh *DBHelper) DeleteWebhookSubscription(id int) error {
+	if err := dh.ensureMigrated(); err != nil {
+		return err
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	result, err := dh.pgClient.ExecContext(ctx, `DELETE FROM webhook_subscriptions WHERE id = $1`, id)
+	if err != nil {
+		log.Println("DeleteWebhookSubscription: error deleting subscription from database:", err)
+		return err
+	}
+	if deleted, _ := result.RowsAffected(); deleted == 0 {
+		return providers.ErrWebhookNotFound
+	}
+
+	return nil
+}
+
+// ClaimWebhookDeliveries leases the due deliveries. Rows another instance is claiming are skipped
+// instead of waited for.
+func (dh *DBHelper) ClaimWebhookDeliveries(now time.Time, leaseUntil time.Time, limit int) ([]models.WebhookDelivery, error) {
+	if err := dh.ensureMigrated(); err != nil {
+		return nil, err
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	query := `
+        UPDATE webhook_deliveries
+        SET next_attempt_at = $2
+        WHERE id IN (
+            SELECT id FROM webhook_deliveries
+            WHERE status = 'pending' AND next_attempt_at <= $1
+            ORDER BY next_attempt_at, id
+            LIMIT $3
+            FOR UPDATE SKIP LOCKED
+        )
+        RETURNING ` + webhookDeliveryColumns
+	rows, err := dh.pgClient.QueryContext(ctx, query, now, leaseUntil, limit)
+	if err != nil {
+		log.Println("ClaimWebhookDeliveries: error claiming deliveries in database:", err)
+		return nil, err
+	}
+	defer rows.Close()
+
+	deliveries := []models.WebhookDelivery{}
+	for rows.Next() {
+		var delivery models.WebhookDelivery
+		if err := scanWebhookDelivery(rows, &delivery); err != nil {
+			log.Println("ClaimWebhookDeliveries: error scanning row:", err)
+			return nil, err
+		}
+		deliveries = append(deliveries, delivery)
+	}
+
+	return deliveries, rows.Err()
+}
+
+// CompleteWebhookAttempt records the outcome of an attempt to post a delivery.
+func (dh *DBHelper) CompleteWebhookAttempt(delivery models.WebhookDelivery) error {
+	if err := dh.ensureMigrated(); err != nil {
+		return err
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	query := `
+        UPDATE webhook_deliveries
+        SET status = $2, attempts = $3, next_attempt_at = $4, last_status_code = $5, last_error = $6, delivered_at = $7
+        WHERE id = $1
+    `
+	_, err := dh.pgClient.ExecContext(ctx, query, delivery.ID, delivery.Status, delivery.Attempts, delivery.NextAttemptAt,
+		delivery.LastStatusCode, delivery.LastError, delivery.DeliveredAt)
+	if err != nil {
+		log.Println("CompleteWebhookAttempt: error updating delivery in database:", err)
+		return err
+	}
+
+	return nil
+}
+
+// GetWebhookDeliveries returns the delivery log of a subscription.
+func (dh *DBHelper) GetWebhookDeliveries(subscriptionID int, status models.WebhookDeliveryStatus, limit int) ([]models.WebhookDelivery, error) {
+	if err := dh.ensureMigrated(); err != nil {
+		return nil, err
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	query := `
+        SELECT ` + webhookDeliveryColumns + `
+        FROM webhook_deliveries
+        WHERE subscription_id = $1 AND ($2::text = '' OR status = $2)
+        ORDER BY id DESC
+        LIMIT $3
+    `
+	rows, err := dh.reader().QueryContext(ctx, query, subscriptionID, status, limit)
+	if err != nil {
+		log.Println("GetWebhookDeliveries: error getting results from database:", err)
+		return nil, err
+	}
+	defer rows.Close()
+
+	deliveries := []models.WebhookDelivery{}
+	for rows.Next() {
+		var delivery models.WebhookDelivery
+		if err := scanWebhookDelivery(rows, &delivery); err != nil {
+			log.Println("GetWebhookDeliveries: error scanning row:", err)
+			return nil, err
+		}
+		deliveries = append(deliveries, delivery)
+	}
+
+	return deliveries, rows.Err()
+}
+
+// RedeliverWebhook queues a delivery again, whatever became of it before.
+func (dh *DBHelper) RedeliverWebhook(subscriptionID int, deliveryID int64) (models.WebhookDelivery, error) {
+	var delivery models.WebhookDelivery
+
+	if err := dh.ensureMigrated(); err != nil {
+		return delivery, err
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	query := `
+        UPDATE webhook_deliveries
+        SET status = 'pending', attempts = 0, next_attempt_at = now(), delivered_at = NULL
+        WHERE id = $1 AND subscription_id = $2
+        RETURNING ` + webhookDeliveryColumns
+	err := scanWebhookDelivery(dh.pgClient.QueryRowContext(ctx, query, deliveryID, subscriptionID), &delivery)
+	if errors.Is(err, sql.ErrNoRows) {
+		return delivery, providers.ErrWebhookDeliveryNotFound
+	}
+	if err != nil {
+		log.Println("RedeliverWebhook: error updating delivery in database:", err)
+		return delivery, err
+	}
+
+	return delivery, nil
+}
+
+// QueueWebhookDeliveries queues a delivery of a logged event for every subscription to its type. An
+// event queued before is not queued again.
+func (dh *DBHelper) QueueWebhookDeliveries(event models.EmployeeEvent) (int, error) {
+	if err := dh.ensureMigrated(); err != nil {
+		return 0, err
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	payload, err := json.Marshal(event)
+	if err != nil {
+		return 0, err
+	}
+
+	query := `
+        INSERT INTO webhook_deliveries (subscription_id, event_id, event_type, payload, next_attempt_at)
+        SELECT id, $1, $2, $3, now()
+        FROM webhook_subscriptions
+        WHERE event_types = '[]'::jsonb OR event_types @> jsonb_build_array($2::text)
+        ON CONFLICT (subscription_id, event_id) DO NOTHING
+    `
+	result, err := dh.pgClient.ExecContext(ctx, query, event.ID, event.Type, string(payload))
+	if err != nil {
+		log.Println("QueueWebhookDeliveries: unable to queue webhook deliveries:", err)
+		return 0, err
+	}
+	queued, _ := result.RowsAffected()
+
+	return int(queued), nil
+}
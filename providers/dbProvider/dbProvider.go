@@ -1,58 +1,91 @@
 package dbProvider
 
 import (
+	"Techiebulter/interview/backend/config"
 	"Techiebulter/interview/backend/providers"
+	"Techiebulter/interview/backend/utils"
 	"context"
 	"database/sql"
 	"fmt"
-	"log"
+	"sync"
+	"sync/atomic"
 	"time"
 
 	_ "github.com/lib/pq"
+	"github.com/sirupsen/logrus"
 )
 
 type pgClientProvider struct {
 	pgClient *sql.DB
-	ctx      context.Context
+	cfg      config.DatabaseConfig
+
+	// healthy is updated by the background health check
+	healthy atomic.Bool
+
+	// replicas serve reads, next drives the round robin between them
+	replicas []*replica
+	next     atomic.Uint64
+
+	stop     chan struct{}
+	stopOnce sync.Once
+	done     chan struct{}
 }
 
-func ConnectDB(connectionString string) providers.PgClientProvider {
+// ConnectDB opens the PostgreSQL pool and retries the first connection with exponential backoff.
+// The returned provider is always usable: if every attempt failed the error is returned alongside it,
+// so the caller can decide to start degraded while the background health check keeps reconnecting.
+func ConnectDB(cfg config.DatabaseConfig) (providers.PgClientProvider, error) {
+	pgClient, err := sql.Open("postgres", cfg.URL)
+	if err != nil {
+		return nil, fmt.Errorf("unable to create PostgreSQL client: %w", err)
+	}
 
-	var (
-		pgClient    *sql.DB
-		err         error
-		maxAttempts = 3
-	)
+	pgClient.SetMaxOpenConns(cfg.MaxOpenConns)
+	pgClient.SetMaxIdleConns(cfg.MaxIdleConns)
+	pgClient.SetConnMaxLifetime(cfg.ConnMaxLifetime)
+	pgClient.SetConnMaxIdleTime(cfg.ConnMaxIdleTime)
 
-	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Second)
-	defer cancel()
+	p := &pgClientProvider{
+		pgClient: pgClient,
+		cfg:      cfg,
+		stop:     make(chan struct{}),
+		done:     make(chan struct{}),
+	}
+
+	if err := p.openReplicas(); err != nil {
+		_ = p.closeClients()
+		return nil, err
+	}
 
-	for i := 0; i < maxAttempts; i++ {
-		pgClient, err = sql.Open("postgres", connectionString)
-		if err != nil {
-			fmt.Println("Unable to create PostgreSQL client:", err)
-			time.Sleep(2 * time.Second)
-			continue
+	for attempt := 0; attempt < cfg.ConnectAttempts; attempt++ {
+		if attempt > 0 {
+			time.Sleep(utils.Backoff(cfg.BackoffInitial, cfg.BackoffMax, attempt-1))
 		}
-		err = pgClient.PingContext(ctx)
-		if err != nil {
-			fmt.Println("Unable to connect to PostgreSQL database:", err)
-			time.Sleep(2 * time.Second)
-			continue
+		if err = p.ping(); err == nil {
+			break
 		}
-		break
+		logrus.Warnf("Unable to connect to PostgreSQL database (attempt %d/%d): %v", attempt+1, cfg.ConnectAttempts, err)
 	}
 
-	if err != nil {
-		log.Fatalf("Failed to initialize PostgreSQL client: %v", err)
-	} else {
-		log.Printf("Successfully connected to PostgreSQL database")
+	if err == nil {
+		p.healthy.Store(true)
+		logrus.Info("Successfully connected to PostgreSQL database")
 	}
+	p.checkReplicas()
 
-	return &pgClientProvider{
-		pgClient: pgClient,
-		ctx:      ctx,
+	go p.monitor()
+
+	if err != nil {
+		return p, fmt.Errorf("failed to connect to PostgreSQL after %d attempts: %w", cfg.ConnectAttempts, err)
 	}
+	return p, nil
+}
+
+// ping checks the connection within the configured per-attempt timeout
+func (p *pgClientProvider) ping() error {
+	ctx, cancel := context.WithTimeout(context.Background(), p.cfg.ConnectTimeout)
+	defer cancel()
+	return p.pgClient.PingContext(ctx)
 }
 
 func (p *pgClientProvider) Ping() error {
@@ -60,9 +93,35 @@ func (p *pgClientProvider) Ping() error {
 }
 
 func (p *pgClientProvider) Close() error {
-	return p.pgClient.Close()
+	p.stopOnce.Do(func() {
+		close(p.stop)
+		<-p.done
+	})
+	return p.closeClients()
+}
+
+// closeClients closes the replica pools and then the primary, returning the first error
+func (p *pgClientProvider) closeClients() error {
+	var closeErr error
+	for _, r := range p.replicas {
+		if err := r.client.Close(); err != nil && closeErr == nil {
+			closeErr = fmt.Errorf("closing %s: %w", r.name, err)
+		}
+	}
+	if err := p.pgClient.Close(); err != nil && closeErr == nil {
+		closeErr = err
+	}
+	return closeErr
 }
 
 func (p *pgClientProvider) Client() *sql.DB {
 	return p.pgClient
 }
+
+func (p *pgClientProvider) Healthy() bool {
+	return p.healthy.Load()
+}
+
+func (p *pgClientProvider) Stats() sql.DBStats {
+	return p.pgClient.Stats()
+}
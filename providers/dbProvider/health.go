@@ -0,0 +1,96 @@
+package dbProvider
+
+import (
+	"Techiebulter/interview/backend/utils"
+	"database/sql"
+	"time"
+
+	"github.com/sirupsen/logrus"
+)
+
+// monitor pings the database every HealthCheckInterval and logs pool saturation. While the primary is
+// unreachable it retries on the reconnect backoff instead. The replicas are checked on their own interval,
+// see replicaCheckInterval.
+func (p *pgClientProvider) monitor() {
+	defer close(p.done)
+
+	var replicaChecks <-chan time.Time
+	if len(p.replicas) > 0 {
+		ticker := time.NewTicker(p.replicaCheckInterval())
+		defer ticker.Stop()
+		replicaChecks = ticker.C
+	}
+
+	var (
+		failures int
+		last     sql.DBStats
+	)
+	for {
+		wait := p.cfg.HealthCheckInterval
+		if !p.healthy.Load() {
+			wait = utils.Backoff(p.cfg.BackoffInitial, p.cfg.BackoffMax, failures)
+		}
+
+		if !p.await(wait, replicaChecks) {
+			return
+		}
+
+		if err := p.ping(); err != nil {
+			failures++
+			if p.healthy.Swap(false) {
+				logrus.Errorf("Lost connection to PostgreSQL database: %v", err)
+			} else {
+				logrus.Debugf("PostgreSQL database still unreachable after %d checks: %v", failures, err)
+			}
+			continue
+		}
+
+		if !p.healthy.Swap(true) {
+			logrus.Infof("Reconnected to PostgreSQL database after %d failed checks", failures)
+		}
+		failures = 0
+
+		stats := p.pgClient.Stats()
+		p.logSaturation(stats, last)
+		last = stats
+	}
+}
+
+// await waits for the next check of the primary, checking the replicas whenever replicaChecks ticks.
+// It returns false once the provider is closed.
+func (p *pgClientProvider) await(wait time.Duration, replicaChecks <-chan time.Time) bool {
+	timer := time.NewTimer(wait)
+	defer timer.Stop()
+	for {
+		select {
+		case <-p.stop:
+			return false
+		case <-replicaChecks:
+			p.checkReplicas()
+		case <-timer.C:
+			return true
+		}
+	}
+}
+
+// logSaturation warns when the pool is close to MaxOpenConns or requests had to wait for a connection
+func (p *pgClientProvider) logSaturation(stats, last sql.DBStats) {
+	fields := logrus.Fields{
+		"open":      stats.OpenConnections,
+		"inUse":     stats.InUse,
+		"idle":      stats.Idle,
+		"maxOpen":   stats.MaxOpenConnections,
+		"waitCount": stats.WaitCount - last.WaitCount,
+		"waitTime":  stats.WaitDuration - last.WaitDuration,
+	}
+
+	if stats.MaxOpenConnections > 0 && float64(stats.InUse)/float64(stats.MaxOpenConnections) >= p.cfg.SaturationThreshold {
+		logrus.WithFields(fields).Warn("PostgreSQL connection pool is saturated")
+		return
+	}
+	if stats.WaitCount > last.WaitCount {
+		logrus.WithFields(fields).Warn("requests waited for a PostgreSQL connection")
+		return
+	}
+	logrus.WithFields(fields).Debug("PostgreSQL connection pool stats")
+}
@@ -0,0 +1,117 @@
+package dbProvider
+
+import (
+	"Techiebulter/interview/backend/providers"
+	"context"
+	"database/sql"
+	"fmt"
+	"sync/atomic"
+	"time"
+
+	"github.com/sirupsen/logrus"
+)
+
+// replicationLagQuery reports how far a replica is behind in seconds. A replica that has
+// replayed everything it received is not lagging even if the primary has been idle for a while.
+// On a server that is not in recovery the functions return NULL, which counts as no lag.
+const replicationLagQuery = `
+        SELECT COALESCE(
+            CASE WHEN pg_last_wal_receive_lsn() = pg_last_wal_replay_lsn() THEN 0
+                 ELSE EXTRACT(EPOCH FROM now() - pg_last_xact_replay_timestamp())
+            END, 0)
+    `
+
+type replica struct {
+	name    string
+	client  *sql.DB
+	healthy atomic.Bool
+	lag     atomic.Int64
+}
+
+// openReplicas opens a pool per replica with the same limits as the primary
+func (p *pgClientProvider) openReplicas() error {
+	for i, url := range p.cfg.ReplicaURLs {
+		client, err := sql.Open("postgres", url)
+		if err != nil {
+			return fmt.Errorf("unable to create PostgreSQL client for replica %d: %w", i, err)
+		}
+		client.SetMaxOpenConns(p.cfg.MaxOpenConns)
+		client.SetMaxIdleConns(p.cfg.MaxIdleConns)
+		client.SetConnMaxLifetime(p.cfg.ConnMaxLifetime)
+		client.SetConnMaxIdleTime(p.cfg.ConnMaxIdleTime)
+
+		p.replicas = append(p.replicas, &replica{name: fmt.Sprintf("replica-%d", i), client: client})
+	}
+	return nil
+}
+
+// replicaCheckInterval is how often the replicas are checked: every HealthCheckInterval, but at least
+// twice per MaxReplicaLag so a replica falling behind leaves the rotation before it lags much further
+func (p *pgClientProvider) replicaCheckInterval() time.Duration {
+	if interval := p.cfg.MaxReplicaLag / 2; interval > 0 && interval < p.cfg.HealthCheckInterval {
+		return interval
+	}
+	return p.cfg.HealthCheckInterval
+}
+
+// checkReplicas marks each replica healthy if it answers and lags no more than MaxReplicaLag
+func (p *pgClientProvider) checkReplicas() {
+	for _, r := range p.replicas {
+		lag, err := p.replicationLag(r.client)
+		if err == nil {
+			r.lag.Store(int64(lag))
+		}
+
+		healthy := err == nil && lag <= p.cfg.MaxReplicaLag
+		if healthy == r.healthy.Swap(healthy) {
+			continue
+		}
+		switch {
+		case healthy:
+			logrus.Infof("PostgreSQL %s is back in the read rotation", r.name)
+		case err != nil:
+			logrus.Warnf("PostgreSQL %s is unreachable, reads fall back: %v", r.name, err)
+		default:
+			logrus.Warnf("PostgreSQL %s lags %s behind the primary, reads fall back", r.name, lag)
+		}
+	}
+}
+
+func (p *pgClientProvider) replicationLag(client *sql.DB) (time.Duration, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), p.cfg.ConnectTimeout)
+	defer cancel()
+
+	var seconds float64
+	if err := client.QueryRowContext(ctx, replicationLagQuery).Scan(&seconds); err != nil {
+		return 0, err
+	}
+	return time.Duration(seconds * float64(time.Second)), nil
+}
+
+func (p *pgClientProvider) Reader() *sql.DB {
+	n := len(p.replicas)
+	if n == 0 {
+		return p.pgClient
+	}
+
+	// round robin over the replicas, skipping the unhealthy ones
+	start := int(p.next.Add(1) % uint64(n))
+	for i := 0; i < n; i++ {
+		if r := p.replicas[(start+i)%n]; r.healthy.Load() {
+			return r.client
+		}
+	}
+	return p.pgClient
+}
+
+func (p *pgClientProvider) Replicas() []providers.ReplicaStatus {
+	statuses := make([]providers.ReplicaStatus, 0, len(p.replicas))
+	for _, r := range p.replicas {
+		statuses = append(statuses, providers.ReplicaStatus{
+			Name:    r.name,
+			Healthy: r.healthy.Load(),
+			Lag:     time.Duration(r.lag.Load()),
+		})
+	}
+	return statuses
+}
@@ -0,0 +1,83 @@
+package dbProvider
+
+import (
+	"Techiebulter/interview/backend/config"
+	"Techiebulter/interview/backend/providers"
+	"context"
+	"database/sql"
+	"fmt"
+	"net/url"
+
+	"github.com/sirupsen/logrus"
+	_ "modernc.org/sqlite"
+)
+
+type sqliteClientProvider struct {
+	sqliteClient *sql.DB
+	cfg          config.DatabaseConfig
+}
+
+// ConnectSQLite opens the SQLite database file at cfg.SQLitePath, creating it if needed.
+func ConnectSQLite(cfg config.DatabaseConfig) (providers.PgClientProvider, error) {
+	// foreign keys are off by default in SQLite, and a busy timeout makes concurrent writers wait instead of failing
+	pragmas := url.Values{}
+	pragmas.Add("_pragma", "foreign_keys(1)")
+	pragmas.Add("_pragma", "busy_timeout(5000)")
+	pragmas.Add("_pragma", "journal_mode(WAL)")
+
+	sqliteClient, err := sql.Open("sqlite", "file:"+cfg.SQLitePath+"?"+pragmas.Encode())
+	if err != nil {
+		return nil, fmt.Errorf("unable to create SQLite client: %w", err)
+	}
+
+	// SQLite allows a single writer, one connection avoids "database is locked" errors
+	sqliteClient.SetMaxOpenConns(1)
+
+	p := &sqliteClientProvider{
+		sqliteClient: sqliteClient,
+		cfg:          cfg,
+	}
+	if err := p.ping(); err != nil {
+		_ = sqliteClient.Close()
+		return nil, fmt.Errorf("unable to open SQLite database %s: %w", cfg.SQLitePath, err)
+	}
+
+	logrus.Infof("Successfully opened SQLite database %s", cfg.SQLitePath)
+	return p, nil
+}
+
+func (p *sqliteClientProvider) ping() error {
+	ctx, cancel := context.WithTimeout(context.Background(), p.cfg.ConnectTimeout)
+	defer cancel()
+	return p.sqliteClient.PingContext(ctx)
+}
+
+func (p *sqliteClientProvider) Ping() error {
+	return p.sqliteClient.Ping()
+}
+
+func (p *sqliteClientProvider) Close() error {
+	return p.sqliteClient.Close()
+}
+
+func (p *sqliteClientProvider) Client() *sql.DB {
+	return p.sqliteClient
+}
+
+// Healthy checks the database file directly, there is no background health check for SQLite
+func (p *sqliteClientProvider) Healthy() bool {
+	return p.ping() == nil
+}
+
+func (p *sqliteClientProvider) Stats() sql.DBStats {
+	return p.sqliteClient.Stats()
+}
+
+// Reader returns the only database, SQLite has no replicas
+func (p *sqliteClientProvider) Reader() *sql.DB {
+	return p.sqliteClient
+}
+
+func (p *sqliteClientProvider) Replicas() []providers.ReplicaStatus {
+	return nil
+}
@@ -0,0 +1,104 @@
+package providers
+
+import (
+	"errors"
+	"fmt"
+)
+
+// Errors the DbHelperProvider implementations translate their driver errors into
+var (
+	// ErrEmployeeNotFound is returned when no employee has the requested ID
+	ErrEmployeeNotFound = errors.New("employee not found")
+
+	// ErrEmailTaken is returned when another employee already uses the email address
+	ErrEmailTaken = errors.New("email is already used by another employee")
+
+	// ErrTerminationBeforeHire is returned when the stored and updated dates together would end employment before it began
+	ErrTerminationBeforeHire = errors.New("terminationDate must not be before hireDate")
+
+	// ErrManagerNotFound is returned when the manager of an employee is not a stored employee
+	ErrManagerNotFound = errors.New("managerId must be the ID of a stored employee")
+
+	// ErrCustomFieldExists is returned when a custom field with the same name is already defined
+	ErrCustomFieldExists = errors.New("custom field is already defined")
+
+	// ErrCustomFieldNotFound is returned when no custom field has the requested name
+	ErrCustomFieldNotFound = errors.New("custom field not found")
+
+	// ErrWebhookNotFound is returned when no webhook subscription has the requested ID
+	ErrWebhookNotFound = errors.New("webhook subscription not found")
+
+	// ErrWebhookDeliveryNotFound is returned when the subscription has no delivery with the requested ID
+	ErrWebhookDeliveryNotFound = errors.New("webhook delivery not found")
+
+	// ErrLeaveTypeExists is returned when a leave type with the same name is already defined
+	ErrLeaveTypeExists = errors.New("leave type is already defined")
+
+	// ErrLeaveTypeNotFound is returned when no leave type has the requested ID
+	ErrLeaveTypeNotFound = errors.New("leave type not found")
+
+	// ErrLeaveRequestNotFound is returned when no leave request has the requested ID
+	ErrLeaveRequestNotFound = errors.New("leave request not found")
+
+	// ErrLeaveOverlap is returned when the employee already has pending or approved leave on one of the days
+	ErrLeaveOverlap = errors.New("leave overlaps a pending or approved leave request")
+
+	// ErrInsufficientLeave is returned when the balance of the leave type does not cover the requested days
+	ErrInsufficientLeave = errors.New("leave balance does not cover the requested days")
+
+	// ErrLeaveRequestDecided is returned when the workflow does not let the request move to the requested status
+	ErrLeaveRequestDecided = errors.New("leave request can no longer be changed this way")
+
+	// ErrAlreadyClockedIn is returned when an employee clocks in without having clocked out
+	ErrAlreadyClockedIn = errors.New("employee is already clocked in")
+
+	// ErrNotClockedIn is returned when an employee clocks out without having clocked in
+	ErrNotClockedIn = errors.New("employee is not clocked in")
+
+	// ErrTimesheetExists is returned when the week already has a submitted or approved timesheet
+	ErrTimesheetExists = errors.New("a timesheet for this week is already submitted or approved")
+
+	// ErrTimesheetNotFound is returned when no timesheet has the given ID
+	ErrTimesheetNotFound = errors.New("timesheet not found")
+
+	// ErrTimesheetDecided is returned when a timesheet that is no longer submitted is approved or rejected
+	ErrTimesheetDecided = errors.New("timesheet was already approved or rejected")
+
+	// ErrPayrollRunNotFound is returned when no payroll run has the given ID
+	ErrPayrollRunNotFound = errors.New("payroll run not found")
+
+	// ErrPayrollRunApproved is returned when an approved payroll run would be approved again or replaced
+	ErrPayrollRunApproved = errors.New("payroll run is approved and can no longer change")
+
+	// ErrPayslipNotFound is returned when an employee has no approved payslip for the period
+	ErrPayslipNotFound = errors.New("payslip not found")
+
+	// ErrSalaryBandExists is returned when the position already has a salary band
+	ErrSalaryBandExists = errors.New("position already has a salary band")
+
+	// ErrSalaryBandNotFound is returned when no salary band has the requested ID
+	ErrSalaryBandNotFound = errors.New("salary band not found")
+)
+
+// EmployeeNotFoundError is returned by GetEmployeeById and matches ErrEmployeeNotFound
+type EmployeeNotFoundError struct {
+	ID int
+}
+
+func (e *EmployeeNotFoundError) Error() string {
+	return fmt.Sprintf("employee with ID %d not found", e.ID)
+}
+
+func (e *EmployeeNotFoundError) Is(target error) bool {
+	return target == ErrEmployeeNotFound
+}
+
+// EmployeeMergedError is returned for the ID of an employee that was merged into another one
+type EmployeeMergedError struct {
+	ID         int
+	MergedInto int
+}
+
+func (e *EmployeeMergedError) Error() string {
+	return fmt.Sprintf("employee with ID %d was merged into employee %d", e.ID, e.MergedInto)
+}
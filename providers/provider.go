@@ -2,6 +2,7 @@ package providers
 
 import (
 	"database/sql"
+	"time"
 )
 
 // PgClientProvider provides database connection for PostgreSQL.
@@ -14,4 +15,23 @@ type PgClientProvider interface {
 
 	// Client returns the pointer to the PostgreSQL database client.
 	Client() *sql.DB
+
+	// Healthy reports whether the last background health check reached the database.
+	Healthy() bool
+
+	// Stats returns the connection pool statistics.
+	Stats() sql.DBStats
+
+	// Reader returns a healthy read replica within the staleness tolerance, or the primary if there is none.
+	Reader() *sql.DB
+
+	// Replicas reports the health of every configured read replica.
+	Replicas() []ReplicaStatus
+}
+
+// ReplicaStatus is the last known state of a read replica.
+type ReplicaStatus struct {
+	Name    string        `json:"name"`
+	Healthy bool          `json:"healthy"`
+	Lag     time.Duration `json:"lag"`
 }
@@ -0,0 +1,85 @@
+package sqliteHelperProvider
+
+import (
+	"Techiebulter/interview/backend/models"
+	"Techiebulter/interview/backend/providers"
+	"context"
+	"errors"
+	"log"
+	"time"
+
+	"modernc.org/sqlite"
+	sqlite3 "modernc.org/sqlite/lib"
+)
+
+// CreateCustomFieldDefinition adds a custom field to the employee schema.
+func (sh *SQLiteHelper) CreateCustomFieldDefinition(definition models.CustomFieldDefinition) error {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	query := `
+        INSERT INTO custom_field_definitions (name, type, required, options, description)
+        VALUES (?, ?, ?, ?, ?)
+    `
+	_, err := sh.sqliteClient.ExecContext(ctx, query, definition.Name, definition.Type, definition.Required,
+		definition.Options, definition.Description)
+	if err != nil {
+		var sqliteErr *sqlite.Error
+		if errors.As(err, &sqliteErr) && sqliteErr.Code() == sqlite3.SQLITE_CONSTRAINT_PRIMARYKEY {
+			return providers.ErrCustomFieldExists
+		}
+		log.Println("CreateCustomFieldDefinition: unable to insert definition into database:", err)
+		return err
+	}
+
+	return nil
+}
+
+// GetCustomFieldDefinitions lists the custom field schema ordered by name.
+func (sh *SQLiteHelper) GetCustomFieldDefinitions() ([]models.CustomFieldDefinition, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	query := `
+        SELECT name, type, required, options, description, created_at
+        FROM custom_field_definitions
+        ORDER BY name
+    `
+	rows, err := sh.sqliteClient.QueryContext(ctx, query)
+	if err != nil {
+		log.Println("GetCustomFieldDefinitions: error getting results from database:", err)
+		return nil, err
+	}
+	defer rows.Close()
+
+	definitions := []models.CustomFieldDefinition{}
+	for rows.Next() {
+		var definition models.CustomFieldDefinition
+		err := rows.Scan(&definition.Name, &definition.Type, &definition.Required, &definition.Options,
+			&definition.Description, &definition.CreatedAt)
+		if err != nil {
+			log.Println("GetCustomFieldDefinitions: error scanning row:", err)
+			return nil, err
+		}
+		definitions = append(definitions, definition)
+	}
+
+	return definitions, rows.Err()
+}
+
+// DeleteCustomFieldDefinition removes a custom field from the schema, values already stored on employees are kept.
+func (sh *SQLiteHelper) DeleteCustomFieldDefinition(name string) error {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	result, err := sh.sqliteClient.ExecContext(ctx, `DELETE FROM custom_field_definitions WHERE name = ?`, name)
+	if err != nil {
+		log.Println("DeleteCustomFieldDefinition: error deleting definition from database:", err)
+		return err
+	}
+	if deleted, _ := result.RowsAffected(); deleted == 0 {
+		return providers.ErrCustomFieldNotFound
+	}
+
+	return nil
+}
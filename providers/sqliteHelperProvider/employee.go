@@ -0,0 +1,533 @@
+package sqliteHelperProvider
+
+import (
+	"Techiebulter/interview/backend/models"
+	"Techiebulter/interview/backend/providers"
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"log"
+	"strconv"
+	"strings"
+	"time"
+
+	"modernc.org/sqlite"
+	sqlite3 "modernc.org/sqlite/lib"
+)
+
+// employeeColumns is selected by every query returning employees, in the order scanEmployee expects
+const employeeColumns = `id, name, position, salary, COALESCE(email, ''), phone, hire_date, termination_date,
+        employment_type, status, location, department, manager_id, custom_fields, currency, created_at, updated_at`
+
+// scanEmployee scans a row selected with employeeColumns
+func scanEmployee(row interface {
+	Scan(dest ...interface{}) error
+}, emp *models.Employee) error {
+	return row.Scan(&emp.ID, &emp.Name, &emp.Position, &emp.Salary, &emp.Email, &emp.Phone, &emp.HireDate, &emp.TerminationDate,
+		&emp.EmploymentType, &emp.Status, &emp.Location, &emp.Department, &emp.ManagerID, &emp.CustomFields, &emp.Currency,
+		&emp.CreatedAt, &emp.UpdatedAt)
+}
+
+// translateError maps missing rows and constraint violations to the errors of the providers package
+func translateError(err error) error {
+	if errors.Is(err, sql.ErrNoRows) {
+		return providers.ErrEmployeeNotFound
+	}
+	var sqliteErr *sqlite.Error
+	if errors.As(err, &sqliteErr) && sqliteErr.Code() == sqlite3.SQLITE_CONSTRAINT_UNIQUE &&
+		strings.Contains(sqliteErr.Error(), "employees.email") {
+		return providers.ErrEmailTaken
+	}
+	if errors.As(err, &sqliteErr) && sqliteErr.Code() == sqlite3.SQLITE_CONSTRAINT_CHECK &&
+		strings.Contains(sqliteErr.Error(), "termination_date") {
+		return providers.ErrTerminationBeforeHire
+	}
+	if errors.As(err, &sqliteErr) && sqliteErr.Code() == sqlite3.SQLITE_CONSTRAINT_FOREIGNKEY {
+		return providers.ErrManagerNotFound
+	}
+	return err
+}
+
+// replaceEmployeeQuery writes every column of an employee
+const replaceEmployeeQuery = `
+        UPDATE employees
+        SET name = ?, position = ?, salary = ROUND(?, 2), email = NULLIF(?, ''), phone = ?, hire_date = ?,
+            termination_date = ?, employment_type = ?, status = ?, location = ?, department = ?, manager_id = ?,
+            custom_fields = ?, currency = COALESCE(NULLIF(?, ''), currency), updated_at = CURRENT_TIMESTAMP
+        WHERE id = ?
+        RETURNING ` + employeeColumns
+
+// replaceEmployee stores every field of employee in the row with the given ID and returns the stored row
+func replaceEmployee(ctx context.Context, tx *sql.Tx, id int, employee models.Employee) (models.Employee, error) {
+	var stored models.Employee
+	err := scanEmployee(tx.QueryRowContext(ctx, replaceEmployeeQuery, employee.Name, employee.Position, employee.Salary,
+		employee.Email, employee.Phone, employee.HireDate, employee.TerminationDate, employee.EmploymentType,
+		employee.Status, employee.Location, employee.Department, employee.ManagerID, employee.CustomFields, employee.Currency, id), &stored)
+	return stored, translateError(err)
+}
+
+// recordChange records that employee was stored as it is now, in the transaction that stored it, and
+// adds its salary to the salary history when it changed
+func recordChange(ctx context.Context, tx *sql.Tx, eventType models.EmployeeEventType, employee models.Employee) error {
+	if err := recordSalary(ctx, tx, employee, eventType == models.EmployeeCreated); err != nil {
+		return err
+	}
+	_, err := recordEmployeeEvent(ctx, tx, models.EmployeeEvent{Type: eventType, EmployeeID: employee.ID, Employee: &employee,
+		Time: time.Now().UTC()})
+	return err
+}
+
+// recordDeletion records that the employee with the given ID was deleted, in the transaction that deleted it
+func recordDeletion(ctx context.Context, tx *sql.Tx, id int) error {
+	_, err := recordEmployeeEvent(ctx, tx, models.EmployeeEvent{Type: models.EmployeeDeleted, EmployeeID: id, Time: time.Now().UTC()})
+	return err
+}
+
+// CreateEmployee creates a new employee record in the database.
+func (sh *SQLiteHelper) CreateEmployee(employee models.Employee) error {
+	_, err := sh.InsertEmployee(employee)
+	return err
+}
+
+// InsertEmployee creates a new employee record and returns it as stored, with its ID.
+func (sh *SQLiteHelper) InsertEmployee(employee models.Employee) (models.Employee, error) {
+	var created models.Employee
+
+	// Set a timeout for the database operation
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	// Salary is rounded like the NUMERIC(10, 2) column of the PostgreSQL schema
+	insertQuery := `
+        INSERT INTO employees (name, position, salary, email, phone, hire_date, termination_date,
+            employment_type, status, location, department, manager_id, custom_fields, currency, created_at, updated_at)
+        VALUES (?, ?, ROUND(?, 2), NULLIF(?, ''), ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, CURRENT_TIMESTAMP, CURRENT_TIMESTAMP)
+        RETURNING ` + employeeColumns
+
+	tx, err := sh.sqliteClient.BeginTx(ctx, nil)
+	if err != nil {
+		log.Println("InsertEmployee: unable to begin transaction:", err)
+		return created, err
+	}
+	defer func() { _ = tx.Rollback() }()
+
+	// Execute the insert query to add the new employee
+	employee.ApplyDefaults()
+	err = scanEmployee(tx.QueryRowContext(ctx, insertQuery, employee.Name, employee.Position, employee.Salary,
+		employee.Email, employee.Phone, employee.HireDate, employee.TerminationDate,
+		employee.EmploymentType, employee.Status, employee.Location, employee.Department, employee.ManagerID,
+		employee.CustomFields, employee.Currency), &created)
+	if err != nil {
+		log.Print("InsertEmployee: unable to insert employee into database:", err)
+		return created, translateError(err)
+	}
+
+	if err := recordChange(ctx, tx, models.EmployeeCreated, created); err != nil {
+		log.Println("InsertEmployee:", err)
+		return created, err
+	}
+
+	if err := tx.Commit(); err != nil {
+		log.Println("InsertEmployee: unable to commit transaction:", err)
+		return created, err
+	}
+
+	// Employee successfully created
+	return created, nil
+}
+
+// GetEmployeeById retrieves an employee from the database by their ID.
+func (sh *SQLiteHelper) GetEmployeeById(id int) (models.Employee, error) {
+	// Initialize an empty Employee struct to store the result
+	var emp models.Employee
+
+	// Set a timeout for the database operation
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	// Define the SQL query to select an employee by ID
+	query := `
+        SELECT ` + employeeColumns + `
+        FROM employees
+        WHERE id = ?
+    `
+
+	// Execute the SQL query to retrieve the employee by ID
+	err := scanEmployee(sh.sqliteClient.QueryRowContext(ctx, query, id), &emp)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			// The ID may belong to a duplicate that was merged into another employee
+			if target, redirectErr := redirectOf(ctx, sh.sqliteClient, id); redirectErr == nil {
+				return emp, &providers.EmployeeMergedError{ID: id, MergedInto: target}
+			}
+			// If no employee with the given ID is found, return a specific error
+			return emp, &providers.EmployeeNotFoundError{ID: id}
+		}
+		log.Println("GetEmployeeById: error retrieving employee from database:", err)
+		return emp, err
+	}
+
+	// Return the retrieved employee and nil error
+	return emp, nil
+}
+
+// UpdateEmployee selectively updates an employee's details in the database based on non-zero and non-empty fields.
+func (sh *SQLiteHelper) UpdateEmployee(employee models.Employee) (models.Employee, error) {
+	// Initialize an empty Employee struct to store the updated details
+	var updatedEmployee models.Employee
+
+	// Set a timeout for the database operation
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	// Collect the non-zero and non-empty fields of the employee struct
+	var (
+		assignments []string
+		args        []interface{}
+	)
+	set := func(assignment string, value interface{}) {
+		assignments = append(assignments, assignment)
+		args = append(args, value)
+	}
+	if employee.Name != "" {
+		set("name = ?", employee.Name)
+	}
+	if employee.Position != "" {
+		set("position = ?", employee.Position)
+	}
+	if !employee.Salary.IsZero() {
+		set("salary = ROUND(?, 2)", employee.Salary)
+	}
+	if employee.Currency != "" {
+		set("currency = ?", employee.Currency)
+	}
+	if employee.Email != "" {
+		set("email = ?", employee.Email)
+	}
+	if employee.Phone != "" {
+		set("phone = ?", employee.Phone)
+	}
+	if employee.HireDate != nil {
+		set("hire_date = ?", employee.HireDate)
+	}
+	if employee.TerminationDate != nil {
+		set("termination_date = ?", employee.TerminationDate)
+	}
+	if employee.EmploymentType != "" {
+		set("employment_type = ?", employee.EmploymentType)
+	}
+	if employee.Status != "" {
+		set("status = ?", employee.Status)
+	}
+	if employee.Location != "" {
+		set("location = ?", employee.Location)
+	}
+	if employee.Department != "" {
+		set("department = ?", employee.Department)
+	}
+	if employee.ManagerID != nil {
+		set("manager_id = ?", employee.ManagerID)
+	}
+	if employee.CustomFields != nil {
+		set("custom_fields = ?", employee.CustomFields)
+	}
+	if len(assignments) == 0 {
+		return updatedEmployee, errors.New("no fields to update")
+	}
+
+	// Update the employee with the given ID and retrieve the updated record
+	query := "UPDATE employees SET " + strings.Join(assignments, ", ") + ", updated_at = CURRENT_TIMESTAMP" +
+		" WHERE id = ? RETURNING " + employeeColumns
+	args = append(args, employee.ID)
+
+	tx, err := sh.sqliteClient.BeginTx(ctx, nil)
+	if err != nil {
+		log.Println("UpdateEmployee: unable to begin transaction:", err)
+		return updatedEmployee, err
+	}
+	defer func() { _ = tx.Rollback() }()
+
+	err = scanEmployee(tx.QueryRowContext(ctx, query, args...), &updatedEmployee)
+	if err != nil {
+		log.Println("UpdateEmployee: error updating employee details in database:", err)
+		return updatedEmployee, translateError(err)
+	}
+
+	if err := recordChange(ctx, tx, models.EmployeeUpdated, updatedEmployee); err != nil {
+		log.Println("UpdateEmployee:", err)
+		return updatedEmployee, err
+	}
+
+	if err := tx.Commit(); err != nil {
+		log.Println("UpdateEmployee: unable to commit transaction:", err)
+		return updatedEmployee, err
+	}
+
+	// Return the updated employee details and nil error
+	return updatedEmployee, nil
+}
+
+// PatchEmployee replaces every field of an employee with the result of patch inside one transaction.
+func (sh *SQLiteHelper) PatchEmployee(id int, patch func(current models.Employee) (models.Employee, error)) (models.Employee, error) {
+	var patched models.Employee
+
+	// Set a timeout for the database operation
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	tx, err := sh.sqliteClient.BeginTx(ctx, nil)
+	if err != nil {
+		log.Println("PatchEmployee: unable to begin transaction:", err)
+		return patched, err
+	}
+	defer func() { _ = tx.Rollback() }()
+
+	// The single connection is held by the transaction, so nobody changes the employee in between
+	var current models.Employee
+	query := `SELECT ` + employeeColumns + ` FROM employees WHERE id = ?`
+	if err := scanEmployee(tx.QueryRowContext(ctx, query, id), &current); err != nil {
+		if err == sql.ErrNoRows {
+			return patched, providers.ErrEmployeeNotFound
+		}
+		log.Println("PatchEmployee: error retrieving employee from database:", err)
+		return patched, err
+	}
+
+	employee, err := patch(current)
+	if err != nil {
+		return patched, err
+	}
+
+	// Write every column, so fields the patch cleared are cleared in the database too
+	patched, err = replaceEmployee(ctx, tx, id, employee)
+	if err != nil {
+		log.Println("PatchEmployee: error updating employee in database:", err)
+		return patched, err
+	}
+
+	if err := recordChange(ctx, tx, models.EmployeeUpdated, patched); err != nil {
+		log.Println("PatchEmployee:", err)
+		return patched, err
+	}
+
+	if err := tx.Commit(); err != nil {
+		log.Println("PatchEmployee: unable to commit transaction:", err)
+		return patched, err
+	}
+
+	return patched, nil
+}
+
+// DeleteEmployeeById deletes an employee from the database by their ID, it returns ErrEmployeeNotFound if none has it.
+func (sh *SQLiteHelper) DeleteEmployeeById(id int) error {
+	// Set a timeout for the database operation
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	tx, err := sh.sqliteClient.BeginTx(ctx, nil)
+	if err != nil {
+		log.Println("DeleteEmployeeById: unable to begin transaction:", err)
+		return err
+	}
+	defer func() { _ = tx.Rollback() }()
+
+	// Execute the SQL query to delete the employee by ID
+	result, err := tx.ExecContext(ctx, `DELETE FROM employees WHERE id = ?`, id)
+	if err != nil {
+		log.Println("DeleteEmployeeById: error deleting employee from database:", err)
+		return err
+	}
+
+	// Deleting an employee that does not exist changes nothing
+	if deleted, _ := result.RowsAffected(); deleted == 0 {
+		return &providers.EmployeeNotFoundError{ID: id}
+	}
+	if err := recordDeletion(ctx, tx, id); err != nil {
+		log.Println("DeleteEmployeeById:", err)
+		return err
+	}
+
+	if err := tx.Commit(); err != nil {
+		log.Println("DeleteEmployeeById: unable to commit transaction:", err)
+		return err
+	}
+
+	// Employee successfully deleted
+	return nil
+}
+
+// GetAllEmployees retrieves all employees from the database with pagination.
+func (sh *SQLiteHelper) GetAllEmployees(page string, limit string) ([]models.Employee, error) {
+	// Initialize a slice of Employee structs to store the results
+	var employees []models.Employee
+
+	// Set a timeout for the database operation
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	// Parse page and limit parameters to integers
+	pageNumber, err := strconv.Atoi(page)
+	if err != nil {
+		return nil, fmt.Errorf("invalid page number: %s", page)
+	}
+	limitNumber, err := strconv.Atoi(limit)
+	if err != nil {
+		return nil, fmt.Errorf("invalid limit: %s", limit)
+	}
+
+	// Calculate the offset based on the page number and limit
+	offset := (pageNumber - 1) * limitNumber
+
+	// Define the SQL query to select employees with pagination
+	query := `
+        SELECT ` + employeeColumns + `
+        FROM employees
+        ORDER BY id
+        LIMIT ? OFFSET ?
+    `
+
+	rows, err := sh.sqliteClient.QueryContext(ctx, query, limitNumber, offset)
+	if err != nil {
+		log.Println("GetAllEmployees: error getting results from database:", err)
+		return nil, err
+	}
+	defer rows.Close()
+
+	// Iterate through the result rows and scan each employee into the slice
+	for rows.Next() {
+		var emp models.Employee
+		err := scanEmployee(rows, &emp)
+		if err != nil {
+			log.Println("GetAllEmployees: error scanning row:", err)
+			return nil, err
+		}
+		employees = append(employees, emp)
+	}
+
+	// Check for any errors encountered during iteration
+	if err := rows.Err(); err != nil {
+		log.Println("GetAllEmployees: error iterating over rows:", err)
+		return nil, err
+	}
+
+	// Return the slice of employees and nil error
+	return employees, nil
+}
+
+// GetEmployeesByIds retrieves the employees with the given IDs in one query, IDs without an employee are skipped.
+func (sh *SQLiteHelper) GetEmployeesByIds(ids []int) ([]models.Employee, error) {
+	var employees []models.Employee
+	if len(ids) == 0 {
+		return employees, nil
+	}
+
+	// Set a timeout for the database operation
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	// SQLite has no arrays, every ID gets a placeholder
+	args := make([]interface{}, len(ids))
+	for i, id := range ids {
+		args[i] = id
+	}
+	query := `
+        SELECT ` + employeeColumns + `
+        FROM employees
+        WHERE id IN (?` + strings.Repeat(", ?", len(ids)-1) + `)
+        ORDER BY id
+    `
+
+	rows, err := sh.sqliteClient.QueryContext(ctx, query, args...)
+	if err != nil {
+		log.Println("GetEmployeesByIds: error getting results from database:", err)
+		return nil, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var emp models.Employee
+		if err := scanEmployee(rows, &emp); err != nil {
+			log.Println("GetEmployeesByIds: error scanning row:", err)
+			return nil, err
+		}
+		employees = append(employees, emp)
+	}
+	if err := rows.Err(); err != nil {
+		log.Println("GetEmployeesByIds: error iterating over rows:", err)
+		return nil, err
+	}
+
+	return employees, nil
+}
+
+// SearchEmployees retrieves up to limit employees matching filter with an ID above afterID, ordered by ID.
+func (sh *SQLiteHelper) SearchEmployees(filter models.EmployeeFilter, afterID int, limit int) ([]models.Employee, error) {
+	var employees []models.Employee
+
+	// Set a timeout for the database operation
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	// Build the conditions of the filter, each set field adds one
+	args := []interface{}{afterID}
+	query := `SELECT ` + employeeColumns + ` FROM employees WHERE id > ?`
+	where := func(condition string, value interface{}) {
+		args = append(args, value)
+		query += " AND " + condition
+	}
+	if filter.NameContains != "" {
+		// LIKE ignores the case of ASCII letters in SQLite
+		where(`name LIKE ? ESCAPE '\'`, "%"+escapeLike(filter.NameContains)+"%")
+	}
+	if filter.Position != "" {
+		where("position = ?", filter.Position)
+	}
+	if filter.Status != "" {
+		where("status = ?", filter.Status)
+	}
+	if filter.EmploymentType != "" {
+		where("employment_type = ?", filter.EmploymentType)
+	}
+	if filter.Location != "" {
+		where("location = ?", filter.Location)
+	}
+	if filter.Department != "" {
+		where("department = ?", filter.Department)
+	}
+	if filter.HiredFrom != nil {
+		where("hire_date >= ?", *filter.HiredFrom)
+	}
+	if filter.HiredTo != nil {
+		where("hire_date <= ?", *filter.HiredTo)
+	}
+	args = append(args, limit)
+	query += " ORDER BY id LIMIT ?"
+
+	rows, err := sh.sqliteClient.QueryContext(ctx, query, args...)
+	if err != nil {
+		log.Println("SearchEmployees: error getting results from database:", err)
+		return nil, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var emp models.Employee
+		if err := scanEmployee(rows, &emp); err != nil {
+			log.Println("SearchEmployees: error scanning row:", err)
+			return nil, err
+		}
+		employees = append(employees, emp)
+	}
+	if err := rows.Err(); err != nil {
+		log.Println("SearchEmployees: error iterating over rows:", err)
+		return nil, err
+	}
+
+	return employees, nil
+}
+
+// escapeLike escapes the wildcards of a LIKE pattern, backslash is the escape character
+func escapeLike(s string) string {
+	return strings.NewReplacer(`\`, `\\`, `%`, `\%`, `_`, `\_`).Replace(s)
+}
@@ -0,0 +1,130 @@
+package sqliteHelperProvider
+
+import (
+	"Techiebulter/interview/backend/models"
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"log"
+	"strings"
+	"time"
+)
+
+// recordEmployeeEvent logs event and queues it in the outbox within tx, so the event is published
+// exactly when the change it reports is committed
+func recordEmployeeEvent(ctx context.Context, tx *sql.Tx, event models.EmployeeEvent) (models.EmployeeEvent, error) {
+	// deletes carry no employee, they are stored as NULL
+	var employee sql.NullString
+	if event.Employee != nil {
+		data, err := json.Marshal(event.Employee)
+		if err != nil {
+			return event, err
+		}
+		employee = sql.NullString{String: string(data), Valid: true}
+	}
+
+	insertQuery := `
+        INSERT INTO employee_events (type, employee_id, employee, occurred_at)
+        VALUES (?, ?, ?, ?)
+        RETURNING id
+    `
+	err := tx.QueryRowContext(ctx, insertQuery, event.Type, event.EmployeeID, employee, event.Time.UTC()).Scan(&event.ID)
+	if err != nil {
+		return event, fmt.Errorf("log employee event: %w", err)
+	}
+
+	now := time.Now().UTC()
+	outboxQuery := `INSERT INTO outbox (event_id, employee_id, next_attempt_at, created_at) VALUES (?, ?, ?, ?)`
+	if _, err := tx.ExecContext(ctx, outboxQuery, event.ID, event.EmployeeID, now, now); err != nil {
+		return event, fmt.Errorf("queue employee event: %w", err)
+	}
+
+	return event, nil
+}
+
+// AppendEmployeeEvent stores an event in the employee event log and the outbox.
+func (sh *SQLiteHelper) AppendEmployeeEvent(event models.EmployeeEvent) (models.EmployeeEvent, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	tx, err := sh.sqliteClient.BeginTx(ctx, nil)
+	if err != nil {
+		log.Println("AppendEmployeeEvent: unable to begin transaction:", err)
+		return event, err
+	}
+	defer func() { _ = tx.Rollback() }()
+
+	event, err = recordEmployeeEvent(ctx, tx, event)
+	if err != nil {
+		log.Println("AppendEmployeeEvent: unable to insert event into database:", err)
+		return event, err
+	}
+
+	if err := tx.Commit(); err != nil {
+		log.Println("AppendEmployeeEvent: unable to commit transaction:", err)
+		return event, err
+	}
+
+	return event, nil
+}
+
+// GetLatestEmployeeEventID returns the ID of the newest logged event, 0 when the log is empty.
+func (sh *SQLiteHelper) GetLatestEmployeeEventID() (int64, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	var id int64
+	err := sh.sqliteClient.QueryRowContext(ctx, `SELECT COALESCE(MAX(id), 0) FROM employee_events`).Scan(&id)
+	if err != nil {
+		log.Println("GetLatestEmployeeEventID: error getting results from database:", err)
+		return 0, err
+	}
+
+	return id, nil
+}
+
+// GetEmployeeEvents reads the employee event log after an event ID.
+func (sh *SQLiteHelper) GetEmployeeEvents(afterID int64, types []models.EmployeeEventType, limit int) ([]models.EmployeeEvent, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	query := `SELECT id, type, employee_id, employee, occurred_at FROM employee_events WHERE id > ?`
+	args := []interface{}{afterID}
+	if len(types) > 0 {
+		query += ` AND type IN (?` + strings.Repeat(`, ?`, len(types)-1) + `)`
+		for _, eventType := range types {
+			args = append(args, eventType)
+		}
+	}
+	query += ` ORDER BY id LIMIT ?`
+	args = append(args, limit)
+
+	rows, err := sh.sqliteClient.QueryContext(ctx, query, args...)
+	if err != nil {
+		log.Println("GetEmployeeEvents: error getting results from database:", err)
+		return nil, err
+	}
+	defer rows.Close()
+
+	events := []models.EmployeeEvent{}
+	for rows.Next() {
+		var (
+			event    models.EmployeeEvent
+			employee sql.NullString
+		)
+		if err := rows.Scan(&event.ID, &event.Type, &event.EmployeeID, &employee, &event.Time); err != nil {
+			log.Println("GetEmployeeEvents: error scanning row:", err)
+			return nil, err
+		}
+		if employee.Valid {
+			event.Employee = &models.Employee{}
+			if err := json.Unmarshal([]byte(employee.String), event.Employee); err != nil {
+				return nil, err
+			}
+		}
+		events = append(events, event)
+	}
+
+	return events, rows.Err()
+}
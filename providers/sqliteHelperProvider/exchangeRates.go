@@ -0,0 +1,81 @@
+package sqliteHelperProvider
+
+import (
+	"Techiebulter/interview/backend/models"
+	"context"
+	"log"
+	"time"
+)
+
+// exchangeRateColumns is selected by every query returning exchange rates, in the order scanExchangeRate expects
+const exchangeRateColumns = `currency, rate, effective_date, updated_at`
+
+func scanExchangeRate(row interface {
+	Scan(dest ...interface{}) error
+}, rate *models.ExchangeRate) error {
+	return row.Scan(&rate.Currency, &rate.Rate, &rate.EffectiveDate, &rate.UpdatedAt)
+}
+
+// SaveExchangeRates upserts exchange rates keyed by currency and effective date.
+func (sh *SQLiteHelper) SaveExchangeRates(rates []models.ExchangeRate) ([]models.ExchangeRate, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	tx, err := sh.sqliteClient.BeginTx(ctx, nil)
+	if err != nil {
+		log.Println("SaveExchangeRates: unable to begin transaction:", err)
+		return nil, err
+	}
+	defer func() { _ = tx.Rollback() }()
+
+	query := `
+        INSERT INTO exchange_rates (currency, rate, effective_date, updated_at)
+        VALUES (?, ?, ?, ?)
+        ON CONFLICT (currency, effective_date) DO UPDATE SET rate = excluded.rate, updated_at = excluded.updated_at
+        RETURNING ` + exchangeRateColumns
+	saved := make([]models.ExchangeRate, len(rates))
+	for i, rate := range rates {
+		err := scanExchangeRate(tx.QueryRowContext(ctx, query, rate.Currency, rate.Rate, rate.EffectiveDate, time.Now().UTC()), &saved[i])
+		if err != nil {
+			log.Println("SaveExchangeRates: unable to upsert exchange rate into database:", err)
+			return nil, err
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		log.Println("SaveExchangeRates: unable to commit transaction:", err)
+		return nil, err
+	}
+	return saved, nil
+}
+
+// GetExchangeRates lists exchange rates, optionally of one currency and up to a day.
+func (sh *SQLiteHelper) GetExchangeRates(filter models.ExchangeRateFilter) ([]models.ExchangeRate, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	query := `
+        SELECT ` + exchangeRateColumns + `
+        FROM exchange_rates
+        WHERE (? = '' OR currency = ?) AND (? IS NULL OR effective_date <= ?)
+        ORDER BY currency, effective_date
+    `
+	rows, err := sh.sqliteClient.QueryContext(ctx, query, filter.Currency, filter.Currency, filter.Until, filter.Until)
+	if err != nil {
+		log.Println("GetExchangeRates: error getting results from database:", err)
+		return nil, err
+	}
+	defer rows.Close()
+
+	rates := []models.ExchangeRate{}
+	for rows.Next() {
+		var rate models.ExchangeRate
+		if err := scanExchangeRate(rows, &rate); err != nil {
+			log.Println("GetExchangeRates: error scanning row:", err)
+			return nil, err
+		}
+		rates = append(rates, rate)
+	}
+
+	return rates, rows.Err()
+}
@@ -0,0 +1,101 @@
+package sqliteHelperProvider
+
+import (
+	"Techiebulter/interview/backend/models"
+	"context"
+	"database/sql"
+	"errors"
+	"log"
+	"time"
+)
+
+// idempotencyColumns is selected by every query returning idempotency records, in the order scanIdempotencyRecord expects
+const idempotencyColumns = `scope, key, fingerprint, status_code, content_type, body, created_at, expires_at`
+
+func scanIdempotencyRecord(row *sql.Row, record *models.IdempotencyRecord) error {
+	return row.Scan(&record.Scope, &record.Key, &record.Fingerprint, &record.StatusCode, &record.ContentType, &record.Body,
+		&record.CreatedAt, &record.ExpiresAt)
+}
+
+// ClaimIdempotencyKey stores record unless an unexpired record holds the same scope and key.
+func (sh *SQLiteHelper) ClaimIdempotencyKey(record models.IdempotencyRecord) (models.IdempotencyRecord, bool, error) {
+	var stored models.IdempotencyRecord
+
+	// Set a timeout for the database operation
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	// Times are stored in UTC, so they compare like the text they are stored as
+	now := time.Now().UTC()
+	record.CreatedAt, record.ExpiresAt = now, record.ExpiresAt.UTC()
+
+	// Expired keys are removed on the way, which keeps the table small
+	if _, err := sh.sqliteClient.ExecContext(ctx, `DELETE FROM idempotency_keys WHERE expires_at <= ?`, now); err != nil {
+		log.Println("ClaimIdempotencyKey: error deleting expired keys from database:", err)
+		return stored, false, err
+	}
+
+	insertQuery := `
+        INSERT INTO idempotency_keys (scope, key, fingerprint, created_at, expires_at)
+        VALUES (?, ?, ?, ?, ?)
+        ON CONFLICT (scope, key) DO NOTHING
+    `
+	selectQuery := `SELECT ` + idempotencyColumns + ` FROM idempotency_keys WHERE scope = ? AND key = ?`
+
+	// The stored record can be released between the insert and the select, the insert is retried then
+	for attempt := 0; attempt < 3; attempt++ {
+		result, err := sh.sqliteClient.ExecContext(ctx, insertQuery, record.Scope, record.Key, record.Fingerprint, record.CreatedAt, record.ExpiresAt)
+		if err != nil {
+			log.Println("ClaimIdempotencyKey: unable to insert key into database:", err)
+			return stored, false, err
+		}
+		if inserted, _ := result.RowsAffected(); inserted == 1 {
+			return record, true, nil
+		}
+
+		err = scanIdempotencyRecord(sh.sqliteClient.QueryRowContext(ctx, selectQuery, record.Scope, record.Key), &stored)
+		if errors.Is(err, sql.ErrNoRows) {
+			continue
+		}
+		if err != nil {
+			log.Println("ClaimIdempotencyKey: error retrieving key from database:", err)
+		}
+		return stored, false, err
+	}
+
+	return stored, false, errors.New("idempotency key is claimed and released concurrently")
+}
+
+// CompleteIdempotencyKey stores the response of a claimed key, kept until record.ExpiresAt.
+func (sh *SQLiteHelper) CompleteIdempotencyKey(record models.IdempotencyRecord) error {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	query := `
+        UPDATE idempotency_keys
+        SET status_code = ?, content_type = ?, body = ?, expires_at = ?
+        WHERE scope = ? AND key = ?
+    `
+	_, err := sh.sqliteClient.ExecContext(ctx, query, record.StatusCode, record.ContentType, record.Body, record.ExpiresAt.UTC(),
+		record.Scope, record.Key)
+	if err != nil {
+		log.Println("CompleteIdempotencyKey: error updating key in database:", err)
+		return err
+	}
+
+	return nil
+}
+
+// ReleaseIdempotencyKey forgets a claimed key.
+func (sh *SQLiteHelper) ReleaseIdempotencyKey(scope string, key string) error {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	_, err := sh.sqliteClient.ExecContext(ctx, `DELETE FROM idempotency_keys WHERE scope = ? AND key = ?`, scope, key)
+	if err != nil {
+		log.Println("ReleaseIdempotencyKey: error deleting key from database:", err)
+		return err
+	}
+
+	return nil
+}
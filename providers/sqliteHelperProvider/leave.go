@@ -0,0 +1,311 @@
+package sqliteHelperProvider
+
+import (
+	"Techiebulter/interview/backend/models"
+	"Techiebulter/interview/backend/providers"
+	"context"
+	"database/sql"
+	"errors"
+	"log"
+	"time"
+
+	"modernc.org/sqlite"
+	sqlite3 "modernc.org/sqlite/lib"
+)
+
+// leaveRequestColumns is selected by every query returning leave requests, in the order scanLeaveRequest expects
+const leaveRequestColumns = `id, employee_id, leave_type_id, start_date, end_date, days, reason, status, decided_by,
+    decision_note, decided_at, created_at`
+
+func scanLeaveRequest(row interface {
+	Scan(dest ...interface{}) error
+}, request *models.LeaveRequest) error {
+	return row.Scan(&request.ID, &request.EmployeeID, &request.LeaveTypeID, &request.StartDate, &request.EndDate, &request.Days,
+		&request.Reason, &request.Status, &request.DecidedBy, &request.DecisionNote, &request.DecidedAt, &request.CreatedAt)
+}
+
+// CreateLeaveType stores a leave type.
+func (sh *SQLiteHelper) CreateLeaveType(leaveType models.LeaveType) (models.LeaveType, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	query := `
+        INSERT INTO leave_types (name, accrual, days_per_period, created_at)
+        VALUES (?, ?, ?, ?)
+        RETURNING id, created_at
+    `
+	err := sh.sqliteClient.QueryRowContext(ctx, query, leaveType.Name, leaveType.Accrual, leaveType.DaysPerPeriod, time.Now().UTC()).
+		Scan(&leaveType.ID, &leaveType.CreatedAt)
+	if err != nil {
+		var sqliteErr *sqlite.Error
+		if errors.As(err, &sqliteErr) && sqliteErr.Code() == sqlite3.SQLITE_CONSTRAINT_UNIQUE {
+			return leaveType, providers.ErrLeaveTypeExists
+		}
+		log.Println("CreateLeaveType: unable to insert leave type into database:", err)
+		return leaveType, err
+	}
+
+	return leaveType, nil
+}
+
+// GetLeaveTypes lists the leave types ordered by ID.
+func (sh *SQLiteHelper) GetLeaveTypes() ([]models.LeaveType, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	rows, err := sh.sqliteClient.QueryContext(ctx, `SELECT id, name, accrual, days_per_period, created_at FROM leave_types ORDER BY id`)
+	if err != nil {
+		log.Println("GetLeaveTypes: error getting results from database:", err)
+		return nil, err
+	}
+	defer rows.Close()
+
+	leaveTypes := []models.LeaveType{}
+	for rows.Next() {
+		var leaveType models.LeaveType
+		if err := rows.Scan(&leaveType.ID, &leaveType.Name, &leaveType.Accrual, &leaveType.DaysPerPeriod, &leaveType.CreatedAt); err != nil {
+			log.Println("GetLeaveTypes: error scanning row:", err)
+			return nil, err
+		}
+		leaveTypes = append(leaveTypes, leaveType)
+	}
+
+	return leaveTypes, rows.Err()
+}
+
+// leaveOf returns the service start of an employee with its leave requests. The single connection is
+// held by the transaction, so the requests are checked and changed one at a time.
+func leaveOf(ctx context.Context, tx *sql.Tx, employeeID int) (models.Date, []models.LeaveRequest, error) {
+	var employee models.Employee
+	err := tx.QueryRowContext(ctx, `SELECT hire_date, created_at FROM employees WHERE id = ?`, employeeID).
+		Scan(&employee.HireDate, &employee.CreatedAt)
+	if errors.Is(err, sql.ErrNoRows) {
+		return models.Date{}, nil, providers.ErrEmployeeNotFound
+	}
+	if err != nil {
+		return models.Date{}, nil, err
+	}
+
+	rows, err := tx.QueryContext(ctx, `SELECT `+leaveRequestColumns+` FROM leave_requests WHERE employee_id = ?`, employeeID)
+	if err != nil {
+		return models.Date{}, nil, err
+	}
+	defer rows.Close()
+	var requests []models.LeaveRequest
+	for rows.Next() {
+		var request models.LeaveRequest
+		if err := scanLeaveRequest(rows, &request); err != nil {
+			return models.Date{}, nil, err
+		}
+		requests = append(requests, request)
+	}
+	return employee.ServiceStart(), requests, rows.Err()
+}
+
+// getLeaveType reads one leave type, ErrLeaveTypeNotFound if there is none
+func getLeaveType(ctx context.Context, tx *sql.Tx, id int) (models.LeaveType, error) {
+	var leaveType models.LeaveType
+	err := tx.QueryRowContext(ctx, `SELECT id, name, accrual, days_per_period, created_at FROM leave_types WHERE id = ?`, id).
+		Scan(&leaveType.ID, &leaveType.Name, &leaveType.Accrual, &leaveType.DaysPerPeriod, &leaveType.CreatedAt)
+	if errors.Is(err, sql.ErrNoRows) {
+		return leaveType, providers.ErrLeaveTypeNotFound
+	}
+	return leaveType, err
+}
+
+// CreateLeaveRequest stores a pending leave request after checking it against the employee's other
+// requests and balance in one transaction.
+func (sh *SQLiteHelper) CreateLeaveRequest(request models.LeaveRequest) (models.LeaveRequest, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	tx, err := sh.sqliteClient.BeginTx(ctx, nil)
+	if err != nil {
+		log.Println("CreateLeaveRequest: unable to begin transaction:", err)
+		return request, err
+	}
+	defer func() { _ = tx.Rollback() }()
+
+	serviceStart, requests, err := leaveOf(ctx, tx, request.EmployeeID)
+	if err != nil {
+		return request, err
+	}
+	leaveType, err := getLeaveType(ctx, tx, request.LeaveTypeID)
+	if err != nil {
+		return request, err
+	}
+	for _, other := range requests {
+		if other.Holds() && other.Overlaps(request) {
+			return request, providers.ErrLeaveOverlap
+		}
+	}
+	// pending requests are promised already, approving them all must stay possible
+	balance := models.NewLeaveBalance(leaveType, serviceStart, request.StartDate, requests)
+	if balance.Limited() && request.Days > balance.Available-balance.Pending {
+		return request, providers.ErrInsufficientLeave
+	}
+
+	query := `
+        INSERT INTO leave_requests (employee_id, leave_type_id, start_date, end_date, days, reason, status, created_at)
+        VALUES (?, ?, ?, ?, ?, ?, ?, ?)
+        RETURNING ` + leaveRequestColumns
+	err = scanLeaveRequest(tx.QueryRowContext(ctx, query, request.EmployeeID, request.LeaveTypeID, request.StartDate,
+		request.EndDate, request.Days, request.Reason, models.LeavePending, time.Now().UTC()), &request)
+	if err != nil {
+		log.Println("CreateLeaveRequest: unable to insert leave request into database:", err)
+		return request, err
+	}
+
+	if err := tx.Commit(); err != nil {
+		log.Println("CreateLeaveRequest: unable to commit transaction:", err)
+		return request, err
+	}
+
+	return request, nil
+}
+
+// GetLeaveRequest reads one leave request.
+func (sh *SQLiteHelper) GetLeaveRequest(id int) (models.LeaveRequest, error) {
+	var request models.LeaveRequest
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	err := scanLeaveRequest(sh.sqliteClient.QueryRowContext(ctx, `SELECT `+leaveRequestColumns+` FROM leave_requests WHERE id = ?`, id), &request)
+	if errors.Is(err, sql.ErrNoRows) {
+		return request, providers.ErrLeaveRequestNotFound
+	}
+	if err != nil {
+		log.Println("GetLeaveRequest: error getting result from database:", err)
+		return request, err
+	}
+
+	return request, nil
+}
+
+// GetLeaveRequests lists the leave requests of an employee by start date, only those with the given status unless it is empty.
+func (sh *SQLiteHelper) GetLeaveRequests(employeeID int, status models.LeaveRequestStatus) ([]models.LeaveRequest, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	query := `
+        SELECT ` + leaveRequestColumns + `
+        FROM leave_requests
+        WHERE employee_id = ? AND (? = '' OR status = ?)
+        ORDER BY start_date, id
+    `
+	rows, err := sh.sqliteClient.QueryContext(ctx, query, employeeID, status, status)
+	if err != nil {
+		log.Println("GetLeaveRequests: error getting results from database:", err)
+		return nil, err
+	}
+	defer rows.Close()
+
+	requests := []models.LeaveRequest{}
+	for rows.Next() {
+		var request models.LeaveRequest
+		if err := scanLeaveRequest(rows, &request); err != nil {
+			log.Println("GetLeaveRequests: error scanning row:", err)
+			return nil, err
+		}
+		requests = append(requests, request)
+	}
+
+	return requests, rows.Err()
+}
+
+// GetEmployeesOnLeave lists the IDs of the employees an approved leave request covers on day.
+func (sh *SQLiteHelper) GetEmployeesOnLeave(day models.Date) ([]int, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	query := `
+        SELECT DISTINCT employee_id
+        FROM leave_requests
+        WHERE status = ? AND start_date <= ? AND end_date >= ?
+        ORDER BY employee_id
+    `
+	rows, err := sh.sqliteClient.QueryContext(ctx, query, models.LeaveApproved, day, day)
+	if err != nil {
+		log.Println("GetEmployeesOnLeave: error getting results from database:", err)
+		return nil, err
+	}
+	defer rows.Close()
+
+	ids := []int{}
+	for rows.Next() {
+		var id int
+		if err := rows.Scan(&id); err != nil {
+			log.Println("GetEmployeesOnLeave: error scanning row:", err)
+			return nil, err
+		}
+		ids = append(ids, id)
+	}
+
+	return ids, rows.Err()
+}
+
+// DecideLeaveRequest moves a leave request to status in one transaction. Approvals are checked
+// against the balance once more, it may have been used up since the request was made.
+func (sh *SQLiteHelper) DecideLeaveRequest(id int, status models.LeaveRequestStatus, decidedBy string, note string) (models.LeaveRequest, error) {
+	var request models.LeaveRequest
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	tx, err := sh.sqliteClient.BeginTx(ctx, nil)
+	if err != nil {
+		log.Println("DecideLeaveRequest: unable to begin transaction:", err)
+		return request, err
+	}
+	defer func() { _ = tx.Rollback() }()
+
+	var employeeID int
+	err = tx.QueryRowContext(ctx, `SELECT employee_id FROM leave_requests WHERE id = ?`, id).Scan(&employeeID)
+	if errors.Is(err, sql.ErrNoRows) {
+		return request, providers.ErrLeaveRequestNotFound
+	}
+	if err != nil {
+		log.Println("DecideLeaveRequest: error getting result from database:", err)
+		return request, err
+	}
+	serviceStart, requests, err := leaveOf(ctx, tx, employeeID)
+	if err != nil {
+		return request, err
+	}
+	for _, other := range requests {
+		if other.ID == id {
+			request = other
+		}
+	}
+	if !request.CanBecome(status, models.NewDate(time.Now())) {
+		return request, providers.ErrLeaveRequestDecided
+	}
+	if status == models.LeaveApproved {
+		leaveType, err := getLeaveType(ctx, tx, request.LeaveTypeID)
+		if err != nil {
+			return request, err
+		}
+		balance := models.NewLeaveBalance(leaveType, serviceStart, request.StartDate, requests)
+		if balance.Limited() && request.Days > balance.Available {
+			return request, providers.ErrInsufficientLeave
+		}
+	}
+
+	query := `
+        UPDATE leave_requests
+        SET status = ?, decided_by = ?, decision_note = ?, decided_at = ?
+        WHERE id = ?
+        RETURNING ` + leaveRequestColumns
+	if err := scanLeaveRequest(tx.QueryRowContext(ctx, query, status, decidedBy, note, time.Now().UTC(), id), &request); err != nil {
+		log.Println("DecideLeaveRequest: unable to update leave request in database:", err)
+		return request, err
+	}
+
+	if err := tx.Commit(); err != nil {
+		log.Println("DecideLeaveRequest: unable to commit transaction:", err)
+		return request, err
+	}
+
+	return request, nil
+}
@@ -0,0 +1,204 @@
+package sqliteHelperProvider
+
+import (
+	"Techiebulter/interview/backend/models"
+	"Techiebulter/interview/backend/providers"
+	"context"
+	"database/sql"
+	"encoding/json"
+	"log"
+	"time"
+)
+
+// redirectOf returns the employee a merged ID redirects to, sql.ErrNoRows if the ID was never merged
+func redirectOf(ctx context.Context, db interface {
+	QueryRowContext(ctx context.Context, query string, args ...interface{}) *sql.Row
+}, id int) (int, error) {
+	query := `
+        SELECT r.target_id
+        FROM employee_redirects r
+        JOIN employees e ON e.id = r.target_id
+        WHERE r.id = ?
+    `
+	var target int
+	err := db.QueryRowContext(ctx, query, id).Scan(&target)
+	return target, err
+}
+
+// MergeEmployees merges the duplicate into the survivor, keeping snapshots of both in employee_merges.
+func (sh *SQLiteHelper) MergeEmployees(request models.MergeRequest, mergedBy string) (models.Employee, error) {
+	var merged models.Employee
+
+	// Set a timeout for the database operation
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	tx, err := sh.sqliteClient.BeginTx(ctx, nil)
+	if err != nil {
+		log.Println("MergeEmployees: unable to begin transaction:", err)
+		return merged, err
+	}
+	defer func() { _ = tx.Rollback() }()
+
+	query := `SELECT ` + employeeColumns + ` FROM employees WHERE id IN (?, ?) ORDER BY id`
+	// The single connection is held by the transaction, so nobody changes the employees in between
+	rows, err := tx.QueryContext(ctx, query, request.SurvivorID, request.DuplicateID)
+	if err != nil {
+		log.Println("MergeEmployees: error retrieving employees from database:", err)
+		return merged, err
+	}
+	found := map[int]models.Employee{}
+	for rows.Next() {
+		var emp models.Employee
+		if err := scanEmployee(rows, &emp); err != nil {
+			rows.Close()
+			log.Println("MergeEmployees: error scanning row:", err)
+			return merged, err
+		}
+		found[emp.ID] = emp
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return merged, err
+	}
+	for _, id := range []int{request.SurvivorID, request.DuplicateID} {
+		if _, ok := found[id]; ok {
+			continue
+		}
+		if target, err := redirectOf(ctx, tx, id); err == nil {
+			return merged, &providers.EmployeeMergedError{ID: id, MergedInto: target}
+		}
+		return merged, providers.ErrEmployeeNotFound
+	}
+	survivor, duplicate := found[request.SurvivorID], found[request.DuplicateID]
+
+	employee, err := request.Merge(survivor, duplicate)
+	if err != nil {
+		return merged, err
+	}
+	if errs := employee.Validate(models.EmployeePatchRules); len(errs) > 0 {
+		return merged, errs
+	}
+
+	survivorBefore, err := json.Marshal(survivor)
+	if err != nil {
+		return merged, err
+	}
+	mergedEmployee, err := json.Marshal(duplicate)
+	if err != nil {
+		return merged, err
+	}
+	insertQuery := `
+        INSERT INTO employee_merges (survivor_id, merged_id, survivor_before, merged_employee, taken_fields, merged_by, merged_at)
+        VALUES (?, ?, ?, ?, ?, ?, ?)
+    `
+	_, err = tx.ExecContext(ctx, insertQuery, survivor.ID, duplicate.ID, string(survivorBefore), string(mergedEmployee),
+		models.StringList(request.TakeFromDuplicate), mergedBy, time.Now().UTC())
+	if err != nil {
+		log.Println("MergeEmployees: error recording merge in database:", err)
+		return merged, err
+	}
+
+	// IDs merged into the duplicate earlier now lead to the survivor as well
+	if _, err := tx.ExecContext(ctx, `UPDATE employee_redirects SET target_id = ? WHERE target_id = ?`, survivor.ID, duplicate.ID); err != nil {
+		log.Println("MergeEmployees: error moving redirects in database:", err)
+		return merged, err
+	}
+	// the leave requests of the duplicate move to the survivor instead of going with the duplicate
+	if _, err := tx.ExecContext(ctx, `UPDATE leave_requests SET employee_id = ? WHERE employee_id = ?`, survivor.ID, duplicate.ID); err != nil {
+		log.Println("MergeEmployees: error moving leave requests in database:", err)
+		return merged, err
+	}
+	// so do the hours it worked, except an open attendance entry or a timesheet of a week the survivor
+	// has one for, which go with the duplicate
+	if _, err := tx.ExecContext(ctx, `UPDATE attendance SET employee_id = ? WHERE employee_id = ? AND clock_out IS NOT NULL`, survivor.ID, duplicate.ID); err != nil {
+		log.Println("MergeEmployees: error moving attendance in database:", err)
+		return merged, err
+	}
+	if _, err := tx.ExecContext(ctx, `
+        UPDATE timesheets SET employee_id = ?
+        WHERE employee_id = ? AND (status = 'rejected' OR week_start NOT IN (
+            SELECT week_start FROM timesheets WHERE employee_id = ? AND status <> 'rejected'))`, survivor.ID, duplicate.ID, survivor.ID); err != nil {
+		log.Println("MergeEmployees: error moving timesheets in database:", err)
+		return merged, err
+	}
+	// the reports of the duplicate are managed by the survivor from now on
+	if _, err := tx.ExecContext(ctx, `UPDATE employees SET manager_id = ? WHERE manager_id = ? AND id <> ?`, survivor.ID, duplicate.ID, survivor.ID); err != nil {
+		log.Println("MergeEmployees: error moving reports in database:", err)
+		return merged, err
+	}
+	// The duplicate goes first, so the survivor can take over its email address
+	if _, err := tx.ExecContext(ctx, `DELETE FROM employees WHERE id = ?`, duplicate.ID); err != nil {
+		log.Println("MergeEmployees: error deleting duplicate from database:", err)
+		return merged, err
+	}
+	if _, err := tx.ExecContext(ctx, `INSERT INTO employee_redirects (id, target_id) VALUES (?, ?)`, duplicate.ID, survivor.ID); err != nil {
+		log.Println("MergeEmployees: error adding redirect to database:", err)
+		return merged, err
+	}
+
+	merged, err = replaceEmployee(ctx, tx, survivor.ID, employee)
+	if err != nil {
+		log.Println("MergeEmployees: error updating survivor in database:", err)
+		return merged, err
+	}
+
+	// watchers see the duplicate go before the survivor changes
+	if err := recordDeletion(ctx, tx, duplicate.ID); err != nil {
+		log.Println("MergeEmployees:", err)
+		return merged, err
+	}
+	if err := recordChange(ctx, tx, models.EmployeeUpdated, merged); err != nil {
+		log.Println("MergeEmployees:", err)
+		return merged, err
+	}
+
+	if err := tx.Commit(); err != nil {
+		log.Println("MergeEmployees: unable to commit transaction:", err)
+		return merged, err
+	}
+
+	return merged, nil
+}
+
+// GetEmployeeMerges lists the merges into an employee, oldest first.
+func (sh *SQLiteHelper) GetEmployeeMerges(survivorID int) ([]models.EmployeeMerge, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	query := `
+        SELECT id, survivor_id, merged_id, survivor_before, merged_employee, taken_fields, merged_by, merged_at
+        FROM employee_merges
+        WHERE survivor_id = ?
+        ORDER BY merged_at, id
+    `
+	rows, err := sh.sqliteClient.QueryContext(ctx, query, survivorID)
+	if err != nil {
+		log.Println("GetEmployeeMerges: error getting results from database:", err)
+		return nil, err
+	}
+	defer rows.Close()
+
+	merges := []models.EmployeeMerge{}
+	for rows.Next() {
+		var (
+			merge                          models.EmployeeMerge
+			survivorBefore, mergedEmployee []byte
+		)
+		err := rows.Scan(&merge.ID, &merge.SurvivorID, &merge.MergedID, &survivorBefore, &mergedEmployee,
+			&merge.TakenFromDuplicate, &merge.MergedBy, &merge.MergedAt)
+		if err != nil {
+			log.Println("GetEmployeeMerges: error scanning row:", err)
+			return nil, err
+		}
+		if err := json.Unmarshal(survivorBefore, &merge.SurvivorBefore); err != nil {
+			return nil, err
+		}
+		if err := json.Unmarshal(mergedEmployee, &merge.MergedEmployee); err != nil {
+			return nil, err
+		}
+		merges = append(merges, merge)
+	}
+
+	return merges, rows.Err()
+}
@@ -0,0 +1,464 @@
+package sqliteHelperProvider
+
+import (
+	"Techiebulter/interview/backend/models"
+	"context"
+	"database/sql"
+	"fmt"
+	"log"
+	"time"
+)
+
+type migration struct {
+	version int
+	name    string
+	query   string
+}
+
+// migrations are applied in order and never edited once released, add a new one instead.
+// Checks mirror the PostgreSQL column types, which SQLite does not enforce by itself.
+var migrations = []migration{
+	{
+		version: 1,
+		name:    "create employees",
+		query: `
+            CREATE TABLE employees (
+                id INTEGER PRIMARY KEY AUTOINCREMENT,
+                name VARCHAR(255) NOT NULL CHECK (length(name) <= 255),
+                position VARCHAR(255) NOT NULL CHECK (length(position) <= 255),
+                salary NUMERIC(10, 2) NOT NULL CHECK (abs(salary) < 100000000)
+            );
+        `,
+	},
+	{
+		// SQLite cannot add UNIQUE columns or columns defaulting to CURRENT_TIMESTAMP,
+		// so uniqueness is an index and existing rows get their timestamps set afterwards
+		version: 2,
+		name:    "extend employee profile",
+		query: `
+            ALTER TABLE employees ADD COLUMN email VARCHAR(255) CHECK (length(email) <= 255);
+            CREATE UNIQUE INDEX employees_email_key ON employees (email);
+            ALTER TABLE employees ADD COLUMN phone VARCHAR(32) NOT NULL DEFAULT '' CHECK (length(phone) <= 32);
+            ALTER TABLE employees ADD COLUMN hire_date DATE;
+            ALTER TABLE employees ADD COLUMN termination_date DATE CHECK (termination_date >= hire_date);
+            ALTER TABLE employees ADD COLUMN employment_type VARCHAR(16) NOT NULL DEFAULT 'full-time'
+                CHECK (employment_type IN ('full-time', 'part-time', 'contractor'));
+            ALTER TABLE employees ADD COLUMN status VARCHAR(16) NOT NULL DEFAULT 'active'
+                CHECK (status IN ('active', 'on-leave', 'terminated'));
+            ALTER TABLE employees ADD COLUMN location VARCHAR(255) NOT NULL DEFAULT '' CHECK (length(location) <= 255);
+            ALTER TABLE employees ADD COLUMN custom_fields TEXT NOT NULL DEFAULT '{}';
+            ALTER TABLE employees ADD COLUMN created_at TIMESTAMP NOT NULL DEFAULT '1970-01-01 00:00:00';
+            ALTER TABLE employees ADD COLUMN updated_at TIMESTAMP NOT NULL DEFAULT '1970-01-01 00:00:00';
+            UPDATE employees SET created_at = CURRENT_TIMESTAMP, updated_at = CURRENT_TIMESTAMP;
+
+            CREATE TABLE custom_field_definitions (
+                name VARCHAR(63) PRIMARY KEY,
+                type VARCHAR(16) NOT NULL,
+                required BOOLEAN NOT NULL DEFAULT false,
+                options TEXT NOT NULL DEFAULT '[]',
+                description TEXT NOT NULL DEFAULT '',
+                created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP
+            );
+        `,
+	},
+	{
+		version: 3,
+		name:    "create idempotency keys",
+		query: `
+            CREATE TABLE idempotency_keys (
+                scope VARCHAR(255) NOT NULL,
+                key VARCHAR(255) NOT NULL,
+                fingerprint CHAR(64) NOT NULL,
+                status_code INTEGER NOT NULL DEFAULT 0,
+                content_type VARCHAR(255) NOT NULL DEFAULT '',
+                body BLOB,
+                created_at TIMESTAMP NOT NULL,
+                expires_at TIMESTAMP NOT NULL,
+                PRIMARY KEY (scope, key)
+            );
+
+            CREATE INDEX idempotency_keys_expires_at ON idempotency_keys (expires_at);
+        `,
+	},
+	{
+		version: 4,
+		name:    "create employee merges",
+		query: `
+            CREATE TABLE employee_merges (
+                id INTEGER PRIMARY KEY AUTOINCREMENT,
+                survivor_id INTEGER NOT NULL,
+                merged_id INTEGER NOT NULL UNIQUE,
+                survivor_before TEXT NOT NULL,
+                merged_employee TEXT NOT NULL,
+                taken_fields TEXT NOT NULL DEFAULT '[]',
+                merged_by VARCHAR(255) NOT NULL DEFAULT '',
+                merged_at TIMESTAMP NOT NULL
+            );
+
+            CREATE INDEX employee_merges_survivor_id ON employee_merges (survivor_id);
+
+            CREATE TABLE employee_redirects (
+                id INTEGER PRIMARY KEY,
+                target_id INTEGER NOT NULL REFERENCES employees (id) ON DELETE CASCADE
+            );
+
+            CREATE INDEX employee_redirects_target_id ON employee_redirects (target_id);
+        `,
+	},
+	{
+		version: 5,
+		name:    "create employee events",
+		query: `
+            CREATE TABLE employee_events (
+                id INTEGER PRIMARY KEY AUTOINCREMENT,
+                type VARCHAR(16) NOT NULL,
+                employee_id INTEGER NOT NULL,
+                employee TEXT,
+                occurred_at TIMESTAMP NOT NULL
+            );
+        `,
+	},
+	{
+		version: 6,
+		name:    "create webhooks",
+		query: `
+            CREATE TABLE webhook_subscriptions (
+                id INTEGER PRIMARY KEY AUTOINCREMENT,
+                url VARCHAR(2048) NOT NULL,
+                event_types TEXT NOT NULL DEFAULT '[]',
+                secret VARCHAR(255) NOT NULL,
+                created_at TIMESTAMP NOT NULL
+            );
+
+            CREATE TABLE webhook_deliveries (
+                id INTEGER PRIMARY KEY AUTOINCREMENT,
+                subscription_id INTEGER NOT NULL REFERENCES webhook_subscriptions (id) ON DELETE CASCADE,
+                event_id INTEGER NOT NULL,
+                event_type VARCHAR(16) NOT NULL,
+                payload TEXT NOT NULL,
+                status VARCHAR(16) NOT NULL DEFAULT 'pending',
+                attempts INTEGER NOT NULL DEFAULT 0,
+                next_attempt_at TIMESTAMP,
+                last_status_code INTEGER NOT NULL DEFAULT 0,
+                last_error TEXT NOT NULL DEFAULT '',
+                delivered_at TIMESTAMP,
+                created_at TIMESTAMP NOT NULL
+            );
+
+            CREATE INDEX webhook_deliveries_due ON webhook_deliveries (next_attempt_at) WHERE status = 'pending';
+            CREATE INDEX webhook_deliveries_subscription_id ON webhook_deliveries (subscription_id, id);
+        `,
+	},
+	{
+		version: 7,
+		name:    "create outbox",
+		query: `
+            CREATE TABLE outbox (
+                id INTEGER PRIMARY KEY AUTOINCREMENT,
+                event_id INTEGER NOT NULL REFERENCES employee_events (id) ON DELETE CASCADE,
+                employee_id INTEGER NOT NULL,
+                attempts INTEGER NOT NULL DEFAULT 0,
+                next_attempt_at TIMESTAMP NOT NULL,
+                last_error TEXT NOT NULL DEFAULT '',
+                created_at TIMESTAMP NOT NULL
+            );
+
+            CREATE INDEX outbox_due ON outbox (next_attempt_at, id);
+            CREATE INDEX outbox_employee_id ON outbox (employee_id, id);
+
+            -- the relay publishes at least once, a repeated event must not be queued twice
+            CREATE UNIQUE INDEX webhook_deliveries_event ON webhook_deliveries (subscription_id, event_id);
+        `,
+	},
+	{
+		version: 8,
+		name:    "create leave",
+		query: `
+            CREATE TABLE leave_types (
+                id INTEGER PRIMARY KEY AUTOINCREMENT,
+                name TEXT NOT NULL UNIQUE,
+                accrual TEXT NOT NULL,
+                days_per_period NUMERIC(6, 2) NOT NULL DEFAULT 0,
+                created_at TIMESTAMP NOT NULL
+            );
+
+            CREATE TABLE leave_requests (
+                id INTEGER PRIMARY KEY AUTOINCREMENT,
+                employee_id INTEGER NOT NULL REFERENCES employees (id) ON DELETE CASCADE,
+                leave_type_id INTEGER NOT NULL REFERENCES leave_types (id),
+                start_date DATE NOT NULL,
+                end_date DATE NOT NULL CHECK (end_date >= start_date),
+                days NUMERIC(6, 2) NOT NULL,
+                reason TEXT NOT NULL DEFAULT '',
+                status TEXT NOT NULL DEFAULT 'pending',
+                decided_by TEXT NOT NULL DEFAULT '',
+                decision_note TEXT NOT NULL DEFAULT '',
+                decided_at TIMESTAMP,
+                created_at TIMESTAMP NOT NULL
+            );
+
+            CREATE INDEX leave_requests_employee_id ON leave_requests (employee_id, start_date);
+        `,
+	},
+	{
+		version: 9,
+		name:    "create attendance and timesheets",
+		query: `
+            ALTER TABLE employees ADD COLUMN department VARCHAR(255) NOT NULL DEFAULT '' CHECK (length(department) <= 255);
+
+            CREATE TABLE attendance (
+                id INTEGER PRIMARY KEY AUTOINCREMENT,
+                employee_id INTEGER NOT NULL REFERENCES employees (id) ON DELETE CASCADE,
+                clock_in TIMESTAMP NOT NULL,
+                clock_out TIMESTAMP CHECK (clock_out >= clock_in),
+                hours NUMERIC(6, 2) NOT NULL DEFAULT 0,
+                note TEXT NOT NULL DEFAULT ''
+            );
+
+            CREATE INDEX attendance_clock_in ON attendance (clock_in);
+            CREATE INDEX attendance_employee_id ON attendance (employee_id, clock_in);
+            -- an employee is clocked in once at a time
+            CREATE UNIQUE INDEX attendance_open ON attendance (employee_id) WHERE clock_out IS NULL;
+
+            CREATE TABLE timesheets (
+                id INTEGER PRIMARY KEY AUTOINCREMENT,
+                employee_id INTEGER NOT NULL REFERENCES employees (id) ON DELETE CASCADE,
+                week_start DATE NOT NULL,
+                entries TEXT NOT NULL DEFAULT '[]',
+                hours NUMERIC(6, 2) NOT NULL,
+                status TEXT NOT NULL DEFAULT 'submitted',
+                decided_by TEXT NOT NULL DEFAULT '',
+                decision_note TEXT NOT NULL DEFAULT '',
+                decided_at TIMESTAMP,
+                created_at TIMESTAMP NOT NULL
+            );
+
+            CREATE INDEX timesheets_week_start ON timesheets (week_start);
+            -- a rejected timesheet may be submitted again, any other one holds its week
+            CREATE UNIQUE INDEX timesheets_week ON timesheets (employee_id, week_start) WHERE status <> 'rejected';
+        `,
+	},
+	{
+		version: 10,
+		name:    "create salary history and payroll",
+		query: `
+            CREATE TABLE salary_history (
+                id INTEGER PRIMARY KEY AUTOINCREMENT,
+                employee_id INTEGER NOT NULL REFERENCES employees (id) ON DELETE CASCADE,
+                salary NUMERIC(10, 2) NOT NULL,
+                effective_from DATE NOT NULL,
+                recorded_at TIMESTAMP NOT NULL,
+                UNIQUE (employee_id, effective_from)
+            );
+
+            -- the salaries stored so far are taken to apply since the hire date
+            INSERT INTO salary_history (employee_id, salary, effective_from, recorded_at)
+            SELECT id, salary, COALESCE(hire_date, substr(created_at, 1, 10)), CURRENT_TIMESTAMP FROM employees;
+
+            CREATE TABLE payroll_runs (
+                id INTEGER PRIMARY KEY AUTOINCREMENT,
+                period VARCHAR(7) NOT NULL UNIQUE,
+                status TEXT NOT NULL DEFAULT 'draft',
+                rules TEXT NOT NULL,
+                employees INTEGER NOT NULL DEFAULT 0,
+                gross NUMERIC(14, 2) NOT NULL DEFAULT 0,
+                deductions NUMERIC(14, 2) NOT NULL DEFAULT 0,
+                net NUMERIC(14, 2) NOT NULL DEFAULT 0,
+                created_by TEXT NOT NULL DEFAULT '',
+                created_at TIMESTAMP NOT NULL,
+                approved_by TEXT NOT NULL DEFAULT '',
+                approved_at TIMESTAMP
+            );
+
+            -- payslips outlive the employees they pay, so they keep the employee ID without a reference
+            CREATE TABLE payslips (
+                id INTEGER PRIMARY KEY AUTOINCREMENT,
+                run_id INTEGER NOT NULL REFERENCES payroll_runs (id) ON DELETE CASCADE,
+                employee_id INTEGER NOT NULL,
+                name VARCHAR(255) NOT NULL,
+                position VARCHAR(255) NOT NULL,
+                department VARCHAR(255) NOT NULL DEFAULT '',
+                employment_type TEXT NOT NULL,
+                segments TEXT NOT NULL DEFAULT '[]',
+                base_pay NUMERIC(12, 2) NOT NULL,
+                lines TEXT NOT NULL DEFAULT '[]',
+                gross NUMERIC(12, 2) NOT NULL,
+                deductions NUMERIC(12, 2) NOT NULL,
+                net NUMERIC(12, 2) NOT NULL,
+                UNIQUE (run_id, employee_id)
+            );
+
+            CREATE INDEX payslips_employee_id ON payslips (employee_id);
+        `,
+	},
+	{
+		version: 11,
+		name:    "add currencies and exchange rates",
+		query: `
+            -- the amounts stored so far are in the base currency, which is only known to the helper:
+            -- currency_backfill records the rows it tags with the base currency after migrating
+            ALTER TABLE employees ADD COLUMN currency VARCHAR(3) NOT NULL DEFAULT 'USD' CHECK (length(currency) = 3);
+            ALTER TABLE salary_history ADD COLUMN currency VARCHAR(3) NOT NULL DEFAULT 'USD' CHECK (length(currency) = 3);
+            ALTER TABLE payroll_runs ADD COLUMN currency VARCHAR(3) NOT NULL DEFAULT 'USD' CHECK (length(currency) = 3);
+            ALTER TABLE payslips ADD COLUMN currency VARCHAR(3) NOT NULL DEFAULT 'USD' CHECK (length(currency) = 3);
+
+            CREATE TABLE currency_backfill (
+                table_name VARCHAR(64) PRIMARY KEY,
+                last_id INTEGER NOT NULL
+            );
+            INSERT INTO currency_backfill (table_name, last_id) SELECT 'employees', id FROM employees ORDER BY id DESC LIMIT 1;
+            INSERT INTO currency_backfill (table_name, last_id) SELECT 'salary_history', id FROM salary_history ORDER BY id DESC LIMIT 1;
+            INSERT INTO currency_backfill (table_name, last_id) SELECT 'payroll_runs', id FROM payroll_runs ORDER BY id DESC LIMIT 1;
+            INSERT INTO currency_backfill (table_name, last_id) SELECT 'payslips', id FROM payslips ORDER BY id DESC LIMIT 1;
+
+            -- a rate is what one unit of the currency is worth in the base currency from its effective date
+            CREATE TABLE exchange_rates (
+                currency VARCHAR(3) NOT NULL,
+                effective_date DATE NOT NULL,
+                rate NUMERIC(18, 8) NOT NULL CHECK (rate > 0),
+                updated_at TIMESTAMP NOT NULL,
+                PRIMARY KEY (currency, effective_date)
+            );
+        `,
+	},
+	{
+		version: 12,
+		name:    "add salary bands",
+		query: `
+            -- positions are matched to bands by position_key, the position in lower case with single spaces
+            CREATE TABLE salary_bands (
+                id INTEGER PRIMARY KEY AUTOINCREMENT,
+                position VARCHAR(255) NOT NULL,
+                position_key VARCHAR(255) NOT NULL UNIQUE,
+                currency VARCHAR(3) NOT NULL CHECK (length(currency) = 3),
+                min NUMERIC(10, 2) NOT NULL,
+                mid NUMERIC(10, 2) NOT NULL,
+                max NUMERIC(10, 2) NOT NULL,
+                enforcement VARCHAR(10) NOT NULL CHECK (enforcement IN ('warn', 'reject')),
+                created_at TIMESTAMP NOT NULL,
+                updated_at TIMESTAMP NOT NULL,
+                CHECK (min > 0 AND min <= mid AND mid <= max)
+            );
+        `,
+	},
+	{
+		version: 13,
+		name:    "add employee managers",
+		query: `
+            -- reports of a deleted employee are left without a manager
+            ALTER TABLE employees ADD COLUMN manager_id INTEGER REFERENCES employees (id) ON DELETE SET NULL;
+        `,
+	},
+}
+
+// migrate applies every migration newer than the recorded schema version, each in its own transaction
+func (sh *SQLiteHelper) migrate() error {
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+
+	createTableQuery := `
+        CREATE TABLE IF NOT EXISTS schema_migrations (
+            version INTEGER PRIMARY KEY,
+            name TEXT NOT NULL,
+            applied_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP
+        )
+    `
+	if _, err := sh.sqliteClient.ExecContext(ctx, createTableQuery); err != nil {
+		log.Println("migrate: unable to create schema_migrations table:", err)
+		return err
+	}
+
+	var current int
+	err := sh.sqliteClient.QueryRowContext(ctx, `SELECT COALESCE(MAX(version), 0) FROM schema_migrations`).Scan(&current)
+	if err != nil {
+		log.Println("migrate: unable to read schema version:", err)
+		return err
+	}
+
+	for _, m := range migrations {
+		if m.version <= current {
+			continue
+		}
+
+		tx, err := sh.sqliteClient.BeginTx(ctx, nil)
+		if err != nil {
+			return err
+		}
+		if _, err := tx.ExecContext(ctx, m.query); err != nil {
+			_ = tx.Rollback()
+			return fmt.Errorf("migration %d (%s): %w", m.version, m.name, err)
+		}
+		if _, err := tx.ExecContext(ctx, `INSERT INTO schema_migrations (version, name) VALUES (?, ?)`, m.version, m.name); err != nil {
+			_ = tx.Rollback()
+			return fmt.Errorf("migration %d (%s): %w", m.version, m.name, err)
+		}
+		if err := tx.Commit(); err != nil {
+			return fmt.Errorf("migration %d (%s): %w", m.version, m.name, err)
+		}
+		log.Printf("migrate: applied migration %d (%s)", m.version, m.name)
+	}
+
+	return sh.backfillBaseCurrency(ctx)
+}
+
+// backfillBaseCurrency tags the amounts stored before currencies existed, as recorded in
+// currency_backfill, with the base currency and forgets them
+func (sh *SQLiteHelper) backfillBaseCurrency(ctx context.Context) error {
+	tx, err := sh.sqliteClient.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer func() { _ = tx.Rollback() }()
+
+	backfilled, err := backfillCurrency(ctx, tx, sh.baseCurrency, `UPDATE %s SET currency = ? WHERE id <= ?`)
+	if err != nil {
+		return fmt.Errorf("backfill base currency: %w", err)
+	}
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("backfill base currency: %w", err)
+	}
+	if backfilled {
+		log.Printf("migrate: tagged the amounts stored before currencies with %s", sh.baseCurrency)
+	}
+	return nil
+}
+
+// backfillCurrency sets the currency of the rows recorded in currency_backfill with update, a statement
+// taking the table name for %s and the currency and the last ID as parameters. It reports whether there were any.
+func backfillCurrency(ctx context.Context, tx *sql.Tx, currency models.Currency, update string) (bool, error) {
+	rows, err := tx.QueryContext(ctx, `SELECT table_name, last_id FROM currency_backfill`)
+	if err != nil {
+		return false, err
+	}
+	type pending struct {
+		table  string
+		lastID int
+	}
+	var backfill []pending
+	for rows.Next() {
+		var p pending
+		if err := rows.Scan(&p.table, &p.lastID); err != nil {
+			_ = rows.Close()
+			return false, err
+		}
+		backfill = append(backfill, p)
+	}
+	if err := rows.Err(); err != nil {
+		_ = rows.Close()
+		return false, err
+	}
+	if err := rows.Close(); err != nil {
+		return false, err
+	}
+
+	// the table names were written by the migration, not taken from a request
+	for _, p := range backfill {
+		if _, err := tx.ExecContext(ctx, fmt.Sprintf(update, p.table), currency, p.lastID); err != nil {
+			return false, err
+		}
+	}
+	if _, err := tx.ExecContext(ctx, `DELETE FROM currency_backfill`); err != nil {
+		return false, err
+	}
+	return len(backfill) > 0, nil
+}
@@ -0,0 +1,136 @@
+package sqliteHelperProvider
+
+import (
+	"Techiebulter/interview/backend/models"
+	"context"
+	"database/sql"
+	"encoding/json"
+	"log"
+	"strings"
+	"time"
+)
+
+// ClaimOutboxMessages leases the due outbox messages that are the oldest of their employee.
+func (sh *SQLiteHelper) ClaimOutboxMessages(now time.Time, leaseUntil time.Time, limit int) ([]models.OutboxMessage, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	// The single connection is held by the transaction, so no other claim runs in between
+	tx, err := sh.sqliteClient.BeginTx(ctx, nil)
+	if err != nil {
+		log.Println("ClaimOutboxMessages: unable to begin transaction:", err)
+		return nil, err
+	}
+	defer func() { _ = tx.Rollback() }()
+
+	// a message waits while an older one of its employee is unpublished, even one leased elsewhere
+	query := `
+        SELECT o.id, o.attempts, o.last_error, o.created_at, e.id, e.type, e.employee_id, e.employee, e.occurred_at
+        FROM outbox o
+        JOIN employee_events e ON e.id = o.event_id
+        WHERE o.next_attempt_at <= ?
+            AND NOT EXISTS (SELECT 1 FROM outbox older WHERE older.employee_id = o.employee_id AND older.id < o.id)
+        ORDER BY o.id
+        LIMIT ?
+    `
+	rows, err := tx.QueryContext(ctx, query, now.UTC(), limit)
+	if err != nil {
+		log.Println("ClaimOutboxMessages: error getting results from database:", err)
+		return nil, err
+	}
+
+	messages := []models.OutboxMessage{}
+	for rows.Next() {
+		var (
+			message  models.OutboxMessage
+			employee sql.NullString
+		)
+		err := rows.Scan(&message.ID, &message.Attempts, &message.LastError, &message.CreatedAt,
+			&message.Event.ID, &message.Event.Type, &message.Event.EmployeeID, &employee, &message.Event.Time)
+		if err != nil {
+			rows.Close()
+			log.Println("ClaimOutboxMessages: error scanning row:", err)
+			return nil, err
+		}
+		if employee.Valid {
+			message.Event.Employee = &models.Employee{}
+			if err := json.Unmarshal([]byte(employee.String), message.Event.Employee); err != nil {
+				rows.Close()
+				return nil, err
+			}
+		}
+		message.NextAttemptAt = leaseUntil
+		messages = append(messages, message)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	if len(messages) == 0 {
+		return messages, nil
+	}
+
+	args := []interface{}{leaseUntil.UTC()}
+	for _, message := range messages {
+		args = append(args, message.ID)
+	}
+	leaseQuery := `UPDATE outbox SET next_attempt_at = ? WHERE id IN (?` + strings.Repeat(`, ?`, len(messages)-1) + `)`
+	if _, err := tx.ExecContext(ctx, leaseQuery, args...); err != nil {
+		log.Println("ClaimOutboxMessages: error claiming messages in database:", err)
+		return nil, err
+	}
+
+	if err := tx.Commit(); err != nil {
+		log.Println("ClaimOutboxMessages: unable to commit transaction:", err)
+		return nil, err
+	}
+
+	return messages, nil
+}
+
+// CompleteOutboxAttempt removes a published message or stores when it is attempted again.
+func (sh *SQLiteHelper) CompleteOutboxAttempt(message models.OutboxMessage, published bool) error {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	var err error
+	if published {
+		_, err = sh.sqliteClient.ExecContext(ctx, `DELETE FROM outbox WHERE id = ?`, message.ID)
+	} else {
+		query := `UPDATE outbox SET attempts = ?, next_attempt_at = ?, last_error = ? WHERE id = ?`
+		_, err = sh.sqliteClient.ExecContext(ctx, query, message.Attempts, message.NextAttemptAt.UTC(), message.LastError, message.ID)
+	}
+	if err != nil {
+		log.Println("CompleteOutboxAttempt: error updating message in database:", err)
+		return err
+	}
+
+	return nil
+}
+
+// GetOutboxBacklog counts the unpublished outbox messages.
+func (sh *SQLiteHelper) GetOutboxBacklog() (models.OutboxBacklog, error) {
+	var backlog models.OutboxBacklog
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	query := `SELECT COUNT(*), COALESCE(SUM(attempts > 0), 0) FROM outbox`
+	if err := sh.sqliteClient.QueryRowContext(ctx, query).Scan(&backlog.Pending, &backlog.Failing); err != nil {
+		log.Println("GetOutboxBacklog: error getting results from database:", err)
+		return backlog, err
+	}
+	if backlog.Pending == 0 {
+		return backlog, nil
+	}
+
+	// selected by itself, the column keeps its type and scans as a time
+	var oldest time.Time
+	if err := sh.sqliteClient.QueryRowContext(ctx, `SELECT created_at FROM outbox ORDER BY id LIMIT 1`).Scan(&oldest); err != nil {
+		log.Println("GetOutboxBacklog: error getting results from database:", err)
+		return backlog, err
+	}
+	backlog.OldestCreatedAt = &oldest
+
+	return backlog, nil
+}
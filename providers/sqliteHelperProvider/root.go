@@ -0,0 +1,35 @@
+package sqliteHelperProvider
+
+import (
+	"Techiebulter/interview/backend/models"
+	"Techiebulter/interview/backend/providers"
+	"database/sql"
+)
+
+// SQLiteHelper implements providers.DbHelperProvider on an SQLite database,
+// for installations that run without a PostgreSQL server.
+type SQLiteHelper struct {
+	sqliteClient *sql.DB
+
+	// baseCurrency tags the amounts stored before currencies existed
+	baseCurrency models.Currency
+}
+
+// NewSQLiteHelper brings the schema up to date and returns the helper
+func NewSQLiteHelper(sqliteClient providers.PgClientProvider, baseCurrency models.Currency) (providers.DbHelperProvider, error) {
+	sh := &SQLiteHelper{
+		sqliteClient: sqliteClient.Client(),
+		baseCurrency: baseCurrency,
+	}
+
+	if err := sh.migrate(); err != nil {
+		return nil, err
+	}
+
+	return sh, nil
+}
+
+// ReadFromPrimary returns the helper itself, SQLite has no replicas
+func (sh *SQLiteHelper) ReadFromPrimary() providers.DbHelperProvider {
+	return sh
+}
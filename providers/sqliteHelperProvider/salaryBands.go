@@ -0,0 +1,117 @@
+package sqliteHelperProvider
+
+import (
+	"Techiebulter/interview/backend/models"
+	"Techiebulter/interview/backend/providers"
+	"context"
+	"database/sql"
+	"errors"
+	"log"
+	"time"
+
+	"modernc.org/sqlite"
+	sqlite3 "modernc.org/sqlite/lib"
+)
+
+// salaryBandColumns is selected by every query returning salary bands, in the order scanSalaryBand expects
+const salaryBandColumns = `id, position, currency, min, mid, max, enforcement, created_at, updated_at`
+
+func scanSalaryBand(row interface {
+	Scan(dest ...interface{}) error
+}, band *models.SalaryBand) error {
+	return row.Scan(&band.ID, &band.Position, &band.Currency, &band.Min, &band.Mid, &band.Max, &band.Enforcement,
+		&band.CreatedAt, &band.UpdatedAt)
+}
+
+// CreateSalaryBand stores a salary band.
+func (sh *SQLiteHelper) CreateSalaryBand(band models.SalaryBand) (models.SalaryBand, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	query := `
+        INSERT INTO salary_bands (position, position_key, currency, min, mid, max, enforcement, created_at, updated_at)
+        VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?)
+        RETURNING ` + salaryBandColumns
+	now := time.Now().UTC()
+	err := scanSalaryBand(sh.sqliteClient.QueryRowContext(ctx, query, band.Position, models.PositionKey(band.Position), band.Currency,
+		band.Min, band.Mid, band.Max, band.Enforcement, now, now), &band)
+	if err != nil {
+		var sqliteErr *sqlite.Error
+		if errors.As(err, &sqliteErr) && sqliteErr.Code() == sqlite3.SQLITE_CONSTRAINT_UNIQUE {
+			return band, providers.ErrSalaryBandExists
+		}
+		log.Println("CreateSalaryBand: unable to insert salary band into database:", err)
+		return band, err
+	}
+
+	return band, nil
+}
+
+// GetSalaryBands lists the salary bands ordered by position.
+func (sh *SQLiteHelper) GetSalaryBands() ([]models.SalaryBand, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	rows, err := sh.sqliteClient.QueryContext(ctx, `SELECT `+salaryBandColumns+` FROM salary_bands ORDER BY position_key`)
+	if err != nil {
+		log.Println("GetSalaryBands: error getting results from database:", err)
+		return nil, err
+	}
+	defer rows.Close()
+
+	bands := []models.SalaryBand{}
+	for rows.Next() {
+		var band models.SalaryBand
+		if err := scanSalaryBand(rows, &band); err != nil {
+			log.Println("GetSalaryBands: error scanning row:", err)
+			return nil, err
+		}
+		bands = append(bands, band)
+	}
+
+	return bands, rows.Err()
+}
+
+// UpdateSalaryBand replaces every field of a salary band.
+func (sh *SQLiteHelper) UpdateSalaryBand(band models.SalaryBand) (models.SalaryBand, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	query := `
+        UPDATE salary_bands
+        SET position = ?, position_key = ?, currency = ?, min = ?, mid = ?, max = ?, enforcement = ?, updated_at = ?
+        WHERE id = ?
+        RETURNING ` + salaryBandColumns
+	err := scanSalaryBand(sh.sqliteClient.QueryRowContext(ctx, query, band.Position, models.PositionKey(band.Position), band.Currency,
+		band.Min, band.Mid, band.Max, band.Enforcement, time.Now().UTC(), band.ID), &band)
+	if errors.Is(err, sql.ErrNoRows) {
+		return band, providers.ErrSalaryBandNotFound
+	}
+	if err != nil {
+		var sqliteErr *sqlite.Error
+		if errors.As(err, &sqliteErr) && sqliteErr.Code() == sqlite3.SQLITE_CONSTRAINT_UNIQUE {
+			return band, providers.ErrSalaryBandExists
+		}
+		log.Println("UpdateSalaryBand: unable to update salary band in database:", err)
+		return band, err
+	}
+
+	return band, nil
+}
+
+// DeleteSalaryBand removes a salary band.
+func (sh *SQLiteHelper) DeleteSalaryBand(id int) error {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	result, err := sh.sqliteClient.ExecContext(ctx, `DELETE FROM salary_bands WHERE id = ?`, id)
+	if err != nil {
+		log.Println("DeleteSalaryBand: error deleting salary band from database:", err)
+		return err
+	}
+	if deleted, _ := result.RowsAffected(); deleted == 0 {
+		return providers.ErrSalaryBandNotFound
+	}
+
+	return nil
+}
@@ -0,0 +1,251 @@
+package sqliteHelperProvider
+
+import (
+	"Techiebulter/interview/backend/models"
+	"Techiebulter/interview/backend/providers"
+	"context"
+	"database/sql"
+	"errors"
+	"log"
+	"time"
+
+	"modernc.org/sqlite"
+	sqlite3 "modernc.org/sqlite/lib"
+)
+
+// attendanceColumns is selected by every query returning attendance entries, in the order scanAttendanceEntry expects
+const attendanceColumns = `id, employee_id, clock_in, clock_out, hours, note`
+
+func scanAttendanceEntry(row interface {
+	Scan(dest ...interface{}) error
+}, entry *models.AttendanceEntry) error {
+	return row.Scan(&entry.ID, &entry.EmployeeID, &entry.ClockIn, &entry.ClockOut, &entry.Hours, &entry.Note)
+}
+
+// timesheetColumns is selected by every query returning timesheets, in the order scanTimesheet expects
+const timesheetColumns = `id, employee_id, week_start, entries, hours, status, decided_by, decision_note, decided_at, created_at`
+
+func scanTimesheet(row interface {
+	Scan(dest ...interface{}) error
+}, timesheet *models.Timesheet) error {
+	return row.Scan(&timesheet.ID, &timesheet.EmployeeID, &timesheet.WeekStart, &timesheet.Entries, &timesheet.Hours,
+		&timesheet.Status, &timesheet.DecidedBy, &timesheet.DecisionNote, &timesheet.DecidedAt, &timesheet.CreatedAt)
+}
+
+// ClockIn opens an attendance entry for the employee now.
+func (sh *SQLiteHelper) ClockIn(employeeID int, note string) (models.AttendanceEntry, error) {
+	var entry models.AttendanceEntry
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	query := `
+        INSERT INTO attendance (employee_id, clock_in, note)
+        VALUES (?, ?, ?)
+        RETURNING ` + attendanceColumns
+	err := scanAttendanceEntry(sh.sqliteClient.QueryRowContext(ctx, query, employeeID, time.Now().UTC(), note), &entry)
+	if err != nil {
+		var sqliteErr *sqlite.Error
+		if errors.As(err, &sqliteErr) && sqliteErr.Code() == sqlite3.SQLITE_CONSTRAINT_UNIQUE {
+			return entry, providers.ErrAlreadyClockedIn
+		}
+		if errors.As(err, &sqliteErr) && sqliteErr.Code() == sqlite3.SQLITE_CONSTRAINT_FOREIGNKEY {
+			return entry, providers.ErrEmployeeNotFound
+		}
+		log.Println("ClockIn: unable to insert attendance entry into database:", err)
+		return entry, err
+	}
+
+	return entry, nil
+}
+
+// ClockOut closes the open attendance entry of the employee now.
+func (sh *SQLiteHelper) ClockOut(employeeID int) (models.AttendanceEntry, error) {
+	var entry models.AttendanceEntry
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	tx, err := sh.sqliteClient.BeginTx(ctx, nil)
+	if err != nil {
+		log.Println("ClockOut: unable to begin transaction:", err)
+		return entry, err
+	}
+	defer func() { _ = tx.Rollback() }()
+
+	err = scanAttendanceEntry(tx.QueryRowContext(ctx, `SELECT `+attendanceColumns+` FROM attendance WHERE employee_id = ? AND clock_out IS NULL`,
+		employeeID), &entry)
+	if errors.Is(err, sql.ErrNoRows) {
+		return entry, providers.ErrNotClockedIn
+	}
+	if err != nil {
+		log.Println("ClockOut: error getting result from database:", err)
+		return entry, err
+	}
+
+	now := time.Now().UTC()
+	query := `
+        UPDATE attendance
+        SET clock_out = ?, hours = ?
+        WHERE id = ?
+        RETURNING ` + attendanceColumns
+	if err := scanAttendanceEntry(tx.QueryRowContext(ctx, query, now, models.HoursBetween(entry.ClockIn, now), entry.ID), &entry); err != nil {
+		log.Println("ClockOut: unable to update attendance entry in database:", err)
+		return entry, err
+	}
+
+	if err := tx.Commit(); err != nil {
+		log.Println("ClockOut: unable to commit transaction:", err)
+		return entry, err
+	}
+
+	return entry, nil
+}
+
+// GetAttendance lists the entries clocked in from the first to the last day by clock in time, of every employee when employeeID is 0.
+func (sh *SQLiteHelper) GetAttendance(employeeID int, from models.Date, to models.Date) ([]models.AttendanceEntry, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	query := `
+        SELECT ` + attendanceColumns + `
+        FROM attendance
+        WHERE (? = 0 OR employee_id = ?) AND clock_in >= ? AND clock_in < ?
+        ORDER BY clock_in, id
+    `
+	rows, err := sh.sqliteClient.QueryContext(ctx, query, employeeID, employeeID, from.Time, to.AddDate(0, 0, 1))
+	if err != nil {
+		log.Println("GetAttendance: error getting results from database:", err)
+		return nil, err
+	}
+	defer rows.Close()
+
+	entries := []models.AttendanceEntry{}
+	for rows.Next() {
+		var entry models.AttendanceEntry
+		if err := scanAttendanceEntry(rows, &entry); err != nil {
+			log.Println("GetAttendance: error scanning row:", err)
+			return nil, err
+		}
+		entries = append(entries, entry)
+	}
+
+	return entries, rows.Err()
+}
+
+// CreateTimesheet stores a timesheet as submitted.
+func (sh *SQLiteHelper) CreateTimesheet(timesheet models.Timesheet) (models.Timesheet, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	query := `
+        INSERT INTO timesheets (employee_id, week_start, entries, hours, status, created_at)
+        VALUES (?, ?, ?, ?, ?, ?)
+        RETURNING ` + timesheetColumns
+	err := scanTimesheet(sh.sqliteClient.QueryRowContext(ctx, query, timesheet.EmployeeID, timesheet.WeekStart, timesheet.Entries,
+		timesheet.Hours, models.TimesheetSubmitted, time.Now().UTC()), &timesheet)
+	if err != nil {
+		var sqliteErr *sqlite.Error
+		if errors.As(err, &sqliteErr) && sqliteErr.Code() == sqlite3.SQLITE_CONSTRAINT_UNIQUE {
+			return timesheet, providers.ErrTimesheetExists
+		}
+		if errors.As(err, &sqliteErr) && sqliteErr.Code() == sqlite3.SQLITE_CONSTRAINT_FOREIGNKEY {
+			return timesheet, providers.ErrEmployeeNotFound
+		}
+		log.Println("CreateTimesheet: unable to insert timesheet into database:", err)
+		return timesheet, err
+	}
+
+	return timesheet, nil
+}
+
+// GetTimesheet reads one timesheet.
+func (sh *SQLiteHelper) GetTimesheet(id int) (models.Timesheet, error) {
+	var timesheet models.Timesheet
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	err := scanTimesheet(sh.sqliteClient.QueryRowContext(ctx, `SELECT `+timesheetColumns+` FROM timesheets WHERE id = ?`, id), &timesheet)
+	if errors.Is(err, sql.ErrNoRows) {
+		return timesheet, providers.ErrTimesheetNotFound
+	}
+	if err != nil {
+		log.Println("GetTimesheet: error getting result from database:", err)
+		return timesheet, err
+	}
+
+	return timesheet, nil
+}
+
+// GetTimesheets lists the timesheets matching filter by week and ID.
+func (sh *SQLiteHelper) GetTimesheets(filter models.TimesheetFilter) ([]models.Timesheet, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	// Build the conditions of the filter, each set field adds one
+	var args []interface{}
+	query := `SELECT ` + timesheetColumns + ` FROM timesheets WHERE 1 = 1`
+	where := func(condition string, value interface{}) {
+		args = append(args, value)
+		query += " AND " + condition
+	}
+	if filter.EmployeeID != 0 {
+		where("employee_id = ?", filter.EmployeeID)
+	}
+	if !filter.WeekStart.IsZero() {
+		where("week_start = ?", filter.WeekStart)
+	}
+	if filter.Status != "" {
+		where("status = ?", filter.Status)
+	}
+	query += " ORDER BY week_start, id"
+
+	rows, err := sh.sqliteClient.QueryContext(ctx, query, args...)
+	if err != nil {
+		log.Println("GetTimesheets: error getting results from database:", err)
+		return nil, err
+	}
+	defer rows.Close()
+
+	timesheets := []models.Timesheet{}
+	for rows.Next() {
+		var timesheet models.Timesheet
+		if err := scanTimesheet(rows, &timesheet); err != nil {
+			log.Println("GetTimesheets: error scanning row:", err)
+			return nil, err
+		}
+		timesheets = append(timesheets, timesheet)
+	}
+
+	return timesheets, rows.Err()
+}
+
+// DecideTimesheet approves or rejects a submitted timesheet.
+func (sh *SQLiteHelper) DecideTimesheet(id int, status models.TimesheetStatus, decidedBy string, note string) (models.Timesheet, error) {
+	var timesheet models.Timesheet
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	query := `
+        UPDATE timesheets
+        SET status = ?, decided_by = ?, decision_note = ?, decided_at = ?
+        WHERE id = ? AND status = ?
+        RETURNING ` + timesheetColumns
+	err := scanTimesheet(sh.sqliteClient.QueryRowContext(ctx, query, status, decidedBy, note, time.Now().UTC(), id,
+		models.TimesheetSubmitted), &timesheet)
+	if errors.Is(err, sql.ErrNoRows) {
+		// either there is no such timesheet or it was decided already
+		if _, err := sh.GetTimesheet(id); err != nil {
+			return timesheet, err
+		}
+		return timesheet, providers.ErrTimesheetDecided
+	}
+	if err != nil {
+		log.Println("DecideTimesheet: unable to update timesheet in database:", err)
+		return timesheet, err
+	}
+
+	return timesheet, nil
+}
@@ -0,0 +1,239 @@
+package sqliteHelperProvider
+
+import (
+	"Techiebulter/interview/backend/models"
+	"Techiebulter/interview/backend/providers"
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"log"
+	"time"
+)
+
+// webhookDeliveryColumns is selected by every query returning deliveries, in the order scanWebhookDelivery expects
+const webhookDeliveryColumns = `id, subscription_id, event_id, event_type, payload, status, attempts, next_attempt_at,
+    last_status_code, last_error, delivered_at, created_at`
+
+func scanWebhookDelivery(row interface {
+	Scan(dest ...interface{}) error
+}, delivery *models.WebhookDelivery) error {
+	var payload string
+	err := row.Scan(&delivery.ID, &delivery.SubscriptionID, &delivery.EventID, &delivery.EventType, &payload, &delivery.Status,
+		&delivery.Attempts, &delivery.NextAttemptAt, &delivery.LastStatusCode, &delivery.LastError, &delivery.DeliveredAt,
+		&delivery.CreatedAt)
+	delivery.Payload = []byte(payload)
+	return err
+}
+
+// CreateWebhookSubscription stores a webhook subscription.
+func (sh *SQLiteHelper) CreateWebhookSubscription(subscription models.WebhookSubscription) (models.WebhookSubscription, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	query := `
+        INSERT INTO webhook_subscriptions (url, event_types, secret, created_at)
+        VALUES (?, ?, ?, ?)
+        RETURNING id, created_at
+    `
+	err := sh.sqliteClient.QueryRowContext(ctx, query, subscription.URL, subscription.EventTypes, subscription.Secret, time.Now().UTC()).
+		Scan(&subscription.ID, &subscription.CreatedAt)
+	if err != nil {
+		log.Println("CreateWebhookSubscription: unable to insert subscription into database:", err)
+		return subscription, err
+	}
+
+	return subscription, nil
+}
+
+// GetWebhookSubscriptions lists the webhook subscriptions ordered by ID.
+func (sh *SQLiteHelper) GetWebhookSubscriptions() ([]models.WebhookSubscription, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	query := `SELECT id, url, event_types, secret, created_at FROM webhook_subscriptions ORDER BY id`
+	rows, err := sh.sqliteClient.QueryContext(ctx, query)
+	if err != nil {
+		log.Println("GetWebhookSubscriptions: error getting results from database:", err)
+		return nil, err
+	}
+	defer rows.Close()
+
+	subscriptions := []models.WebhookSubscription{}
+	for rows.Next() {
+		var subscription models.WebhookSubscription
+		err := rows.Scan(&subscription.ID, &subscription.URL, &subscription.EventTypes, &subscription.Secret, &subscription.CreatedAt)
+		if err != nil {
+			log.Println("GetWebhookSubscriptions: error scanning row:", err)
+			return nil, err
+		}
+		subscriptions = append(subscriptions, subscription)
+	}
+
+	return subscriptions, rows.Err()
+}
+
+// DeleteWebhookSubscription removes a webhook subscription, its deliveries are deleted with it.
+func (sh *SQLiteHelper) DeleteWebhookSubscription(id int) error {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	result, err := sh.sqliteClient.ExecContext(ctx, `DELETE FROM webhook_subscriptions WHERE id = ?`, id)
+	if err != nil {
+		log.Println("DeleteWebhookSubscription: error deleting subscription from database:", err)
+		return err
+	}
+	if deleted, _ := result.RowsAffected(); deleted == 0 {
+		return providers.ErrWebhookNotFound
+	}
+
+	return nil
+}
+
+// ClaimWebhookDeliveries leases the due deliveries.
+func (sh *SQLiteHelper) ClaimWebhookDeliveries(now time.Time, leaseUntil time.Time, limit int) ([]models.WebhookDelivery, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	query := `
+        UPDATE webhook_deliveries
+        SET next_attempt_at = ?
+        WHERE id IN (
+            SELECT id FROM webhook_deliveries
+            WHERE status = 'pending' AND next_attempt_at <= ?
+            ORDER BY next_attempt_at, id
+            LIMIT ?
+        )
+        RETURNING ` + webhookDeliveryColumns
+	rows, err := sh.sqliteClient.QueryContext(ctx, query, leaseUntil.UTC(), now.UTC(), limit)
+	if err != nil {
+		log.Println("ClaimWebhookDeliveries: error claiming deliveries in database:", err)
+		return nil, err
+	}
+	defer rows.Close()
+
+	deliveries := []models.WebhookDelivery{}
+	for rows.Next() {
+		var delivery models.WebhookDelivery
+		if err := scanWebhookDelivery(rows, &delivery); err != nil {
+			log.Println("ClaimWebhookDeliveries: error scanning row:", err)
+			return nil, err
+		}
+		deliveries = append(deliveries, delivery)
+	}
+
+	return deliveries, rows.Err()
+}
+
+// CompleteWebhookAttempt records the outcome of an attempt to post a delivery.
+func (sh *SQLiteHelper) CompleteWebhookAttempt(delivery models.WebhookDelivery) error {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	query := `
+        UPDATE webhook_deliveries
+        SET status = ?, attempts = ?, next_attempt_at = ?, last_status_code = ?, last_error = ?, delivered_at = ?
+        WHERE id = ?
+    `
+	_, err := sh.sqliteClient.ExecContext(ctx, query, delivery.Status, delivery.Attempts, utcOrNil(delivery.NextAttemptAt),
+		delivery.LastStatusCode, delivery.LastError, utcOrNil(delivery.DeliveredAt), delivery.ID)
+	if err != nil {
+		log.Println("CompleteWebhookAttempt: error updating delivery in database:", err)
+		return err
+	}
+
+	return nil
+}
+
+// GetWebhookDeliveries returns the delivery log of a subscription.
+func (sh *SQLiteHelper) GetWebhookDeliveries(subscriptionID int, status models.WebhookDeliveryStatus, limit int) ([]models.WebhookDelivery, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	query := `
+        SELECT ` + webhookDeliveryColumns + `
+        FROM webhook_deliveries
+        WHERE subscription_id = ? AND (? = '' OR status = ?)
+        ORDER BY id DESC
+        LIMIT ?
+    `
+	rows, err := sh.sqliteClient.QueryContext(ctx, query, subscriptionID, status, status, limit)
+	if err != nil {
+		log.Println("GetWebhookDeliveries: error getting results from database:", err)
+		return nil, err
+	}
+	defer rows.Close()
+
+	deliveries := []models.WebhookDelivery{}
+	for rows.Next() {
+		var delivery models.WebhookDelivery
+		if err := scanWebhookDelivery(rows, &delivery); err != nil {
+			log.Println("GetWebhookDeliveries: error scanning row:", err)
+			return nil, err
+		}
+		deliveries = append(deliveries, delivery)
+	}
+
+	return deliveries, rows.Err()
+}
+
+// RedeliverWebhook queues a delivery again, whatever became of it before.
+func (sh *SQLiteHelper) RedeliverWebhook(subscriptionID int, deliveryID int64) (models.WebhookDelivery, error) {
+	var delivery models.WebhookDelivery
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	query := `
+        UPDATE webhook_deliveries
+        SET status = 'pending', attempts = 0, next_attempt_at = ?, delivered_at = NULL
+        WHERE id = ? AND subscription_id = ?
+        RETURNING ` + webhookDeliveryColumns
+	err := scanWebhookDelivery(sh.sqliteClient.QueryRowContext(ctx, query, time.Now().UTC(), deliveryID, subscriptionID), &delivery)
+	if errors.Is(err, sql.ErrNoRows) {
+		return delivery, providers.ErrWebhookDeliveryNotFound
+	}
+	if err != nil {
+		log.Println("RedeliverWebhook: error updating delivery in database:", err)
+		return delivery, err
+	}
+
+	return delivery, nil
+}
+
+// utcOrNil stores optional times in UTC, like every other time, so they compare as text
+func utcOrNil(t *time.Time) interface{} {
+	if t == nil {
+		return nil
+	}
+	return t.UTC()
+}
+
+// QueueWebhookDeliveries queues a delivery of a logged event for every subscription to its type. An
+// event queued before is not queued again.
+func (sh *SQLiteHelper) QueueWebhookDeliveries(event models.EmployeeEvent) (int, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	payload, err := json.Marshal(event)
+	if err != nil {
+		return 0, err
+	}
+
+	now := time.Now().UTC()
+	query := `
+        INSERT INTO webhook_deliveries (subscription_id, event_id, event_type, payload, next_attempt_at, created_at)
+        SELECT id, ?, ?, ?, ?, ?
+        FROM webhook_subscriptions
+        WHERE event_types = '[]' OR EXISTS (SELECT 1 FROM json_each(event_types) WHERE value = ?)
+        ON CONFLICT (subscription_id, event_id) DO NOTHING
+    `
+	result, err := sh.sqliteClient.ExecContext(ctx, query, event.ID, event.Type, string(payload), now, now, event.Type)
+	if err != nil {
+		log.Println("QueueWebhookDeliveries: unable to queue webhook deliveries:", err)
+		return 0, err
+	}
+	queued, _ := result.RowsAffected()
+
+	return int(queued), nil
+}
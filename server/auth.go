@@ -0,0 +1,109 @@
+package server
+
+import (
+	"Techiebulter/interview/backend/models"
+	"crypto/subtle"
+	"strconv"
+	"strings"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// principalKey is the fiber.Ctx local holding the authenticated models.Principal
+const principalKey = "principal"
+
+// guardRejectedKey is the fiber.Ctx local set when a guard answered the request instead of its handler
+const guardRejectedKey = "guardRejected"
+
+// Authenticate resolves the API key of the request to a principal.
+// When auth is disabled every caller is treated as an admin.
+func (srv *Server) Authenticate(c *fiber.Ctx) error {
+	if !srv.Config.Auth.Enabled {
+		c.Locals(principalKey, models.Principal{Role: models.RoleAdmin})
+		return c.Next()
+	}
+
+	key := c.Get("X-API-Key")
+	if key == "" {
+		key = strings.TrimPrefix(c.Get(fiber.HeaderAuthorization), "Bearer ")
+	}
+
+	principal, ok := srv.lookupAPIKey(key)
+	if !ok {
+		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{"status": "fail", "message": "missing or invalid API key"})
+	}
+
+	c.Locals(principalKey, principal)
+	return c.Next()
+}
+
+// lookupAPIKey compares key against every configured key in constant time
+func (srv *Server) lookupAPIKey(key string) (models.Principal, bool) {
+	var (
+		principal models.Principal
+		found     bool
+	)
+	if key == "" {
+		return principal, false
+	}
+	for _, apiKey := range srv.Config.Auth.APIKeys {
+		if subtle.ConstantTimeCompare([]byte(key), []byte(apiKey.Key)) == 1 {
+			principal = models.Principal{Role: apiKey.Role, EmployeeID: apiKey.EmployeeID}
+			found = true
+		}
+	}
+	return principal, found
+}
+
+// RequireRole only lets principals holding one of the given roles through
+func RequireRole(roles ...models.Role) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		if !principalOf(c).HasRole(roles...) {
+			return forbidden(c)
+		}
+		return c.Next()
+	}
+}
+
+// forbidden answers a principal a guard does not let through, Idempotent does not store the answer
+func forbidden(c *fiber.Ctx) error {
+	c.Locals(guardRejectedKey, true)
+	return c.Status(fiber.StatusForbidden).JSON(fiber.Map{"status": "fail", "message": "insufficient permissions"})
+}
+
+// principalOf returns the principal stored by Authenticate
+func principalOf(c *fiber.Ctx) models.Principal {
+	principal, _ := c.Locals(principalKey).(models.Principal)
+	return principal
+}
+
+// RequireSelfOrRole lets principals holding one of the given roles through, and employees to the
+// routes of their own :id
+func RequireSelfOrRole(roles ...models.Role) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		principal := principalOf(c)
+		if !principal.HasRole(roles...) && (principal.EmployeeID == 0 || c.Params("id") != strconv.Itoa(principal.EmployeeID)) {
+			return forbidden(c)
+		}
+		return c.Next()
+	}
+}
+
+// mayDecideFor reports whether principal may approve or reject what the employee with the given ID asked
+// for. HR may for everyone but themself, managers for the employees they manage.
+func (srv *Server) mayDecideFor(principal models.Principal, employeeID int) (bool, error) {
+	if principal.EmployeeID != 0 && principal.EmployeeID == employeeID {
+		return false, nil
+	}
+	if principal.HasRole(models.RoleAdmin, models.RoleHR) {
+		return true, nil
+	}
+	if principal.EmployeeID == 0 {
+		return false, nil
+	}
+	employee, err := srv.DBHelper.ReadFromPrimary().GetEmployeeById(employeeID)
+	if err != nil {
+		return false, err
+	}
+	return employee.ManagerID != nil && *employee.ManagerID == principal.EmployeeID, nil
+}
@@ -0,0 +1,31 @@
+package server
+
+import (
+	"Techiebulter/interview/backend/providers"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// lastWriteHeader is set on every write response. Clients echo it on later reads so
+// that, while replicas may still be behind that write, the reads go to the primary.
+const lastWriteHeader = "X-Session-Last-Write"
+
+// markWrite stamps the response of a successful write with the read-your-writes hint
+func markWrite(c *fiber.Ctx) {
+	c.Set(lastWriteHeader, time.Now().UTC().Format(time.RFC3339Nano))
+}
+
+// readHelper returns the DB helper for a read, the primary if the session wrote recently
+func (srv *Server) readHelper(c *fiber.Ctx) providers.DbHelperProvider {
+	hint := c.Get(lastWriteHeader)
+	if hint == "" {
+		return srv.DBHelper
+	}
+
+	lastWrite, err := time.Parse(time.RFC3339Nano, hint)
+	if err != nil || time.Since(lastWrite) > srv.Config.Database.MaxReplicaLag {
+		return srv.DBHelper
+	}
+	return srv.DBHelper.ReadFromPrimary()
+}
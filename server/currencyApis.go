@@ -0,0 +1,154 @@
+package server
+
+import (
+	"Techiebulter/interview/backend/models"
+	"bytes"
+	"errors"
+	"log"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// saveExchangeRates stores validated rates and answers with respond once they are stored
+func (s *Server) saveExchangeRates(c *fiber.Ctx, rates []models.ExchangeRate, respond func(saved []models.ExchangeRate) error) error {
+	// Use a channel to communicate errors and results back from the goroutine
+	resultChan := make(chan []models.ExchangeRate, 1)
+	errChan := make(chan error, 1)
+
+	go func() {
+		saved, err := s.DBHelper.SaveExchangeRates(rates)
+		if err != nil {
+			errChan <- err
+			return
+		}
+		resultChan <- saved
+	}()
+
+	select {
+	case saved := <-resultChan:
+		markWrite(c)
+		return respond(saved)
+	case err := <-errChan:
+		log.Println("SaveExchangeRates: error inserting data in the database", err)
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"status": "fail", "data": fiber.Map{"error": err}})
+	}
+}
+
+// CreateExchangeRate stores what a unit of a currency is worth in the base currency from a day on,
+// replacing the rate of the currency on that day
+func (s *Server) CreateExchangeRate(c *fiber.Ctx) error {
+	var rate models.ExchangeRate
+	if err := c.BodyParser(&rate); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"status": "fail", "message": err.Error()})
+	}
+	if errs := rate.Validate(s.Config.Currency.Base); len(errs) > 0 {
+		return validationFailed(c, errs)
+	}
+	return s.saveExchangeRates(c, []models.ExchangeRate{rate}, func(saved []models.ExchangeRate) error {
+		return c.Status(fiber.StatusOK).JSON(fiber.Map{"status": "success", "rate": saved[0]})
+	})
+}
+
+// UploadExchangeRates stores the rates of a CSV document at once, nothing is stored unless every line is valid
+func (s *Server) UploadExchangeRates(c *fiber.Ctx) error {
+	rates, errs := models.ParseExchangeRatesCSV(bytes.NewReader(c.Body()), s.Config.Currency.Base)
+	if len(errs) > 0 {
+		return validationFailed(c, errs)
+	}
+	return s.saveExchangeRates(c, rates, func(saved []models.ExchangeRate) error {
+		return c.Status(fiber.StatusOK).JSON(fiber.Map{"status": "success", "rates": saved})
+	})
+}
+
+// GetExchangeRates lists the exchange rates, optionally of one currency and effective up to a day
+func (s *Server) GetExchangeRates(c *fiber.Ctx) error {
+	filter := models.ExchangeRateFilter{Currency: models.Currency(c.Query("currency"))}
+	if c.Query("until") != "" {
+		until, fieldErr := queryDate(c, "until", models.Date{})
+		if fieldErr != nil {
+			return validationFailed(c, models.ValidationErrors{*fieldErr})
+		}
+		filter.Until = &until
+	}
+	dbHelper := s.readHelper(c)
+
+	// Use a channel to communicate errors and results back from the goroutine
+	resultChan := make(chan []models.ExchangeRate, 1)
+	errChan := make(chan error, 1)
+
+	go func() {
+		rates, err := dbHelper.GetExchangeRates(filter)
+		if err != nil {
+			errChan <- err
+			return
+		}
+		resultChan <- rates
+	}()
+
+	select {
+	case rates := <-resultChan:
+		return c.Status(fiber.StatusOK).JSON(fiber.Map{"status": "success", "base": s.Config.Currency.Base, "rates": rates})
+	case err := <-errChan:
+		log.Println("GetExchangeRates: error getting results from DB", err)
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"status": "fail", "data": fiber.Map{"error": err}})
+	}
+}
+
+// GetSalaryReport sums the salaries of the employees employed on a day by department, position or
+// location, normalized to the base currency or another one with the exchange rates of the day
+func (s *Server) GetSalaryReport(c *fiber.Ctx) error {
+	asOf, fieldErr := queryDate(c, "asOf", models.NewDate(time.Now()))
+	if fieldErr != nil {
+		return validationFailed(c, models.ValidationErrors{*fieldErr})
+	}
+	query := models.SalaryReportQuery{AsOf: asOf, GroupBy: models.SalaryGroupBy(c.Query("groupBy", string(models.GroupByDepartment))),
+		Currency: models.Currency(c.Query("currency", string(s.Config.Currency.Base)))}
+	if errs := models.SalaryReportQueryRules.Validate(&query); len(errs) > 0 {
+		return validationFailed(c, errs)
+	}
+	dbHelper := s.readHelper(c)
+
+	// Use a channel to communicate errors and results back from the goroutine
+	resultChan := make(chan models.SalaryReport, 1)
+	errChan := make(chan error, 1)
+
+	go func() {
+		employees, err := allEmployees(dbHelper)
+		if err != nil {
+			errChan <- err
+			return
+		}
+		history, err := dbHelper.GetSalaryHistory(0, asOf)
+		if err != nil {
+			errChan <- err
+			return
+		}
+		rates, err := dbHelper.GetExchangeRates(models.ExchangeRateFilter{Until: &asOf})
+		if err != nil {
+			errChan <- err
+			return
+		}
+		report, err := models.NewSalaryReport(query, employees, history, models.NewConverter(s.Config.Currency.Base, asOf, rates))
+		if err != nil {
+			errChan <- err
+			return
+		}
+		resultChan <- report
+	}()
+
+	select {
+	case report := <-resultChan:
+		return c.Status(fiber.StatusOK).JSON(fiber.Map{"status": "success", "report": report})
+	case err := <-errChan:
+		var (
+			missingRate *models.MissingRateError
+			overflow    *models.OverflowError
+		)
+		if errors.As(err, &missingRate) || errors.As(err, &overflow) {
+			return c.Status(fiber.StatusConflict).JSON(fiber.Map{"status": "fail", "error": err.Error()})
+		}
+		log.Println("GetSalaryReport: error getting results from DB", err)
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"status": "fail", "data": fiber.Map{"error": err}})
+	}
+}
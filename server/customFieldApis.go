@@ -0,0 +1,89 @@
+package server
+
+import (
+	"Techiebulter/interview/backend/models"
+	"Techiebulter/interview/backend/providers"
+	"errors"
+	"log"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+func (s *Server) CreateCustomField(c *fiber.Ctx) error {
+	var definition models.CustomFieldDefinition
+
+	if err := c.BodyParser(&definition); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"status": "fail", "message": err.Error()})
+	}
+
+	if errs := definition.Validate(); len(errs) > 0 {
+		return validationFailed(c, errs)
+	}
+
+	// Use a channel to communicate errors back from the goroutine
+	errChan := make(chan error, 1)
+
+	go func() {
+		errChan <- s.DBHelper.CreateCustomFieldDefinition(definition)
+	}()
+
+	err := <-errChan
+	if errors.Is(err, providers.ErrCustomFieldExists) {
+		return c.Status(fiber.StatusConflict).JSON(fiber.Map{"status": "fail", "error": err.Error()})
+	}
+	if err != nil {
+		log.Println("CreateCustomField: error inserting data in the database", err)
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"status": "fail", "data": fiber.Map{"error": err}})
+	}
+
+	markWrite(c)
+	return c.Status(fiber.StatusOK).JSON(fiber.Map{"status": "success"})
+}
+
+func (s *Server) GetCustomFields(c *fiber.Ctx) error {
+	dbHelper := s.readHelper(c)
+
+	// Use a channel to communicate errors and results back from the goroutine
+	resultChan := make(chan []models.CustomFieldDefinition, 1)
+	errChan := make(chan error, 1)
+
+	go func() {
+		definitions, err := dbHelper.GetCustomFieldDefinitions()
+		if err != nil {
+			errChan <- err
+			return
+		}
+		resultChan <- definitions
+	}()
+
+	select {
+	case definitions := <-resultChan:
+		return c.Status(fiber.StatusOK).JSON(fiber.Map{"status": "success", "customFields": definitions})
+	case err := <-errChan:
+		log.Println("GetCustomFields: error getting results from DB", err)
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"status": "fail", "data": fiber.Map{"error": err}})
+	}
+}
+
+func (s *Server) DeleteCustomField(c *fiber.Ctx) error {
+	name := c.Params("name")
+
+	// Use a channel to communicate errors back from the goroutine
+	errChan := make(chan error, 1)
+
+	go func() {
+		errChan <- s.DBHelper.DeleteCustomFieldDefinition(name)
+	}()
+
+	err := <-errChan
+	if errors.Is(err, providers.ErrCustomFieldNotFound) {
+		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{"status": "fail", "error": err.Error()})
+	}
+	if err != nil {
+		log.Println("DeleteCustomField: error deleting definition from DB", err)
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"status": "fail", "data": fiber.Map{"error": err}})
+	}
+
+	markWrite(c)
+	return c.Status(fiber.StatusOK).JSON(fiber.Map{"status": "success"})
+}
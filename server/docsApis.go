@@ -0,0 +1,30 @@
+package server
+
+import (
+	"Techiebulter/interview/backend/docs"
+	"path/filepath"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// OpenAPISpec serves the OpenAPI document of the API
+func (srv *Server) OpenAPISpec(c *fiber.Ctx) error {
+	c.Set(fiber.HeaderContentType, fiber.MIMEApplicationJSONCharsetUTF8)
+	return c.Status(fiber.StatusOK).Send(docs.OpenAPI)
+}
+
+// SwaggerUI serves a page to browse and try the API
+func (srv *Server) SwaggerUI(c *fiber.Ctx) error {
+	c.Set(fiber.HeaderContentType, fiber.MIMETextHTMLCharsetUTF8)
+	return c.Status(fiber.StatusOK).Send(docs.SwaggerUI)
+}
+
+// SwaggerUIAsset serves the Swagger UI stylesheet and script embedded next to the page
+func (srv *Server) SwaggerUIAsset(c *fiber.Ctx) error {
+	asset, err := docs.SwaggerUIAssets.ReadFile("swagger-ui/" + c.Params("asset"))
+	if err != nil {
+		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{"status": "fail", "error": "asset not found"})
+	}
+	c.Type(filepath.Ext(c.Params("asset")))
+	return c.Status(fiber.StatusOK).Send(asset)
+}
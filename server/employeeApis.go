@@ -2,6 +2,8 @@ package server
 
 import (
 	"Techiebulter/interview/backend/models"
+	"Techiebulter/interview/backend/providers"
+	"errors"
 	"log"
 	"strconv"
 
@@ -11,13 +13,29 @@ import (
 func (s *Server) CreateEmployee(c *fiber.Ctx) error {
 	var Employee models.Employee
 
-	if err := c.BodyParser(&Employee); err != nil {
+	if errs, err := parseBody(c, &Employee); err != nil {
 		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"status": "fail", "message": err.Error()})
+	} else if len(errs) > 0 {
+		return validationFailed(c, errs)
 	}
 
-	// Check if all fields of Employee are present
-	if err := Employee.CheckFeilds(); err != nil {
-		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"status": "fail", "error": err.Error()})
+	// Check the fields of Employee, required custom fields included
+	errs, err := s.validateEmployee(&Employee, models.EmployeeCreateRules, true)
+	if err != nil {
+		log.Println("CreateEmployee: error getting custom field definitions from DB", err)
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"status": "fail", "data": fiber.Map{"error": err}})
+	}
+	if len(errs) > 0 {
+		return validationFailed(c, errs)
+	}
+
+	// Check the salary against the band of the position
+	warnings, errs, err := s.checkSalaryBand(Employee, true)
+	if err != nil {
+		return salaryBandFailed(c, err, "CreateEmployee: error checking the salary band")
+	}
+	if len(errs) > 0 {
+		return validationFailed(c, errs)
 	}
 
 	// Use a channel to communicate errors back from goroutines
@@ -29,14 +47,21 @@ func (s *Server) CreateEmployee(c *fiber.Ctx) error {
 	}()
 
 	// Wait for the database operation to complete
-	err := <-errChan
+	err = <-errChan
 
+	if errors.Is(err, providers.ErrEmailTaken) {
+		return c.Status(fiber.StatusConflict).JSON(fiber.Map{"status": "fail", "error": err.Error()})
+	}
+	if errors.Is(err, providers.ErrManagerNotFound) {
+		return validationFailed(c, models.ValidationErrors{{Field: "managerId", Code: models.CodeInvalidValue, Message: err.Error()}})
+	}
 	if err != nil {
 		log.Println("CreateEmployee: error inserting data in the database", err)
 		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"status": "fail", "data": fiber.Map{"error": err}})
 	}
 
-	return c.Status(fiber.StatusOK).JSON(fiber.Map{"status": "success"})
+	markWrite(c)
+	return c.Status(fiber.StatusOK).JSON(withWarnings(fiber.Map{"status": "success"}, warnings))
 }
 
 func (s *Server) GetEmployeeById(c *fiber.Ctx) error {
@@ -45,6 +70,7 @@ func (s *Server) GetEmployeeById(c *fiber.Ctx) error {
 	if err != nil {
 		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"status": "fail", "message": err.Error()})
 	}
+	dbHelper := s.readHelper(c)
 
 	// Use a channel to communicate results and errors back from goroutines
 	resultChan := make(chan interface{}, 1)
@@ -52,12 +78,17 @@ func (s *Server) GetEmployeeById(c *fiber.Ctx) error {
 
 	// Start a goroutine to execute the database operation
 	go func() {
-		employeeDetails, err := s.DBHelper.GetEmployeeById(id)
+		employeeDetails, err := dbHelper.GetEmployeeById(id)
+		if err != nil {
+			errChan <- err
+			return
+		}
+		shown, err := withLeaveStatus(dbHelper, employeeDetails)
 		if err != nil {
 			errChan <- err
 			return
 		}
-		resultChan <- employeeDetails
+		resultChan <- shown[0]
 	}()
 
 	// Wait for the database operation to complete
@@ -65,21 +96,46 @@ func (s *Server) GetEmployeeById(c *fiber.Ctx) error {
 	case employeeDetails := <-resultChan:
 		return c.Status(fiber.StatusOK).JSON(fiber.Map{"status": "success", "employeeDetails": employeeDetails})
 	case err := <-errChan:
+		// a merged duplicate's ID leads to the employee it was merged into
+		var merged *providers.EmployeeMergedError
+		if errors.As(err, &merged) {
+			c.Location("/api/GetEmployeeById/" + strconv.Itoa(merged.MergedInto))
+			return c.Status(fiber.StatusMovedPermanently).JSON(fiber.Map{"status": "fail", "error": err.Error(), "mergedInto": merged.MergedInto})
+		}
+		if errors.Is(err, providers.ErrEmployeeNotFound) {
+			return c.Status(fiber.StatusNotFound).JSON(fiber.Map{"status": "fail", "error": err.Error()})
+		}
 		log.Println("GetEmployeeById: error getting results from DB", err)
-		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"status": "success", "data": fiber.Map{"error": err}})
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"status": "fail", "data": fiber.Map{"error": err}})
 	}
 }
 
 func (s *Server) UpdateEmployee(c *fiber.Ctx) error {
 	var Employee models.Employee
 
-	if err := c.BodyParser(&Employee); err != nil {
+	if errs, err := parseBody(c, &Employee); err != nil {
 		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"status": "fail", "message": err.Error()})
+	} else if len(errs) > 0 {
+		return validationFailed(c, errs)
+	}
+
+	// Check the fields being changed, custom fields are replaced as a whole so they are only checked when sent
+	errs, err := s.validateEmployee(&Employee, models.EmployeeUpdateRules, false)
+	if err != nil {
+		log.Println("UpdateEmployee: error getting custom field definitions from DB", err)
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"status": "fail", "data": fiber.Map{"error": err}})
+	}
+	if len(errs) > 0 {
+		return validationFailed(c, errs)
 	}
 
-	// Check if Id of Employee is present
-	if err := Employee.CheckId(); err != nil {
-		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"status": "fail", "error": err.Error()})
+	// Check the salary against the band of the position if either changes
+	warnings, errs, err := s.checkSalaryBand(Employee, false)
+	if err != nil {
+		return salaryBandFailed(c, err, "UpdateEmployee: error checking the salary band")
+	}
+	if len(errs) > 0 {
+		return validationFailed(c, errs)
 	}
 
 	// Use a channel to communicate errors and results back from the goroutine
@@ -93,14 +149,27 @@ func (s *Server) UpdateEmployee(c *fiber.Ctx) error {
 			errChan <- err
 			return
 		}
-		resultChan <- updatedEmployeeDetails
+		resultChan <- s.writtenWithLeaveStatus(updatedEmployeeDetails)
 	}()
 
 	// Wait for the database operation to complete
 	select {
 	case updatedEmployeeDetails := <-resultChan:
-		return c.Status(fiber.StatusOK).JSON(fiber.Map{"status": "success", "updatedEmployeeDetails": updatedEmployeeDetails})
+		markWrite(c)
+		return c.Status(fiber.StatusOK).JSON(withWarnings(fiber.Map{"status": "success", "updatedEmployeeDetails": updatedEmployeeDetails}, warnings))
 	case err := <-errChan:
+		if errors.Is(err, providers.ErrEmployeeNotFound) {
+			return c.Status(fiber.StatusNotFound).JSON(fiber.Map{"status": "fail", "error": err.Error()})
+		}
+		if errors.Is(err, providers.ErrEmailTaken) {
+			return c.Status(fiber.StatusConflict).JSON(fiber.Map{"status": "fail", "error": err.Error()})
+		}
+		if errors.Is(err, providers.ErrTerminationBeforeHire) {
+			return validationFailed(c, models.ValidationErrors{{Field: "terminationDate", Code: models.CodeOutOfRange, Message: err.Error()}})
+		}
+		if errors.Is(err, providers.ErrManagerNotFound) {
+			return validationFailed(c, models.ValidationErrors{{Field: "managerId", Code: models.CodeInvalidValue, Message: err.Error()}})
+		}
 		log.Println("UpdateEmployee: error updating data in the database", err)
 		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"status": "fail", "data": fiber.Map{"error": err}})
 	}
@@ -123,17 +192,22 @@ func (s *Server) DeleteEmployee(c *fiber.Ctx) error {
 
 	// Wait for the database operation to complete
 	err = <-errChan
+	if errors.Is(err, providers.ErrEmployeeNotFound) {
+		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{"status": "fail", "error": err.Error()})
+	}
 	if err != nil {
 		log.Println("DeleteEmployeeById: error deleting employee from DB", err)
 		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"status": "fail", "data": fiber.Map{"error": err}})
 	}
 
+	markWrite(c)
 	return c.Status(fiber.StatusOK).JSON(fiber.Map{"status": "success"})
 }
 
 func (s *Server) GetAllEmployees(c *fiber.Ctx) error {
 	page_string := c.Params("page")
 	limit_string := c.Params("limit")
+	dbHelper := s.readHelper(c)
 
 	// Use a channel to communicate errors and results back from the goroutine
 	resultChan := make(chan []models.Employee, 1)
@@ -141,11 +215,15 @@ func (s *Server) GetAllEmployees(c *fiber.Ctx) error {
 
 	// Start a goroutine to execute the database operation
 	go func() {
-		allEmployees, err := s.DBHelper.GetAllEmployees(page_string, limit_string)
+		allEmployees, err := dbHelper.GetAllEmployees(page_string, limit_string)
 		if err != nil {
 			errChan <- err
 			return
 		}
+		if allEmployees, err = withLeaveStatus(dbHelper, allEmployees...); err != nil {
+			errChan <- err
+			return
+		}
 		resultChan <- allEmployees
 	}()
 
@@ -0,0 +1,261 @@
+package server
+
+import (
+	"Techiebulter/interview/backend/models"
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"log"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gofiber/contrib/websocket"
+	"github.com/gofiber/fiber/v2"
+)
+
+// eventReplayPageSize is how many logged events are read at a time while a stream catches up
+const eventReplayPageSize = 500
+
+// eventStreamKey is the fiber.Ctx local holding the eventStream of a WebSocket request
+const eventStreamKey = "eventStream"
+
+// eventStream selects the events sent to a client
+type eventStream struct {
+	// types lists the event types sent, every type when empty
+	types []models.EmployeeEventType
+	// resume is set when the client sent the ID of the last event it received, the logged
+	// events after lastID are sent before the live ones
+	resume bool
+	lastID int64
+}
+
+func (s eventStream) wants(eventType models.EmployeeEventType) bool {
+	if len(s.types) == 0 {
+		return true
+	}
+	for _, t := range s.types {
+		if t == eventType {
+			return true
+		}
+	}
+	return false
+}
+
+// eventStreamOf reads the comma separated types query parameter and the ID of the last event
+// received, from the Last-Event-ID header sent by reconnecting EventSources or the lastEventId query parameter
+func eventStreamOf(c *fiber.Ctx) (eventStream, models.ValidationErrors) {
+	var (
+		stream eventStream
+		errs   models.ValidationErrors
+	)
+
+	for _, name := range strings.Split(c.Query("types"), ",") {
+		eventType := models.EmployeeEventType(strings.TrimSpace(name))
+		if eventType == "" {
+			continue
+		}
+		known := false
+		for _, t := range models.EmployeeEventTypes {
+			known = known || t == eventType
+		}
+		if !known {
+			errs = append(errs, models.FieldError{Field: "types", Code: models.CodeInvalidValue,
+				Message: fmt.Sprintf("%q is not an event type, use created, updated or deleted", eventType)})
+			continue
+		}
+		stream.types = append(stream.types, eventType)
+	}
+
+	field, lastID := "Last-Event-ID", c.Get("Last-Event-ID")
+	if lastID == "" {
+		field, lastID = "lastEventId", c.Query("lastEventId")
+	}
+	if lastID != "" {
+		id, err := strconv.ParseInt(lastID, 10, 64)
+		if err != nil || id < 0 {
+			errs = append(errs, models.FieldError{Field: field, Code: models.CodeInvalidFormat, Message: field + " must be the ID of an event"})
+		}
+		stream.resume, stream.lastID = true, id
+	}
+
+	return stream, errs
+}
+
+// followEvents calls send with the logged events the client missed and then with the live ones, in
+// the order of their IDs, and heartbeat whenever no event arrived for the configured interval. It
+// returns nil when done is closed or the server stops, and the first error of send, heartbeat or reading the log otherwise.
+func (srv *Server) followEvents(stream eventStream, done <-chan struct{}, send func(models.EmployeeEvent) error, heartbeat func() error) error {
+	eventLog := srv.DBHelper.ReadFromPrimary()
+	heartbeats := time.NewTicker(srv.Config.Events.Heartbeat)
+	defer heartbeats.Stop()
+
+	for {
+		// subscribing before reading the log means no event is missed in between, events that arrive
+		// both ways are told apart by their IDs
+		live, cancel := srv.events.subscribe()
+
+		for stream.resume {
+			events, err := eventLog.GetEmployeeEvents(stream.lastID, stream.types, eventReplayPageSize)
+			if err != nil {
+				cancel()
+				log.Println("followEvents: error reading the event log", err)
+				return err
+			}
+			for _, event := range events {
+				if err := send(event); err != nil {
+					cancel()
+					return err
+				}
+				stream.lastID = event.ID
+			}
+			if len(events) < eventReplayPageSize {
+				break
+			}
+		}
+
+		catchUp, err := srv.forwardEvents(&stream, live, done, send, heartbeat, heartbeats.C)
+		cancel()
+		if !catchUp {
+			return err
+		}
+	}
+}
+
+// forwardEvents sends the live events the client did not receive from the log yet. It returns
+// catchUp set when the subscription was dropped because the client fell behind, the buffered
+// events are sent first and the rest is read from the log.
+func (srv *Server) forwardEvents(stream *eventStream, live <-chan models.EmployeeEvent, done <-chan struct{},
+	send func(models.EmployeeEvent) error, heartbeat func() error, heartbeats <-chan time.Time) (catchUp bool, err error) {
+	for {
+		select {
+		case event, ok := <-live:
+			if !ok {
+				return true, nil
+			}
+			if event.ID != 0 && event.ID <= stream.lastID {
+				continue
+			}
+			if stream.wants(event.Type) {
+				if err := send(event); err != nil {
+					return false, err
+				}
+			}
+			if event.ID != 0 {
+				stream.resume, stream.lastID = true, event.ID
+			}
+		case <-heartbeats:
+			if err := heartbeat(); err != nil {
+				return false, err
+			}
+		case <-done:
+			return false, nil
+		case <-srv.stopping:
+			return false, nil
+		}
+	}
+}
+
+// StreamEvents sends employee changes as server-sent events, each with its event ID, its type as the
+// event name and the models.EmployeeEvent as JSON data. A client reconnecting with Last-Event-ID first
+// receives the events it missed from the event log.
+func (srv *Server) StreamEvents(c *fiber.Ctx) error {
+	stream, errs := eventStreamOf(c)
+	if len(errs) > 0 {
+		return validationFailed(c, errs)
+	}
+
+	c.Set(fiber.HeaderContentType, "text/event-stream")
+	c.Set(fiber.HeaderCacheControl, "no-cache")
+	// nginx would otherwise buffer the stream
+	c.Set("X-Accel-Buffering", "no")
+
+	conn := c.Context().Conn()
+	writeTimeout := srv.Config.Server.WriteTimeout
+	c.Context().SetBodyStreamWriter(func(w *bufio.Writer) {
+		// the write timeout applies to every flush, not to the whole stream
+		flush := func() error {
+			if writeTimeout > 0 {
+				_ = conn.SetWriteDeadline(time.Now().Add(writeTimeout))
+			}
+			return w.Flush()
+		}
+		comment := func(text string) error {
+			fmt.Fprintf(w, ": %s\n\n", text)
+			return flush()
+		}
+
+		// the headers go out right away, before the first event
+		if comment("connected") != nil {
+			return
+		}
+		_ = srv.followEvents(stream, nil, func(event models.EmployeeEvent) error {
+			data, err := json.Marshal(event)
+			if err != nil {
+				return err
+			}
+			if event.ID != 0 {
+				fmt.Fprintf(w, "id: %d\n", event.ID)
+			}
+			fmt.Fprintf(w, "event: %s\ndata: %s\n\n", event.Type, data)
+			return flush()
+		}, func() error {
+			return comment("heartbeat")
+		})
+	})
+	return nil
+}
+
+// UpgradeEvents accepts WebSocket upgrade requests for EventsWebSocket, with the parameters of StreamEvents
+func (srv *Server) UpgradeEvents(c *fiber.Ctx) error {
+	if !websocket.IsWebSocketUpgrade(c) {
+		return c.Status(fiber.StatusUpgradeRequired).JSON(fiber.Map{"status": "fail", "message": "expected a WebSocket upgrade request"})
+	}
+	stream, errs := eventStreamOf(c)
+	if len(errs) > 0 {
+		return validationFailed(c, errs)
+	}
+	c.Locals(eventStreamKey, stream)
+	return c.Next()
+}
+
+// EventsWebSocket sends the events of StreamEvents as JSON text messages and pings while idle.
+// Messages from the client are ignored, the connection is closed with 1001 when the server stops.
+func (srv *Server) EventsWebSocket() fiber.Handler {
+	return websocket.New(func(conn *websocket.Conn) {
+		stream, _ := conn.Locals(eventStreamKey).(eventStream)
+		deadline := func() time.Time {
+			return time.Now().Add(srv.Config.Events.Heartbeat)
+		}
+
+		// reading processes the control frames of the client and notices when it goes away
+		done := make(chan struct{})
+		go func() {
+			defer close(done)
+			for {
+				if _, _, err := conn.ReadMessage(); err != nil {
+					return
+				}
+			}
+		}()
+
+		err := srv.followEvents(stream, done, func(event models.EmployeeEvent) error {
+			_ = conn.SetWriteDeadline(deadline())
+			return conn.WriteJSON(event)
+		}, func() error {
+			return conn.WriteControl(websocket.PingMessage, nil, deadline())
+		})
+
+		select {
+		case <-done:
+			// the client is gone, there is no one to say goodbye to
+			return
+		default:
+		}
+		closing := websocket.FormatCloseMessage(websocket.CloseGoingAway, "server is stopping")
+		if err != nil {
+			closing = websocket.FormatCloseMessage(websocket.CloseInternalServerErr, "event stream failed")
+		}
+		_ = conn.WriteControl(websocket.CloseMessage, closing, deadline())
+	})
+}
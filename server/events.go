@@ -0,0 +1,172 @@
+package server
+
+import (
+	"Techiebulter/interview/backend/models"
+	"Techiebulter/interview/backend/providers"
+	"log"
+	"sync"
+)
+
+// watcherBuffer is how many events a watcher may fall behind before it is dropped
+const watcherBuffer = 64
+
+// eventHub follows the event log and fans the events out to the watchers subscribed at the time
+type eventHub struct {
+	// log is read from the primary, the events logged since the last catch-up are sent
+	log providers.DbHelperProvider
+
+	mu sync.Mutex
+	// lastID is the newest event sent, following is set once it was read from the log. While nobody
+	// watches, the hub stops following and starts again from the newest event on the next subscribe.
+	lastID    int64
+	following bool
+	watchers  map[chan models.EmployeeEvent]struct{}
+}
+
+func newEventHub(log providers.DbHelperProvider) *eventHub {
+	return &eventHub{log: log, watchers: map[chan models.EmployeeEvent]struct{}{}}
+}
+
+// subscribe registers a watcher until cancel is called. The channel is closed when the watcher
+// falls more than watcherBuffer events behind, so a slow watcher never blocks writers.
+func (h *eventHub) subscribe() (events <-chan models.EmployeeEvent, cancel func()) {
+	ch := make(chan models.EmployeeEvent, watcherBuffer)
+	h.mu.Lock()
+	h.watchers[ch] = struct{}{}
+	h.follow()
+	h.mu.Unlock()
+
+	return ch, func() {
+		h.mu.Lock()
+		defer h.mu.Unlock()
+		if _, ok := h.watchers[ch]; ok {
+			delete(h.watchers, ch)
+			close(ch)
+		}
+	}
+}
+
+// follow starts following the log at its newest event, it is called with the lock held
+func (h *eventHub) follow() {
+	if h.following {
+		return
+	}
+	latest, err := h.log.GetLatestEmployeeEventID()
+	if err != nil {
+		log.Println("follow: unable to read the event log", err)
+		return
+	}
+	h.lastID, h.following = latest, true
+}
+
+// catchUp sends the events logged since the last catch-up to the watchers, in the order of their IDs.
+// It runs after every write of this instance and every outbox poll, so the watchers see the writes of
+// other instances too.
+func (h *eventHub) catchUp() {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	if len(h.watchers) == 0 {
+		h.following = false
+		return
+	}
+	if !h.following {
+		h.follow()
+		return
+	}
+
+	for {
+		events, err := h.log.GetEmployeeEvents(h.lastID, nil, eventReplayPageSize)
+		if err != nil {
+			log.Println("catchUp: unable to read the event log", err)
+			return
+		}
+		for _, event := range events {
+			for ch := range h.watchers {
+				select {
+				case ch <- event:
+				default:
+					delete(h.watchers, ch)
+					close(ch)
+				}
+			}
+			h.lastID = event.ID
+		}
+		if len(events) < eventReplayPageSize {
+			return
+		}
+	}
+}
+
+// publishingHelper has the event hub catch up and wakes the outbox relay after every successful
+// employee write. The writes log their events themselves, in their own transaction.
+type publishingHelper struct {
+	providers.DbHelperProvider
+	events *eventHub
+	// outboxDue wakes the outbox relay
+	outboxDue chan struct{}
+}
+
+func (h publishingHelper) written() {
+	h.events.catchUp()
+	select {
+	case h.outboxDue <- struct{}{}:
+	default:
+	}
+}
+
+func (h publishingHelper) CreateEmployee(employee models.Employee) error {
+	_, err := h.InsertEmployee(employee)
+	return err
+}
+
+func (h publishingHelper) InsertEmployee(employee models.Employee) (models.Employee, error) {
+	created, err := h.DbHelperProvider.InsertEmployee(employee)
+	if err == nil {
+		h.written()
+	}
+	return created, err
+}
+
+func (h publishingHelper) UpdateEmployee(employee models.Employee) (models.Employee, error) {
+	updated, err := h.DbHelperProvider.UpdateEmployee(employee)
+	if err == nil {
+		h.written()
+	}
+	return updated, err
+}
+
+func (h publishingHelper) PatchEmployee(id int, patch func(current models.Employee) (models.Employee, error)) (models.Employee, error) {
+	patched, err := h.DbHelperProvider.PatchEmployee(id, patch)
+	if err == nil {
+		h.written()
+	}
+	return patched, err
+}
+
+func (h publishingHelper) DeleteEmployeeById(id int) error {
+	err := h.DbHelperProvider.DeleteEmployeeById(id)
+	if err == nil {
+		h.written()
+	}
+	return err
+}
+
+func (h publishingHelper) MergeEmployees(request models.MergeRequest, mergedBy string) (models.Employee, error) {
+	merged, err := h.DbHelperProvider.MergeEmployees(request, mergedBy)
+	if err == nil {
+		h.written()
+	}
+	return merged, err
+}
+
+func (h publishingHelper) AppendEmployeeEvent(event models.EmployeeEvent) (models.EmployeeEvent, error) {
+	logged, err := h.DbHelperProvider.AppendEmployeeEvent(event)
+	if err == nil {
+		h.written()
+	}
+	return logged, err
+}
+
+func (h publishingHelper) ReadFromPrimary() providers.DbHelperProvider {
+	return publishingHelper{DbHelperProvider: h.DbHelperProvider.ReadFromPrimary(), events: h.events, outboxDue: h.outboxDue}
+}
@@ -0,0 +1,275 @@
+package server
+
+import (
+	"Techiebulter/interview/backend/models"
+	"Techiebulter/interview/backend/providers"
+	"context"
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/graphql-go/graphql"
+	"github.com/graphql-go/graphql/gqlerrors"
+	"github.com/graphql-go/graphql/language/ast"
+	"github.com/graphql-go/graphql/language/parser"
+	"github.com/graphql-go/graphql/language/source"
+)
+
+// graphqlParams is a GraphQL request, the JSON body of a POST or the query string of a GET
+type graphqlParams struct {
+	Query         string                 `json:"query"`
+	OperationName string                 `json:"operationName"`
+	Variables     map[string]interface{} `json:"variables"`
+}
+
+// GraphQL executes a query or mutation against the employee schema. Requests that do not parse,
+// fail validation or exceed the depth and complexity limits are answered with 400 before anything
+// is resolved, errors while resolving are reported next to the data with 200.
+func (srv *Server) GraphQL(c *fiber.Ctx) error {
+	var params graphqlParams
+	if c.Method() == fiber.MethodGet {
+		params.Query = c.Query("query")
+		params.OperationName = c.Query("operationName")
+		if variables := c.Query("variables"); variables != "" {
+			if err := json.Unmarshal([]byte(variables), &params.Variables); err != nil {
+				return graphqlRequestFailed(c, "variables must be a JSON object", "BAD_REQUEST")
+			}
+		}
+	} else if err := json.Unmarshal(c.Body(), &params); err != nil {
+		return graphqlRequestFailed(c, "body must be a JSON object with a query", "BAD_REQUEST")
+	}
+	if strings.TrimSpace(params.Query) == "" {
+		return graphqlRequestFailed(c, "query is required", "BAD_REQUEST")
+	}
+
+	document, err := parser.Parse(parser.ParseParams{Source: source.NewSource(&source.Source{Body: []byte(params.Query), Name: "GraphQL request"})})
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"errors": gqlerrors.FormatErrors(err)})
+	}
+	if validation := graphql.ValidateDocument(&employeeSchema, document, nil); !validation.IsValid {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"errors": validation.Errors})
+	}
+
+	operation := operationOf(document, params.OperationName)
+	if operation == nil {
+		return graphqlRequestFailed(c, "operation not found", "BAD_REQUEST")
+	}
+	// mutations change data, so they are not accepted from links and other GET requests
+	if operation.Operation == ast.OperationTypeMutation && c.Method() == fiber.MethodGet {
+		c.Set(fiber.HeaderAllow, fiber.MethodPost)
+		return graphqlRequestFailed(c, "mutations must be sent with POST", "METHOD_NOT_ALLOWED", fiber.StatusMethodNotAllowed)
+	}
+
+	cost := queryCost{fragments: fragmentsOf(document), variables: params.Variables, visiting: map[string]bool{}}
+	depth, complexity := cost.measure(operation.SelectionSet, 0)
+	if depth > srv.Config.GraphQL.MaxDepth {
+		return graphqlRequestFailed(c, fmt.Sprintf("query depth %d exceeds the limit of %d", depth, srv.Config.GraphQL.MaxDepth), "QUERY_TOO_DEEP")
+	}
+	if complexity > srv.Config.GraphQL.MaxComplexity {
+		return graphqlRequestFailed(c, fmt.Sprintf("query complexity %d exceeds the limit of %d", complexity, srv.Config.GraphQL.MaxComplexity), "QUERY_TOO_COMPLEX")
+	}
+
+	request := srv.newGraphQLRequest(c)
+
+	// Use a channel to communicate the result back from the goroutine
+	resultChan := make(chan *graphql.Result, 1)
+
+	// Start a goroutine to resolve the operation
+	go func() {
+		resultChan <- graphql.Execute(graphql.ExecuteParams{
+			Schema:        employeeSchema,
+			AST:           document,
+			OperationName: params.OperationName,
+			Args:          params.Variables,
+			Context:       context.WithValue(context.Background(), graphqlRequestKey{}, request),
+		})
+	}()
+
+	// Wait for the operation to complete
+	result := <-resultChan
+	for i := range result.Errors {
+		result.Errors[i].Extensions = extensionsOf(result.Errors[i])
+	}
+	if request.wrote {
+		markWrite(c)
+	}
+	return c.Status(fiber.StatusOK).JSON(result)
+}
+
+// graphqlRequestFailed answers a request that was rejected before execution, with 400 unless another status is given
+func graphqlRequestFailed(c *fiber.Ctx, message, code string, status ...int) error {
+	httpStatus := fiber.StatusBadRequest
+	if len(status) > 0 {
+		httpStatus = status[0]
+	}
+	return c.Status(httpStatus).JSON(fiber.Map{"errors": []fiber.Map{{"message": message, "extensions": fiber.Map{"code": code}}}})
+}
+
+// operationOf returns the named operation of document, or its only one when name is empty
+func operationOf(document *ast.Document, name string) *ast.OperationDefinition {
+	var found *ast.OperationDefinition
+	for _, definition := range document.Definitions {
+		operation, ok := definition.(*ast.OperationDefinition)
+		if !ok {
+			continue
+		}
+		if name == "" && found != nil {
+			return nil
+		}
+		if name == "" || (operation.Name != nil && operation.Name.Value == name) {
+			found = operation
+		}
+	}
+	return found
+}
+
+func fragmentsOf(document *ast.Document) map[string]*ast.FragmentDefinition {
+	fragments := map[string]*ast.FragmentDefinition{}
+	for _, definition := range document.Definitions {
+		if fragment, ok := definition.(*ast.FragmentDefinition); ok {
+			fragments[fragment.Name.Value] = fragment
+		}
+	}
+	return fragments
+}
+
+// pagedFields are the fields returning a page of items with the page size used when first is not set.
+// What is selected below them is resolved once per item, so it counts that many times.
+var pagedFields = map[string]int{
+	"employees": defaultGraphQLPageSize,
+}
+
+// queryCost measures how deep an operation nests and how many fields it resolves. Every field counts
+// once, fields below a paged field count once per requested item, and introspection is free.
+type queryCost struct {
+	fragments map[string]*ast.FragmentDefinition
+	variables map[string]interface{}
+	visiting  map[string]bool
+}
+
+func (q queryCost) measure(set *ast.SelectionSet, depth int) (maxDepth, complexity int) {
+	maxDepth = depth
+	if set == nil {
+		return maxDepth, 0
+	}
+
+	for _, selection := range set.Selections {
+		var childDepth, childComplexity int
+		switch selection := selection.(type) {
+		case *ast.Field:
+			if strings.HasPrefix(selection.Name.Value, "__") {
+				continue
+			}
+			childDepth, childComplexity = q.measure(selection.SelectionSet, depth+1)
+			if pageSize, paged := pagedFields[selection.Name.Value]; paged {
+				childComplexity *= q.intArgument(selection, "first", pageSize)
+			}
+			childComplexity++
+		case *ast.InlineFragment:
+			childDepth, childComplexity = q.measure(selection.SelectionSet, depth)
+		case *ast.FragmentSpread:
+			name := selection.Name.Value
+			fragment := q.fragments[name]
+			if fragment == nil || q.visiting[name] {
+				continue
+			}
+			q.visiting[name] = true
+			childDepth, childComplexity = q.measure(fragment.SelectionSet, depth)
+			delete(q.visiting, name)
+		}
+		if childDepth > maxDepth {
+			maxDepth = childDepth
+		}
+		complexity += childComplexity
+	}
+	return maxDepth, complexity
+}
+
+// intArgument reads an integer argument given inline or as a variable
+func (q queryCost) intArgument(field *ast.Field, name string, fallback int) int {
+	for _, argument := range field.Arguments {
+		if argument.Name.Value != name {
+			continue
+		}
+		switch value := argument.Value.(type) {
+		case *ast.IntValue:
+			if n, err := strconv.Atoi(value.Value); err == nil {
+				return n
+			}
+		case *ast.Variable:
+			switch n := q.variables[value.Name.Value].(type) {
+			case float64:
+				return int(n)
+			case int:
+				return n
+			}
+		}
+	}
+	return fallback
+}
+
+// graphqlRequest is what the resolvers of one request share, reached through graphqlRequestKey
+type graphqlRequest struct {
+	srv       *Server
+	principal models.Principal
+	// reads go through reader, which honours the read-your-writes hint of the request
+	reader    providers.DbHelperProvider
+	employees *employeeLoader
+	// wrote is set by mutations, so the response carries the read-your-writes hint
+	wrote bool
+}
+
+type graphqlRequestKey struct{}
+
+func (srv *Server) newGraphQLRequest(c *fiber.Ctx) *graphqlRequest {
+	reader := srv.readHelper(c)
+	return &graphqlRequest{
+		srv:       srv,
+		principal: principalOf(c),
+		reader:    reader,
+		employees: newEmployeeLoader(reader),
+	}
+}
+
+func requestOf(ctx context.Context) *graphqlRequest {
+	return ctx.Value(graphqlRequestKey{}).(*graphqlRequest)
+}
+
+// graphqlError is an error reported to the client with a code and other details in its extensions
+type graphqlError struct {
+	message    string
+	extensions map[string]interface{}
+}
+
+func (e graphqlError) Error() string {
+	return e.message
+}
+
+func (e graphqlError) Extensions() map[string]interface{} {
+	return e.extensions
+}
+
+// extensionsOf finds the extensions of the error a resolver returned. The executor only copies them
+// for errors returned directly, those returned from a thunk are wrapped once more.
+func extensionsOf(err gqlerrors.FormattedError) map[string]interface{} {
+	if err.Extensions != nil {
+		return err.Extensions
+	}
+	var cause error = err
+	for cause != nil {
+		if extended, ok := cause.(gqlerrors.ExtendedError); ok {
+			return extended.Extensions()
+		}
+		switch wrapper := cause.(type) {
+		case gqlerrors.FormattedError:
+			cause = wrapper.OriginalError()
+		case *gqlerrors.Error:
+			cause = wrapper.OriginalError
+		default:
+			cause = nil
+		}
+	}
+	return nil
+}
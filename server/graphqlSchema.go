@@ -0,0 +1,571 @@
+package server
+
+import (
+	"Techiebulter/interview/backend/models"
+	"Techiebulter/interview/backend/providers"
+	"Techiebulter/interview/backend/utils/dataloader"
+	"encoding/base64"
+	"errors"
+	"log"
+	"strconv"
+	"strings"
+
+	"github.com/graphql-go/graphql"
+	"github.com/graphql-go/graphql/language/ast"
+)
+
+// Page sizes of the employees connection
+const (
+	defaultGraphQLPageSize = 20
+	maxGraphQLPageSize     = 100
+)
+
+// employeeCursorPrefix makes cursors opaque, clients must not build them
+const employeeCursorPrefix = "employee:"
+
+// employeeLoader batches the employee lookups of one GraphQL request into a single query
+type employeeLoader = dataloader.Loader[int, models.Employee]
+
+func newEmployeeLoader(dbHelper providers.DbHelperProvider) *employeeLoader {
+	return dataloader.New(func(ids []int) map[int]dataloader.Result[models.Employee] {
+		results := make(map[int]dataloader.Result[models.Employee], len(ids))
+		employees, err := dbHelper.GetEmployeesByIds(ids)
+		if err == nil {
+			employees, err = withLeaveStatus(dbHelper, employees...)
+		}
+		if err != nil {
+			for _, id := range ids {
+				results[id] = dataloader.Result[models.Employee]{Err: err}
+			}
+			return results
+		}
+		for _, employee := range employees {
+			results[employee.ID] = dataloader.Result[models.Employee]{Value: employee}
+		}
+
+		// IDs that were not found may have been merged, the single lookup tells which
+		for _, id := range ids {
+			if _, found := results[id]; !found {
+				_, err := dbHelper.GetEmployeeById(id)
+				results[id] = dataloader.Result[models.Employee]{Err: err}
+			}
+		}
+		return results
+	})
+}
+
+// dateScalar is a calendar date like 2024-01-31
+var dateScalar = graphql.NewScalar(graphql.ScalarConfig{
+	Name:        "Date",
+	Description: "A calendar date like 2024-01-31",
+	Serialize: func(value interface{}) interface{} {
+		switch value := value.(type) {
+		case models.Date:
+			return value.String()
+		case *models.Date:
+			if value != nil {
+				return value.String()
+			}
+		}
+		return nil
+	},
+	ParseValue: func(value interface{}) interface{} {
+		if text, ok := value.(string); ok {
+			if date, err := models.ParseDate(text); err == nil {
+				return date
+			}
+		}
+		return nil
+	},
+	ParseLiteral: func(value ast.Value) interface{} {
+		if text, ok := value.(*ast.StringValue); ok {
+			if date, err := models.ParseDate(text.Value); err == nil {
+				return date
+			}
+		}
+		return nil
+	},
+})
+
+// jsonScalar carries the custom fields, any JSON value
+var jsonScalar = graphql.NewScalar(graphql.ScalarConfig{
+	Name:        "JSON",
+	Description: "Any JSON value",
+	Serialize:   func(value interface{}) interface{} { return value },
+	ParseValue:  func(value interface{}) interface{} { return value },
+	ParseLiteral: func(value ast.Value) interface{} {
+		return literalValue(value)
+	},
+})
+
+// literalValue converts a literal of the query to the value encoding/json would decode
+func literalValue(value ast.Value) interface{} {
+	switch value := value.(type) {
+	case *ast.StringValue:
+		return value.Value
+	case *ast.EnumValue:
+		return value.Value
+	case *ast.BooleanValue:
+		return value.Value
+	case *ast.IntValue:
+		n, _ := strconv.ParseFloat(value.Value, 64)
+		return n
+	case *ast.FloatValue:
+		n, _ := strconv.ParseFloat(value.Value, 64)
+		return n
+	case *ast.ListValue:
+		list := make([]interface{}, len(value.Values))
+		for i, item := range value.Values {
+			list[i] = literalValue(item)
+		}
+		return list
+	case *ast.ObjectValue:
+		object := make(map[string]interface{}, len(value.Fields))
+		for _, field := range value.Fields {
+			object[field.Name.Value] = literalValue(field.Value)
+		}
+		return object
+	}
+	return nil
+}
+
+var employeeStatusEnum = graphql.NewEnum(graphql.EnumConfig{
+	Name: "EmployeeStatus",
+	Values: graphql.EnumValueConfigMap{
+		"ACTIVE":     {Value: models.StatusActive},
+		"ON_LEAVE":   {Value: models.StatusOnLeave},
+		"TERMINATED": {Value: models.StatusTerminated},
+	},
+})
+
+var employmentTypeEnum = graphql.NewEnum(graphql.EnumConfig{
+	Name: "EmploymentType",
+	Values: graphql.EnumValueConfigMap{
+		"FULL_TIME":  {Value: models.EmploymentFullTime},
+		"PART_TIME":  {Value: models.EmploymentPartTime},
+		"CONTRACTOR": {Value: models.EmploymentContractor},
+	},
+})
+
+// employeeField resolves a field of the employee being resolved
+func employeeField(fieldType graphql.Output, value func(e models.Employee) interface{}) *graphql.Field {
+	return &graphql.Field{Type: fieldType, Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+		return value(p.Source.(models.Employee)), nil
+	}}
+}
+
+// optional turns empty text into null
+func optional(s string) interface{} {
+	if s == "" {
+		return nil
+	}
+	return s
+}
+
+var employeeType = graphql.NewObject(graphql.ObjectConfig{
+	Name: "Employee",
+	Fields: graphql.Fields{
+		"id":              employeeField(graphql.NewNonNull(graphql.Int), func(e models.Employee) interface{} { return e.ID }),
+		"name":            employeeField(graphql.NewNonNull(graphql.String), func(e models.Employee) interface{} { return e.Name }),
+		"position":        employeeField(graphql.NewNonNull(graphql.String), func(e models.Employee) interface{} { return e.Position }),
+		"salary":          employeeField(graphql.NewNonNull(graphql.Float), func(e models.Employee) interface{} { return e.Salary.Float64() }),
+		"currency":        employeeField(graphql.NewNonNull(graphql.String), func(e models.Employee) interface{} { return e.Currency }),
+		"email":           employeeField(graphql.String, func(e models.Employee) interface{} { return optional(e.Email) }),
+		"phone":           employeeField(graphql.String, func(e models.Employee) interface{} { return optional(e.Phone) }),
+		"hireDate":        employeeField(dateScalar, func(e models.Employee) interface{} { return e.HireDate }),
+		"terminationDate": employeeField(dateScalar, func(e models.Employee) interface{} { return e.TerminationDate }),
+		"employmentType":  employeeField(graphql.NewNonNull(employmentTypeEnum), func(e models.Employee) interface{} { return e.EmploymentType }),
+		"status":          employeeField(graphql.NewNonNull(employeeStatusEnum), func(e models.Employee) interface{} { return e.Status }),
+		"location":        employeeField(graphql.String, func(e models.Employee) interface{} { return optional(e.Location) }),
+		"department":      employeeField(graphql.String, func(e models.Employee) interface{} { return optional(e.Department) }),
+		"managerId":       employeeField(graphql.Int, func(e models.Employee) interface{} { return e.ManagerID }),
+		"customFields": employeeField(graphql.NewNonNull(jsonScalar), func(e models.Employee) interface{} {
+			if e.CustomFields == nil {
+				return map[string]interface{}{}
+			}
+			return map[string]interface{}(e.CustomFields)
+		}),
+		"createdAt": employeeField(graphql.NewNonNull(graphql.DateTime), func(e models.Employee) interface{} { return e.CreatedAt }),
+		"updatedAt": employeeField(graphql.NewNonNull(graphql.DateTime), func(e models.Employee) interface{} { return e.UpdatedAt }),
+	},
+})
+
+// employeePage is the source of an EmployeeConnection
+type employeePage struct {
+	employees   []models.Employee
+	hasNextPage bool
+}
+
+func employeeCursor(id int) string {
+	return base64.StdEncoding.EncodeToString([]byte(employeeCursorPrefix + strconv.Itoa(id)))
+}
+
+func parseEmployeeCursor(cursor string) (int, bool) {
+	decoded, err := base64.StdEncoding.DecodeString(cursor)
+	if err != nil || !strings.HasPrefix(string(decoded), employeeCursorPrefix) {
+		return 0, false
+	}
+	id, err := strconv.Atoi(strings.TrimPrefix(string(decoded), employeeCursorPrefix))
+	return id, err == nil
+}
+
+var employeeEdgeType = graphql.NewObject(graphql.ObjectConfig{
+	Name: "EmployeeEdge",
+	Fields: graphql.Fields{
+		"cursor": employeeField(graphql.NewNonNull(graphql.String), func(e models.Employee) interface{} { return employeeCursor(e.ID) }),
+		"node":   employeeField(graphql.NewNonNull(employeeType), func(e models.Employee) interface{} { return e }),
+	},
+})
+
+var pageInfoType = graphql.NewObject(graphql.ObjectConfig{
+	Name: "PageInfo",
+	Fields: graphql.Fields{
+		"hasNextPage": &graphql.Field{Type: graphql.NewNonNull(graphql.Boolean), Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+			return p.Source.(employeePage).hasNextPage, nil
+		}},
+		"endCursor": &graphql.Field{Type: graphql.String, Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+			page := p.Source.(employeePage)
+			if len(page.employees) == 0 {
+				return nil, nil
+			}
+			return employeeCursor(page.employees[len(page.employees)-1].ID), nil
+		}},
+	},
+})
+
+var employeeConnectionType = graphql.NewObject(graphql.ObjectConfig{
+	Name: "EmployeeConnection",
+	Fields: graphql.Fields{
+		"edges": &graphql.Field{Type: graphql.NewNonNull(graphql.NewList(graphql.NewNonNull(employeeEdgeType))), Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+			return p.Source.(employeePage).employees, nil
+		}},
+		"pageInfo": &graphql.Field{Type: graphql.NewNonNull(pageInfoType), Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+			return p.Source, nil
+		}},
+	},
+})
+
+var employeeFilterType = graphql.NewInputObject(graphql.InputObjectConfig{
+	Name: "EmployeeFilter",
+	Fields: graphql.InputObjectConfigFieldMap{
+		"nameContains":   {Type: graphql.String, Description: "Part of the name, ignoring case"},
+		"position":       {Type: graphql.String},
+		"status":         {Type: employeeStatusEnum},
+		"employmentType": {Type: employmentTypeEnum},
+		"location":       {Type: graphql.String},
+		"department":     {Type: graphql.String},
+		"hiredFrom":      {Type: dateScalar, Description: "Earliest hire date, inclusive"},
+		"hiredTo":        {Type: dateScalar, Description: "Latest hire date, inclusive"},
+	},
+})
+
+var employeeInputType = graphql.NewInputObject(graphql.InputObjectConfig{
+	Name:        "EmployeeInput",
+	Description: "Fields of an employee. New employees need name, position and salary, updates change only the fields that are set.",
+	Fields: graphql.InputObjectConfigFieldMap{
+		"name":            {Type: graphql.String},
+		"position":        {Type: graphql.String},
+		"salary":          {Type: graphql.Float},
+		"currency":        {Type: graphql.String},
+		"email":           {Type: graphql.String},
+		"phone":           {Type: graphql.String},
+		"hireDate":        {Type: dateScalar},
+		"terminationDate": {Type: dateScalar},
+		"employmentType":  {Type: employmentTypeEnum},
+		"status":          {Type: employeeStatusEnum},
+		"location":        {Type: graphql.String},
+		"department":      {Type: graphql.String},
+		"managerId":       {Type: graphql.Int, Description: "ID of the employee managing this one"},
+		"customFields":    {Type: jsonScalar},
+	},
+})
+
+// employeeFromInput reads an EmployeeInput, fields that are not set keep their zero value. Salaries that
+// are not a whole number of cents are reported as validation errors.
+func employeeFromInput(input map[string]interface{}) (models.Employee, models.ValidationErrors) {
+	var errs models.ValidationErrors
+	var employee models.Employee
+	text := func(name string) string {
+		s, _ := input[name].(string)
+		return s
+	}
+	date := func(name string) *models.Date {
+		if d, ok := input[name].(models.Date); ok {
+			return &d
+		}
+		return nil
+	}
+	employee.Name = text("name")
+	employee.Position = text("position")
+	if salary, ok := input["salary"].(float64); ok {
+		var err error
+		employee.Salary, err = models.ExactMoney(salary)
+		var amountErr *models.AmountError
+		if errors.As(err, &amountErr) {
+			errs = append(errs, amountErr.FieldError("Salary"))
+		}
+	}
+	employee.Currency = models.Currency(text("currency"))
+	employee.Email = text("email")
+	employee.Phone = text("phone")
+	employee.HireDate = date("hireDate")
+	employee.TerminationDate = date("terminationDate")
+	employee.EmploymentType, _ = input["employmentType"].(models.EmploymentType)
+	employee.Status, _ = input["status"].(models.EmployeeStatus)
+	employee.Location = text("location")
+	employee.Department = text("department")
+	if managerID, ok := input["managerId"].(int); ok {
+		employee.ManagerID = &managerID
+	}
+	if customFields, ok := input["customFields"].(map[string]interface{}); ok {
+		employee.CustomFields = customFields
+	}
+	return employee, errs
+}
+
+func filterFromInput(input map[string]interface{}) models.EmployeeFilter {
+	var filter models.EmployeeFilter
+	filter.NameContains, _ = input["nameContains"].(string)
+	filter.Position, _ = input["position"].(string)
+	filter.Status, _ = input["status"].(models.EmployeeStatus)
+	filter.EmploymentType, _ = input["employmentType"].(models.EmploymentType)
+	filter.Location, _ = input["location"].(string)
+	filter.Department, _ = input["department"].(string)
+	if d, ok := input["hiredFrom"].(models.Date); ok {
+		filter.HiredFrom = &d
+	}
+	if d, ok := input["hiredTo"].(models.Date); ok {
+		filter.HiredTo = &d
+	}
+	return filter
+}
+
+var queryType = graphql.NewObject(graphql.ObjectConfig{
+	Name: "Query",
+	Fields: graphql.Fields{
+		"employee": &graphql.Field{
+			Type:        employeeType,
+			Description: "The employee with the ID, null if there is none",
+			Args:        graphql.FieldConfigArgument{"id": {Type: graphql.NewNonNull(graphql.Int)}},
+			Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+				request := requestOf(p.Context)
+				if err := requireSelfOrHR(request, p.Args["id"].(int)); err != nil {
+					return nil, err
+				}
+				load := request.employees.Load(p.Args["id"].(int))
+				return func() (interface{}, error) {
+					employee, err := load()
+					if errors.Is(err, providers.ErrEmployeeNotFound) {
+						return nil, nil
+					}
+					if err != nil {
+						return nil, graphqlErrorOf("employee", err)
+					}
+					return employee, nil
+				}, nil
+			},
+		},
+		"employees": &graphql.Field{
+			Type:        graphql.NewNonNull(employeeConnectionType),
+			Description: "Employees matching filter by ID, a page of first employees after the cursor",
+			Args: graphql.FieldConfigArgument{
+				"filter": {Type: employeeFilterType},
+				"first":  {Type: graphql.Int, DefaultValue: defaultGraphQLPageSize},
+				"after":  {Type: graphql.String},
+			},
+			Resolve: resolveEmployees,
+		},
+	},
+})
+
+func resolveEmployees(p graphql.ResolveParams) (interface{}, error) {
+	request := requestOf(p.Context)
+	if err := requireHR(request); err != nil {
+		return nil, err
+	}
+
+	first, _ := p.Args["first"].(int)
+	if first < 1 || first > maxGraphQLPageSize {
+		return nil, graphqlErrorOf("employees", models.ValidationErrors{{Field: "first", Code: models.CodeOutOfRange,
+			Message: "first must be between 1 and " + strconv.Itoa(maxGraphQLPageSize)}})
+	}
+	afterID := 0
+	if after, ok := p.Args["after"].(string); ok {
+		if afterID, ok = parseEmployeeCursor(after); !ok {
+			return nil, graphqlErrorOf("employees", models.ValidationErrors{{Field: "after", Code: models.CodeInvalidFormat,
+				Message: "after is not a cursor returned by employees"}})
+		}
+	}
+	filter, _ := p.Args["filter"].(map[string]interface{})
+
+	// one more than requested tells whether there is a next page
+	employees, err := request.reader.SearchEmployees(filterFromInput(filter), afterID, first+1)
+	if err == nil {
+		employees, err = withLeaveStatus(request.reader, employees...)
+	}
+	if err != nil {
+		return nil, graphqlErrorOf("employees", err)
+	}
+	page := employeePage{employees: employees, hasNextPage: len(employees) > first}
+	if page.hasNextPage {
+		page.employees = employees[:first]
+	}
+	for _, employee := range page.employees {
+		request.employees.Prime(employee.ID, employee)
+	}
+	return page, nil
+}
+
+// requireHR rejects mutations and listings from principals that are not HR, like hrOnly does for REST
+func requireHR(request *graphqlRequest) error {
+	if !request.principal.HasRole(models.RoleAdmin, models.RoleHR) {
+		return graphqlError{message: "insufficient permissions", extensions: map[string]interface{}{"code": "FORBIDDEN"}}
+	}
+	return nil
+}
+
+// requireSelfOrHR also lets employees read themselves, like selfOrHR does for REST
+func requireSelfOrHR(request *graphqlRequest, id int) error {
+	if request.principal.EmployeeID != 0 && request.principal.EmployeeID == id {
+		return nil
+	}
+	return requireHR(request)
+}
+
+var mutationType = graphql.NewObject(graphql.ObjectConfig{
+	Name: "Mutation",
+	Fields: graphql.Fields{
+		"createEmployee": &graphql.Field{
+			Type: graphql.NewNonNull(employeeType),
+			Args: graphql.FieldConfigArgument{"input": {Type: graphql.NewNonNull(employeeInputType)}},
+			Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+				request := requestOf(p.Context)
+				if err := requireHR(request); err != nil {
+					return nil, err
+				}
+
+				// Check the fields of the employee, required custom fields included
+				employee, errs := employeeFromInput(p.Args["input"].(map[string]interface{}))
+				if len(errs) > 0 {
+					return nil, graphqlErrorOf("createEmployee", errs)
+				}
+				errs, err := request.srv.validateEmployee(&employee, models.EmployeeCreateRules, true)
+				if err != nil {
+					return nil, graphqlErrorOf("createEmployee", err)
+				}
+				if len(errs) > 0 {
+					return nil, graphqlErrorOf("createEmployee", errs)
+				}
+				// salaries outside a warning band are stored, the warning is only answered over REST
+				if _, errs, err = request.srv.checkSalaryBand(employee, true); err != nil {
+					return nil, graphqlErrorOf("createEmployee", err)
+				}
+				if len(errs) > 0 {
+					return nil, graphqlErrorOf("createEmployee", errs)
+				}
+
+				created, err := request.srv.DBHelper.InsertEmployee(employee)
+				if err != nil {
+					return nil, graphqlErrorOf("createEmployee", err)
+				}
+				request.wrote = true
+				request.employees.Prime(created.ID, created)
+				return created, nil
+			},
+		},
+		"updateEmployee": &graphql.Field{
+			Type: graphql.NewNonNull(employeeType),
+			Args: graphql.FieldConfigArgument{
+				"id":    {Type: graphql.NewNonNull(graphql.Int)},
+				"input": {Type: graphql.NewNonNull(employeeInputType)},
+			},
+			Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+				request := requestOf(p.Context)
+				if err := requireHR(request); err != nil {
+					return nil, err
+				}
+
+				// Check the fields being changed, custom fields are replaced as a whole so they are only checked when sent
+				employee, errs := employeeFromInput(p.Args["input"].(map[string]interface{}))
+				if len(errs) > 0 {
+					return nil, graphqlErrorOf("updateEmployee", errs)
+				}
+				employee.ID = p.Args["id"].(int)
+				errs, err := request.srv.validateEmployee(&employee, models.EmployeeUpdateRules, false)
+				if err != nil {
+					return nil, graphqlErrorOf("updateEmployee", err)
+				}
+				if len(errs) > 0 {
+					return nil, graphqlErrorOf("updateEmployee", errs)
+				}
+				// salaries outside a warning band are stored, the warning is only answered over REST
+				if _, errs, err = request.srv.checkSalaryBand(employee, false); err != nil {
+					return nil, graphqlErrorOf("updateEmployee", err)
+				}
+				if len(errs) > 0 {
+					return nil, graphqlErrorOf("updateEmployee", errs)
+				}
+
+				updated, err := request.srv.DBHelper.UpdateEmployee(employee)
+				if err != nil {
+					return nil, graphqlErrorOf("updateEmployee", err)
+				}
+				request.wrote = true
+				updated = request.srv.writtenWithLeaveStatus(updated)
+				request.employees.Prime(updated.ID, updated)
+				return updated, nil
+			},
+		},
+		"deleteEmployee": &graphql.Field{
+			Type:        graphql.NewNonNull(graphql.Boolean),
+			Description: "Deletes the employee",
+			Args:        graphql.FieldConfigArgument{"id": {Type: graphql.NewNonNull(graphql.Int)}},
+			Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+				request := requestOf(p.Context)
+				if err := requireHR(request); err != nil {
+					return nil, err
+				}
+				if err := request.srv.DBHelper.DeleteEmployeeById(p.Args["id"].(int)); err != nil {
+					return nil, graphqlErrorOf("deleteEmployee", err)
+				}
+				request.wrote = true
+				return true, nil
+			},
+		},
+	},
+})
+
+// employeeSchema is served at /graphql
+var employeeSchema = func() graphql.Schema {
+	schema, err := graphql.NewSchema(graphql.SchemaConfig{Query: queryType, Mutation: mutationType})
+	if err != nil {
+		panic(err)
+	}
+	return schema
+}()
+
+// graphqlErrorOf maps the errors of the providers package to error codes, the way the REST handlers map them to HTTP statuses
+func graphqlErrorOf(field string, err error) error {
+	var (
+		errs        models.ValidationErrors
+		merged      *providers.EmployeeMergedError
+		missingRate *models.MissingRateError
+		overflow    *models.OverflowError
+	)
+	switch {
+	case errors.As(err, &errs):
+		return graphqlError{message: errs.Error(), extensions: map[string]interface{}{"code": "BAD_USER_INPUT", "errors": errs}}
+	case errors.As(err, &merged):
+		return graphqlError{message: err.Error(), extensions: map[string]interface{}{"code": "EMPLOYEE_MERGED", "mergedInto": merged.MergedInto}}
+	case errors.Is(err, providers.ErrEmployeeNotFound):
+		return graphqlError{message: err.Error(), extensions: map[string]interface{}{"code": "NOT_FOUND"}}
+	case errors.Is(err, providers.ErrEmailTaken), errors.As(err, &missingRate), errors.As(err, &overflow):
+		return graphqlError{message: err.Error(), extensions: map[string]interface{}{"code": "CONFLICT"}}
+	case errors.Is(err, providers.ErrTerminationBeforeHire):
+		return graphqlErrorOf(field, models.ValidationErrors{{Field: "terminationDate", Code: models.CodeOutOfRange, Message: err.Error()}})
+	case errors.Is(err, providers.ErrManagerNotFound):
+		return graphqlErrorOf(field, models.ValidationErrors{{Field: "managerId", Code: models.CodeInvalidValue, Message: err.Error()}})
+	}
+	log.Println(field+": error in the database operation", err)
+	return graphqlError{message: "internal error", extensions: map[string]interface{}{"code": "INTERNAL"}}
+}
@@ -0,0 +1,219 @@
+package server
+
+import (
+	"Techiebulter/interview/backend/models"
+	"Techiebulter/interview/backend/proto/employeepb"
+	"Techiebulter/interview/backend/providers"
+	"context"
+	"errors"
+	"log"
+	"strconv"
+	"strings"
+	"time"
+
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/health"
+	healthpb "google.golang.org/grpc/health/grpc_health_v1"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/reflection"
+	"google.golang.org/grpc/status"
+)
+
+// employeeServicePrefix starts the full method names that require an API key, health checks and reflection do not
+const employeeServicePrefix = "/employee.v1.EmployeeService/"
+
+// grpcHRMethods need the admin or hr role, like the REST routes guarded by hrOnly. GetEmployee also lets
+// employees read themselves, see grpcRequireSelfOrHR.
+var grpcHRMethods = map[string]bool{
+	employeepb.EmployeeService_CreateEmployee_FullMethodName: true,
+	employeepb.EmployeeService_UpdateEmployee_FullMethodName: true,
+	employeepb.EmployeeService_DeleteEmployee_FullMethodName: true,
+	employeepb.EmployeeService_ListEmployees_FullMethodName:  true,
+	employeepb.EmployeeService_WatchEmployees_FullMethodName: true,
+}
+
+// principalContextKey holds the authenticated models.Principal of a gRPC call
+type principalContextKey struct{}
+
+// newGRPCServer serves the employee service on top of the server's DBHelper, with health checking
+// and, when configured, reflection
+func (srv *Server) newGRPCServer() *grpc.Server {
+	grpcServer := grpc.NewServer(
+		grpc.UnaryInterceptor(srv.authenticateUnary),
+		grpc.StreamInterceptor(srv.authenticateStream),
+	)
+	employeepb.RegisterEmployeeServiceServer(grpcServer, &employeeService{srv: srv})
+
+	srv.grpcHealth = health.NewServer()
+	srv.grpcHealth.SetServingStatus(employeepb.EmployeeService_ServiceDesc.ServiceName, healthpb.HealthCheckResponse_SERVING)
+	healthpb.RegisterHealthServer(grpcServer, srv.grpcHealth)
+
+	if srv.Config.GRPC.Reflection {
+		reflection.Register(grpcServer)
+	}
+	return grpcServer
+}
+
+// reportGRPCHealth mirrors the database health into the gRPC health service until the server stops
+func (srv *Server) reportGRPCHealth() {
+	interval := srv.Config.Database.HealthCheckInterval
+	if interval <= 0 {
+		interval = 15 * time.Second
+	}
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		serving := healthpb.HealthCheckResponse_SERVING
+		if !srv.PGClient.Healthy() {
+			serving = healthpb.HealthCheckResponse_NOT_SERVING
+		}
+		srv.grpcHealth.SetServingStatus("", serving)
+		srv.grpcHealth.SetServingStatus(employeepb.EmployeeService_ServiceDesc.ServiceName, serving)
+
+		select {
+		case <-srv.stopping:
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+func (srv *Server) authenticateUnary(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+	ctx, err := srv.authorizeCall(ctx, info.FullMethod)
+	if err != nil {
+		return nil, err
+	}
+	return handler(ctx, req)
+}
+
+func (srv *Server) authenticateStream(service interface{}, stream grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+	ctx, err := srv.authorizeCall(stream.Context(), info.FullMethod)
+	if err != nil {
+		return err
+	}
+	return handler(service, &authenticatedStream{ServerStream: stream, ctx: ctx})
+}
+
+// authorizeCall resolves the API key of the call like Authenticate and checks the role like RequireRole
+func (srv *Server) authorizeCall(ctx context.Context, fullMethod string) (context.Context, error) {
+	if !strings.HasPrefix(fullMethod, employeeServicePrefix) {
+		return ctx, nil
+	}
+
+	principal := models.Principal{Role: models.RoleAdmin}
+	if srv.Config.Auth.Enabled {
+		md, _ := metadata.FromIncomingContext(ctx)
+		var key string
+		if values := md.Get("x-api-key"); len(values) > 0 {
+			key = values[0]
+		} else if values := md.Get("authorization"); len(values) > 0 {
+			key = strings.TrimPrefix(values[0], "Bearer ")
+		}
+
+		var ok bool
+		if principal, ok = srv.lookupAPIKey(key); !ok {
+			return ctx, status.Error(codes.Unauthenticated, "missing or invalid API key")
+		}
+	}
+
+	if grpcHRMethods[fullMethod] && !principal.HasRole(models.RoleAdmin, models.RoleHR) {
+		return ctx, status.Error(codes.PermissionDenied, "insufficient permissions")
+	}
+	return context.WithValue(ctx, principalContextKey{}, principal), nil
+}
+
+// grpcRequireSelfOrHR lets principals holding the admin or hr role through, and employees to their own id,
+// like RequireSelfOrRole
+func grpcRequireSelfOrHR(ctx context.Context, id int) error {
+	principal, _ := ctx.Value(principalContextKey{}).(models.Principal)
+	if !principal.HasRole(models.RoleAdmin, models.RoleHR) && (principal.EmployeeID == 0 || principal.EmployeeID != id) {
+		return status.Error(codes.PermissionDenied, "insufficient permissions")
+	}
+	return nil
+}
+
+// authenticatedStream carries the context holding the principal into stream handlers
+type authenticatedStream struct {
+	grpc.ServerStream
+	ctx context.Context
+}
+
+func (s *authenticatedStream) Context() context.Context {
+	return s.ctx
+}
+
+// grpcFieldNames maps the JSON field names of validation errors to the fields of employeepb.Employee
+var grpcFieldNames = map[string]string{
+	"ID":              "id",
+	"Name":            "name",
+	"position":        "position",
+	"Salary":          "salary",
+	"email":           "email",
+	"phone":           "phone",
+	"hireDate":        "hire_date",
+	"terminationDate": "termination_date",
+	"employmentType":  "employment_type",
+	"status":          "status",
+	"location":        "location",
+	"currency":        "currency",
+}
+
+// invalidEmployee reports validation errors of the employee in a request as InvalidArgument with field violations
+func invalidEmployee(errs models.ValidationErrors) error {
+	violations := make([]*errdetails.BadRequest_FieldViolation, len(errs))
+	for i, fieldErr := range errs {
+		field := grpcFieldNames[fieldErr.Field]
+		if strings.HasPrefix(fieldErr.Field, "customFields.") {
+			field = "custom_fields." + strings.TrimPrefix(fieldErr.Field, "customFields.")
+		}
+		if field == "" {
+			field = fieldErr.Field
+		}
+		violations[i] = &errdetails.BadRequest_FieldViolation{Field: "employee." + field, Description: fieldErr.Message}
+	}
+
+	st, err := status.New(codes.InvalidArgument, errs.Error()).WithDetails(&errdetails.BadRequest{FieldViolations: violations})
+	if err != nil {
+		return status.Error(codes.InvalidArgument, errs.Error())
+	}
+	return st.Err()
+}
+
+// grpcError maps the errors of the providers package to gRPC status codes, the way the REST handlers map them to HTTP statuses
+func grpcError(method string, err error) error {
+	var (
+		errs        models.ValidationErrors
+		merged      *providers.EmployeeMergedError
+		missingRate *models.MissingRateError
+		overflow    *models.OverflowError
+	)
+	switch {
+	case errors.As(err, &errs):
+		return invalidEmployee(errs)
+	case errors.As(err, &merged):
+		st, detailErr := status.New(codes.NotFound, err.Error()).WithDetails(&errdetails.ErrorInfo{
+			Reason:   "EMPLOYEE_MERGED",
+			Domain:   "employee.v1",
+			Metadata: map[string]string{"mergedInto": strconv.Itoa(merged.MergedInto)},
+		})
+		if detailErr != nil {
+			return status.Error(codes.NotFound, err.Error())
+		}
+		return st.Err()
+	case errors.Is(err, providers.ErrEmployeeNotFound):
+		return status.Error(codes.NotFound, err.Error())
+	case errors.Is(err, providers.ErrEmailTaken):
+		return status.Error(codes.AlreadyExists, err.Error())
+	case errors.As(err, &missingRate), errors.As(err, &overflow):
+		return status.Error(codes.FailedPrecondition, err.Error())
+	case errors.Is(err, providers.ErrTerminationBeforeHire):
+		return invalidEmployee(models.ValidationErrors{{Field: "terminationDate", Code: models.CodeOutOfRange, Message: err.Error()}})
+	case errors.Is(err, providers.ErrManagerNotFound):
+		return invalidEmployee(models.ValidationErrors{{Field: "managerId", Code: models.CodeInvalidValue, Message: err.Error()}})
+	}
+	log.Println(method+": error in the database operation", err)
+	return status.Error(codes.Internal, "internal error")
+}
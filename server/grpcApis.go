@@ -0,0 +1,250 @@
+package server
+
+import (
+	"Techiebulter/interview/backend/models"
+	"Techiebulter/interview/backend/proto/employeepb"
+	"context"
+	"errors"
+	"strconv"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/structpb"
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+// employeeService implements employeepb.EmployeeServiceServer on top of the same DBHelper as the REST API
+type employeeService struct {
+	employeepb.UnimplementedEmployeeServiceServer
+	srv *Server
+}
+
+// eventTypes maps the types of employee events to the proto enum
+var eventTypes = map[models.EmployeeEventType]employeepb.EmployeeEvent_Type{
+	models.EmployeeCreated: employeepb.EmployeeEvent_CREATED,
+	models.EmployeeUpdated: employeepb.EmployeeEvent_UPDATED,
+	models.EmployeeDeleted: employeepb.EmployeeEvent_DELETED,
+}
+
+func (e *employeeService) CreateEmployee(ctx context.Context, req *employeepb.CreateEmployeeRequest) (*employeepb.Employee, error) {
+	employee, errs := employeeFromProto(req.GetEmployee())
+	if len(errs) > 0 {
+		return nil, invalidEmployee(errs)
+	}
+
+	// Check the fields of the employee, required custom fields included
+	errs, err := e.srv.validateEmployee(&employee, models.EmployeeCreateRules, true)
+	if err != nil {
+		return nil, grpcError("CreateEmployee", err)
+	}
+	if len(errs) > 0 {
+		return nil, invalidEmployee(errs)
+	}
+	// salaries outside a warning band are stored, the warning is only answered over REST
+	if _, errs, err = e.srv.checkSalaryBand(employee, true); err != nil {
+		return nil, grpcError("CreateEmployee", err)
+	}
+	if len(errs) > 0 {
+		return nil, invalidEmployee(errs)
+	}
+
+	created, err := e.srv.DBHelper.InsertEmployee(employee)
+	if err != nil {
+		return nil, grpcError("CreateEmployee", err)
+	}
+	return employeeToProto(created)
+}
+
+func (e *employeeService) GetEmployee(ctx context.Context, req *employeepb.GetEmployeeRequest) (*employeepb.Employee, error) {
+	if err := grpcRequireSelfOrHR(ctx, int(req.GetId())); err != nil {
+		return nil, err
+	}
+	employee, err := e.srv.DBHelper.GetEmployeeById(int(req.GetId()))
+	if err != nil {
+		return nil, grpcError("GetEmployee", err)
+	}
+	shown, err := withLeaveStatus(e.srv.DBHelper, employee)
+	if err != nil {
+		return nil, grpcError("GetEmployee", err)
+	}
+	return employeeToProto(shown[0])
+}
+
+func (e *employeeService) UpdateEmployee(ctx context.Context, req *employeepb.UpdateEmployeeRequest) (*employeepb.Employee, error) {
+	employee, errs := employeeFromProto(req.GetEmployee())
+	if len(errs) > 0 {
+		return nil, invalidEmployee(errs)
+	}
+
+	// Check the fields being changed, custom fields are replaced as a whole so they are only checked when sent
+	errs, err := e.srv.validateEmployee(&employee, models.EmployeeUpdateRules, false)
+	if err != nil {
+		return nil, grpcError("UpdateEmployee", err)
+	}
+	if len(errs) > 0 {
+		return nil, invalidEmployee(errs)
+	}
+	// salaries outside a warning band are stored, the warning is only answered over REST
+	if _, errs, err = e.srv.checkSalaryBand(employee, false); err != nil {
+		return nil, grpcError("UpdateEmployee", err)
+	}
+	if len(errs) > 0 {
+		return nil, invalidEmployee(errs)
+	}
+
+	updated, err := e.srv.DBHelper.UpdateEmployee(employee)
+	if err != nil {
+		return nil, grpcError("UpdateEmployee", err)
+	}
+	return employeeToProto(e.srv.writtenWithLeaveStatus(updated))
+}
+
+func (e *employeeService) DeleteEmployee(ctx context.Context, req *employeepb.DeleteEmployeeRequest) (*employeepb.DeleteEmployeeResponse, error) {
+	if req.GetId() < 1 {
+		return nil, status.Error(codes.InvalidArgument, "id must be at least 1")
+	}
+	if err := e.srv.DBHelper.DeleteEmployeeById(int(req.GetId())); err != nil {
+		return nil, grpcError("DeleteEmployee", err)
+	}
+	return &employeepb.DeleteEmployeeResponse{}, nil
+}
+
+func (e *employeeService) ListEmployees(ctx context.Context, req *employeepb.ListEmployeesRequest) (*employeepb.ListEmployeesResponse, error) {
+	if req.GetPage() < 1 || req.GetLimit() < 1 {
+		return nil, status.Error(codes.InvalidArgument, "page and limit must be at least 1")
+	}
+
+	employees, err := e.srv.DBHelper.GetAllEmployees(strconv.Itoa(int(req.GetPage())), strconv.Itoa(int(req.GetLimit())))
+	if err != nil {
+		return nil, grpcError("ListEmployees", err)
+	}
+	if employees, err = withLeaveStatus(e.srv.DBHelper, employees...); err != nil {
+		return nil, grpcError("ListEmployees", err)
+	}
+
+	resp := &employeepb.ListEmployeesResponse{Employees: make([]*employeepb.Employee, 0, len(employees))}
+	for _, employee := range employees {
+		pb, err := employeeToProto(employee)
+		if err != nil {
+			return nil, err
+		}
+		resp.Employees = append(resp.Employees, pb)
+	}
+	return resp, nil
+}
+
+// WatchEmployees streams the changes made after the call starts, to one employee when employee_id is set.
+// A watcher too slow to keep up is ended with ResourceExhausted and has to watch again, every watch ends when the server stops.
+func (e *employeeService) WatchEmployees(req *employeepb.WatchEmployeesRequest, stream employeepb.EmployeeService_WatchEmployeesServer) error {
+	events, cancel := e.srv.events.subscribe()
+	defer cancel()
+
+	// headers tell the client the watch is registered, changes from then on are streamed
+	if err := stream.SendHeader(nil); err != nil {
+		return err
+	}
+
+	for {
+		select {
+		case <-stream.Context().Done():
+			return nil
+		case <-e.srv.stopping:
+			return status.Error(codes.Unavailable, "server is shutting down")
+		case event, ok := <-events:
+			if !ok {
+				return status.Error(codes.ResourceExhausted, "watcher fell too far behind")
+			}
+			if req.GetEmployeeId() != 0 && int32(event.EmployeeID) != req.GetEmployeeId() {
+				continue
+			}
+
+			pb := &employeepb.EmployeeEvent{
+				Type:       eventTypes[event.Type],
+				EmployeeId: int32(event.EmployeeID),
+				Time:       timestamppb.New(event.Time),
+			}
+			if event.Employee != nil {
+				employee, err := employeeToProto(*event.Employee)
+				if err != nil {
+					return err
+				}
+				pb.Employee = employee
+			}
+			if err := stream.Send(pb); err != nil {
+				return err
+			}
+		}
+	}
+}
+
+// employeeFromProto converts the employee of a request, dates that do not parse and salaries that are not a
+// whole number of cents are reported as validation errors
+func employeeFromProto(pb *employeepb.Employee) (models.Employee, models.ValidationErrors) {
+	employee := models.Employee{
+		ID:             int(pb.GetId()),
+		Name:           pb.GetName(),
+		Position:       pb.GetPosition(),
+		Currency:       models.Currency(pb.GetCurrency()),
+		Email:          pb.GetEmail(),
+		Phone:          pb.GetPhone(),
+		EmploymentType: models.EmploymentType(pb.GetEmploymentType()),
+		Status:         models.EmployeeStatus(pb.GetStatus()),
+		Location:       pb.GetLocation(),
+	}
+	if pb.GetCustomFields() != nil {
+		employee.CustomFields = pb.GetCustomFields().AsMap()
+	}
+
+	var errs models.ValidationErrors
+	salary, err := models.ExactMoney(pb.GetSalary())
+	var amountErr *models.AmountError
+	if errors.As(err, &amountErr) {
+		errs = append(errs, amountErr.FieldError("Salary"))
+	}
+	employee.Salary = salary
+	parseDate := func(field, value string) *models.Date {
+		if value == "" {
+			return nil
+		}
+		date, err := models.ParseDate(value)
+		if err != nil {
+			errs = append(errs, models.FieldError{Field: field, Code: models.CodeInvalidFormat, Message: field + " must be a date like 2006-01-02"})
+			return nil
+		}
+		return &date
+	}
+	employee.HireDate = parseDate("hireDate", pb.GetHireDate())
+	employee.TerminationDate = parseDate("terminationDate", pb.GetTerminationDate())
+	return employee, errs
+}
+
+func employeeToProto(employee models.Employee) (*employeepb.Employee, error) {
+	pb := &employeepb.Employee{
+		Id:             int32(employee.ID),
+		Name:           employee.Name,
+		Position:       employee.Position,
+		Salary:         employee.Salary.Float64(),
+		Currency:       string(employee.Currency),
+		Email:          employee.Email,
+		Phone:          employee.Phone,
+		EmploymentType: string(employee.EmploymentType),
+		Status:         string(employee.Status),
+		Location:       employee.Location,
+		CreatedAt:      timestamppb.New(employee.CreatedAt),
+		UpdatedAt:      timestamppb.New(employee.UpdatedAt),
+	}
+	if employee.HireDate != nil {
+		pb.HireDate = employee.HireDate.String()
+	}
+	if employee.TerminationDate != nil {
+		pb.TerminationDate = employee.TerminationDate.String()
+	}
+	if employee.CustomFields != nil {
+		customFields, err := structpb.NewStruct(employee.CustomFields)
+		if err != nil {
+			return nil, grpcError("employeeToProto", err)
+		}
+		pb.CustomFields = customFields
+	}
+	return pb, nil
+}
@@ -13,3 +13,25 @@ func (srv *Server) HealthCheck(c *fiber.Ctx) error {
 	})
 	return nil
 }
+
+// ReadinessCheck reports whether the service can serve traffic, i.e. the database is reachable
+func (srv *Server) ReadinessCheck(c *fiber.Ctx) error {
+	stats := srv.PGClient.Stats()
+	pool := fiber.Map{
+		"open":    stats.OpenConnections,
+		"inUse":   stats.InUse,
+		"idle":    stats.Idle,
+		"maxOpen": stats.MaxOpenConnections,
+	}
+
+	// unhealthy replicas do not fail readiness, reads fall back to the primary
+	replicas := []fiber.Map{}
+	for _, replica := range srv.PGClient.Replicas() {
+		replicas = append(replicas, fiber.Map{"name": replica.Name, "healthy": replica.Healthy, "lag": replica.Lag.String()})
+	}
+
+	if !srv.PGClient.Healthy() {
+		return c.Status(fiber.StatusServiceUnavailable).JSON(fiber.Map{"status": "fail", "database": "down", "pool": pool, "replicas": replicas})
+	}
+	return c.Status(fiber.StatusOK).JSON(fiber.Map{"status": "success", "database": "up", "pool": pool, "replicas": replicas})
+}
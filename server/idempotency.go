@@ -0,0 +1,102 @@
+package server
+
+import (
+	"Techiebulter/interview/backend/models"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"log"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// Headers of idempotent requests
+const (
+	idempotencyKeyHeader     = "Idempotency-Key"
+	idempotentReplayedHeader = "Idempotent-Replayed"
+	maxIdempotencyKeyLength  = 255
+)
+
+// Idempotent makes POST requests carrying an Idempotency-Key safe to retry: the first response is
+// stored for the configured TTL and replayed to retries, so the request is only executed once.
+// Server errors and the rejections of route guards are not stored, a retry executes the request again.
+// Until the response is stored the key is only leased to the request, so a retry takes over the key of
+// a request that died without answering.
+func (srv *Server) Idempotent(c *fiber.Ctx) error {
+	key := c.Get(idempotencyKeyHeader)
+	if c.Method() != fiber.MethodPost || key == "" {
+		return c.Next()
+	}
+	if len(key) > maxIdempotencyKeyLength {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"status": "fail",
+			"error": fmt.Sprintf("%s must be at most %d characters", idempotencyKeyHeader, maxIdempotencyKeyLength)})
+	}
+
+	principal := principalOf(c)
+	record := models.IdempotencyRecord{
+		Scope:       principal.String(),
+		Key:         key,
+		Fingerprint: requestFingerprint(c),
+		ExpiresAt:   time.Now().Add(srv.Config.Idempotency.Lease),
+	}
+
+	stored, claimed, err := srv.DBHelper.ClaimIdempotencyKey(record)
+	if err != nil {
+		log.Println("Idempotent: error claiming idempotency key", err)
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"status": "fail", "data": fiber.Map{"error": err}})
+	}
+
+	if !claimed {
+		switch {
+		case stored.Fingerprint != record.Fingerprint:
+			return validationFailed(c, models.ValidationErrors{{Field: idempotencyKeyHeader, Code: models.CodeInvalidValue,
+				Message: idempotencyKeyHeader + " was already used for a different request"}})
+		case !stored.Completed():
+			return c.Status(fiber.StatusConflict).JSON(fiber.Map{"status": "fail",
+				"error": "a request with this " + idempotencyKeyHeader + " is still in progress"})
+		}
+
+		c.Set(idempotentReplayedHeader, "true")
+		c.Set(fiber.HeaderContentType, stored.ContentType)
+		return c.Status(stored.StatusCode).Send(stored.Body)
+	}
+
+	if err := c.Next(); err != nil {
+		srv.releaseIdempotencyKey(record)
+		return err
+	}
+
+	response := c.Response()
+	if rejected, _ := c.Locals(guardRejectedKey).(bool); rejected || response.StatusCode() >= fiber.StatusInternalServerError {
+		srv.releaseIdempotencyKey(record)
+		return nil
+	}
+
+	record.StatusCode = response.StatusCode()
+	record.ContentType = string(response.Header.ContentType())
+	record.Body = append([]byte(nil), response.Body()...)
+	record.ExpiresAt = time.Now().Add(srv.Config.Idempotency.TTL)
+	if err := srv.DBHelper.CompleteIdempotencyKey(record); err != nil {
+		// the request succeeded, so it is answered anyway, retries get a 409 until the lease runs out
+		log.Println("Idempotent: error storing the response of an idempotent request", err)
+	}
+	return nil
+}
+
+func (srv *Server) releaseIdempotencyKey(record models.IdempotencyRecord) {
+	if err := srv.DBHelper.ReleaseIdempotencyKey(record.Scope, record.Key); err != nil {
+		log.Println("Idempotent: error releasing idempotency key", err)
+	}
+}
+
+// requestFingerprint hashes what identifies a request: method, path including the query and body
+func requestFingerprint(c *fiber.Ctx) string {
+	hash := sha256.New()
+	hash.Write([]byte(c.Method()))
+	hash.Write([]byte{0})
+	hash.Write([]byte(c.OriginalURL()))
+	hash.Write([]byte{0})
+	hash.Write(c.Body())
+	return hex.EncodeToString(hash.Sum(nil))
+}
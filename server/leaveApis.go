@@ -0,0 +1,324 @@
+package server
+
+import (
+	"Techiebulter/interview/backend/models"
+	"Techiebulter/interview/backend/providers"
+	"errors"
+	"log"
+	"strconv"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// errForbidden is sent by a handler's goroutine that found the principal may not do what it asked
+var errForbidden = errors.New("insufficient permissions")
+
+// leaveFailed answers the errors of the leave workflow, anything else is logged with message as a server error
+func leaveFailed(c *fiber.Ctx, err error, message string) error {
+	switch {
+	case errors.Is(err, errForbidden):
+		return c.Status(fiber.StatusForbidden).JSON(fiber.Map{"status": "fail", "message": err.Error()})
+	case errors.Is(err, providers.ErrEmployeeNotFound), errors.Is(err, providers.ErrLeaveRequestNotFound):
+		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{"status": "fail", "error": err.Error()})
+	case errors.Is(err, providers.ErrLeaveTypeNotFound):
+		return validationFailed(c, models.ValidationErrors{{Field: "leaveTypeId", Code: models.CodeInvalidValue,
+			Message: "leaveTypeId must be the ID of a leave type"}})
+	case errors.Is(err, providers.ErrLeaveOverlap), errors.Is(err, providers.ErrInsufficientLeave),
+		errors.Is(err, providers.ErrLeaveRequestDecided):
+		return c.Status(fiber.StatusConflict).JSON(fiber.Map{"status": "fail", "error": err.Error()})
+	}
+	log.Println(message, err)
+	return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"status": "fail", "data": fiber.Map{"error": err}})
+}
+
+// CreateLeaveType defines a kind of leave and the policy its days accrue by
+func (s *Server) CreateLeaveType(c *fiber.Ctx) error {
+	var leaveType models.LeaveType
+
+	if err := c.BodyParser(&leaveType); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"status": "fail", "message": err.Error()})
+	}
+	if errs := leaveType.Validate(); len(errs) > 0 {
+		return validationFailed(c, errs)
+	}
+
+	// Use a channel to communicate errors and results back from the goroutine
+	resultChan := make(chan models.LeaveType, 1)
+	errChan := make(chan error, 1)
+
+	go func() {
+		created, err := s.DBHelper.CreateLeaveType(leaveType)
+		if err != nil {
+			errChan <- err
+			return
+		}
+		resultChan <- created
+	}()
+
+	select {
+	case created := <-resultChan:
+		markWrite(c)
+		return c.Status(fiber.StatusOK).JSON(fiber.Map{"status": "success", "leaveType": created})
+	case err := <-errChan:
+		if errors.Is(err, providers.ErrLeaveTypeExists) {
+			return c.Status(fiber.StatusConflict).JSON(fiber.Map{"status": "fail", "error": err.Error()})
+		}
+		log.Println("CreateLeaveType: error inserting data in the database", err)
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"status": "fail", "data": fiber.Map{"error": err}})
+	}
+}
+
+// GetLeaveTypes lists the leave types
+func (s *Server) GetLeaveTypes(c *fiber.Ctx) error {
+	dbHelper := s.readHelper(c)
+
+	// Use a channel to communicate errors and results back from the goroutine
+	resultChan := make(chan []models.LeaveType, 1)
+	errChan := make(chan error, 1)
+
+	go func() {
+		leaveTypes, err := dbHelper.GetLeaveTypes()
+		if err != nil {
+			errChan <- err
+			return
+		}
+		resultChan <- leaveTypes
+	}()
+
+	select {
+	case leaveTypes := <-resultChan:
+		return c.Status(fiber.StatusOK).JSON(fiber.Map{"status": "success", "leaveTypes": leaveTypes})
+	case err := <-errChan:
+		log.Println("GetLeaveTypes: error getting results from DB", err)
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"status": "fail", "data": fiber.Map{"error": err}})
+	}
+}
+
+// CreateLeaveRequest asks for leave for an employee, it waits as pending for HR to decide
+func (s *Server) CreateLeaveRequest(c *fiber.Ctx) error {
+	id, err := strconv.Atoi(c.Params("id"))
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"status": "fail", "message": "Invalid employee ID"})
+	}
+
+	var request models.LeaveRequest
+	if err := c.BodyParser(&request); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"status": "fail", "message": err.Error()})
+	}
+	if errs := request.Validate(); len(errs) > 0 {
+		return validationFailed(c, errs)
+	}
+	request.EmployeeID = id
+
+	// Use a channel to communicate errors and results back from the goroutine
+	resultChan := make(chan models.LeaveRequest, 1)
+	errChan := make(chan error, 1)
+
+	go func() {
+		created, err := s.DBHelper.CreateLeaveRequest(request)
+		if err != nil {
+			errChan <- err
+			return
+		}
+		resultChan <- created
+	}()
+
+	select {
+	case created := <-resultChan:
+		markWrite(c)
+		return c.Status(fiber.StatusOK).JSON(fiber.Map{"status": "success", "leaveRequest": created})
+	case err := <-errChan:
+		return leaveFailed(c, err, "CreateLeaveRequest: error inserting data in the database")
+	}
+}
+
+// GetLeaveRequests lists the leave requests of an employee by start date, the status query parameter
+// keeps only pending, approved, rejected or cancelled ones
+func (s *Server) GetLeaveRequests(c *fiber.Ctx) error {
+	id, err := strconv.Atoi(c.Params("id"))
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"status": "fail", "message": "Invalid employee ID"})
+	}
+	status := models.LeaveRequestStatus(c.Query("status"))
+	if status != "" && !status.IsValid() {
+		return validationFailed(c, models.ValidationErrors{{Field: "status", Code: models.CodeInvalidValue,
+			Message: "status must be pending, approved, rejected or cancelled"}})
+	}
+	dbHelper := s.readHelper(c)
+
+	// Use a channel to communicate errors and results back from the goroutine
+	resultChan := make(chan []models.LeaveRequest, 1)
+	errChan := make(chan error, 1)
+
+	go func() {
+		requests, err := dbHelper.GetLeaveRequests(id, status)
+		if err != nil {
+			errChan <- err
+			return
+		}
+		resultChan <- requests
+	}()
+
+	select {
+	case requests := <-resultChan:
+		return c.Status(fiber.StatusOK).JSON(fiber.Map{"status": "success", "leaveRequests": requests})
+	case err := <-errChan:
+		log.Println("GetLeaveRequests: error getting results from DB", err)
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"status": "fail", "data": fiber.Map{"error": err}})
+	}
+}
+
+// GetLeaveBalances returns the balance of every leave type of an employee, today or on the day in the on query parameter
+func (s *Server) GetLeaveBalances(c *fiber.Ctx) error {
+	id, err := strconv.Atoi(c.Params("id"))
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"status": "fail", "message": "Invalid employee ID"})
+	}
+	on := models.NewDate(time.Now())
+	if value := c.Query("on"); value != "" {
+		if on, err = models.ParseDate(value); err != nil {
+			return validationFailed(c, models.ValidationErrors{{Field: "on", Code: models.CodeInvalidFormat, Message: "on must be a date like 2006-01-02"}})
+		}
+	}
+	dbHelper := s.readHelper(c)
+
+	// Use a channel to communicate errors and results back from the goroutine
+	resultChan := make(chan []models.LeaveBalance, 1)
+	errChan := make(chan error, 1)
+
+	go func() {
+		employee, err := dbHelper.GetEmployeeById(id)
+		if err != nil {
+			errChan <- err
+			return
+		}
+		leaveTypes, err := dbHelper.GetLeaveTypes()
+		if err != nil {
+			errChan <- err
+			return
+		}
+		requests, err := dbHelper.GetLeaveRequests(id, "")
+		if err != nil {
+			errChan <- err
+			return
+		}
+		balances := make([]models.LeaveBalance, 0, len(leaveTypes))
+		for _, leaveType := range leaveTypes {
+			balances = append(balances, models.NewLeaveBalance(leaveType, employee.ServiceStart(), on, requests))
+		}
+		resultChan <- balances
+	}()
+
+	select {
+	case balances := <-resultChan:
+		return c.Status(fiber.StatusOK).JSON(fiber.Map{"status": "success", "on": on, "balances": balances})
+	case err := <-errChan:
+		return leaveFailed(c, err, "GetLeaveBalances: error getting results from DB")
+	}
+}
+
+// ApproveLeaveRequest approves a pending leave request, the days are taken from the balance
+func (s *Server) ApproveLeaveRequest(c *fiber.Ctx) error {
+	return s.decideLeaveRequest(c, models.LeaveApproved)
+}
+
+// RejectLeaveRequest rejects a pending leave request
+func (s *Server) RejectLeaveRequest(c *fiber.Ctx) error {
+	return s.decideLeaveRequest(c, models.LeaveRejected)
+}
+
+// CancelLeaveRequest withdraws a pending leave request, or an approved one that has not started, the
+// employee who asked for it may cancel it as well
+func (s *Server) CancelLeaveRequest(c *fiber.Ctx) error {
+	return s.decideLeaveRequest(c, models.LeaveCancelled)
+}
+
+// decideLeaveRequest moves a leave request to status. HR and the manager of the employee decide on the
+// requests of others, employees may only cancel their own.
+func (s *Server) decideLeaveRequest(c *fiber.Ctx, status models.LeaveRequestStatus) error {
+	id, err := strconv.Atoi(c.Params("id"))
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"status": "fail", "message": "Invalid leave request ID"})
+	}
+	var decision models.LeaveDecision
+	if len(c.Body()) > 0 {
+		if err := c.BodyParser(&decision); err != nil {
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"status": "fail", "message": err.Error()})
+		}
+	}
+	if errs := models.LeaveDecisionRules.Validate(&decision); len(errs) > 0 {
+		return validationFailed(c, errs)
+	}
+	principal := principalOf(c)
+
+	// Use a channel to communicate errors and results back from the goroutine
+	resultChan := make(chan models.LeaveRequest, 1)
+	errChan := make(chan error, 1)
+
+	go func() {
+		request, err := s.DBHelper.ReadFromPrimary().GetLeaveRequest(id)
+		if err != nil {
+			errChan <- err
+			return
+		}
+		own := principal.EmployeeID != 0 && principal.EmployeeID == request.EmployeeID
+		hr := principal.HasRole(models.RoleAdmin, models.RoleHR)
+		allowed := own || hr
+		if status != models.LeaveCancelled {
+			// approving and rejecting is up to HR and the manager of the employee
+			if allowed, err = s.mayDecideFor(principal, request.EmployeeID); err != nil {
+				errChan <- err
+				return
+			}
+		}
+		if !allowed {
+			errChan <- errForbidden
+			return
+		}
+		decided, err := s.DBHelper.DecideLeaveRequest(id, status, principal.String(), decision.Note)
+		if err != nil {
+			errChan <- err
+			return
+		}
+		resultChan <- decided
+	}()
+
+	select {
+	case decided := <-resultChan:
+		markWrite(c)
+		return c.Status(fiber.StatusOK).JSON(fiber.Map{"status": "success", "leaveRequest": decided})
+	case err := <-errChan:
+		return leaveFailed(c, err, "decideLeaveRequest: error updating leave request in DB")
+	}
+}
+
+// withLeaveStatus returns employees with the active ones an approved leave request covers today shown as
+// on leave, every response with employees goes through it
+func withLeaveStatus(dbHelper providers.DbHelperProvider, employees ...models.Employee) ([]models.Employee, error) {
+	ids, err := dbHelper.GetEmployeesOnLeave(models.NewDate(time.Now()))
+	if err != nil {
+		return nil, err
+	}
+	onLeave := make(map[int]bool, len(ids))
+	for _, id := range ids {
+		onLeave[id] = true
+	}
+	for i := range employees {
+		if employees[i].Status == models.StatusActive && onLeave[employees[i].ID] {
+			employees[i].Status = models.StatusOnLeave
+		}
+	}
+	return employees, nil
+}
+
+// writtenWithLeaveStatus returns an employee a write stored through withLeaveStatus. The write is done
+// by then, so if the leave cannot be read the stored status is shown.
+func (s *Server) writtenWithLeaveStatus(employee models.Employee) models.Employee {
+	shown, err := withLeaveStatus(s.DBHelper.ReadFromPrimary(), employee)
+	if err != nil {
+		log.Println("writtenWithLeaveStatus: error getting the employees on leave from DB", err)
+		return employee
+	}
+	return shown[0]
+}
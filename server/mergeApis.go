@@ -0,0 +1,149 @@
+package server
+
+import (
+	"Techiebulter/interview/backend/models"
+	"Techiebulter/interview/backend/providers"
+	"errors"
+	"log"
+	"strconv"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// Paging of the duplicate scan and its default threshold
+const (
+	duplicateScanPageSize = 1000
+	defaultDuplicateScore = 0.6
+)
+
+// GetDuplicateEmployees lists pairs of employees that are probably the same person, best match first.
+// The minScore query parameter sets the lowest score reported, between 0 and 1.
+func (s *Server) GetDuplicateEmployees(c *fiber.Ctx) error {
+	minScore := defaultDuplicateScore
+	if value := c.Query("minScore"); value != "" {
+		score, err := strconv.ParseFloat(value, 64)
+		if err != nil || score < 0 || score > 1 {
+			return validationFailed(c, models.ValidationErrors{{Field: "minScore", Code: models.CodeOutOfRange,
+				Message: "minScore must be a number between 0 and 1"}})
+		}
+		minScore = score
+	}
+	dbHelper := s.readHelper(c)
+
+	// Use a channel to communicate errors and results back from the goroutine
+	resultChan := make(chan []models.DuplicateCandidate, 1)
+	errChan := make(chan error, 1)
+
+	// Start a goroutine to execute the database operation
+	go func() {
+		var employees []models.Employee
+		for page := 1; ; page++ {
+			batch, err := dbHelper.GetAllEmployees(strconv.Itoa(page), strconv.Itoa(duplicateScanPageSize))
+			if err != nil {
+				errChan <- err
+				return
+			}
+			employees = append(employees, batch...)
+			if len(batch) < duplicateScanPageSize {
+				break
+			}
+		}
+		resultChan <- models.FindDuplicates(employees, minScore)
+	}()
+
+	// Wait for the database operation to complete
+	select {
+	case duplicates := <-resultChan:
+		if duplicates == nil {
+			duplicates = []models.DuplicateCandidate{}
+		}
+		return c.Status(fiber.StatusOK).JSON(fiber.Map{"status": "success", "duplicates": duplicates})
+	case err := <-errChan:
+		log.Println("GetDuplicateEmployees: error getting results from DB", err)
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"status": "fail", "data": fiber.Map{"error": err}})
+	}
+}
+
+// MergeEmployees merges a duplicate employee into a surviving one. The duplicate is deleted, its ID
+// redirects to the survivor and both records are kept in the merge history.
+func (s *Server) MergeEmployees(c *fiber.Ctx) error {
+	var request models.MergeRequest
+
+	if err := c.BodyParser(&request); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"status": "fail", "message": err.Error()})
+	}
+	if errs := models.MergeRequestRules.Validate(&request); len(errs) > 0 {
+		return validationFailed(c, errs)
+	}
+	mergedBy := principalOf(c).String()
+
+	// Use a channel to communicate errors and results back from the goroutine
+	resultChan := make(chan models.Employee, 1)
+	errChan := make(chan error, 1)
+
+	// Start a goroutine to execute the database operation
+	go func() {
+		mergedEmployee, err := s.DBHelper.MergeEmployees(request, mergedBy)
+		if err != nil {
+			errChan <- err
+			return
+		}
+		resultChan <- mergedEmployee
+	}()
+
+	// Wait for the database operation to complete
+	select {
+	case mergedEmployee := <-resultChan:
+		markWrite(c)
+		return c.Status(fiber.StatusOK).JSON(fiber.Map{"status": "success", "mergedEmployee": mergedEmployee})
+	case err := <-errChan:
+		var (
+			errs   models.ValidationErrors
+			merged *providers.EmployeeMergedError
+		)
+		switch {
+		case errors.As(err, &errs):
+			return validationFailed(c, errs)
+		case errors.As(err, &merged):
+			return c.Status(fiber.StatusConflict).JSON(fiber.Map{"status": "fail", "error": err.Error(), "mergedInto": merged.MergedInto})
+		case errors.Is(err, providers.ErrEmployeeNotFound):
+			return c.Status(fiber.StatusNotFound).JSON(fiber.Map{"status": "fail", "error": err.Error()})
+		case errors.Is(err, providers.ErrEmailTaken):
+			return c.Status(fiber.StatusConflict).JSON(fiber.Map{"status": "fail", "error": err.Error()})
+		}
+		log.Println("MergeEmployees: error merging employees in the database", err)
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"status": "fail", "data": fiber.Map{"error": err}})
+	}
+}
+
+// GetEmployeeMerges lists the employees merged into the given one with their records from before the merge.
+func (s *Server) GetEmployeeMerges(c *fiber.Ctx) error {
+	id, err := strconv.Atoi(c.Params("id"))
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"status": "fail", "message": err.Error()})
+	}
+	dbHelper := s.readHelper(c)
+
+	// Use a channel to communicate errors and results back from the goroutine
+	resultChan := make(chan []models.EmployeeMerge, 1)
+	errChan := make(chan error, 1)
+
+	// Start a goroutine to execute the database operation
+	go func() {
+		merges, err := dbHelper.GetEmployeeMerges(id)
+		if err != nil {
+			errChan <- err
+			return
+		}
+		resultChan <- merges
+	}()
+
+	// Wait for the database operation to complete
+	select {
+	case merges := <-resultChan:
+		return c.Status(fiber.StatusOK).JSON(fiber.Map{"status": "success", "merges": merges})
+	case err := <-errChan:
+		log.Println("GetEmployeeMerges: error getting results from DB", err)
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"status": "fail", "data": fiber.Map{"error": err}})
+	}
+}
@@ -0,0 +1,66 @@
+package server
+
+import (
+	"Techiebulter/interview/backend/docs"
+	"Techiebulter/interview/backend/models"
+	"Techiebulter/interview/backend/utils/openapi"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/sirupsen/logrus"
+)
+
+// apiDocument is the OpenAPI document requests and responses are checked against
+var apiDocument = openapi.MustLoad(docs.OpenAPI)
+
+// ValidateRequest answers requests whose parameters or JSON body do not match the OpenAPI document
+// with 400 and every mismatch. Routes missing from the document are passed through.
+func (srv *Server) ValidateRequest(c *fiber.Ctx) error {
+	op, params := apiDocument.Find(c.Method(), c.Path())
+	if op == nil {
+		return c.Next()
+	}
+
+	errs := op.ValidateRequest(openapi.Request{
+		PathParams:  params,
+		Query:       func(name string) string { return c.Query(name) },
+		Header:      func(name string) string { return c.Get(name) },
+		ContentType: c.Get(fiber.HeaderContentType),
+		Body:        c.Body(),
+	})
+	if len(errs) > 0 {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"status": "fail", "errors": fieldErrors(errs)})
+	}
+	return c.Next()
+}
+
+// ValidateResponse replaces responses that do not match the OpenAPI document with a 500 listing
+// every mismatch. It is meant for tests, where drift between the handlers and the document should fail loudly.
+func (srv *Server) ValidateResponse(c *fiber.Ctx) error {
+	if err := c.Next(); err != nil {
+		return err
+	}
+	op, _ := apiDocument.Find(c.Method(), c.Path())
+	resp := c.Response()
+	// streams are written after the handlers returned and never end, WebSocket upgrades have no body
+	if op == nil || c.Method() == fiber.MethodHead || resp.IsBodyStream() || resp.StatusCode() == fiber.StatusSwitchingProtocols {
+		return nil
+	}
+
+	errs := op.ValidateResponse(resp.StatusCode(), string(resp.Header.ContentType()), resp.Body())
+	if len(errs) == 0 {
+		return nil
+	}
+	logrus.Errorf("ValidateResponse: %s %s answered %d, which does not match the OpenAPI document: %v",
+		c.Method(), c.Path(), resp.StatusCode(), errs)
+	return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"status": "fail",
+		"error": "response does not match the OpenAPI document", "errors": fieldErrors(errs)})
+}
+
+// fieldErrors reports schema mismatches in the format of validation errors
+func fieldErrors(errs openapi.Errors) models.ValidationErrors {
+	fieldErrs := make(models.ValidationErrors, len(errs))
+	for i, err := range errs {
+		fieldErrs[i] = models.FieldError{Field: err.Field, Code: err.Code, Message: err.Message}
+	}
+	return fieldErrs
+}
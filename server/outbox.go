@@ -0,0 +1,165 @@
+package server
+
+import (
+	"Techiebulter/interview/backend/config"
+	"Techiebulter/interview/backend/models"
+	"Techiebulter/interview/backend/utils"
+	"Techiebulter/interview/backend/utils/outbox"
+	"context"
+	"fmt"
+	"log"
+	"sync"
+	"time"
+)
+
+// outboxSinks builds the sinks named in the configuration
+func (srv *Server) outboxSinks() []outbox.Sink {
+	var sinks []outbox.Sink
+	for _, name := range srv.Config.Outbox.Sinks {
+		switch name {
+		case config.SinkWebhooks:
+			sinks = append(sinks, webhookSink{srv: srv})
+		case config.SinkFile:
+			sinks = append(sinks, &outbox.FileSink{Path: srv.Config.Outbox.File})
+		}
+	}
+	return sinks
+}
+
+// webhookSink queues the webhook deliveries of an event, the webhook worker posts them
+type webhookSink struct {
+	srv *Server
+}
+
+func (s webhookSink) Name() string { return config.SinkWebhooks }
+
+func (s webhookSink) Publish(_ context.Context, event models.EmployeeEvent) error {
+	queued, err := s.srv.DBHelper.ReadFromPrimary().QueueWebhookDeliveries(event)
+	if err == nil && queued > 0 {
+		s.srv.wakeWebhooks()
+	}
+	return err
+}
+
+// relayMetrics counts what the outbox relay of this instance published since it started
+type relayMetrics struct {
+	mu        sync.Mutex
+	published int64
+	retried   int64
+	sinks     map[string]models.OutboxSinkMetrics
+}
+
+// attempted records the outcome of publishing to a sink
+func (m *relayMetrics) attempted(sink string, err error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if m.sinks == nil {
+		m.sinks = map[string]models.OutboxSinkMetrics{}
+	}
+	metrics := m.sinks[sink]
+	if err != nil {
+		metrics.Failed++
+		metrics.LastError = err.Error()
+	} else {
+		now := time.Now().UTC()
+		metrics.Published++
+		metrics.LastPublishedAt = &now
+	}
+	m.sinks[sink] = metrics
+}
+
+// completed records whether a message was published to every sink or is retried
+func (m *relayMetrics) completed(published bool) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if published {
+		m.published++
+	} else {
+		m.retried++
+	}
+}
+
+// snapshot returns the metrics with an entry for every sink, attempted or not
+func (m *relayMetrics) snapshot(sinks []outbox.Sink) models.OutboxMetrics {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	metrics := models.OutboxMetrics{Published: m.published, Retried: m.retried, Sinks: map[string]models.OutboxSinkMetrics{}}
+	for _, sink := range sinks {
+		metrics.Sinks[sink.Name()] = m.sinks[sink.Name()]
+	}
+	return metrics
+}
+
+// relayOutbox publishes the due outbox messages, every poll interval and whenever an employee was
+// written, until the server stops. The event hub catches up on every poll as well.
+func (srv *Server) relayOutbox() {
+	defer srv.background.Done()
+
+	poll := time.NewTicker(srv.Config.Outbox.PollInterval)
+	defer poll.Stop()
+
+	for {
+		srv.events.catchUp()
+		// full batches mean more messages may be due
+		for srv.publishOutbox() == srv.Config.Outbox.BatchSize {
+		}
+		select {
+		case <-poll.C:
+		case <-srv.outboxDue:
+		case <-srv.stopping:
+			return
+		}
+	}
+}
+
+// publishOutbox claims a batch of due messages, publishes them concurrently and returns how many it
+// claimed. A batch holds at most one message per employee, so their order is kept.
+func (srv *Server) publishOutbox() int {
+	cfg := srv.Config.Outbox
+	dbHelper := srv.DBHelper.ReadFromPrimary()
+
+	// the lease outlasts the attempts, messages of an instance that died meanwhile are retried after it
+	now := time.Now()
+	lease := cfg.PublishTimeout*time.Duration(len(srv.Sinks)) + time.Minute
+	messages, err := dbHelper.ClaimOutboxMessages(now, now.Add(lease), cfg.BatchSize)
+	if err != nil {
+		return 0
+	}
+
+	var wg sync.WaitGroup
+	for _, message := range messages {
+		wg.Add(1)
+		go func(message models.OutboxMessage) {
+			defer wg.Done()
+			err := srv.publishMessage(message.Event)
+			if err != nil {
+				message.Attempts++
+				message.NextAttemptAt = time.Now().Add(utils.Backoff(cfg.BackoffInitial, cfg.BackoffMax, message.Attempts-1))
+				message.LastError = err.Error()
+				log.Printf("publishOutbox: event %d is retried after %d attempts: %v", message.Event.ID, message.Attempts, err)
+			}
+			srv.outboxMetrics.completed(err == nil)
+			if err := dbHelper.CompleteOutboxAttempt(message, err == nil); err != nil {
+				log.Println("publishOutbox: unable to record outbox attempt", err)
+			}
+		}(message)
+	}
+	wg.Wait()
+
+	return len(messages)
+}
+
+// publishMessage publishes an event to the sinks in order and stops at the first that fails. The
+// whole message is retried, so the sinks before it see the event again.
+func (srv *Server) publishMessage(event models.EmployeeEvent) error {
+	for _, sink := range srv.Sinks {
+		ctx, cancel := context.WithTimeout(context.Background(), srv.Config.Outbox.PublishTimeout)
+		err := sink.Publish(ctx, event)
+		cancel()
+		srv.outboxMetrics.attempted(sink.Name(), err)
+		if err != nil {
+			return fmt.Errorf("%s: %w", sink.Name(), err)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,37 @@
+package server
+
+import (
+	"Techiebulter/interview/backend/models"
+	"log"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// GetOutboxMetrics reports the backlog of the outbox, shared by every instance, and what the relay of
+// this instance published to each sink since it started
+func (s *Server) GetOutboxMetrics(c *fiber.Ctx) error {
+	dbHelper := s.DBHelper.ReadFromPrimary()
+
+	// Use a channel to communicate errors and results back from the goroutine
+	resultChan := make(chan models.OutboxBacklog, 1)
+	errChan := make(chan error, 1)
+
+	go func() {
+		backlog, err := dbHelper.GetOutboxBacklog()
+		if err != nil {
+			errChan <- err
+			return
+		}
+		resultChan <- backlog
+	}()
+
+	select {
+	case backlog := <-resultChan:
+		metrics := s.outboxMetrics.snapshot(s.Sinks)
+		metrics.Backlog = backlog
+		return c.Status(fiber.StatusOK).JSON(fiber.Map{"status": "success", "outbox": metrics})
+	case err := <-errChan:
+		log.Println("GetOutboxMetrics: error getting results from DB", err)
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"status": "fail", "data": fiber.Map{"error": err}})
+	}
+}
@@ -0,0 +1,220 @@
+package server
+
+import (
+	"Techiebulter/interview/backend/models"
+	"Techiebulter/interview/backend/providers"
+	"Techiebulter/interview/backend/utils/jsonpatch"
+	"encoding/json"
+	"errors"
+	"log"
+	"mime"
+	"reflect"
+	"sort"
+	"strconv"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// Media types accepted by PatchEmployee
+const (
+	mergePatchMediaType = "application/merge-patch+json"
+	jsonPatchMediaType  = "application/json-patch+json"
+)
+
+// errPatchRejected wraps the validation errors of a patched employee, so they leave the transaction unchanged
+type errPatchRejected struct {
+	errs models.ValidationErrors
+}
+
+func (e errPatchRejected) Error() string {
+	return e.errs.Error()
+}
+
+// PatchEmployee changes an employee with a JSON Merge Patch or a JSON Patch document.
+// Unlike UpdateEmployee it can clear fields, and JSON Patch test operations make edits conditional.
+func (s *Server) PatchEmployee(c *fiber.Ctx) error {
+	id, err := strconv.Atoi(c.Params("id"))
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"status": "fail", "message": err.Error()})
+	}
+
+	var apply func(doc, patch []byte) ([]byte, error)
+	mediaType, _, _ := mime.ParseMediaType(c.Get(fiber.HeaderContentType))
+	switch mediaType {
+	case mergePatchMediaType:
+		apply = jsonpatch.MergePatch
+	case jsonPatchMediaType:
+		apply = jsonpatch.Apply
+	default:
+		return c.Status(fiber.StatusUnsupportedMediaType).JSON(fiber.Map{"status": "fail",
+			"error": "Content-Type must be " + mergePatchMediaType + " or " + jsonPatchMediaType})
+	}
+
+	// The custom field definitions and salary bands are read up front, the patch runs inside the transaction
+	definitions, err := s.DBHelper.GetCustomFieldDefinitions()
+	if err != nil {
+		log.Println("PatchEmployee: error getting custom field definitions from DB", err)
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"status": "fail", "data": fiber.Map{"error": err}})
+	}
+	bands, converter, err := s.salaryBands()
+	if err != nil {
+		log.Println("PatchEmployee: error getting salary bands from DB", err)
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"status": "fail", "data": fiber.Map{"error": err}})
+	}
+
+	// fiber reuses the body buffer once the handler returns
+	patch := append([]byte(nil), c.Body()...)
+
+	// Use a channel to communicate errors and results back from the goroutine
+	resultChan := make(chan models.Employee, 1)
+	errChan := make(chan error, 1)
+	var warnings models.ValidationErrors
+
+	// Start a goroutine to execute the database operation
+	go func() {
+		patchedEmployee, err := s.DBHelper.PatchEmployee(id, func(current models.Employee) (models.Employee, error) {
+			patched, err := applyEmployeePatch(current, patch, apply, definitions, s.Config.Currency.Base)
+			// salaries are only checked against their band when the position or salary changes
+			if err != nil || (patched.Position == current.Position && patched.Salary == current.Salary && patched.Currency == current.Currency) {
+				return patched, err
+			}
+			bandWarnings, errs, err := bandViolations(patched, bands, converter)
+			if err != nil {
+				return current, err
+			}
+			if len(errs) > 0 {
+				return current, errPatchRejected{errs}
+			}
+			warnings = bandWarnings
+			return patched, nil
+		})
+		if err != nil {
+			errChan <- err
+			return
+		}
+		resultChan <- s.writtenWithLeaveStatus(patchedEmployee)
+	}()
+
+	// Wait for the database operation to complete
+	select {
+	case patchedEmployee := <-resultChan:
+		markWrite(c)
+		return c.Status(fiber.StatusOK).JSON(withWarnings(fiber.Map{"status": "success", "updatedEmployeeDetails": patchedEmployee}, warnings))
+	case err := <-errChan:
+		var (
+			rejected    errPatchRejected
+			missingRate *models.MissingRateError
+			overflow    *models.OverflowError
+		)
+		switch {
+		case errors.As(err, &rejected):
+			return validationFailed(c, rejected.errs)
+		case errors.Is(err, providers.ErrEmployeeNotFound):
+			return c.Status(fiber.StatusNotFound).JSON(fiber.Map{"status": "fail", "error": err.Error()})
+		case errors.Is(err, jsonpatch.ErrInvalidPatch):
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"status": "fail", "error": err.Error()})
+		case errors.Is(err, jsonpatch.ErrTestFailed), errors.Is(err, providers.ErrEmailTaken), errors.As(err, &missingRate),
+			errors.As(err, &overflow):
+			return c.Status(fiber.StatusConflict).JSON(fiber.Map{"status": "fail", "error": err.Error()})
+		case errors.Is(err, jsonpatch.ErrPathNotFound):
+			return c.Status(fiber.StatusUnprocessableEntity).JSON(fiber.Map{"status": "fail", "error": err.Error()})
+		case errors.Is(err, providers.ErrManagerNotFound):
+			return validationFailed(c, models.ValidationErrors{{Field: "managerId", Code: models.CodeInvalidValue, Message: err.Error()}})
+		}
+		log.Println("PatchEmployee: error patching employee in the database", err)
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"status": "fail", "data": fiber.Map{"error": err}})
+	}
+}
+
+// applyEmployeePatch applies patch to the JSON form of current and validates the result as a whole, a cleared
+// currency falls back to base like the currency of a new employee
+func applyEmployeePatch(current models.Employee, patch []byte, apply func(doc, patch []byte) ([]byte, error),
+	definitions []models.CustomFieldDefinition, base models.Currency) (models.Employee, error) {
+	doc, err := json.Marshal(current)
+	if err != nil {
+		return current, err
+	}
+	patchedDoc, err := apply(doc, patch)
+	if err != nil {
+		return current, err
+	}
+
+	var known, members map[string]json.RawMessage
+	if err := json.Unmarshal(doc, &known); err != nil {
+		return current, err
+	}
+	if err := json.Unmarshal(patchedDoc, &members); err != nil {
+		return current, errPatchRejected{models.ValidationErrors{{Field: "", Code: models.CodeInvalidType,
+			Message: "the patched employee must be an object"}}}
+	}
+
+	// Decode member by member, so type errors and unknown fields are reported per field
+	var (
+		employee models.Employee
+		errs     models.ValidationErrors
+	)
+	names := make([]string, 0, len(members))
+	for name := range members {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		if _, ok := known[name]; !ok {
+			errs = append(errs, models.FieldError{Field: name, Code: models.CodeUnknownField, Message: name + " is not an employee field"})
+			continue
+		}
+		member, _ := json.Marshal(map[string]json.RawMessage{name: members[name]})
+		if err := json.Unmarshal(member, &employee); err != nil {
+			var amountErr *models.AmountError
+			if errors.As(err, &amountErr) {
+				errs = append(errs, amountErr.FieldError(name))
+				continue
+			}
+			message := name + ": " + err.Error()
+			var typeErr *json.UnmarshalTypeError
+			if errors.As(err, &typeErr) {
+				message = name + " must not be a " + typeErr.Value
+			}
+			errs = append(errs, models.FieldError{Field: name, Code: models.CodeInvalidType, Message: message})
+		}
+	}
+
+	if employee.ID != current.ID {
+		errs = append(errs, models.FieldError{Field: "ID", Code: models.CodeInvalidValue, Message: "ID cannot be changed"})
+		employee.ID = current.ID
+	}
+	// timestamps are maintained by the database
+	employee.CreatedAt, employee.UpdatedAt = current.CreatedAt, current.UpdatedAt
+
+	// cleared fields fall back to the defaults of a new employee
+	if employee.Currency == "" {
+		employee.Currency = base
+	}
+	employee.ApplyDefaults()
+
+	// fields that could not be decoded were reported already
+	rejected := make(map[string]bool, len(errs))
+	for _, fieldErr := range errs {
+		rejected[fieldErr.Field] = true
+	}
+	for _, fieldErr := range employee.Validate(models.EmployeePatchRules) {
+		if !rejected[fieldErr.Field] {
+			errs = append(errs, fieldErr)
+		}
+	}
+
+	// values of custom fields whose definition was deleted are kept as long as the patch leaves them alone
+	for _, fieldErr := range models.ValidateCustomFields(definitions, employee.CustomFields) {
+		name := fieldErr.Field[len("customFields."):]
+		stored, ok := current.CustomFields[name]
+		if fieldErr.Code == models.CodeUnknownField && ok && reflect.DeepEqual(stored, employee.CustomFields[name]) {
+			continue
+		}
+		errs = append(errs, fieldErr)
+	}
+
+	if len(errs) > 0 {
+		return current, errPatchRejected{errs}
+	}
+	return employee, nil
+}
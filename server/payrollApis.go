@@ -0,0 +1,362 @@
+package server
+
+import (
+	"Techiebulter/interview/backend/models"
+	"Techiebulter/interview/backend/providers"
+	"Techiebulter/interview/backend/utils/payslip"
+	"bytes"
+	"errors"
+	"fmt"
+	"log"
+	"strconv"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// payrollEmployeesPage is how many employees a payroll run or report reads at a time
+const payrollEmployeesPage = 500
+
+// allEmployees reads every employee a page at a time
+func allEmployees(dbHelper providers.DbHelperProvider) ([]models.Employee, error) {
+	var employees []models.Employee
+	for afterID := 0; ; {
+		page, err := dbHelper.SearchEmployees(models.EmployeeFilter{}, afterID, payrollEmployeesPage)
+		if err != nil {
+			return nil, err
+		}
+		employees = append(employees, page...)
+		if len(page) < payrollEmployeesPage {
+			return employees, nil
+		}
+		afterID = page[len(page)-1].ID
+	}
+}
+
+// payrollFailed answers the errors of payroll runs, anything else is logged with message as a server error
+func payrollFailed(c *fiber.Ctx, err error, message string) error {
+	var (
+		missingRate *models.MissingRateError
+		overflow    *models.OverflowError
+	)
+	switch {
+	case errors.As(err, &missingRate), errors.As(err, &overflow):
+		return c.Status(fiber.StatusConflict).JSON(fiber.Map{"status": "fail", "error": err.Error()})
+	case errors.Is(err, providers.ErrPayrollRunNotFound), errors.Is(err, providers.ErrPayslipNotFound):
+		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{"status": "fail", "error": err.Error()})
+	case errors.Is(err, providers.ErrPayrollRunApproved):
+		return c.Status(fiber.StatusConflict).JSON(fiber.Map{"status": "fail", "error": err.Error()})
+	}
+	log.Println(message, err)
+	return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"status": "fail", "data": fiber.Map{"error": err}})
+}
+
+// payrollRules are the configured rules payslips are computed by
+func (s *Server) payrollRules() models.PayrollRules {
+	rules := models.PayrollRules{SalaryBasis: s.Config.Payroll.SalaryBasis, Components: []models.PayComponent{},
+		Overtime: s.overtimeRules()}
+	for _, component := range s.Config.Payroll.Components {
+		rules.Components = append(rules.Components, models.PayComponent{Name: component.Name, Kind: component.Kind,
+			Percent: component.Percent, Amount: component.Amount})
+	}
+	return rules
+}
+
+// computePayroll computes the payroll of period from every employee, the salary history up to the end of
+// the period and the approved timesheets of the weeks starting in it, exchanging amounts at the rates of
+// the last day of the period
+func (s *Server) computePayroll(dbHelper providers.DbHelperProvider, period models.PayPeriod) (models.PayrollRun, error) {
+	employees, err := allEmployees(dbHelper)
+	if err != nil {
+		return models.PayrollRun{}, err
+	}
+
+	history, err := dbHelper.GetSalaryHistory(0, period.End())
+	if err != nil {
+		return models.PayrollRun{}, err
+	}
+
+	var timesheets []models.Timesheet
+	for week := models.WeekOf(period.Start()); !week.After(period.End().Time); week = (models.Date{Time: week.AddDate(0, 0, 7)}) {
+		if week.Before(period.Start().Time) {
+			continue
+		}
+		approved, err := dbHelper.GetTimesheets(models.TimesheetFilter{WeekStart: week, Status: models.TimesheetApproved})
+		if err != nil {
+			return models.PayrollRun{}, err
+		}
+		timesheets = append(timesheets, approved...)
+	}
+
+	end := period.End()
+	rates, err := dbHelper.GetExchangeRates(models.ExchangeRateFilter{Until: &end})
+	if err != nil {
+		return models.PayrollRun{}, err
+	}
+
+	return models.NewPayrollRun(period, s.payrollRules(), employees, history, timesheets,
+		models.NewConverter(s.Config.Currency.Base, end, rates))
+}
+
+// PreviewPayrollRun computes the payroll of a period without storing it, a dry run
+func (s *Server) PreviewPayrollRun(c *fiber.Ctx) error {
+	var request models.PayrollRunRequest
+	if err := c.BodyParser(&request); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"status": "fail", "message": err.Error()})
+	}
+	if errs := models.PayrollRunRequestRules.Validate(&request); len(errs) > 0 {
+		return validationFailed(c, errs)
+	}
+	dbHelper := s.readHelper(c)
+
+	// Use a channel to communicate errors and results back from the goroutine
+	resultChan := make(chan models.PayrollRun, 1)
+	errChan := make(chan error, 1)
+
+	go func() {
+		run, err := s.computePayroll(dbHelper, request.Period)
+		if err != nil {
+			errChan <- err
+			return
+		}
+		resultChan <- run
+	}()
+
+	select {
+	case run := <-resultChan:
+		return c.Status(fiber.StatusOK).JSON(fiber.Map{"status": "success", "run": run})
+	case err := <-errChan:
+		return payrollFailed(c, err, "PreviewPayrollRun: error getting results from DB")
+	}
+}
+
+// CreatePayrollRun computes the payroll of a period and stores it as a draft, replacing an earlier draft
+// of the period. Approved periods are not run again.
+func (s *Server) CreatePayrollRun(c *fiber.Ctx) error {
+	var request models.PayrollRunRequest
+	if err := c.BodyParser(&request); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"status": "fail", "message": err.Error()})
+	}
+	if errs := models.PayrollRunRequestRules.Validate(&request); len(errs) > 0 {
+		return validationFailed(c, errs)
+	}
+	createdBy := principalOf(c).String()
+
+	// Use a channel to communicate errors and results back from the goroutine
+	resultChan := make(chan models.PayrollRun, 1)
+	errChan := make(chan error, 1)
+
+	go func() {
+		run, err := s.computePayroll(s.DBHelper.ReadFromPrimary(), request.Period)
+		if err != nil {
+			errChan <- err
+			return
+		}
+		run.CreatedBy = createdBy
+		saved, err := s.DBHelper.SavePayrollRun(run)
+		if err != nil {
+			errChan <- err
+			return
+		}
+		resultChan <- saved
+	}()
+
+	select {
+	case run := <-resultChan:
+		markWrite(c)
+		return c.Status(fiber.StatusOK).JSON(fiber.Map{"status": "success", "run": run})
+	case err := <-errChan:
+		return payrollFailed(c, err, "CreatePayrollRun: error inserting data in the database")
+	}
+}
+
+// GetPayrollRuns lists the payroll runs by period, without their payslips
+func (s *Server) GetPayrollRuns(c *fiber.Ctx) error {
+	dbHelper := s.readHelper(c)
+
+	// Use a channel to communicate errors and results back from the goroutine
+	resultChan := make(chan []models.PayrollRun, 1)
+	errChan := make(chan error, 1)
+
+	go func() {
+		runs, err := dbHelper.GetPayrollRuns()
+		if err != nil {
+			errChan <- err
+			return
+		}
+		resultChan <- runs
+	}()
+
+	select {
+	case runs := <-resultChan:
+		return c.Status(fiber.StatusOK).JSON(fiber.Map{"status": "success", "runs": runs})
+	case err := <-errChan:
+		return payrollFailed(c, err, "GetPayrollRuns: error getting results from DB")
+	}
+}
+
+// GetPayrollRun reads a payroll run with its payslips
+func (s *Server) GetPayrollRun(c *fiber.Ctx) error {
+	id, err := strconv.Atoi(c.Params("id"))
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"status": "fail", "message": "Invalid payroll run ID"})
+	}
+	dbHelper := s.readHelper(c)
+
+	// Use a channel to communicate errors and results back from the goroutine
+	resultChan := make(chan models.PayrollRun, 1)
+	errChan := make(chan error, 1)
+
+	go func() {
+		run, err := dbHelper.GetPayrollRun(id)
+		if err != nil {
+			errChan <- err
+			return
+		}
+		resultChan <- run
+	}()
+
+	select {
+	case run := <-resultChan:
+		return c.Status(fiber.StatusOK).JSON(fiber.Map{"status": "success", "run": run})
+	case err := <-errChan:
+		return payrollFailed(c, err, "GetPayrollRun: error getting result from DB")
+	}
+}
+
+// ApprovePayrollRun locks a draft payroll run, its payslips become visible to the employees
+func (s *Server) ApprovePayrollRun(c *fiber.Ctx) error {
+	id, err := strconv.Atoi(c.Params("id"))
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"status": "fail", "message": "Invalid payroll run ID"})
+	}
+	approvedBy := principalOf(c).String()
+
+	// Use a channel to communicate errors and results back from the goroutine
+	resultChan := make(chan models.PayrollRun, 1)
+	errChan := make(chan error, 1)
+
+	go func() {
+		run, err := s.DBHelper.ApprovePayrollRun(id, approvedBy)
+		if err != nil {
+			errChan <- err
+			return
+		}
+		resultChan <- run
+	}()
+
+	select {
+	case run := <-resultChan:
+		markWrite(c)
+		return c.Status(fiber.StatusOK).JSON(fiber.Map{"status": "success", "run": run})
+	case err := <-errChan:
+		return payrollFailed(c, err, "ApprovePayrollRun: error updating payroll run in DB")
+	}
+}
+
+// GetSalaryHistory lists the salary changes of an employee effective on or before the until query
+// parameter, by default today
+func (s *Server) GetSalaryHistory(c *fiber.Ctx) error {
+	id, err := strconv.Atoi(c.Params("id"))
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"status": "fail", "message": "Invalid employee ID"})
+	}
+	until, fieldErr := queryDate(c, "until", models.NewDate(time.Now()))
+	if fieldErr != nil {
+		return validationFailed(c, models.ValidationErrors{*fieldErr})
+	}
+	dbHelper := s.readHelper(c)
+
+	// Use a channel to communicate errors and results back from the goroutine
+	resultChan := make(chan []models.SalaryChange, 1)
+	errChan := make(chan error, 1)
+
+	go func() {
+		history, err := dbHelper.GetSalaryHistory(id, until)
+		if err != nil {
+			errChan <- err
+			return
+		}
+		resultChan <- history
+	}()
+
+	select {
+	case history := <-resultChan:
+		return c.Status(fiber.StatusOK).JSON(fiber.Map{"status": "success", "history": history})
+	case err := <-errChan:
+		return payrollFailed(c, err, "GetSalaryHistory: error getting results from DB")
+	}
+}
+
+// GetPayslips lists the payslips of an employee in approved payroll runs by period
+func (s *Server) GetPayslips(c *fiber.Ctx) error {
+	id, err := strconv.Atoi(c.Params("id"))
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"status": "fail", "message": "Invalid employee ID"})
+	}
+	dbHelper := s.readHelper(c)
+
+	// Use a channel to communicate errors and results back from the goroutine
+	resultChan := make(chan []models.Payslip, 1)
+	errChan := make(chan error, 1)
+
+	go func() {
+		payslips, err := dbHelper.GetPayslips(id)
+		if err != nil {
+			errChan <- err
+			return
+		}
+		resultChan <- payslips
+	}()
+
+	select {
+	case payslips := <-resultChan:
+		return c.Status(fiber.StatusOK).JSON(fiber.Map{"status": "success", "payslips": payslips})
+	case err := <-errChan:
+		return payrollFailed(c, err, "GetPayslips: error getting results from DB")
+	}
+}
+
+// GetPayslipPDF renders the approved payslip of an employee for a period as a PDF document branded with
+// the configured company
+func (s *Server) GetPayslipPDF(c *fiber.Ctx) error {
+	id, err := strconv.Atoi(c.Params("id"))
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"status": "fail", "message": "Invalid employee ID"})
+	}
+	period := models.PayPeriod(c.Params("period"))
+	if !period.IsValid() {
+		return validationFailed(c, models.ValidationErrors{{Field: "period", Code: models.CodeInvalidFormat,
+			Message: "period must be a month like 2006-01"}})
+	}
+	dbHelper := s.readHelper(c)
+	branding := payslip.Branding{CompanyName: s.Config.Branding.CompanyName, CompanyAddress: s.Config.Branding.CompanyAddress,
+		Logo: s.Config.Branding.Logo, Color: s.Config.Branding.Color}
+
+	// Use a channel to communicate errors and results back from the goroutine
+	resultChan := make(chan []byte, 1)
+	errChan := make(chan error, 1)
+
+	go func() {
+		found, err := dbHelper.GetPayslip(id, period)
+		if err != nil {
+			errChan <- err
+			return
+		}
+		var document bytes.Buffer
+		if err := payslip.Render(&document, found, branding); err != nil {
+			errChan <- err
+			return
+		}
+		resultChan <- document.Bytes()
+	}()
+
+	select {
+	case document := <-resultChan:
+		c.Set(fiber.HeaderContentType, "application/pdf")
+		c.Set(fiber.HeaderContentDisposition, fmt.Sprintf(`attachment; filename="payslip-%d-%s.pdf"`, id, period))
+		return c.Status(fiber.StatusOK).Send(document)
+	case err := <-errChan:
+		return payrollFailed(c, err, "GetPayslipPDF: error rendering payslip")
+	}
+}
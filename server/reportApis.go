@@ -0,0 +1,120 @@
+package server
+
+import (
+	"Techiebulter/interview/backend/models"
+	"errors"
+	"log"
+	"strings"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// GetCompensationReport describes the salaries of the employees employed on a day by position, department
+// or location, normalized to the base currency or another one. Groups smaller than the configured minimum
+// are left out, reports are served from the cache until the configured TTL has passed.
+func (s *Server) GetCompensationReport(c *fiber.Ctx) error {
+	asOf, fieldErr := queryDate(c, "asOf", models.NewDate(time.Now()))
+	if fieldErr != nil {
+		return validationFailed(c, models.ValidationErrors{*fieldErr})
+	}
+	query := models.SalaryReportQuery{AsOf: asOf, GroupBy: models.SalaryGroupBy(c.Query("groupBy", string(models.GroupByPosition))),
+		Currency: models.Currency(c.Query("currency", string(s.Config.Currency.Base)))}
+	if errs := models.SalaryReportQueryRules.Validate(&query); len(errs) > 0 {
+		return validationFailed(c, errs)
+	}
+	key := strings.Join([]string{query.AsOf.String(), string(query.GroupBy), string(query.Currency)}, "|")
+	if report, ok := s.compensationReports.Get(key); ok {
+		return c.Status(fiber.StatusOK).JSON(fiber.Map{"status": "success", "report": report})
+	}
+	dbHelper := s.readHelper(c)
+
+	// Use a channel to communicate errors and results back from the goroutine
+	resultChan := make(chan models.CompensationReport, 1)
+	errChan := make(chan error, 1)
+
+	go func() {
+		employees, err := allEmployees(dbHelper)
+		if err != nil {
+			errChan <- err
+			return
+		}
+		history, err := dbHelper.GetSalaryHistory(0, asOf)
+		if err != nil {
+			errChan <- err
+			return
+		}
+		rates, err := dbHelper.GetExchangeRates(models.ExchangeRateFilter{Until: &asOf})
+		if err != nil {
+			errChan <- err
+			return
+		}
+		report, err := models.NewCompensationReport(query, s.Config.Reports.MinGroupSize, employees, history,
+			models.NewConverter(s.Config.Currency.Base, asOf, rates))
+		if err != nil {
+			errChan <- err
+			return
+		}
+		resultChan <- report
+	}()
+
+	select {
+	case report := <-resultChan:
+		s.compensationReports.Set(key, report)
+		return c.Status(fiber.StatusOK).JSON(fiber.Map{"status": "success", "report": report})
+	case err := <-errChan:
+		var (
+			missingRate *models.MissingRateError
+			overflow    *models.OverflowError
+		)
+		if errors.As(err, &missingRate) || errors.As(err, &overflow) {
+			return c.Status(fiber.StatusConflict).JSON(fiber.Map{"status": "fail", "error": err.Error()})
+		}
+		log.Println("GetCompensationReport: error getting results from DB", err)
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"status": "fail", "data": fiber.Map{"error": err}})
+	}
+}
+
+// GetHeadcountTrend counts the employees by month, quarter or year from their hire and termination dates,
+// trends are served from the cache until the configured TTL has passed
+func (s *Server) GetHeadcountTrend(c *fiber.Ctx) error {
+	to, fieldErr := queryDate(c, "to", models.NewDate(time.Now()))
+	if fieldErr != nil {
+		return validationFailed(c, models.ValidationErrors{*fieldErr})
+	}
+	from, fieldErr := queryDate(c, "from", models.NewDate(to.AddDate(-1, 0, 1)))
+	if fieldErr != nil {
+		return validationFailed(c, models.ValidationErrors{*fieldErr})
+	}
+	query := models.HeadcountQuery{From: from, To: to, Interval: models.HeadcountInterval(c.Query("interval", string(models.IntervalMonth)))}
+	if errs := models.HeadcountQueryRules.Validate(&query); len(errs) > 0 {
+		return validationFailed(c, errs)
+	}
+	key := strings.Join([]string{query.From.String(), query.To.String(), string(query.Interval)}, "|")
+	if trend, ok := s.headcountTrends.Get(key); ok {
+		return c.Status(fiber.StatusOK).JSON(fiber.Map{"status": "success", "trend": trend})
+	}
+	dbHelper := s.readHelper(c)
+
+	// Use a channel to communicate errors and results back from the goroutine
+	resultChan := make(chan models.HeadcountTrend, 1)
+	errChan := make(chan error, 1)
+
+	go func() {
+		employees, err := allEmployees(dbHelper)
+		if err != nil {
+			errChan <- err
+			return
+		}
+		resultChan <- models.NewHeadcountTrend(query, employees)
+	}()
+
+	select {
+	case trend := <-resultChan:
+		s.headcountTrends.Set(key, trend)
+		return c.Status(fiber.StatusOK).JSON(fiber.Map{"status": "success", "trend": trend})
+	case err := <-errChan:
+		log.Println("GetHeadcountTrend: error getting results from DB", err)
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"status": "fail", "data": fiber.Map{"error": err}})
+	}
+}
@@ -1,25 +1,52 @@
 package server
 
 import (
+	"Techiebulter/interview/backend/models"
+
 	"github.com/gofiber/fiber/v2"
+	"github.com/gofiber/fiber/v2/middleware/cors"
 	lg "github.com/gofiber/fiber/v2/middleware/logger"
 )
 
 // InjectRoutes function keeps all the fiber router end point for the server
 func (srv *Server) InjectRoutes() *fiber.App {
-	app := fiber.New()
-	app.Use(lg.New())
-	// app.Use(cors.New(cors.Config{
-	// 	AllowOrigins:     "http://localhost:3000",
-	// 	AllowHeaders:     "Origin, Content-Type, Accept",
-	// 	AllowMethods:     "GET, POST, PATCH, DELETE",
-	// 	AllowCredentials: true,
-	// }))
+	app := fiber.New(fiber.Config{
+		ReadTimeout:  srv.Config.Server.ReadTimeout,
+		WriteTimeout: srv.Config.Server.WriteTimeout,
+		IdleTimeout:  srv.Config.Server.IdleTimeout,
+	})
+	if srv.Config.Features.RequestLogging {
+		app.Use(lg.New())
+	}
+	if srv.Config.CORS.Enabled {
+		app.Use(cors.New(cors.Config{
+			AllowOrigins:     srv.Config.CORS.AllowOrigins,
+			AllowHeaders:     srv.Config.CORS.AllowHeaders,
+			AllowMethods:     srv.Config.CORS.AllowMethods,
+			AllowCredentials: srv.Config.CORS.AllowCredentials,
+		}))
+	}
 
 	app.Get("/", func(c *fiber.Ctx) error {
 		return c.SendString("you are on /")
 	})
 
+	// in tests responses are checked against the API documentation
+	if srv.Config.Features.ValidateResponses {
+		app.Use(srv.ValidateResponse)
+	}
+
+	// API documentation, the spec must describe every route registered here
+	app.Get("/openapi.json", srv.OpenAPISpec)
+	app.Get("/docs", srv.SwaggerUI)
+	app.Get("/docs/:asset", srv.SwaggerUIAsset)
+
+	// GraphQL reads and writes employees like the REST API, with the same API keys and roles
+	if srv.Config.GraphQL.Enabled {
+		app.Get("/graphql", srv.Authenticate, srv.GraphQL)
+		app.Post("/graphql", srv.Authenticate, srv.GraphQL)
+	}
+
 	api := app.Group("/api")
 
 	api.Get("/", func(c *fiber.Ctx) error {
@@ -27,13 +54,104 @@ func (srv *Server) InjectRoutes() *fiber.App {
 	})
 
 	api.Get("/healthchecker", srv.HealthCheck)
+	api.Get("/readiness", srv.ReadinessCheck)
+
+	// every route registered below this point requires an API key when auth is enabled
+	api.Use(srv.Authenticate)
+
+	// requests that do not match the API documentation are rejected before anything runs
+	if srv.Config.Features.ValidateRequests {
+		api.Use(srv.ValidateRequest)
+	}
+
+	// POST requests with an Idempotency-Key are executed once and replayed to retries
+	api.Use(srv.Idempotent)
+
+	// HR reads and writes every employee, employees only read their own record
+	hrOnly := RequireRole(models.RoleAdmin, models.RoleHR)
+	selfOrHR := RequireSelfOrRole(models.RoleAdmin, models.RoleHR)
+
+	api.Post("/CreateEmpolyee", hrOnly, srv.CreateEmployee)
+	api.Get("/GetEmployeeById/:id", selfOrHR, srv.GetEmployeeById)
+	api.Put("/UpdateEmployee", hrOnly, srv.UpdateEmployee)
+	api.Delete("/DeleteEmployee/:id", hrOnly, srv.DeleteEmployee)
+
+	api.Get("/GetAllEmployees/:page/:limit", hrOnly, srv.GetAllEmployees)
+
+	v1 := api.Group("/v1")
+	v1.Get("/employees/duplicates", hrOnly, srv.GetDuplicateEmployees)
+	v1.Post("/employees/merge", hrOnly, srv.MergeEmployees)
+	v1.Patch("/employees/:id", hrOnly, srv.PatchEmployee)
+	v1.Get("/employees/:id/merges", hrOnly, srv.GetEmployeeMerges)
+
+	// employees ask for leave, or HR for them, and HR or their manager decides on it
+	v1.Post("/leave-types", hrOnly, srv.CreateLeaveType)
+	v1.Get("/leave-types", srv.GetLeaveTypes)
+	v1.Post("/employees/:id/leave-requests", selfOrHR, srv.CreateLeaveRequest)
+	v1.Get("/employees/:id/leave-requests", selfOrHR, srv.GetLeaveRequests)
+	v1.Get("/employees/:id/leave-balances", selfOrHR, srv.GetLeaveBalances)
+	v1.Post("/leave-requests/:id/approve", srv.ApproveLeaveRequest)
+	v1.Post("/leave-requests/:id/reject", srv.RejectLeaveRequest)
+	v1.Post("/leave-requests/:id/cancel", srv.CancelLeaveRequest)
+
+	// employees clock in and out or submit weekly timesheets, HR or their manager approves timesheets and HR
+	// reports the hours
+	v1.Post("/employees/:id/clock-in", selfOrHR, srv.ClockIn)
+	v1.Post("/employees/:id/clock-out", selfOrHR, srv.ClockOut)
+	v1.Get("/employees/:id/attendance", selfOrHR, srv.GetAttendance)
+	v1.Post("/employees/:id/timesheets", selfOrHR, srv.SubmitTimesheet)
+	v1.Get("/employees/:id/timesheets", selfOrHR, srv.GetTimesheets)
+	v1.Post("/timesheets/:id/approve", srv.ApproveTimesheet)
+	v1.Post("/timesheets/:id/reject", srv.RejectTimesheet)
+	v1.Get("/reports/weekly-hours", hrOnly, srv.GetWeeklyHours)
+
+	// HR runs and approves the payroll of a month, employees read their salary history and approved payslips, also as PDF
+	v1.Post("/payroll/runs/preview", hrOnly, srv.PreviewPayrollRun)
+	v1.Post("/payroll/runs", hrOnly, srv.CreatePayrollRun)
+	v1.Get("/payroll/runs", hrOnly, srv.GetPayrollRuns)
+	v1.Get("/payroll/runs/:id", hrOnly, srv.GetPayrollRun)
+	v1.Post("/payroll/runs/:id/approve", hrOnly, srv.ApprovePayrollRun)
+	v1.Get("/employees/:id/salary-history", selfOrHR, srv.GetSalaryHistory)
+	v1.Get("/employees/:id/payslips", selfOrHR, srv.GetPayslips)
+	v1.Get("/employees/:id/payslips/:period.pdf", selfOrHR, srv.GetPayslipPDF)
+
+	// HR keeps the exchange rates salaries in other currencies are normalized by and reports salaries by group
+	v1.Post("/exchange-rates", hrOnly, srv.CreateExchangeRate)
+	v1.Post("/exchange-rates/csv", hrOnly, srv.UploadExchangeRates)
+	v1.Get("/exchange-rates", hrOnly, srv.GetExchangeRates)
+	v1.Get("/reports/salaries", hrOnly, srv.GetSalaryReport)
+
+	// compensation analytics aggregate salaries and headcount without exposing small groups
+	v1.Get("/reports/compensation", hrOnly, srv.GetCompensationReport)
+	v1.Get("/reports/headcount", hrOnly, srv.GetHeadcountTrend)
+
+	// HR defines salary bands per position, salaries are checked against them when they are set and reported by group
+	v1.Post("/salary-bands", hrOnly, srv.CreateSalaryBand)
+	v1.Get("/salary-bands", hrOnly, srv.GetSalaryBands)
+	v1.Put("/salary-bands/:id", hrOnly, srv.UpdateSalaryBand)
+	v1.Delete("/salary-bands/:id", hrOnly, srv.DeleteSalaryBand)
+	v1.Get("/reports/pay-equity", hrOnly, srv.GetPayEquityReport)
+
+	// employee changes are pushed to HR dashboards as they happen
+	v1.Get("/events", hrOnly, srv.StreamEvents)
+	v1.Get("/events/ws", hrOnly, srv.UpgradeEvents, srv.EventsWebSocket())
+
+	adminOnly := RequireRole(models.RoleAdmin)
+
+	// admins extend the employee schema with custom fields, HR reads them to fill them in
+	v1.Post("/custom-fields", adminOnly, srv.CreateCustomField)
+	v1.Get("/custom-fields", hrOnly, srv.GetCustomFields)
+	v1.Delete("/custom-fields/:name", adminOnly, srv.DeleteCustomField)
 
-	api.Post("/CreateEmpolyee", srv.CreateEmployee)
-	api.Get("/GetEmployeeById/:id", srv.GetEmployeeById)
-	api.Put("/UpdateEmployee", srv.UpdateEmployee)
-	api.Delete("/DeleteEmployee/:id", srv.DeleteEmployee)
+	// employee changes are posted to the subscribed URLs, signed and retried until they arrive
+	v1.Post("/webhooks", adminOnly, srv.CreateWebhook)
+	v1.Get("/webhooks", adminOnly, srv.GetWebhooks)
+	v1.Delete("/webhooks/:id", adminOnly, srv.DeleteWebhook)
+	v1.Get("/webhooks/:id/deliveries", adminOnly, srv.GetWebhookDeliveries)
+	v1.Post("/webhooks/:id/deliveries/:deliveryId/redeliver", adminOnly, srv.RedeliverWebhook)
 
-	api.Get("/GetAllEmployees/:page/:limit", srv.GetAllEmployees)
+	// employee events are published to the outbox sinks by a relay, this shows how far behind it is
+	v1.Get("/outbox", adminOnly, srv.GetOutboxMetrics)
 
 	return app
 }
@@ -0,0 +1,278 @@
+package server
+
+import (
+	"Techiebulter/interview/backend/models"
+	"Techiebulter/interview/backend/providers"
+	"errors"
+	"log"
+	"strconv"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// salaryBandFailed answers the errors of salary bands and of comparing salaries to them, anything else is
+// logged with message as a server error
+func salaryBandFailed(c *fiber.Ctx, err error, message string) error {
+	var (
+		missingRate *models.MissingRateError
+		overflow    *models.OverflowError
+	)
+	switch {
+	case errors.Is(err, providers.ErrSalaryBandNotFound):
+		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{"status": "fail", "error": err.Error()})
+	case errors.Is(err, providers.ErrSalaryBandExists), errors.As(err, &missingRate), errors.As(err, &overflow):
+		return c.Status(fiber.StatusConflict).JSON(fiber.Map{"status": "fail", "error": err.Error()})
+	}
+	log.Println(message, err)
+	return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"status": "fail", "data": fiber.Map{"error": err}})
+}
+
+// salaryBands reads the salary bands with a converter for the rates effective today, which salaries are
+// exchanged to the currencies of their bands with
+func (s *Server) salaryBands() ([]models.SalaryBand, models.Converter, error) {
+	bands, err := s.DBHelper.GetSalaryBands()
+	if err != nil || len(bands) == 0 {
+		return bands, models.Converter{}, err
+	}
+	today := models.NewDate(time.Now())
+	rates, err := s.DBHelper.GetExchangeRates(models.ExchangeRateFilter{Until: &today})
+	if err != nil {
+		return nil, models.Converter{}, err
+	}
+	return bands, models.NewConverter(s.Config.Currency.Base, today, rates), nil
+}
+
+// checkSalaryBand compares the salary of a new employee, or of an employee as update leaves them, to the
+// band of their position. A salary outside a rejecting band is returned in errs, outside a warning band in
+// warnings. Updates that change neither the position nor the salary or its currency are not checked, so
+// employees outside a band created after they were paid can still be changed otherwise.
+func (s *Server) checkSalaryBand(employee models.Employee, creating bool) (warnings, errs models.ValidationErrors, err error) {
+	if !creating && employee.Position == "" && employee.Salary.IsZero() && employee.Currency == "" {
+		return nil, nil, nil
+	}
+	bands, converter, err := s.salaryBands()
+	if err != nil || len(bands) == 0 {
+		return nil, nil, err
+	}
+	if !creating {
+		current, err := s.DBHelper.GetEmployeeById(employee.ID)
+		if errors.Is(err, providers.ErrEmployeeNotFound) {
+			// the update itself reports the missing employee
+			return nil, nil, nil
+		}
+		if err != nil {
+			return nil, nil, err
+		}
+		if employee.Position != "" {
+			current.Position = employee.Position
+		}
+		if !employee.Salary.IsZero() {
+			current.Salary = employee.Salary
+		}
+		if employee.Currency != "" {
+			current.Currency = employee.Currency
+		}
+		employee = current
+	}
+	return bandViolations(employee, bands, converter)
+}
+
+// bandViolations sorts a salary outside the band of the position of employee into warnings or errs by
+// the enforcement of the band
+func bandViolations(employee models.Employee, bands []models.SalaryBand, converter models.Converter) (warnings, errs models.ValidationErrors, err error) {
+	check, err := models.CheckSalaryBand(employee, bands, converter)
+	if err != nil || check == nil {
+		return nil, nil, err
+	}
+	if check.Band.Enforcement == models.EnforceReject {
+		return nil, models.ValidationErrors{check.FieldError()}, nil
+	}
+	return models.ValidationErrors{check.FieldError()}, nil, nil
+}
+
+// withWarnings adds warnings to a response body if there are any
+func withWarnings(body fiber.Map, warnings models.ValidationErrors) fiber.Map {
+	if len(warnings) > 0 {
+		body["warnings"] = warnings
+	}
+	return body
+}
+
+// CreateSalaryBand defines the salary range of a position
+func (s *Server) CreateSalaryBand(c *fiber.Ctx) error {
+	var band models.SalaryBand
+
+	if errs, err := parseBody(c, &band); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"status": "fail", "message": err.Error()})
+	} else if len(errs) > 0 {
+		return validationFailed(c, errs)
+	}
+	if errs := band.Validate(); len(errs) > 0 {
+		return validationFailed(c, errs)
+	}
+
+	// Use a channel to communicate errors and results back from the goroutine
+	resultChan := make(chan models.SalaryBand, 1)
+	errChan := make(chan error, 1)
+
+	go func() {
+		created, err := s.DBHelper.CreateSalaryBand(band)
+		if err != nil {
+			errChan <- err
+			return
+		}
+		resultChan <- created
+	}()
+
+	select {
+	case created := <-resultChan:
+		markWrite(c)
+		return c.Status(fiber.StatusOK).JSON(fiber.Map{"status": "success", "salaryBand": created})
+	case err := <-errChan:
+		return salaryBandFailed(c, err, "CreateSalaryBand: error inserting data in the database")
+	}
+}
+
+// GetSalaryBands lists the salary bands
+func (s *Server) GetSalaryBands(c *fiber.Ctx) error {
+	dbHelper := s.readHelper(c)
+
+	// Use a channel to communicate errors and results back from the goroutine
+	resultChan := make(chan []models.SalaryBand, 1)
+	errChan := make(chan error, 1)
+
+	go func() {
+		bands, err := dbHelper.GetSalaryBands()
+		if err != nil {
+			errChan <- err
+			return
+		}
+		resultChan <- bands
+	}()
+
+	select {
+	case bands := <-resultChan:
+		return c.Status(fiber.StatusOK).JSON(fiber.Map{"status": "success", "salaryBands": bands})
+	case err := <-errChan:
+		log.Println("GetSalaryBands: error getting results from DB", err)
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"status": "fail", "data": fiber.Map{"error": err}})
+	}
+}
+
+// UpdateSalaryBand replaces a salary band. Employees already paid outside the changed band are listed by
+// the pay equity report, they are not checked until their position or salary changes.
+func (s *Server) UpdateSalaryBand(c *fiber.Ctx) error {
+	id, err := strconv.Atoi(c.Params("id"))
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"status": "fail", "message": err.Error()})
+	}
+	var band models.SalaryBand
+
+	if errs, err := parseBody(c, &band); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"status": "fail", "message": err.Error()})
+	} else if len(errs) > 0 {
+		return validationFailed(c, errs)
+	}
+	band.ID = id
+	if errs := band.Validate(); len(errs) > 0 {
+		return validationFailed(c, errs)
+	}
+
+	// Use a channel to communicate errors and results back from the goroutine
+	resultChan := make(chan models.SalaryBand, 1)
+	errChan := make(chan error, 1)
+
+	go func() {
+		updated, err := s.DBHelper.UpdateSalaryBand(band)
+		if err != nil {
+			errChan <- err
+			return
+		}
+		resultChan <- updated
+	}()
+
+	select {
+	case updated := <-resultChan:
+		markWrite(c)
+		return c.Status(fiber.StatusOK).JSON(fiber.Map{"status": "success", "salaryBand": updated})
+	case err := <-errChan:
+		return salaryBandFailed(c, err, "UpdateSalaryBand: error updating data in the database")
+	}
+}
+
+// DeleteSalaryBand removes a salary band, salaries of its position are no longer checked
+func (s *Server) DeleteSalaryBand(c *fiber.Ctx) error {
+	id, err := strconv.Atoi(c.Params("id"))
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"status": "fail", "message": err.Error()})
+	}
+
+	// Use a channel to communicate errors back from the goroutine
+	errChan := make(chan error, 1)
+
+	go func() {
+		errChan <- s.DBHelper.DeleteSalaryBand(id)
+	}()
+
+	if err := <-errChan; err != nil {
+		return salaryBandFailed(c, err, "DeleteSalaryBand: error deleting salary band from DB")
+	}
+
+	markWrite(c)
+	return c.Status(fiber.StatusOK).JSON(fiber.Map{"status": "success"})
+}
+
+// GetPayEquityReport compares the salaries of the employees employed on a day to the bands of their
+// positions, listing the employees outside their band and the compa-ratios by position, department or location
+func (s *Server) GetPayEquityReport(c *fiber.Ctx) error {
+	asOf, fieldErr := queryDate(c, "asOf", models.NewDate(time.Now()))
+	if fieldErr != nil {
+		return validationFailed(c, models.ValidationErrors{*fieldErr})
+	}
+	query := models.SalaryReportQuery{AsOf: asOf, GroupBy: models.SalaryGroupBy(c.Query("groupBy", string(models.GroupByPosition)))}
+	if errs := models.SalaryReportQueryRules.Validate(&query); len(errs) > 0 {
+		return validationFailed(c, errs)
+	}
+	dbHelper := s.readHelper(c)
+
+	// Use a channel to communicate errors and results back from the goroutine
+	resultChan := make(chan models.PayEquityReport, 1)
+	errChan := make(chan error, 1)
+
+	go func() {
+		bands, err := dbHelper.GetSalaryBands()
+		if err != nil {
+			errChan <- err
+			return
+		}
+		employees, err := allEmployees(dbHelper)
+		if err != nil {
+			errChan <- err
+			return
+		}
+		history, err := dbHelper.GetSalaryHistory(0, asOf)
+		if err != nil {
+			errChan <- err
+			return
+		}
+		rates, err := dbHelper.GetExchangeRates(models.ExchangeRateFilter{Until: &asOf})
+		if err != nil {
+			errChan <- err
+			return
+		}
+		report, err := models.NewPayEquityReport(query, bands, employees, history, models.NewConverter(s.Config.Currency.Base, asOf, rates))
+		if err != nil {
+			errChan <- err
+			return
+		}
+		resultChan <- report
+	}()
+
+	select {
+	case report := <-resultChan:
+		return c.Status(fiber.StatusOK).JSON(fiber.Map{"status": "success", "report": report})
+	case err := <-errChan:
+		return salaryBandFailed(c, err, "GetPayEquityReport: error getting results from DB")
+	}
+}
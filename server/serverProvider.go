@@ -1,65 +1,213 @@
 package server
 
 import (
+	"Techiebulter/interview/backend/config"
+	"Techiebulter/interview/backend/models"
 	"Techiebulter/interview/backend/providers"
 	"Techiebulter/interview/backend/providers/dbHelperProvider"
 	"Techiebulter/interview/backend/providers/dbProvider"
-	"Techiebulter/interview/backend/utils"
-	"log"
-	"net/http"
+	"Techiebulter/interview/backend/providers/sqliteHelperProvider"
+	"Techiebulter/interview/backend/utils/outbox"
+	"Techiebulter/interview/backend/utils/ttlcache"
+	"context"
+	"fmt"
+	"io"
+	"net"
+	"sync"
 
 	"github.com/gofiber/fiber/v2"
-	"github.com/joho/godotenv"
 	"github.com/sirupsen/logrus"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/health"
 )
 
 type Server struct {
-	PGClient providers.PgClientProvider
-	DBHelper providers.DbHelperProvider
-	Handler  *fiber.App
+	Config     *config.Config
+	PGClient   providers.PgClientProvider
+	DBHelper   providers.DbHelperProvider
+	Handler    *fiber.App
+	GRPCServer *grpc.Server
+
+	// Sinks receive every employee event from the outbox relay. New adds the sinks named in OUTBOX_SINKS,
+	// others like the NATS and Kafka sinks of utils/outbox are appended before Start.
+	Sinks []outbox.Sink
+
+	events     *eventHub
+	grpcHealth *health.Server
+	stopping   chan struct{}
+	// outboxDue and webhooksDue wake the outbox relay and the webhook worker
+	outboxDue     chan struct{}
+	webhooksDue   chan struct{}
+	outboxMetrics relayMetrics
+	// background runs the outbox relay and the webhook deliveries, Stop waits for them before closing the database
+	background sync.WaitGroup
+	// compensationReports and headcountTrends keep computed reports for the configured TTL, by query
+	compensationReports *ttlcache.Cache[string, models.CompensationReport]
+	headcountTrends     *ttlcache.Cache[string, models.HeadcountTrend]
 }
 
-func SrvInit() *Server {
+func SrvInit(cfg *config.Config) *Server {
 
-	// load .env file
-	err := godotenv.Load(".env")
-	if err != nil {
-		log.Fatalf("Error loading .env file")
+	// logging is configured first so everything below honours the configured level
+	level, _ := logrus.ParseLevel(cfg.Log.Level)
+	logrus.SetLevel(level)
+	if cfg.Log.Format == "json" {
+		logrus.SetFormatter(&logrus.JSONFormatter{})
 	}
 
-	// psql database connection
-	pgClient := dbProvider.ConnectDB(utils.GetPGSQLConnectionString())
+	var (
+		pgClient providers.PgClientProvider
+		dbHelper providers.DbHelperProvider
+		err      error
+	)
+	switch cfg.Database.Driver {
+	case config.DriverSQLite:
+		// sqlite database file, for installations without a PostgreSQL server
+		pgClient, err = dbProvider.ConnectSQLite(cfg.Database)
+		if err != nil {
+			logrus.Fatalf("Failed to initialize SQLite client: %v", err)
+		}
 
-	// dbHelpProvider contains all db related helper functions aka repository layer
-	dbHelper := dbHelperProvider.NewDBHelper(pgClient.Client())
+		// the SQLite helper migrates its own schema on start
+		dbHelper, err = sqliteHelperProvider.NewSQLiteHelper(pgClient, cfg.Currency.Base)
+		if err != nil {
+			logrus.Fatalf("Failed to migrate SQLite database: %v", err)
+		}
+	default:
+		// psql database connection, optionally starting degraded while it keeps reconnecting
+		pgClient, err = dbProvider.ConnectDB(cfg.Database)
+		if err != nil {
+			if pgClient == nil || !cfg.Database.AllowDegradedStart {
+				logrus.Fatalf("Failed to initialize PostgreSQL client: %v", err)
+			}
+			logrus.Warnf("Starting in degraded mode, readiness reports unhealthy until the database is reachable: %v", err)
+		}
 
-	return &Server{
-		PGClient: pgClient,
-		DBHelper: dbHelper,
+		// dbHelpProvider contains all db related helper functions aka repository layer
+		dbHelper = dbHelperProvider.NewDBHelper(pgClient, cfg.Currency.Base)
 	}
+
+	return New(cfg, pgClient, dbHelper)
 }
 
-func (srv *Server) Start() {
-	addr := ":" + utils.GetFIBERPORTString()
-	Handler := srv.InjectRoutes()
-	Handler.Handler()
+// New builds the HTTP handler and, when enabled, the gRPC server on top of an open database.
+// Writes through DBHelper are published to the employees' watchers.
+func New(cfg *config.Config, pgClient providers.PgClientProvider, dbHelper providers.DbHelperProvider) *Server {
+	events := newEventHub(dbHelper.ReadFromPrimary())
+	outboxDue := make(chan struct{}, 1)
+	srv := &Server{
+		Config:      cfg,
+		PGClient:    pgClient,
+		DBHelper:    publishingHelper{DbHelperProvider: dbHelper, events: events, outboxDue: outboxDue},
+		events:      events,
+		stopping:    make(chan struct{}),
+		outboxDue:   outboxDue,
+		webhooksDue: make(chan struct{}, 1),
 
-	srv.Handler = Handler
+		compensationReports: ttlcache.New[string, models.CompensationReport](cfg.Reports.CacheTTL),
+		headcountTrends:     ttlcache.New[string, models.HeadcountTrend](cfg.Reports.CacheTTL),
+	}
+	srv.Sinks = srv.outboxSinks()
 
-	_ = srv.PGClient.Ping()
+	// routes are built up front so Stop can always reach the handler, even if Start never ran
+	srv.Handler = srv.InjectRoutes()
+	if cfg.GRPC.Enabled {
+		srv.GRPCServer = srv.newGRPCServer()
+	}
 
-	logrus.Info("Server running at PORT ", addr)
-	if err := Handler.Listen(addr); err != nil && err != http.ErrServerClosed {
-		logrus.Fatalf("Start %v", err)
-		return
+	return srv
+}
+
+// Start listens for HTTP and gRPC requests and blocks until the listeners are shut down.
+// It returns nil after a graceful shutdown and the first listen error otherwise.
+func (srv *Server) Start() error {
+	errChan := make(chan error, 2)
+
+	srv.background.Add(2)
+	go srv.relayOutbox()
+	go srv.deliverWebhooks()
+
+	if srv.GRPCServer != nil {
+		grpcAddr := ":" + srv.Config.GRPC.Port
+		listener, err := net.Listen("tcp", grpcAddr)
+		if err != nil {
+			return fmt.Errorf("start grpc: %w", err)
+		}
+		go srv.reportGRPCHealth()
+
+		logrus.Info("gRPC server running at PORT ", grpcAddr)
+		go func() {
+			if err := srv.GRPCServer.Serve(listener); err != nil {
+				errChan <- fmt.Errorf("start grpc: %w", err)
+			}
+		}()
 	}
 
+	addr := ":" + srv.Config.Server.Port
+	logrus.Info("Server running at PORT ", addr)
+	go func() {
+		if err := srv.Handler.Listen(addr); err != nil {
+			errChan <- fmt.Errorf("start: %w", err)
+			return
+		}
+		errChan <- nil
+	}()
+
+	return <-errChan
 }
 
-func (srv *Server) Stop() {
-	logrus.Info("closing postgresql...")
-	_ = srv.PGClient.Close()
+// Stop shuts the services down in order: the HTTP and gRPC listeners stop accepting connections,
+// in-flight requests drain until the shutdown timeout elapses, and only then is the database closed.
+func (srv *Server) Stop() error {
+	var stopErr error
+
+	ctx, cancel := context.WithTimeout(context.Background(), srv.Config.Server.ShutdownTimeout)
+	defer cancel()
 
 	logrus.Info("closing server...")
-	_ = srv.Handler.Shutdown()
+	// event streams and watches never finish by themselves, they end first so their connections drain
+	close(srv.stopping)
+	if err := srv.Handler.ShutdownWithContext(ctx); err != nil {
+		stopErr = fmt.Errorf("closing server: %w", err)
+	}
+
+	if srv.GRPCServer != nil {
+		logrus.Info("closing gRPC server...")
+		srv.grpcHealth.Shutdown()
+		stopped := make(chan struct{})
+		go func() {
+			srv.GRPCServer.GracefulStop()
+			close(stopped)
+		}()
+		select {
+		case <-stopped:
+		case <-ctx.Done():
+			srv.GRPCServer.Stop()
+		}
+	}
+
+	// attempts in flight are recorded before the database goes away
+	finished := make(chan struct{})
+	go func() {
+		srv.background.Wait()
+		close(finished)
+	}()
+	select {
+	case <-finished:
+		for _, sink := range srv.Sinks {
+			if closer, ok := sink.(io.Closer); ok {
+				if err := closer.Close(); err != nil && stopErr == nil {
+					stopErr = fmt.Errorf("closing %s sink: %w", sink.Name(), err)
+				}
+			}
+		}
+	case <-ctx.Done():
+	}
+
+	logrus.Info("closing database...")
+	if err := srv.PGClient.Close(); err != nil && stopErr == nil {
+		stopErr = fmt.Errorf("closing database: %w", err)
+	}
+
+	return stopErr
 }
@@ -0,0 +1,366 @@
+package server
+
+import (
+	"Techiebulter/interview/backend/models"
+	"Techiebulter/interview/backend/providers"
+	"errors"
+	"log"
+	"strconv"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// timesheetFailed answers the errors of attendance and timesheets, anything else is logged with message as a server error
+func timesheetFailed(c *fiber.Ctx, err error, message string) error {
+	switch {
+	case errors.Is(err, errForbidden):
+		return c.Status(fiber.StatusForbidden).JSON(fiber.Map{"status": "fail", "message": err.Error()})
+	case errors.Is(err, providers.ErrEmployeeNotFound), errors.Is(err, providers.ErrTimesheetNotFound):
+		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{"status": "fail", "error": err.Error()})
+	case errors.Is(err, providers.ErrAlreadyClockedIn), errors.Is(err, providers.ErrNotClockedIn),
+		errors.Is(err, providers.ErrTimesheetExists), errors.Is(err, providers.ErrTimesheetDecided):
+		return c.Status(fiber.StatusConflict).JSON(fiber.Map{"status": "fail", "error": err.Error()})
+	}
+	log.Println(message, err)
+	return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"status": "fail", "data": fiber.Map{"error": err}})
+}
+
+// overtimeRules are the configured rules weekly hours are split by
+func (s *Server) overtimeRules() models.OvertimeRules {
+	return models.OvertimeRules{DailyHours: s.Config.Overtime.DailyHours, WeeklyHours: s.Config.Overtime.WeeklyHours,
+		Multiplier: s.Config.Overtime.Multiplier}
+}
+
+// queryDate reads the date in the query parameter name, fallback when it is not set
+func queryDate(c *fiber.Ctx, name string, fallback models.Date) (models.Date, *models.FieldError) {
+	value := c.Query(name)
+	if value == "" {
+		return fallback, nil
+	}
+	day, err := models.ParseDate(value)
+	if err != nil {
+		return day, &models.FieldError{Field: name, Code: models.CodeInvalidFormat, Message: name + " must be a date like 2006-01-02"}
+	}
+	return day, nil
+}
+
+// ClockIn starts an attendance entry for an employee
+func (s *Server) ClockIn(c *fiber.Ctx) error {
+	id, err := strconv.Atoi(c.Params("id"))
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"status": "fail", "message": "Invalid employee ID"})
+	}
+	var request models.ClockRequest
+	if len(c.Body()) > 0 {
+		if err := c.BodyParser(&request); err != nil {
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"status": "fail", "message": err.Error()})
+		}
+	}
+	if errs := models.ClockRequestRules.Validate(&request); len(errs) > 0 {
+		return validationFailed(c, errs)
+	}
+
+	// Use a channel to communicate errors and results back from the goroutine
+	resultChan := make(chan models.AttendanceEntry, 1)
+	errChan := make(chan error, 1)
+
+	go func() {
+		entry, err := s.DBHelper.ClockIn(id, request.Note)
+		if err != nil {
+			errChan <- err
+			return
+		}
+		resultChan <- entry
+	}()
+
+	select {
+	case entry := <-resultChan:
+		markWrite(c)
+		return c.Status(fiber.StatusOK).JSON(fiber.Map{"status": "success", "attendance": entry})
+	case err := <-errChan:
+		return timesheetFailed(c, err, "ClockIn: error inserting data in the database")
+	}
+}
+
+// ClockOut ends the attendance entry an employee clocked in to
+func (s *Server) ClockOut(c *fiber.Ctx) error {
+	id, err := strconv.Atoi(c.Params("id"))
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"status": "fail", "message": "Invalid employee ID"})
+	}
+
+	// Use a channel to communicate errors and results back from the goroutine
+	resultChan := make(chan models.AttendanceEntry, 1)
+	errChan := make(chan error, 1)
+
+	go func() {
+		entry, err := s.DBHelper.ClockOut(id)
+		if err != nil {
+			errChan <- err
+			return
+		}
+		resultChan <- entry
+	}()
+
+	select {
+	case entry := <-resultChan:
+		markWrite(c)
+		return c.Status(fiber.StatusOK).JSON(fiber.Map{"status": "success", "attendance": entry})
+	case err := <-errChan:
+		return timesheetFailed(c, err, "ClockOut: error updating data in the database")
+	}
+}
+
+// GetAttendance lists the attendance entries of an employee clocked in between the from and to query
+// parameters, both included, by default in the current week
+func (s *Server) GetAttendance(c *fiber.Ctx) error {
+	id, err := strconv.Atoi(c.Params("id"))
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"status": "fail", "message": "Invalid employee ID"})
+	}
+	week := models.WeekOf(models.NewDate(time.Now()))
+	var errs models.ValidationErrors
+	from, fieldErr := queryDate(c, "from", week)
+	if fieldErr != nil {
+		errs = append(errs, *fieldErr)
+	}
+	to, fieldErr := queryDate(c, "to", models.Date{Time: week.AddDate(0, 0, 6)})
+	if fieldErr != nil {
+		errs = append(errs, *fieldErr)
+	}
+	if len(errs) == 0 && to.Before(from.Time) {
+		errs = append(errs, models.FieldError{Field: "to", Code: models.CodeOutOfRange, Message: "to must not be before from"})
+	}
+	if len(errs) > 0 {
+		return validationFailed(c, errs)
+	}
+	dbHelper := s.readHelper(c)
+
+	// Use a channel to communicate errors and results back from the goroutine
+	resultChan := make(chan []models.AttendanceEntry, 1)
+	errChan := make(chan error, 1)
+
+	go func() {
+		entries, err := dbHelper.GetAttendance(id, from, to)
+		if err != nil {
+			errChan <- err
+			return
+		}
+		resultChan <- entries
+	}()
+
+	select {
+	case entries := <-resultChan:
+		return c.Status(fiber.StatusOK).JSON(fiber.Map{"status": "success", "from": from, "to": to, "attendance": entries})
+	case err := <-errChan:
+		log.Println("GetAttendance: error getting results from DB", err)
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"status": "fail", "data": fiber.Map{"error": err}})
+	}
+}
+
+// SubmitTimesheet submits the project hours of an employee's week, they wait for HR to approve them
+func (s *Server) SubmitTimesheet(c *fiber.Ctx) error {
+	id, err := strconv.Atoi(c.Params("id"))
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"status": "fail", "message": "Invalid employee ID"})
+	}
+
+	var timesheet models.Timesheet
+	if err := c.BodyParser(&timesheet); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"status": "fail", "message": err.Error()})
+	}
+	if errs := timesheet.Validate(); len(errs) > 0 {
+		return validationFailed(c, errs)
+	}
+	timesheet.EmployeeID = id
+
+	// Use a channel to communicate errors and results back from the goroutine
+	resultChan := make(chan models.Timesheet, 1)
+	errChan := make(chan error, 1)
+
+	go func() {
+		created, err := s.DBHelper.CreateTimesheet(timesheet)
+		if err != nil {
+			errChan <- err
+			return
+		}
+		resultChan <- created
+	}()
+
+	select {
+	case created := <-resultChan:
+		markWrite(c)
+		return c.Status(fiber.StatusOK).JSON(fiber.Map{"status": "success", "timesheet": created})
+	case err := <-errChan:
+		return timesheetFailed(c, err, "SubmitTimesheet: error inserting data in the database")
+	}
+}
+
+// GetTimesheets lists the timesheets of an employee by week, the status query parameter keeps only
+// submitted, approved or rejected ones
+func (s *Server) GetTimesheets(c *fiber.Ctx) error {
+	id, err := strconv.Atoi(c.Params("id"))
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"status": "fail", "message": "Invalid employee ID"})
+	}
+	status := models.TimesheetStatus(c.Query("status"))
+	if status != "" && !status.IsValid() {
+		return validationFailed(c, models.ValidationErrors{{Field: "status", Code: models.CodeInvalidValue,
+			Message: "status must be submitted, approved or rejected"}})
+	}
+	dbHelper := s.readHelper(c)
+
+	// Use a channel to communicate errors and results back from the goroutine
+	resultChan := make(chan []models.Timesheet, 1)
+	errChan := make(chan error, 1)
+
+	go func() {
+		timesheets, err := dbHelper.GetTimesheets(models.TimesheetFilter{EmployeeID: id, Status: status})
+		if err != nil {
+			errChan <- err
+			return
+		}
+		resultChan <- timesheets
+	}()
+
+	select {
+	case timesheets := <-resultChan:
+		return c.Status(fiber.StatusOK).JSON(fiber.Map{"status": "success", "timesheets": timesheets})
+	case err := <-errChan:
+		log.Println("GetTimesheets: error getting results from DB", err)
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"status": "fail", "data": fiber.Map{"error": err}})
+	}
+}
+
+// ApproveTimesheet approves a submitted timesheet, its hours count in the weekly summary
+func (s *Server) ApproveTimesheet(c *fiber.Ctx) error {
+	return s.decideTimesheet(c, models.TimesheetApproved)
+}
+
+// RejectTimesheet rejects a submitted timesheet, the employee may submit the week again
+func (s *Server) RejectTimesheet(c *fiber.Ctx) error {
+	return s.decideTimesheet(c, models.TimesheetRejected)
+}
+
+// decideTimesheet moves a submitted timesheet to status. HR and the manager of the employee decide on it,
+// nobody on their own timesheets.
+func (s *Server) decideTimesheet(c *fiber.Ctx, status models.TimesheetStatus) error {
+	id, err := strconv.Atoi(c.Params("id"))
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"status": "fail", "message": "Invalid timesheet ID"})
+	}
+	var decision models.TimesheetDecision
+	if len(c.Body()) > 0 {
+		if err := c.BodyParser(&decision); err != nil {
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"status": "fail", "message": err.Error()})
+		}
+	}
+	if errs := models.TimesheetDecisionRules.Validate(&decision); len(errs) > 0 {
+		return validationFailed(c, errs)
+	}
+	principal := principalOf(c)
+
+	// Use a channel to communicate errors and results back from the goroutine
+	resultChan := make(chan models.Timesheet, 1)
+	errChan := make(chan error, 1)
+
+	go func() {
+		timesheet, err := s.DBHelper.ReadFromPrimary().GetTimesheet(id)
+		if err != nil {
+			errChan <- err
+			return
+		}
+		allowed, err := s.mayDecideFor(principal, timesheet.EmployeeID)
+		if err != nil {
+			errChan <- err
+			return
+		}
+		if !allowed {
+			errChan <- errForbidden
+			return
+		}
+		decided, err := s.DBHelper.DecideTimesheet(id, status, principal.String(), decision.Note)
+		if err != nil {
+			errChan <- err
+			return
+		}
+		resultChan <- decided
+	}()
+
+	select {
+	case decided := <-resultChan:
+		markWrite(c)
+		return c.Status(fiber.StatusOK).JSON(fiber.Map{"status": "success", "timesheet": decided})
+	case err := <-errChan:
+		return timesheetFailed(c, err, "decideTimesheet: error updating timesheet in DB")
+	}
+}
+
+// GetWeeklyHours reports the hours worked per employee and department in the week holding the day in the
+// week query parameter, by default the current week. The department query parameter keeps one department.
+func (s *Server) GetWeeklyHours(c *fiber.Ctx) error {
+	day, fieldErr := queryDate(c, "week", models.NewDate(time.Now()))
+	if fieldErr != nil {
+		return validationFailed(c, models.ValidationErrors{*fieldErr})
+	}
+	week := models.WeekOf(day)
+	department := c.Query("department")
+	dbHelper := s.readHelper(c)
+
+	// Use a channel to communicate errors and results back from the goroutine
+	resultChan := make(chan models.WeeklySummary, 1)
+	errChan := make(chan error, 1)
+
+	go func() {
+		attendance, err := dbHelper.GetAttendance(0, week, models.Date{Time: week.AddDate(0, 0, 6)})
+		if err != nil {
+			errChan <- err
+			return
+		}
+		timesheets, err := dbHelper.GetTimesheets(models.TimesheetFilter{WeekStart: week, Status: models.TimesheetApproved})
+		if err != nil {
+			errChan <- err
+			return
+		}
+		seen := map[int]bool{}
+		var ids []int
+		for _, entry := range attendance {
+			if !seen[entry.EmployeeID] {
+				seen[entry.EmployeeID] = true
+				ids = append(ids, entry.EmployeeID)
+			}
+		}
+		for _, timesheet := range timesheets {
+			if !seen[timesheet.EmployeeID] {
+				seen[timesheet.EmployeeID] = true
+				ids = append(ids, timesheet.EmployeeID)
+			}
+		}
+		var employees []models.Employee
+		if len(ids) > 0 {
+			if employees, err = dbHelper.GetEmployeesByIds(ids); err != nil {
+				errChan <- err
+				return
+			}
+		}
+		if department != "" {
+			kept := employees[:0]
+			for _, employee := range employees {
+				if employee.Department == department {
+					kept = append(kept, employee)
+				}
+			}
+			employees = kept
+		}
+		resultChan <- models.NewWeeklySummary(week, s.overtimeRules(), employees, attendance, timesheets)
+	}()
+
+	select {
+	case summary := <-resultChan:
+		return c.Status(fiber.StatusOK).JSON(fiber.Map{"status": "success", "summary": summary})
+	case err := <-errChan:
+		log.Println("GetWeeklyHours: error getting results from DB", err)
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"status": "fail", "data": fiber.Map{"error": err}})
+	}
+}
@@ -0,0 +1,67 @@
+package server
+
+import (
+	"Techiebulter/interview/backend/models"
+	"encoding/json"
+	"errors"
+	"reflect"
+	"sort"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// validationFailed responds with every rejected field of the request
+func validationFailed(c *fiber.Ctx, errs models.ValidationErrors) error {
+	return c.Status(fiber.StatusUnprocessableEntity).JSON(fiber.Map{"status": "fail", "errors": errs})
+}
+
+// parseBody decodes the body into v. Amounts that are not a whole number of cents or too large are
+// answered like validation errors of the members holding them, other decoding errors are returned.
+func parseBody(c *fiber.Ctx, v interface{}) (models.ValidationErrors, error) {
+	err := c.BodyParser(v)
+	var amountErr *models.AmountError
+	if !errors.As(err, &amountErr) {
+		return nil, err
+	}
+
+	// Decode member by member to find the members holding the amounts
+	var members map[string]json.RawMessage
+	if json.Unmarshal(c.Body(), &members) != nil {
+		return nil, err
+	}
+	names := make([]string, 0, len(members))
+	for name := range members {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	var errs models.ValidationErrors
+	for _, name := range names {
+		member, _ := json.Marshal(map[string]json.RawMessage{name: members[name]})
+		if errors.As(json.Unmarshal(member, reflect.New(reflect.TypeOf(v).Elem()).Interface()), &amountErr) {
+			errs = append(errs, amountErr.FieldError(name))
+		}
+	}
+	if len(errs) == 0 {
+		return nil, err
+	}
+	return errs, nil
+}
+
+// validateEmployee checks employee against rules and the custom field definitions. Custom fields
+// are checked whenever they are set, or always when creating an employee, which must have the required
+// ones and is paid in the base currency unless it says otherwise.
+func (s *Server) validateEmployee(employee *models.Employee, rules models.RuleSet[models.Employee], creating bool) (models.ValidationErrors, error) {
+	if creating && employee.Currency == "" {
+		employee.Currency = s.Config.Currency.Base
+	}
+	errs := employee.Validate(rules)
+	if employee.CustomFields == nil && !creating {
+		return errs, nil
+	}
+
+	definitions, err := s.DBHelper.GetCustomFieldDefinitions()
+	if err != nil {
+		return nil, err
+	}
+	return append(errs, models.ValidateCustomFields(definitions, employee.CustomFields)...), nil
+}
@@ -0,0 +1,222 @@
+package server
+
+import (
+	"Techiebulter/interview/backend/models"
+	"Techiebulter/interview/backend/providers"
+	"errors"
+	"log"
+	"strconv"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// Size of the delivery log returned by default and at most
+const (
+	defaultDeliveryLogLimit = 50
+	maxDeliveryLogLimit     = 500
+)
+
+// wakeWebhooks has the delivery worker look for due deliveries right away
+func (s *Server) wakeWebhooks() {
+	select {
+	case s.webhooksDue <- struct{}{}:
+	default:
+	}
+}
+
+// CreateWebhook subscribes a URL to employee events. The secret signs every delivery and is never returned.
+func (s *Server) CreateWebhook(c *fiber.Ctx) error {
+	var subscription models.WebhookSubscription
+
+	if err := c.BodyParser(&subscription); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"status": "fail", "message": err.Error()})
+	}
+	if subscription.EventTypes == nil {
+		subscription.EventTypes = models.StringList{}
+	}
+	if errs := models.WebhookSubscriptionRules.Validate(&subscription); len(errs) > 0 {
+		return validationFailed(c, errs)
+	}
+
+	// Use a channel to communicate errors and results back from the goroutine
+	resultChan := make(chan models.WebhookSubscription, 1)
+	errChan := make(chan error, 1)
+
+	go func() {
+		created, err := s.DBHelper.CreateWebhookSubscription(subscription)
+		if err != nil {
+			errChan <- err
+			return
+		}
+		resultChan <- created
+	}()
+
+	select {
+	case created := <-resultChan:
+		created.Secret = ""
+		markWrite(c)
+		return c.Status(fiber.StatusOK).JSON(fiber.Map{"status": "success", "webhook": created})
+	case err := <-errChan:
+		log.Println("CreateWebhook: error inserting data in the database", err)
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"status": "fail", "data": fiber.Map{"error": err}})
+	}
+}
+
+// GetWebhooks lists the webhook subscriptions without their secrets
+func (s *Server) GetWebhooks(c *fiber.Ctx) error {
+	dbHelper := s.readHelper(c)
+
+	// Use a channel to communicate errors and results back from the goroutine
+	resultChan := make(chan []models.WebhookSubscription, 1)
+	errChan := make(chan error, 1)
+
+	go func() {
+		subscriptions, err := dbHelper.GetWebhookSubscriptions()
+		if err != nil {
+			errChan <- err
+			return
+		}
+		resultChan <- subscriptions
+	}()
+
+	select {
+	case subscriptions := <-resultChan:
+		for i := range subscriptions {
+			subscriptions[i].Secret = ""
+		}
+		return c.Status(fiber.StatusOK).JSON(fiber.Map{"status": "success", "webhooks": subscriptions})
+	case err := <-errChan:
+		log.Println("GetWebhooks: error getting results from DB", err)
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"status": "fail", "data": fiber.Map{"error": err}})
+	}
+}
+
+// DeleteWebhook unsubscribes a webhook, its pending deliveries are dropped
+func (s *Server) DeleteWebhook(c *fiber.Ctx) error {
+	id, err := strconv.Atoi(c.Params("id"))
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"status": "fail", "message": "Invalid webhook ID"})
+	}
+
+	// Use a channel to communicate errors back from the goroutine
+	errChan := make(chan error, 1)
+
+	go func() {
+		errChan <- s.DBHelper.DeleteWebhookSubscription(id)
+	}()
+
+	err = <-errChan
+	if errors.Is(err, providers.ErrWebhookNotFound) {
+		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{"status": "fail", "error": err.Error()})
+	}
+	if err != nil {
+		log.Println("DeleteWebhook: error deleting subscription from DB", err)
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"status": "fail", "data": fiber.Map{"error": err}})
+	}
+
+	markWrite(c)
+	return c.Status(fiber.StatusOK).JSON(fiber.Map{"status": "success"})
+}
+
+// GetWebhookDeliveries returns the delivery log of a subscription, newest first. The status query
+// parameter keeps only pending, delivered or dead deliveries and limit caps how many are returned.
+func (s *Server) GetWebhookDeliveries(c *fiber.Ctx) error {
+	id, err := strconv.Atoi(c.Params("id"))
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"status": "fail", "message": "Invalid webhook ID"})
+	}
+
+	var errs models.ValidationErrors
+	status := models.WebhookDeliveryStatus(c.Query("status"))
+	if status != "" && !status.IsValid() {
+		errs = append(errs, models.FieldError{Field: "status", Code: models.CodeInvalidValue,
+			Message: "status must be pending, delivered or dead"})
+	}
+	limit := defaultDeliveryLogLimit
+	if value := c.Query("limit"); value != "" {
+		limit, err = strconv.Atoi(value)
+		if err != nil || limit < 1 || limit > maxDeliveryLogLimit {
+			errs = append(errs, models.FieldError{Field: "limit", Code: models.CodeOutOfRange,
+				Message: "limit must be a number between 1 and 500"})
+		}
+	}
+	if len(errs) > 0 {
+		return validationFailed(c, errs)
+	}
+	dbHelper := s.readHelper(c)
+
+	// Use a channel to communicate errors and results back from the goroutine
+	resultChan := make(chan []models.WebhookDelivery, 1)
+	errChan := make(chan error, 1)
+
+	go func() {
+		subscriptions, err := dbHelper.GetWebhookSubscriptions()
+		if err != nil {
+			errChan <- err
+			return
+		}
+		known := false
+		for _, subscription := range subscriptions {
+			known = known || subscription.ID == id
+		}
+		if !known {
+			errChan <- providers.ErrWebhookNotFound
+			return
+		}
+		deliveries, err := dbHelper.GetWebhookDeliveries(id, status, limit)
+		if err != nil {
+			errChan <- err
+			return
+		}
+		resultChan <- deliveries
+	}()
+
+	select {
+	case deliveries := <-resultChan:
+		return c.Status(fiber.StatusOK).JSON(fiber.Map{"status": "success", "deliveries": deliveries})
+	case err := <-errChan:
+		if errors.Is(err, providers.ErrWebhookNotFound) {
+			return c.Status(fiber.StatusNotFound).JSON(fiber.Map{"status": "fail", "error": err.Error()})
+		}
+		log.Println("GetWebhookDeliveries: error getting results from DB", err)
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"status": "fail", "data": fiber.Map{"error": err}})
+	}
+}
+
+// RedeliverWebhook queues a delivery again with fresh attempts, typically a dead one once the receiver is fixed
+func (s *Server) RedeliverWebhook(c *fiber.Ctx) error {
+	id, err := strconv.Atoi(c.Params("id"))
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"status": "fail", "message": "Invalid webhook ID"})
+	}
+	deliveryID, err := strconv.ParseInt(c.Params("deliveryId"), 10, 64)
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"status": "fail", "message": "Invalid delivery ID"})
+	}
+
+	// Use a channel to communicate errors and results back from the goroutine
+	resultChan := make(chan models.WebhookDelivery, 1)
+	errChan := make(chan error, 1)
+
+	go func() {
+		delivery, err := s.DBHelper.RedeliverWebhook(id, deliveryID)
+		if err != nil {
+			errChan <- err
+			return
+		}
+		resultChan <- delivery
+	}()
+
+	select {
+	case delivery := <-resultChan:
+		s.wakeWebhooks()
+		markWrite(c)
+		return c.Status(fiber.StatusAccepted).JSON(fiber.Map{"status": "success", "delivery": delivery})
+	case err := <-errChan:
+		if errors.Is(err, providers.ErrWebhookDeliveryNotFound) {
+			return c.Status(fiber.StatusNotFound).JSON(fiber.Map{"status": "fail", "error": err.Error()})
+		}
+		log.Println("RedeliverWebhook: error updating delivery in DB", err)
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"status": "fail", "data": fiber.Map{"error": err}})
+	}
+}
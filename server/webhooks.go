@@ -0,0 +1,143 @@
+package server
+
+import (
+	"Techiebulter/interview/backend/models"
+	"Techiebulter/interview/backend/utils"
+	"bytes"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+)
+
+// webhookBatchSize is how many due deliveries are claimed and posted at a time
+const webhookBatchSize = 20
+
+// Headers of a webhook request. The signature is the hex HMAC-SHA256 of the timestamp, a dot and
+// the body, keyed with the subscription secret, so receivers can reject forged and replayed requests.
+const (
+	webhookIDHeader        = "X-Webhook-ID"
+	webhookEventHeader     = "X-Webhook-Event"
+	webhookTimestampHeader = "X-Webhook-Timestamp"
+	webhookSignatureHeader = "X-Webhook-Signature"
+)
+
+// signWebhook returns the X-Webhook-Signature of a payload sent at timestamp
+func signWebhook(secret, timestamp string, payload []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(timestamp + "."))
+	mac.Write(payload)
+	return "sha256=" + hex.EncodeToString(mac.Sum(nil))
+}
+
+// deliverWebhooks posts the due webhook deliveries, every poll interval and whenever deliveries were
+// queued, until the server stops
+func (srv *Server) deliverWebhooks() {
+	defer srv.background.Done()
+
+	client := &http.Client{Timeout: srv.Config.Webhooks.Timeout}
+	poll := time.NewTicker(srv.Config.Webhooks.PollInterval)
+	defer poll.Stop()
+
+	for {
+		// full batches mean more deliveries may be due
+		for srv.deliverDueWebhooks(client) == webhookBatchSize {
+		}
+		select {
+		case <-poll.C:
+		case <-srv.webhooksDue:
+		case <-srv.stopping:
+			return
+		}
+	}
+}
+
+// deliverDueWebhooks claims a batch of due deliveries, posts them concurrently and returns how many it claimed
+func (srv *Server) deliverDueWebhooks(client *http.Client) int {
+	dbHelper := srv.DBHelper.ReadFromPrimary()
+
+	// the lease outlasts the attempts, deliveries of an instance that died meanwhile are retried after it
+	now := time.Now()
+	deliveries, err := dbHelper.ClaimWebhookDeliveries(now, now.Add(srv.Config.Webhooks.Timeout+time.Minute), webhookBatchSize)
+	if err != nil || len(deliveries) == 0 {
+		return 0
+	}
+	subscriptions, err := dbHelper.GetWebhookSubscriptions()
+	if err != nil {
+		// the claimed deliveries are retried once their lease ends
+		return 0
+	}
+	byID := map[int]models.WebhookSubscription{}
+	for _, subscription := range subscriptions {
+		byID[subscription.ID] = subscription
+	}
+
+	var wg sync.WaitGroup
+	for _, delivery := range deliveries {
+		subscription, ok := byID[delivery.SubscriptionID]
+		if !ok {
+			// deleted meanwhile, its deliveries went with it
+			continue
+		}
+		wg.Add(1)
+		go func(delivery models.WebhookDelivery) {
+			defer wg.Done()
+			delivery = srv.attemptWebhook(client, subscription, delivery)
+			if err := dbHelper.CompleteWebhookAttempt(delivery); err != nil {
+				log.Println("deliverDueWebhooks: unable to record webhook attempt", err)
+			}
+		}(delivery)
+	}
+	wg.Wait()
+
+	return len(deliveries)
+}
+
+// attemptWebhook posts a delivery once and returns it updated with the outcome. Any 2xx response
+// counts as delivered, anything else is retried with backoff until the attempts run out.
+func (srv *Server) attemptWebhook(client *http.Client, subscription models.WebhookSubscription, delivery models.WebhookDelivery) models.WebhookDelivery {
+	cfg := srv.Config.Webhooks
+	delivery.Attempts++
+	delivery.LastStatusCode, delivery.LastError = 0, ""
+
+	timestamp := strconv.FormatInt(time.Now().Unix(), 10)
+	req, err := http.NewRequest(http.MethodPost, subscription.URL, bytes.NewReader(delivery.Payload))
+	if err == nil {
+		req.Header.Set("Content-Type", "application/json")
+		req.Header.Set(webhookIDHeader, strconv.FormatInt(delivery.ID, 10))
+		req.Header.Set(webhookEventHeader, string(delivery.EventType))
+		req.Header.Set(webhookTimestampHeader, timestamp)
+		req.Header.Set(webhookSignatureHeader, signWebhook(subscription.Secret, timestamp, delivery.Payload))
+
+		var resp *http.Response
+		resp, err = client.Do(req)
+		if err == nil {
+			// the body is read so the connection can be reused
+			_, _ = io.Copy(io.Discard, io.LimitReader(resp.Body, 64<<10))
+			resp.Body.Close()
+			delivery.LastStatusCode = resp.StatusCode
+			if resp.StatusCode < 200 || resp.StatusCode > 299 {
+				err = fmt.Errorf("receiver answered %d", resp.StatusCode)
+			}
+		}
+	}
+
+	now := time.Now()
+	switch {
+	case err == nil:
+		delivery.Status, delivery.NextAttemptAt, delivery.DeliveredAt = models.WebhookDelivered, nil, &now
+	case delivery.Attempts >= cfg.MaxAttempts:
+		delivery.Status, delivery.NextAttemptAt, delivery.LastError = models.WebhookDead, nil, err.Error()
+		log.Printf("attemptWebhook: delivery %d to subscription %d is dead after %d attempts: %v", delivery.ID, subscription.ID, delivery.Attempts, err)
+	default:
+		next := now.Add(utils.Backoff(cfg.BackoffInitial, cfg.BackoffMax, delivery.Attempts-1))
+		delivery.NextAttemptAt, delivery.LastError = &next, err.Error()
+	}
+	return delivery
+}
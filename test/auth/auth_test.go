@@ -0,0 +1,102 @@
+package auth_test
+
+import (
+	"Techiebulter/interview/backend/config"
+	"Techiebulter/interview/backend/models"
+	"Techiebulter/interview/backend/server"
+	"Techiebulter/interview/backend/test/internal/apitest"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestEmployeeReads(t *testing.T) {
+	app := apitest.NewApp(t)
+	apitest.Call(t, app, "hr-key", "POST", "/api/CreateEmpolyee", `{"Name":"John Doe","position":"Engineer","Salary":5000}`, 200)
+	apitest.Call(t, app, "hr-key", "POST", "/api/CreateEmpolyee", `{"Name":"Jane Roe","position":"Manager","Salary":9000}`, 200)
+
+	apitest.Call(t, app, "employee-key", "GET", "/api/GetEmployeeById/1", "", 200)
+	apitest.Call(t, app, "employee-key", "GET", "/api/GetEmployeeById/2", "", 403)
+	apitest.Call(t, app, "employee-key", "GET", "/api/GetAllEmployees/1/10", "", 403)
+	apitest.Call(t, app, "employee-key", "GET", "/api/v1/events", "", 403)
+	apitest.Call(t, app, "employee-key", "GET", "/api/v1/events/ws", "", 403)
+
+	apitest.Call(t, app, "hr-key", "GET", "/api/GetEmployeeById/2", "", 200)
+	apitest.Call(t, app, "hr-key", "GET", "/api/GetAllEmployees/1/10", "", 200)
+}
+
+func TestMiddleware(t *testing.T) {
+	cfg := config.Default()
+	cfg.Auth.Enabled = true
+	cfg.Auth.APIKeys = []config.APIKey{
+		{Key: "admin-key", Role: models.RoleAdmin},
+		{Key: "hr-key", Role: models.RoleHR},
+		{Key: "employee-key", Role: models.RoleEmployee, EmployeeID: 7},
+		{Key: "unbound-key", Role: models.RoleEmployee},
+	}
+	newMiddlewareApp := func(cfg *config.Config) *fiber.App {
+		srv := &server.Server{Config: cfg}
+		app := fiber.New()
+		ok := func(c *fiber.Ctx) error { return c.SendStatus(fiber.StatusOK) }
+		app.Get("/open", srv.Authenticate, ok)
+		app.Get("/admin", srv.Authenticate, server.RequireRole(models.RoleAdmin), ok)
+		app.Get("/employees/:id", srv.Authenticate, server.RequireSelfOrRole(models.RoleAdmin, models.RoleHR), ok)
+		return app
+	}
+	status := func(app *fiber.App, path string, header ...string) int {
+		req := httptest.NewRequest("GET", path, nil)
+		for i := 0; i+1 < len(header); i += 2 {
+			req.Header.Set(header[i], header[i+1])
+		}
+		resp, err := app.Test(req)
+		require.NoError(t, err)
+		return resp.StatusCode
+	}
+	app := newMiddlewareApp(cfg)
+
+	assert.Equal(t, fiber.StatusUnauthorized, status(app, "/open"))
+	assert.Equal(t, fiber.StatusUnauthorized, status(app, "/open", "X-API-Key", "wrong-key"))
+	assert.Equal(t, fiber.StatusOK, status(app, "/open", "X-API-Key", "employee-key"))
+	assert.Equal(t, fiber.StatusOK, status(app, "/open", fiber.HeaderAuthorization, "Bearer employee-key"))
+
+	assert.Equal(t, fiber.StatusOK, status(app, "/admin", "X-API-Key", "admin-key"))
+	assert.Equal(t, fiber.StatusForbidden, status(app, "/admin", "X-API-Key", "hr-key"))
+
+	assert.Equal(t, fiber.StatusOK, status(app, "/employees/3", "X-API-Key", "hr-key"))
+	assert.Equal(t, fiber.StatusOK, status(app, "/employees/7", "X-API-Key", "employee-key"))
+	assert.Equal(t, fiber.StatusForbidden, status(app, "/employees/3", "X-API-Key", "employee-key"))
+	assert.Equal(t, fiber.StatusForbidden, status(app, "/employees/0", "X-API-Key", "unbound-key"), "keys bound to no employee are nobody")
+
+	cfg = config.Default()
+	app = newMiddlewareApp(cfg)
+	assert.Equal(t, fiber.StatusOK, status(app, "/admin"), "without auth every caller is an admin")
+}
+
+func TestIdempotencyKeys(t *testing.T) {
+	app := apitest.NewApp(t)
+	post := func(key, idempotencyKey, path, body string, status int) bool {
+		req := httptest.NewRequest("POST", path, strings.NewReader(body))
+		req.Header.Set(fiber.HeaderContentType, fiber.MIMEApplicationJSON)
+		req.Header.Set("X-API-Key", key)
+		req.Header.Set("Idempotency-Key", idempotencyKey)
+		resp, err := app.Test(req)
+		require.NoError(t, err)
+		require.Equal(t, status, resp.StatusCode, "POST %s", path)
+		return resp.Header.Get("Idempotent-Replayed") == "true"
+	}
+	const create = `{"Name":"John Doe","position":"Engineer","Salary":5000}`
+
+	assert.False(t, post("hr-key", "create-john", "/api/CreateEmpolyee", create, 200))
+	assert.True(t, post("hr-key", "create-john", "/api/CreateEmpolyee", create, 200), "retries are replayed")
+	assert.Len(t, apitest.Call(t, app, "hr-key", "GET", "/api/GetAllEmployees/1/10", "", 200)["employees"], 1)
+	post("hr-key", "create-john", "/api/CreateEmpolyee", `{"Name":"Jane Roe","position":"Manager","Salary":9000}`, 422)
+
+	// the rejections of route guards are not stored, the key stays free for the caller's next request
+	assert.False(t, post("employee-key", "employee-1", "/api/CreateEmpolyee", create, 403))
+	assert.False(t, post("employee-key", "employee-1", "/api/CreateEmpolyee", create, 403))
+	assert.False(t, post("employee-key", "employee-1", "/api/v1/employees/1/clock-in", "", 200))
+}
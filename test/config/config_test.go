@@ -0,0 +1,76 @@
+package config_test
+
+import (
+	"Techiebulter/interview/backend/config"
+	"Techiebulter/interview/backend/models"
+	"bytes"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestLoadPrecedence(t *testing.T) {
+	file := filepath.Join(t.TempDir(), "config.yaml")
+	require.NoError(t, os.WriteFile(file, []byte("server:\n  port: \"4000\"\n  readTimeout: 7s\ngraphql:\n  maxDepth: 5\n"), 0o600))
+	t.Setenv("CONFIG_FILE", file)
+	t.Setenv("PGSQL_URL", "postgres://app@db/employees")
+	t.Setenv("FIBER_PORT", "5000")
+	t.Setenv("SERVER_READ_TIMEOUT", "8s")
+
+	cfg, err := config.Load([]string{"-port", "6000"})
+	require.NoError(t, err)
+	defaults := config.Default()
+
+	assert.Equal(t, "6000", cfg.Server.Port)
+	assert.Equal(t, config.SourceFlag, cfg.Source("server.port"), "flags win over the environment and the file")
+	assert.Equal(t, 8*time.Second, cfg.Server.ReadTimeout)
+	assert.Equal(t, config.SourceEnv, cfg.Source("server.readTimeout"), "the environment wins over the file")
+	assert.Equal(t, 5, cfg.GraphQL.MaxDepth)
+	assert.Equal(t, config.SourceFile, cfg.Source("graphql.maxDepth"), "the file wins over the defaults")
+	assert.Equal(t, defaults.Server.WriteTimeout, cfg.Server.WriteTimeout)
+	assert.Equal(t, config.SourceDefault, cfg.Source("server.writeTimeout"))
+}
+
+func TestValidateReportsEveryProblem(t *testing.T) {
+	cfg := config.Default()
+	cfg.Database.URL = "postgres://app@db/employees"
+	require.NoError(t, cfg.Validate())
+
+	cfg.Server.Port = "0"
+	cfg.GraphQL.Enabled = true
+	cfg.GraphQL.MaxDepth = 0
+	cfg.Auth.Enabled = true
+	cfg.Auth.APIKeys = nil
+	cfg.Idempotency.Lease = 2 * cfg.Idempotency.TTL
+
+	var validationErr *config.ValidationError
+	require.True(t, errors.As(cfg.Validate(), &validationErr))
+	assert.Equal(t, []string{
+		`server.port: "0" is not a port between 1 and 65535`,
+		"graphql.maxDepth: must be at least 1",
+		"auth.apiKeys: at least one key is required when auth is enabled",
+		"idempotency.lease: must be positive and at most idempotency.ttl",
+	}, validationErr.Problems)
+}
+
+func TestPrintMasksSecrets(t *testing.T) {
+	cfg := config.Default()
+	cfg.Database.URL = "postgres://app:hunter2@db:5432/employees"
+	cfg.Database.ReplicaURLs = []string{"host=replica user=app password=swordfish"}
+	cfg.Auth.APIKeys = []config.APIKey{{Key: "topsecret", Role: models.RoleHR}, {Key: "mine", Role: models.RoleEmployee, EmployeeID: 7}}
+
+	var out bytes.Buffer
+	require.NoError(t, cfg.Print(&out))
+	printed := out.String()
+	for _, secret := range []string{"hunter2", "swordfish", "topsecret", "mine"} {
+		assert.NotContains(t, printed, secret)
+	}
+	assert.Contains(t, printed, "postgres://app:****@db:5432/employees")
+	assert.Contains(t, printed, "password=****")
+	assert.Contains(t, printed, "****:hr,****:employee:7", "roles stay visible")
+}
@@ -1,7 +1,9 @@
 package server_test
 
 import (
+	"Techiebulter/interview/backend/config"
 	"Techiebulter/interview/backend/models"
+	"Techiebulter/interview/backend/providers"
 	"Techiebulter/interview/backend/providers/dbHelperProvider"
 	"Techiebulter/interview/backend/providers/dbProvider"
 	"Techiebulter/interview/backend/server"
@@ -9,6 +11,7 @@ import (
 	"context"
 	"database/sql"
 	"errors"
+	"log"
 	"testing"
 
 	"github.com/gofiber/fiber"
@@ -56,10 +59,21 @@ func (m *MockDBClient) DeleteEmployeeById(id int) error {
 }
 
 // psql database connection
-var pgClient = dbProvider.ConnectDB(utils.GetPGSQLConnectionString())
+var pgClient = connectDB()
+
+func connectDB() providers.PgClientProvider {
+	cfg := config.Default().Database
+	cfg.URL = utils.GetPGSQLConnectionString()
+
+	client, err := dbProvider.ConnectDB(cfg)
+	if err != nil {
+		log.Fatalf("Failed to initialize PostgreSQL client: %v", err)
+	}
+	return client
+}
 
 // dbHelpProvider contains all db related helper functions aka repository layer
-var dbHelper = dbHelperProvider.NewDBHelper(pgClient.Client())
+var dbHelper = dbHelperProvider.NewDBHelper(pgClient, config.Default().Currency.Base)
 
 // Create an instance of DBHelper with the mock database client
 var dh = &server.Server{
@@ -114,7 +128,7 @@ func TestCreateEmployee(t *testing.T) {
 		employee := models.Employee{
 			Name:     "Trehan",
 			Position: "Software Engineer",
-			Salary:   5000000,
+			Salary:   models.MustParseMoney("5000000"),
 		}
 		err := dh.DBHelper.CreateEmployee(employee)
 		if err != nil {
@@ -133,7 +147,7 @@ func TestCreateEmployee(t *testing.T) {
 		employee := models.Employee{
 			Name:     "Trehan",
 			Position: "Software Engineer",
-			Salary:   5000000,
+			Salary:   models.MustParseMoney("5000000"),
 		}
 		err := dh.DBHelper.CreateEmployee(employee)
 		if err == nil {
@@ -207,7 +221,7 @@ func TestUpdateEmployee(t *testing.T) {
 			ID:       1,
 			Name:     "Trehan",
 			Position: "Senior Software Engineer",
-			Salary:   60000,
+			Salary:   models.MustParseMoney("60000"),
 		}
 
 		// Mock UpdateEmployee function to return the expected updated employee details
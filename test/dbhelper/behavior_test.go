@@ -0,0 +1,826 @@
+package dbhelper_test
+
+import (
+	"Techiebulter/interview/backend/config"
+	"Techiebulter/interview/backend/models"
+	"Techiebulter/interview/backend/providers"
+	"Techiebulter/interview/backend/providers/dbHelperProvider"
+	"Techiebulter/interview/backend/providers/dbProvider"
+	"Techiebulter/interview/backend/providers/sqliteHelperProvider"
+	"Techiebulter/interview/backend/utils"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// missingID is never handed out by the test databases
+const missingID = 2147483647
+
+func TestSQLiteHelper(t *testing.T) {
+	cfg := config.Default().Database
+	cfg.Driver = config.DriverSQLite
+	cfg.SQLitePath = filepath.Join(t.TempDir(), "employees.db")
+
+	client, err := dbProvider.ConnectSQLite(cfg)
+	require.NoError(t, err)
+	defer client.Close()
+
+	dbHelper, err := sqliteHelperProvider.NewSQLiteHelper(client, models.DefaultCurrency)
+	require.NoError(t, err)
+
+	runBehaviorSuite(t, dbHelper)
+}
+
+// TestBaseCurrencyBackfill checks that the amounts stored before currencies existed are tagged with
+// the configured base currency once, when the migration recorded them in currency_backfill
+func TestBaseCurrencyBackfill(t *testing.T) {
+	cfg := config.Default().Database
+	cfg.Driver = config.DriverSQLite
+	cfg.SQLitePath = filepath.Join(t.TempDir(), "employees.db")
+
+	client, err := dbProvider.ConnectSQLite(cfg)
+	require.NoError(t, err)
+	defer client.Close()
+
+	dbHelper, err := sqliteHelperProvider.NewSQLiteHelper(client, "EUR")
+	require.NoError(t, err)
+	for _, name := range []string{"legacy", "recent"} {
+		require.NoError(t, dbHelper.CreateEmployee(models.Employee{Name: name, Position: "Engineer", Salary: models.MustParseMoney("1000"), Currency: "USD"}))
+	}
+	legacy := findByName(t, dbHelper, "legacy")
+	_, err = client.Client().Exec(`INSERT INTO currency_backfill (table_name, last_id) VALUES ('employees', ?)`, legacy.ID)
+	require.NoError(t, err)
+
+	// the next start tags the recorded rows only, later starts change nothing
+	for _, base := range []models.Currency{"EUR", "GBP"} {
+		dbHelper, err = sqliteHelperProvider.NewSQLiteHelper(client, base)
+		require.NoError(t, err)
+		assert.Equal(t, models.Currency("EUR"), findByName(t, dbHelper, "legacy").Currency)
+		assert.Equal(t, models.Currency("USD"), findByName(t, dbHelper, "recent").Currency)
+	}
+}
+
+// TestPostgresHelper runs against the database in PGSQL_URL and is skipped without one
+func TestPostgresHelper(t *testing.T) {
+	cfg := config.Default().Database
+	cfg.URL = utils.GetPGSQLConnectionString()
+	cfg.ConnectAttempts = 1
+	if cfg.URL == "" {
+		t.Skip("PGSQL_URL is not set")
+	}
+
+	client, err := dbProvider.ConnectDB(cfg)
+	if err != nil {
+		t.Skipf("PostgreSQL is not reachable: %v", err)
+	}
+	defer client.Close()
+
+	runBehaviorSuite(t, dbHelperProvider.NewDBHelper(client, models.DefaultCurrency))
+}
+
+// runBehaviorSuite holds the expectations every providers.DbHelperProvider implementation must meet
+func runBehaviorSuite(t *testing.T, dbHelper providers.DbHelperProvider) {
+	// names are unique per run so the suite also works on a database that already holds employees
+	name := fmt.Sprintf("behavior-%d", time.Now().UnixNano())
+	var created models.Employee
+
+	t.Run("CreateEmployee_Success", func(t *testing.T) {
+		err := dbHelper.CreateEmployee(models.Employee{Name: name, Position: "Software Engineer", Salary: models.MustParseMoney("1234.57")})
+		require.NoError(t, err)
+
+		created = findByName(t, dbHelper, name)
+		assert.Equal(t, "Software Engineer", created.Position)
+		// salary keeps its cents
+		assert.Equal(t, models.MustParseMoney("1234.57"), created.Salary)
+		assert.Equal(t, models.DefaultCurrency, created.Currency)
+		assert.Equal(t, models.EmploymentFullTime, created.EmploymentType)
+		assert.Equal(t, models.StatusActive, created.Status)
+		assert.Equal(t, models.CustomFields{}, created.CustomFields)
+		assert.False(t, created.CreatedAt.IsZero())
+	})
+	require.NotZero(t, created.ID, "later cases need the created employee")
+
+	t.Run("CreateEmployee_SalaryOverflow", func(t *testing.T) {
+		err := dbHelper.CreateEmployee(models.Employee{Name: name + "-overflow", Position: "CEO", Salary: models.MustParseMoney("100000000")})
+		assert.Error(t, err)
+	})
+
+	t.Run("CreateEmployee_NameTooLong", func(t *testing.T) {
+		err := dbHelper.CreateEmployee(models.Employee{Name: strings.Repeat("n", 256), Position: "Intern", Salary: models.MustParseMoney("1")})
+		assert.Error(t, err)
+	})
+
+	t.Run("GetEmployeeById_Success", func(t *testing.T) {
+		emp, err := dbHelper.GetEmployeeById(created.ID)
+		require.NoError(t, err)
+		assert.Equal(t, created, emp)
+	})
+
+	t.Run("GetEmployeeById_NotFound", func(t *testing.T) {
+		_, err := dbHelper.GetEmployeeById(missingID)
+		assert.EqualError(t, err, fmt.Sprintf("employee with ID %d not found", missingID))
+	})
+
+	t.Run("GetEmployeeById_ReadFromPrimary", func(t *testing.T) {
+		emp, err := dbHelper.ReadFromPrimary().GetEmployeeById(created.ID)
+		require.NoError(t, err)
+		assert.Equal(t, created, emp)
+	})
+
+	t.Run("UpdateEmployee_Partial", func(t *testing.T) {
+		updated, err := dbHelper.UpdateEmployee(models.Employee{ID: created.ID, Position: "Senior Software Engineer"})
+		require.NoError(t, err)
+		assert.Equal(t, name, updated.Name)
+		assert.Equal(t, "Senior Software Engineer", updated.Position)
+		assert.Equal(t, models.MustParseMoney("1234.57"), updated.Salary)
+		assert.Equal(t, created.CreatedAt, updated.CreatedAt)
+		assert.False(t, updated.UpdatedAt.Before(created.UpdatedAt))
+
+		updated, err = dbHelper.UpdateEmployee(models.Employee{ID: created.ID, Salary: models.MustParseMoney("60000")})
+		require.NoError(t, err)
+		assert.Equal(t, "Senior Software Engineer", updated.Position)
+		assert.Equal(t, models.MustParseMoney("60000"), updated.Salary)
+	})
+
+	t.Run("UpdateEmployee_NoFields", func(t *testing.T) {
+		_, err := dbHelper.UpdateEmployee(models.Employee{ID: created.ID})
+		assert.Error(t, err)
+	})
+
+	t.Run("UpdateEmployee_NotFound", func(t *testing.T) {
+		_, err := dbHelper.UpdateEmployee(models.Employee{ID: missingID, Name: "nobody"})
+		assert.Error(t, err)
+	})
+
+	t.Run("UpdateEmployee_Profile", func(t *testing.T) {
+		hireDate := models.NewDate(time.Date(2021, time.March, 1, 0, 0, 0, 0, time.UTC))
+		updated, err := dbHelper.UpdateEmployee(models.Employee{
+			ID:             created.ID,
+			Email:          name + "@example.com",
+			Phone:          "+1 555 0100",
+			HireDate:       &hireDate,
+			EmploymentType: models.EmploymentContractor,
+			Location:       "Berlin",
+			Department:     "Platform",
+			CustomFields:   models.CustomFields{"team": "platform", "remote": true},
+		})
+		require.NoError(t, err)
+		assert.Equal(t, name+"@example.com", updated.Email)
+		assert.Equal(t, "+1 555 0100", updated.Phone)
+		require.NotNil(t, updated.HireDate)
+		assert.True(t, hireDate.Equal(updated.HireDate.Time))
+		assert.Nil(t, updated.TerminationDate)
+		assert.Equal(t, models.EmploymentContractor, updated.EmploymentType)
+		assert.Equal(t, "Berlin", updated.Location)
+		assert.Equal(t, "Platform", updated.Department)
+		assert.Equal(t, models.CustomFields{"team": "platform", "remote": true}, updated.CustomFields)
+
+		emp, err := dbHelper.GetEmployeeById(created.ID)
+		require.NoError(t, err)
+		assert.Equal(t, updated, emp)
+	})
+
+	t.Run("UpdateEmployee_TerminationBeforeHire", func(t *testing.T) {
+		terminationDate := models.NewDate(time.Date(2020, time.January, 1, 0, 0, 0, 0, time.UTC))
+		_, err := dbHelper.UpdateEmployee(models.Employee{ID: created.ID, TerminationDate: &terminationDate})
+		assert.ErrorIs(t, err, providers.ErrTerminationBeforeHire)
+	})
+
+	t.Run("PatchEmployee_ClearsFields", func(t *testing.T) {
+		patched, err := dbHelper.PatchEmployee(created.ID, func(current models.Employee) (models.Employee, error) {
+			assert.Equal(t, "Berlin", current.Location)
+			current.Email, current.HireDate, current.Location = "", nil, ""
+			return current, nil
+		})
+		require.NoError(t, err)
+		assert.Empty(t, patched.Email)
+		assert.Nil(t, patched.HireDate)
+		assert.Empty(t, patched.Location)
+		assert.Equal(t, "+1 555 0100", patched.Phone, "fields the patch keeps are kept")
+	})
+
+	t.Run("PatchEmployee_Rejected", func(t *testing.T) {
+		rejected := errors.New("rejected")
+		_, err := dbHelper.PatchEmployee(created.ID, func(current models.Employee) (models.Employee, error) {
+			return current, rejected
+		})
+		assert.Equal(t, rejected, err)
+	})
+
+	t.Run("PatchEmployee_NotFound", func(t *testing.T) {
+		_, err := dbHelper.PatchEmployee(missingID, func(current models.Employee) (models.Employee, error) {
+			return current, nil
+		})
+		assert.ErrorIs(t, err, providers.ErrEmployeeNotFound)
+	})
+
+	t.Run("CreateEmployee_EmailTaken", func(t *testing.T) {
+		require.NoError(t, dbHelper.CreateEmployee(models.Employee{Name: name + "-email", Position: "Tester", Salary: models.MustParseMoney("1"), Email: name + "@example.com"}))
+		err := dbHelper.CreateEmployee(models.Employee{Name: name + "-dup", Position: "Tester", Salary: models.MustParseMoney("1"), Email: name + "@example.com"})
+		assert.ErrorIs(t, err, providers.ErrEmailTaken)
+		require.NoError(t, dbHelper.DeleteEmployeeById(findByName(t, dbHelper, name+"-email").ID))
+	})
+
+	t.Run("MergeEmployees", func(t *testing.T) {
+		require.NoError(t, dbHelper.CreateEmployee(models.Employee{Name: name + "-survivor", Position: "Tester", Salary: models.MustParseMoney("1")}))
+		require.NoError(t, dbHelper.CreateEmployee(models.Employee{Name: name + "-duplicate", Position: "QA", Salary: models.MustParseMoney("2"),
+			Email: name + "-merge@example.com", CustomFields: models.CustomFields{"team": "qa"}}))
+		survivor, duplicate := findByName(t, dbHelper, name+"-survivor"), findByName(t, dbHelper, name+"-duplicate")
+		defer func() { _ = dbHelper.DeleteEmployeeById(survivor.ID) }()
+
+		request := models.MergeRequest{SurvivorID: survivor.ID, DuplicateID: duplicate.ID, TakeFromDuplicate: []string{"position"}}
+		merged, err := dbHelper.MergeEmployees(request, "admin:0")
+		require.NoError(t, err)
+		assert.Equal(t, name+"-survivor", merged.Name)
+		assert.Equal(t, "QA", merged.Position)
+		assert.Equal(t, name+"-merge@example.com", merged.Email, "the survivor takes over the duplicate's email")
+		assert.Equal(t, models.CustomFields{"team": "qa"}, merged.CustomFields)
+
+		var mergedErr *providers.EmployeeMergedError
+		_, err = dbHelper.GetEmployeeById(duplicate.ID)
+		require.True(t, errors.As(err, &mergedErr), "got %v", err)
+		assert.Equal(t, survivor.ID, mergedErr.MergedInto)
+
+		_, err = dbHelper.MergeEmployees(request, "admin:0")
+		assert.True(t, errors.As(err, &mergedErr), "got %v", err)
+
+		merges, err := dbHelper.GetEmployeeMerges(survivor.ID)
+		require.NoError(t, err)
+		require.Len(t, merges, 1)
+		assert.Equal(t, duplicate.ID, merges[0].MergedID)
+		assert.Equal(t, "QA", merges[0].MergedEmployee.Position)
+		assert.Equal(t, "Tester", merges[0].SurvivorBefore.Position)
+		assert.Equal(t, models.StringList{"position"}, merges[0].TakenFromDuplicate)
+		assert.Equal(t, "admin:0", merges[0].MergedBy)
+	})
+
+	t.Run("MergeEmployees_NotFound", func(t *testing.T) {
+		_, err := dbHelper.MergeEmployees(models.MergeRequest{SurvivorID: created.ID, DuplicateID: missingID}, "admin:0")
+		assert.ErrorIs(t, err, providers.ErrEmployeeNotFound)
+	})
+
+	t.Run("CustomFieldDefinitions", func(t *testing.T) {
+		definition := models.CustomFieldDefinition{Name: "f_" + fmt.Sprint(time.Now().UnixNano()), Type: models.CustomFieldEnum,
+			Options: models.StringList{"a", "b"}, Description: "behavior suite"}
+		require.NoError(t, dbHelper.CreateCustomFieldDefinition(definition))
+		assert.ErrorIs(t, dbHelper.CreateCustomFieldDefinition(definition), providers.ErrCustomFieldExists)
+
+		definitions, err := dbHelper.GetCustomFieldDefinitions()
+		require.NoError(t, err)
+		var found *models.CustomFieldDefinition
+		for i := range definitions {
+			if definitions[i].Name == definition.Name {
+				found = &definitions[i]
+			}
+		}
+		require.NotNil(t, found)
+		assert.Equal(t, definition.Options, found.Options)
+		assert.False(t, found.CreatedAt.IsZero())
+
+		require.NoError(t, dbHelper.DeleteCustomFieldDefinition(definition.Name))
+		assert.ErrorIs(t, dbHelper.DeleteCustomFieldDefinition(definition.Name), providers.ErrCustomFieldNotFound)
+		definitions, err = dbHelper.GetCustomFieldDefinitions()
+		require.NoError(t, err)
+		for _, d := range definitions {
+			assert.NotEqual(t, definition.Name, d.Name)
+		}
+	})
+
+	t.Run("LeaveRequests", func(t *testing.T) {
+		leaveType, err := dbHelper.CreateLeaveType(models.LeaveType{Name: name + "-vacation", Accrual: models.AccrualMonthly, DaysPerPeriod: 2})
+		require.NoError(t, err)
+		_, err = dbHelper.CreateLeaveType(leaveType)
+		assert.ErrorIs(t, err, providers.ErrLeaveTypeExists)
+
+		// three full months of service by the first day of the leave earn six days
+		hired := models.NewDate(time.Date(2030, 1, 7, 0, 0, 0, 0, time.UTC))
+		employee, err := dbHelper.InsertEmployee(models.Employee{Name: name + "-leave", Position: "Engineer", Salary: models.MustParseMoney("1000"), HireDate: &hired})
+		require.NoError(t, err)
+		defer func() { _ = dbHelper.DeleteEmployeeById(employee.ID) }()
+		request := func(start, end string) models.LeaveRequest {
+			r := models.LeaveRequest{EmployeeID: employee.ID, LeaveTypeID: leaveType.ID}
+			r.StartDate, _ = models.ParseDate(start)
+			r.EndDate, _ = models.ParseDate(end)
+			require.Empty(t, r.Validate())
+			return r
+		}
+
+		first, err := dbHelper.CreateLeaveRequest(request("2030-04-08", "2030-04-10"))
+		require.NoError(t, err)
+		assert.Equal(t, models.LeavePending, first.Status)
+		assert.EqualValues(t, 3, first.Days)
+
+		_, err = dbHelper.CreateLeaveRequest(request("2030-04-10", "2030-04-11"))
+		assert.ErrorIs(t, err, providers.ErrLeaveOverlap)
+		_, err = dbHelper.CreateLeaveRequest(request("2030-04-15", "2030-04-18"))
+		assert.ErrorIs(t, err, providers.ErrInsufficientLeave, "the pending days are promised already")
+		_, err = dbHelper.CreateLeaveRequest(models.LeaveRequest{EmployeeID: employee.ID, LeaveTypeID: missingID,
+			StartDate: first.StartDate, EndDate: first.EndDate, Days: 1})
+		assert.ErrorIs(t, err, providers.ErrLeaveTypeNotFound)
+
+		second, err := dbHelper.CreateLeaveRequest(request("2030-04-15", "2030-04-17"))
+		require.NoError(t, err)
+
+		approved, err := dbHelper.DecideLeaveRequest(first.ID, models.LeaveApproved, "hr:0", "enjoy")
+		require.NoError(t, err)
+		assert.Equal(t, models.LeaveApproved, approved.Status)
+		assert.Equal(t, "hr:0", approved.DecidedBy)
+		assert.Equal(t, "enjoy", approved.DecisionNote)
+		require.NotNil(t, approved.DecidedAt)
+		_, err = dbHelper.DecideLeaveRequest(first.ID, models.LeaveRejected, "hr:0", "")
+		assert.ErrorIs(t, err, providers.ErrLeaveRequestDecided)
+		_, err = dbHelper.DecideLeaveRequest(-1, models.LeaveApproved, "hr:0", "")
+		assert.ErrorIs(t, err, providers.ErrLeaveRequestNotFound)
+
+		rejected, err := dbHelper.DecideLeaveRequest(second.ID, models.LeaveRejected, "hr:0", "")
+		require.NoError(t, err)
+		assert.Equal(t, models.LeaveRejected, rejected.Status)
+
+		stored, err := dbHelper.GetLeaveRequest(first.ID)
+		require.NoError(t, err)
+		assert.Equal(t, approved.Status, stored.Status)
+		assert.Equal(t, first.StartDate, stored.StartDate)
+		requests, err := dbHelper.GetLeaveRequests(employee.ID, "")
+		require.NoError(t, err)
+		require.Len(t, requests, 2)
+		assert.Equal(t, first.ID, requests[0].ID)
+		requests, err = dbHelper.GetLeaveRequests(employee.ID, models.LeaveApproved)
+		require.NoError(t, err)
+		require.Len(t, requests, 1)
+
+		balance := models.NewLeaveBalance(leaveType, employee.ServiceStart(), second.StartDate, requests)
+		assert.EqualValues(t, 6, balance.Accrued)
+		assert.EqualValues(t, 3, balance.Available)
+	})
+
+	t.Run("AttendanceAndTimesheets", func(t *testing.T) {
+		employee, err := dbHelper.InsertEmployee(models.Employee{Name: name + "-hours", Position: "Contractor", Salary: models.MustParseMoney("1000")})
+		require.NoError(t, err)
+		defer func() { _ = dbHelper.DeleteEmployeeById(employee.ID) }()
+
+		_, err = dbHelper.ClockOut(employee.ID)
+		assert.ErrorIs(t, err, providers.ErrNotClockedIn)
+		open, err := dbHelper.ClockIn(employee.ID, "office")
+		require.NoError(t, err)
+		assert.Nil(t, open.ClockOut)
+		_, err = dbHelper.ClockIn(employee.ID, "")
+		assert.ErrorIs(t, err, providers.ErrAlreadyClockedIn)
+		_, err = dbHelper.ClockIn(missingID, "")
+		assert.ErrorIs(t, err, providers.ErrEmployeeNotFound)
+		closed, err := dbHelper.ClockOut(employee.ID)
+		require.NoError(t, err)
+		assert.Equal(t, open.ID, closed.ID)
+		require.NotNil(t, closed.ClockOut)
+		assert.Equal(t, "office", closed.Note)
+
+		today := models.NewDate(time.Now().UTC())
+		entries, err := dbHelper.GetAttendance(employee.ID, today, today)
+		require.NoError(t, err)
+		require.Len(t, entries, 1)
+		assert.Equal(t, closed.ID, entries[0].ID)
+		entries, err = dbHelper.GetAttendance(0, models.Date{Time: today.AddDate(0, 0, 1)}, models.Date{Time: today.AddDate(0, 0, 7)})
+		require.NoError(t, err)
+		assert.Empty(t, entries)
+
+		week, _ := models.ParseDate("2030-03-04")
+		timesheet := models.Timesheet{EmployeeID: employee.ID, WeekStart: week,
+			Entries: models.TimesheetEntries{{Date: week, Project: "Apollo", Hours: 10}}}
+		require.Empty(t, timesheet.Validate())
+		submitted, err := dbHelper.CreateTimesheet(timesheet)
+		require.NoError(t, err)
+		assert.Equal(t, models.TimesheetSubmitted, submitted.Status)
+		assert.Equal(t, timesheet.Entries, submitted.Entries)
+		_, err = dbHelper.CreateTimesheet(timesheet)
+		assert.ErrorIs(t, err, providers.ErrTimesheetExists)
+
+		rejected, err := dbHelper.DecideTimesheet(submitted.ID, models.TimesheetRejected, "hr:0", "wrong project")
+		require.NoError(t, err)
+		assert.Equal(t, models.TimesheetRejected, rejected.Status)
+		_, err = dbHelper.DecideTimesheet(submitted.ID, models.TimesheetApproved, "hr:0", "")
+		assert.ErrorIs(t, err, providers.ErrTimesheetDecided)
+		_, err = dbHelper.DecideTimesheet(-1, models.TimesheetApproved, "hr:0", "")
+		assert.ErrorIs(t, err, providers.ErrTimesheetNotFound)
+
+		// a rejected timesheet gives its week back
+		resubmitted, err := dbHelper.CreateTimesheet(timesheet)
+		require.NoError(t, err)
+		_, err = dbHelper.DecideTimesheet(resubmitted.ID, models.TimesheetApproved, "hr:0", "")
+		require.NoError(t, err)
+		timesheets, err := dbHelper.GetTimesheets(models.TimesheetFilter{EmployeeID: employee.ID})
+		require.NoError(t, err)
+		assert.Len(t, timesheets, 2)
+		timesheets, err = dbHelper.GetTimesheets(models.TimesheetFilter{WeekStart: week, Status: models.TimesheetApproved})
+		require.NoError(t, err)
+		require.Len(t, timesheets, 1)
+		assert.Equal(t, resubmitted.ID, timesheets[0].ID)
+		assert.EqualValues(t, 10, timesheets[0].Hours)
+	})
+
+	t.Run("SalaryHistoryAndPayroll", func(t *testing.T) {
+		hired, _ := models.ParseDate("2020-01-15")
+		employee, err := dbHelper.InsertEmployee(models.Employee{Name: name + "-payroll", Position: "Engineer", Salary: models.MustParseMoney("1000"), HireDate: &hired})
+		require.NoError(t, err)
+		defer func() { _ = dbHelper.DeleteEmployeeById(employee.ID) }()
+
+		employee.Salary = models.MustParseMoney("2000")
+		_, err = dbHelper.UpdateEmployee(models.Employee{ID: employee.ID, Salary: models.MustParseMoney("2000")})
+		require.NoError(t, err)
+		_, err = dbHelper.UpdateEmployee(models.Employee{ID: employee.ID, Location: "Berlin"})
+		require.NoError(t, err)
+		today := models.NewDate(time.Now().UTC())
+		history, err := dbHelper.GetSalaryHistory(employee.ID, today)
+		require.NoError(t, err)
+		require.Len(t, history, 2, "only changes of the salary are recorded")
+		assert.Equal(t, models.MustParseMoney("1000"), history[0].Salary)
+		assert.Equal(t, hired, history[0].EffectiveFrom, "a new employee's salary is effective from the hire date")
+		assert.Equal(t, models.MustParseMoney("2000"), history[1].Salary)
+		assert.Equal(t, today, history[1].EffectiveFrom)
+		before, err := dbHelper.GetSalaryHistory(employee.ID, hired)
+		require.NoError(t, err)
+		assert.Len(t, before, 1)
+
+		// runs are unique per period, so every run of the suite uses its own
+		period := models.PayPeriod(fmt.Sprintf("%d-07", 3000+time.Now().UnixNano()/1000%6000))
+		rules := models.PayrollRules{SalaryBasis: models.SalaryMonthly, Components: []models.PayComponent{
+			{Name: "tax", Kind: models.Deduction, Percent: 10}}}
+		run, err := models.NewPayrollRun(period, rules, []models.Employee{employee}, history, nil,
+			models.NewConverter(models.DefaultCurrency, period.End(), nil))
+		require.NoError(t, err)
+		run.CreatedBy = "hr:0"
+		// concurrent first runs of the period replace each other rather than fail
+		type saved struct {
+			run models.PayrollRun
+			err error
+		}
+		saves := make(chan saved, 4)
+		for i := 0; i < cap(saves); i++ {
+			go func() {
+				draft, err := dbHelper.SavePayrollRun(run)
+				saves <- saved{draft, err}
+			}()
+		}
+		var draft models.PayrollRun
+		for i := 0; i < cap(saves); i++ {
+			result := <-saves
+			require.NoError(t, result.err)
+			if result.run.ID > draft.ID {
+				draft = result.run
+			}
+		}
+		assert.Equal(t, models.PayrollDraft, draft.Status)
+		require.Len(t, draft.Payslips, 1)
+		assert.NotZero(t, draft.Payslips[0].ID)
+		replaced, err := dbHelper.SavePayrollRun(run)
+		require.NoError(t, err)
+		assert.NotEqual(t, draft.ID, replaced.ID, "a draft is replaced when the period is run again")
+		_, err = dbHelper.GetPayrollRun(draft.ID)
+		assert.ErrorIs(t, err, providers.ErrPayrollRunNotFound)
+
+		stored, err := dbHelper.GetPayrollRun(replaced.ID)
+		require.NoError(t, err)
+		assert.Equal(t, rules, stored.Rules)
+		assert.Equal(t, "hr:0", stored.CreatedBy)
+		require.Len(t, stored.Payslips, 1)
+		assert.Equal(t, replaced.Payslips[0], stored.Payslips[0])
+		assert.Equal(t, models.MustParseMoney("1800"), stored.Payslips[0].Net)
+		payslips, err := dbHelper.GetPayslips(employee.ID)
+		require.NoError(t, err)
+		assert.Empty(t, payslips, "draft payslips are not listed")
+		_, err = dbHelper.GetPayslip(employee.ID, period)
+		assert.ErrorIs(t, err, providers.ErrPayslipNotFound)
+
+		approved, err := dbHelper.ApprovePayrollRun(replaced.ID, "hr:0")
+		require.NoError(t, err)
+		assert.Equal(t, models.PayrollApproved, approved.Status)
+		assert.NotNil(t, approved.ApprovedAt)
+		_, err = dbHelper.ApprovePayrollRun(replaced.ID, "hr:0")
+		assert.ErrorIs(t, err, providers.ErrPayrollRunApproved)
+		_, err = dbHelper.SavePayrollRun(run)
+		assert.ErrorIs(t, err, providers.ErrPayrollRunApproved)
+		_, err = dbHelper.ApprovePayrollRun(-1, "hr:0")
+		assert.ErrorIs(t, err, providers.ErrPayrollRunNotFound)
+
+		payslips, err = dbHelper.GetPayslips(employee.ID)
+		require.NoError(t, err)
+		require.Len(t, payslips, 1)
+		assert.Equal(t, period, payslips[0].Period)
+		payslip, err := dbHelper.GetPayslip(employee.ID, period)
+		require.NoError(t, err)
+		assert.Equal(t, payslips[0], payslip)
+		runs, err := dbHelper.GetPayrollRuns()
+		require.NoError(t, err)
+		assert.Contains(t, runs, models.PayrollRun{ID: approved.ID, Period: period, Status: models.PayrollApproved, Rules: rules,
+			Currency: models.DefaultCurrency, Employees: 1, Gross: models.MustParseMoney("2000"), Deductions: models.MustParseMoney("200"),
+			Net: models.MustParseMoney("1800"), CreatedBy: "hr:0", CreatedAt: approved.CreatedAt, ApprovedBy: "hr:0", ApprovedAt: approved.ApprovedAt})
+	})
+
+	t.Run("ExchangeRates", func(t *testing.T) {
+		// rates are unique per currency and day, so every run of the suite uses its own days
+		day := models.NewDate(time.Date(3000+int(time.Now().UnixNano()/1000%6000), time.January, 1, 0, 0, 0, 0, time.UTC))
+		next := models.Date{Time: day.AddDate(0, 0, 1)}
+		saved, err := dbHelper.SaveExchangeRates([]models.ExchangeRate{
+			{Currency: "EUR", Rate: models.MustParseRate("1.0834"), EffectiveDate: day},
+			{Currency: "EUR", Rate: models.MustParseRate("1.1"), EffectiveDate: next},
+		})
+		require.NoError(t, err)
+		require.Len(t, saved, 2)
+		assert.Equal(t, "1.0834", saved[0].Rate.String())
+		assert.False(t, saved[0].UpdatedAt.IsZero())
+
+		replaced, err := dbHelper.SaveExchangeRates([]models.ExchangeRate{{Currency: "EUR", Rate: models.MustParseRate("1.12345678"), EffectiveDate: next}})
+		require.NoError(t, err)
+		assert.Equal(t, "1.12345678", replaced[0].Rate.String(), "a rate of the same day is replaced")
+
+		rates, err := dbHelper.GetExchangeRates(models.ExchangeRateFilter{Currency: "EUR", Until: &next})
+		require.NoError(t, err)
+		require.GreaterOrEqual(t, len(rates), 2)
+		assert.Equal(t, replaced[0], rates[len(rates)-1])
+		assert.Equal(t, saved[0], rates[len(rates)-2])
+		rates, err = dbHelper.GetExchangeRates(models.ExchangeRateFilter{Currency: "GBP", Until: &day})
+		require.NoError(t, err)
+		for _, rate := range rates {
+			assert.Equal(t, models.Currency("GBP"), rate.Currency)
+		}
+	})
+
+	t.Run("IdempotencyKeys", func(t *testing.T) {
+		record := models.IdempotencyRecord{Scope: "hr:0", Key: name, Fingerprint: strings.Repeat("a", 64), ExpiresAt: time.Now().Add(time.Hour)}
+
+		_, claimed, err := dbHelper.ClaimIdempotencyKey(record)
+		require.NoError(t, err)
+		assert.True(t, claimed)
+
+		stored, claimed, err := dbHelper.ClaimIdempotencyKey(record)
+		require.NoError(t, err)
+		assert.False(t, claimed)
+		assert.False(t, stored.Completed(), "the response is not stored yet")
+
+		record.StatusCode, record.ContentType, record.Body = 201, "application/json", []byte(`{"status":"success"}`)
+		require.NoError(t, dbHelper.CompleteIdempotencyKey(record))
+		stored, _, err = dbHelper.ClaimIdempotencyKey(record)
+		require.NoError(t, err)
+		assert.Equal(t, record.Fingerprint, stored.Fingerprint)
+		assert.Equal(t, 201, stored.StatusCode)
+		assert.Equal(t, record.Body, stored.Body)
+
+		require.NoError(t, dbHelper.ReleaseIdempotencyKey(record.Scope, record.Key))
+		_, claimed, err = dbHelper.ClaimIdempotencyKey(record)
+		require.NoError(t, err)
+		assert.True(t, claimed, "released keys can be claimed again")
+
+		expired := models.IdempotencyRecord{Scope: "hr:0", Key: name + "-expired", Fingerprint: record.Fingerprint, ExpiresAt: time.Now().Add(-time.Second)}
+		_, claimed, err = dbHelper.ClaimIdempotencyKey(expired)
+		require.NoError(t, err)
+		require.True(t, claimed)
+		_, claimed, err = dbHelper.ClaimIdempotencyKey(expired)
+		require.NoError(t, err)
+		assert.True(t, claimed, "expired keys can be claimed again")
+
+		// a completed key is kept until its new expiry, not the lease it was claimed with
+		expired.StatusCode, expired.ExpiresAt = 201, time.Now().Add(time.Hour)
+		require.NoError(t, dbHelper.CompleteIdempotencyKey(expired))
+		stored, claimed, err = dbHelper.ClaimIdempotencyKey(expired)
+		require.NoError(t, err)
+		assert.False(t, claimed)
+		assert.True(t, stored.Completed())
+
+		require.NoError(t, dbHelper.ReleaseIdempotencyKey(record.Scope, record.Key))
+		require.NoError(t, dbHelper.ReleaseIdempotencyKey(expired.Scope, expired.Key))
+	})
+
+	t.Run("GetAllEmployees_Pagination", func(t *testing.T) {
+		require.NoError(t, dbHelper.CreateEmployee(models.Employee{Name: name + "-2", Position: "Tester", Salary: models.MustParseMoney("1000")}))
+
+		firstPage, err := dbHelper.GetAllEmployees("1", "1")
+		require.NoError(t, err)
+		assert.Len(t, firstPage, 1)
+
+		secondPage, err := dbHelper.GetAllEmployees("2", "1")
+		require.NoError(t, err)
+		require.Len(t, secondPage, 1)
+		assert.Greater(t, secondPage[0].ID, firstPage[0].ID, "employees are ordered by ID")
+	})
+
+	t.Run("GetAllEmployees_InvalidParams", func(t *testing.T) {
+		_, err := dbHelper.GetAllEmployees("first", "10")
+		assert.EqualError(t, err, "invalid page number: first")
+
+		_, err = dbHelper.GetAllEmployees("1", "ten")
+		assert.EqualError(t, err, "invalid limit: ten")
+	})
+
+	t.Run("SearchEmployees", func(t *testing.T) {
+		second := findByName(t, dbHelper, name+"-2")
+
+		matches, err := dbHelper.SearchEmployees(models.EmployeeFilter{NameContains: strings.ToUpper(name)}, 0, 1)
+		require.NoError(t, err)
+		require.Len(t, matches, 1)
+		assert.Equal(t, created.ID, matches[0].ID, "names match ignoring case, ordered by ID")
+
+		matches, err = dbHelper.SearchEmployees(models.EmployeeFilter{NameContains: name}, created.ID, 10)
+		require.NoError(t, err)
+		require.Len(t, matches, 1)
+		assert.Equal(t, second.ID, matches[0].ID, "only employees after the given ID are returned")
+
+		matches, err = dbHelper.SearchEmployees(models.EmployeeFilter{NameContains: name, Position: "Tester", Status: models.StatusActive}, 0, 10)
+		require.NoError(t, err)
+		assert.Len(t, matches, 1)
+
+		matches, err = dbHelper.SearchEmployees(models.EmployeeFilter{NameContains: strings.Replace(name, "-", "_", 1)}, 0, 10)
+		require.NoError(t, err)
+		assert.Empty(t, matches, "wildcards in the name are matched literally")
+	})
+
+	t.Run("GetEmployeesByIds", func(t *testing.T) {
+		second := findByName(t, dbHelper, name+"-2")
+
+		employees, err := dbHelper.GetEmployeesByIds([]int{second.ID, missingID, created.ID})
+		require.NoError(t, err)
+		require.Len(t, employees, 2, "missing IDs are left out")
+		assert.Equal(t, second, employees[1])
+	})
+
+	t.Run("EmployeeEventLog", func(t *testing.T) {
+		// Postgres keeps the log of earlier runs, so the events of this run are read after the latest one
+		latest, err := dbHelper.AppendEmployeeEvent(models.EmployeeEvent{Type: models.EmployeeDeleted, EmployeeID: missingID, Time: time.Now()})
+		require.NoError(t, err)
+		updated, err := dbHelper.AppendEmployeeEvent(models.EmployeeEvent{Type: models.EmployeeUpdated, EmployeeID: created.ID, Employee: &created, Time: time.Now()})
+		require.NoError(t, err)
+		assert.Greater(t, updated.ID, latest.ID)
+		_, err = dbHelper.AppendEmployeeEvent(models.EmployeeEvent{Type: models.EmployeeDeleted, EmployeeID: created.ID, Time: time.Now()})
+		require.NoError(t, err)
+
+		events, err := dbHelper.GetEmployeeEvents(latest.ID, nil, 10)
+		require.NoError(t, err)
+		require.Len(t, events, 2)
+		assert.Equal(t, updated.ID, events[0].ID)
+		require.NotNil(t, events[0].Employee)
+		assert.Equal(t, created.Name, events[0].Employee.Name)
+		assert.Nil(t, events[1].Employee)
+
+		events, err = dbHelper.GetEmployeeEvents(latest.ID, []models.EmployeeEventType{models.EmployeeDeleted, models.EmployeeCreated}, 10)
+		require.NoError(t, err)
+		require.Len(t, events, 1)
+		assert.Equal(t, models.EmployeeDeleted, events[0].Type)
+
+		events, err = dbHelper.GetEmployeeEvents(latest.ID, nil, 1)
+		require.NoError(t, err)
+		assert.Len(t, events, 1, "at most limit events are returned")
+	})
+
+	t.Run("WebhookDeliveries", func(t *testing.T) {
+		subscription, err := dbHelper.CreateWebhookSubscription(models.WebhookSubscription{URL: "https://hooks.example.com/" + name,
+			EventTypes: models.StringList{string(models.EmployeeUpdated)}, Secret: "0123456789abcdef"})
+		require.NoError(t, err)
+		defer dbHelper.DeleteWebhookSubscription(subscription.ID)
+
+		// a logged event is queued for the subscriptions that want it, once
+		deleted, err := dbHelper.AppendEmployeeEvent(models.EmployeeEvent{Type: models.EmployeeDeleted, EmployeeID: created.ID, Time: time.Now()})
+		require.NoError(t, err)
+		event, err := dbHelper.AppendEmployeeEvent(models.EmployeeEvent{Type: models.EmployeeUpdated, EmployeeID: created.ID, Employee: &created, Time: time.Now()})
+		require.NoError(t, err)
+		for _, e := range []models.EmployeeEvent{deleted, event, event} {
+			_, err := dbHelper.QueueWebhookDeliveries(e)
+			require.NoError(t, err)
+		}
+
+		now := time.Now()
+		claimed, err := dbHelper.ClaimWebhookDeliveries(now, now.Add(time.Minute), 100)
+		require.NoError(t, err)
+		var delivery *models.WebhookDelivery
+		for i := range claimed {
+			if claimed[i].SubscriptionID == subscription.ID {
+				require.Nil(t, delivery, "only the updated event is delivered")
+				delivery = &claimed[i]
+			}
+		}
+		require.NotNil(t, delivery)
+		assert.Equal(t, event.ID, delivery.EventID)
+		var payload models.EmployeeEvent
+		require.NoError(t, json.Unmarshal(delivery.Payload, &payload))
+		assert.Equal(t, event.ID, payload.ID)
+
+		// a leased delivery is not claimed twice
+		claimed, err = dbHelper.ClaimWebhookDeliveries(now, now.Add(time.Minute), 100)
+		require.NoError(t, err)
+		for _, d := range claimed {
+			assert.NotEqual(t, delivery.ID, d.ID)
+		}
+
+		delivery.Status, delivery.Attempts, delivery.NextAttemptAt, delivery.LastStatusCode, delivery.LastError = models.WebhookDead, 8, nil, 500, "receiver answered 500"
+		require.NoError(t, dbHelper.CompleteWebhookAttempt(*delivery))
+		deliveries, err := dbHelper.GetWebhookDeliveries(subscription.ID, models.WebhookDead, 10)
+		require.NoError(t, err)
+		require.Len(t, deliveries, 1)
+		assert.Equal(t, 500, deliveries[0].LastStatusCode)
+		deliveries, err = dbHelper.GetWebhookDeliveries(subscription.ID, models.WebhookPending, 10)
+		require.NoError(t, err)
+		assert.Empty(t, deliveries)
+
+		redelivered, err := dbHelper.RedeliverWebhook(subscription.ID, delivery.ID)
+		require.NoError(t, err)
+		assert.Equal(t, models.WebhookPending, redelivered.Status)
+		assert.Zero(t, redelivered.Attempts)
+		_, err = dbHelper.RedeliverWebhook(subscription.ID, missingID)
+		assert.ErrorIs(t, err, providers.ErrWebhookDeliveryNotFound)
+
+		require.NoError(t, dbHelper.DeleteWebhookSubscription(subscription.ID))
+		assert.ErrorIs(t, dbHelper.DeleteWebhookSubscription(subscription.ID), providers.ErrWebhookNotFound)
+	})
+
+	t.Run("Outbox", func(t *testing.T) {
+		// employee writes queue their events with the change
+		employee, err := dbHelper.InsertEmployee(models.Employee{Name: name + "-outbox", Position: "Engineer", Salary: models.MustParseMoney("1000")})
+		require.NoError(t, err)
+		_, err = dbHelper.UpdateEmployee(models.Employee{ID: employee.ID, Salary: models.MustParseMoney("1100")})
+		require.NoError(t, err)
+
+		claim := func() []models.OutboxMessage {
+			now := time.Now()
+			claimed, err := dbHelper.ClaimOutboxMessages(now, now.Add(time.Minute), 1000)
+			require.NoError(t, err)
+			var mine []models.OutboxMessage
+			for _, message := range claimed {
+				if message.Event.EmployeeID == employee.ID {
+					mine = append(mine, message)
+				}
+			}
+			return mine
+		}
+
+		// the update waits for the insert, and a leased message is not claimed again
+		claimed := claim()
+		require.Len(t, claimed, 1)
+		assert.Equal(t, models.EmployeeCreated, claimed[0].Event.Type)
+		require.NotNil(t, claimed[0].Event.Employee)
+		assert.Equal(t, employee.Name, claimed[0].Event.Employee.Name)
+		assert.Empty(t, claim())
+
+		backlog, err := dbHelper.GetOutboxBacklog()
+		require.NoError(t, err)
+		assert.GreaterOrEqual(t, backlog.Pending, 2)
+		require.NotNil(t, backlog.OldestCreatedAt)
+
+		// a failed attempt is retried when due, the update keeps waiting meanwhile
+		message := claimed[0]
+		message.Attempts, message.NextAttemptAt, message.LastError = 1, time.Now().Add(-time.Second), "file: disk full"
+		require.NoError(t, dbHelper.CompleteOutboxAttempt(message, false))
+		claimed = claim()
+		require.Len(t, claimed, 1)
+		assert.Equal(t, message.ID, claimed[0].ID)
+		assert.Equal(t, 1, claimed[0].Attempts)
+		assert.Equal(t, "file: disk full", claimed[0].LastError)
+
+		require.NoError(t, dbHelper.CompleteOutboxAttempt(claimed[0], true))
+		claimed = claim()
+		require.Len(t, claimed, 1)
+		assert.Equal(t, models.EmployeeUpdated, claimed[0].Event.Type)
+		require.NoError(t, dbHelper.CompleteOutboxAttempt(claimed[0], true))
+
+		// deleting the employee queues its deletion, deleting it again queues nothing
+		require.NoError(t, dbHelper.DeleteEmployeeById(employee.ID))
+		require.ErrorIs(t, dbHelper.DeleteEmployeeById(employee.ID), providers.ErrEmployeeNotFound)
+		claimed = claim()
+		require.Len(t, claimed, 1)
+		assert.Equal(t, models.EmployeeDeleted, claimed[0].Event.Type)
+		assert.Nil(t, claimed[0].Event.Employee)
+		require.NoError(t, dbHelper.CompleteOutboxAttempt(claimed[0], true))
+	})
+
+	t.Run("DeleteEmployee_Success", func(t *testing.T) {
+		for _, emp := range []models.Employee{created, findByName(t, dbHelper, name+"-2")} {
+			require.NoError(t, dbHelper.DeleteEmployeeById(emp.ID))
+
+			_, err := dbHelper.GetEmployeeById(emp.ID)
+			assert.Error(t, err)
+		}
+	})
+
+	t.Run("DeleteEmployee_NotFound", func(t *testing.T) {
+		assert.ErrorIs(t, dbHelper.DeleteEmployeeById(missingID), providers.ErrEmployeeNotFound)
+	})
+}
+
+// findByName pages through all employees, CreateEmployee does not return the new ID
+func findByName(t *testing.T, dbHelper providers.DbHelperProvider, name string) models.Employee {
+	t.Helper()
+	for page := 1; ; page++ {
+		employees, err := dbHelper.GetAllEmployees(fmt.Sprint(page), "100")
+		require.NoError(t, err)
+		for _, emp := range employees {
+			if emp.Name == name {
+				return emp
+			}
+		}
+		if len(employees) < 100 {
+			t.Fatalf("employee %q not found", name)
+		}
+	}
+}
@@ -0,0 +1,208 @@
+package docs_test
+
+import (
+	"Techiebulter/interview/backend/server"
+	"Techiebulter/interview/backend/test/internal/apitest"
+	"encoding/json"
+	"io"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// newApp serves the API from a fresh SQLite database with responses checked against the document
+func newApp(t *testing.T) *fiber.App {
+	t.Helper()
+	cfg := apitest.Config(t)
+	client, dbHelper := apitest.Open(t, cfg)
+	return (&server.Server{Config: cfg, PGClient: client, DBHelper: dbHelper}).InjectRoutes()
+}
+
+func call(t *testing.T, app *fiber.App, method, path, contentType, body string) (int, map[string]interface{}) {
+	t.Helper()
+	req := httptest.NewRequest(method, path, strings.NewReader(body))
+	if contentType != "" {
+		req.Header.Set(fiber.HeaderContentType, contentType)
+	}
+	resp, err := app.Test(req)
+	require.NoError(t, err)
+	data, err := io.ReadAll(resp.Body)
+	require.NoError(t, err)
+
+	var decoded map[string]interface{}
+	_ = json.Unmarshal(data, &decoded)
+	require.NotEqual(t, "response does not match the OpenAPI document", decoded["error"], "%s %s: %s", method, path, data)
+	return resp.StatusCode, decoded
+}
+
+// TestResponsesMatchSpec walks through the API, every response must match the document
+func TestResponsesMatchSpec(t *testing.T) {
+	app := newApp(t)
+	const jsonType = fiber.MIMEApplicationJSON
+
+	steps := []struct {
+		method, path, contentType, body string
+		status                          int
+	}{
+		{"GET", "/", "", "", 200},
+		{"GET", "/api/", "", "", 200},
+		{"GET", "/api/healthchecker", "", "", 200},
+		{"GET", "/api/readiness", "", "", 200},
+		{"POST", "/api/CreateEmpolyee", jsonType, `{"Name":"John Doe","position":"Engineer","Salary":1000,"hireDate":"2024-01-31"}`, 200},
+		{"POST", "/api/CreateEmpolyee", jsonType, `{"Name":"Jon Doe","position":"QA","Salary":900,"email":"jon@example.com"}`, 200},
+		{"POST", "/api/CreateEmpolyee", jsonType, `{"Name":"Jane Roe","position":"QA","Salary":900,"email":"jon@example.com"}`, 409},
+		{"POST", "/api/CreateEmpolyee", jsonType, `{"Name":"","position":"QA","Salary":-1}`, 422},
+		{"GET", "/api/GetEmployeeById/1", "", "", 200},
+		{"GET", "/api/GetEmployeeById/99", "", "", 404},
+		{"PUT", "/api/UpdateEmployee", jsonType, `{"ID":1,"status":"on-leave"}`, 200},
+		{"PUT", "/api/UpdateEmployee", jsonType, `{"ID":99,"status":"on-leave"}`, 404},
+		{"GET", "/api/GetAllEmployees/1/10", "", "", 200},
+		{"GET", "/api/GetAllEmployees/5/10", "", "", 200},
+		{"PATCH", "/api/v1/employees/1", "application/merge-patch+json", `{"location":"Berlin"}`, 200},
+		{"PATCH", "/api/v1/employees/1", "application/json-patch+json", `[{"op":"test","path":"/Salary","value":1}]`, 409},
+		{"PATCH", "/api/v1/employees/1", "text/plain", `{}`, 415},
+		{"PATCH", "/api/v1/employees/99", "application/merge-patch+json", `{}`, 404},
+		{"POST", "/api/v1/custom-fields", jsonType, `{"name":"team","type":"enum","options":["core","web"]}`, 200},
+		{"POST", "/api/v1/custom-fields", jsonType, `{"name":"team","type":"string"}`, 409},
+		{"GET", "/api/v1/custom-fields", "", "", 200},
+		{"GET", "/api/v1/employees/duplicates?minScore=0.5", "", "", 200},
+		{"POST", "/api/v1/employees/merge", jsonType, `{"survivorId":1,"duplicateId":2}`, 200},
+		{"GET", "/api/GetEmployeeById/2", "", "", 301},
+		{"POST", "/api/v1/employees/merge", jsonType, `{"survivorId":1,"duplicateId":2}`, 409},
+		{"GET", "/api/v1/employees/1/merges", "", "", 200},
+		{"POST", "/api/v1/leave-types", jsonType, `{"name":"Vacation","accrual":"yearly","daysPerPeriod":25}`, 200},
+		{"POST", "/api/v1/leave-types", jsonType, `{"name":"Vacation","accrual":"monthly","daysPerPeriod":2}`, 409},
+		{"POST", "/api/v1/leave-types", jsonType, `{"name":"Unpaid","accrual":"unlimited","daysPerPeriod":2}`, 422},
+		{"GET", "/api/v1/leave-types", "", "", 200},
+		{"POST", "/api/v1/employees/1/leave-requests", jsonType, `{"leaveTypeId":1,"startDate":"2030-03-04","endDate":"2030-03-08","reason":"Skiing"}`, 200},
+		{"POST", "/api/v1/employees/1/leave-requests", jsonType, `{"leaveTypeId":1,"startDate":"2030-03-08","endDate":"2030-03-11"}`, 409},
+		{"POST", "/api/v1/employees/1/leave-requests", jsonType, `{"leaveTypeId":9,"startDate":"2030-04-01","endDate":"2030-04-01"}`, 422},
+		{"POST", "/api/v1/employees/99/leave-requests", jsonType, `{"leaveTypeId":1,"startDate":"2030-04-01","endDate":"2030-04-01"}`, 404},
+		{"POST", "/api/v1/leave-requests/1/approve", jsonType, `{"note":"Enjoy"}`, 200},
+		{"POST", "/api/v1/leave-requests/1/reject", "", "", 409},
+		{"POST", "/api/v1/leave-requests/9/cancel", "", "", 404},
+		{"GET", "/api/v1/employees/1/leave-requests?status=approved", "", "", 200},
+		{"GET", "/api/v1/employees/1/leave-balances?on=2030-03-01", "", "", 200},
+		{"GET", "/api/v1/employees/99/leave-balances", "", "", 404},
+		{"POST", "/api/v1/employees/1/clock-in", jsonType, `{"note":"Office"}`, 200},
+		{"POST", "/api/v1/employees/1/clock-in", "", "", 409},
+		{"POST", "/api/v1/employees/99/clock-in", "", "", 404},
+		{"POST", "/api/v1/employees/1/clock-out", "", "", 200},
+		{"POST", "/api/v1/employees/1/clock-out", "", "", 409},
+		{"GET", "/api/v1/employees/1/attendance", "", "", 200},
+		{"GET", "/api/v1/employees/1/attendance?from=2030-03-08&to=2030-03-04", "", "", 422},
+		{"POST", "/api/v1/employees/1/timesheets", jsonType, `{"weekStart":"2030-03-04","entries":[{"date":"2030-03-04","project":"Apollo","hours":9.5}]}`, 200},
+		{"POST", "/api/v1/employees/1/timesheets", jsonType, `{"weekStart":"2030-03-04","entries":[{"date":"2030-03-05","project":"Apollo","hours":8}]}`, 409},
+		{"POST", "/api/v1/employees/1/timesheets", jsonType, `{"weekStart":"2030-03-05","entries":[{"date":"2030-03-05","project":"Apollo","hours":8}]}`, 422},
+		{"POST", "/api/v1/timesheets/1/approve", jsonType, `{"note":"Thanks"}`, 200},
+		{"POST", "/api/v1/timesheets/1/reject", "", "", 409},
+		{"POST", "/api/v1/timesheets/9/reject", "", "", 404},
+		{"GET", "/api/v1/employees/1/timesheets?status=approved", "", "", 200},
+		{"GET", "/api/v1/reports/weekly-hours?week=2030-03-06", "", "", 200},
+		{"POST", "/api/v1/payroll/runs/preview", jsonType, `{"period":"2030-03"}`, 200},
+		{"POST", "/api/v1/payroll/runs/preview", jsonType, `{"period":"2030-13"}`, 422},
+		{"POST", "/api/v1/payroll/runs", jsonType, `{"period":"2030-03"}`, 200},
+		{"GET", "/api/v1/payroll/runs", "", "", 200},
+		{"GET", "/api/v1/payroll/runs/1", "", "", 200},
+		{"GET", "/api/v1/payroll/runs/9", "", "", 404},
+		{"POST", "/api/v1/payroll/runs/1/approve", "", "", 200},
+		{"POST", "/api/v1/payroll/runs/1/approve", "", "", 409},
+		{"POST", "/api/v1/payroll/runs", jsonType, `{"period":"2030-03"}`, 409},
+		{"GET", "/api/v1/employees/1/salary-history?until=2030-12-31", "", "", 200},
+		{"GET", "/api/v1/employees/1/payslips", "", "", 200},
+		{"GET", "/api/v1/employees/1/payslips/2030-03.pdf", "", "", 200},
+		{"GET", "/api/v1/employees/1/payslips/2030-04.pdf", "", "", 404},
+		{"GET", "/api/v1/employees/1/payslips/2030-4.pdf", "", "", 422},
+		{"POST", "/api/v1/exchange-rates", jsonType, `{"currency":"EUR","rate":1.0834,"effectiveDate":"2030-01-01"}`, 200},
+		{"POST", "/api/v1/exchange-rates", jsonType, `{"currency":"USD","rate":1,"effectiveDate":"2030-01-01"}`, 422},
+		{"POST", "/api/v1/exchange-rates/csv", "text/csv", "currency,rate,effectiveDate\nGBP,1.27,2030-01-01\n", 200},
+		{"POST", "/api/v1/exchange-rates/csv", "text/csv", "currency,rate,effectiveDate\nGBP,-1,2030-01-01\n", 422},
+		{"GET", "/api/v1/exchange-rates?currency=EUR&until=2030-12-31", "", "", 200},
+		{"GET", "/api/v1/reports/salaries?asOf=2030-03-31&groupBy=position&currency=EUR", "", "", 200},
+		{"GET", "/api/v1/reports/salaries?groupBy=team", "", "", 422},
+		{"GET", "/api/v1/reports/compensation?asOf=2030-03-31&groupBy=department", "", "", 200},
+		{"GET", "/api/v1/reports/compensation?currency=EURO", "", "", 422},
+		{"GET", "/api/v1/reports/headcount?from=2024-01-01&to=2030-12-31&interval=quarter", "", "", 200},
+		{"GET", "/api/v1/reports/headcount?from=2024-01-01&to=2023-12-31", "", "", 422},
+		{"POST", "/api/v1/salary-bands", jsonType, `{"position":"engineer","currency":"USD","min":500,"mid":800,"max":900}`, 200},
+		{"POST", "/api/v1/salary-bands", jsonType, `{"position":"QA","currency":"USD","min":1000,"mid":1200,"max":1400,"enforcement":"reject"}`, 200},
+		{"POST", "/api/v1/salary-bands", jsonType, `{"position":" Engineer","currency":"USD","min":500,"mid":800,"max":900}`, 409},
+		{"POST", "/api/v1/salary-bands", jsonType, `{"position":"Manager","currency":"USD","min":900,"mid":800,"max":1000}`, 422},
+		{"GET", "/api/v1/salary-bands", "", "", 200},
+		{"PUT", "/api/v1/salary-bands/2", jsonType, `{"position":"QA","currency":"USD","min":1000,"mid":1100,"max":1400,"enforcement":"reject"}`, 200},
+		{"PUT", "/api/v1/salary-bands/9", jsonType, `{"position":"QA","currency":"USD","min":1000,"mid":1100,"max":1400}`, 404},
+		{"PUT", "/api/UpdateEmployee", jsonType, `{"ID":1,"Salary":950}`, 200},
+		{"POST", "/api/CreateEmpolyee", jsonType, `{"Name":"Jane Roe","position":"QA","Salary":900}`, 422},
+		{"GET", "/api/v1/reports/pay-equity?asOf=2030-03-31", "", "", 200},
+		{"GET", "/api/v1/reports/pay-equity?groupBy=team", "", "", 422},
+		{"DELETE", "/api/v1/salary-bands/2", "", "", 200},
+		{"DELETE", "/api/v1/salary-bands/2", "", "", 404},
+		{"GET", "/graphql?query=%7Bemployee(id:2)%7Bid%7D%7D", "", "", 200},
+		{"GET", "/graphql?query=mutation%7BdeleteEmployee(id:1)%7D", "", "", 405},
+		{"POST", "/graphql", jsonType, `{"query":"{ employees(first: 5) { edges { node { id name hireDate customFields } } pageInfo { hasNextPage } } }"}`, 200},
+		{"POST", "/graphql", jsonType, `{"query":"{ employees { unknown } }"}`, 400},
+		{"GET", "/api/v1/events?types=created,hired", "", "", 422},
+		{"GET", "/api/v1/events/ws", "", "", 426},
+		{"POST", "/api/v1/webhooks", jsonType, `{"url":"https://hooks.example.com/employees","eventTypes":["created"],"secret":"0123456789abcdef"}`, 200},
+		{"POST", "/api/v1/webhooks", jsonType, `{"url":"ftp://hooks.example.com/employees","secret":"0123456789abcdef"}`, 422},
+		{"GET", "/api/v1/webhooks", "", "", 200},
+		{"GET", "/api/v1/webhooks/1/deliveries?status=dead", "", "", 200},
+		{"GET", "/api/v1/webhooks/9/deliveries", "", "", 404},
+		{"POST", "/api/v1/webhooks/1/deliveries/9/redeliver", "", "", 404},
+		{"GET", "/api/v1/outbox", "", "", 200},
+		{"DELETE", "/api/v1/webhooks/1", "", "", 200},
+		{"DELETE", "/api/v1/webhooks/1", "", "", 404},
+		{"DELETE", "/api/v1/custom-fields/team", "", "", 200},
+		{"DELETE", "/api/v1/custom-fields/team", "", "", 404},
+		{"DELETE", "/api/DeleteEmployee/1", "", "", 200},
+		{"DELETE", "/api/DeleteEmployee/1", "", "", 404},
+		{"GET", "/openapi.json", "", "", 200},
+		{"GET", "/docs", "", "", 200},
+		{"GET", "/docs/swagger-ui-bundle.js", "", "", 200},
+	}
+	for _, step := range steps {
+		status, body := call(t, app, step.method, step.path, step.contentType, step.body)
+		assert.Equal(t, step.status, status, "%s %s: %v", step.method, step.path, body)
+	}
+}
+
+// TestRequestValidation checks that requests not matching the document are rejected with every mismatch
+func TestRequestValidation(t *testing.T) {
+	app := newApp(t)
+
+	cases := []struct {
+		name, method, path, contentType, body string
+		fields                                map[string]string
+	}{
+		{"PathParam", "GET", "/api/GetEmployeeById/abc", "", "", map[string]string{"id": "invalid_type"}},
+		{"QueryParam", "GET", "/api/v1/employees/duplicates?minScore=high", "", "", map[string]string{"minScore": "invalid_type"}},
+		{"BodyTypes", "POST", "/api/CreateEmpolyee", fiber.MIMEApplicationJSON, `{"Name":7,"Salary":"lots","hireDate":"31.01.2024"}`,
+			map[string]string{"Name": "invalid_type", "Salary": "invalid_type", "hireDate": "invalid_format"}},
+		{"Amounts", "POST", "/api/CreateEmpolyee", fiber.MIMEApplicationJSON, `{"Name":"John Doe","position":"Engineer","Salary":1234.567}`,
+			map[string]string{"Salary": "invalid_value"}},
+		{"SalaryOverflow", "POST", "/api/CreateEmpolyee", fiber.MIMEApplicationJSON, `{"Name":"John Doe","position":"Engineer","Salary":100000000}`,
+			map[string]string{"Salary": "out_of_range"}},
+		{"MalformedBody", "PUT", "/api/UpdateEmployee", fiber.MIMEApplicationJSON, `{"ID":`, map[string]string{"": "invalid_format"}},
+		{"MissingBody", "POST", "/api/v1/employees/merge", fiber.MIMEApplicationJSON, ``, map[string]string{"": "required"}},
+		{"PatchDocument", "PATCH", "/api/v1/employees/1", "application/json-patch+json", `[{"op":"increment","path":"/Salary"}]`,
+			map[string]string{"0.op": "invalid_value"}},
+	}
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			status, body := call(t, app, c.method, c.path, c.contentType, c.body)
+			require.Equal(t, fiber.StatusBadRequest, status, "%v", body)
+
+			fields := map[string]string{}
+			errs, _ := body["errors"].([]interface{})
+			for _, e := range errs {
+				fieldErr := e.(map[string]interface{})
+				fields[fieldErr["field"].(string)] = fieldErr["code"].(string)
+			}
+			assert.Equal(t, c.fields, fields)
+		})
+	}
+}
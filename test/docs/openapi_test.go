@@ -0,0 +1,86 @@
+package docs_test
+
+import (
+	"Techiebulter/interview/backend/config"
+	"Techiebulter/interview/backend/docs"
+	"Techiebulter/interview/backend/server"
+	"encoding/json"
+	"net/http/httptest"
+	"regexp"
+	"strings"
+	"testing"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// pathParam matches fiber path parameters like :id
+var pathParam = regexp.MustCompile(`:(\w+)`)
+
+type spec struct {
+	OpenAPI string                                `json:"openapi"`
+	Paths   map[string]map[string]json.RawMessage `json:"paths"`
+}
+
+func loadSpec(t *testing.T) spec {
+	t.Helper()
+	var s spec
+	require.NoError(t, json.Unmarshal(docs.OpenAPI, &s))
+	return s
+}
+
+// TestSpecCoversRoutes fails when a route registered by InjectRoutes is not described by the spec
+func TestSpecCoversRoutes(t *testing.T) {
+	s := loadSpec(t)
+	assert.Equal(t, "3.1.0", s.OpenAPI)
+
+	app := (&server.Server{Config: config.Default()}).InjectRoutes()
+	described := map[string]bool{}
+	for _, route := range app.GetRoutes(true) {
+		// fiber answers HEAD for every GET route itself
+		if route.Method == fiber.MethodHead {
+			continue
+		}
+		path := pathParam.ReplaceAllString(route.Path, "{$1}")
+		method := strings.ToLower(route.Method)
+		described[method+" "+path] = true
+		_, ok := s.Paths[path][method]
+		assert.True(t, ok, "%s %s is missing from docs/openapi.json", route.Method, path)
+	}
+
+	// and the other way round, the spec must not describe routes that do not exist
+	for path, operations := range s.Paths {
+		for method := range operations {
+			if method == "parameters" || method == "summary" || method == "description" {
+				continue
+			}
+			assert.True(t, described[method+" "+path], "docs/openapi.json describes %s %s, which is not registered",
+				strings.ToUpper(method), path)
+		}
+	}
+}
+
+func TestServeDocs(t *testing.T) {
+	app := (&server.Server{Config: config.Default()}).InjectRoutes()
+
+	resp, err := app.Test(httptest.NewRequest("GET", "/openapi.json", nil))
+	require.NoError(t, err)
+	assert.Equal(t, fiber.StatusOK, resp.StatusCode)
+	assert.Contains(t, resp.Header.Get(fiber.HeaderContentType), fiber.MIMEApplicationJSON)
+
+	resp, err = app.Test(httptest.NewRequest("GET", "/docs", nil))
+	require.NoError(t, err)
+	assert.Equal(t, fiber.StatusOK, resp.StatusCode)
+	assert.Contains(t, resp.Header.Get(fiber.HeaderContentType), fiber.MIMETextHTML)
+
+	for asset, contentType := range map[string]string{"swagger-ui.css": "text/css", "swagger-ui-bundle.js": "javascript"} {
+		resp, err = app.Test(httptest.NewRequest("GET", "/docs/"+asset, nil))
+		require.NoError(t, err)
+		assert.Equal(t, fiber.StatusOK, resp.StatusCode, asset)
+		assert.Contains(t, resp.Header.Get(fiber.HeaderContentType), contentType)
+	}
+	resp, err = app.Test(httptest.NewRequest("GET", "/docs/index.html", nil))
+	require.NoError(t, err)
+	assert.Equal(t, fiber.StatusNotFound, resp.StatusCode)
+}
@@ -0,0 +1,157 @@
+package events_test
+
+import (
+	"Techiebulter/interview/backend/config"
+	"Techiebulter/interview/backend/models"
+	"Techiebulter/interview/backend/test/internal/apitest"
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"net"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/fasthttp/websocket"
+	"github.com/gofiber/fiber/v2"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// serve runs the API on a free port until the test ends and returns its address
+func serve(t *testing.T) string {
+	t.Helper()
+	srv := apitest.NewServer(t, func(cfg *config.Config) {
+		cfg.GRPC.Enabled = false
+		cfg.Events.Heartbeat = 50 * time.Millisecond
+	})
+
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	go func() { _ = srv.Handler.Listener(listener) }()
+	t.Cleanup(func() { _ = srv.Stop() })
+	return listener.Addr().String()
+}
+
+func send(t *testing.T, addr, method, path, body string) {
+	t.Helper()
+	req, err := http.NewRequest(method, "http://"+addr+path, strings.NewReader(body))
+	require.NoError(t, err)
+	req.Header.Set(fiber.HeaderContentType, fiber.MIMEApplicationJSON)
+	resp, err := http.DefaultClient.Do(req)
+	require.NoError(t, err)
+	resp.Body.Close()
+	require.Equal(t, fiber.StatusOK, resp.StatusCode, "%s %s", method, path)
+}
+
+// sseEvent is one server-sent event, comments are skipped
+type sseEvent struct {
+	id, name string
+	data     models.EmployeeEvent
+}
+
+type sseStream struct {
+	resp   *http.Response
+	reader *bufio.Reader
+}
+
+func subscribe(t *testing.T, addr, query, lastEventID string) *sseStream {
+	t.Helper()
+	req, err := http.NewRequest(fiber.MethodGet, "http://"+addr+"/api/v1/events"+query, nil)
+	require.NoError(t, err)
+	if lastEventID != "" {
+		req.Header.Set("Last-Event-ID", lastEventID)
+	}
+	resp, err := http.DefaultClient.Do(req)
+	require.NoError(t, err)
+	t.Cleanup(func() { resp.Body.Close() })
+	require.Equal(t, fiber.StatusOK, resp.StatusCode)
+	require.Equal(t, "text/event-stream", resp.Header.Get(fiber.HeaderContentType))
+	return &sseStream{resp: resp, reader: bufio.NewReader(resp.Body)}
+}
+
+func (s *sseStream) next(t *testing.T) sseEvent {
+	t.Helper()
+	var event sseEvent
+	for {
+		line, err := s.reader.ReadString('\n')
+		require.NoError(t, err)
+		line = strings.TrimSuffix(line, "\n")
+		switch {
+		case line == "" && event.name != "":
+			return event
+		case strings.HasPrefix(line, "id: "):
+			event.id = strings.TrimPrefix(line, "id: ")
+		case strings.HasPrefix(line, "event: "):
+			event.name = strings.TrimPrefix(line, "event: ")
+		case strings.HasPrefix(line, "data: "):
+			require.NoError(t, json.Unmarshal([]byte(strings.TrimPrefix(line, "data: ")), &event.data))
+		}
+	}
+}
+
+func TestServerSentEvents(t *testing.T) {
+	addr := serve(t)
+
+	stream := subscribe(t, addr, "", "")
+	send(t, addr, fiber.MethodPost, "/api/CreateEmpolyee", `{"Name":"John Doe","position":"Engineer","Salary":5000}`)
+	created := stream.next(t)
+	assert.Equal(t, "created", created.name)
+	assert.Equal(t, fmt.Sprint(created.data.ID), created.id)
+	require.NotNil(t, created.data.Employee)
+	assert.Equal(t, "John Doe", created.data.Employee.Name)
+	stream.resp.Body.Close()
+
+	// changes made while the client was away are replayed from the event log
+	send(t, addr, fiber.MethodPut, "/api/UpdateEmployee", fmt.Sprintf(`{"ID":%d,"Salary":5500}`, created.data.EmployeeID))
+	send(t, addr, fiber.MethodDelete, fmt.Sprintf("/api/DeleteEmployee/%d", created.data.EmployeeID), "")
+
+	stream = subscribe(t, addr, "", created.id)
+	updated := stream.next(t)
+	assert.Equal(t, "updated", updated.name)
+	assert.Equal(t, models.MustParseMoney("5500"), updated.data.Employee.Salary)
+	deleted := stream.next(t)
+	assert.Equal(t, "deleted", deleted.name)
+	assert.Nil(t, deleted.data.Employee)
+
+	// the filter applies to replayed and live events alike
+	stream = subscribe(t, addr, "?types=created,deleted", "0")
+	assert.Equal(t, created.id, stream.next(t).id)
+	assert.Equal(t, deleted.id, stream.next(t).id)
+	send(t, addr, fiber.MethodPost, "/api/CreateEmpolyee", `{"Name":"Jane Roe","position":"QA","Salary":4000}`)
+	assert.Equal(t, "Jane Roe", stream.next(t).data.Employee.Name)
+}
+
+func TestInvalidStreamParameters(t *testing.T) {
+	addr := serve(t)
+
+	resp, err := http.Get("http://" + addr + "/api/v1/events?types=created,hired")
+	require.NoError(t, err)
+	resp.Body.Close()
+	assert.Equal(t, fiber.StatusUnprocessableEntity, resp.StatusCode)
+
+	resp, err = http.Get("http://" + addr + "/api/v1/events?lastEventId=-1")
+	require.NoError(t, err)
+	resp.Body.Close()
+	assert.Equal(t, fiber.StatusBadRequest, resp.StatusCode, "the request does not match the API documentation")
+}
+
+func TestWebSocket(t *testing.T) {
+	addr := serve(t)
+	send(t, addr, fiber.MethodPost, "/api/CreateEmpolyee", `{"Name":"John Doe","position":"Engineer","Salary":5000}`)
+
+	conn, _, err := websocket.DefaultDialer.Dial("ws://"+addr+"/api/v1/events/ws?types=created&lastEventId=0", nil)
+	require.NoError(t, err)
+	defer conn.Close()
+
+	var event models.EmployeeEvent
+	require.NoError(t, conn.ReadJSON(&event))
+	assert.Equal(t, models.EmployeeCreated, event.Type)
+	assert.Equal(t, "John Doe", event.Employee.Name)
+
+	send(t, addr, fiber.MethodPut, "/api/UpdateEmployee", `{"ID":1,"Salary":5500}`)
+	send(t, addr, fiber.MethodPost, "/api/CreateEmpolyee", `{"Name":"Jane Roe","position":"QA","Salary":4000}`)
+	require.NoError(t, conn.ReadJSON(&event))
+	assert.Equal(t, "Jane Roe", event.Employee.Name, "updates are filtered out")
+}
@@ -0,0 +1,221 @@
+package graphql_test
+
+import (
+	"Techiebulter/interview/backend/config"
+	"Techiebulter/interview/backend/models"
+	"Techiebulter/interview/backend/providers"
+	"Techiebulter/interview/backend/server"
+	"Techiebulter/interview/backend/test/internal/apitest"
+	"encoding/json"
+	"io"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// countingHelper counts the employee reads that reach the database
+type countingHelper struct {
+	providers.DbHelperProvider
+	byID, byIDs int
+}
+
+func (h *countingHelper) GetEmployeeById(id int) (models.Employee, error) {
+	h.byID++
+	return h.DbHelperProvider.GetEmployeeById(id)
+}
+
+func (h *countingHelper) GetEmployeesByIds(ids []int) ([]models.Employee, error) {
+	h.byIDs++
+	return h.DbHelperProvider.GetEmployeesByIds(ids)
+}
+
+func newApp(t *testing.T, configure func(cfg *config.Config)) (*fiber.App, *countingHelper) {
+	t.Helper()
+	cfg := apitest.Config(t)
+	if configure != nil {
+		configure(cfg)
+	}
+	client, dbHelper := apitest.Open(t, cfg)
+
+	counting := &countingHelper{DbHelperProvider: dbHelper}
+	return server.New(cfg, client, counting).Handler, counting
+}
+
+type response struct {
+	Data   map[string]interface{} `json:"data"`
+	Errors []struct {
+		Message    string                 `json:"message"`
+		Extensions map[string]interface{} `json:"extensions"`
+	} `json:"errors"`
+}
+
+func query(t *testing.T, app *fiber.App, apiKey, document string, variables map[string]interface{}) (int, response) {
+	t.Helper()
+	body, err := json.Marshal(map[string]interface{}{"query": document, "variables": variables})
+	require.NoError(t, err)
+	req := httptest.NewRequest(fiber.MethodPost, "/graphql", strings.NewReader(string(body)))
+	req.Header.Set(fiber.HeaderContentType, fiber.MIMEApplicationJSON)
+	if apiKey != "" {
+		req.Header.Set("X-API-Key", apiKey)
+	}
+	resp, err := app.Test(req)
+	require.NoError(t, err)
+	data, err := io.ReadAll(resp.Body)
+	require.NoError(t, err)
+
+	var decoded response
+	require.NoError(t, json.Unmarshal(data, &decoded), string(data))
+	return resp.StatusCode, decoded
+}
+
+const createEmployee = `mutation($input: EmployeeInput!) { createEmployee(input: $input) { id name status hireDate } }`
+
+func create(t *testing.T, app *fiber.App, input map[string]interface{}) int {
+	t.Helper()
+	status, resp := query(t, app, "", createEmployee, map[string]interface{}{"input": input})
+	require.Equal(t, fiber.StatusOK, status)
+	require.Empty(t, resp.Errors)
+	return int(resp.Data["createEmployee"].(map[string]interface{})["id"].(float64))
+}
+
+func TestQueriesAndMutations(t *testing.T) {
+	app, counting := newApp(t, nil)
+
+	id := create(t, app, map[string]interface{}{"name": "John Doe", "position": "Engineer", "salary": 5000, "hireDate": "2021-06-01"})
+	create(t, app, map[string]interface{}{"name": "Jane Smith", "position": "Manager", "salary": 7000, "status": "ON_LEAVE"})
+	create(t, app, map[string]interface{}{"name": "Johnny Walker", "position": "Engineer", "salary": 6000})
+
+	t.Run("Filter and paginate", func(t *testing.T) {
+		const page = `query($after: String) {
+			employees(filter: {nameContains: "JOHN", position: "Engineer"}, first: 1, after: $after) {
+				edges { node { name } }
+				pageInfo { hasNextPage endCursor }
+			}
+		}`
+		_, resp := query(t, app, "", page, nil)
+		require.Empty(t, resp.Errors)
+		connection := resp.Data["employees"].(map[string]interface{})
+		pageInfo := connection["pageInfo"].(map[string]interface{})
+		assert.Equal(t, "John Doe", connection["edges"].([]interface{})[0].(map[string]interface{})["node"].(map[string]interface{})["name"])
+		assert.Equal(t, true, pageInfo["hasNextPage"])
+
+		_, resp = query(t, app, "", page, map[string]interface{}{"after": pageInfo["endCursor"]})
+		connection = resp.Data["employees"].(map[string]interface{})
+		assert.Equal(t, "Johnny Walker", connection["edges"].([]interface{})[0].(map[string]interface{})["node"].(map[string]interface{})["name"])
+		assert.Equal(t, false, connection["pageInfo"].(map[string]interface{})["hasNextPage"])
+
+		_, resp = query(t, app, "", `{ employees(filter: {status: ON_LEAVE}) { edges { node { name status } } } }`, nil)
+		assert.Len(t, resp.Data["employees"].(map[string]interface{})["edges"], 1)
+	})
+
+	t.Run("Batching", func(t *testing.T) {
+		counting.byID, counting.byIDs = 0, 0
+		_, resp := query(t, app, "", `{
+			a: employee(id: 1) { name }
+			b: employee(id: 2) { name }
+			c: employee(id: 3) { name }
+			missing: employee(id: 99) { name }
+		}`, nil)
+		require.Empty(t, resp.Errors)
+		assert.Equal(t, map[string]interface{}{"name": "John Doe"}, resp.Data["a"])
+		assert.Equal(t, map[string]interface{}{"name": "Johnny Walker"}, resp.Data["c"])
+		assert.Nil(t, resp.Data["missing"])
+		assert.Equal(t, 1, counting.byIDs, "the employees are read in one batch")
+		assert.Equal(t, 1, counting.byID, "only the missing ID is looked up alone")
+	})
+
+	t.Run("Update and delete", func(t *testing.T) {
+		_, resp := query(t, app, "", `mutation { updateEmployee(id: `+jsonInt(id)+`, input: {salary: 5500}) { salary position } }`, nil)
+		require.Empty(t, resp.Errors)
+		assert.Equal(t, map[string]interface{}{"salary": 5500.0, "position": "Engineer"}, resp.Data["updateEmployee"])
+
+		_, resp = query(t, app, "", `mutation { deleteEmployee(id: `+jsonInt(id)+`) }`, nil)
+		assert.Equal(t, true, resp.Data["deleteEmployee"])
+	})
+
+	t.Run("Errors", func(t *testing.T) {
+		_, resp := query(t, app, "", createEmployee, map[string]interface{}{"input": map[string]interface{}{"position": "Engineer", "salary": 5000}})
+		require.Len(t, resp.Errors, 1)
+		assert.Equal(t, "BAD_USER_INPUT", resp.Errors[0].Extensions["code"])
+
+		_, resp = query(t, app, "", `mutation { updateEmployee(id: 99, input: {salary: 1234.567}) { id } }`, nil)
+		require.Len(t, resp.Errors, 1)
+		assert.Equal(t, "BAD_USER_INPUT", resp.Errors[0].Extensions["code"], "fractions of a cent are rejected")
+
+		_, resp = query(t, app, "", `mutation { updateEmployee(id: 99, input: {salary: 1}) { id } }`, nil)
+		require.Len(t, resp.Errors, 1)
+		assert.Equal(t, "NOT_FOUND", resp.Errors[0].Extensions["code"])
+
+		status, resp := query(t, app, "", `{ employee(id: 1) { unknownField } }`, nil)
+		assert.Equal(t, fiber.StatusBadRequest, status)
+		assert.NotEmpty(t, resp.Errors)
+	})
+}
+
+func jsonInt(n int) string {
+	data, _ := json.Marshal(n)
+	return string(data)
+}
+
+func TestLimits(t *testing.T) {
+	app, _ := newApp(t, func(cfg *config.Config) {
+		cfg.GraphQL.MaxDepth = 4
+		cfg.GraphQL.MaxComplexity = 50
+	})
+
+	status, resp := query(t, app, "", `{ employees(first: 5) { edges { node { id name } } } }`, nil)
+	assert.Equal(t, fiber.StatusOK, status, "depth 4 and complexity 1 + 5 * 4 fit the limits")
+	assert.Empty(t, resp.Errors)
+
+	status, resp = query(t, app, "", `{ employees { pageInfo { hasNextPage } edges { node { id } cursor } } }`, nil)
+	assert.Equal(t, fiber.StatusBadRequest, status, "the default page of 20 makes it too complex")
+	require.Len(t, resp.Errors, 1)
+	assert.Equal(t, "QUERY_TOO_COMPLEX", resp.Errors[0].Extensions["code"])
+
+	status, resp = query(t, app, "", `query($n: Int) { employees(first: $n) { ...edges } } fragment edges on EmployeeConnection { edges { node { id } } }`,
+		map[string]interface{}{"n": 100})
+	assert.Equal(t, fiber.StatusBadRequest, status, "variables and fragments are counted")
+	assert.Equal(t, "QUERY_TOO_COMPLEX", resp.Errors[0].Extensions["code"])
+
+	status, resp = query(t, app, "", `{ __schema { types { fields { type { ofType { name } } } } } }`, nil)
+	assert.Equal(t, fiber.StatusOK, status, "introspection is not limited")
+	assert.Empty(t, resp.Errors)
+
+	app, _ = newApp(t, func(cfg *config.Config) { cfg.GraphQL.MaxDepth = 2 })
+	status, resp = query(t, app, "", `{ employees(first: 1) { edges { node { id } } } }`, nil)
+	assert.Equal(t, fiber.StatusBadRequest, status)
+	require.Len(t, resp.Errors, 1)
+	assert.Equal(t, "QUERY_TOO_DEEP", resp.Errors[0].Extensions["code"])
+}
+
+func TestAuth(t *testing.T) {
+	app, _ := newApp(t, func(cfg *config.Config) {
+		cfg.Auth.Enabled = true
+		cfg.Auth.APIKeys = []config.APIKey{{Key: "hr-key", Role: models.RoleHR}, {Key: "employee-key", Role: models.RoleEmployee, EmployeeID: 1}}
+	})
+	input := map[string]interface{}{"input": map[string]interface{}{"name": "John Doe", "position": "Engineer", "salary": 5000}}
+
+	status, _ := query(t, app, "", `{ employee(id: 1) { id } }`, nil)
+	assert.Equal(t, fiber.StatusUnauthorized, status)
+
+	_, resp := query(t, app, "employee-key", createEmployee, input)
+	require.Len(t, resp.Errors, 1)
+	assert.Equal(t, "FORBIDDEN", resp.Errors[0].Extensions["code"])
+
+	_, resp = query(t, app, "hr-key", createEmployee, input)
+	assert.Empty(t, resp.Errors)
+	_, resp = query(t, app, "employee-key", `{ employee(id: 1) { name } }`, nil)
+	assert.Equal(t, map[string]interface{}{"name": "John Doe"}, resp.Data["employee"])
+
+	_, resp = query(t, app, "hr-key", createEmployee, input)
+	assert.Empty(t, resp.Errors)
+	for _, read := range []string{`{ employee(id: 2) { name salary } }`, `{ employees { edges { node { salary } } } }`} {
+		_, resp = query(t, app, "employee-key", read, nil)
+		require.Len(t, resp.Errors, 1, read)
+		assert.Equal(t, "FORBIDDEN", resp.Errors[0].Extensions["code"], read)
+	}
+}
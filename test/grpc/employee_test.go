@@ -0,0 +1,182 @@
+package grpc_test
+
+import (
+	"Techiebulter/interview/backend/config"
+	"Techiebulter/interview/backend/models"
+	"Techiebulter/interview/backend/proto/employeepb"
+	"Techiebulter/interview/backend/test/internal/apitest"
+	"context"
+	"net"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials/insecure"
+	healthpb "google.golang.org/grpc/health/grpc_health_v1"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+	"google.golang.org/grpc/test/bufconn"
+)
+
+// dial serves the gRPC API of a server on an SQLite database in memory and connects to it
+func dial(t *testing.T, configure ...func(cfg *config.Config)) *grpc.ClientConn {
+	t.Helper()
+	srv := apitest.NewServer(t, configure...)
+
+	listener := bufconn.Listen(1 << 20)
+	go func() { _ = srv.GRPCServer.Serve(listener) }()
+	t.Cleanup(srv.GRPCServer.Stop)
+
+	conn, err := grpc.Dial("bufnet",
+		grpc.WithContextDialer(func(ctx context.Context, _ string) (net.Conn, error) { return listener.DialContext(ctx) }),
+		grpc.WithTransportCredentials(insecure.NewCredentials()))
+	require.NoError(t, err)
+	t.Cleanup(func() { _ = conn.Close() })
+	return conn
+}
+
+func TestEmployeeService(t *testing.T) {
+	client := employeepb.NewEmployeeServiceClient(dial(t))
+	ctx := context.Background()
+
+	created, err := client.CreateEmployee(ctx, &employeepb.CreateEmployeeRequest{Employee: &employeepb.Employee{
+		Name: "John Doe", Position: "Engineer", Salary: 5000, Email: "John@Example.com", HireDate: "2021-06-01",
+	}})
+	require.NoError(t, err)
+	assert.NotZero(t, created.Id)
+	assert.Equal(t, "john@example.com", created.Email)
+	assert.Equal(t, "2021-06-01", created.HireDate)
+	assert.Equal(t, string(models.StatusActive), created.Status, "defaults are applied like over REST")
+
+	got, err := client.GetEmployee(ctx, &employeepb.GetEmployeeRequest{Id: created.Id})
+	require.NoError(t, err)
+	assert.Equal(t, "John Doe", got.Name)
+
+	updated, err := client.UpdateEmployee(ctx, &employeepb.UpdateEmployeeRequest{Employee: &employeepb.Employee{Id: created.Id, Salary: 6000}})
+	require.NoError(t, err)
+	assert.Equal(t, 6000.0, updated.Salary)
+	assert.Equal(t, "Engineer", updated.Position, "fields that are not set are kept")
+
+	list, err := client.ListEmployees(ctx, &employeepb.ListEmployeesRequest{Page: 1, Limit: 10})
+	require.NoError(t, err)
+	assert.Len(t, list.Employees, 1)
+
+	_, err = client.DeleteEmployee(ctx, &employeepb.DeleteEmployeeRequest{Id: created.Id})
+	require.NoError(t, err)
+	_, err = client.GetEmployee(ctx, &employeepb.GetEmployeeRequest{Id: created.Id})
+	assert.Equal(t, codes.NotFound, status.Code(err))
+}
+
+func TestEmployeeServiceErrors(t *testing.T) {
+	client := employeepb.NewEmployeeServiceClient(dial(t))
+	ctx := context.Background()
+
+	_, err := client.CreateEmployee(ctx, &employeepb.CreateEmployeeRequest{Employee: &employeepb.Employee{
+		Position: "Engineer", Salary: 5000, HireDate: "01/06/2021",
+	}})
+	st := status.Convert(err)
+	require.Equal(t, codes.InvalidArgument, st.Code())
+	var fields []string
+	for _, detail := range st.Details() {
+		if badRequest, ok := detail.(*errdetails.BadRequest); ok {
+			for _, violation := range badRequest.FieldViolations {
+				fields = append(fields, violation.Field)
+			}
+		}
+	}
+	assert.Equal(t, []string{"employee.hire_date"}, fields, "dates are checked before the other fields")
+
+	_, err = client.CreateEmployee(ctx, &employeepb.CreateEmployeeRequest{Employee: &employeepb.Employee{Position: "Engineer", Salary: 5000}})
+	assert.Equal(t, codes.InvalidArgument, status.Code(err))
+
+	_, err = client.CreateEmployee(ctx, &employeepb.CreateEmployeeRequest{Employee: &employeepb.Employee{Name: "John", Position: "Engineer", Salary: 1234.567}})
+	assert.Equal(t, codes.InvalidArgument, status.Code(err), "fractions of a cent are rejected")
+
+	employee := &employeepb.Employee{Name: "Jane Doe", Position: "Engineer", Salary: 5000, Email: "jane@example.com"}
+	_, err = client.CreateEmployee(ctx, &employeepb.CreateEmployeeRequest{Employee: employee})
+	require.NoError(t, err)
+	_, err = client.CreateEmployee(ctx, &employeepb.CreateEmployeeRequest{Employee: employee})
+	assert.Equal(t, codes.AlreadyExists, status.Code(err))
+
+	_, err = client.UpdateEmployee(ctx, &employeepb.UpdateEmployeeRequest{Employee: &employeepb.Employee{Id: 999, Salary: 6000}})
+	assert.Equal(t, codes.NotFound, status.Code(err))
+
+	_, err = client.ListEmployees(ctx, &employeepb.ListEmployeesRequest{Page: 0, Limit: 10})
+	assert.Equal(t, codes.InvalidArgument, status.Code(err))
+}
+
+func TestEmployeeServiceAuth(t *testing.T) {
+	conn := dial(t, func(cfg *config.Config) {
+		cfg.Auth.Enabled = true
+		cfg.Auth.APIKeys = []config.APIKey{
+			{Key: "hr-key", Role: models.RoleHR},
+			{Key: "employee-key", Role: models.RoleEmployee, EmployeeID: 1},
+		}
+	})
+	client := employeepb.NewEmployeeServiceClient(conn)
+	create := &employeepb.CreateEmployeeRequest{Employee: &employeepb.Employee{Name: "John Doe", Position: "Engineer", Salary: 5000}}
+
+	_, err := client.ListEmployees(context.Background(), &employeepb.ListEmployeesRequest{Page: 1, Limit: 10})
+	assert.Equal(t, codes.Unauthenticated, status.Code(err))
+
+	employeeCtx := metadata.AppendToOutgoingContext(context.Background(), "x-api-key", "employee-key")
+	_, err = client.ListEmployees(employeeCtx, &employeepb.ListEmployeesRequest{Page: 1, Limit: 10})
+	assert.Equal(t, codes.PermissionDenied, status.Code(err), "salaries of others are for HR")
+	_, err = client.CreateEmployee(employeeCtx, create)
+	assert.Equal(t, codes.PermissionDenied, status.Code(err))
+
+	hrCtx := metadata.AppendToOutgoingContext(context.Background(), "authorization", "Bearer hr-key")
+	_, err = client.CreateEmployee(hrCtx, create)
+	assert.NoError(t, err)
+	_, err = client.CreateEmployee(hrCtx, create)
+	assert.NoError(t, err)
+
+	_, err = client.GetEmployee(employeeCtx, &employeepb.GetEmployeeRequest{Id: 1})
+	assert.NoError(t, err, "employees read themselves")
+	_, err = client.GetEmployee(employeeCtx, &employeepb.GetEmployeeRequest{Id: 2})
+	assert.Equal(t, codes.PermissionDenied, status.Code(err))
+	watch, err := client.WatchEmployees(employeeCtx, &employeepb.WatchEmployeesRequest{})
+	require.NoError(t, err)
+	_, err = watch.Recv()
+	assert.Equal(t, codes.PermissionDenied, status.Code(err))
+
+	health, err := healthpb.NewHealthClient(conn).Check(context.Background(), &healthpb.HealthCheckRequest{Service: "employee.v1.EmployeeService"})
+	require.NoError(t, err, "health checks need no API key")
+	assert.Equal(t, healthpb.HealthCheckResponse_SERVING, health.Status)
+}
+
+func TestWatchEmployees(t *testing.T) {
+	client := employeepb.NewEmployeeServiceClient(dial(t))
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	first, err := client.CreateEmployee(ctx, &employeepb.CreateEmployeeRequest{Employee: &employeepb.Employee{Name: "John Doe", Position: "Engineer", Salary: 5000}})
+	require.NoError(t, err)
+
+	stream, err := client.WatchEmployees(ctx, &employeepb.WatchEmployeesRequest{EmployeeId: first.Id})
+	require.NoError(t, err)
+	// the stream is open once its headers arrive, changes made before that are not reported
+	_, err = stream.Header()
+	require.NoError(t, err)
+
+	_, err = client.CreateEmployee(ctx, &employeepb.CreateEmployeeRequest{Employee: &employeepb.Employee{Name: "Jane Doe", Position: "Engineer", Salary: 5000}})
+	require.NoError(t, err)
+	_, err = client.UpdateEmployee(ctx, &employeepb.UpdateEmployeeRequest{Employee: &employeepb.Employee{Id: first.Id, Salary: 6000}})
+	require.NoError(t, err)
+	_, err = client.DeleteEmployee(ctx, &employeepb.DeleteEmployeeRequest{Id: first.Id})
+	require.NoError(t, err)
+
+	event, err := stream.Recv()
+	require.NoError(t, err)
+	assert.Equal(t, employeepb.EmployeeEvent_UPDATED, event.Type, "changes to other employees are filtered out")
+	assert.Equal(t, 6000.0, event.Employee.Salary)
+
+	event, err = stream.Recv()
+	require.NoError(t, err)
+	assert.Equal(t, employeepb.EmployeeEvent_DELETED, event.Type)
+	assert.Nil(t, event.Employee)
+}
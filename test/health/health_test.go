@@ -0,0 +1,38 @@
+package health_test
+
+import (
+	"Techiebulter/interview/backend/providers/dbProvider"
+	"Techiebulter/interview/backend/server"
+	"Techiebulter/interview/backend/test/internal/apitest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestReadiness(t *testing.T) {
+	app := apitest.NewServer(t).Handler
+	ready := apitest.Call(t, app, "", "GET", "/api/readiness", "", 200)
+	assert.Equal(t, "up", ready["database"])
+
+	// a PostgreSQL provider that never reached its database starts degraded and is not ready
+	cfg := apitest.Config(t)
+	cfg.Database.URL = "postgres://postgres@127.0.0.1:1/employees?sslmode=disable"
+	cfg.Database.ConnectAttempts = 2
+	cfg.Database.BackoffInitial = time.Millisecond
+	cfg.Database.ReplicaURLs = []string{cfg.Database.URL}
+	client, err := dbProvider.ConnectDB(cfg.Database)
+	require.Error(t, err)
+	t.Cleanup(func() { _ = client.Close() })
+	assert.False(t, client.Healthy())
+
+	_, dbHelper := apitest.Open(t, cfg)
+	app = server.New(cfg, client, dbHelper).Handler
+	ready = apitest.Call(t, app, "", "GET", "/api/readiness", "", 503)
+	assert.Equal(t, "fail", ready["status"])
+	assert.Equal(t, "down", ready["database"])
+	replicas := ready["replicas"].([]interface{})
+	require.Len(t, replicas, 1)
+	assert.Equal(t, false, replicas[0].(map[string]interface{})["healthy"])
+}
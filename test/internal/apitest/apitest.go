@@ -0,0 +1,93 @@
+// Package apitest serves the API from a fresh SQLite database for the endpoint suites under test/
+package apitest
+
+import (
+	"Techiebulter/interview/backend/config"
+	"Techiebulter/interview/backend/models"
+	"Techiebulter/interview/backend/providers"
+	"Techiebulter/interview/backend/providers/dbProvider"
+	"Techiebulter/interview/backend/providers/sqliteHelperProvider"
+	"Techiebulter/interview/backend/server"
+	"encoding/json"
+	"io"
+	"net/http/httptest"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/stretchr/testify/require"
+)
+
+// APIKeys are the keys NewApp accepts: hr-key for HR, hr-self-key for HR bound to employee 1, and
+// employee-key and colleague-key for employees 1 and 2
+var APIKeys = []config.APIKey{
+	{Key: "hr-key", Role: models.RoleHR},
+	{Key: "hr-self-key", Role: models.RoleHR, EmployeeID: 1},
+	{Key: "employee-key", Role: models.RoleEmployee, EmployeeID: 1},
+	{Key: "colleague-key", Role: models.RoleEmployee, EmployeeID: 2},
+}
+
+// Config returns the default configuration on an SQLite database in the test's temporary directory, with
+// request logging off and every response checked against the OpenAPI document
+func Config(t *testing.T) *config.Config {
+	t.Helper()
+	cfg := config.Default()
+	cfg.Database.Driver = config.DriverSQLite
+	cfg.Database.SQLitePath = filepath.Join(t.TempDir(), "employees.db")
+	cfg.Features.RequestLogging = false
+	cfg.Features.ValidateResponses = true
+	return cfg
+}
+
+// Open connects to the database of cfg and migrates it, the connection is closed when the test ends
+func Open(t *testing.T, cfg *config.Config) (providers.PgClientProvider, providers.DbHelperProvider) {
+	t.Helper()
+	client, err := dbProvider.ConnectSQLite(cfg.Database)
+	require.NoError(t, err)
+	t.Cleanup(func() { _ = client.Close() })
+	dbHelper, err := sqliteHelperProvider.NewSQLiteHelper(client, cfg.Currency.Base)
+	require.NoError(t, err)
+	return client, dbHelper
+}
+
+// NewServer creates a server on Config, changed by configure
+func NewServer(t *testing.T, configure ...func(cfg *config.Config)) *server.Server {
+	t.Helper()
+	cfg := Config(t)
+	for _, apply := range configure {
+		apply(cfg)
+	}
+	client, dbHelper := Open(t, cfg)
+	return server.New(cfg, client, dbHelper)
+}
+
+// NewApp returns the handler of a server requiring one of APIKeys, configure is applied after the keys are set
+func NewApp(t *testing.T, configure ...func(cfg *config.Config)) *fiber.App {
+	t.Helper()
+	withKeys := func(cfg *config.Config) {
+		cfg.Auth.Enabled = true
+		cfg.Auth.APIKeys = APIKeys
+	}
+	return NewServer(t, append([]func(cfg *config.Config){withKeys}, configure...)...).Handler
+}
+
+// Call sends a JSON request with the API key, none if key is empty, requires the response to have status
+// and returns its decoded body
+func Call(t *testing.T, app *fiber.App, key, method, path, body string, status int) map[string]interface{} {
+	t.Helper()
+	req := httptest.NewRequest(method, path, strings.NewReader(body))
+	req.Header.Set(fiber.HeaderContentType, fiber.MIMEApplicationJSON)
+	if key != "" {
+		req.Header.Set("X-API-Key", key)
+	}
+	resp, err := app.Test(req)
+	require.NoError(t, err)
+	data, err := io.ReadAll(resp.Body)
+	require.NoError(t, err)
+	require.Equal(t, status, resp.StatusCode, "%s %s: %s", method, path, data)
+
+	var decoded map[string]interface{}
+	require.NoError(t, json.Unmarshal(data, &decoded))
+	return decoded
+}
@@ -0,0 +1,74 @@
+package jsonpatch_test
+
+import (
+	"Techiebulter/interview/backend/utils/jsonpatch"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// cases are taken from the examples in appendix A of RFC 6902
+func TestApply(t *testing.T) {
+	cases := []struct {
+		name, doc, patch, expected string
+	}{
+		{"AddMember", `{"foo":"bar"}`, `[{"op":"add","path":"/baz","value":"qux"}]`, `{"baz":"qux","foo":"bar"}`},
+		{"AddArrayElement", `{"foo":["bar","baz"]}`, `[{"op":"add","path":"/foo/1","value":"qux"}]`, `{"foo":["bar","qux","baz"]}`},
+		{"AddToArrayEnd", `{"foo":["bar"]}`, `[{"op":"add","path":"/foo/-","value":["abc"]}]`, `{"foo":["bar",["abc"]]}`},
+		{"AddNull", `{"foo":"bar"}`, `[{"op":"add","path":"/baz","value":null}]`, `{"baz":null,"foo":"bar"}`},
+		{"RemoveMember", `{"baz":"qux","foo":"bar"}`, `[{"op":"remove","path":"/baz"}]`, `{"foo":"bar"}`},
+		{"RemoveArrayElement", `{"foo":["bar","qux","baz"]}`, `[{"op":"remove","path":"/foo/1"}]`, `{"foo":["bar","baz"]}`},
+		{"Replace", `{"baz":"qux","foo":"bar"}`, `[{"op":"replace","path":"/baz","value":"boo"}]`, `{"baz":"boo","foo":"bar"}`},
+		{"Move", `{"foo":{"bar":"baz","waldo":"fred"},"qux":{"corge":"grault"}}`,
+			`[{"op":"move","from":"/foo/waldo","path":"/qux/thud"}]`,
+			`{"foo":{"bar":"baz"},"qux":{"corge":"grault","thud":"fred"}}`},
+		{"MoveArrayElement", `{"foo":["all","grass","cows","eat"]}`, `[{"op":"move","from":"/foo/1","path":"/foo/3"}]`,
+			`{"foo":["all","cows","eat","grass"]}`},
+		{"Copy", `{"a":{"b":[1]}}`, `[{"op":"copy","from":"/a","path":"/c"},{"op":"add","path":"/c/b/-","value":2}]`,
+			`{"a":{"b":[1]},"c":{"b":[1,2]}}`},
+		{"TestSuccess", `{"baz":"qux","foo":["a",2,"c"]}`,
+			`[{"op":"test","path":"/baz","value":"qux"},{"op":"test","path":"/foo/1","value":2}]`,
+			`{"baz":"qux","foo":["a",2,"c"]}`},
+		{"EscapedPointer", `{"/":9,"~1":10}`, `[{"op":"test","path":"/~01","value":10},{"op":"remove","path":"/~1"}]`, `{"~1":10}`},
+	}
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			result, err := jsonpatch.Apply([]byte(c.doc), []byte(c.patch))
+			require.NoError(t, err)
+			assert.JSONEq(t, c.expected, string(result))
+		})
+	}
+}
+
+func TestApplyErrors(t *testing.T) {
+	cases := []struct {
+		name, patch string
+		err         error
+	}{
+		{"TestFails", `[{"op":"test","path":"/baz","value":"bar"}]`, jsonpatch.ErrTestFailed},
+		{"MissingTarget", `[{"op":"add","path":"/baz/bat","value":"qux"}]`, jsonpatch.ErrPathNotFound},
+		{"IndexOutOfRange", `[{"op":"add","path":"/foo/3","value":"x"}]`, jsonpatch.ErrPathNotFound},
+		{"RemoveMissing", `[{"op":"remove","path":"/nope"}]`, jsonpatch.ErrPathNotFound},
+		{"UnknownOp", `[{"op":"increment","path":"/baz"}]`, jsonpatch.ErrInvalidPatch},
+		{"MissingValue", `[{"op":"add","path":"/x"}]`, jsonpatch.ErrInvalidPatch},
+		{"NotAnArray", `{"op":"add","path":"/x","value":1}`, jsonpatch.ErrInvalidPatch},
+	}
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			_, err := jsonpatch.Apply([]byte(`{"baz":"qux","foo":["a","b"]}`), []byte(c.patch))
+			assert.ErrorIs(t, err, c.err)
+		})
+	}
+}
+
+// the example of section 3 of RFC 7396
+func TestMergePatch(t *testing.T) {
+	doc := `{"title":"Goodbye!","author":{"givenName":"John","familyName":"Doe"},"tags":["example","sample"],"content":"This will be unchanged"}`
+	patch := `{"title":"Hello!","phoneNumber":"+01-123-456-7890","author":{"familyName":null},"tags":["example"]}`
+
+	result, err := jsonpatch.MergePatch([]byte(doc), []byte(patch))
+	require.NoError(t, err)
+	assert.JSONEq(t, `{"title":"Hello!","author":{"givenName":"John"},"tags":["example"],"content":"This will be unchanged","phoneNumber":"+01-123-456-7890"}`,
+		string(result))
+}
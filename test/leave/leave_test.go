@@ -0,0 +1,106 @@
+package leave_test
+
+import (
+	"Techiebulter/interview/backend/models"
+	"Techiebulter/interview/backend/test/internal/apitest"
+	"encoding/json"
+	"fmt"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestLeaveWorkflow(t *testing.T) {
+	app := apitest.NewApp(t)
+	apitest.Call(t, app, "hr-key", "POST", "/api/CreateEmpolyee", `{"Name":"John Doe","position":"Engineer","Salary":5000,"hireDate":"2020-01-06"}`, 200)
+	apitest.Call(t, app, "hr-key", "POST", "/api/CreateEmpolyee", `{"Name":"Jane Roe","position":"QA","Salary":4000,"hireDate":"2020-01-06"}`, 200)
+	apitest.Call(t, app, "employee-key", "POST", "/api/v1/leave-types", `{"name":"Vacation","accrual":"yearly","daysPerPeriod":25}`, 403)
+	apitest.Call(t, app, "hr-key", "POST", "/api/v1/leave-types", `{"name":"Vacation","accrual":"yearly","daysPerPeriod":25}`, 200)
+	apitest.Call(t, app, "employee-key", "GET", "/api/v1/leave-types", "", 200)
+
+	// a week from today always holds a working day
+	today := time.Now()
+	week := fmt.Sprintf(`{"leaveTypeId":1,"startDate":"%s","endDate":"%s"}`, today.Format(models.DateLayout), today.AddDate(0, 0, 6).Format(models.DateLayout))
+	apitest.Call(t, app, "employee-key", "POST", "/api/v1/employees/2/leave-requests", week, 403)
+	request := apitest.Call(t, app, "employee-key", "POST", "/api/v1/employees/1/leave-requests", week, 200)["leaveRequest"].(map[string]interface{})
+	assert.Equal(t, "pending", request["status"])
+	assert.EqualValues(t, 5, request["days"])
+	employee := apitest.Call(t, app, "hr-key", "GET", "/api/GetEmployeeById/1", "", 200)["employeeDetails"].(map[string]interface{})
+	assert.Equal(t, "active", employee["status"], "pending leave does not count")
+
+	// employees do not decide on leave, nor does HR on its own
+	apitest.Call(t, app, "employee-key", "POST", "/api/v1/leave-requests/1/approve", "", 403)
+	apitest.Call(t, app, "hr-self-key", "POST", "/api/v1/leave-requests/1/approve", "", 403)
+	approved := apitest.Call(t, app, "hr-key", "POST", "/api/v1/leave-requests/1/approve", `{"note":"Enjoy"}`, 200)["leaveRequest"].(map[string]interface{})
+	assert.Equal(t, "approved", approved["status"])
+	assert.Equal(t, "hr:0", approved["decidedBy"])
+
+	employee = apitest.Call(t, app, "hr-key", "GET", "/api/GetEmployeeById/1", "", 200)["employeeDetails"].(map[string]interface{})
+	assert.Equal(t, "on-leave", employee["status"])
+	employee = apitest.Call(t, app, "hr-key", "GET", "/api/GetEmployeeById/2", "", 200)["employeeDetails"].(map[string]interface{})
+	assert.Equal(t, "active", employee["status"])
+	// every read of the employee shows the leave
+	employees := apitest.Call(t, app, "hr-key", "GET", "/api/GetAllEmployees/1/10", "", 200)["employees"].([]interface{})
+	require.Len(t, employees, 2)
+	assert.Equal(t, "on-leave", employees[0].(map[string]interface{})["status"])
+	assert.Equal(t, "active", employees[1].(map[string]interface{})["status"])
+	data := apitest.Call(t, app, "hr-key", "POST", "/graphql", `{"query":"{ employee(id: 1) { status } employees(first: 10) { edges { node { status } } } }"}`, 200)["data"].(map[string]interface{})
+	assert.Equal(t, "ON_LEAVE", data["employee"].(map[string]interface{})["status"])
+	node := data["employees"].(map[string]interface{})["edges"].([]interface{})[0].(map[string]interface{})["node"]
+	assert.Equal(t, "ON_LEAVE", node.(map[string]interface{})["status"])
+	// and so does every write
+	employee = apitest.Call(t, app, "hr-key", "PUT", "/api/UpdateEmployee", `{"ID":1,"location":"Berlin"}`, 200)["updatedEmployeeDetails"].(map[string]interface{})
+	assert.Equal(t, "on-leave", employee["status"])
+	req := httptest.NewRequest("PATCH", "/api/v1/employees/1", strings.NewReader(`{"department":"R&D"}`))
+	req.Header.Set(fiber.HeaderContentType, "application/merge-patch+json")
+	req.Header.Set("X-API-Key", "hr-key")
+	resp, err := app.Test(req)
+	require.NoError(t, err)
+	require.Equal(t, fiber.StatusOK, resp.StatusCode)
+	var patched struct{ UpdatedEmployeeDetails models.Employee }
+	require.NoError(t, json.NewDecoder(resp.Body).Decode(&patched))
+	assert.Equal(t, models.StatusOnLeave, patched.UpdatedEmployeeDetails.Status)
+	data = apitest.Call(t, app, "hr-key", "POST", "/graphql", `{"query":"mutation { updateEmployee(id: 1, input: {location: \"Paris\"}) { status } }"}`, 200)["data"].(map[string]interface{})
+	assert.Equal(t, "ON_LEAVE", data["updateEmployee"].(map[string]interface{})["status"])
+
+	balances := apitest.Call(t, app, "employee-key", "GET", "/api/v1/employees/1/leave-balances", "", 200)["balances"].([]interface{})
+	require.Len(t, balances, 1)
+	assert.EqualValues(t, 5, balances[0].(map[string]interface{})["used"])
+	apitest.Call(t, app, "colleague-key", "GET", "/api/v1/employees/1/leave-balances", "", 403)
+
+	// leave that started is not cancelled, and only by the employee or HR
+	apitest.Call(t, app, "colleague-key", "POST", "/api/v1/leave-requests/1/cancel", "", 403)
+	apitest.Call(t, app, "employee-key", "POST", "/api/v1/leave-requests/1/cancel", "", 409)
+}
+
+func TestManagerDecidesLeave(t *testing.T) {
+	app := apitest.NewApp(t)
+	apitest.Call(t, app, "hr-key", "POST", "/api/CreateEmpolyee", `{"Name":"John Doe","position":"Lead","Salary":7000,"hireDate":"2020-01-06"}`, 200)
+	apitest.Call(t, app, "hr-key", "POST", "/api/CreateEmpolyee", `{"Name":"Jane Roe","position":"QA","Salary":4000,"hireDate":"2020-01-06"}`, 200)
+	apitest.Call(t, app, "hr-key", "POST", "/api/CreateEmpolyee", `{"Name":"Max Mustermann","position":"Engineer","Salary":5000,"hireDate":"2020-01-06","managerId":1}`, 200)
+	apitest.Call(t, app, "hr-key", "POST", "/api/CreateEmpolyee", `{"Name":"Erika Mustermann","position":"Engineer","Salary":5000,"managerId":9}`, 422)
+	apitest.Call(t, app, "hr-key", "PUT", "/api/UpdateEmployee", `{"ID":2,"managerId":2}`, 422)
+	apitest.Call(t, app, "hr-key", "PUT", "/api/UpdateEmployee", `{"ID":1,"managerId":2}`, 200)
+	apitest.Call(t, app, "hr-key", "POST", "/api/v1/leave-types", `{"name":"Vacation","accrual":"yearly","daysPerPeriod":25}`, 200)
+
+	day := time.Now().AddDate(0, 0, 30).Format(models.DateLayout)
+	leave := fmt.Sprintf(`{"leaveTypeId":1,"startDate":"%s","endDate":"%s"}`, day, day)
+	apitest.Call(t, app, "hr-key", "POST", "/api/v1/employees/3/leave-requests", leave, 200)
+
+	// John manages Max, Jane only manages John
+	apitest.Call(t, app, "colleague-key", "POST", "/api/v1/leave-requests/1/approve", "", 403)
+	approved := apitest.Call(t, app, "employee-key", "POST", "/api/v1/leave-requests/1/approve", "", 200)["leaveRequest"].(map[string]interface{})
+	assert.Equal(t, "approved", approved["status"])
+	assert.Equal(t, "employee:1", approved["decidedBy"])
+
+	// managers do not decide on their own leave
+	apitest.Call(t, app, "employee-key", "POST", "/api/v1/employees/1/leave-requests", leave, 200)
+	apitest.Call(t, app, "employee-key", "POST", "/api/v1/leave-requests/2/reject", "", 403)
+	rejected := apitest.Call(t, app, "colleague-key", "POST", "/api/v1/leave-requests/2/reject", "", 200)["leaveRequest"].(map[string]interface{})
+	assert.Equal(t, "rejected", rejected["status"])
+}
@@ -0,0 +1,75 @@
+package models_test
+
+import (
+	"Techiebulter/interview/backend/models"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCompensationReport(t *testing.T) {
+	employee := func(id int, position, salary string, currency models.Currency) models.Employee {
+		return models.Employee{ID: id, Position: position, Salary: money(salary), Currency: currency}
+	}
+	employees := []models.Employee{
+		employee(1, "Engineer", "400", "USD"),
+		employee(2, "Engineer", "100", "USD"),
+		employee(3, "Engineer", "500", "EUR"),
+		employee(4, "Engineer", "300", "USD"),
+		employee(5, "Engineer", "200", "USD"),
+		employee(6, "Sales", "1000", "USD"),
+		employee(7, "Sales", "2000", "GBP"),
+	}
+	rates := []models.ExchangeRate{
+		{Currency: "EUR", Rate: models.MustParseRate("1.2"), EffectiveDate: date(t, "2024-01-01")},
+		{Currency: "GBP", Rate: models.MustParseRate("1.5"), EffectiveDate: date(t, "2024-01-01")},
+	}
+	query := models.SalaryReportQuery{AsOf: date(t, "2024-07-01"), GroupBy: models.GroupByPosition, Currency: "USD"}
+
+	report, err := models.NewCompensationReport(query, 3, employees, nil, models.NewConverter("USD", query.AsOf, rates))
+	require.NoError(t, err)
+	require.Len(t, report.Groups, 1, "the two salespeople are left out")
+	assert.Equal(t, 1, report.SuppressedGroups)
+	engineers := report.Groups[0]
+	assert.Equal(t, "Engineer", engineers.Key)
+	assert.Equal(t, 5, engineers.Headcount)
+	assert.Equal(t, models.SalaryStats{Min: money("100"), P10: money("140"), P25: money("200"), Median: money("300"),
+		P75: money("400"), P90: money("520"), Max: money("600"), Mean: money("320")}, engineers.Salary, "euros are normalized to dollars")
+	assert.Equal(t, 5, report.Headcount)
+	assert.Equal(t, engineers.Salary, *report.Salary, "only the groups shown are described")
+	assert.Equal(t, rates[:1], report.Rates, "the pound rate would tell a salesperson is paid in pounds")
+
+	report, err = models.NewCompensationReport(query, 10, employees, nil, models.NewConverter("USD", query.AsOf, rates))
+	require.NoError(t, err)
+	assert.Empty(t, report.Groups)
+	assert.Nil(t, report.Salary)
+	assert.Equal(t, 2, report.SuppressedGroups)
+}
+
+func TestHeadcountTrend(t *testing.T) {
+	day := func(s string) *models.Date {
+		d := date(t, s)
+		return &d
+	}
+	employees := []models.Employee{
+		{ID: 1, HireDate: day("2024-02-10")},
+		{ID: 2, TerminationDate: day("2024-05-15")},
+		{ID: 3, HireDate: day("2024-04-01"), TerminationDate: day("2024-08-31")},
+		{ID: 4, HireDate: day("2025-01-01")},
+	}
+	query := models.HeadcountQuery{From: date(t, "2024-02-15"), To: date(t, "2024-09-10"), Interval: models.IntervalQuarter}
+	require.Empty(t, models.HeadcountQueryRules.Validate(&query))
+
+	trend := models.NewHeadcountTrend(query, employees)
+	assert.Equal(t, []models.HeadcountPoint{
+		{Start: date(t, "2024-02-15"), End: date(t, "2024-03-31"), Headcount: 2},
+		{Start: date(t, "2024-04-01"), End: date(t, "2024-06-30"), Headcount: 2, Hires: 1, Terminations: 1},
+		{Start: date(t, "2024-07-01"), End: date(t, "2024-09-10"), Headcount: 1, Terminations: 1},
+	}, trend.Points, "the first and last quarters are cut to the trend")
+
+	query = models.HeadcountQuery{From: date(t, "2024-02-15"), To: date(t, "2024-02-14"), Interval: "week"}
+	assert.Equal(t, map[string]string{"interval": models.CodeInvalidValue, "to": models.CodeOutOfRange}, fieldCodes(models.HeadcountQueryRules.Validate(&query)))
+	query = models.HeadcountQuery{From: date(t, "2014-01-01"), To: date(t, "2024-01-01"), Interval: models.IntervalMonth}
+	assert.Equal(t, map[string]string{"to": models.CodeOutOfRange}, fieldCodes(models.HeadcountQueryRules.Validate(&query)), "121 months")
+}
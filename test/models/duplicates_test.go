@@ -0,0 +1,89 @@
+package models_test
+
+import (
+	"Techiebulter/interview/backend/models"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestFindDuplicates(t *testing.T) {
+	employees := []models.Employee{
+		{ID: 1, Name: "John Doe", Email: "john@example.com", Phone: "+1 (555) 010-2030"},
+		{ID: 2, Name: "Doe, John", Phone: "555-010-2030"},
+		{ID: 3, Name: "Jon Doe", Email: "JOHN@example.com"},
+		{ID: 4, Name: "Jane Smith", Email: "jane@example.com"},
+		{ID: 5, Name: "Janet Smithers"},
+	}
+
+	candidates := models.FindDuplicates(employees, 0.6)
+	pairs := map[[2]int]models.DuplicateCandidate{}
+	for _, candidate := range candidates {
+		pairs[[2]int{candidate.Employee.ID, candidate.Duplicate.ID}] = candidate
+	}
+
+	exact := pairs[[2]int{1, 2}]
+	assert.Equal(t, 1.0, exact.NameSimilarity, "word order and punctuation are ignored")
+	assert.True(t, exact.PhoneMatch, "formatting and country codes are ignored")
+	assert.Equal(t, 0.75, exact.Score)
+
+	typo := pairs[[2]int{1, 3}]
+	assert.True(t, typo.EmailMatch, "emails are compared case-insensitively")
+	assert.Greater(t, typo.Score, 0.8)
+
+	assert.NotContains(t, pairs, [2]int{4, 5}, "similar names without shared contact details score too low")
+	for i := 1; i < len(candidates); i++ {
+		assert.GreaterOrEqual(t, candidates[i-1].Score, candidates[i].Score, "best matches come first")
+	}
+}
+
+func TestMergeRequest(t *testing.T) {
+	early := models.NewDate(time.Date(2019, time.March, 1, 0, 0, 0, 0, time.UTC))
+	late := models.NewDate(time.Date(2021, time.June, 1, 0, 0, 0, 0, time.UTC))
+	survivor := models.Employee{ID: 1, Name: "John Doe", Position: "Engineer", Salary: money("100"), HireDate: &late,
+		CustomFields: models.CustomFields{"team": "core"}}
+	duplicate := models.Employee{ID: 2, Name: "Jon Doe", Position: "Senior Engineer", Salary: money("200"), Email: "john@example.com",
+		HireDate: &early, CustomFields: models.CustomFields{"team": "web", "badge": float64(7)}}
+
+	t.Run("Merge", func(t *testing.T) {
+		request := models.MergeRequest{SurvivorID: 1, DuplicateID: 2, TakeFromDuplicate: []string{"Salary"}}
+		merged, err := request.Merge(survivor, duplicate)
+		require.NoError(t, err)
+		assert.Equal(t, "John Doe", merged.Name)
+		assert.Equal(t, "Engineer", merged.Position)
+		assert.Equal(t, money("200"), merged.Salary)
+		assert.Equal(t, "john@example.com", merged.Email, "empty fields are filled from the duplicate")
+		assert.Equal(t, &early, merged.HireDate, "the earlier hire date is kept")
+		assert.Equal(t, models.CustomFields{"team": "core", "badge": float64(7)}, merged.CustomFields)
+		assert.Equal(t, models.CustomFields{"team": "core"}, survivor.CustomFields, "the survivor is not changed")
+	})
+
+	t.Run("Manager", func(t *testing.T) {
+		manager, survivorID := 5, 1
+		managed := duplicate
+		managed.ManagerID = &manager
+		merged, err := models.MergeRequest{SurvivorID: 1, DuplicateID: 2}.Merge(survivor, managed)
+		require.NoError(t, err)
+		assert.Equal(t, &manager, merged.ManagerID, "the manager is filled from the duplicate")
+
+		managed.ManagerID = &survivorID
+		merged, err = models.MergeRequest{SurvivorID: 1, DuplicateID: 2, TakeFromDuplicate: []string{"managerId"}}.Merge(survivor, managed)
+		require.NoError(t, err)
+		assert.Nil(t, merged.ManagerID, "the survivor does not manage itself")
+	})
+
+	t.Run("WrongEmployees", func(t *testing.T) {
+		_, err := models.MergeRequest{SurvivorID: 2, DuplicateID: 1}.Merge(survivor, duplicate)
+		assert.Error(t, err)
+	})
+
+	t.Run("Rules", func(t *testing.T) {
+		errs := models.MergeRequestRules.Validate(&models.MergeRequest{SurvivorID: 3, DuplicateID: 3, TakeFromDuplicate: []string{"ID"}})
+		assert.Equal(t, map[string]string{"duplicateId": models.CodeInvalidValue, "takeFromDuplicate": models.CodeInvalidValue}, fieldCodes(errs))
+
+		errs = models.MergeRequestRules.Validate(&models.MergeRequest{})
+		assert.Equal(t, map[string]string{"survivorId": models.CodeRequired, "duplicateId": models.CodeRequired}, fieldCodes(errs))
+	})
+}
@@ -0,0 +1,99 @@
+package models_test
+
+import (
+	"Techiebulter/interview/backend/models"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func date(t *testing.T, s string) models.Date {
+	t.Helper()
+	d, err := models.ParseDate(s)
+	require.NoError(t, err)
+	return d
+}
+
+func TestLeaveAccrual(t *testing.T) {
+	hired := date(t, "2024-01-31")
+	monthly := models.LeaveType{Accrual: models.AccrualMonthly, DaysPerPeriod: 1.5}
+	yearly := models.LeaveType{Accrual: models.AccrualYearly, DaysPerPeriod: 25}
+	unlimited := models.LeaveType{Accrual: models.AccrualUnlimited}
+
+	cases := []struct {
+		on                         string
+		monthly, yearly, unlimited float64
+	}{
+		{"2024-01-30", 0, 0, 0},
+		{"2024-01-31", 0, 25, 0},
+		{"2024-02-29", 0, 25, 0},
+		{"2024-03-31", 3, 25, 0},
+		{"2025-01-30", 16.5, 25, 0},
+		{"2025-01-31", 18, 50, 0},
+	}
+	for _, c := range cases {
+		on := date(t, c.on)
+		assert.Equal(t, c.monthly, monthly.Accrued(hired, on), "monthly on %s", c.on)
+		assert.Equal(t, c.yearly, yearly.Accrued(hired, on), "yearly on %s", c.on)
+		assert.Equal(t, c.unlimited, unlimited.Accrued(hired, on), "unlimited on %s", c.on)
+	}
+}
+
+func TestLeaveTypeValidation(t *testing.T) {
+	assert.Empty(t, (&models.LeaveType{Name: " Vacation ", Accrual: models.AccrualYearly, DaysPerPeriod: 25}).Validate())
+	assert.Empty(t, (&models.LeaveType{Name: "Unpaid", Accrual: models.AccrualUnlimited}).Validate())
+
+	assert.Equal(t, map[string]string{"name": models.CodeRequired, "accrual": models.CodeInvalidValue},
+		fieldCodes((&models.LeaveType{Accrual: "weekly", DaysPerPeriod: 1}).Validate()))
+	assert.Equal(t, map[string]string{"daysPerPeriod": models.CodeInvalidValue},
+		fieldCodes((&models.LeaveType{Name: "Vacation", Accrual: models.AccrualMonthly}).Validate()))
+	assert.Equal(t, map[string]string{"daysPerPeriod": models.CodeInvalidValue},
+		fieldCodes((&models.LeaveType{Name: "Unpaid", Accrual: models.AccrualUnlimited, DaysPerPeriod: 1}).Validate()))
+}
+
+func TestLeaveRequestValidation(t *testing.T) {
+	request := models.LeaveRequest{LeaveTypeID: 1, StartDate: date(t, "2024-07-05"), EndDate: date(t, "2024-07-09"), Reason: " Trip "}
+	require.Empty(t, request.Validate())
+	assert.EqualValues(t, 3, request.Days, "the weekend is not counted")
+	assert.Equal(t, "Trip", request.Reason)
+
+	weekend := models.LeaveRequest{LeaveTypeID: 1, StartDate: date(t, "2024-07-06"), EndDate: date(t, "2024-07-07")}
+	assert.Equal(t, map[string]string{"endDate": models.CodeOutOfRange}, fieldCodes(weekend.Validate()))
+	backwards := models.LeaveRequest{LeaveTypeID: 1, StartDate: date(t, "2024-07-09"), EndDate: date(t, "2024-07-05")}
+	assert.Equal(t, map[string]string{"endDate": models.CodeOutOfRange}, fieldCodes(backwards.Validate()))
+	long := models.LeaveRequest{LeaveTypeID: 1, StartDate: date(t, "2024-07-09"), EndDate: date(t, "2025-07-09")}
+	assert.Equal(t, map[string]string{"endDate": models.CodeOutOfRange}, fieldCodes(long.Validate()))
+	assert.Equal(t, map[string]string{"leaveTypeId": models.CodeRequired, "startDate": models.CodeRequired, "endDate": models.CodeRequired},
+		fieldCodes((&models.LeaveRequest{}).Validate()))
+}
+
+func TestLeaveWorkflow(t *testing.T) {
+	today := date(t, "2024-07-01")
+	pending := models.LeaveRequest{Status: models.LeavePending, StartDate: date(t, "2024-06-01")}
+	assert.True(t, pending.CanBecome(models.LeaveApproved, today))
+	assert.True(t, pending.CanBecome(models.LeaveRejected, today))
+	assert.True(t, pending.CanBecome(models.LeaveCancelled, today))
+
+	started := models.LeaveRequest{Status: models.LeaveApproved, StartDate: today}
+	assert.False(t, started.CanBecome(models.LeaveCancelled, today), "leave that started is not cancelled")
+	assert.False(t, started.CanBecome(models.LeaveRejected, today))
+	upcoming := models.LeaveRequest{Status: models.LeaveApproved, StartDate: date(t, "2024-07-02")}
+	assert.True(t, upcoming.CanBecome(models.LeaveCancelled, today))
+
+	rejected := models.LeaveRequest{Status: models.LeaveRejected, StartDate: date(t, "2024-07-02")}
+	assert.False(t, rejected.CanBecome(models.LeaveApproved, today))
+}
+
+func TestLeaveBalance(t *testing.T) {
+	vacation := models.LeaveType{ID: 1, Name: "Vacation", Accrual: models.AccrualYearly, DaysPerPeriod: 25}
+	requests := []models.LeaveRequest{
+		{LeaveTypeID: 1, Status: models.LeaveApproved, Days: 5},
+		{LeaveTypeID: 1, Status: models.LeavePending, Days: 2},
+		{LeaveTypeID: 1, Status: models.LeaveRejected, Days: 10},
+		{LeaveTypeID: 2, Status: models.LeaveApproved, Days: 3},
+	}
+	balance := models.NewLeaveBalance(vacation, date(t, "2024-01-31"), date(t, "2024-06-01"), requests)
+	assert.Equal(t, models.LeaveBalance{LeaveTypeID: 1, LeaveType: "Vacation", Accrual: models.AccrualYearly,
+		Accrued: 25, Used: 5, Pending: 2, Available: 20}, balance)
+}
@@ -0,0 +1,140 @@
+package models_test
+
+import (
+	"Techiebulter/interview/backend/models"
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// money parses an amount of a test
+func money(s string) models.Money {
+	return models.MustParseMoney(s)
+}
+
+func TestMoney(t *testing.T) {
+	assert.Equal(t, int64(123457), money("1234.57").Cents())
+	assert.Equal(t, "-0.05", money("-0.050").String())
+	var amountErr *models.AmountError
+	_, err := models.ParseMoney("1234.567")
+	require.ErrorAs(t, err, &amountErr, "fractions of a cent are not rounded")
+	assert.Equal(t, models.CodeInvalidValue, amountErr.Code)
+	_, err = models.ParseMoney("1e20")
+	require.ErrorAs(t, err, &amountErr)
+	assert.Equal(t, models.CodeOutOfRange, amountErr.Code)
+	exact, err := models.ExactMoney(0.07)
+	require.NoError(t, err)
+	assert.Equal(t, money("0.07"), exact, "floats are taken as the decimal they print as")
+	_, err = models.ExactMoney(0.005)
+	assert.ErrorAs(t, err, &amountErr)
+	assert.Equal(t, "0.30", money("0.1").Add(money("0.2")).String(), "amounts add up to the cent")
+	assert.Equal(t, money("1200"), money("37200").MulDiv(1, 31))
+	assert.Equal(t, money("10.01"), money("100.05").Percent(10))
+
+	var employee models.Employee
+	require.NoError(t, json.Unmarshal([]byte(`{"Salary":1234.5,"currency":"EUR"}`), &employee))
+	assert.Equal(t, money("1234.50"), employee.Salary)
+	encoded, err := json.Marshal(employee.Salary)
+	require.NoError(t, err)
+	assert.Equal(t, "1234.50", string(encoded))
+	assert.Error(t, json.Unmarshal([]byte(`{"Salary":"1234.5"}`), &employee), "amounts are numbers")
+	assert.ErrorAs(t, json.Unmarshal([]byte(`{"Salary":1234.505}`), &employee), &amountErr)
+
+	var scanned models.Money
+	for _, src := range []interface{}{int64(12), 12.0, []byte("12.00"), "12"} {
+		require.NoError(t, scanned.Scan(src))
+		assert.Equal(t, money("12"), scanned)
+	}
+
+	assert.True(t, models.Currency("EUR").IsValid())
+	assert.False(t, models.Currency("EURO").IsValid())
+}
+
+func TestParseExchangeRatesCSV(t *testing.T) {
+	rates, errs := models.ParseExchangeRatesCSV(strings.NewReader("effectiveDate,currency,rate\n2024-07-01,eur,1.0834\n2024-07-01,GBP,1.27\n"), "USD")
+	assert.Empty(t, errs)
+	require.Len(t, rates, 2)
+	assert.Equal(t, models.Currency("EUR"), rates[0].Currency)
+	assert.Equal(t, "1.0834", rates[0].Rate.String())
+	assert.Equal(t, date(t, "2024-07-01"), rates[1].EffectiveDate)
+
+	_, errs = models.ParseExchangeRatesCSV(strings.NewReader("currency,rate,effectiveDate\nEUR,-1,2024-07-01\nXYZ,1.2,July\nUSD,1,2024-07-01\n"), "USD")
+	assert.Equal(t, map[string]string{
+		"lines[2].rate":          models.CodeOutOfRange,
+		"lines[3].currency":      models.CodeInvalidValue,
+		"lines[3].effectiveDate": models.CodeInvalidFormat,
+		"lines[4].currency":      models.CodeInvalidValue,
+	}, fieldCodes(errs), "the base currency has no rate")
+
+	_, errs = models.ParseExchangeRatesCSV(strings.NewReader("currency,rate\n"), "USD")
+	assert.Equal(t, map[string]string{"csv": models.CodeRequired}, fieldCodes(errs))
+}
+
+func TestConverter(t *testing.T) {
+	rates := []models.ExchangeRate{
+		{Currency: "EUR", Rate: models.MustParseRate("1.1"), EffectiveDate: date(t, "2024-01-01")},
+		{Currency: "EUR", Rate: models.MustParseRate("1.2"), EffectiveDate: date(t, "2024-07-01")},
+		{Currency: "GBP", Rate: models.MustParseRate("1.5"), EffectiveDate: date(t, "2024-07-02")},
+	}
+	converter := models.NewConverter("USD", date(t, "2024-07-01"), rates)
+
+	dollars, err := converter.Exchange(money("100"), "EUR", "USD")
+	require.NoError(t, err)
+	assert.Equal(t, money("120"), dollars, "the latest rate on or before the day")
+	euros, err := converter.Exchange(money("100"), "USD", "EUR")
+	require.NoError(t, err)
+	assert.Equal(t, money("83.33"), euros)
+
+	_, err = converter.Exchange(money("100"), "GBP", "EUR")
+	var missing *models.MissingRateError
+	require.ErrorAs(t, err, &missing)
+	assert.Equal(t, "no exchange rate for GBP on or before 2024-07-01", err.Error())
+
+	pounds, err := models.NewConverter("USD", date(t, "2024-07-02"), rates).Exchange(money("100"), "EUR", "GBP")
+	require.NoError(t, err)
+	assert.Equal(t, money("80"), pounds, "other currencies are exchanged through the base currency")
+
+	huge := []models.ExchangeRate{{Currency: "XAU", Rate: models.MustParseRate("9999999999"), EffectiveDate: date(t, "2024-01-01")}}
+	_, err = models.NewConverter("USD", date(t, "2024-07-01"), huge).Exchange(money("99999999"), "XAU", "USD")
+	var overflow *models.OverflowError
+	assert.ErrorAs(t, err, &overflow, "the exchanged amount does not fit")
+}
+
+func TestSalaryReport(t *testing.T) {
+	hired, later := date(t, "2024-01-01"), date(t, "2024-09-01")
+	employees := []models.Employee{
+		{ID: 1, Department: "Engineering", Salary: money("6000"), Currency: "USD", HireDate: &hired},
+		{ID: 2, Department: "Engineering", Salary: money("5000"), Currency: "EUR", HireDate: &hired},
+		{ID: 3, Department: "Sales", Salary: money("4000"), Currency: "USD"},
+		{ID: 4, Department: "Sales", Salary: money("4000"), Currency: "USD", HireDate: &later},
+	}
+	history := []models.SalaryChange{
+		{EmployeeID: 1, Salary: money("5000"), Currency: "USD", EffectiveFrom: hired},
+		{EmployeeID: 1, Salary: money("6000"), Currency: "USD", EffectiveFrom: date(t, "2024-08-01")},
+	}
+	rates := []models.ExchangeRate{{Currency: "EUR", Rate: models.MustParseRate("1.2"), EffectiveDate: hired}}
+	query := models.SalaryReportQuery{AsOf: date(t, "2024-07-01"), GroupBy: models.GroupByDepartment, Currency: "USD"}
+
+	report, err := models.NewSalaryReport(query, employees, history, models.NewConverter("USD", query.AsOf, rates))
+	require.NoError(t, err)
+	assert.Equal(t, 3, report.Headcount, "not hired yet on the day")
+	assert.Equal(t, money("15000"), report.Total)
+	require.Len(t, report.Groups, 2)
+	assert.Equal(t, models.SalaryGroup{Key: "Engineering", Headcount: 2, Total: money("11000"), Totals: []models.CurrencyTotal{
+		{Currency: "EUR", Headcount: 1, Amount: money("5000")},
+		{Currency: "USD", Headcount: 1, Amount: money("5000")},
+	}}, report.Groups[0], "the salary effective on the day")
+	assert.Equal(t, rates, report.Rates)
+
+	query.Currency = "EUR"
+	report, err = models.NewSalaryReport(query, employees, history, models.NewConverter("USD", query.AsOf, rates))
+	require.NoError(t, err)
+	assert.Equal(t, money("12500"), report.Total)
+
+	_, err = models.NewSalaryReport(query, employees, history, models.NewConverter("USD", query.AsOf, nil))
+	var missing *models.MissingRateError
+	assert.ErrorAs(t, err, &missing)
+}
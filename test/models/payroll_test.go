@@ -0,0 +1,148 @@
+package models_test
+
+import (
+	"Techiebulter/interview/backend/models"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestPayPeriod(t *testing.T) {
+	period, err := models.ParsePayPeriod("2024-02")
+	require.NoError(t, err)
+	assert.Equal(t, date(t, "2024-02-01"), period.Start())
+	assert.Equal(t, date(t, "2024-02-29"), period.End())
+	assert.Equal(t, 29, period.Days())
+
+	_, err = models.ParsePayPeriod("2024-13")
+	assert.Error(t, err)
+	assert.False(t, models.PayPeriod("2024-7").IsValid())
+	assert.Equal(t, map[string]string{"period": models.CodeInvalidFormat},
+		fieldCodes(models.PayrollRunRequestRules.Validate(&models.PayrollRunRequest{Period: "2024-07-01"})))
+}
+
+func TestSalaryEffectiveFrom(t *testing.T) {
+	today := date(t, "2024-07-10")
+	past, future := date(t, "2024-01-15"), date(t, "2024-08-01")
+
+	assert.Equal(t, past, models.Employee{HireDate: &past}.SalaryEffectiveFrom(true, today), "new employees are paid from their hire date")
+	assert.Equal(t, today, models.Employee{HireDate: &past}.SalaryEffectiveFrom(false, today))
+	assert.Equal(t, future, models.Employee{HireDate: &future}.SalaryEffectiveFrom(false, today), "nothing is paid before the hire date")
+	assert.Equal(t, today, models.Employee{}.SalaryEffectiveFrom(true, today))
+}
+
+func TestPayrollRun(t *testing.T) {
+	hired, terminated, left := date(t, "2024-07-11"), date(t, "2024-07-26"), date(t, "2024-06-30")
+	longAgo := date(t, "2020-01-01")
+	usd := models.DefaultCurrency
+	employees := []models.Employee{
+		{ID: 4, Name: "Joe", EmploymentType: models.EmploymentContractor, Currency: usd},
+		{ID: 1, Name: "Jane", Department: "Engineering", EmploymentType: models.EmploymentFullTime, HireDate: &hired, Currency: usd},
+		{ID: 2, Name: "John", EmploymentType: models.EmploymentFullTime, HireDate: &longAgo, TerminationDate: &terminated, Currency: usd},
+		{ID: 3, Name: "Joan", EmploymentType: models.EmploymentFullTime, HireDate: &longAgo, TerminationDate: &left, Currency: usd},
+	}
+	history := []models.SalaryChange{
+		{EmployeeID: 1, Salary: money("3100"), Currency: usd, EffectiveFrom: hired},
+		{EmployeeID: 2, Salary: money("3100"), Currency: usd, EffectiveFrom: longAgo},
+		{EmployeeID: 2, Salary: money("6200"), Currency: usd, EffectiveFrom: date(t, "2024-07-17")},
+		{EmployeeID: 3, Salary: money("3100"), Currency: usd, EffectiveFrom: longAgo},
+		{EmployeeID: 4, Salary: money("20"), Currency: usd, EffectiveFrom: date(t, "2024-01-01")},
+		{EmployeeID: 4, Salary: money("30"), Currency: usd, EffectiveFrom: date(t, "2024-07-08")},
+	}
+	timesheet := func(weekStart string, status models.TimesheetStatus, hours float64) models.Timesheet {
+		return models.Timesheet{EmployeeID: 4, WeekStart: date(t, weekStart), Status: status,
+			Entries: models.TimesheetEntries{{Date: date(t, weekStart), Project: "Apollo", Hours: hours}}}
+	}
+	timesheets := []models.Timesheet{
+		timesheet("2024-06-24", models.TimesheetApproved, 8),
+		timesheet("2024-07-01", models.TimesheetApproved, 10),
+		timesheet("2024-07-08", models.TimesheetSubmitted, 8),
+		timesheet("2024-07-29", models.TimesheetApproved, 8),
+	}
+	rules := models.PayrollRules{SalaryBasis: models.SalaryMonthly, Overtime: models.OvertimeRules{DailyHours: 8, Multiplier: 1.5},
+		Components: []models.PayComponent{
+			{Name: "meal", Kind: models.Allowance, Amount: money("100")},
+			{Name: "tax", Kind: models.Deduction, Percent: 20},
+			{Name: "bonus", Kind: models.Allowance, Percent: 10},
+		}}
+	rates := []models.ExchangeRate{{Currency: "EUR", Rate: models.MustParseRate("1.25"), EffectiveDate: date(t, "2024-07-31")},
+		{Currency: "EUR", Rate: models.MustParseRate("2"), EffectiveDate: date(t, "2024-08-01")}}
+	converter := models.NewConverter(usd, date(t, "2024-07-31"), rates)
+
+	run, err := models.NewPayrollRun("2024-07", rules, employees, history, timesheets, converter)
+	require.NoError(t, err)
+	assert.Equal(t, models.PayrollDraft, run.Status)
+	assert.Equal(t, usd, run.Currency)
+	require.Len(t, run.Payslips, 3, "Joan left before the period")
+
+	jane := run.Payslips[0]
+	assert.Equal(t, models.PaySegments{{From: hired, To: date(t, "2024-07-31"), Salary: money("3100"), Currency: usd, Days: 21,
+		Amount: money("2100")}}, jane.Segments, "hired on the 11th")
+	assert.Equal(t, models.PayslipLines{{Name: "meal", Kind: models.Allowance, Amount: money("100")},
+		{Name: "bonus", Kind: models.Allowance, Amount: money("210")}, {Name: "tax", Kind: models.Deduction, Amount: money("482")}}, jane.Lines)
+	assert.Equal(t, []models.Money{money("2100"), money("2410"), money("482"), money("1928")},
+		[]models.Money{jane.BasePay, jane.Gross, jane.Deductions, jane.Net})
+	assert.Equal(t, "Engineering", jane.Department)
+
+	john := run.Payslips[1]
+	assert.Equal(t, models.PaySegments{
+		{From: date(t, "2024-07-01"), To: date(t, "2024-07-16"), Salary: money("3100"), Currency: usd, Days: 16, Amount: money("1600")},
+		{From: date(t, "2024-07-17"), To: terminated, Salary: money("6200"), Currency: usd, Days: 10, Amount: money("2000")},
+	}, john.Segments, "a raise on the 17th, terminated on the 26th")
+	assert.Equal(t, money("3600"), john.BasePay)
+
+	joe := run.Payslips[2]
+	assert.Equal(t, models.PaySegments{
+		{From: date(t, "2024-07-01"), To: date(t, "2024-07-07"), Salary: money("20"), Currency: usd, Hours: 11, Amount: money("220")},
+		{From: date(t, "2024-07-29"), To: date(t, "2024-08-04"), Salary: money("30"), Currency: usd, Hours: 8, Amount: money("240")},
+	}, joe.Segments, "approved weeks starting in the period at the rate of their Monday, two hours of overtime")
+	assert.Equal(t, []models.Money{money("460"), money("606"), money("121.20"), money("484.80")},
+		[]models.Money{joe.BasePay, joe.Gross, joe.Deductions, joe.Net})
+
+	assert.Equal(t, 3, run.Employees)
+	assert.Equal(t, []models.Money{money("7076"), money("1415.20"), money("5660.80")}, []models.Money{run.Gross, run.Deductions, run.Net})
+	again, err := models.NewPayrollRun("2024-07", rules, employees, history, timesheets, converter)
+	require.NoError(t, err)
+	assert.Equal(t, run, again, "runs are reproducible")
+
+	annual, err := models.NewPayrollRun("2024-07", models.PayrollRules{SalaryBasis: models.SalaryAnnual}, employees[1:2],
+		[]models.SalaryChange{{EmployeeID: 1, Salary: money("37200"), Currency: usd, EffectiveFrom: hired}}, nil, converter)
+	require.NoError(t, err)
+	assert.Equal(t, money("2100"), annual.Payslips[0].Net)
+
+	loan, err := models.NewPayrollRun("2024-07", models.PayrollRules{SalaryBasis: models.SalaryMonthly, Components: []models.PayComponent{
+		{Name: "loan", Kind: models.Deduction, Amount: money("5000")}}}, employees[1:2], history, nil, converter)
+	require.NoError(t, err)
+	assert.Equal(t, models.PayslipLines{{Name: "loan", Kind: models.Deduction, Amount: money("2100")}}, loan.Payslips[0].Lines,
+		"deductions stop at the gross pay")
+	assert.True(t, loan.Payslips[0].Net.IsZero())
+
+	// Jane is paid in euros since the 17th, her meal allowance of 100 dollars is 80 euros at the rate of the
+	// last day of the period and the run is totalled in dollars
+	euros := append(history[:1:1], models.SalaryChange{EmployeeID: 1, Salary: money("1550"), Currency: "EUR", EffectiveFrom: date(t, "2024-07-17")})
+	paidInEuros := employees[1]
+	paidInEuros.Currency = "EUR"
+	mixed, err := models.NewPayrollRun("2024-07", models.PayrollRules{SalaryBasis: models.SalaryMonthly, Components: rules.Components[:1]},
+		[]models.Employee{paidInEuros}, euros, nil, converter)
+	require.NoError(t, err)
+	payslip := mixed.Payslips[0]
+	assert.Equal(t, models.Currency("EUR"), payslip.Currency)
+	assert.Equal(t, []models.Money{money("480"), money("750")}, []models.Money{payslip.Segments[0].Amount, payslip.Segments[1].Amount},
+		"six days at 3100 dollars exchanged at 1.25, fifteen days at 1550 euros")
+	assert.Equal(t, money("1310"), payslip.Net)
+	assert.Equal(t, money("1637.50"), mixed.Net)
+
+	_, err = models.NewPayrollRun("2024-07", rules, []models.Employee{paidInEuros}, euros, nil, models.NewConverter(usd, date(t, "2024-07-30"), rates))
+	var missing *models.MissingRateError
+	require.ErrorAs(t, err, &missing)
+	assert.Equal(t, models.Currency("EUR"), missing.Currency)
+
+	// a week of a contractor at the highest hourly rate is more than a payslip holds
+	_, err = models.NewPayrollRun("2024-07", rules, employees[:1],
+		[]models.SalaryChange{{EmployeeID: 4, Salary: money("99999999.99"), Currency: usd, EffectiveFrom: longAgo}},
+		[]models.Timesheet{timesheet("2024-07-01", models.TimesheetApproved, 120)}, converter)
+	var overflow *models.OverflowError
+	require.ErrorAs(t, err, &overflow)
+	assert.Equal(t, "base pay of employee 4", overflow.What)
+}
@@ -0,0 +1,94 @@
+package models_test
+
+import (
+	"Techiebulter/interview/backend/models"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSalaryBandValidation(t *testing.T) {
+	band := models.SalaryBand{Position: "  Engineer ", Currency: "eur", Min: money("50000"), Mid: money("60000"), Max: money("70000")}
+	require.Empty(t, band.Validate())
+	assert.Equal(t, "Engineer", band.Position)
+	assert.Equal(t, models.Currency("EUR"), band.Currency)
+	assert.Equal(t, models.EnforceWarn, band.Enforcement, "bands warn unless told otherwise")
+
+	band = models.SalaryBand{Position: "Engineer", Currency: "EURO", Min: money("50000"), Mid: money("80000"), Max: money("70000"),
+		Enforcement: "block"}
+	assert.Equal(t, map[string]string{"currency": models.CodeInvalidValue, "mid": models.CodeOutOfRange, "enforcement": models.CodeInvalidValue},
+		fieldCodes(band.Validate()))
+	band = models.SalaryBand{}
+	assert.Equal(t, map[string]string{"position": models.CodeRequired, "currency": models.CodeRequired, "min": models.CodeRequired,
+		"mid": models.CodeRequired, "max": models.CodeRequired}, fieldCodes(band.Validate()), "unset amounts are not compared")
+}
+
+func TestCheckSalaryBand(t *testing.T) {
+	bands := []models.SalaryBand{
+		{Position: "Engineer L3", Currency: "EUR", Min: money("50000"), Mid: money("60000"), Max: money("70000"), Enforcement: models.EnforceReject},
+	}
+	rates := []models.ExchangeRate{{Currency: "EUR", Rate: models.MustParseRate("1.25"), EffectiveDate: date(t, "2024-01-01")}}
+	converter := models.NewConverter("USD", date(t, "2024-07-01"), rates)
+
+	check, err := models.CheckSalaryBand(models.Employee{Position: "engineer  l3", Salary: money("100000"), Currency: "USD"}, bands, converter)
+	require.NoError(t, err)
+	require.NotNil(t, check, "positions match ignoring case and spaces")
+	assert.Equal(t, models.AboveBand, check.Standing)
+	assert.Equal(t, money("80000"), check.Salary)
+	assert.Equal(t, 1.333, check.CompaRatio)
+	assert.Equal(t, models.FieldError{Field: "Salary", Code: models.CodeOutOfRange,
+		Message: "Salary must be between 50000.00 and 70000.00 EUR, the band of Engineer L3"}, check.FieldError())
+
+	check, err = models.CheckSalaryBand(models.Employee{Position: "Engineer L3", Salary: money("70000"), Currency: "EUR"}, bands, converter)
+	assert.NoError(t, err)
+	assert.Nil(t, check, "the bounds are within the band")
+	check, err = models.CheckSalaryBand(models.Employee{Position: "Engineer L4", Salary: money("1"), Currency: "EUR"}, bands, converter)
+	assert.NoError(t, err)
+	assert.Nil(t, check, "positions without a band are not checked")
+
+	_, err = models.CheckSalaryBand(models.Employee{Position: "Engineer L3", Salary: money("1"), Currency: "GBP"}, bands, converter)
+	var missing *models.MissingRateError
+	assert.ErrorAs(t, err, &missing)
+}
+
+func TestPayEquityReport(t *testing.T) {
+	bands := []models.SalaryBand{
+		{ID: 1, Position: "Engineer", Currency: "USD", Min: money("80"), Mid: money("100"), Max: money("120")},
+		{ID: 2, Position: "Designer", Currency: "EUR", Min: money("80"), Mid: money("100"), Max: money("120")},
+	}
+	employees := []models.Employee{
+		{ID: 1, Name: "A", Position: "Engineer", Department: "Product", Salary: money("130"), Currency: "USD"},
+		{ID: 2, Name: "B", Position: "Engineer", Department: "Product", Salary: money("100"), Currency: "USD"},
+		{ID: 3, Name: "C", Position: "Designer", Department: "Product", Salary: money("100"), Currency: "USD"},
+		{ID: 4, Name: "D", Position: "Engineer", Department: "Ops", Salary: money("70"), Currency: "USD"},
+		{ID: 5, Name: "E", Position: "Manager", Department: "Ops", Salary: money("500"), Currency: "USD"},
+		{ID: 6, Name: "F", Position: "Engineer", Department: "Ops", Salary: money("1"), Currency: "USD",
+			TerminationDate: func() *models.Date { d := date(t, "2024-01-31"); return &d }()},
+	}
+	// the designer was paid 60 until their raise to 100
+	history := []models.SalaryChange{
+		{EmployeeID: 3, Salary: money("60"), Currency: "USD", EffectiveFrom: date(t, "2024-01-01")},
+		{EmployeeID: 3, Salary: money("100"), Currency: "USD", EffectiveFrom: date(t, "2024-08-01")},
+	}
+	rates := []models.ExchangeRate{{Currency: "EUR", Rate: models.MustParseRate("1.2"), EffectiveDate: date(t, "2024-01-01")}}
+	query := models.SalaryReportQuery{AsOf: date(t, "2024-07-01"), GroupBy: models.GroupByDepartment}
+
+	report, err := models.NewPayEquityReport(query, bands, employees, history, models.NewConverter("USD", query.AsOf, rates))
+	require.NoError(t, err)
+	assert.Equal(t, 4, report.Headcount)
+	assert.Equal(t, 1, report.Unbanded, "the manager")
+	assert.Equal(t, []models.PayEquityGroup{
+		{Key: "Ops", Headcount: 1, Unbanded: 1, BelowBand: 1, CompaRatio: &models.RatioStats{Min: 0.7, Median: 0.7, Mean: 0.7, Max: 0.7}},
+		{Key: "Product", Headcount: 3, BelowBand: 1, WithinBand: 1, AboveBand: 1,
+			CompaRatio: &models.RatioStats{Min: 0.5, Median: 1, Mean: 0.933, Max: 1.3}},
+	}, report.Groups)
+	require.Len(t, report.OutOfBand, 3)
+	assert.Equal(t, []int{4, 1, 3}, []int{report.OutOfBand[0].EmployeeID, report.OutOfBand[1].EmployeeID, report.OutOfBand[2].EmployeeID},
+		"by group and ID")
+	designer := report.OutOfBand[2]
+	assert.Equal(t, money("60"), designer.Salary, "the salary paid on the day")
+	assert.Equal(t, money("50"), designer.BandSalary, "exchanged to the currency of the band")
+	assert.Equal(t, models.BelowBand, designer.Standing)
+	assert.Equal(t, rates, report.Rates)
+}
@@ -0,0 +1,95 @@
+package models_test
+
+import (
+	"Techiebulter/interview/backend/models"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestTimesheetValidation(t *testing.T) {
+	timesheet := models.Timesheet{WeekStart: date(t, "2024-07-01"), Entries: models.TimesheetEntries{
+		{Date: date(t, "2024-07-01"), Project: " Apollo ", Hours: 6},
+		{Date: date(t, "2024-07-07"), Project: "Gemini", Hours: 2.5},
+	}}
+	require.Empty(t, timesheet.Validate())
+	assert.EqualValues(t, 8.5, timesheet.Hours)
+	assert.Equal(t, "Apollo", timesheet.Entries[0].Project)
+
+	tuesday := models.Timesheet{WeekStart: date(t, "2024-07-02"), Entries: models.TimesheetEntries{
+		{Date: date(t, "2024-07-02"), Project: "Apollo", Hours: 1},
+	}}
+	assert.Equal(t, map[string]string{"weekStart": models.CodeInvalidValue}, fieldCodes(tuesday.Validate()))
+	assert.Equal(t, map[string]string{"weekStart": models.CodeRequired, "entries": models.CodeRequired},
+		fieldCodes((&models.Timesheet{}).Validate()))
+
+	invalid := models.Timesheet{WeekStart: date(t, "2024-07-01"), Entries: models.TimesheetEntries{
+		{Date: date(t, "2024-07-08"), Project: "Apollo", Hours: 1},
+		{Date: date(t, "2024-07-02"), Hours: 25},
+	}}
+	assert.Equal(t, map[string]string{"entries[0].date": models.CodeOutOfRange, "entries[1].project": models.CodeRequired,
+		"entries[1].hours": models.CodeOutOfRange, "entries": models.CodeOutOfRange}, fieldCodes(invalid.Validate()))
+
+	long := models.Timesheet{WeekStart: date(t, "2024-07-01"), Entries: models.TimesheetEntries{
+		{Date: date(t, "2024-07-03"), Project: "Apollo", Hours: 16},
+		{Date: date(t, "2024-07-03"), Project: "Gemini", Hours: 9},
+	}}
+	assert.Equal(t, map[string]string{"entries": models.CodeOutOfRange}, fieldCodes(long.Validate()), "a day has 24 hours")
+}
+
+func TestWeekOf(t *testing.T) {
+	assert.Equal(t, date(t, "2024-07-01"), models.WeekOf(date(t, "2024-07-01")))
+	assert.Equal(t, date(t, "2024-07-01"), models.WeekOf(date(t, "2024-07-04")))
+	assert.Equal(t, date(t, "2024-07-01"), models.WeekOf(date(t, "2024-07-07")))
+}
+
+func TestOvertimeSplit(t *testing.T) {
+	rules := models.OvertimeRules{DailyHours: 8, WeeklyHours: 40, Multiplier: 1.5}
+	days := map[models.Date]float64{}
+	for day := 1; day <= 6; day++ {
+		days[models.Date{Time: date(t, "2024-07-01").AddDate(0, 0, day-1)}] = 8
+	}
+	days[date(t, "2024-07-01")] = 10
+
+	// two hours beyond the first day and the sixth day beyond the week
+	assert.Equal(t, models.WorkedHours{Hours: 50, Regular: 40, Overtime: 10, Payable: 55}, rules.Split(days))
+	assert.Equal(t, models.WorkedHours{Hours: 50, Regular: 48, Overtime: 2, Payable: 51},
+		models.OvertimeRules{DailyHours: 8, Multiplier: 1.5}.Split(days), "without a weekly limit")
+	assert.Equal(t, models.WorkedHours{Hours: 50, Regular: 50, Payable: 50}, models.OvertimeRules{Multiplier: 1}.Split(days))
+}
+
+func TestWeeklySummary(t *testing.T) {
+	week := date(t, "2024-07-01")
+	clockIn := time.Date(2024, 7, 2, 8, 0, 0, 0, time.UTC)
+	clockOut := clockIn.Add(10 * time.Hour)
+	employees := []models.Employee{
+		{ID: 1, Name: "Jane", Department: "Engineering", EmploymentType: models.EmploymentFullTime},
+		{ID: 2, Name: "John", Department: "Engineering", EmploymentType: models.EmploymentContractor},
+		{ID: 3, Name: "Joan"},
+	}
+	attendance := []models.AttendanceEntry{
+		{EmployeeID: 1, ClockIn: clockIn, ClockOut: &clockOut, Hours: 10},
+		{EmployeeID: 1, ClockIn: clockIn.Add(24 * time.Hour)},
+		{EmployeeID: 2, ClockIn: clockIn, ClockOut: &clockOut, Hours: 10},
+		{EmployeeID: 3, ClockIn: clockIn.AddDate(0, 0, 7), ClockOut: &clockOut, Hours: 10},
+	}
+	timesheets := []models.Timesheet{
+		{EmployeeID: 2, WeekStart: week, Status: models.TimesheetApproved, Entries: models.TimesheetEntries{
+			{Date: week, Project: "Apollo", Hours: 4}, {Date: date(t, "2024-07-02"), Project: "Gemini", Hours: 9}}},
+		{EmployeeID: 3, WeekStart: week, Status: models.TimesheetSubmitted, Entries: models.TimesheetEntries{
+			{Date: week, Project: "Apollo", Hours: 4}}},
+	}
+
+	summary := models.NewWeeklySummary(week, models.OvertimeRules{DailyHours: 8, Multiplier: 2}, employees, attendance, timesheets)
+	require.Len(t, summary.Employees, 2, "Joan has neither an approved timesheet nor attendance in the week")
+	assert.Equal(t, models.HoursFromAttendance, summary.Employees[0].Source)
+	assert.Equal(t, models.WorkedHours{Hours: 10, Regular: 8, Overtime: 2, Payable: 12}, summary.Employees[0].WorkedHours,
+		"the open entry does not count")
+	assert.Equal(t, models.HoursFromTimesheet, summary.Employees[1].Source, "the timesheet replaces the attendance")
+	assert.Equal(t, map[string]float64{"Apollo": 4, "Gemini": 9}, summary.Employees[1].Projects)
+	assert.Equal(t, models.WorkedHours{Hours: 13, Regular: 12, Overtime: 1, Payable: 14}, summary.Employees[1].WorkedHours)
+	assert.Equal(t, []models.DepartmentWeek{{Department: "Engineering", Employees: 2,
+		WorkedHours: models.WorkedHours{Hours: 23, Regular: 20, Overtime: 3, Payable: 26}}}, summary.Departments)
+}
@@ -0,0 +1,89 @@
+package models_test
+
+import (
+	"Techiebulter/interview/backend/models"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// fieldCodes reduces errs to field -> code for comparisons
+func fieldCodes(errs models.ValidationErrors) map[string]string {
+	codes := map[string]string{}
+	for _, fieldErr := range errs {
+		codes[fieldErr.Field] = fieldErr.Code
+	}
+	return codes
+}
+
+func TestEmployeeCreateRules(t *testing.T) {
+	t.Run("Valid", func(t *testing.T) {
+		emp := models.Employee{Name: " Jane ", Position: "Engineer", Salary: money("1000"), Currency: " eur", Email: " Jane@Example.com "}
+		assert.Empty(t, emp.Validate(models.EmployeeCreateRules))
+		assert.Equal(t, "Jane", emp.Name)
+		assert.Equal(t, "jane@example.com", emp.Email)
+		assert.Equal(t, models.Currency("EUR"), emp.Currency)
+	})
+
+	t.Run("ReportsEveryField", func(t *testing.T) {
+		hire := models.NewDate(time.Date(2022, time.May, 1, 0, 0, 0, 0, time.UTC))
+		termination := models.NewDate(time.Date(2022, time.April, 1, 0, 0, 0, 0, time.UTC))
+		emp := models.Employee{
+			Position:        strings.Repeat("p", models.MaxTextLength+1),
+			Salary:          models.MoneyFromCents(models.MaxSalary * 100),
+			Currency:        "EURO",
+			Email:           "not-an-email",
+			EmploymentType:  "intern",
+			HireDate:        &hire,
+			TerminationDate: &termination,
+		}
+		assert.Equal(t, map[string]string{
+			"Name":            models.CodeRequired,
+			"position":        models.CodeTooLong,
+			"Salary":          models.CodeOutOfRange,
+			"currency":        models.CodeInvalidValue,
+			"email":           models.CodeInvalidFormat,
+			"employmentType":  models.CodeInvalidValue,
+			"terminationDate": models.CodeOutOfRange,
+		}, fieldCodes(emp.Validate(models.EmployeeCreateRules)))
+	})
+}
+
+func TestEmployeeUpdateRules(t *testing.T) {
+	emp := models.Employee{ID: 1, Salary: money("10")}
+	assert.Empty(t, emp.Validate(models.EmployeeUpdateRules), "only set fields are checked")
+
+	emp = models.Employee{Salary: money("-5")}
+	assert.Equal(t, map[string]string{"ID": models.CodeRequired, "Salary": models.CodeOutOfRange},
+		fieldCodes(emp.Validate(models.EmployeeUpdateRules)))
+}
+
+func TestEmployeePatchRules(t *testing.T) {
+	emp := models.Employee{ID: 1, Position: "Engineer", Salary: money("10"), Currency: models.DefaultCurrency}
+	assert.Equal(t, map[string]string{"Name": models.CodeRequired}, fieldCodes(emp.Validate(models.EmployeePatchRules)),
+		"a patch must not remove required fields")
+}
+
+func TestValidateCustomFields(t *testing.T) {
+	definitions := []models.CustomFieldDefinition{
+		{Name: "team", Type: models.CustomFieldEnum, Options: models.StringList{"a", "b"}, Required: true},
+		{Name: "remote", Type: models.CustomFieldBoolean},
+		{Name: "started", Type: models.CustomFieldDate},
+	}
+
+	assert.Empty(t, models.ValidateCustomFields(definitions, models.CustomFields{"team": "a", "started": "2024-02-29"}))
+	assert.Equal(t, map[string]string{
+		"customFields.team":    models.CodeRequired,
+		"customFields.remote":  models.CodeInvalidType,
+		"customFields.started": models.CodeInvalidFormat,
+		"customFields.other":   models.CodeUnknownField,
+	}, fieldCodes(models.ValidateCustomFields(definitions, models.CustomFields{"remote": "yes", "started": "2023-02-29", "other": 1})))
+}
+
+func TestCustomFieldDefinitionRules(t *testing.T) {
+	definition := models.CustomFieldDefinition{Name: "Team", Type: models.CustomFieldString, Options: models.StringList{"a"}}
+	assert.Equal(t, map[string]string{"name": models.CodeInvalidFormat, "options": models.CodeInvalidValue},
+		fieldCodes(definition.Validate()))
+}
@@ -0,0 +1,171 @@
+package outbox_test
+
+import (
+	"Techiebulter/interview/backend/config"
+	"Techiebulter/interview/backend/models"
+	"Techiebulter/interview/backend/server"
+	"Techiebulter/interview/backend/test/internal/apitest"
+	"Techiebulter/interview/backend/utils/outbox"
+	"bufio"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// relay is a running server publishing to a file and the NATS and Kafka fakes
+type relay struct {
+	srv   *server.Server
+	file  string
+	nats  *outbox.FakeNATS
+	kafka *outbox.FakeKafka
+}
+
+// serve starts the server with fast polls and retries
+func serve(t *testing.T) relay {
+	t.Helper()
+	r := relay{file: filepath.Join(t.TempDir(), "events.jsonl"), nats: &outbox.FakeNATS{}, kafka: &outbox.FakeKafka{}}
+
+	r.srv = apitest.NewServer(t, func(cfg *config.Config) {
+		cfg.Server.Port = "0"
+		cfg.GRPC.Enabled = false
+		cfg.Outbox.Sinks = []string{config.SinkWebhooks, config.SinkFile}
+		cfg.Outbox.File = r.file
+		cfg.Outbox.PollInterval = 10 * time.Millisecond
+		cfg.Outbox.BackoffInitial = 10 * time.Millisecond
+		cfg.Outbox.BackoffMax = 20 * time.Millisecond
+	})
+	r.srv.Sinks = append(r.srv.Sinks, outbox.NATSSink{Conn: r.nats, Prefix: "employees"}, outbox.KafkaSink{Producer: r.kafka, Topic: "employees"})
+
+	go func() { _ = r.srv.Start() }()
+	t.Cleanup(func() { _ = r.srv.Stop() })
+	return r
+}
+
+// create adds an employee and returns its ID
+func create(t *testing.T, srv *server.Server) int {
+	t.Helper()
+	apitest.Call(t, srv.Handler, "", fiber.MethodPost, "/api/CreateEmpolyee", `{"Name":"John Doe","position":"Engineer","Salary":5000}`, fiber.StatusOK)
+	employees := apitest.Call(t, srv.Handler, "", fiber.MethodGet, "/api/GetAllEmployees/1/100", "", fiber.StatusOK)["employees"].([]interface{})
+	require.NotEmpty(t, employees)
+	return int(employees[len(employees)-1].(map[string]interface{})["ID"].(float64))
+}
+
+// fileEvents reads the events the file sink wrote so far
+func fileEvents(t *testing.T, path string) []models.EmployeeEvent {
+	t.Helper()
+	file, err := os.Open(path)
+	if os.IsNotExist(err) {
+		return nil
+	}
+	require.NoError(t, err)
+	defer file.Close()
+
+	var events []models.EmployeeEvent
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		var event models.EmployeeEvent
+		require.NoError(t, json.Unmarshal(scanner.Bytes(), &event))
+		events = append(events, event)
+	}
+	require.NoError(t, scanner.Err())
+	return events
+}
+
+// kafkaEvents decodes the records produced to the Kafka fake and checks they are keyed by employee
+func kafkaEvents(t *testing.T, kafka *outbox.FakeKafka) []models.EmployeeEvent {
+	t.Helper()
+	var events []models.EmployeeEvent
+	for _, record := range kafka.Records() {
+		var event models.EmployeeEvent
+		require.NoError(t, json.Unmarshal(record.Value, &event))
+		assert.Equal(t, "employees", record.Topic)
+		assert.Equal(t, fmt.Sprint(event.EmployeeID), string(record.Key))
+		events = append(events, event)
+	}
+	return events
+}
+
+// types returns the event types of an employee, in the order they were published
+func types(events []models.EmployeeEvent, employeeID int) []models.EmployeeEventType {
+	var types []models.EmployeeEventType
+	for _, event := range events {
+		if event.EmployeeID == employeeID {
+			types = append(types, event.Type)
+		}
+	}
+	return types
+}
+
+func metrics(t *testing.T, srv *server.Server) models.OutboxMetrics {
+	t.Helper()
+	body := apitest.Call(t, srv.Handler, "", fiber.MethodGet, "/api/v1/outbox", "", fiber.StatusOK)
+	data, err := json.Marshal(body["outbox"])
+	require.NoError(t, err)
+	var metrics models.OutboxMetrics
+	require.NoError(t, json.Unmarshal(data, &metrics))
+	return metrics
+}
+
+func TestEventsArePublishedInOrderToEverySink(t *testing.T) {
+	r := serve(t)
+
+	id := create(t, r.srv)
+	apitest.Call(t, r.srv.Handler, "", fiber.MethodPut, "/api/UpdateEmployee", fmt.Sprintf(`{"ID":%d,"Salary":5500}`, id), fiber.StatusOK)
+	apitest.Call(t, r.srv.Handler, "", fiber.MethodDelete, fmt.Sprintf("/api/DeleteEmployee/%d", id), "", fiber.StatusOK)
+
+	want := []models.EmployeeEventType{models.EmployeeCreated, models.EmployeeUpdated, models.EmployeeDeleted}
+	require.Eventually(t, func() bool {
+		return len(types(kafkaEvents(t, r.kafka), id)) == len(want)
+	}, 5*time.Second, 10*time.Millisecond)
+
+	assert.Equal(t, want, types(kafkaEvents(t, r.kafka), id))
+	assert.Equal(t, want, types(fileEvents(t, r.file), id))
+	var subjects []string
+	for _, message := range r.nats.Messages() {
+		subjects = append(subjects, message.Subject)
+	}
+	assert.Equal(t, []string{"employees.created", "employees.updated", "employees.deleted"}, subjects)
+
+	m := metrics(t, r.srv)
+	assert.Equal(t, 0, m.Backlog.Pending)
+	assert.EqualValues(t, 3, m.Published)
+	for _, sink := range []string{"webhooks", "file", "nats", "kafka"} {
+		assert.EqualValues(t, 3, m.Sinks[sink].Published, sink)
+	}
+}
+
+func TestFailingSinkIsRetriedUntilItRecovers(t *testing.T) {
+	r := serve(t)
+	r.kafka.Fail(errors.New("broker unavailable"))
+
+	id := create(t, r.srv)
+	apitest.Call(t, r.srv.Handler, "", fiber.MethodPut, "/api/UpdateEmployee", fmt.Sprintf(`{"ID":%d,"Salary":5500}`, id), fiber.StatusOK)
+
+	// the creation is retried and the update waits behind it
+	require.Eventually(t, func() bool { return metrics(t, r.srv).Sinks["kafka"].Failed >= 3 }, 5*time.Second, 10*time.Millisecond)
+	m := metrics(t, r.srv)
+	assert.Equal(t, 2, m.Backlog.Pending)
+	assert.Equal(t, 1, m.Backlog.Failing)
+	assert.GreaterOrEqual(t, m.Retried, int64(3))
+	assert.Equal(t, "broker unavailable", m.Sinks["kafka"].LastError)
+	assert.Empty(t, kafkaEvents(t, r.kafka))
+	for _, event := range fileEvents(t, r.file) {
+		assert.Equal(t, models.EmployeeCreated, event.Type, "the update was published before the creation")
+	}
+
+	// once the broker is back both go out in order, the sinks before it saw the creation more than once
+	r.kafka.Fail(nil)
+	require.Eventually(t, func() bool { return metrics(t, r.srv).Backlog.Pending == 0 }, 5*time.Second, 10*time.Millisecond)
+	assert.Equal(t, []models.EmployeeEventType{models.EmployeeCreated, models.EmployeeUpdated}, types(kafkaEvents(t, r.kafka), id))
+	published := types(fileEvents(t, r.file), id)
+	assert.Greater(t, len(published), 2)
+	assert.Equal(t, models.EmployeeUpdated, published[len(published)-1])
+}
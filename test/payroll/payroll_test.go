@@ -0,0 +1,205 @@
+package payroll_test
+
+import (
+	"Techiebulter/interview/backend/config"
+	"Techiebulter/interview/backend/models"
+	"Techiebulter/interview/backend/test/internal/apitest"
+	"Techiebulter/interview/backend/utils/payslip"
+	"bytes"
+	"encoding/json"
+	"image"
+	"image/color"
+	"image/draw"
+	"image/png"
+	"io"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// newApp pays annual salaries less a 10% tax
+func newApp(t *testing.T, configure ...func(cfg *config.Config)) *fiber.App {
+	t.Helper()
+	payroll := func(cfg *config.Config) {
+		cfg.Payroll.SalaryBasis = models.SalaryAnnual
+		cfg.Payroll.Components = []config.PayComponent{{Name: "tax", Kind: models.Deduction, Percent: 10}}
+	}
+	return apitest.NewApp(t, append([]func(cfg *config.Config){payroll}, configure...)...)
+}
+
+func TestPayrollWorkflow(t *testing.T) {
+	app := newApp(t)
+	apitest.Call(t, app, "hr-key", "POST", "/api/CreateEmpolyee", `{"Name":"John Doe","position":"Engineer","Salary":37200,"department":"Engineering","hireDate":"2024-07-11"}`, 200)
+	apitest.Call(t, app, "hr-key", "POST", "/api/CreateEmpolyee", `{"Name":"Jane Roe","position":"QA","Salary":20,"department":"Quality","employmentType":"contractor","hireDate":"2024-01-01"}`, 200)
+	apitest.Call(t, app, "colleague-key", "POST", "/api/v1/employees/2/timesheets", `{"weekStart":"2024-07-01","entries":[{"date":"2024-07-01","project":"Apollo","hours":10}]}`, 200)
+	apitest.Call(t, app, "hr-key", "POST", "/api/v1/timesheets/1/approve", "", 200)
+
+	history := apitest.Call(t, app, "employee-key", "GET", "/api/v1/employees/1/salary-history", "", 200)["history"].([]interface{})
+	require.Len(t, history, 1)
+	assert.Equal(t, "2024-07-11", history[0].(map[string]interface{})["effectiveFrom"])
+	apitest.Call(t, app, "colleague-key", "GET", "/api/v1/employees/1/salary-history", "", 403)
+
+	// a preview is not stored, only HR runs payroll
+	apitest.Call(t, app, "employee-key", "POST", "/api/v1/payroll/runs/preview", `{"period":"2024-07"}`, 403)
+	preview := apitest.Call(t, app, "hr-key", "POST", "/api/v1/payroll/runs/preview", `{"period":"2024-07"}`, 200)["run"].(map[string]interface{})
+	assert.Len(t, preview["payslips"], 2)
+	assert.Empty(t, apitest.Call(t, app, "hr-key", "GET", "/api/v1/payroll/runs", "", 200)["runs"])
+
+	run := apitest.Call(t, app, "hr-key", "POST", "/api/v1/payroll/runs", `{"period":"2024-07"}`, 200)["run"].(map[string]interface{})
+	assert.Equal(t, "draft", run["status"])
+	assert.EqualValues(t, 2100+220, run["gross"], "John from the 11th, Jane's approved hours with one overtime hour")
+	assert.EqualValues(t, 232, run["deductions"])
+	run = apitest.Call(t, app, "hr-key", "POST", "/api/v1/payroll/runs", `{"period":"2024-07"}`, 200)["run"].(map[string]interface{})
+	assert.EqualValues(t, 2, run["id"], "a draft is replaced")
+	assert.Empty(t, apitest.Call(t, app, "employee-key", "GET", "/api/v1/employees/1/payslips", "", 200)["payslips"], "drafts are not shown to employees")
+
+	apitest.Call(t, app, "hr-key", "POST", "/api/v1/payroll/runs/1/approve", "", 404)
+	approved := apitest.Call(t, app, "hr-key", "POST", "/api/v1/payroll/runs/2/approve", "", 200)["run"].(map[string]interface{})
+	assert.Equal(t, "approved", approved["status"])
+	assert.Equal(t, "hr:0", approved["approvedBy"])
+	apitest.Call(t, app, "hr-key", "POST", "/api/v1/payroll/runs/2/approve", "", 409)
+	apitest.Call(t, app, "hr-key", "POST", "/api/v1/payroll/runs", `{"period":"2024-07"}`, 409)
+
+	payslips := apitest.Call(t, app, "employee-key", "GET", "/api/v1/employees/1/payslips", "", 200)["payslips"].([]interface{})
+	require.Len(t, payslips, 1)
+	payslip := payslips[0].(map[string]interface{})
+	assert.Equal(t, "2024-07", payslip["period"])
+	assert.EqualValues(t, 1890, payslip["net"])
+	apitest.Call(t, app, "colleague-key", "GET", "/api/v1/employees/1/payslips", "", 403)
+
+	// the payslip is downloaded as a PDF by the employee and HR
+	apitest.Call(t, app, "colleague-key", "GET", "/api/v1/employees/1/payslips/2024-07.pdf", "", 403)
+	apitest.Call(t, app, "employee-key", "GET", "/api/v1/employees/1/payslips/2024-08.pdf", "", 404)
+	for _, key := range []string{"employee-key", "hr-key"} {
+		req := httptest.NewRequest("GET", "/api/v1/employees/1/payslips/2024-07.pdf", nil)
+		req.Header.Set("X-API-Key", key)
+		resp, err := app.Test(req)
+		require.NoError(t, err)
+		require.Equal(t, 200, resp.StatusCode)
+		assert.Equal(t, "application/pdf", resp.Header.Get(fiber.HeaderContentType))
+		assert.Equal(t, `attachment; filename="payslip-1-2024-07.pdf"`, resp.Header.Get(fiber.HeaderContentDisposition))
+		document, err := io.ReadAll(resp.Body)
+		require.NoError(t, err)
+		assert.True(t, bytes.HasPrefix(document, []byte("%PDF-")))
+	}
+}
+
+func TestCurrencyWorkflow(t *testing.T) {
+	app := newApp(t)
+	apitest.Call(t, app, "hr-key", "POST", "/api/CreateEmpolyee", `{"Name":"John Doe","position":"Engineer","Salary":60000,"currency":"eur","department":"Engineering","hireDate":"2024-01-01"}`, 200)
+	apitest.Call(t, app, "hr-key", "POST", "/api/CreateEmpolyee", `{"Name":"Jane Roe","position":"Sales Lead","Salary":30000,"department":"Sales","hireDate":"2024-01-01"}`, 200)
+	apitest.Call(t, app, "hr-key", "GET", "/api/v1/reports/salaries?asOf=2024-07-01", "", 409)
+
+	upload := func(body string, status int) {
+		req := httptest.NewRequest("POST", "/api/v1/exchange-rates/csv", strings.NewReader(body))
+		req.Header.Set(fiber.HeaderContentType, "text/csv")
+		req.Header.Set("X-API-Key", "hr-key")
+		resp, err := app.Test(req)
+		require.NoError(t, err)
+		require.Equal(t, status, resp.StatusCode)
+	}
+	upload("currency,rate,effectiveDate\nEUR,1.1,2024-01-01\nGBP,0,2024-01-01\n", 422)
+	assert.Empty(t, apitest.Call(t, app, "hr-key", "GET", "/api/v1/exchange-rates", "", 200)["rates"], "nothing is stored from an invalid upload")
+	upload("currency,rate,effectiveDate\nEUR,1.1,2024-01-01\n", 200)
+	apitest.Call(t, app, "employee-key", "POST", "/api/v1/exchange-rates", `{"currency":"EUR","rate":1.2,"effectiveDate":"2024-07-01"}`, 403)
+	apitest.Call(t, app, "hr-key", "POST", "/api/v1/exchange-rates", `{"currency":"EUR","rate":1.2,"effectiveDate":"2024-07-01"}`, 200)
+	rates := apitest.Call(t, app, "hr-key", "GET", "/api/v1/exchange-rates?currency=EUR&until=2024-06-30", "", 200)["rates"].([]interface{})
+	require.Len(t, rates, 1)
+	assert.EqualValues(t, 1.1, rates[0].(map[string]interface{})["rate"])
+
+	report := apitest.Call(t, app, "hr-key", "GET", "/api/v1/reports/salaries?asOf=2024-07-01", "", 200)["report"].(map[string]interface{})
+	assert.Equal(t, "USD", report["currency"])
+	assert.EqualValues(t, 72000+30000, report["total"], "euros at the rate of the day")
+	assert.Len(t, report["groups"], 2)
+	report = apitest.Call(t, app, "hr-key", "GET", "/api/v1/reports/salaries?asOf=2024-06-30&groupBy=location&currency=EUR", "", 200)["report"].(map[string]interface{})
+	assert.EqualValues(t, 60000+27272.73, report["total"])
+	assert.Len(t, report["groups"], 1)
+	apitest.Call(t, app, "employee-key", "GET", "/api/v1/reports/salaries", "", 403)
+}
+
+func TestPatchClearedCurrency(t *testing.T) {
+	app := newApp(t, func(cfg *config.Config) { cfg.Currency.Base = "EUR" })
+	apitest.Call(t, app, "hr-key", "POST", "/api/CreateEmpolyee", `{"Name":"John Doe","position":"Engineer","Salary":60000,"currency":"GBP"}`, 200)
+
+	req := httptest.NewRequest("PATCH", "/api/v1/employees/1", strings.NewReader(`{"currency":null}`))
+	req.Header.Set(fiber.HeaderContentType, "application/merge-patch+json")
+	req.Header.Set("X-API-Key", "hr-key")
+	resp, err := app.Test(req)
+	require.NoError(t, err)
+	require.Equal(t, fiber.StatusOK, resp.StatusCode)
+	var body map[string]interface{}
+	require.NoError(t, json.NewDecoder(resp.Body).Decode(&body))
+	assert.Equal(t, "EUR", body["updatedEmployeeDetails"].(map[string]interface{})["currency"], "the base currency, not USD")
+}
+
+func TestSalaryBands(t *testing.T) {
+	app := newApp(t)
+	apitest.Call(t, app, "hr-key", "POST", "/api/v1/exchange-rates", `{"currency":"EUR","rate":1.1,"effectiveDate":"2024-01-01"}`, 200)
+	apitest.Call(t, app, "employee-key", "POST", "/api/v1/salary-bands", `{"position":"Engineer","currency":"EUR","min":50000,"mid":60000,"max":70000}`, 403)
+	apitest.Call(t, app, "hr-key", "POST", "/api/v1/salary-bands", `{"position":"Engineer","currency":"EUR","min":50000,"mid":60000,"max":70000,"enforcement":"reject"}`, 200)
+	apitest.Call(t, app, "hr-key", "POST", "/api/v1/salary-bands", `{"position":"sales  lead","currency":"USD","min":40000,"mid":50000,"max":60000}`, 200)
+
+	errs := apitest.Call(t, app, "hr-key", "POST", "/api/CreateEmpolyee", `{"Name":"John Doe","position":"engineer","Salary":100000}`, 422)["errors"].([]interface{})
+	assert.Equal(t, "Salary must be between 50000.00 and 70000.00 EUR, the band of Engineer", errs[0].(map[string]interface{})["message"],
+		"dollars are exchanged to euros")
+	assert.NotContains(t, apitest.Call(t, app, "hr-key", "POST", "/api/CreateEmpolyee", `{"Name":"John Doe","position":"Engineer","Salary":60000,"currency":"EUR"}`, 200), "warnings")
+	warnings := apitest.Call(t, app, "hr-key", "POST", "/api/CreateEmpolyee", `{"Name":"Jane Roe","position":"Sales Lead","Salary":30000,"department":"Sales"}`, 200)["warnings"]
+	assert.Len(t, warnings, 1, "the band of sales leads only warns")
+	assert.NotContains(t, apitest.Call(t, app, "hr-key", "PUT", "/api/UpdateEmployee", `{"ID":2,"phone":"555-0100"}`, 200), "warnings",
+		"the salary is only checked when it or the position changes")
+	patch := func(body string, status int) {
+		req := httptest.NewRequest("PATCH", "/api/v1/employees/1", strings.NewReader(body))
+		req.Header.Set(fiber.HeaderContentType, "application/merge-patch+json")
+		req.Header.Set("X-API-Key", "hr-key")
+		resp, err := app.Test(req)
+		require.NoError(t, err)
+		require.Equal(t, status, resp.StatusCode)
+	}
+	patch(`{"Salary":80000}`, 422)
+	patch(`{"Salary":65000}`, 200)
+
+	report := apitest.Call(t, app, "hr-key", "GET", "/api/v1/reports/pay-equity?groupBy=department", "", 200)["report"].(map[string]interface{})
+	assert.EqualValues(t, 2, report["headcount"])
+	outOfBand := report["outOfBand"].([]interface{})
+	require.Len(t, outOfBand, 1)
+	assert.EqualValues(t, 2, outOfBand[0].(map[string]interface{})["employeeId"])
+	assert.EqualValues(t, 0.6, outOfBand[0].(map[string]interface{})["compaRatio"])
+	assert.Equal(t, "below", outOfBand[0].(map[string]interface{})["standing"])
+	groups := report["groups"].([]interface{})
+	require.Len(t, groups, 2)
+	assert.EqualValues(t, 1, groups[1].(map[string]interface{})["belowBand"], "sales")
+	apitest.Call(t, app, "employee-key", "GET", "/api/v1/reports/pay-equity", "", 403)
+}
+
+func TestRenderPayslip(t *testing.T) {
+	logo := filepath.Join(t.TempDir(), "logo.png")
+	img := image.NewRGBA(image.Rect(0, 0, 40, 20))
+	draw.Draw(img, img.Bounds(), &image.Uniform{C: color.RGBA{R: 31, G: 78, B: 121, A: 255}}, image.Point{}, draw.Src)
+	file, err := os.Create(logo)
+	require.NoError(t, err)
+	require.NoError(t, png.Encode(file, img))
+	require.NoError(t, file.Close())
+
+	period, money := models.PayPeriod("2024-07"), models.MustParseMoney
+	slip := models.Payslip{Period: period, EmployeeID: 7, Name: "Zoë Müller", Position: "Engineer", Department: "Engineering",
+		EmploymentType: models.EmploymentFullTime, Currency: "EUR", BasePay: money("1234567.5"), Gross: money("1234667.5"),
+		Deductions: money("10"), Net: money("1234657.5"),
+		Segments: models.PaySegments{{From: period.Start(), To: period.End(), Salary: money("1234567.5"), Currency: "EUR", Days: 31,
+			Amount: money("1234567.5")}},
+		Lines: models.PayslipLines{{Name: "meal", Kind: models.Allowance, Amount: money("100")},
+			{Name: "tax", Kind: models.Deduction, Amount: money("10")}}}
+	branding := payslip.Branding{CompanyName: "Techiebulter", CompanyAddress: "1 Main Street\nBerlin", Logo: logo, Color: "#1f4e79"}
+
+	var document bytes.Buffer
+	require.NoError(t, payslip.Render(&document, slip, branding))
+	assert.True(t, bytes.HasPrefix(document.Bytes(), []byte("%PDF-")))
+
+	branding.Logo = filepath.Join(t.TempDir(), "missing.png")
+	assert.Error(t, payslip.Render(&bytes.Buffer{}, slip, branding), "a missing logo fails the document")
+}
@@ -0,0 +1,130 @@
+package replicas_test
+
+import (
+	"Techiebulter/interview/backend/config"
+	"Techiebulter/interview/backend/models"
+	"Techiebulter/interview/backend/providers"
+	"Techiebulter/interview/backend/providers/dbProvider"
+	"Techiebulter/interview/backend/server"
+	"Techiebulter/interview/backend/test/internal/apitest"
+	"Techiebulter/interview/backend/utils"
+	"database/sql"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// unreachableURL points at a port nothing listens on
+const unreachableURL = "postgres://postgres@127.0.0.1:1/employees?sslmode=disable"
+
+func connect(t *testing.T, url string, replicaURLs ...string) (providers.PgClientProvider, error) {
+	t.Helper()
+	cfg := config.Default().Database
+	cfg.URL = url
+	cfg.ReplicaURLs = replicaURLs
+	cfg.ConnectAttempts = 1
+	cfg.ConnectTimeout = time.Second
+	client, err := dbProvider.ConnectDB(cfg)
+	require.NotNil(t, client)
+	t.Cleanup(func() { _ = client.Close() })
+	return client, err
+}
+
+func TestReadsFallBackToThePrimary(t *testing.T) {
+	client, err := connect(t, unreachableURL)
+	require.Error(t, err)
+	assert.Same(t, client.Client(), client.Reader(), "without replicas the primary serves reads")
+
+	client, err = connect(t, unreachableURL, unreachableURL, unreachableURL)
+	require.Error(t, err)
+	for i := 0; i < 4; i++ {
+		assert.Same(t, client.Client(), client.Reader(), "unreachable replicas are skipped")
+	}
+	for _, replica := range client.Replicas() {
+		assert.False(t, replica.Healthy, replica.Name)
+	}
+}
+
+// TestReadsRoundRobin runs against the database in PGSQL_URL and is skipped without one
+func TestReadsRoundRobin(t *testing.T) {
+	url := utils.GetPGSQLConnectionString()
+	if url == "" {
+		t.Skip("PGSQL_URL is not set")
+	}
+	// the primary counts as a replica without lag
+	client, err := connect(t, url, url, url)
+	require.NoError(t, err)
+	first, second := client.Reader(), client.Reader()
+	assert.NotSame(t, client.Client(), first)
+	assert.NotSame(t, client.Client(), second)
+	assert.NotSame(t, first, second, "consecutive reads go to different replicas")
+	assert.Same(t, first, client.Reader())
+
+	client, err = connect(t, url, url, unreachableURL, url)
+	require.NoError(t, err)
+	readers := map[*sql.DB]bool{}
+	for i := 0; i < 6; i++ {
+		readers[client.Reader()] = true
+	}
+	assert.Len(t, readers, 2, "the unreachable replica is skipped")
+	assert.False(t, readers[client.Client()])
+}
+
+// readHelper serves reads as if from a replica, ReadFromPrimary returns a helper counting its reads
+type readHelper struct {
+	providers.DbHelperProvider
+	primaryReads *atomic.Int32
+}
+
+func (h readHelper) ReadFromPrimary() providers.DbHelperProvider {
+	return primaryHelper{h}
+}
+
+type primaryHelper struct {
+	readHelper
+}
+
+func (h primaryHelper) GetEmployeeById(id int) (models.Employee, error) {
+	h.primaryReads.Add(1)
+	return h.DbHelperProvider.GetEmployeeById(id)
+}
+
+func TestReadYourWrites(t *testing.T) {
+	cfg := apitest.Config(t)
+	client, dbHelper := apitest.Open(t, cfg)
+	var primaryReads atomic.Int32
+	app := server.New(cfg, client, readHelper{DbHelperProvider: dbHelper, primaryReads: &primaryReads}).Handler
+
+	// send returns the X-Session-Last-Write header of the response
+	send := func(method, path, body, lastWrite string) string {
+		req := httptest.NewRequest(method, path, strings.NewReader(body))
+		req.Header.Set(fiber.HeaderContentType, fiber.MIMEApplicationJSON)
+		if lastWrite != "" {
+			req.Header.Set("X-Session-Last-Write", lastWrite)
+		}
+		resp, err := app.Test(req)
+		require.NoError(t, err)
+		require.Equal(t, fiber.StatusOK, resp.StatusCode, "%s %s", method, path)
+		return resp.Header.Get("X-Session-Last-Write")
+	}
+
+	lastWrite := send("POST", "/api/CreateEmpolyee", `{"Name":"John Doe","position":"Engineer","Salary":5000}`, "")
+	written, err := time.Parse(time.RFC3339Nano, lastWrite)
+	require.NoError(t, err, "writes are stamped")
+	assert.WithinDuration(t, time.Now(), written, time.Minute)
+	assert.Empty(t, send("GET", "/api/GetEmployeeById/1", "", ""), "reads are not stamped")
+	assert.EqualValues(t, 0, primaryReads.Load(), "reads without the header may use a replica")
+
+	send("GET", "/api/GetEmployeeById/1", "", lastWrite)
+	assert.EqualValues(t, 1, primaryReads.Load(), "reads after a recent write go to the primary")
+
+	stale := written.Add(-2 * cfg.Database.MaxReplicaLag).Format(time.RFC3339Nano)
+	send("GET", "/api/GetEmployeeById/1", "", stale)
+	assert.EqualValues(t, 1, primaryReads.Load(), "the replicas have caught up with older writes")
+}
@@ -0,0 +1,63 @@
+package reports_test
+
+import (
+	"Techiebulter/interview/backend/config"
+	"Techiebulter/interview/backend/test/internal/apitest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCompensationReport(t *testing.T) {
+	app := apitest.NewApp(t, func(cfg *config.Config) {
+		cfg.Reports.CacheTTL = time.Hour
+		cfg.Reports.MinGroupSize = 2
+	})
+	apitest.Call(t, app, "hr-key", "POST", "/api/CreateEmpolyee", `{"Name":"John Doe","position":"Engineer","Salary":5000,"hireDate":"2020-01-06"}`, 200)
+	apitest.Call(t, app, "hr-key", "POST", "/api/CreateEmpolyee", `{"Name":"Jane Roe","position":"Engineer","Salary":7000,"hireDate":"2020-01-06"}`, 200)
+	apitest.Call(t, app, "hr-key", "POST", "/api/CreateEmpolyee", `{"Name":"Max Mustermann","position":"QA","Salary":4000,"hireDate":"2020-01-06"}`, 200)
+	apitest.Call(t, app, "employee-key", "GET", "/api/v1/reports/compensation", "", 403)
+
+	// the only QA is not shown on their own
+	report := apitest.Call(t, app, "hr-key", "GET", "/api/v1/reports/compensation", "", 200)["report"].(map[string]interface{})
+	assert.EqualValues(t, 2, report["minGroupSize"])
+	assert.EqualValues(t, 2, report["headcount"])
+	assert.EqualValues(t, 1, report["suppressedGroups"])
+	groups := report["groups"].([]interface{})
+	require.Len(t, groups, 1)
+	assert.Equal(t, "Engineer", groups[0].(map[string]interface{})["key"])
+	assert.EqualValues(t, 2, groups[0].(map[string]interface{})["headcount"])
+
+	// a second QA is not seen until the cached report expires, other queries are computed anew
+	apitest.Call(t, app, "hr-key", "POST", "/api/CreateEmpolyee", `{"Name":"Erika Mustermann","position":"QA","Salary":4500,"hireDate":"2020-01-06"}`, 200)
+	cached := apitest.Call(t, app, "hr-key", "GET", "/api/v1/reports/compensation", "", 200)["report"].(map[string]interface{})
+	assert.Equal(t, report, cached)
+	byLocation := apitest.Call(t, app, "hr-key", "GET", "/api/v1/reports/compensation?groupBy=location", "", 200)["report"].(map[string]interface{})
+	assert.EqualValues(t, 4, byLocation["headcount"])
+}
+
+func TestReportCacheExpiry(t *testing.T) {
+	app := apitest.NewApp(t, func(cfg *config.Config) {
+		cfg.Reports.CacheTTL = 50 * time.Millisecond
+		cfg.Reports.MinGroupSize = 1
+	})
+	const headcount = "/api/v1/reports/headcount?from=2024-01-01&to=2024-12-31&interval=year"
+	hires := func() interface{} {
+		points := apitest.Call(t, app, "hr-key", "GET", headcount, "", 200)["trend"].(map[string]interface{})["points"].([]interface{})
+		require.Len(t, points, 1)
+		return points[0].(map[string]interface{})["hires"]
+	}
+	apitest.Call(t, app, "hr-key", "POST", "/api/CreateEmpolyee", `{"Name":"John Doe","position":"Engineer","Salary":5000,"hireDate":"2024-03-01"}`, 200)
+	assert.EqualValues(t, 1, hires())
+
+	apitest.Call(t, app, "hr-key", "POST", "/api/CreateEmpolyee", `{"Name":"Jane Roe","position":"Engineer","Salary":7000,"hireDate":"2024-06-01"}`, 200)
+	assert.EqualValues(t, 1, hires(), "served from the cache")
+	time.Sleep(60 * time.Millisecond)
+	assert.EqualValues(t, 2, hires(), "computed again once expired")
+
+	report := apitest.Call(t, app, "hr-key", "GET", "/api/v1/reports/compensation", "", 200)["report"].(map[string]interface{})
+	assert.EqualValues(t, 0, report["suppressedGroups"], "a minimum of 1 shows every group")
+	assert.EqualValues(t, 2, report["headcount"])
+}
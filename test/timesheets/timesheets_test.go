@@ -0,0 +1,79 @@
+package timesheets_test
+
+import (
+	"Techiebulter/interview/backend/models"
+	"Techiebulter/interview/backend/test/internal/apitest"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestTimesheetWorkflow(t *testing.T) {
+	app := apitest.NewApp(t)
+	apitest.Call(t, app, "hr-key", "POST", "/api/CreateEmpolyee", `{"Name":"John Doe","position":"Engineer","Salary":5000,"department":"Engineering"}`, 200)
+	apitest.Call(t, app, "hr-key", "POST", "/api/CreateEmpolyee", `{"Name":"Jane Roe","position":"QA","Salary":4000,"department":"Quality","employmentType":"contractor"}`, 200)
+
+	// employees clock in and out themselves, HR may do it for them
+	apitest.Call(t, app, "colleague-key", "POST", "/api/v1/employees/1/clock-in", "", 403)
+	apitest.Call(t, app, "employee-key", "POST", "/api/v1/employees/1/clock-in", `{"note":"Office"}`, 200)
+	apitest.Call(t, app, "hr-key", "POST", "/api/v1/employees/1/clock-in", "", 409)
+	entry := apitest.Call(t, app, "employee-key", "POST", "/api/v1/employees/1/clock-out", "", 200)["attendance"].(map[string]interface{})
+	assert.NotNil(t, entry["clockOut"])
+	attendance := apitest.Call(t, app, "employee-key", "GET", "/api/v1/employees/1/attendance", "", 200)["attendance"].([]interface{})
+	assert.Len(t, attendance, 1)
+
+	week := models.WeekOf(models.NewDate(time.Now()))
+	timesheet := `{"weekStart":"` + week.String() + `","entries":[{"date":"` + week.String() + `","project":"Apollo","hours":10},` +
+		`{"date":"` + week.AddDate(0, 0, 1).Format(models.DateLayout) + `","project":"Gemini","hours":6}]}`
+	apitest.Call(t, app, "employee-key", "POST", "/api/v1/employees/2/timesheets", timesheet, 403)
+	submitted := apitest.Call(t, app, "colleague-key", "POST", "/api/v1/employees/2/timesheets", timesheet, 200)["timesheet"].(map[string]interface{})
+	assert.Equal(t, "submitted", submitted["status"])
+	assert.EqualValues(t, 16, submitted["hours"])
+
+	// employees do not approve timesheets, nor does HR its own
+	apitest.Call(t, app, "colleague-key", "POST", "/api/v1/timesheets/1/approve", "", 403)
+	own := apitest.Call(t, app, "hr-self-key", "POST", "/api/v1/employees/1/timesheets", timesheet, 200)["timesheet"].(map[string]interface{})
+	apitest.Call(t, app, "hr-self-key", "POST", fmt.Sprintf("/api/v1/timesheets/%v/approve", own["id"]), "", 403)
+	approved := apitest.Call(t, app, "hr-self-key", "POST", "/api/v1/timesheets/1/approve", "", 200)["timesheet"].(map[string]interface{})
+	assert.Equal(t, "approved", approved["status"])
+	assert.Equal(t, "hr:1", approved["decidedBy"])
+
+	// John's submitted timesheet is not approved, his attendance counts for him
+	apitest.Call(t, app, "employee-key", "GET", "/api/v1/reports/weekly-hours", "", 403)
+	summary := apitest.Call(t, app, "hr-key", "GET", "/api/v1/reports/weekly-hours", "", 200)["summary"].(map[string]interface{})
+	employees := summary["employees"].([]interface{})
+	require.Len(t, employees, 2)
+	jane := employees[1].(map[string]interface{})
+	assert.Equal(t, "timesheet", jane["source"])
+	assert.Equal(t, "Quality", jane["department"])
+	assert.EqualValues(t, 16, jane["hours"])
+	assert.EqualValues(t, 2, jane["overtime"])
+	assert.EqualValues(t, 17, jane["payable"])
+	assert.Equal(t, "attendance", employees[0].(map[string]interface{})["source"])
+	assert.Len(t, summary["departments"], 2)
+
+	quality := apitest.Call(t, app, "hr-key", "GET", "/api/v1/reports/weekly-hours?department=Quality&week="+week.String(), "", 200)["summary"].(map[string]interface{})
+	assert.Len(t, quality["employees"], 1)
+}
+
+func TestManagerDecidesTimesheets(t *testing.T) {
+	app := apitest.NewApp(t)
+	apitest.Call(t, app, "hr-key", "POST", "/api/CreateEmpolyee", `{"Name":"John Doe","position":"Lead","Salary":7000}`, 200)
+	apitest.Call(t, app, "hr-key", "POST", "/api/CreateEmpolyee", `{"Name":"Jane Roe","position":"QA","Salary":4000,"managerId":1}`, 200)
+	apitest.Call(t, app, "hr-key", "POST", "/api/CreateEmpolyee", `{"Name":"Max Mustermann","position":"Engineer","Salary":5000}`, 200)
+
+	week := models.WeekOf(models.NewDate(time.Now()))
+	timesheet := `{"weekStart":"` + week.String() + `","entries":[{"date":"` + week.String() + `","project":"Apollo","hours":8}]}`
+	apitest.Call(t, app, "colleague-key", "POST", "/api/v1/employees/2/timesheets", timesheet, 200)
+	apitest.Call(t, app, "hr-key", "POST", "/api/v1/employees/3/timesheets", timesheet, 200)
+
+	// John manages Jane but not Max
+	approved := apitest.Call(t, app, "employee-key", "POST", "/api/v1/timesheets/1/approve", "", 200)["timesheet"].(map[string]interface{})
+	assert.Equal(t, "employee:1", approved["decidedBy"])
+	apitest.Call(t, app, "employee-key", "POST", "/api/v1/timesheets/2/reject", "", 403)
+	apitest.Call(t, app, "colleague-key", "POST", "/api/v1/timesheets/2/reject", "", 403)
+	apitest.Call(t, app, "hr-key", "POST", "/api/v1/timesheets/2/reject", "", 200)
+}
@@ -0,0 +1,33 @@
+package utils_test
+
+import (
+	"Techiebulter/interview/backend/utils"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestBackoff(t *testing.T) {
+	const initial, max = 100 * time.Millisecond, time.Second
+	// the delay doubles per attempt up to max, the jitter picks a point in its upper half
+	for _, test := range []struct {
+		attempt int
+		delay   time.Duration
+	}{
+		{0, 100 * time.Millisecond},
+		{1, 200 * time.Millisecond},
+		{3, 800 * time.Millisecond},
+		{4, max},
+		{1000, max},
+	} {
+		seen := map[time.Duration]bool{}
+		for i := 0; i < 100; i++ {
+			wait := utils.Backoff(initial, max, test.attempt)
+			assert.GreaterOrEqual(t, wait, test.delay/2, "attempt %d", test.attempt)
+			assert.LessOrEqual(t, wait, test.delay, "attempt %d", test.attempt)
+			seen[wait] = true
+		}
+		assert.Greater(t, len(seen), 1, "attempt %d is jittered", test.attempt)
+	}
+}
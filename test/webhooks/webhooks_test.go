@@ -0,0 +1,139 @@
+package webhooks_test
+
+import (
+	"Techiebulter/interview/backend/config"
+	"Techiebulter/interview/backend/models"
+	"Techiebulter/interview/backend/server"
+	"Techiebulter/interview/backend/test/internal/apitest"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const secret = "0123456789abcdef"
+
+// receipt is a webhook request whose signature matched
+type receipt struct {
+	eventType string
+	event     models.EmployeeEvent
+}
+
+// receive answers webhooks with the status held by status, after checking their signature like a receiver should
+func receive(t *testing.T, status *atomic.Int32) (string, <-chan receipt) {
+	t.Helper()
+	receipts := make(chan receipt, 16)
+	receiver := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		mac := hmac.New(sha256.New, []byte(secret))
+		mac.Write([]byte(r.Header.Get("X-Webhook-Timestamp") + "."))
+		mac.Write(body)
+		if !hmac.Equal([]byte(r.Header.Get("X-Webhook-Signature")), []byte("sha256="+hex.EncodeToString(mac.Sum(nil)))) {
+			t.Errorf("webhook %s has an invalid signature", r.Header.Get("X-Webhook-ID"))
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		if code := int(status.Load()); code != http.StatusOK {
+			w.WriteHeader(code)
+			return
+		}
+		var event models.EmployeeEvent
+		assert.NoError(t, json.Unmarshal(body, &event))
+		receipts <- receipt{eventType: r.Header.Get("X-Webhook-Event"), event: event}
+	}))
+	t.Cleanup(receiver.Close)
+	return receiver.URL, receipts
+}
+
+// serve starts the server with fast retries, requests are sent to its handler
+func serve(t *testing.T) *server.Server {
+	t.Helper()
+	srv := apitest.NewServer(t, func(cfg *config.Config) {
+		cfg.Server.Port = "0"
+		cfg.GRPC.Enabled = false
+		cfg.Webhooks.MaxAttempts = 3
+		cfg.Webhooks.BackoffInitial = 10 * time.Millisecond
+		cfg.Webhooks.BackoffMax = 20 * time.Millisecond
+		cfg.Webhooks.PollInterval = 10 * time.Millisecond
+	})
+
+	go func() { _ = srv.Start() }()
+	t.Cleanup(func() { _ = srv.Stop() })
+	return srv
+}
+
+func next(t *testing.T, receipts <-chan receipt) receipt {
+	t.Helper()
+	select {
+	case r := <-receipts:
+		return r
+	case <-time.After(5 * time.Second):
+		t.Fatal("no webhook arrived")
+		return receipt{}
+	}
+}
+
+func TestWebhookDelivery(t *testing.T) {
+	var status atomic.Int32
+	status.Store(http.StatusOK)
+	url, receipts := receive(t, &status)
+	srv := serve(t)
+
+	created := apitest.Call(t, srv.Handler, "", fiber.MethodPost, "/api/v1/webhooks",
+		fmt.Sprintf(`{"url":%q,"eventTypes":["created","updated"],"secret":%q}`, url, secret), fiber.StatusOK)
+	webhook := created["webhook"].(map[string]interface{})
+	assert.NotContains(t, webhook, "secret", "the secret is write only")
+	id := int(webhook["id"].(float64))
+
+	apitest.Call(t, srv.Handler, "", fiber.MethodPost, "/api/CreateEmpolyee", `{"Name":"John Doe","position":"Engineer","Salary":5000}`, fiber.StatusOK)
+	r := next(t, receipts)
+	assert.Equal(t, "created", r.eventType)
+	assert.Equal(t, "John Doe", r.event.Employee.Name)
+
+	// a receiver that keeps failing has the delivery retried until it is dead-lettered
+	status.Store(http.StatusServiceUnavailable)
+	apitest.Call(t, srv.Handler, "", fiber.MethodPut, "/api/UpdateEmployee", fmt.Sprintf(`{"ID":%d,"Salary":5500}`, r.event.EmployeeID), fiber.StatusOK)
+	var dead map[string]interface{}
+	require.Eventually(t, func() bool {
+		deliveries := apitest.Call(t, srv.Handler, "", fiber.MethodGet, fmt.Sprintf("/api/v1/webhooks/%d/deliveries?status=dead", id), "", fiber.StatusOK)["deliveries"].([]interface{})
+		if len(deliveries) == 0 {
+			return false
+		}
+		dead = deliveries[0].(map[string]interface{})
+		return true
+	}, 5*time.Second, 10*time.Millisecond)
+	assert.Equal(t, "updated", dead["eventType"])
+	assert.Equal(t, 3.0, dead["attempts"])
+	assert.Equal(t, 503.0, dead["lastStatusCode"])
+
+	// once the receiver is fixed the dead delivery can be sent again
+	status.Store(http.StatusOK)
+	apitest.Call(t, srv.Handler, "", fiber.MethodPost, fmt.Sprintf("/api/v1/webhooks/%d/deliveries/%.0f/redeliver", id, dead["id"]), "", fiber.StatusAccepted)
+	r = next(t, receipts)
+	assert.Equal(t, "updated", r.eventType)
+	assert.Equal(t, models.MustParseMoney("5500"), r.event.Employee.Salary)
+
+	// deletions were not subscribed to
+	apitest.Call(t, srv.Handler, "", fiber.MethodDelete, fmt.Sprintf("/api/DeleteEmployee/%d", r.event.EmployeeID), "", fiber.StatusOK)
+	require.Eventually(t, func() bool {
+		deliveries := apitest.Call(t, srv.Handler, "", fiber.MethodGet, fmt.Sprintf("/api/v1/webhooks/%d/deliveries", id), "", fiber.StatusOK)["deliveries"].([]interface{})
+		for _, d := range deliveries {
+			if d.(map[string]interface{})["status"] != string(models.WebhookDelivered) {
+				return false
+			}
+		}
+		return len(deliveries) == 2
+	}, 5*time.Second, 10*time.Millisecond)
+	assert.Empty(t, receipts)
+}
@@ -0,0 +1,20 @@
+package utils
+
+import (
+	"math/rand"
+	"time"
+)
+
+// Backoff returns the delay before retry number attempt (starting at 0): the initial delay
+// doubled per attempt and capped at max, with jitter picking a point in its upper half.
+func Backoff(initial, max time.Duration, attempt int) time.Duration {
+	delay := initial
+	for i := 0; i < attempt && delay < max; i++ {
+		delay *= 2
+	}
+	if delay > max {
+		delay = max
+	}
+	half := delay / 2
+	return half + time.Duration(rand.Int63n(int64(half)+1))
+}
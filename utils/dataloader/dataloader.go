@@ -0,0 +1,76 @@
+// Package dataloader batches the lookups of one request into as few fetches as possible.
+// Load only records a key and returns a thunk. The first thunk called fetches every key recorded
+// until then in one batch, so resolvers that call Load for each item of a list and run the thunks
+// afterwards, as GraphQL executors do level by level, cause a single fetch instead of one per item.
+package dataloader
+
+import (
+	"fmt"
+	"sync"
+)
+
+// BatchFunc fetches the values of keys, keys it has no value or error for fail to load
+type BatchFunc[K comparable, V any] func(keys []K) map[K]Result[V]
+
+// Result is the outcome of loading one key
+type Result[V any] struct {
+	Value V
+	Err   error
+}
+
+// Loader caches the results of a BatchFunc, it is meant to live for a single request
+type Loader[K comparable, V any] struct {
+	fetch BatchFunc[K, V]
+
+	mu      sync.Mutex
+	pending []K
+	results map[K]*Result[V]
+}
+
+// New returns a loader fetching with fetch
+func New[K comparable, V any](fetch BatchFunc[K, V]) *Loader[K, V] {
+	return &Loader[K, V]{fetch: fetch, results: map[K]*Result[V]{}}
+}
+
+// Load schedules key for the next batch, unless it was loaded before, and returns a thunk that
+// waits for its result
+func (l *Loader[K, V]) Load(key K) func() (V, error) {
+	l.mu.Lock()
+	if _, ok := l.results[key]; !ok {
+		l.results[key] = nil
+		l.pending = append(l.pending, key)
+	}
+	l.mu.Unlock()
+
+	return func() (V, error) {
+		l.mu.Lock()
+		defer l.mu.Unlock()
+		if l.results[key] == nil {
+			l.dispatch()
+		}
+		result := l.results[key]
+		return result.Value, result.Err
+	}
+}
+
+// Prime stores value for key, for example after a write, so later loads do not fetch it
+func (l *Loader[K, V]) Prime(key K, value V) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.results[key] = &Result[V]{Value: value}
+}
+
+// dispatch fetches every pending key, l.mu must be held
+func (l *Loader[K, V]) dispatch() {
+	keys := l.pending
+	l.pending = nil
+
+	fetched := l.fetch(keys)
+	for _, key := range keys {
+		if result, ok := fetched[key]; ok {
+			l.results[key] = &result
+		} else {
+			l.results[key] = &Result[V]{Err: fmt.Errorf("dataloader: no result for key %v", key)}
+		}
+	}
+}
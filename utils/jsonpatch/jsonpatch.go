@@ -0,0 +1,391 @@
+// Package jsonpatch applies JSON Merge Patch (RFC 7396) and JSON Patch (RFC 6902) documents.
+package jsonpatch
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"reflect"
+	"strconv"
+	"strings"
+)
+
+var (
+	// ErrInvalidPatch is returned when the patch document itself is malformed
+	ErrInvalidPatch = errors.New("invalid patch")
+
+	// ErrTestFailed is returned when a test operation does not match the document
+	ErrTestFailed = errors.New("test operation failed")
+
+	// ErrPathNotFound is returned when an operation refers to a location the document does not have
+	ErrPathNotFound = errors.New("path not found")
+)
+
+// MergePatch applies a JSON Merge Patch to doc: objects are merged recursively,
+// null removes a member and any other value replaces the target.
+func MergePatch(doc, patch []byte) ([]byte, error) {
+	var target, patchValue interface{}
+	if err := json.Unmarshal(doc, &target); err != nil {
+		return nil, err
+	}
+	if err := json.Unmarshal(patch, &patchValue); err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidPatch, err)
+	}
+	return json.Marshal(mergePatch(target, patchValue))
+}
+
+func mergePatch(target, patch interface{}) interface{} {
+	patchObject, ok := patch.(map[string]interface{})
+	if !ok {
+		return patch
+	}
+
+	targetObject, ok := target.(map[string]interface{})
+	if !ok {
+		targetObject = map[string]interface{}{}
+	}
+	for key, value := range patchObject {
+		if value == nil {
+			delete(targetObject, key)
+		} else {
+			targetObject[key] = mergePatch(targetObject[key], value)
+		}
+	}
+	return targetObject
+}
+
+// operation is one step of a JSON Patch, value is nil when the member is absent
+type operation struct {
+	op    string
+	path  []string
+	from  []string
+	value json.RawMessage
+}
+
+// Apply applies a JSON Patch to doc. Operations are applied in order and the
+// patch fails as a whole, leaving doc untouched, when any of them fails.
+func Apply(doc, patch []byte) ([]byte, error) {
+	operations, err := parsePatch(patch)
+	if err != nil {
+		return nil, err
+	}
+
+	var target interface{}
+	if err := json.Unmarshal(doc, &target); err != nil {
+		return nil, err
+	}
+	for i, op := range operations {
+		if target, err = op.apply(target); err != nil {
+			return nil, fmt.Errorf("operation %d (%s): %w", i, op.op, err)
+		}
+	}
+	return json.Marshal(target)
+}
+
+func parsePatch(patch []byte) ([]operation, error) {
+	var raw []map[string]json.RawMessage
+	if err := json.Unmarshal(patch, &raw); err != nil {
+		return nil, fmt.Errorf("%w: a JSON Patch must be an array of operations: %v", ErrInvalidPatch, err)
+	}
+
+	operations := make([]operation, len(raw))
+	for i, members := range raw {
+		op := &operations[i]
+		var path string
+		if err := unmarshalMember(members, "op", &op.op); err != nil {
+			return nil, fmt.Errorf("%w: operation %d: %v", ErrInvalidPatch, i, err)
+		}
+		if err := unmarshalMember(members, "path", &path); err != nil {
+			return nil, fmt.Errorf("%w: operation %d: %v", ErrInvalidPatch, i, err)
+		}
+		var err error
+		if op.path, err = parsePointer(path); err != nil {
+			return nil, fmt.Errorf("%w: operation %d: %v", ErrInvalidPatch, i, err)
+		}
+
+		switch op.op {
+		case "add", "replace", "test":
+			value, ok := members["value"]
+			if !ok {
+				return nil, fmt.Errorf("%w: operation %d (%s) needs a value", ErrInvalidPatch, i, op.op)
+			}
+			op.value = value
+		case "move", "copy":
+			var from string
+			if err := unmarshalMember(members, "from", &from); err != nil {
+				return nil, fmt.Errorf("%w: operation %d: %v", ErrInvalidPatch, i, err)
+			}
+			if op.from, err = parsePointer(from); err != nil {
+				return nil, fmt.Errorf("%w: operation %d: %v", ErrInvalidPatch, i, err)
+			}
+		case "remove":
+		default:
+			return nil, fmt.Errorf("%w: operation %d has unknown op %q", ErrInvalidPatch, i, op.op)
+		}
+	}
+	return operations, nil
+}
+
+func unmarshalMember(members map[string]json.RawMessage, name string, dst *string) error {
+	raw, ok := members[name]
+	if !ok {
+		return fmt.Errorf("%s is missing", name)
+	}
+	if err := json.Unmarshal(raw, dst); err != nil {
+		return fmt.Errorf("%s must be a string", name)
+	}
+	return nil
+}
+
+// parsePointer splits a JSON Pointer (RFC 6901) into its unescaped reference tokens
+func parsePointer(pointer string) ([]string, error) {
+	if pointer == "" {
+		return []string{}, nil
+	}
+	if !strings.HasPrefix(pointer, "/") {
+		return nil, fmt.Errorf("pointer %q must start with /", pointer)
+	}
+	tokens := strings.Split(pointer[1:], "/")
+	for i, token := range tokens {
+		tokens[i] = strings.ReplaceAll(strings.ReplaceAll(token, "~1", "/"), "~0", "~")
+	}
+	return tokens, nil
+}
+
+func (op operation) apply(doc interface{}) (interface{}, error) {
+	switch op.op {
+	case "add":
+		value, err := op.decodeValue()
+		if err != nil {
+			return nil, err
+		}
+		return add(doc, op.path, value)
+	case "remove":
+		doc, _, err := remove(doc, op.path)
+		return doc, err
+	case "replace":
+		value, err := op.decodeValue()
+		if err != nil {
+			return nil, err
+		}
+		if _, err := get(doc, op.path); err != nil {
+			return nil, err
+		}
+		if len(op.path) == 0 {
+			return value, nil
+		}
+		doc, _, err = remove(doc, op.path)
+		if err != nil {
+			return nil, err
+		}
+		return add(doc, op.path, value)
+	case "move":
+		if isProperPrefix(op.from, op.path) {
+			return nil, fmt.Errorf("%w: cannot move a value into one of its children", ErrInvalidPatch)
+		}
+		doc, value, err := remove(doc, op.from)
+		if err != nil {
+			return nil, err
+		}
+		return add(doc, op.path, value)
+	case "copy":
+		value, err := get(doc, op.from)
+		if err != nil {
+			return nil, err
+		}
+		return add(doc, op.path, deepCopy(value))
+	case "test":
+		expected, err := op.decodeValue()
+		if err != nil {
+			return nil, err
+		}
+		actual, err := get(doc, op.path)
+		if err != nil {
+			return nil, fmt.Errorf("%w: %v", ErrTestFailed, err)
+		}
+		if !reflect.DeepEqual(expected, actual) {
+			return nil, fmt.Errorf("%w: value at %s differs", ErrTestFailed, formatPointer(op.path))
+		}
+		return doc, nil
+	}
+	return nil, fmt.Errorf("%w: unknown op %q", ErrInvalidPatch, op.op)
+}
+
+func (op operation) decodeValue() (interface{}, error) {
+	var value interface{}
+	if err := json.Unmarshal(op.value, &value); err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidPatch, err)
+	}
+	return value, nil
+}
+
+// get returns the value path points at
+func get(doc interface{}, path []string) (interface{}, error) {
+	for i, token := range path {
+		switch node := doc.(type) {
+		case map[string]interface{}:
+			child, ok := node[token]
+			if !ok {
+				return nil, notFound(path[:i+1])
+			}
+			doc = child
+		case []interface{}:
+			index, err := arrayIndex(token, len(node)-1, path[:i+1])
+			if err != nil {
+				return nil, err
+			}
+			doc = node[index]
+		default:
+			return nil, notFound(path[:i+1])
+		}
+	}
+	return doc, nil
+}
+
+// update walks to the parent of the location path points at and calls change with it and the
+// last token. The parents are stored again on the way back, as arrays may have been reallocated.
+func update(doc interface{}, path []string, change func(parent interface{}, token string) (interface{}, error)) (interface{}, error) {
+	return updateFrom(doc, path, 0, change)
+}
+
+func updateFrom(doc interface{}, path []string, depth int, change func(parent interface{}, token string) (interface{}, error)) (interface{}, error) {
+	if depth == len(path)-1 {
+		return change(doc, path[depth])
+	}
+
+	token := path[depth]
+	switch node := doc.(type) {
+	case map[string]interface{}:
+		child, ok := node[token]
+		if !ok {
+			return nil, notFound(path[:depth+1])
+		}
+		changed, err := updateFrom(child, path, depth+1, change)
+		if err != nil {
+			return nil, err
+		}
+		node[token] = changed
+		return node, nil
+	case []interface{}:
+		index, err := arrayIndex(token, len(node)-1, path[:depth+1])
+		if err != nil {
+			return nil, err
+		}
+		changed, err := updateFrom(node[index], path, depth+1, change)
+		if err != nil {
+			return nil, err
+		}
+		node[index] = changed
+		return node, nil
+	}
+	return nil, notFound(path[:depth+1])
+}
+
+func add(doc interface{}, path []string, value interface{}) (interface{}, error) {
+	if len(path) == 0 {
+		return value, nil
+	}
+	return update(doc, path, func(parent interface{}, token string) (interface{}, error) {
+		switch node := parent.(type) {
+		case map[string]interface{}:
+			node[token] = value
+			return node, nil
+		case []interface{}:
+			index := len(node)
+			if token != "-" {
+				var err error
+				if index, err = arrayIndex(token, len(node), path); err != nil {
+					return nil, err
+				}
+			}
+			node = append(node, nil)
+			copy(node[index+1:], node[index:])
+			node[index] = value
+			return node, nil
+		}
+		return nil, notFound(path)
+	})
+}
+
+func remove(doc interface{}, path []string) (interface{}, interface{}, error) {
+	if len(path) == 0 {
+		return nil, nil, fmt.Errorf("%w: cannot remove the whole document", ErrInvalidPatch)
+	}
+	var removed interface{}
+	doc, err := update(doc, path, func(parent interface{}, token string) (interface{}, error) {
+		switch node := parent.(type) {
+		case map[string]interface{}:
+			value, ok := node[token]
+			if !ok {
+				return nil, notFound(path)
+			}
+			removed = value
+			delete(node, token)
+			return node, nil
+		case []interface{}:
+			index, err := arrayIndex(token, len(node)-1, path)
+			if err != nil {
+				return nil, err
+			}
+			removed = node[index]
+			return append(node[:index:index], node[index+1:]...), nil
+		}
+		return nil, notFound(path)
+	})
+	return doc, removed, err
+}
+
+// arrayIndex parses an array index token, which must not exceed max
+func arrayIndex(token string, max int, path []string) (int, error) {
+	index, err := strconv.Atoi(token)
+	if err != nil || index < 0 || (len(token) > 1 && token[0] == '0') || token[0] == '+' {
+		return 0, fmt.Errorf("%w: %s is not an array index", ErrPathNotFound, formatPointer(path))
+	}
+	if index > max {
+		return 0, notFound(path)
+	}
+	return index, nil
+}
+
+func isProperPrefix(prefix, path []string) bool {
+	if len(prefix) >= len(path) {
+		return false
+	}
+	for i := range prefix {
+		if prefix[i] != path[i] {
+			return false
+		}
+	}
+	return true
+}
+
+func deepCopy(value interface{}) interface{} {
+	switch v := value.(type) {
+	case map[string]interface{}:
+		copied := make(map[string]interface{}, len(v))
+		for key, child := range v {
+			copied[key] = deepCopy(child)
+		}
+		return copied
+	case []interface{}:
+		copied := make([]interface{}, len(v))
+		for i, child := range v {
+			copied[i] = deepCopy(child)
+		}
+		return copied
+	}
+	return value
+}
+
+func notFound(path []string) error {
+	return fmt.Errorf("%w: %s", ErrPathNotFound, formatPointer(path))
+}
+
+func formatPointer(path []string) string {
+	var b strings.Builder
+	for _, token := range path {
+		b.WriteByte('/')
+		b.WriteString(strings.ReplaceAll(strings.ReplaceAll(token, "~", "~0"), "/", "~1"))
+	}
+	return b.String()
+}
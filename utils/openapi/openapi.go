@@ -0,0 +1,341 @@
+// Package openapi validates HTTP requests and responses against an OpenAPI 3.1 document.
+// It understands the parts of the document this service uses: path, query and header parameters,
+// JSON request and response bodies, and the JSON Schema keywords listed on Schema.
+package openapi
+
+import (
+	"encoding/json"
+	"fmt"
+	"mime"
+	"net/http"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// Document is a parsed OpenAPI document
+type Document struct {
+	schemas map[string]*Schema
+	routes  []*route
+}
+
+// route is a path template of the document with its operations by lowercase method
+type route struct {
+	template   string
+	pattern    *regexp.Regexp
+	params     []string
+	operations map[string]*Operation
+}
+
+// Operation is a method on a path of the document
+type Operation struct {
+	ID         string
+	Path       string
+	parameters []*parameter
+	body       *requestBody
+	responses  map[string]*response
+	schemas    map[string]*Schema
+}
+
+type parameter struct {
+	Ref      string  `json:"$ref"`
+	Name     string  `json:"name"`
+	In       string  `json:"in"`
+	Required bool    `json:"required"`
+	Schema   *Schema `json:"schema"`
+}
+
+type requestBody struct {
+	Ref      string               `json:"$ref"`
+	Required bool                 `json:"required"`
+	Content  map[string]mediaType `json:"content"`
+}
+
+type response struct {
+	Ref     string               `json:"$ref"`
+	Content map[string]mediaType `json:"content"`
+}
+
+type mediaType struct {
+	Schema *Schema `json:"schema"`
+}
+
+// the JSON form of the document, only what validation needs
+type rawDocument struct {
+	OpenAPI    string                                `json:"openapi"`
+	Paths      map[string]map[string]json.RawMessage `json:"paths"`
+	Components struct {
+		Schemas       map[string]*Schema      `json:"schemas"`
+		Parameters    map[string]*parameter   `json:"parameters"`
+		RequestBodies map[string]*requestBody `json:"requestBodies"`
+		Responses     map[string]*response    `json:"responses"`
+	} `json:"components"`
+}
+
+type rawOperation struct {
+	OperationID string               `json:"operationId"`
+	Parameters  []*parameter         `json:"parameters"`
+	RequestBody *requestBody         `json:"requestBody"`
+	Responses   map[string]*response `json:"responses"`
+}
+
+// methods are the keys of a path item that hold operations
+var methods = map[string]bool{"get": true, "put": true, "post": true, "delete": true, "options": true, "head": true, "patch": true, "trace": true}
+
+// pathParam matches the parameters of a path template like {id}, also within a segment like {period}.pdf
+var pathParam = regexp.MustCompile(`\{(\w+)\}`)
+
+// Load parses an OpenAPI 3.x document and resolves the references of its operations
+func Load(data []byte) (*Document, error) {
+	var raw rawDocument
+	if err := json.Unmarshal(data, &raw); err != nil {
+		return nil, fmt.Errorf("openapi: %w", err)
+	}
+	if !strings.HasPrefix(raw.OpenAPI, "3.") {
+		return nil, fmt.Errorf("openapi: unsupported version %q", raw.OpenAPI)
+	}
+
+	doc := &Document{schemas: raw.Components.Schemas}
+	for template, item := range raw.Paths {
+		r := &route{template: template, operations: map[string]*Operation{}}
+
+		// path templates match like fiber routes: case-insensitive and with an optional trailing slash
+		expr := "(?i)^"
+		for _, segment := range strings.Split(trimSlash(template), "/")[1:] {
+			expr += "/"
+			literal := 0
+			for _, match := range pathParam.FindAllStringSubmatchIndex(segment, -1) {
+				r.params = append(r.params, segment[match[2]:match[3]])
+				expr += regexp.QuoteMeta(segment[literal:match[0]]) + "([^/]+)"
+				literal = match[1]
+			}
+			expr += regexp.QuoteMeta(segment[literal:])
+		}
+		if expr == "(?i)^" {
+			expr += "/"
+		}
+		r.pattern = regexp.MustCompile(expr + "$")
+
+		var shared []*parameter
+		if data, ok := item["parameters"]; ok {
+			if err := json.Unmarshal(data, &shared); err != nil {
+				return nil, fmt.Errorf("openapi: %s: %w", template, err)
+			}
+		}
+		for method, data := range item {
+			if !methods[method] {
+				continue
+			}
+			var rawOp rawOperation
+			if err := json.Unmarshal(data, &rawOp); err != nil {
+				return nil, fmt.Errorf("openapi: %s %s: %w", strings.ToUpper(method), template, err)
+			}
+			op := &Operation{ID: rawOp.OperationID, Path: template, responses: map[string]*response{}, schemas: doc.schemas}
+			for _, p := range append(append([]*parameter(nil), shared...), rawOp.Parameters...) {
+				if p.Ref != "" {
+					p = raw.Components.Parameters[strings.TrimPrefix(p.Ref, "#/components/parameters/")]
+				}
+				if p == nil {
+					return nil, fmt.Errorf("openapi: %s %s: unresolved parameter", strings.ToUpper(method), template)
+				}
+				op.parameters = append(op.parameters, p)
+			}
+			op.body = rawOp.RequestBody
+			if op.body != nil && op.body.Ref != "" {
+				op.body = raw.Components.RequestBodies[strings.TrimPrefix(op.body.Ref, "#/components/requestBodies/")]
+			}
+			for status, resp := range rawOp.Responses {
+				if resp.Ref != "" {
+					resp = raw.Components.Responses[strings.TrimPrefix(resp.Ref, "#/components/responses/")]
+				}
+				if resp == nil {
+					return nil, fmt.Errorf("openapi: %s %s: unresolved response %s", strings.ToUpper(method), template, status)
+				}
+				op.responses[status] = resp
+			}
+			r.operations[method] = op
+		}
+		doc.routes = append(doc.routes, r)
+	}
+	return doc, nil
+}
+
+// MustLoad is like Load but panics if the document cannot be parsed
+func MustLoad(data []byte) *Document {
+	doc, err := Load(data)
+	if err != nil {
+		panic(err)
+	}
+	return doc
+}
+
+// Find returns the operation serving method and path with the values of its path parameters.
+// Literal segments win over parameters, so /employees/duplicates is not read as /employees/{id}.
+func (d *Document) Find(method, path string) (*Operation, map[string]string) {
+	method = strings.ToLower(method)
+	if method == "head" {
+		method = "get"
+	}
+	path = trimSlash(path)
+
+	var (
+		best   *route
+		values []string
+	)
+	for _, r := range d.routes {
+		if r.operations[method] == nil || (best != nil && len(r.params) >= len(best.params)) {
+			continue
+		}
+		if match := r.pattern.FindStringSubmatch(path); match != nil {
+			best, values = r, match[1:]
+		}
+	}
+	if best == nil {
+		return nil, nil
+	}
+	params := make(map[string]string, len(values))
+	for i, name := range best.params {
+		params[name] = values[i]
+	}
+	return best.operations[method], params
+}
+
+func trimSlash(path string) string {
+	if len(path) > 1 {
+		return strings.TrimSuffix(path, "/")
+	}
+	return path
+}
+
+// Request is what ValidateRequest looks at, Query and Header return "" for missing values
+type Request struct {
+	PathParams  map[string]string
+	Query       func(name string) string
+	Header      func(name string) string
+	ContentType string
+	Body        []byte
+}
+
+// ValidateRequest checks the parameters and the JSON body of r. Bodies of media types the operation
+// does not declare are left to the handler, which knows how to reject them.
+func (op *Operation) ValidateRequest(r Request) Errors {
+	v := &validator{schemas: op.schemas}
+	for _, p := range op.parameters {
+		var value string
+		switch p.In {
+		case "path":
+			value = r.PathParams[p.Name]
+		case "query":
+			value = r.Query(p.Name)
+		case "header":
+			value = r.Header(p.Name)
+		default:
+			continue
+		}
+		if value == "" {
+			if p.Required {
+				v.fail(p.Name, CodeRequired, "is required")
+			}
+			continue
+		}
+		v.validate(p.Name, p.Schema, parseParameter(v.resolve(p.Schema), value))
+	}
+
+	if op.body == nil {
+		return v.errs
+	}
+	mediaType, _, _ := mime.ParseMediaType(r.ContentType)
+	content, declared := op.body.Content[mediaType]
+	if !declared && mediaType != "" {
+		return v.errs
+	}
+	if len(r.Body) == 0 {
+		if op.body.Required {
+			v.fail("", CodeRequired, "is required")
+		}
+		return v.errs
+	}
+	if content.Schema == nil && mediaType == "" {
+		// without a content type the body is checked against the first JSON media type
+		for name, candidate := range op.body.Content {
+			if isJSON(name) {
+				content = candidate
+				break
+			}
+		}
+	}
+	if mediaType != "" && !isJSON(mediaType) {
+		// only JSON bodies are checked against their schema
+		return v.errs
+	}
+	var body interface{}
+	if err := json.Unmarshal(r.Body, &body); err != nil {
+		v.fail("", CodeInvalidFormat, "is not valid JSON")
+		return v.errs
+	}
+	v.validate("", content.Schema, body)
+	return v.errs
+}
+
+// parseParameter converts the text of a parameter to the JSON type its schema expects, text that
+// does not convert is returned as is and fails the type check
+func parseParameter(schema *Schema, value string) interface{} {
+	if schema == nil {
+		return value
+	}
+	for _, t := range schema.Type {
+		switch t {
+		case "integer", "number":
+			if f, err := strconv.ParseFloat(value, 64); err == nil {
+				return f
+			}
+		case "boolean":
+			if b, err := strconv.ParseBool(value); err == nil {
+				return b
+			}
+		case "string":
+			return value
+		}
+	}
+	return value
+}
+
+// ValidateResponse checks that status is documented for the operation and that a JSON body matches its schema
+func (op *Operation) ValidateResponse(status int, contentType string, body []byte) Errors {
+	v := &validator{schemas: op.schemas}
+	resp := op.responses[strconv.Itoa(status)]
+	if resp == nil {
+		resp = op.responses[strconv.Itoa(status/100)+"XX"]
+	}
+	if resp == nil {
+		resp = op.responses["default"]
+	}
+	if resp == nil {
+		v.fail("", CodeInvalidValue, "status %d %s is not documented", status, http.StatusText(status))
+		return v.errs
+	}
+	if len(resp.Content) == 0 {
+		return v.errs
+	}
+
+	mediaType, _, _ := mime.ParseMediaType(contentType)
+	content, ok := resp.Content[mediaType]
+	if !ok {
+		v.fail("", CodeInvalidValue, "content type %q is not documented for status %d", mediaType, status)
+		return v.errs
+	}
+	if !isJSON(mediaType) {
+		return v.errs
+	}
+	var value interface{}
+	if err := json.Unmarshal(body, &value); err != nil {
+		v.fail("", CodeInvalidFormat, "is not valid JSON")
+		return v.errs
+	}
+	v.validate("", content.Schema, value)
+	return v.errs
+}
+
+func isJSON(mediaType string) bool {
+	return mediaType == "application/json" || strings.HasSuffix(mediaType, "+json")
+}
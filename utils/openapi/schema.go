@@ -0,0 +1,335 @@
+package openapi
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+	"math/big"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Codes of validation errors, the same the models package reports for field errors
+const (
+	CodeRequired      = "required"
+	CodeTooLong       = "too_long"
+	CodeOutOfRange    = "out_of_range"
+	CodeInvalidFormat = "invalid_format"
+	CodeInvalidValue  = "invalid_value"
+	CodeInvalidType   = "invalid_type"
+	CodeUnknownField  = "unknown_field"
+)
+
+// Error is a value that does not match its schema. Field is the dotted path of the value,
+// empty for the whole body.
+type Error struct {
+	Field   string `json:"field"`
+	Code    string `json:"code"`
+	Message string `json:"message"`
+}
+
+// Errors lists every mismatch found
+type Errors []Error
+
+func (e Errors) Error() string {
+	messages := make([]string, len(e))
+	for i, err := range e {
+		messages[i] = err.Message
+	}
+	return strings.Join(messages, "; ")
+}
+
+// Schema is the subset of JSON Schema used by the document
+type Schema struct {
+	Ref                  string             `json:"$ref"`
+	Type                 typeList           `json:"type"`
+	Enum                 []interface{}      `json:"enum"`
+	Const                json.RawMessage    `json:"const"`
+	Format               string             `json:"format"`
+	Properties           map[string]*Schema `json:"properties"`
+	Required             []string           `json:"required"`
+	AdditionalProperties *additional        `json:"additionalProperties"`
+	Items                *Schema            `json:"items"`
+	OneOf                []*Schema          `json:"oneOf"`
+	AnyOf                []*Schema          `json:"anyOf"`
+	Minimum              *float64           `json:"minimum"`
+	Maximum              *float64           `json:"maximum"`
+	ExclusiveMinimum     *float64           `json:"exclusiveMinimum"`
+	ExclusiveMaximum     *float64           `json:"exclusiveMaximum"`
+	MultipleOf           *float64           `json:"multipleOf"`
+	MaxLength            *int               `json:"maxLength"`
+}
+
+// typeList accepts both "type": "string" and "type": ["string", "null"]
+type typeList []string
+
+func (t *typeList) UnmarshalJSON(data []byte) error {
+	var single string
+	if err := json.Unmarshal(data, &single); err == nil {
+		*t = typeList{single}
+		return nil
+	}
+	var list []string
+	if err := json.Unmarshal(data, &list); err != nil {
+		return err
+	}
+	*t = list
+	return nil
+}
+
+// additional is additionalProperties, either a boolean or a schema for the other members
+type additional struct {
+	allowed bool
+	schema  *Schema
+}
+
+func (a *additional) UnmarshalJSON(data []byte) error {
+	if err := json.Unmarshal(data, &a.allowed); err == nil {
+		return nil
+	}
+	a.allowed = true
+	return json.Unmarshal(data, &a.schema)
+}
+
+// validator checks values against schemas, resolving references to the document's components
+type validator struct {
+	schemas map[string]*Schema
+	errs    Errors
+}
+
+func (v *validator) fail(field, code, format string, args ...interface{}) {
+	name := field
+	if name == "" {
+		name = "body"
+	}
+	v.errs = append(v.errs, Error{Field: field, Code: code, Message: name + " " + fmt.Sprintf(format, args...)})
+}
+
+func (v *validator) resolve(schema *Schema) *Schema {
+	for schema != nil && schema.Ref != "" {
+		schema = v.schemas[strings.TrimPrefix(schema.Ref, "#/components/schemas/")]
+	}
+	return schema
+}
+
+// validate checks value, decoded by encoding/json, against schema
+func (v *validator) validate(field string, schema *Schema, value interface{}) {
+	schema = v.resolve(schema)
+	if schema == nil {
+		return
+	}
+
+	if len(schema.Type) > 0 && !matchesType(schema.Type, value) {
+		v.fail(field, CodeInvalidType, "must be %s", describeTypes(schema.Type))
+		return
+	}
+	if schema.Const != nil {
+		var expected interface{}
+		if err := json.Unmarshal(schema.Const, &expected); err == nil && !reflect.DeepEqual(expected, value) {
+			v.fail(field, CodeInvalidValue, "must be %s", string(schema.Const))
+			return
+		}
+	}
+	if len(schema.Enum) > 0 && !containsValue(schema.Enum, value) {
+		v.fail(field, CodeInvalidValue, "must be one of %s", describeValues(schema.Enum))
+		return
+	}
+	if len(schema.OneOf) > 0 {
+		v.validateOneOf(field, schema.OneOf, value, true)
+	}
+	if len(schema.AnyOf) > 0 {
+		v.validateOneOf(field, schema.AnyOf, value, false)
+	}
+
+	switch value := value.(type) {
+	case string:
+		if schema.MaxLength != nil && len([]rune(value)) > *schema.MaxLength {
+			v.fail(field, CodeTooLong, "must be at most %d characters", *schema.MaxLength)
+		}
+		if !matchesFormat(schema.Format, value) {
+			v.fail(field, CodeInvalidFormat, "must be a %s", schema.Format)
+		}
+	case float64:
+		if (schema.Minimum != nil && value < *schema.Minimum) || (schema.Maximum != nil && value > *schema.Maximum) {
+			v.fail(field, CodeOutOfRange, "must be %s", describeRange(schema.Minimum, schema.Maximum))
+		}
+		if schema.ExclusiveMinimum != nil && value <= *schema.ExclusiveMinimum {
+			v.fail(field, CodeOutOfRange, "must be above %s", formatFloat(*schema.ExclusiveMinimum))
+		}
+		if schema.ExclusiveMaximum != nil && value >= *schema.ExclusiveMaximum {
+			v.fail(field, CodeOutOfRange, "must be below %s", formatFloat(*schema.ExclusiveMaximum))
+		}
+		if schema.MultipleOf != nil && !multipleOf(value, *schema.MultipleOf) {
+			v.fail(field, CodeInvalidValue, "must be a multiple of %s", formatFloat(*schema.MultipleOf))
+		}
+	case []interface{}:
+		if schema.Items != nil {
+			for i, item := range value {
+				v.validate(join(field, strconv.Itoa(i)), schema.Items, item)
+			}
+		}
+	case map[string]interface{}:
+		v.validateObject(field, schema, value)
+	}
+}
+
+func (v *validator) validateObject(field string, schema *Schema, object map[string]interface{}) {
+	for _, name := range schema.Required {
+		if _, ok := object[name]; !ok {
+			v.fail(join(field, name), CodeRequired, "is required")
+		}
+	}
+
+	names := make([]string, 0, len(object))
+	for name := range object {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		if property, ok := schema.Properties[name]; ok {
+			v.validate(join(field, name), property, object[name])
+			continue
+		}
+		switch {
+		case schema.AdditionalProperties == nil:
+		case !schema.AdditionalProperties.allowed:
+			v.fail(join(field, name), CodeUnknownField, "is not a known field")
+		case schema.AdditionalProperties.schema != nil:
+			v.validate(join(field, name), schema.AdditionalProperties.schema, object[name])
+		}
+	}
+}
+
+// validateOneOf requires value to match one of schemas, and no other one if exactly is set
+func (v *validator) validateOneOf(field string, schemas []*Schema, value interface{}, exactly bool) {
+	matches := 0
+	var closest Errors
+	for _, schema := range schemas {
+		branch := &validator{schemas: v.schemas}
+		branch.validate(field, schema, value)
+		if len(branch.errs) == 0 {
+			matches++
+		} else if closest == nil || len(branch.errs) < len(closest) {
+			closest = branch.errs
+		}
+	}
+	switch {
+	case matches == 0:
+		v.errs = append(v.errs, closest...)
+	case matches > 1 && exactly:
+		v.fail(field, CodeInvalidValue, "matches more than one schema")
+	}
+}
+
+func join(field, name string) string {
+	if field == "" {
+		return name
+	}
+	return field + "." + name
+}
+
+func matchesType(types typeList, value interface{}) bool {
+	for _, t := range types {
+		switch value := value.(type) {
+		case nil:
+			if t == "null" {
+				return true
+			}
+		case bool:
+			if t == "boolean" {
+				return true
+			}
+		case string:
+			if t == "string" {
+				return true
+			}
+		case float64:
+			if t == "number" || (t == "integer" && value == math.Trunc(value)) {
+				return true
+			}
+		case []interface{}:
+			if t == "array" {
+				return true
+			}
+		case map[string]interface{}:
+			if t == "object" {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+func matchesFormat(format, value string) bool {
+	switch format {
+	case "date":
+		_, err := time.Parse("2006-01-02", value)
+		return err == nil
+	case "date-time":
+		_, err := time.Parse(time.RFC3339Nano, value)
+		return err == nil
+	}
+	return true
+}
+
+func containsValue(values []interface{}, value interface{}) bool {
+	for _, candidate := range values {
+		if reflect.DeepEqual(candidate, value) {
+			return true
+		}
+	}
+	return false
+}
+
+func describeTypes(types typeList) string {
+	names := make([]string, len(types))
+	for i, t := range types {
+		switch t {
+		case "null":
+			names[i] = "null"
+		case "array", "integer", "object":
+			names[i] = "an " + t
+		default:
+			names[i] = "a " + t
+		}
+	}
+	return strings.Join(names, " or ")
+}
+
+func describeValues(values []interface{}) string {
+	names := make([]string, len(values))
+	for i, value := range values {
+		data, _ := json.Marshal(value)
+		names[i] = string(data)
+	}
+	return strings.Join(names, ", ")
+}
+
+func describeRange(min, max *float64) string {
+	switch {
+	case min != nil && max != nil:
+		return "between " + formatFloat(*min) + " and " + formatFloat(*max)
+	case min != nil:
+		return "at least " + formatFloat(*min)
+	default:
+		return "at most " + formatFloat(*max)
+	}
+}
+
+func formatFloat(f float64) string {
+	return strconv.FormatFloat(f, 'f', -1, 64)
+}
+
+// multipleOf reports whether value is a whole multiple of divisor. Both are taken as the shortest
+// decimals that print them, so 0.07 is a multiple of 0.01 although their floats are not.
+func multipleOf(value, divisor float64) bool {
+	v, okValue := new(big.Rat).SetString(formatFloat(value))
+	d, okDivisor := new(big.Rat).SetString(formatFloat(divisor))
+	if !okValue || !okDivisor || d.Sign() == 0 {
+		return true
+	}
+	return v.Quo(v, d).IsInt()
+}
@@ -0,0 +1,82 @@
+package outbox
+
+import (
+	"context"
+	"sync"
+)
+
+// NATSMessage is a message published to FakeNATS
+type NATSMessage struct {
+	Subject string
+	Data    []byte
+}
+
+// FakeNATS is an in-process NATSPublisher that keeps what is published, for tests and local setups
+// without a NATS server
+type FakeNATS struct {
+	mu       sync.Mutex
+	err      error
+	messages []NATSMessage
+}
+
+func (f *FakeNATS) Publish(subject string, data []byte) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if f.err != nil {
+		return f.err
+	}
+	f.messages = append(f.messages, NATSMessage{Subject: subject, Data: append([]byte(nil), data...)})
+	return nil
+}
+
+// Fail makes Publish return err, nil makes it succeed again
+func (f *FakeNATS) Fail(err error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.err = err
+}
+
+// Messages returns the messages published so far, oldest first
+func (f *FakeNATS) Messages() []NATSMessage {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return append([]NATSMessage(nil), f.messages...)
+}
+
+// KafkaRecord is a record produced to FakeKafka
+type KafkaRecord struct {
+	Topic      string
+	Key, Value []byte
+}
+
+// FakeKafka is an in-process KafkaProducer that keeps what is produced, for tests and local setups
+// without a Kafka cluster
+type FakeKafka struct {
+	mu      sync.Mutex
+	err     error
+	records []KafkaRecord
+}
+
+func (f *FakeKafka) Produce(_ context.Context, topic string, key, value []byte) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if f.err != nil {
+		return f.err
+	}
+	f.records = append(f.records, KafkaRecord{Topic: topic, Key: append([]byte(nil), key...), Value: append([]byte(nil), value...)})
+	return nil
+}
+
+// Fail makes Produce return err, nil makes it succeed again
+func (f *FakeKafka) Fail(err error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.err = err
+}
+
+// Records returns the records produced so far, oldest first
+func (f *FakeKafka) Records() []KafkaRecord {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return append([]KafkaRecord(nil), f.records...)
+}
@@ -0,0 +1,111 @@
+// Package outbox holds the sinks the outbox relay publishes employee events to. The relay publishes
+// every event at least once and the events of one employee in order, so sinks may see an event again
+// after a failure and their consumers drop repeats by the event ID.
+package outbox
+
+import (
+	"Techiebulter/interview/backend/models"
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"strconv"
+	"sync"
+)
+
+// Sink publishes employee events somewhere. Publish returns once the event is accepted, an error has
+// the event published again later.
+type Sink interface {
+	// Name identifies the sink in metrics and logs
+	Name() string
+	Publish(ctx context.Context, event models.EmployeeEvent) error
+}
+
+// FileSink appends every event as a line of JSON to the file at Path. The file is opened on the first
+// publish, a file that cannot be opened fails the publish and is tried again with the retry.
+type FileSink struct {
+	Path string
+
+	mu   sync.Mutex
+	file *os.File
+}
+
+func (s *FileSink) Name() string { return "file" }
+
+// Publish writes the event and syncs the file, so an accepted event survives a crash
+func (s *FileSink) Publish(_ context.Context, event models.EmployeeEvent) error {
+	line, err := json.Marshal(event)
+	if err != nil {
+		return err
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.file == nil {
+		file, err := os.OpenFile(s.Path, os.O_WRONLY|os.O_APPEND|os.O_CREATE, 0o644)
+		if err != nil {
+			return fmt.Errorf("open outbox file: %w", err)
+		}
+		s.file = file
+	}
+	if _, err := s.file.Write(append(line, '\n')); err != nil {
+		return err
+	}
+	return s.file.Sync()
+}
+
+// Close closes the file if it was opened
+func (s *FileSink) Close() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.file == nil {
+		return nil
+	}
+	err := s.file.Close()
+	s.file = nil
+	return err
+}
+
+// NATSPublisher is the part of a NATS connection the NATS sink uses, *nats.Conn of
+// github.com/nats-io/nats.go has this method
+type NATSPublisher interface {
+	Publish(subject string, data []byte) error
+}
+
+// NATSSink publishes every event as JSON to the subject Prefix, a dot and the event type, like employees.updated
+type NATSSink struct {
+	Conn   NATSPublisher
+	Prefix string
+}
+
+func (s NATSSink) Name() string { return "nats" }
+
+func (s NATSSink) Publish(_ context.Context, event models.EmployeeEvent) error {
+	data, err := json.Marshal(event)
+	if err != nil {
+		return err
+	}
+	return s.Conn.Publish(s.Prefix+"."+string(event.Type), data)
+}
+
+// KafkaProducer is the part of a Kafka client the Kafka sink uses. Produce returns once the broker
+// acknowledged the record.
+type KafkaProducer interface {
+	Produce(ctx context.Context, topic string, key, value []byte) error
+}
+
+// KafkaSink publishes every event as JSON to Topic, keyed by the employee ID so the events of an
+// employee land in one partition and keep their order
+type KafkaSink struct {
+	Producer KafkaProducer
+	Topic    string
+}
+
+func (s KafkaSink) Name() string { return "kafka" }
+
+func (s KafkaSink) Publish(ctx context.Context, event models.EmployeeEvent) error {
+	data, err := json.Marshal(event)
+	if err != nil {
+		return err
+	}
+	return s.Producer.Produce(ctx, s.Topic, []byte(strconv.Itoa(event.EmployeeID)), data)
+}
@@ -0,0 +1,248 @@
+// Package payslip renders payslips as PDF documents. Every payslip is laid out by the same template: the
+// company header, the employee, what the base pay is made of, the allowances and deductions and the net pay.
+package payslip
+
+import (
+	"Techiebulter/interview/backend/models"
+	"fmt"
+	"io"
+	"math"
+	"strconv"
+	"strings"
+
+	"github.com/go-pdf/fpdf"
+)
+
+// Branding is the company a payslip is issued by. Logo is the path of a PNG or JPEG file and Color a hex
+// color like #1f4e79, both optional.
+type Branding struct {
+	CompanyName    string
+	CompanyAddress string
+	Logo           string
+	Color          string
+}
+
+// the layout of an A4 page in millimetres
+const (
+	margin     = 15.0
+	pageWidth  = 210.0 - 2*margin
+	lineHeight = 7.0
+	logoHeight = 16.0
+)
+
+// gray is the color of labels and table rules
+var gray = rgb{110, 110, 110}
+
+type rgb struct{ r, g, b int }
+
+// parseColor reads a #rrggbb color, black if it is not one
+func parseColor(hex string) rgb {
+	v, err := strconv.ParseUint(strings.TrimPrefix(hex, "#"), 16, 32)
+	if err != nil || len(hex) != 7 {
+		return rgb{}
+	}
+	return rgb{int(v >> 16 & 0xff), int(v >> 8 & 0xff), int(v & 0xff)}
+}
+
+// Render writes payslip as a PDF document to w
+func Render(w io.Writer, payslip models.Payslip, branding Branding) error {
+	pdf := fpdf.New("P", "mm", "A4", "")
+	pdf.SetMargins(margin, margin, margin)
+	pdf.SetAutoPageBreak(true, margin)
+	pdf.SetTitle(fmt.Sprintf("Payslip %s %s", payslip.Period, payslip.Name), true)
+	pdf.SetAuthor(branding.CompanyName, true)
+	pdf.AddPage()
+
+	// the core fonts are encoded in cp1252, names and addresses are translated from UTF-8
+	t := page{pdf: pdf, tr: pdf.UnicodeTranslatorFromDescriptor(""), accent: parseColor(branding.Color)}
+	t.header(branding)
+	t.employee(payslip)
+	t.basePay(payslip)
+	t.lines(payslip)
+	t.netPay(payslip)
+
+	if err := pdf.Output(w); err != nil {
+		return fmt.Errorf("render payslip: %w", err)
+	}
+	return nil
+}
+
+// page draws the sections of a payslip one below the other
+type page struct {
+	pdf    *fpdf.Fpdf
+	tr     func(string) string
+	accent rgb
+}
+
+func (t *page) header(branding Branding) {
+	pdf := t.pdf
+	top := pdf.GetY()
+	textX := margin
+	if branding.Logo != "" {
+		pdf.ImageOptions(branding.Logo, margin, top, 0, logoHeight, false, fpdf.ImageOptions{ReadDpi: true}, 0, "")
+		if info := pdf.GetImageInfo(branding.Logo); info != nil {
+			textX += info.Width()*logoHeight/info.Height() + 5
+		}
+	}
+
+	pdf.SetXY(textX, top)
+	pdf.SetFont("Helvetica", "B", 18)
+	t.color(t.accent)
+	pdf.CellFormat(0, 9, t.tr(branding.CompanyName), "", 1, "L", false, 0, "")
+	if branding.CompanyAddress != "" {
+		pdf.SetX(textX)
+		pdf.SetFont("Helvetica", "", 9)
+		t.color(gray)
+		pdf.MultiCell(0, 4.5, t.tr(branding.CompanyAddress), "", "L", false)
+	}
+
+	pdf.SetY(math.Max(pdf.GetY(), top+logoHeight) + 4)
+	pdf.SetDrawColor(t.accent.r, t.accent.g, t.accent.b)
+	pdf.SetLineWidth(0.8)
+	pdf.Line(margin, pdf.GetY(), margin+pageWidth, pdf.GetY())
+	pdf.Ln(6)
+}
+
+func (t *page) employee(payslip models.Payslip) {
+	pdf := t.pdf
+	pdf.SetFont("Helvetica", "B", 14)
+	t.color(rgb{})
+	pdf.CellFormat(0, 8, "Payslip for "+payslip.Period.Start().Format("January 2006"), "", 1, "L", false, 0, "")
+	pdf.Ln(2)
+
+	fields := [][2]string{
+		{"Employee", payslip.Name},
+		{"Employee ID", strconv.Itoa(payslip.EmployeeID)},
+		{"Position", payslip.Position},
+		{"Department", payslip.Department},
+		{"Employment type", string(payslip.EmploymentType)},
+		{"Pay period", payslip.Period.Start().String() + " to " + payslip.Period.End().String()},
+		{"Currency", string(payslip.Currency)},
+	}
+	for _, field := range fields {
+		if field[1] == "" {
+			continue
+		}
+		pdf.SetFont("Helvetica", "", 10)
+		t.color(gray)
+		pdf.CellFormat(40, 6, field[0], "", 0, "L", false, 0, "")
+		pdf.SetFont("Helvetica", "", 10)
+		t.color(rgb{})
+		pdf.CellFormat(0, 6, t.tr(field[1]), "", 1, "L", false, 0, "")
+	}
+	pdf.Ln(4)
+}
+
+// basePay lists the segments of the base pay, days at a salary or hours at a rate. Salaries paid in
+// another currency than the payslip's are shown with their currency.
+func (t *page) basePay(payslip models.Payslip) {
+	hourly := payslip.EmploymentType == models.EmploymentContractor
+	quantity, rate := "Days", "Salary"
+	if hourly {
+		quantity, rate = "Hours", "Hourly rate"
+	}
+	t.heading("Base pay")
+	widths := []float64{70, 30, 40, 40}
+	t.row(widths, true, "Period", quantity, rate, "Amount")
+	for _, segment := range payslip.Segments {
+		count := strconv.Itoa(segment.Days)
+		if hourly {
+			count = strconv.FormatFloat(segment.Hours, 'f', -1, 64)
+		}
+		salary := amount(segment.Salary)
+		if segment.Currency != "" && segment.Currency != payslip.Currency {
+			salary += " " + string(segment.Currency)
+		}
+		t.row(widths, false, segment.From.String()+" to "+segment.To.String(), count, salary, amount(segment.Amount))
+	}
+	t.total("Base pay", payslip.BasePay)
+}
+
+// lines lists the allowances making up the gross pay and the deductions taken from it
+func (t *page) lines(payslip models.Payslip) {
+	widths := []float64{140, 40}
+	t.heading("Earnings")
+	t.row(widths, false, "Base pay", amount(payslip.BasePay))
+	for _, line := range payslip.Lines {
+		if line.Kind == models.Allowance {
+			t.row(widths, false, t.tr(line.Name), amount(line.Amount))
+		}
+	}
+	t.total("Gross pay", payslip.Gross)
+
+	t.heading("Deductions")
+	for _, line := range payslip.Lines {
+		if line.Kind == models.Deduction {
+			t.row(widths, false, t.tr(line.Name), amount(line.Amount))
+		}
+	}
+	t.total("Total deductions", payslip.Deductions)
+}
+
+func (t *page) netPay(payslip models.Payslip) {
+	pdf := t.pdf
+	pdf.Ln(2)
+	pdf.SetFillColor(t.accent.r, t.accent.g, t.accent.b)
+	pdf.SetTextColor(255, 255, 255)
+	pdf.SetFont("Helvetica", "B", 13)
+	pdf.CellFormat(140, 11, "  Net pay", "", 0, "L", true, 0, "")
+	pdf.CellFormat(40, 11, strings.TrimSpace(amount(payslip.Net)+" "+string(payslip.Currency))+"  ", "", 1, "R", true, 0, "")
+}
+
+func (t *page) heading(title string) {
+	pdf := t.pdf
+	pdf.SetFont("Helvetica", "B", 11)
+	t.color(t.accent)
+	pdf.CellFormat(0, 8, title, "", 1, "L", false, 0, "")
+}
+
+// row writes cells of widths, the first one left aligned and the others right aligned
+func (t *page) row(widths []float64, header bool, cells ...string) {
+	pdf := t.pdf
+	if header {
+		pdf.SetFont("Helvetica", "B", 9)
+		t.color(gray)
+	} else {
+		pdf.SetFont("Helvetica", "", 10)
+		t.color(rgb{})
+	}
+	pdf.SetDrawColor(gray.r, gray.g, gray.b)
+	pdf.SetLineWidth(0.1)
+	for i, cell := range cells {
+		align, ln := "R", 0
+		if i == 0 {
+			align = "L"
+		}
+		if i == len(cells)-1 {
+			ln = 1
+		}
+		pdf.CellFormat(widths[i], lineHeight, cell, "B", ln, align, false, 0, "")
+	}
+}
+
+func (t *page) total(label string, value models.Money) {
+	pdf := t.pdf
+	pdf.SetFont("Helvetica", "B", 10)
+	t.color(rgb{})
+	pdf.CellFormat(pageWidth-40, lineHeight, label, "", 0, "R", false, 0, "")
+	pdf.CellFormat(40, lineHeight, amount(value), "", 1, "R", false, 0, "")
+	pdf.Ln(3)
+}
+
+func (t *page) color(c rgb) {
+	t.pdf.SetTextColor(c.r, c.g, c.b)
+}
+
+// amount writes a value with two decimals and thousands separators, like 12,345.60
+func amount(value models.Money) string {
+	s := value.String()
+	sign := ""
+	if strings.HasPrefix(s, "-") {
+		sign, s = "-", s[1:]
+	}
+	whole, cents := s[:len(s)-3], s[len(s)-3:]
+	for i := len(whole) - 3; i > 0; i -= 3 {
+		whole = whole[:i] + "," + whole[i:]
+	}
+	return sign + whole + cents
+}
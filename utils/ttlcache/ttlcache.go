@@ -0,0 +1,56 @@
+// Package ttlcache keeps computed values for a fixed time, so expensive results like reports are
+// computed at most once per TTL however often they are asked for.
+package ttlcache
+
+import (
+	"sync"
+	"time"
+)
+
+// Cache keeps values by key for TTL after they were stored, a zero TTL or a nil cache caches nothing
+type Cache[K comparable, V any] struct {
+	ttl time.Duration
+
+	mu      sync.Mutex
+	entries map[K]entry[V]
+}
+
+type entry[V any] struct {
+	value     V
+	expiresAt time.Time
+}
+
+// New returns an empty cache keeping values for ttl
+func New[K comparable, V any](ttl time.Duration) *Cache[K, V] {
+	return &Cache[K, V]{ttl: ttl, entries: map[K]entry[V]{}}
+}
+
+// Get returns the value stored for key, ok is false if there is none or it expired
+func (c *Cache[K, V]) Get(key K) (value V, ok bool) {
+	if c == nil {
+		return value, false
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	e, ok := c.entries[key]
+	if !ok || !time.Now().Before(e.expiresAt) {
+		return value, false
+	}
+	return e.value, true
+}
+
+// Set stores value for key and drops the expired values
+func (c *Cache[K, V]) Set(key K, value V) {
+	if c == nil || c.ttl <= 0 {
+		return
+	}
+	now := time.Now()
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	for k, e := range c.entries {
+		if !now.Before(e.expiresAt) {
+			delete(c.entries, k)
+		}
+	}
+	c.entries[key] = entry[V]{value: value, expiresAt: now.Add(c.ttl)}
+}